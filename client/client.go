@@ -0,0 +1,359 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+// Package client is the Go client of the kvrocks controller HTTP APIs, it follows
+// the redirects to the leader and retries the requests while the leader is unavailable.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	DefaultAddr         = "http://127.0.0.1:9379"
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 200 * time.Millisecond
+
+	defaultTimeout = 30 * time.Second
+	maxRedirects   = 5
+)
+
+type Options struct {
+	// Addr is the address of any controller with the scheme, e.g. `http://127.0.0.1:9379`
+	Addr string
+	// HTTPClient is used to send the requests, its redirect policy is overridden
+	// since the redirects to the leader are followed by the client itself.
+	HTTPClient *http.Client
+	// Token is the bearer token, it takes precedence over the basic auth
+	Token    string
+	Username string
+	Password string
+	// MaxRetries is the max retries if the leader is unavailable or the idempotent
+	// request failed to be sent, 0 means DefaultMaxRetries and negative means no retry.
+	MaxRetries int
+	// RetryBackoff is the backoff of the first retry, it's doubled for each retry
+	RetryBackoff time.Duration
+}
+
+type Client struct {
+	options    Options
+	httpClient *http.Client
+
+	mu sync.RWMutex
+	// leaderAddr is the address which served the last request, it's updated by the redirects
+	leaderAddr string
+}
+
+// Error is the error response of the controller, it matches the errors in consts by the status code,
+// e.g. errors.Is(err, consts.ErrNotFound) is true if the status code is 404.
+type Error struct {
+	StatusCode int
+	Message    string
+	// Leader is the address of the leader if the follower refused to serve the request
+	Leader string
+	// NotLeader is true if the request was refused without being served since there's no leader
+	NotLeader bool
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("status %d: %s", err.StatusCode, err.Message)
+}
+
+func (err *Error) Is(target error) bool {
+	switch err.StatusCode {
+	case http.StatusBadRequest:
+		return target == consts.ErrInvalidArgument
+	case http.StatusUnauthorized:
+		return target == consts.ErrUnauthorized
+	case http.StatusForbidden:
+		return target == consts.ErrForbidden
+	case http.StatusNotFound:
+		return target == consts.ErrNotFound
+	case http.StatusConflict:
+		return target == consts.ErrConflict || target == consts.ErrAlreadyExists || target == consts.ErrVersionMismatch
+	case http.StatusPreconditionFailed:
+		return target == consts.ErrPreconditionFailed || target == consts.ErrVersionMismatch
+	case http.StatusServiceUnavailable:
+		return target == consts.ErrUnavailable
+	}
+	return false
+}
+
+// RequestOption sets the optional headers of the request
+type RequestOption func(req *http.Request)
+
+// WithIfMatch makes the request fail with 412 if the cluster version isn't the expected one
+func WithIfMatch(version int64) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(consts.HeaderIfMatch, strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+}
+
+// WithConfirmProtected confirms the operations which are blocked on the protected clusters
+func WithConfirmProtected() RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(consts.HeaderConfirmProtected, "yes")
+	}
+}
+
+func New(options Options) (*Client, error) {
+	if options.Addr == "" {
+		options.Addr = DefaultAddr
+	}
+	addr, err := url.Parse(options.Addr)
+	if err != nil || addr.Host == "" || (addr.Scheme != "http" && addr.Scheme != "https") {
+		return nil, fmt.Errorf("%w: invalid controller address: %s", consts.ErrInvalidArgument, options.Addr)
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultMaxRetries
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = DefaultRetryBackoff
+	}
+	httpClient := &http.Client{Timeout: defaultTimeout}
+	if options.HTTPClient != nil {
+		copied := *options.HTTPClient
+		httpClient = &copied
+	}
+	// the redirect is followed manually, since the http client drops the
+	// authorization header when redirecting to another host.
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &Client{
+		options:    options,
+		httpClient: httpClient,
+		leaderAddr: addr.Scheme + "://" + addr.Host,
+	}, nil
+}
+
+// LeaderAddr returns the address of the controller which served the last request
+func (c *Client) LeaderAddr() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.leaderAddr
+}
+
+func (c *Client) setLeaderAddr(addr string) {
+	c.mu.Lock()
+	c.leaderAddr = addr
+	c.mu.Unlock()
+}
+
+type errorResponse struct {
+	Error json.RawMessage `json:"error"`
+	Data  struct {
+		Leader    string `json:"leader"`
+		NotLeader bool   `json:"not_leader"`
+	} `json:"data"`
+}
+
+// parseError parses the error response, the error may be a plain string
+func parseError(statusCode int, body []byte) *Error {
+	err := &Error{StatusCode: statusCode, Message: http.StatusText(statusCode)}
+	var rsp errorResponse
+	if json.Unmarshal(body, &rsp) != nil || len(rsp.Error) == 0 {
+		return err
+	}
+	var message struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(rsp.Error, &message) == nil && message.Message != "" {
+		err.Message = message.Message
+	} else {
+		var plain string
+		if json.Unmarshal(rsp.Error, &plain) == nil && plain != "" {
+			err.Message = plain
+		}
+	}
+	err.Leader = rsp.Data.Leader
+	err.NotLeader = rsp.Data.NotLeader
+	return err
+}
+
+// isRetryable returns whether the request may succeed after the leader is available. The request
+// refused by the follower is always retryable since it wasn't served, while the non-idempotent
+// request isn't retried after other unavailable errors which might happen after it was applied.
+func (err *Error) isRetryable(method string) bool {
+	if err.NotLeader {
+		return true
+	}
+	return err.StatusCode == http.StatusServiceUnavailable && isIdempotent(method)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (c *Client) newRequest(ctx context.Context, method, target string, body []byte, options []RequestOption) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.options.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.options.Token)
+	} else if c.options.Username != "" {
+		req.SetBasicAuth(c.options.Username, c.options.Password)
+	}
+	for _, option := range options {
+		option(req)
+	}
+	return req, nil
+}
+
+func (c *Client) sleep(ctx context.Context, retries int) error {
+	timer := time.NewTimer(c.options.RetryBackoff << (retries - 1))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do sends the request to the leader and decodes the data of the response into out,
+// the path is relative to the API prefix and should be escaped by the caller.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any, options ...RequestOption) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	requestURI := apiPrefix + path
+	if len(query) > 0 {
+		requestURI += "?" + query.Encode()
+	}
+
+	target := c.LeaderAddr() + requestURI
+	retries, redirects := 0, 0
+	for {
+		req, err := c.newRequest(ctx, method, target, body, options)
+		if err != nil {
+			return err
+		}
+		rsp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil || !isIdempotent(method) || retries >= c.options.MaxRetries {
+				return err
+			}
+			retries++
+			if err := c.sleep(ctx, retries); err != nil {
+				return err
+			}
+			continue
+		}
+		rspBody, err := io.ReadAll(rsp.Body)
+		_ = rsp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case rsp.StatusCode == http.StatusTemporaryRedirect || rsp.StatusCode == http.StatusPermanentRedirect:
+			location, err := rsp.Location()
+			if err != nil {
+				return fmt.Errorf("invalid redirect: %w", err)
+			}
+			if redirects++; redirects > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			c.setLeaderAddr(location.Scheme + "://" + location.Host)
+			target = location.String()
+			continue
+		case rsp.StatusCode >= http.StatusBadRequest:
+			rspErr := parseError(rsp.StatusCode, rspBody)
+			if rspErr.Leader != "" && redirects < maxRedirects {
+				// the follower refused the request with the leader address
+				redirects++
+				scheme, _, _ := strings.Cut(c.LeaderAddr(), "://")
+				c.setLeaderAddr(scheme + "://" + rspErr.Leader)
+				target = c.LeaderAddr() + requestURI
+				continue
+			}
+			if !rspErr.isRetryable(method) || retries >= c.options.MaxRetries {
+				return rspErr
+			}
+			retries++
+			if err := c.sleep(ctx, retries); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if out == nil || rsp.StatusCode == http.StatusNoContent || len(rspBody) == 0 {
+			return nil
+		}
+		data := struct {
+			Data any `json:"data"`
+		}{Data: out}
+		if err := json.Unmarshal(rspBody, &data); err != nil {
+			return fmt.Errorf("failed to decode the response: %w", err)
+		}
+		return nil
+	}
+}
+
+// resourcePath joins the escaped path segments
+func resourcePath(segments ...string) string {
+	var builder strings.Builder
+	for _, segment := range segments {
+		builder.WriteString("/")
+		builder.WriteString(url.PathEscape(segment))
+	}
+	return builder.String()
+}
+
+// checkNames rejects the empty names which would route the request to another API
+func checkNames(names ...string) error {
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("%w: the name should NOT be empty", consts.ErrInvalidArgument)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func newTestClient(t *testing.T, addr string) *Client {
+	c, err := New(Options{Addr: addr, Token: "secret", RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{Addr: "127.0.0.1:9379"})
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+	c, err := New(Options{})
+	require.NoError(t, err)
+	require.Equal(t, DefaultAddr, c.LeaderAddr())
+	require.Equal(t, DefaultMaxRetries, c.options.MaxRetries)
+}
+
+func TestClient_Redirect(t *testing.T) {
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the authorization header should be kept after the redirect
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.Equal(t, "/api/v1/namespaces/test-ns/clusters/test-cluster", r.URL.Path)
+		_, _ = fmt.Fprint(w, `{"data":{"cluster":{"name":"test-cluster","version":3,"shards":[]}}}`)
+	}))
+	defer leader.Close()
+	follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, leader.URL+r.RequestURI, http.StatusTemporaryRedirect)
+	}))
+	defer follower.Close()
+
+	c := newTestClient(t, follower.URL)
+	cluster, err := c.GetCluster(context.Background(), "test-ns", "test-cluster")
+	require.NoError(t, err)
+	require.Equal(t, "test-cluster", cluster.Name)
+	require.EqualValues(t, 3, cluster.Version.Load())
+	// the following requests are sent to the leader directly
+	require.Equal(t, leader.URL, c.LeaderAddr())
+}
+
+func TestClient_NotLeader(t *testing.T) {
+	var leaderRequests atomic.Int32
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaderRequests.Add(1)
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, `"3"`, r.Header.Get(consts.HeaderIfMatch))
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, `{"data":{"new_master_id":"new-master"}}`)
+	}))
+	defer leader.Close()
+	follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = fmt.Fprintf(w, `{"error":{"message":"not the leader"},"data":{"leader":"%s"}}`, leader.Listener.Addr().String())
+	}))
+	defer follower.Close()
+
+	c := newTestClient(t, follower.URL)
+	newMasterID, err := c.FailoverShard(context.Background(), "test-ns", "test-cluster", 0, "", WithIfMatch(3))
+	require.NoError(t, err)
+	require.Equal(t, "new-master", newMasterID)
+	require.EqualValues(t, 1, leaderRequests.Load())
+	require.Equal(t, leader.URL, c.LeaderAddr())
+}
+
+func TestClient_Retry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"no leader now, please retry later"},"data":{"leader":"","not_leader":true}}`)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"the store is unavailable"}}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"data":{"namespaces":["ns0","ns1"]}}`)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	namespaces, err := c.ListNamespaces(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"ns0", "ns1"}, namespaces)
+	require.EqualValues(t, 3, requests.Load())
+
+	// give up after the max retries
+	requests.Store(0)
+	c.options.MaxRetries = 1
+	_, err = c.ListNamespaces(context.Background())
+	require.ErrorIs(t, err, consts.ErrUnavailable)
+	require.EqualValues(t, 2, requests.Load())
+}
+
+func TestClient_RetryNonIdempotent(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			// the request which wasn't served by the follower is retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"no leader now, please retry later"},"data":{"leader":"","not_leader":true}}`)
+		default:
+			// the request might be applied before the store became unavailable
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"the store is unavailable"}}`)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	err := c.CreateNamespace(context.Background(), &CreateNamespaceRequest{Namespace: "ns0"})
+	require.ErrorIs(t, err, consts.ErrUnavailable)
+	require.EqualValues(t, 2, requests.Load())
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"the cluster is protected"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"the entry does not exist"}}`)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	_, err := c.GetNamespace(context.Background(), "not-exists")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	require.EqualError(t, err, "status 404: the entry does not exist")
+	err = c.DeleteCluster(context.Background(), "test-ns", "test-cluster")
+	require.ErrorIs(t, err, consts.ErrForbidden)
+	require.NotErrorIs(t, err, consts.ErrNotFound)
+	// the empty name is rejected without sending the request
+	_, err = c.GetCluster(context.Background(), "test-ns", "")
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+
+	// only the failed If-Match is 412, the version conflicts inside the controller are 409
+	require.ErrorIs(t, &Error{StatusCode: http.StatusPreconditionFailed}, consts.ErrPreconditionFailed)
+	require.ErrorIs(t, &Error{StatusCode: http.StatusConflict}, consts.ErrVersionMismatch)
+	require.NotErrorIs(t, &Error{StatusCode: http.StatusConflict}, consts.ErrPreconditionFailed)
+}
+
+func TestClient_MigrateSlot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/namespaces/test-ns/clusters/test-cluster/migrate", r.URL.Path)
+		require.Equal(t, "yes", r.Header.Get(consts.HeaderConfirmProtected))
+		var req MigrateSlotRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, store.SlotRange{Start: 1, Stop: 100}, req.Slot)
+		require.Equal(t, 1, req.Target)
+		require.True(t, req.Force)
+		_, _ = fmt.Fprint(w, `{"data":{"cluster":{"name":"test-cluster","shards":[]},"headroom":null,"migration_id":"1-1"}}`)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	result, err := c.MigrateSlot(context.Background(), "test-ns", "test-cluster", &MigrateSlotRequest{
+		Target: 1,
+		Slot:   store.SlotRange{Start: 1, Stop: 100},
+		Force:  true,
+	}, WithConfirmProtected())
+	require.NoError(t, err)
+	require.Equal(t, "1-1", result.MigrationID)
+	require.Nil(t, result.Headroom)
+}
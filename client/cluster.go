@@ -0,0 +1,222 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+type CreateClusterRequest struct {
+	Name     string   `json:"name"`
+	Nodes    []string `json:"nodes"`
+	Password string   `json:"password,omitempty"`
+	// MasterAuth is the masterauth of the nodes if it's different from the password
+	MasterAuth  string            `json:"master_auth,omitempty"`
+	Replicas    int               `json:"replicas,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// Weights are the weights of the shards to distribute the slots, it's even if empty
+	Weights   []int `json:"weights,omitempty"`
+	Protected bool  `json:"protected,omitempty"`
+}
+
+// UpdateClusterRequest changes the cluster partially, the nil fields won't be changed
+type UpdateClusterRequest struct {
+	Description *string           `json:"description,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// HealthCheck, Compaction and Placement will be removed if they're empty
+	HealthCheck *store.HealthCheck        `json:"health_check,omitempty"`
+	Compaction  *store.CompactionSchedule `json:"compaction,omitempty"`
+	Placement   *store.PlacementRules     `json:"placement,omitempty"`
+	// Protected won't be changed if it's nil, unprotecting the cluster requires WithConfirmProtected
+	Protected *bool `json:"protected,omitempty"`
+}
+
+type MigrateSlotRequest struct {
+	Target   int             `json:"target"`
+	Slot     store.SlotRange `json:"slot"`
+	SlotOnly bool            `json:"slot_only,omitempty"`
+	// Force migrates the slot even if the target would exceed the headroom limits or the placement rules
+	Force bool `json:"force,omitempty"`
+	// Source is the explicit source shard index, it's inferred from the slot ownership if nil
+	Source      *int `json:"source,omitempty"`
+	ForceSource bool `json:"force_source,omitempty"`
+}
+
+type MigrateSlotResult struct {
+	Cluster  *store.Cluster           `json:"cluster"`
+	Headroom *store.MigrationHeadroom `json:"headroom"`
+	// MigrationID is empty if only the slot was moved
+	MigrationID string `json:"migration_id"`
+}
+
+func (c *Client) ListClusters(ctx context.Context, namespace string) ([]string, error) {
+	if err := checkNames(namespace); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Clusters []string `json:"clusters"`
+	}
+	if err := c.do(ctx, http.MethodGet, resourcePath("namespaces", namespace, "clusters"), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Clusters, nil
+}
+
+// ListClusterSummaries returns the overviews of the clusters with the health checked by the leader
+func (c *Client) ListClusterSummaries(ctx context.Context, namespace string) ([]store.ClusterSummary, error) {
+	if err := checkNames(namespace); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Clusters []store.ClusterSummary `json:"clusters"`
+	}
+	query := url.Values{"detail": []string{"summary"}}
+	if err := c.do(ctx, http.MethodGet, resourcePath("namespaces", namespace, "clusters"), query, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Clusters, nil
+}
+
+func (c *Client) GetCluster(ctx context.Context, namespace, cluster string) (*store.Cluster, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Cluster *store.Cluster `json:"cluster"`
+	}
+	if err := c.do(ctx, http.MethodGet, resourcePath("namespaces", namespace, "clusters", cluster), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Cluster, nil
+}
+
+func (c *Client) CreateCluster(ctx context.Context, namespace string, req *CreateClusterRequest) (*store.Cluster, error) {
+	if err := checkNames(namespace, req.Name); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Cluster *store.Cluster `json:"cluster"`
+	}
+	if err := c.do(ctx, http.MethodPost, resourcePath("namespaces", namespace, "clusters"), nil, req, &result); err != nil {
+		return nil, err
+	}
+	return result.Cluster, nil
+}
+
+func (c *Client) UpdateCluster(ctx context.Context, namespace, cluster string,
+	req *UpdateClusterRequest, options ...RequestOption,
+) (*store.Cluster, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Cluster *store.Cluster `json:"cluster"`
+	}
+	err := c.do(ctx, http.MethodPatch, resourcePath("namespaces", namespace, "clusters", cluster), nil, req, &result, options...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Cluster, nil
+}
+
+// DeleteCluster removes the cluster from the controller without resetting the nodes
+func (c *Client) DeleteCluster(ctx context.Context, namespace, cluster string, options ...RequestOption) error {
+	if err := checkNames(namespace, cluster); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, resourcePath("namespaces", namespace, "clusters", cluster), nil, nil, nil, options...)
+}
+
+// CheckCluster audits the consistency between the stored topology and the view of each node
+func (c *Client) CheckCluster(ctx context.Context, namespace, cluster string) (*store.CheckReport, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Report *store.CheckReport `json:"report"`
+	}
+	if err := c.do(ctx, http.MethodPost, resourcePath("namespaces", namespace, "clusters", cluster, "check"), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Report, nil
+}
+
+func (c *Client) MigrateSlot(ctx context.Context, namespace, cluster string,
+	req *MigrateSlotRequest, options ...RequestOption,
+) (*MigrateSlotResult, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result MigrateSlotResult
+	err := c.do(ctx, http.MethodPost, resourcePath("namespaces", namespace, "clusters", cluster, "migrate"), nil, req, &result, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListMigrations returns the latest slot migration jobs of the cluster
+func (c *Client) ListMigrations(ctx context.Context, namespace, cluster string) ([]*store.Job, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Jobs []*store.Job `json:"jobs"`
+	}
+	if err := c.do(ctx, http.MethodGet, resourcePath("namespaces", namespace, "clusters", cluster, "migrations"), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Jobs, nil
+}
+
+func (c *Client) GetMigration(ctx context.Context, namespace, cluster, id string) (*store.Job, error) {
+	if err := checkNames(namespace, cluster, id); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Job *store.Job `json:"job"`
+	}
+	if err := c.do(ctx, http.MethodGet, resourcePath("namespaces", namespace, "clusters", cluster, "migrations", id), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Job, nil
+}
+
+// CancelMigration cancels the queued or running slot migration job, the slot stays in the source shard
+func (c *Client) CancelMigration(ctx context.Context, namespace, cluster, id string) (*store.Job, error) {
+	if err := checkNames(namespace, cluster, id); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Job *store.Job `json:"job"`
+	}
+	if err := c.do(ctx, http.MethodDelete, resourcePath("namespaces", namespace, "clusters", cluster, "migrations", id), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Job, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+type CreateNamespaceRequest struct {
+	Namespace   string `json:"namespace"`
+	Owner       string `json:"owner,omitempty"`
+	Description string `json:"description,omitempty"`
+	Contact     string `json:"contact,omitempty"`
+	// Labels are added to the metrics and events of the clusters in the namespace
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
+	var result struct {
+		Namespaces []string `json:"namespaces"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/namespaces", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Namespaces, nil
+}
+
+func (c *Client) GetNamespace(ctx context.Context, namespace string) (*store.Namespace, error) {
+	if err := checkNames(namespace); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Namespace *store.Namespace `json:"namespace"`
+	}
+	if err := c.do(ctx, http.MethodGet, resourcePath("namespaces", namespace), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Namespace, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, req *CreateNamespaceRequest) error {
+	if err := checkNames(req.Namespace); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, "/namespaces", nil, req, nil)
+}
+
+func (c *Client) DeleteNamespace(ctx context.Context, namespace string) error {
+	if err := checkNames(namespace); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, resourcePath("namespaces", namespace), nil, nil, nil)
+}
+
+// ListEvents returns the events after the last event ID, 0 means all the persisted events
+func (c *Client) ListEvents(ctx context.Context, lastEventID int64) ([]store.Event, error) {
+	query := make(url.Values)
+	if lastEventID > 0 {
+		query.Set("last_event_id", strconv.FormatInt(lastEventID, 10))
+	}
+	var result struct {
+		Events []store.Event `json:"events"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/events", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Events, nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+type CreateNodeRequest struct {
+	Addr string `json:"addr"`
+	// Role is the role of the new node, it's slave if empty
+	Role       string `json:"role,omitempty"`
+	Password   string `json:"password,omitempty"`
+	MasterAuth string `json:"master_auth,omitempty"`
+	// Labels describe the node, e.g. the `host` and `zone` labels
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func nodesPath(namespace, cluster string, shard int) string {
+	return shardPath(namespace, cluster, shard) + "/nodes"
+}
+
+func (c *Client) ListNodes(ctx context.Context, namespace, cluster string, shard int) ([]*store.ClusterNode, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Nodes []*store.ClusterNode `json:"nodes"`
+	}
+	if err := c.do(ctx, http.MethodGet, nodesPath(namespace, cluster, shard), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Nodes, nil
+}
+
+// CreateNode adds the node into the shard and returns the ID of the new node
+func (c *Client) CreateNode(ctx context.Context, namespace, cluster string, shard int,
+	req *CreateNodeRequest, options ...RequestOption,
+) (string, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return "", err
+	}
+	var id string
+	if err := c.do(ctx, http.MethodPost, nodesPath(namespace, cluster, shard), nil, req, &id, options...); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (c *Client) DeleteNode(ctx context.Context, namespace, cluster string, shard int,
+	id string, options ...RequestOption,
+) error {
+	if err := checkNames(namespace, cluster, id); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, nodesPath(namespace, cluster, shard)+resourcePath(id), nil, nil, nil, options...)
+}
+
+// UpdateNodeLabels merges the labels into the node, the label will be removed if its value is empty
+func (c *Client) UpdateNodeLabels(ctx context.Context, namespace, cluster string, shard int,
+	id string, labels map[string]string, options ...RequestOption,
+) (*store.ClusterNode, error) {
+	if err := checkNames(namespace, cluster, id); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Node *store.ClusterNode `json:"node"`
+	}
+	body := map[string]map[string]string{"labels": labels}
+	err := c.do(ctx, http.MethodPost, nodesPath(namespace, cluster, shard)+resourcePath(id, "labels"), nil, body, &result, options...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Node, nil
+}
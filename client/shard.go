@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+type CreateShardRequest struct {
+	// Nodes are the addresses of the nodes, the first node would be the master
+	Nodes      []string `json:"nodes"`
+	Password   string   `json:"password,omitempty"`
+	MasterAuth string   `json:"master_auth,omitempty"`
+}
+
+func shardsPath(namespace, cluster string) string {
+	return resourcePath("namespaces", namespace, "clusters", cluster, "shards")
+}
+
+func shardPath(namespace, cluster string, shard int) string {
+	return resourcePath("namespaces", namespace, "clusters", cluster, "shards", strconv.Itoa(shard))
+}
+
+func (c *Client) ListShards(ctx context.Context, namespace, cluster string) ([]*store.Shard, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Shards []*store.Shard `json:"shards"`
+	}
+	if err := c.do(ctx, http.MethodGet, shardsPath(namespace, cluster), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Shards, nil
+}
+
+func (c *Client) GetShard(ctx context.Context, namespace, cluster string, shard int) (*store.Shard, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Shard *store.Shard `json:"shard"`
+	}
+	if err := c.do(ctx, http.MethodGet, shardPath(namespace, cluster, shard), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Shard, nil
+}
+
+func (c *Client) CreateShard(ctx context.Context, namespace, cluster string,
+	req *CreateShardRequest, options ...RequestOption,
+) (*store.Shard, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Shard *store.Shard `json:"shard"`
+	}
+	if err := c.do(ctx, http.MethodPost, shardsPath(namespace, cluster), nil, req, &result, options...); err != nil {
+		return nil, err
+	}
+	return result.Shard, nil
+}
+
+func (c *Client) DeleteShard(ctx context.Context, namespace, cluster string, shard int, options ...RequestOption) error {
+	if err := checkNames(namespace, cluster); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, shardPath(namespace, cluster, shard), nil, nil, nil, options...)
+}
+
+// FailoverShard promotes a replica of the shard and returns the ID of the new master,
+// the preferred node is promoted if it's not empty and eligible.
+func (c *Client) FailoverShard(ctx context.Context, namespace, cluster string, shard int,
+	preferredNodeID string, options ...RequestOption,
+) (string, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return "", err
+	}
+	var result struct {
+		NewMasterID string `json:"new_master_id"`
+	}
+	body := map[string]string{"preferred_node_id": preferredNodeID}
+	err := c.do(ctx, http.MethodPost, shardPath(namespace, cluster, shard)+"/failover", nil, body, &result, options...)
+	if err != nil {
+		return "", err
+	}
+	return result.NewMasterID, nil
+}
+
+// SimulateFailover shows which replica would be promoted without failing over the master
+func (c *Client) SimulateFailover(ctx context.Context, namespace, cluster string, shard int,
+	preferredNodeID string,
+) (*store.FailoverSimulation, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Simulation *store.FailoverSimulation `json:"simulation"`
+	}
+	query := url.Values{"simulate": []string{"true"}}
+	body := map[string]string{"preferred_node_id": preferredNodeID}
+	err := c.do(ctx, http.MethodPost, shardPath(namespace, cluster, shard)+"/failover", query, body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Simulation, nil
+}
+
+// FreezeShard marks whether the shard is frozen, the controller won't fail over or migrate the frozen shard
+func (c *Client) FreezeShard(ctx context.Context, namespace, cluster string, shard int,
+	frozen bool, options ...RequestOption,
+) (*store.Shard, error) {
+	if err := checkNames(namespace, cluster); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Shard *store.Shard `json:"shard"`
+	}
+	body := map[string]bool{"frozen": frozen}
+	err := c.do(ctx, http.MethodPost, shardPath(namespace, cluster, shard)+"/freeze", nil, body, &result, options...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Shard, nil
+}
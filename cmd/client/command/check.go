@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+type CheckOptions struct {
+	namespace string
+}
+
+var checkOptions CheckOptions
+
+var CheckCommand = &cobra.Command{
+	Use:   "check",
+	Short: "Check the consistency of a cluster",
+	Example: `
+# Check the consistency between the stored topology and the cluster nodes
+kvctl check cluster <cluster> -n <namespace>
+`,
+	PreRunE: checkPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		client := newClient(host)
+		resource := strings.ToLower(args[0])
+		switch resource {
+		case ResourceCluster:
+			return checkCluster(client, &checkOptions, args[1])
+		default:
+			return fmt.Errorf("unsupported resource type: %s", resource)
+		}
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func checkPreRun(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing resource name")
+	}
+	if checkOptions.namespace == "" {
+		return errors.New("missing namespace, please specify with -n or --namespace")
+	}
+	return nil
+}
+
+func checkCluster(client *client, options *CheckOptions, cluster string) error {
+	rsp, err := client.restyCli.R().
+		SetPathParam("namespace", options.namespace).
+		SetPathParam("cluster", cluster).
+		Post("/namespaces/{namespace}/clusters/{cluster}/check")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	var result struct {
+		Report *store.CheckReport `json:"report"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	report := result.Report
+	if len(report.Findings) == 0 {
+		printLine("cluster: %s (version %d) is consistent.", report.Cluster, report.Version)
+		return nil
+	}
+
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"SEVERITY", "CODE", "SHARD", "NODE", "MESSAGE"})
+	writer.SetCenterSeparator("|")
+	for _, finding := range report.Findings {
+		shard := "-"
+		if finding.Shard >= 0 {
+			shard = fmt.Sprintf("%d", finding.Shard)
+		}
+		writer.Append([]string{finding.Severity, finding.Code, shard, finding.Addr, finding.Message})
+	}
+	writer.Render()
+	if !report.Healthy {
+		return fmt.Errorf("cluster: %s is inconsistent", report.Cluster)
+	}
+	return nil
+}
+
+func init() {
+	CheckCommand.Flags().StringVarP(&checkOptions.namespace, "namespace", "n", "", "The namespace of the cluster")
+}
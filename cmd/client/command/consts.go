@@ -25,4 +25,8 @@ const (
 	ResourceCluster   = "cluster"
 	ResourceShard     = "shard"
 	ResourceNode      = "node"
+	ResourceMigration = "migration"
 )
+
+// confirmProtectedFlag confirms the operations which are blocked on the protected clusters
+const confirmProtectedFlag = "yes-i-know"
@@ -35,6 +35,16 @@ type CreateOptions struct {
 	replica   int
 	nodes     []string
 	password  string
+	// masterAuth is the masterauth of the nodes if it's different from the password
+	masterAuth string
+
+	description string
+	annotations map[string]string
+	weights     []int
+	protected   bool
+
+	owner   string
+	contact string
 }
 
 var createOptions CreateOptions
@@ -46,9 +56,21 @@ var CreateCommand = &cobra.Command{
 # Create a namespace
 kvctl create namespace <namespace>
 
+# Create a namespace with the metadata
+kvctl create namespace <namespace> --owner team-a --contact team-a@example.com --description "user cache"
+
 # Create a cluster in the namespace
 kvctl create cluster <cluster> -n <namespace> --replica 1 --nodes 127.0.0.1:6379,127.0.0.1:6380,127.0.0.1:6381
 
+# Create a cluster with the description and annotations
+kvctl create cluster <cluster> -n <namespace> --nodes 127.0.0.1:6379 --description "user cache" --annotation owner=team-a
+
+# Create a cluster with 3 shards and the first shard owns half of the slots
+kvctl create cluster <cluster> -n <namespace> --nodes 127.0.0.1:6379,127.0.0.1:6380,127.0.0.1:6381 --weights 2,1,1
+
+# Create a protected cluster which can't be deleted or migrated by force without --yes-i-know
+kvctl create cluster <cluster> -n <namespace> --nodes 127.0.0.1:6379 --protected
+
 # Create a shard in the cluster
 kvctl create shard -n <namespace> -c <cluster> --nodes 127.0.0.1:6379,127.0.0.1:6380
 
@@ -64,7 +86,7 @@ kvctl create node 127.0.0.1:6379 -n <namespace> -c <cluster> --shard <shard>
 			if len(args) < 2 {
 				return errors.New("missing namespace name")
 			}
-			return createNamespace(client, args[1])
+			return createNamespace(client, args[1], &createOptions)
 		case ResourceCluster:
 			if len(args) < 2 {
 				return errors.New("missing cluster name")
@@ -119,9 +141,14 @@ func createPreRun(_ *cobra.Command, args []string) error {
 	return nil
 }
 
-func createNamespace(cli *client, name string) error {
+func createNamespace(cli *client, name string, options *CreateOptions) error {
 	rsp, err := cli.restyCli.R().
-		SetBody(map[string]string{"namespace": name}).
+		SetBody(map[string]string{
+			"namespace":   name,
+			"owner":       options.owner,
+			"description": options.description,
+			"contact":     options.contact,
+		}).
 		Post("/namespaces")
 	if err != nil {
 		return err
@@ -138,10 +165,15 @@ func createCluster(cli *client, options *CreateOptions) error {
 	rsp, err := cli.restyCli.R().
 		SetPathParam("namespace", options.namespace).
 		SetBody(map[string]interface{}{
-			"name":     options.cluster,
-			"replicas": options.replica,
-			"nodes":    options.nodes,
-			"password": options.password,
+			"name":        options.cluster,
+			"replicas":    options.replica,
+			"nodes":       options.nodes,
+			"password":    options.password,
+			"master_auth": options.masterAuth,
+			"description": options.description,
+			"annotations": options.annotations,
+			"weights":     options.weights,
+			"protected":   options.protected,
 		}).
 		Post("/namespaces/{namespace}/clusters")
 	if err != nil {
@@ -160,9 +192,10 @@ func createShard(cli *client, options *CreateOptions) error {
 		SetPathParam("namespace", options.namespace).
 		SetPathParam("cluster", options.cluster).
 		SetBody(map[string]interface{}{
-			"name":     options.cluster,
-			"nodes":    options.nodes,
-			"password": options.password,
+			"name":        options.cluster,
+			"nodes":       options.nodes,
+			"password":    options.password,
+			"master_auth": options.masterAuth,
 		}).
 		Post("/namespaces/{namespace}/clusters/{cluster}/shards")
 	if err != nil {
@@ -182,8 +215,9 @@ func createNodes(cli *client, options *CreateOptions) error {
 		SetPathParam("cluster", options.cluster).
 		SetPathParam("shard", strconv.Itoa(options.shard)).
 		SetBody(map[string]interface{}{
-			"addr":     options.nodes[0],
-			"password": options.password,
+			"addr":        options.nodes[0],
+			"password":    options.password,
+			"master_auth": options.masterAuth,
 		}).
 		Post("/namespaces/{namespace}/clusters/{cluster}/shards/{shard}/nodes")
 	if err != nil {
@@ -204,4 +238,11 @@ func init() {
 	CreateCommand.Flags().IntVarP(&createOptions.replica, "replica", "r", 1, "The replica number")
 	CreateCommand.Flags().StringSliceVarP(&createOptions.nodes, "nodes", "", nil, "The node list")
 	CreateCommand.Flags().StringVarP(&createOptions.password, "password", "", "", "The password")
+	CreateCommand.Flags().StringVarP(&createOptions.masterAuth, "master-auth", "", "", "The masterauth if it's different from the password")
+	CreateCommand.Flags().StringVarP(&createOptions.description, "description", "", "", "The description of the namespace or cluster")
+	CreateCommand.Flags().IntSliceVarP(&createOptions.weights, "weights", "", nil, "The weights of the shards to distribute the slots")
+	CreateCommand.Flags().StringToStringVarP(&createOptions.annotations, "annotation", "", nil, "The annotations of the cluster in key=value format")
+	CreateCommand.Flags().BoolVar(&createOptions.protected, "protected", false, "Protect the cluster from being deleted or migrated by force")
+	CreateCommand.Flags().StringVarP(&createOptions.owner, "owner", "", "", "The owner of the namespace")
+	CreateCommand.Flags().StringVarP(&createOptions.contact, "contact", "", "", "The contact of the namespace")
 }
@@ -22,16 +22,25 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+
+	"github.com/apache/kvrocks-controller/consts"
 )
 
 type DeleteOptions struct {
 	namespace string
 	cluster   string
 	shard     int
+
+	resetNodes bool
+	flushData  bool
+	// confirmProtected confirms to delete the protected cluster
+	confirmProtected bool
 }
 
 var deleteOptions DeleteOptions
@@ -46,11 +55,20 @@ kvctl delete namespace <namespace>
 # Delete a cluster in the namespace
 kvctl delete cluster <cluster> -n <namespace>
 
+# Delete a cluster and reset its nodes, the data will be flushed with --flush-data
+kvctl delete cluster <cluster> -n <namespace> --reset-nodes [--flush-data]
+
+# Delete a protected cluster
+kvctl delete cluster <cluster> -n <namespace> --yes-i-know
+
 # Delete a shard in the cluster
 kvctl delete shard <shard> -n <namespace> -c <cluster>
 
 # Delete a node in the cluster
 kvctl delete node <node_id> -n <namespace> -c <cluster> --shard <shard>
+
+# Cancel the queued or running slot migration in the cluster
+kvctl delete migration <migration_id> -n <namespace> -c <cluster>
 `,
 	PreRunE: deletePreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -77,6 +95,8 @@ kvctl delete node <node_id> -n <namespace> -c <cluster> --shard <shard>
 		case ResourceNode:
 			nodeID := args[1]
 			return deleteNode(client, &deleteOptions, nodeID)
+		case ResourceMigration:
+			return cancelMigration(client, &deleteOptions, args[1])
 		default:
 			return fmt.Errorf("unsupported resource type %s", resource)
 		}
@@ -103,7 +123,7 @@ func deletePreRun(_ *cobra.Command, args []string) error {
 	if deleteOptions.cluster == "" {
 		return fmt.Errorf("missing cluster, please specify the cluster via -c or --cluster option")
 	}
-	if resource == ResourceShard {
+	if resource == ResourceShard || resource == ResourceMigration {
 		return nil
 	}
 	if deleteOptions.shard == -1 {
@@ -130,14 +150,27 @@ func deleteNamespace(client *client, namespace string) error {
 }
 
 func deleteCluster(client *client, options *DeleteOptions) error {
-	rsp, err := client.restyCli.R().
+	req := client.restyCli.R().
 		SetPathParams(map[string]string{
 			"namespace": options.namespace,
 			"cluster":   options.cluster,
-		}).Delete("/namespaces/{namespace}/clusters/{cluster}")
+		})
+	if options.resetNodes {
+		req.SetQueryParam("reset_nodes", "true")
+	}
+	if options.flushData {
+		req.SetQueryParams(map[string]string{"flush_data": "true", "confirm": options.cluster})
+	}
+	if options.confirmProtected {
+		req.SetHeader(consts.HeaderConfirmProtected, "yes")
+	}
+	rsp, err := req.Delete("/namespaces/{namespace}/clusters/{cluster}")
 	if err != nil {
 		return err
 	}
+	if options.resetNodes {
+		printNodeResetResults(rsp.Body())
+	}
 	if rsp.IsError() {
 		return unmarshalError(rsp.Body())
 	}
@@ -145,6 +178,30 @@ func deleteCluster(client *client, options *DeleteOptions) error {
 	return nil
 }
 
+func printNodeResetResults(body []byte) {
+	var result struct {
+		Nodes []struct {
+			ID    string `json:"id"`
+			Addr  string `json:"addr"`
+			Error string `json:"error"`
+		} `json:"nodes"`
+	}
+	if err := unmarshalData(body, &result); err != nil || len(result.Nodes) == 0 {
+		return
+	}
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"ID", "ADDRESS", "RESULT"})
+	writer.SetCenterSeparator("|")
+	for _, node := range result.Nodes {
+		status := "OK"
+		if node.Error != "" {
+			status = node.Error
+		}
+		writer.Append([]string{node.ID, node.Addr, status})
+	}
+	writer.Render()
+}
+
 func deleteShard(client *client, options *DeleteOptions) error {
 	rsp, err := client.restyCli.R().
 		SetPathParam("namespace", options.namespace).
@@ -178,8 +235,27 @@ func deleteNode(client *client, options *DeleteOptions, nodeID string) error {
 	return nil
 }
 
+func cancelMigration(client *client, options *DeleteOptions, id string) error {
+	rsp, err := client.restyCli.R().
+		SetPathParam("namespace", options.namespace).
+		SetPathParam("cluster", options.cluster).
+		SetPathParam("id", id).
+		Delete("/namespaces/{namespace}/clusters/{cluster}/migrations/{id}")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	printLine("cancel migration: %s successfully.", id)
+	return nil
+}
+
 func init() {
 	DeleteCommand.Flags().StringVarP(&deleteOptions.namespace, "namespace", "n", "", "The namespace")
 	DeleteCommand.Flags().StringVarP(&deleteOptions.cluster, "cluster", "c", "", "The cluster")
 	DeleteCommand.Flags().IntVarP(&deleteOptions.shard, "shard", "s", -1, "The shard")
+	DeleteCommand.Flags().BoolVar(&deleteOptions.resetNodes, "reset-nodes", false, "Reset the nodes before deleting the cluster")
+	DeleteCommand.Flags().BoolVar(&deleteOptions.flushData, "flush-data", false, "Flush the data of the nodes when resetting, requires --reset-nodes")
+	DeleteCommand.Flags().BoolVar(&deleteOptions.confirmProtected, confirmProtectedFlag, false, "Confirm to delete the protected cluster")
 }
@@ -22,15 +22,20 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+
+	"github.com/apache/kvrocks-controller/store"
 )
 
 type FailoverOptions struct {
 	namespace string
 	cluster   string
 	preferred string
+	simulate  bool
 }
 
 var failoverOptions FailoverOptions
@@ -44,6 +49,9 @@ kvctl failover shard <shard_index> -n <namespace> -c <cluster>
 
 # Failover the master of a shard with preferred slave
 kvctl failover shard <shard_index> --preferred <node_id> -n <namespace> -c <cluster>
+
+# Show which slave would be promoted without failing over the master
+kvctl failover shard <shard_index> --simulate -n <namespace> -c <cluster>
 `,
 	PreRunE: failoverPreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -59,6 +67,9 @@ kvctl failover shard <shard_index> --preferred <node_id> -n <namespace> -c <clus
 			if err != nil {
 				return fmt.Errorf("invalid shard index: %s", args[1])
 			}
+			if failoverOptions.simulate {
+				return simulateFailoverShard(client, &failoverOptions, shardIndex)
+			}
 			return failoverShard(client, &failoverOptions, shardIndex)
 		default:
 			return fmt.Errorf("unsupported resource type: %s", resource)
@@ -104,8 +115,48 @@ func failoverShard(client *client, options *FailoverOptions, shardIndex int) err
 	return nil
 }
 
+func simulateFailoverShard(client *client, options *FailoverOptions, shardIndex int) error {
+	rsp, err := client.restyCli.R().
+		SetPathParam("namespace", options.namespace).
+		SetPathParam("cluster", options.cluster).
+		SetPathParam("shard", strconv.Itoa(shardIndex)).
+		SetQueryParam("simulate", "true").
+		SetBody(map[string]interface{}{
+			"preferred_node_id": options.preferred,
+		}).
+		Post("/namespaces/{namespace}/clusters/{cluster}/shards/{shard}/failover")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	var result struct {
+		Simulation *store.FailoverSimulation `json:"simulation"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+
+	simulation := result.Simulation
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"ID", "ADDRESS", "SEQUENCE", "PREFERRED", "SELECTED", "REASON"})
+	writer.SetCenterSeparator("|")
+	for _, candidate := range simulation.Candidates {
+		writer.Append([]string{candidate.NodeID, candidate.Addr, strconv.FormatUint(candidate.Sequence, 10),
+			strconv.FormatBool(candidate.Preferred), strconv.FormatBool(candidate.Selected), candidate.Reason})
+	}
+	writer.Render()
+	if simulation.NewMasterID == "" {
+		return fmt.Errorf("failover shard %d would fail: %s", shardIndex, simulation.Error)
+	}
+	printLine("failover shard %d would promote the new master: %s.", shardIndex, simulation.NewMasterID)
+	return nil
+}
+
 func init() {
 	FailoverCommand.Flags().StringVarP(&failoverOptions.namespace, "namespace", "n", "", "The namespace of the cluster")
 	FailoverCommand.Flags().StringVarP(&failoverOptions.cluster, "cluster", "c", "", "The name of the cluster")
 	FailoverCommand.Flags().StringVarP(&failoverOptions.preferred, "preferred", "", "", "The preferred slave node id")
+	FailoverCommand.Flags().BoolVarP(&failoverOptions.simulate, "simulate", "", false, "Show which slave would be promoted without failing over")
 }
@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+type FreezeOptions struct {
+	namespace string
+	cluster   string
+	disable   bool
+}
+
+var freezeOptions FreezeOptions
+
+var FreezeCommand = &cobra.Command{
+	Use:   "freeze",
+	Short: "Freeze the topology of a shard",
+	Example: `
+# Freeze the shard, the controller won't fail over or migrate it
+kvctl freeze shard <shard_index> -n <namespace> -c <cluster>
+
+# Unfreeze the shard
+kvctl freeze shard <shard_index> -n <namespace> -c <cluster> --disable
+`,
+	PreRunE: freezePreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		client := newClient(host)
+		switch args[0] {
+		case ResourceShard:
+			shardIndex, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid shard index: %s", args[1])
+			}
+			return freezeShard(client, &freezeOptions, shardIndex)
+		default:
+			return fmt.Errorf("unsupported resource type: %s", args[0])
+		}
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func freezePreRun(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing resource type or shard index, please specify like `freeze shard <shard_index>`")
+	}
+	if freezeOptions.namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if freezeOptions.cluster == "" {
+		return fmt.Errorf("cluster is required")
+	}
+	return nil
+}
+
+func freezeShard(client *client, options *FreezeOptions, shardIndex int) error {
+	rsp, err := client.restyCli.R().
+		SetPathParam("namespace", options.namespace).
+		SetPathParam("cluster", options.cluster).
+		SetPathParam("shard", strconv.Itoa(shardIndex)).
+		SetBody(map[string]interface{}{"frozen": !options.disable}).
+		Post("/namespaces/{namespace}/clusters/{cluster}/shards/{shard}/freeze")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	if options.disable {
+		printLine("unfreeze shard %d successfully.", shardIndex)
+	} else {
+		printLine("freeze shard %d successfully.", shardIndex)
+	}
+	return nil
+}
+
+func init() {
+	FreezeCommand.Flags().StringVarP(&freezeOptions.namespace, "namespace", "n", "", "The namespace of the cluster")
+	FreezeCommand.Flags().StringVarP(&freezeOptions.cluster, "cluster", "c", "", "The name of the cluster")
+	FreezeCommand.Flags().BoolVar(&freezeOptions.disable, "disable", false, "Unfreeze the shard")
+}
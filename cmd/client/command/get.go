@@ -22,6 +22,7 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -32,6 +33,7 @@ import (
 type GetOptions struct {
 	namespace string
 	cluster   string
+	output    OutputOptions
 }
 
 var getOptions GetOptions
@@ -42,16 +44,27 @@ var GetCommand = &cobra.Command{
 	Example: `
 # Get a cluster 
 kvctl get cluster <cluster> -n <namespace>
+
+# Get the metadata of a namespace
+kvctl get namespace <namespace>
+
+# Get the master address of the shard 0 by the JSONPath
+kvctl get cluster <cluster> -n <namespace> --jsonpath '{.shards[0].nodes[?(@.role=="master")].addr}'
+
+# Get the node addresses of all shards by the Go template
+kvctl get cluster <cluster> -n <namespace> --template '{{range .shards}}{{range .nodes}}{{.addr}} {{end}}{{end}}'
 `,
 	PreRunE: getPreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		host, _ := cmd.Flags().GetString("host")
 		client := newClient(host)
 		if len(args) < 2 {
-			return fmt.Errorf("missing resource name, must be one of [cluster, shard]")
+			return fmt.Errorf("missing resource name, must be one of [namespace, cluster]")
 		}
 		resource := strings.ToLower(args[0])
 		switch resource {
+		case ResourceNamespace:
+			return getNamespace(client, args[1], &getOptions.output)
 		case "cluster":
 			getOptions.cluster = args[1]
 			return getCluster(client, &getOptions)
@@ -67,7 +80,13 @@ func getPreRun(_ *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("missing resource type")
 	}
+	if err := getOptions.output.validate(); err != nil {
+		return err
+	}
 
+	if strings.ToLower(args[0]) == ResourceNamespace {
+		return nil
+	}
 	if getOptions.namespace == "" {
 		return fmt.Errorf("missing namespace, please specify the namespace via -n or --namespace option")
 	}
@@ -92,11 +111,43 @@ func getCluster(client *client, options *GetOptions) error {
 	if err := unmarshalData(rsp.Body(), &result); err != nil {
 		return err
 	}
+	if options.output.enabled() {
+		return printOutput(os.Stdout, &options.output, result.Cluster)
+	}
 	printCluster(result.Cluster)
 	return nil
 }
 
+func getNamespace(client *client, namespace string, output *OutputOptions) error {
+	rsp, err := client.restyCli.R().
+		SetPathParam("namespace", namespace).
+		Get("/namespaces/{namespace}")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	var result struct {
+		Namespace *store.Namespace `json:"namespace"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	if output.enabled() {
+		return printOutput(os.Stdout, output, result.Namespace)
+	}
+	printLine("namespace: %s", result.Namespace.Name)
+	printLine("owner: %s", result.Namespace.Owner)
+	printLine("contact: %s", result.Namespace.Contact)
+	printLine("description: %s", result.Namespace.Description)
+	return nil
+}
+
 func init() {
 	GetCommand.Flags().StringVarP(&getOptions.namespace, "namespace", "n", "", "The namespace of the resource")
 	GetCommand.Flags().StringVarP(&getOptions.cluster, "cluster", "c", "", "The cluster of the resource")
+	GetCommand.Flags().StringVarP(&getOptions.output.template, "template", "", "", "The Go template to render the resource")
+	GetCommand.Flags().StringVarP(&getOptions.output.jsonPath, "jsonpath", "", "", "The JSONPath to extract the fields of the resource")
 }
@@ -23,6 +23,7 @@ package command
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -33,6 +34,9 @@ type ImportOptions struct {
 	cluster   string
 	nodes     []string
 	password  string
+	// masterAuth is the masterauth of the nodes if it's different from the password
+	masterAuth string
+	nodesFile  string
 }
 
 var importOptions ImportOptions
@@ -43,6 +47,9 @@ var ImportCommand = &cobra.Command{
 	Example: `
 # Import a cluster from nodes
 kvctl import cluster <cluster> --nodes 127.0.0.1:6379,127.0.0.1:6380
+
+# Import a cluster from the saved output of CLUSTER NODES without requiring live nodes
+kvctl import cluster <cluster> -n <namespace> -f nodes.txt
 `,
 	PreRunE: importPreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -68,19 +75,29 @@ func importPreRun(_ *cobra.Command, args []string) error {
 	if importOptions.namespace == "" {
 		return errors.New("missing namespace, please specify with -n or --namespace")
 	}
-	if len(importOptions.nodes) == 0 {
-		return errors.New("missing nodes")
+	if len(importOptions.nodes) == 0 && importOptions.nodesFile == "" {
+		return errors.New("missing nodes, please specify with --nodes or --nodes-file")
 	}
 	return nil
 }
 
 func importCluster(client *client, options *ImportOptions) error {
+	var clusterNodes string
+	if options.nodesFile != "" {
+		content, err := os.ReadFile(options.nodesFile)
+		if err != nil {
+			return fmt.Errorf("read the nodes file: %w", err)
+		}
+		clusterNodes = string(content)
+	}
 	rsp, err := client.restyCli.R().
 		SetPathParam("namespace", options.namespace).
 		SetPathParam("cluster", options.cluster).
 		SetBody(map[string]interface{}{
-			"nodes":    options.nodes,
-			"password": options.password,
+			"nodes":         options.nodes,
+			"password":      options.password,
+			"master_auth":   options.masterAuth,
+			"cluster_nodes": clusterNodes,
 		}).
 		Post("/namespaces/{namespace}/clusters/{cluster}/import")
 	if err != nil {
@@ -98,4 +115,6 @@ func init() {
 	ImportCommand.Flags().StringVarP(&importOptions.cluster, "cluster", "c", "", "The cluster name")
 	ImportCommand.Flags().StringSliceVarP(&importOptions.nodes, "nodes", "", nil, "The nodes to import from")
 	ImportCommand.Flags().StringVarP(&importOptions.password, "password", "p", "", "The password of the cluster")
+	ImportCommand.Flags().StringVarP(&importOptions.masterAuth, "master-auth", "", "", "The masterauth of the cluster if it's different from the password")
+	ImportCommand.Flags().StringVarP(&importOptions.nodesFile, "nodes-file", "f", "", "The file of the saved CLUSTER NODES output to import from")
 }
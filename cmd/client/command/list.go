@@ -21,15 +21,24 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+
+	"github.com/apache/kvrocks-controller/store"
 )
 
 var listOptions struct {
 	namespace string
 	cluster   string
+	output    string
 }
 
 var ListCommand = &cobra.Command{
@@ -42,10 +51,16 @@ kvctl list namespaces
 # Display all clusters in the namespace
 kvctl list clusters -n <namespace>
 
+# Display all clusters in the namespace with the description and annotations
+kvctl list clusters -n <namespace> -o wide
+
 # Display all nodes in the cluster
 kvctl list nodes -n <namespace> -c <cluster>
+
+# Display the latest slot migrations in the cluster
+kvctl list migrations -n <namespace> -c <cluster>
 `,
-	ValidArgs: []string{"namespaces", "clusters", "shards", "nodes"},
+	ValidArgs: []string{"namespaces", "clusters", "shards", "nodes", "migrations"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		host, _ := cmd.Flags().GetString("host")
 		client := newClient(host)
@@ -54,6 +69,8 @@ kvctl list nodes -n <namespace> -c <cluster>
 			return listNamespace(client)
 		case "clusters":
 			return listClusters(client)
+		case "migrations":
+			return listMigrations(client)
 		default:
 			return fmt.Errorf("unsupported resource type %s", args[0])
 		}
@@ -75,7 +92,10 @@ func listPreRun(_ *cobra.Command, args []string) error {
 	if listOptions.namespace == "" {
 		return fmt.Errorf("missing namespace, please specify the namespace via -n or --namespace option")
 	}
-	if resource == "nodes" && listOptions.cluster == "" {
+	if listOptions.output != "" && listOptions.output != "wide" {
+		return fmt.Errorf("unsupported output format %s, only 'wide' is supported", listOptions.output)
+	}
+	if (resource == "nodes" || resource == "migrations") && listOptions.cluster == "" {
 		return fmt.Errorf("missing cluster, please specify the cluster via -c or --cluster option")
 	}
 	return nil
@@ -127,13 +147,97 @@ func listClusters(cli *client) error {
 		printLine("no cluster found.")
 		return nil
 	}
+	if listOptions.output == "wide" {
+		return listClustersWide(cli, result.Clusters)
+	}
 	for _, cluster := range result.Clusters {
 		printLine(cluster)
 	}
 	return nil
 }
 
+func listClustersWide(cli *client, clusters []string) error {
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"NAME", "VERSION", "SHARDS", "DESCRIPTION", "ANNOTATIONS"})
+	writer.SetCenterSeparator("|")
+	for _, name := range clusters {
+		rsp, err := cli.restyCli.R().
+			SetPathParam("namespace", listOptions.namespace).
+			SetPathParam("cluster", name).
+			Get("/namespaces/{namespace}/clusters/{cluster}")
+		if err != nil {
+			return err
+		}
+		if rsp.IsError() {
+			return unmarshalError(rsp.Body())
+		}
+		var result struct {
+			Cluster struct {
+				Version     int64             `json:"version"`
+				Shards      []json.RawMessage `json:"shards"`
+				Description string            `json:"description"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"cluster"`
+		}
+		if err := unmarshalData(rsp.Body(), &result); err != nil {
+			return err
+		}
+		annotations := make([]string, 0, len(result.Cluster.Annotations))
+		for key, value := range result.Cluster.Annotations {
+			annotations = append(annotations, key+"="+value)
+		}
+		sort.Strings(annotations)
+		writer.Append([]string{
+			name, strconv.FormatInt(result.Cluster.Version, 10),
+			strconv.Itoa(len(result.Cluster.Shards)),
+			result.Cluster.Description, strings.Join(annotations, ","),
+		})
+	}
+	writer.Render()
+	return nil
+}
+
+func listMigrations(cli *client) error {
+	rsp, err := cli.restyCli.R().
+		SetPathParam("namespace", listOptions.namespace).
+		SetPathParam("cluster", listOptions.cluster).
+		Get("/namespaces/{namespace}/clusters/{cluster}/migrations")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	var result struct {
+		Jobs []*store.Job `json:"jobs"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	if len(result.Jobs) == 0 {
+		printLine("no migration found.")
+		return nil
+	}
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"ID", "SLOT", "SOURCE", "TARGET", "STATUS", "STARTED AT", "ERROR"})
+	writer.SetCenterSeparator("|")
+	for _, job := range result.Jobs {
+		if job.Migration == nil {
+			continue
+		}
+		writer.Append([]string{
+			job.ID, job.Migration.Slot.String(),
+			strconv.Itoa(job.Migration.SourceShard), strconv.Itoa(job.Migration.TargetShard),
+			job.Status, time.Unix(job.StartedAt, 0).Format(time.RFC3339), job.Error,
+		})
+	}
+	writer.Render()
+	return nil
+}
+
 func init() {
 	ListCommand.Flags().StringVarP(&listOptions.namespace, "namespace", "n", "", "The namespace")
 	ListCommand.Flags().StringVarP(&listOptions.cluster, "cluster", "c", "", "The cluster")
+	ListCommand.Flags().StringVarP(&listOptions.output, "output", "o", "", "The output format, only 'wide' is supported")
 }
@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/store"
 	"github.com/spf13/cobra"
 )
@@ -36,6 +37,12 @@ type MigrationOptions struct {
 	slot      string
 	target    int
 	slotOnly  bool
+	force     bool
+	// source is the explicit source shard index, it's inferred from the slot ownership if negative
+	source      int
+	forceSource bool
+	// confirmProtected confirms to migrate the slot only or by force in the protected cluster
+	confirmProtected bool
 }
 
 var migrateOptions MigrationOptions
@@ -46,6 +53,15 @@ var MigrateCommand = &cobra.Command{
 	Example: `
 # Migrate slot between cluster shards 
 kvctl migrate slot <slot> --target <target_shard_index> -n <namespace> -c <cluster>
+
+# Migrate slot even if the target shard would exceed the headroom limits
+kvctl migrate slot <slot> --target <target_shard_index> -n <namespace> -c <cluster> --force
+
+# Migrate slot from the source shard even if the source doesn't own the slot in the metadata
+kvctl migrate slot <slot> --source <source_shard_index> --target <target_shard_index> -n <namespace> -c <cluster> --force-source
+
+# Migrate slot by force in the protected cluster
+kvctl migrate slot <slot> --target <target_shard_index> -n <namespace> -c <cluster> --force --yes-i-know
 `,
 	PreRunE: migrationPreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -85,26 +101,48 @@ func migrationPreRun(_ *cobra.Command, args []string) error {
 	if migrateOptions.target < 0 {
 		return fmt.Errorf("target is required, please specify with --target")
 	}
+	if migrateOptions.forceSource && migrateOptions.source < 0 {
+		return fmt.Errorf("source is required to force the source, please specify with --source")
+	}
 	return nil
 }
 
 func migrateSlot(client *client, options *MigrationOptions) error {
-	rsp, err := client.restyCli.R().
+	body := map[string]interface{}{
+		"slot":     options.slot,
+		"target":   options.target,
+		"slotOnly": strconv.FormatBool(options.slotOnly),
+		"force":    options.force,
+	}
+	if options.source >= 0 {
+		body["source"] = options.source
+		body["force_source"] = options.forceSource
+	}
+	req := client.restyCli.R().
 		SetPathParam("namespace", options.namespace).
 		SetPathParam("cluster", options.cluster).
-		SetBody(map[string]interface{}{
-			"slot":     options.slot,
-			"target":   options.target,
-			"slotOnly": strconv.FormatBool(options.slotOnly),
-		}).
-		Post("/namespaces/{namespace}/clusters/{cluster}/migrate")
+		SetBody(body)
+	if options.confirmProtected {
+		req.SetHeader(consts.HeaderConfirmProtected, "yes")
+	}
+	rsp, err := req.Post("/namespaces/{namespace}/clusters/{cluster}/migrate")
 	if err != nil {
 		return err
 	}
 	if rsp.IsError() {
 		return errors.New(rsp.String())
 	}
-	printLine("migrate slot[%s] task is submitted successfully.", options.slot)
+	var result struct {
+		MigrationID string `json:"migration_id"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	if result.MigrationID == "" {
+		printLine("migrate slot[%s] task is submitted successfully.", options.slot)
+		return nil
+	}
+	printLine("migrate slot[%s] task[%s] is submitted successfully.", options.slot, result.MigrationID)
 	return nil
 }
 
@@ -114,4 +152,8 @@ func init() {
 	MigrateCommand.Flags().StringVarP(&migrateOptions.namespace, "namespace", "n", "", "The namespace")
 	MigrateCommand.Flags().StringVarP(&migrateOptions.cluster, "cluster", "c", "", "The cluster")
 	MigrateCommand.Flags().BoolVar(&migrateOptions.slotOnly, "slot-only", false, "Only migrate slot and ignore the existing data")
+	MigrateCommand.Flags().BoolVar(&migrateOptions.force, "force", false, "Migrate slot even if the target would exceed the headroom limits")
+	MigrateCommand.Flags().IntVar(&migrateOptions.source, "source", -1, "The source shard, it's inferred from the slot ownership if not specified")
+	MigrateCommand.Flags().BoolVar(&migrateOptions.forceSource, "force-source", false, "Migrate slot from the source shard even if it doesn't own the slot")
+	MigrateCommand.Flags().BoolVar(&migrateOptions.confirmProtected, confirmProtectedFlag, false, "Confirm to migrate the slot only or by force in the protected cluster")
 }
@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+const nodeCommandPromote = "promote"
+
+var NodeCommand = &cobra.Command{
+	Use:   "node",
+	Short: "Node operations",
+	Example: `
+# Promote the slave node to the master of its shard
+kvctl node promote <namespace> <cluster> <node_id>
+`,
+	ValidArgs: []string{nodeCommandPromote},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		client := newClient(host)
+		if len(args) == 0 {
+			return errors.New("missing node operation")
+		}
+		switch strings.ToLower(args[0]) {
+		case nodeCommandPromote:
+			if len(args) < 4 {
+				return errors.New("missing namespace, cluster or node_id, please specify like `node promote <namespace> <cluster> <node_id>`")
+			}
+			return promoteNode(client, args[1], args[2], args[3])
+		default:
+			return fmt.Errorf("unsupported operation: '%s' in node command", args[0])
+		}
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// promoteNode finds the shard of the node and fails over the shard with the node as the preferred slave
+func promoteNode(client *client, namespace, cluster, nodeID string) error {
+	rsp, err := client.restyCli.R().SetPathParams(map[string]string{
+		"namespace": namespace,
+		"cluster":   cluster,
+	}).Get("/namespaces/{namespace}/clusters/{cluster}")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	var clusterResult struct {
+		Cluster *store.Cluster `json:"cluster"`
+	}
+	if err := unmarshalData(rsp.Body(), &clusterResult); err != nil {
+		return err
+	}
+
+	shardIndex, oldMasterID := -1, ""
+	for i, shard := range clusterResult.Cluster.Shards {
+		for _, node := range shard.Nodes {
+			if node.ID() == nodeID {
+				shardIndex = i
+			}
+		}
+		if shardIndex != i {
+			continue
+		}
+		if master := shard.GetMasterNode(); master != nil {
+			oldMasterID = master.ID()
+		}
+		break
+	}
+	if shardIndex < 0 {
+		return fmt.Errorf("node %s was not found in cluster %s", nodeID, cluster)
+	}
+	if oldMasterID == nodeID {
+		return fmt.Errorf("node %s is already the master of shard %d", nodeID, shardIndex)
+	}
+
+	rsp, err = client.restyCli.R().
+		SetPathParam("namespace", namespace).
+		SetPathParam("cluster", cluster).
+		SetPathParam("shard", strconv.Itoa(shardIndex)).
+		SetBody(map[string]interface{}{
+			"preferred_node_id": nodeID,
+		}).
+		Post("/namespaces/{namespace}/clusters/{cluster}/shards/{shard}/failover")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	var result struct {
+		NewMasterID string `json:"new_master_id"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	printLine("promote node %s in shard %d successfully, old master id: %s, new master id: %s.",
+		nodeID, shardIndex, oldMasterID, result.NewMasterID)
+	return nil
+}
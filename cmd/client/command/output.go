@@ -0,0 +1,281 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// OutputOptions renders the resource by the Go template or the JSONPath instead of the table,
+// so that the automation can extract the fields without piping the JSON through jq.
+type OutputOptions struct {
+	template string
+	jsonPath string
+}
+
+func (options *OutputOptions) enabled() bool {
+	return options.template != "" || options.jsonPath != ""
+}
+
+func (options *OutputOptions) validate() error {
+	if options.template != "" && options.jsonPath != "" {
+		return errors.New("only one of --template and --jsonpath can be specified")
+	}
+	return nil
+}
+
+// printOutput renders the JSON representation of the value, so the fields are
+// referred by the JSON names, e.g. `{.shards[0].nodes[0].addr}`.
+func printOutput(w io.Writer, options *OutputOptions, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return err
+	}
+
+	if options.template != "" {
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(options.template)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		if err := tmpl.Execute(w, object); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	}
+	output, err := evalJSONPath(object, options.jsonPath)
+	if err != nil {
+		return fmt.Errorf("invalid jsonpath: %w", err)
+	}
+	_, err = fmt.Fprintln(w, output)
+	return err
+}
+
+// evalJSONPath evaluates the kubectl style JSONPath, the expressions are enclosed by the braces
+// and the text outside the braces is printed as it is, e.g. `{.name}: {.shards[*].nodes[0].addr}`.
+// The expression supports the fields, the array indexes, the wildcard `[*]`, the filter like
+// `[?(@.role=="master")]` and the quoted string like `{"\n"}`. The whole template is an expression
+// if it contains no braces.
+func evalJSONPath(object interface{}, jsonPath string) (string, error) {
+	if !strings.Contains(jsonPath, "{") {
+		jsonPath = "{" + jsonPath + "}"
+	}
+	var builder strings.Builder
+	for len(jsonPath) > 0 {
+		start := strings.IndexByte(jsonPath, '{')
+		if start == -1 {
+			builder.WriteString(jsonPath)
+			break
+		}
+		builder.WriteString(jsonPath[:start])
+		stop := strings.IndexByte(jsonPath[start:], '}')
+		if stop == -1 {
+			return "", fmt.Errorf("unclosed expression: %s", jsonPath[start:])
+		}
+		expr := strings.TrimSpace(jsonPath[start+1 : start+stop])
+		jsonPath = jsonPath[start+stop+1:]
+
+		if strings.HasPrefix(expr, `"`) {
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return "", fmt.Errorf("invalid string %s: %w", expr, err)
+			}
+			builder.WriteString(text)
+			continue
+		}
+		values, err := evalPath(object, expr)
+		if err != nil {
+			return "", err
+		}
+		for i, value := range values {
+			if i > 0 {
+				builder.WriteByte(' ')
+			}
+			text, err := formatJSONValue(value)
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(text)
+		}
+	}
+	return builder.String(), nil
+}
+
+// evalPath returns the values matched by the path like `.shards[*].nodes[0].addr`
+func evalPath(object interface{}, path string) ([]interface{}, error) {
+	path = strings.TrimPrefix(path, "$")
+	values := []interface{}{object}
+	for len(path) > 0 {
+		var next []interface{}
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			field := path[:end]
+			path = path[end:]
+			if field == "" {
+				continue
+			}
+			for _, value := range values {
+				fields, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s is not found in the non-object value", field)
+				}
+				if fieldValue, ok := fields[field]; ok {
+					next = append(next, fieldValue)
+				}
+			}
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket: %s", path)
+			}
+			subscript := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			for _, value := range values {
+				matched, err := evalSubscript(value, subscript)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, matched...)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected character %q in the path", path[0])
+		}
+		values = next
+	}
+	return values, nil
+}
+
+// evalSubscript returns the elements of the array by the index, the wildcard or the filter like
+// `?(@.role=="master")`, the wildcard also returns the values of the object sorted by the keys.
+func evalSubscript(value interface{}, subscript string) ([]interface{}, error) {
+	if strings.HasPrefix(subscript, "?(") && strings.HasSuffix(subscript, ")") {
+		return evalFilter(value, subscript[2:len(subscript)-1])
+	}
+	if subscript == "*" {
+		switch v := value.(type) {
+		case []interface{}:
+			return v, nil
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, 0, len(v))
+			for _, key := range keys {
+				values = append(values, v[key])
+			}
+			return values, nil
+		}
+		return nil, nil
+	}
+	index, err := strconv.Atoi(subscript)
+	if err != nil {
+		return nil, fmt.Errorf("invalid array index: %s", subscript)
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("index %d is out of the non-array value", index)
+	}
+	if index < 0 {
+		index += len(array)
+	}
+	if index < 0 || index >= len(array) {
+		return nil, fmt.Errorf("array index %s is out of range", subscript)
+	}
+	return []interface{}{array[index]}, nil
+}
+
+// evalFilter returns the elements whose field equals to (==) or not equals to (!=) the value
+func evalFilter(value interface{}, filter string) ([]interface{}, error) {
+	equal := true
+	index := strings.Index(filter, "==")
+	if index == -1 {
+		equal = false
+		index = strings.Index(filter, "!=")
+	}
+	if index == -1 {
+		return nil, fmt.Errorf("unsupported filter: %s, only == and != are supported", filter)
+	}
+	field, expected := strings.TrimSpace(filter[:index]), strings.TrimSpace(filter[index+2:])
+	if !strings.HasPrefix(field, "@") {
+		return nil, fmt.Errorf("the filter should start with @: %s", filter)
+	}
+	if len(expected) >= 2 && (expected[0] == '"' || expected[0] == '\'') && expected[len(expected)-1] == expected[0] {
+		expected = expected[1 : len(expected)-1]
+	}
+
+	elements, err := evalSubscript(value, "*")
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]interface{}, 0)
+	for _, element := range elements {
+		actual := ""
+		// the element without the field doesn't equal to any value
+		if values, err := evalPath(element, field[1:]); err == nil && len(values) == 1 {
+			if actual, err = formatJSONValue(values[0]); err != nil {
+				return nil, err
+			}
+		}
+		if (actual == expected) == equal {
+			matched = append(matched, element)
+		}
+	}
+	return matched, nil
+}
+
+func formatJSONValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case nil:
+		return "", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func decodeTestObject(t *testing.T, data string) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	var object interface{}
+	require.NoError(t, decoder.Decode(&object))
+	return object
+}
+
+const testCluster = `{
+	"name": "test-cluster",
+	"version": 3,
+	"protected": false,
+	"labels": {"team": "infra", "env": "prod"},
+	"shards": [
+		{"nodes": [{"addr": "127.0.0.1:1111", "role": "master"}, {"addr": "127.0.0.1:2222", "role": "slave"}]},
+		{"nodes": [{"addr": "127.0.0.1:3333", "role": "master"}, {"addr": "127.0.0.1:4444", "role": "slave", "tag": 1}]}
+	]
+}`
+
+func TestEvalJSONPath(t *testing.T) {
+	object := decodeTestObject(t, testCluster)
+	for _, tc := range []struct {
+		jsonPath string
+		output   string
+		err      bool
+	}{
+		{jsonPath: ".name", output: "test-cluster"},
+		{jsonPath: "$.version", output: "3"},
+		{jsonPath: ".protected", output: "false"},
+		{jsonPath: "{.name}: {.version}", output: "test-cluster: 3"},
+		{jsonPath: `{.name}{"\n"}`, output: "test-cluster\n"},
+		{jsonPath: ".shards[0].nodes[0].addr", output: "127.0.0.1:1111"},
+		{jsonPath: ".shards[-1].nodes[-1].addr", output: "127.0.0.1:4444"},
+		{jsonPath: ".shards[*].nodes[0].addr", output: "127.0.0.1:1111 127.0.0.1:3333"},
+		// the wildcard returns the values of the object sorted by the keys
+		{jsonPath: ".labels[*]", output: "prod infra"},
+		{jsonPath: ".shards[0].nodes[0]", output: `{"addr":"127.0.0.1:1111","role":"master"}`},
+		{jsonPath: ".not_exists", output: ""},
+		{jsonPath: ".shards[2]", err: true},
+		{jsonPath: ".shards[x]", err: true},
+		{jsonPath: ".name.first", err: true},
+		{jsonPath: ".shards[0", err: true},
+		{jsonPath: "{.name", err: true},
+		{jsonPath: `{"unclosed}`, err: true},
+	} {
+		output, err := evalJSONPath(object, tc.jsonPath)
+		if tc.err {
+			require.Error(t, err, tc.jsonPath)
+			continue
+		}
+		require.NoError(t, err, tc.jsonPath)
+		require.Equal(t, tc.output, output, tc.jsonPath)
+	}
+}
+
+func TestEvalFilter(t *testing.T) {
+	object := decodeTestObject(t, testCluster)
+	nodes := object.(map[string]interface{})["shards"].([]interface{})[1].(map[string]interface{})["nodes"]
+	for _, tc := range []struct {
+		filter string
+		addrs  []string
+		err    bool
+	}{
+		{filter: `@.role=="master"`, addrs: []string{"127.0.0.1:3333"}},
+		{filter: `@.role == 'slave'`, addrs: []string{"127.0.0.1:4444"}},
+		{filter: `@.role!="master"`, addrs: []string{"127.0.0.1:4444"}},
+		{filter: `@.tag==1`, addrs: []string{"127.0.0.1:4444"}},
+		// the element without the field doesn't equal to the value
+		{filter: `@.tag!=1`, addrs: []string{"127.0.0.1:3333"}},
+		{filter: `@.role=="unknown"`, addrs: []string{}},
+		{filter: `@.role>"master"`, err: true},
+		{filter: `.role=="master"`, err: true},
+	} {
+		matched, err := evalFilter(nodes, tc.filter)
+		if tc.err {
+			require.Error(t, err, tc.filter)
+			continue
+		}
+		require.NoError(t, err, tc.filter)
+		addrs := make([]string, 0, len(matched))
+		for _, element := range matched {
+			addrs = append(addrs, element.(map[string]interface{})["addr"].(string))
+		}
+		require.Equal(t, tc.addrs, addrs, tc.filter)
+	}
+
+	output, err := evalJSONPath(object, `.shards[*].nodes[?(@.role=="master")].addr`)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:1111 127.0.0.1:3333", output)
+}
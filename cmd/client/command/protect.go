@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+type ProtectOptions struct {
+	namespace        string
+	disable          bool
+	confirmProtected bool
+}
+
+var protectOptions ProtectOptions
+
+var ProtectCommand = &cobra.Command{
+	Use:   "protect",
+	Short: "Protect a cluster from being deleted or migrated by force",
+	Example: `
+# Protect the cluster, deleting it or migrating its slots by force requires --yes-i-know
+kvctl protect cluster <cluster> -n <namespace>
+
+# Unprotect the cluster
+kvctl protect cluster <cluster> -n <namespace> --disable --yes-i-know
+`,
+	PreRunE: protectPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		client := newClient(host)
+		switch strings.ToLower(args[0]) {
+		case ResourceCluster:
+			return protectCluster(client, &protectOptions, args[1])
+		default:
+			return fmt.Errorf("unsupported resource type: %s", args[0])
+		}
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func protectPreRun(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing resource type or name, please specify like `protect cluster <cluster>`")
+	}
+	if protectOptions.namespace == "" {
+		return fmt.Errorf("missing namespace, please specify the namespace via -n or --namespace option")
+	}
+	return nil
+}
+
+func protectCluster(client *client, options *ProtectOptions, cluster string) error {
+	req := client.restyCli.R().
+		SetPathParams(map[string]string{
+			"namespace": options.namespace,
+			"cluster":   cluster,
+		}).
+		SetBody(map[string]interface{}{"protected": !options.disable})
+	if options.confirmProtected {
+		req.SetHeader(consts.HeaderConfirmProtected, "yes")
+	}
+	rsp, err := req.Patch("/namespaces/{namespace}/clusters/{cluster}")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	if options.disable {
+		printLine("unprotect cluster: %s successfully.", cluster)
+	} else {
+		printLine("protect cluster: %s successfully.", cluster)
+	}
+	return nil
+}
+
+func init() {
+	ProtectCommand.Flags().StringVarP(&protectOptions.namespace, "namespace", "n", "", "The namespace of the cluster")
+	ProtectCommand.Flags().BoolVar(&protectOptions.disable, "disable", false, "Unprotect the cluster")
+	ProtectCommand.Flags().BoolVar(&protectOptions.confirmProtected, confirmProtectedFlag, false, "Confirm to unprotect the cluster")
+}
@@ -37,6 +37,11 @@ const (
 	raftCommandList   = "list"
 	raftCommandAdd    = "add"
 	raftCommandRemove = "remove"
+	raftCommandUpdate = "update"
+
+	raftCommandStatus             = "status"
+	raftCommandSnapshot           = "snapshot"
+	raftCommandTransferLeadership = "transfer-leadership"
 )
 
 var RaftCommand = &cobra.Command{
@@ -51,18 +56,49 @@ kvctl raft add peer <node_id> <node_address>
 
 # Remove a node from the cluster
 kvctl raft remove peer <node_id>
+
+# Update the address of a node in the cluster
+kvctl raft update peer <node_id> <node_address>
+
+# Display the raft status of the node
+kvctl raft status
+
+# Force the node to create a snapshot now
+kvctl raft snapshot
+
+# Transfer the leadership to another node
+kvctl raft transfer-leadership <node_id>
 `,
-	ValidArgs: []string{raftCommandList, raftCommandAdd, raftCommandRemove},
+	ValidArgs: []string{
+		raftCommandList, raftCommandAdd, raftCommandRemove, raftCommandUpdate,
+		raftCommandStatus, raftCommandSnapshot, raftCommandTransferLeadership,
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		host, _ := cmd.Flags().GetString("host")
 		client := newClient(host)
+		if len(args) == 0 {
+			return errors.New("missing raft operation")
+		}
 		switch strings.ToLower(args[0]) {
+		case raftCommandStatus:
+			return showRaftStatus(client)
+		case raftCommandSnapshot:
+			return triggerRaftSnapshot(client)
+		case raftCommandTransferLeadership:
+			if len(args) < 2 {
+				return errors.New("missing target node_id")
+			}
+			id, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid node_id: %s", args[1])
+			}
+			return transferRaftLeadership(client, id)
 		case raftCommandList:
 			if len(args) < 2 || args[1] != "peers" {
 				return fmt.Errorf("unsupported openeration: '%s' in raft command", args[1])
 			}
 			return listRaftPeers(client)
-		case raftCommandAdd, raftCommandRemove:
+		case raftCommandAdd, raftCommandRemove, raftCommandUpdate:
 			if len(args) < 2 {
 				return errors.New("missing 'peer' in raft command")
 			}
@@ -76,7 +112,7 @@ kvctl raft remove peer <node_id>
 			if err != nil {
 				return fmt.Errorf("invalid node_id: %s", args[1])
 			}
-			if args[0] == raftCommandAdd {
+			if args[0] == raftCommandAdd || args[0] == raftCommandUpdate {
 				if len(args) < 4 {
 					return fmt.Errorf("missing node_address")
 				}
@@ -84,6 +120,9 @@ kvctl raft remove peer <node_id>
 				if _, err := url.Parse(address); err != nil {
 					return fmt.Errorf("invalid node_address: %s", address)
 				}
+				if args[0] == raftCommandUpdate {
+					return updateRaftPeer(client, id, address)
+				}
 				return addRaftPeer(client, id, address)
 			} else {
 				return removeRaftPeer(client, id)
@@ -96,6 +135,25 @@ kvctl raft remove peer <node_id>
 	SilenceErrors: true,
 }
 
+// raftPeer is the member of the raft cluster returned by the peers and status APIs
+type raftPeer struct {
+	ID        uint64 `json:"id"`
+	Addr      string `json:"addr"`
+	IsLeader  bool   `json:"is_leader"`
+	IsLearner bool   `json:"is_learner"`
+	Match     uint64 `json:"match"`
+	Next      uint64 `json:"next"`
+	State     string `json:"state"`
+	Connected bool   `json:"connected"`
+}
+
+func yesOrNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
 func listRaftPeers(cli *client) error {
 	rsp, err := cli.restyCli.R().Get("/raft/peers")
 	if err != nil {
@@ -106,23 +164,70 @@ func listRaftPeers(cli *client) error {
 	}
 
 	var result struct {
-		Leader uint64            `json:"leader"`
-		Peers  map[uint64]string `json:"peers"`
+		Leader uint64     `json:"leader"`
+		Peers  []raftPeer `json:"peers"`
 	}
 	if err := unmarshalData(rsp.Body(), &result); err != nil {
 		return err
 	}
 	writer := tablewriter.NewWriter(os.Stdout)
 	printLine("")
-	writer.SetHeader([]string{"NODE_ID", "NODE_ADDRESS", "IS_LEADER"})
+	writer.SetHeader([]string{"NODE_ID", "NODE_ADDRESS", "IS_LEADER", "IS_LEARNER", "MATCH", "CONNECTED"})
 	writer.SetCenterSeparator("|")
-	for id, addr := range result.Peers {
-		isLeader := "NO"
-		if id == result.Leader {
-			isLeader = "YES"
-		}
-		columns := []string{fmt.Sprintf("%d", id), addr, isLeader}
-		writer.Append(columns)
+	for _, peer := range result.Peers {
+		writer.Append([]string{
+			fmt.Sprintf("%d", peer.ID), peer.Addr, yesOrNo(peer.IsLeader), yesOrNo(peer.IsLearner),
+			fmt.Sprintf("%d", peer.Match), yesOrNo(peer.Connected),
+		})
+	}
+	writer.Render()
+	return nil
+}
+
+func showRaftStatus(cli *client) error {
+	rsp, err := cli.restyCli.R().Get("/raft/status")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	var result struct {
+		Status struct {
+			ID            uint64     `json:"id"`
+			Leader        uint64     `json:"leader"`
+			State         string     `json:"state"`
+			Term          uint64     `json:"term"`
+			CommitIndex   uint64     `json:"commit_index"`
+			AppliedIndex  uint64     `json:"applied_index"`
+			SnapshotIndex uint64     `json:"snapshot_index"`
+			Peers         []raftPeer `json:"peers"`
+		} `json:"status"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	status := result.Status
+	printLine("")
+	printLine("node_id: %d", status.ID)
+	printLine("leader: %d", status.Leader)
+	printLine("state: %s", status.State)
+	printLine("term: %d", status.Term)
+	printLine("commit_index: %d", status.CommitIndex)
+	printLine("applied_index: %d", status.AppliedIndex)
+	printLine("snapshot_index: %d", status.SnapshotIndex)
+	printLine("")
+
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"NODE_ID", "NODE_ADDRESS", "IS_LEARNER", "MATCH", "NEXT", "STATE", "CONNECTED"})
+	writer.SetCenterSeparator("|")
+	for _, peer := range status.Peers {
+		writer.Append([]string{
+			fmt.Sprintf("%d", peer.ID), peer.Addr, yesOrNo(peer.IsLearner),
+			fmt.Sprintf("%d", peer.Match), fmt.Sprintf("%d", peer.Next),
+			peer.State, yesOrNo(peer.Connected),
+		})
 	}
 	writer.Render()
 	return nil
@@ -152,6 +257,30 @@ func addRaftPeer(cli *client, id uint64, address string) error {
 	return nil
 }
 
+func updateRaftPeer(cli *client, id uint64, address string) error {
+	var request struct {
+		ID        uint64 `json:"id"`
+		Peer      string `json:"peer"`
+		Operation string `json:"operation"`
+	}
+	request.ID = id
+	request.Peer = address
+	request.Operation = "update"
+
+	rsp, err := cli.restyCli.R().
+		SetBody(&request).
+		Post("/raft/peers")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	printLine("Update node '%d' with address '%s' successfully", id, address)
+	return nil
+}
+
 func removeRaftPeer(cli *client, id uint64) error {
 	var request struct {
 		ID        uint64 `json:"id"`
@@ -173,3 +302,42 @@ func removeRaftPeer(cli *client, id uint64) error {
 	printLine("Remove node '%d' successfully", id)
 	return nil
 }
+
+func triggerRaftSnapshot(cli *client) error {
+	rsp, err := cli.restyCli.R().Post("/raft/snapshot")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	var result struct {
+		Index uint64 `json:"index"`
+	}
+	if err := unmarshalData(rsp.Body(), &result); err != nil {
+		return err
+	}
+	printLine("Create snapshot at index '%d' successfully", result.Index)
+	return nil
+}
+
+func transferRaftLeadership(cli *client, id uint64) error {
+	var request struct {
+		TargetID uint64 `json:"target_id"`
+	}
+	request.TargetID = id
+
+	rsp, err := cli.restyCli.R().
+		SetBody(&request).
+		Post("/raft/transfer-leadership")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+
+	printLine("Transfer the leadership to node '%d' successfully", id)
+	return nil
+}
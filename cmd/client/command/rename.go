@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var RenameCommand = &cobra.Command{
+	Use:   "rename",
+	Short: "Rename a resource",
+	Example: `
+# Rename the namespace, the clusters in the namespace are moved to the new namespace
+kvctl rename namespace <namespace> <new-namespace>
+`,
+	PreRunE: renamePreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		client := newClient(host)
+		switch strings.ToLower(args[0]) {
+		case ResourceNamespace:
+			return renameNamespace(client, args[1], args[2])
+		default:
+			return fmt.Errorf("unsupported resource type: %s", args[0])
+		}
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func renamePreRun(_ *cobra.Command, args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("missing resource type or name, please specify like `rename namespace <namespace> <new-namespace>`")
+	}
+	return nil
+}
+
+func renameNamespace(client *client, namespace, newNamespace string) error {
+	rsp, err := client.restyCli.R().
+		SetPathParam("namespace", namespace).
+		SetBody(map[string]string{"name": newNamespace}).
+		Post("/namespaces/{namespace}/rename")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	printLine("rename namespace: %s to %s successfully.", namespace, newNamespace)
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package command
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var SupportBundleCommand = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "Collect the controller state into a tarball for the bug reports",
+	Example: `
+# Collect the controller status, the redacted config, the latest logs, the cluster documents,
+# the migration jobs, the events and the metrics into the tarball in the current directory
+kvctl support-bundle
+
+# Write the tarball to the specified file
+kvctl support-bundle -o /tmp/bundle.tar.gz
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = fmt.Sprintf("kvrocks-controller-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+		return downloadSupportBundle(newClient(host), output)
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func downloadSupportBundle(cli *client, output string) error {
+	rsp, err := cli.restyCli.R().Get("/controller/support-bundle")
+	if err != nil {
+		return err
+	}
+	if rsp.IsError() {
+		return unmarshalError(rsp.Body())
+	}
+	if err := os.WriteFile(output, rsp.Body(), 0o600); err != nil {
+		return err
+	}
+	printLine("support bundle was written to %s", output)
+	return nil
+}
+
+func init() {
+	SupportBundleCommand.Flags().StringP("output", "o", "", "The file to write the support bundle")
+}
@@ -57,7 +57,13 @@ func init() {
 	rootCommand.AddCommand(command.ImportCommand)
 	rootCommand.AddCommand(command.MigrateCommand)
 	rootCommand.AddCommand(command.FailoverCommand)
+	rootCommand.AddCommand(command.CheckCommand)
+	rootCommand.AddCommand(command.ProtectCommand)
+	rootCommand.AddCommand(command.FreezeCommand)
+	rootCommand.AddCommand(command.RenameCommand)
 	rootCommand.AddCommand(command.RaftCommand)
+	rootCommand.AddCommand(command.NodeCommand)
+	rootCommand.AddCommand(command.SupportBundleCommand)
 
 	rootCommand.SilenceUsage = true
 	rootCommand.SilenceErrors = true
@@ -23,7 +23,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/apache/kvrocks-controller/store/engine/consul"
@@ -36,17 +39,168 @@ import (
 	"github.com/apache/kvrocks-controller/store/engine/zookeeper"
 )
 
-type AdminConfig struct {
-	Addr string `yaml:"addr"`
+type TLSConfig struct {
+	Enable   bool   `yaml:"enable"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// AllNamespaces allows the token to access all namespaces
+const AllNamespaces = "*"
+
+// NameConfig is the rules of the namespace and cluster names, the names with the slashes
+// or whitespaces are always rejected since they would break the key path in the engine.
+type NameConfig struct {
+	// Pattern is the regular expression which the name should match,
+	// default is `^[a-zA-Z0-9][a-zA-Z0-9._-]*$`.
+	Pattern string `yaml:"pattern"`
+	// MaxLength is the max length of the name, default is 64.
+	MaxLength int `yaml:"max_length"`
+}
+
+// TokenConfig is the bearer token which can only access the APIs of the namespaces
+type TokenConfig struct {
+	Token      string   `yaml:"token"`
+	Namespaces []string `yaml:"namespaces"`
+}
+
+// ListenerConfig describes an extra HTTP listener. The admin(pprof) and metrics
+// routes will be served by the API listener if the addr is empty.
+type ListenerConfig struct {
+	Addr      string          `yaml:"addr"`
+	TLS       TLSConfig       `yaml:"tls"`
+	BasicAuth BasicAuthConfig `yaml:"basic_auth"`
+}
+
+func (c *ListenerConfig) Enabled() bool {
+	return c.Addr != ""
+}
+
+func (c *ListenerConfig) validate(name string) error {
+	if c.TLS.Enable && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+		return fmt.Errorf("%s: cert_file and key_file are required when TLS is enabled", name)
+	}
+	if (c.BasicAuth.Username == "") != (c.BasicAuth.Password == "") {
+		return fmt.Errorf("%s: username and password of basic auth must be set together", name)
+	}
+	return nil
 }
 
 type FailOverConfig struct {
 	PingIntervalSeconds int   `yaml:"ping_interval_seconds"`
 	MaxPingCount        int64 `yaml:"max_ping_count"`
+	// ReplicaMaxPingCount is the failure count to warn the failing replica,
+	// it's the same as MaxPingCount if it's 0.
+	ReplicaMaxPingCount int64 `yaml:"replica_max_ping_count"`
+	// ReplicaAutoRemoveCount is the failure count to remove the failing replica
+	// from the cluster, 0 means never remove the replica automatically.
+	ReplicaAutoRemoveCount int64 `yaml:"replica_auto_remove_count"`
+	// MaxReplicationStallSeconds counts the master as failed if it answers the PING but none of
+	// its connected replicas received the replication stream within it, 0 means disabled.
+	MaxReplicationStallSeconds int `yaml:"max_replication_stall_seconds"`
+	// MaxClusterFailovers and MaxGlobalFailovers are the limits of the automatic failovers
+	// of each cluster and all clusters within the failover window, the automatic failover
+	// is suspended until acknowledged after reaching the limit, 0 means no limit.
+	MaxClusterFailovers int `yaml:"max_cluster_failovers"`
+	MaxGlobalFailovers  int `yaml:"max_global_failovers"`
+	// FailoverWindowSeconds is the rolling window to count the failovers, default is 600.
+	FailoverWindowSeconds int `yaml:"failover_window_seconds"`
+	// Hook is invoked after promoting a new master, it's disabled if nil.
+	Hook *FailoverHookConfig `yaml:"hook"`
+}
+
+// FailoverHookConfig is the external command or webhook which receives the JSON payload
+// of the failover after promoting a new master, e.g. to update the DNS or VIPs.
+type FailoverHookConfig struct {
+	// Command is executed with the payload in the stdin
+	Command []string `yaml:"command"`
+	// URL receives the payload in the POST body
+	URL string `yaml:"url"`
+	// TimeoutMs is the timeout of each attempt, default is 5000.
+	TimeoutMs int64 `yaml:"timeout_ms"`
+	// Retries is the number of the retries after the attempt failed, default is 0.
+	Retries int `yaml:"retries"`
+	// RetryBackoffMs is the interval between the retries, default is 1000.
+	RetryBackoffMs int64 `yaml:"retry_backoff_ms"`
+}
+
+// MigrationConfig is the headroom limits of the target shard master and the verification of
+// the migrated slot, the zero value means no limit. The disk usage in percent is projected after
+// the migration, while the memory RSS is checked as is since the migrated data is on the disk.
+type MigrationConfig struct {
+	MaxTargetDiskUsage   float64 `yaml:"max_target_disk_usage"`
+	MaxTargetMemoryBytes int64   `yaml:"max_target_memory_bytes"`
+	// VerifySampleKeys enables verifying the migrated slot has no keys left on the source node before
+	// committing it, it's the max number of the leftover keys sampled into the migration job.
+	// Default is 0 which means disabled.
+	VerifySampleKeys int `yaml:"verify_sample_keys"`
+	// VerifyMaxAttempts is the max verifications of the migrated slot before failing the migration, default is 10.
+	VerifyMaxAttempts int `yaml:"verify_max_attempts"`
+}
+
+// EngineHealthConfig is the periodic health check of the metadata store engine
+type EngineHealthConfig struct {
+	// ProbeIntervalSeconds is the interval to probe the engine, default is 5.
+	ProbeIntervalSeconds int `yaml:"probe_interval_seconds"`
+	// MaxLatencyMs is the probe latency to consider the engine unhealthy, default is 1000.
+	MaxLatencyMs int64 `yaml:"max_latency_ms"`
+	// MaxErrorRate is the ratio of the failed probes in the recent probes to consider
+	// the engine unhealthy, default is 0.5.
+	MaxErrorRate float64 `yaml:"max_error_rate"`
+	// PauseFailover pauses the automatic failover while the engine is unhealthy,
+	// since the failover decision might be made from the stale or partial metadata.
+	PauseFailover bool `yaml:"pause_failover"`
+}
+
+// NodeRetryConfig is the retry and the circuit breaker of the topology sync and
+// the slot migration commands sent to the nodes.
+type NodeRetryConfig struct {
+	// MaxAttempts is the max attempts of the command, default is 3.
+	MaxAttempts int `yaml:"max_attempts"`
+	// BackoffMs is the base backoff between the attempts, default is 100.
+	BackoffMs int64 `yaml:"backoff_ms"`
+	// BreakerFailures is the consecutive failed commands to open the circuit breaker
+	// of the node, default is 5.
+	BreakerFailures int `yaml:"breaker_failures"`
+	// BreakerCooldownSeconds is the duration to reject the commands to the node
+	// after the circuit breaker was opened, default is 10.
+	BreakerCooldownSeconds int `yaml:"breaker_cooldown_seconds"`
+}
+
+// ResourceConfig caps the resources used by the cluster checkers, so that the controller
+// checking many clusters degrades predictably, the zero value means no limit.
+type ResourceConfig struct {
+	// MaxClusters is the max number of the clusters checked by the controller, the clusters
+	// beyond the limit won't be checked until some checked clusters were removed.
+	MaxClusters int `yaml:"max_clusters"`
+	// MaxNodeConcurrency is the max number of the goroutines which probe or sync the nodes of each cluster.
+	MaxNodeConcurrency int `yaml:"max_node_concurrency"`
+	// MaxGlobalNodeConcurrency is the max number of the goroutines which probe or sync the nodes of all clusters.
+	MaxGlobalNodeConcurrency int `yaml:"max_global_node_concurrency"`
 }
 
 type ControllerConfig struct {
-	FailOver *FailOverConfig `yaml:"failover"`
+	FailOver     *FailOverConfig     `yaml:"failover"`
+	Migration    *MigrationConfig    `yaml:"migration"`
+	EngineHealth *EngineHealthConfig `yaml:"engine_health"`
+	NodeRetry    *NodeRetryConfig    `yaml:"node_retry"`
+	Resources    *ResourceConfig     `yaml:"resources"`
+	// EnableChaos enables the fault injection API, it should only be used in the staging environment.
+	EnableChaos bool `yaml:"enable_chaos"`
+	// SlowCommandThresholdMs is the threshold to log the slow topology sync and info
+	// commands sent to the nodes, 0 means disabled.
+	SlowCommandThresholdMs int64 `yaml:"slow_command_threshold_ms"`
+	// UpdateCoalesceWindowMs is the window to coalesce the node changes of the same cluster
+	// into a single update, 0 means the changes are updated immediately.
+	UpdateCoalesceWindowMs int64 `yaml:"update_coalesce_window_ms"`
+	// LogThrottleEvery logs the first and every Nth repetitive probe and sync error of each node,
+	// and a summary once the node recovers, default is 10 and 1 means every error is logged.
+	LogThrottleEvery int64 `yaml:"log_throttle_every"`
 }
 
 type LogConfig struct {
@@ -62,14 +216,121 @@ const defaultPort = 9379
 
 type Config struct {
 	Addr        string            `yaml:"addr"`
+	TLS         TLSConfig         `yaml:"tls"`
+	BasicAuth   BasicAuthConfig   `yaml:"basic_auth"`
 	StorageType string            `yaml:"storage_type"`
 	Etcd        *etcd.Config      `yaml:"etcd"`
 	Zookeeper   *zookeeper.Config `yaml:"zookeeper"`
 	Raft        *raft.Config      `yaml:"raft"`
 	Consul      *consul.Config    `yaml:"consul"`
-	Admin       AdminConfig       `yaml:"admin"`
+	Admin       ListenerConfig    `yaml:"admin"`
+	Metrics     ListenerConfig    `yaml:"metrics"`
 	Controller  *ControllerConfig `yaml:"controller"`
 	Log         *LogConfig        `yaml:"log"`
+	// ServeLocalReads serves the GET requests from the local engine on the
+	// follower instead of redirecting them to the leader.
+	ServeLocalReads bool `yaml:"serve_local_reads"`
+	// DisableLeaderForward rejects the write requests on the follower with 503 and the leader
+	// address instead of redirecting them, the read requests are served by the local engine.
+	DisableLeaderForward bool `yaml:"disable_leader_forward"`
+	// MaxStalenessMs rejects the read requests on the follower with 503 if its local engine
+	// is staler than it, 0 means the stale reads are always served.
+	MaxStalenessMs int64 `yaml:"max_staleness_ms"`
+	// Standby runs the controller as the witness which never campaigns for the leadership,
+	// it serves the read requests from the local engine and rejects the write requests.
+	Standby bool `yaml:"standby"`
+	// Zone is the zone of the controller which is registered in the controller session
+	Zone string `yaml:"zone"`
+	// Tokens are the bearer tokens bound to the namespaces, the API requests must be
+	// authenticated by either the token or the basic auth if any token was configured.
+	Tokens []TokenConfig `yaml:"tokens"`
+	// Names are the rules to validate the names of the new namespaces and clusters
+	Names *NameConfig `yaml:"names"`
+	// StaticLabels are the keys of the namespace and cluster labels which are added to the
+	// per-cluster metrics, all labels are added to the events no matter they're listed or not.
+	StaticLabels []string `yaml:"static_labels"`
+	// ClusterCodec is the codec of the cluster documents written to the engine, which is
+	// json or msgpack. The documents encoded by any codec are readable no matter what it is.
+	ClusterCodec string `yaml:"cluster_codec"`
+}
+
+// staticLabelPattern is the valid Prometheus label name
+var staticLabelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedStaticLabels are the label names used by the per-cluster metrics
+var reservedStaticLabels = map[string]bool{
+	"namespace": true, "cluster": true, "node": true, "command": true, "result": true, "status": true,
+}
+
+// redactedSecret replaces the secrets in the redacted config
+const redactedSecret = "******"
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedSecret
+}
+
+// redactURL masks the userinfo and the query values of the URL which may carry the credentials,
+// the whole URL is masked if it can't be parsed.
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redactedSecret
+	}
+	hasUser := u.User != nil
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, url.QueryEscape(key)+"="+redactedSecret)
+		}
+		sort.Strings(keys)
+		u.RawQuery = strings.Join(keys, "&")
+	}
+	if hasUser {
+		// the masked userinfo is prepended as is, or it would be escaped by the URL
+		return strings.Replace(u.String(), "://", "://"+redactedSecret+"@", 1)
+	}
+	return u.String()
+}
+
+// Redacted returns a copy of the config with the passwords and tokens redacted,
+// it's safe to be shared in the support bundle.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.BasicAuth.Password = redact(c.BasicAuth.Password)
+	redacted.Admin.BasicAuth.Password = redact(c.Admin.BasicAuth.Password)
+	redacted.Metrics.BasicAuth.Password = redact(c.Metrics.BasicAuth.Password)
+	if c.Etcd != nil {
+		etcdConfig := *c.Etcd
+		etcdConfig.Password = redact(c.Etcd.Password)
+		redacted.Etcd = &etcdConfig
+	}
+	if c.Zookeeper != nil {
+		zkConfig := *c.Zookeeper
+		zkConfig.Auth = redact(c.Zookeeper.Auth)
+		redacted.Zookeeper = &zkConfig
+	}
+	if len(c.Tokens) > 0 {
+		redacted.Tokens = make([]TokenConfig, len(c.Tokens))
+		for i, token := range c.Tokens {
+			redacted.Tokens[i] = TokenConfig{Token: redact(token.Token), Namespaces: token.Namespaces}
+		}
+	}
+	if c.Controller != nil && c.Controller.FailOver != nil && c.Controller.FailOver.Hook != nil {
+		controllerConfig, failOverConfig, hookConfig := *c.Controller, *c.Controller.FailOver, *c.Controller.FailOver.Hook
+		hookConfig.URL = redactURL(hookConfig.URL)
+		failOverConfig.Hook = &hookConfig
+		controllerConfig.FailOver = &failOverConfig
+		redacted.Controller = &controllerConfig
+	}
+	return &redacted
 }
 
 func DefaultFailOverConfig() *FailOverConfig {
@@ -99,6 +360,123 @@ func (c *Config) Validate() error {
 	if c.Controller.FailOver.PingIntervalSeconds < 1 {
 		return errors.New("ping interval required >= 1s")
 	}
+	if c.Controller.FailOver.ReplicaMaxPingCount != 0 && c.Controller.FailOver.ReplicaMaxPingCount < 3 {
+		return errors.New("replica max ping count required >= 3")
+	}
+	// the replica is removed after it was warned, so the count is validated against the replica threshold
+	replicaMaxPingCount := c.Controller.FailOver.ReplicaMaxPingCount
+	if replicaMaxPingCount == 0 {
+		replicaMaxPingCount = c.Controller.FailOver.MaxPingCount
+	}
+	if c.Controller.FailOver.ReplicaAutoRemoveCount < 0 {
+		return errors.New("replica auto remove count required >= 0")
+	}
+	if c.Controller.FailOver.ReplicaAutoRemoveCount != 0 &&
+		c.Controller.FailOver.ReplicaAutoRemoveCount < replicaMaxPingCount {
+		return errors.New("replica auto remove count required >= replica max ping count")
+	}
+	if c.Controller.FailOver.MaxClusterFailovers < 0 || c.Controller.FailOver.MaxGlobalFailovers < 0 {
+		return errors.New("max failovers required >= 0")
+	}
+	if c.Controller.FailOver.FailoverWindowSeconds < 0 {
+		return errors.New("failover window required >= 0")
+	}
+	if hook := c.Controller.FailOver.Hook; hook != nil {
+		if len(hook.Command) == 0 && hook.URL == "" {
+			return errors.New("command or url of the failover hook is required")
+		}
+		if hook.URL != "" {
+			u, err := url.Parse(hook.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid url of the failover hook: %s", hook.URL)
+			}
+		}
+		if hook.TimeoutMs < 0 || hook.Retries < 0 || hook.RetryBackoffMs < 0 {
+			return errors.New("failover hook timeout, retries and backoff required >= 0")
+		}
+	}
+	if c.Standby && strings.ToLower(c.StorageType) == "raft" {
+		return errors.New("standby mode is not supported by the raft engine")
+	}
+	if c.Controller.SlowCommandThresholdMs < 0 {
+		return errors.New("slow command threshold required >= 0")
+	}
+	if c.Controller.UpdateCoalesceWindowMs < 0 {
+		return errors.New("update coalesce window required >= 0")
+	}
+	if c.Controller.LogThrottleEvery < 0 {
+		return errors.New("log throttle every required >= 0")
+	}
+	if health := c.Controller.EngineHealth; health != nil {
+		if health.ProbeIntervalSeconds < 0 || health.MaxLatencyMs < 0 {
+			return errors.New("engine health probe interval and max latency required >= 0")
+		}
+		if health.MaxErrorRate < 0 || health.MaxErrorRate > 1 {
+			return errors.New("engine health max error rate required between 0 and 1")
+		}
+	}
+	if retry := c.Controller.NodeRetry; retry != nil {
+		if retry.MaxAttempts < 0 || retry.BackoffMs < 0 || retry.BreakerFailures < 0 || retry.BreakerCooldownSeconds < 0 {
+			return errors.New("node retry attempts, backoff and circuit breaker required >= 0")
+		}
+	}
+	if resources := c.Controller.Resources; resources != nil {
+		if resources.MaxClusters < 0 || resources.MaxNodeConcurrency < 0 || resources.MaxGlobalNodeConcurrency < 0 {
+			return errors.New("max clusters and node concurrency required >= 0")
+		}
+	}
+	if c.MaxStalenessMs < 0 {
+		return errors.New("max staleness required >= 0")
+	}
+	if names := c.Names; names != nil {
+		if names.MaxLength < 0 {
+			return errors.New("max length of the names required >= 0")
+		}
+		if _, err := regexp.Compile(names.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern of the names: %w", err)
+		}
+	}
+	switch c.ClusterCodec {
+	case "", "json", "msgpack":
+	default:
+		return fmt.Errorf("unsupported cluster codec %q, it should be json or msgpack", c.ClusterCodec)
+	}
+	staticLabels := make(map[string]bool, len(c.StaticLabels))
+	for _, key := range c.StaticLabels {
+		if !staticLabelPattern.MatchString(key) || strings.HasPrefix(key, "__") {
+			return fmt.Errorf("invalid static label %q", key)
+		}
+		if reservedStaticLabels[key] || staticLabels[key] {
+			return fmt.Errorf("static label %q is reserved or duplicated", key)
+		}
+		staticLabels[key] = true
+	}
+	tokens := make(map[string]bool, len(c.Tokens))
+	for _, token := range c.Tokens {
+		if token.Token == "" || len(token.Namespaces) == 0 {
+			return errors.New("token and namespaces of the api token are required")
+		}
+		if tokens[token.Token] {
+			return errors.New("api token should be unique")
+		}
+		tokens[token.Token] = true
+	}
+	api := ListenerConfig{Addr: c.Addr, TLS: c.TLS, BasicAuth: c.BasicAuth}
+	if err := api.validate("api"); err != nil {
+		return err
+	}
+	if err := c.Admin.validate("admin"); err != nil {
+		return err
+	}
+	if err := c.Metrics.validate("metrics"); err != nil {
+		return err
+	}
+	if c.Admin.Enabled() && c.Admin.Addr == c.Addr {
+		return errors.New("admin addr should be different from the api addr")
+	}
+	if c.Metrics.Enabled() && (c.Metrics.Addr == c.Addr || c.Metrics.Addr == c.Admin.Addr) {
+		return errors.New("metrics addr should be different from the api and admin addr")
+	}
 	hostPort := strings.Split(c.Addr, ":")
 	if hostPort[0] == "0.0.0.0" || hostPort[0] == "127.0.0.1" {
 		logger.Get().Warn("Leader forward may not work if the host is " + hostPort[0])
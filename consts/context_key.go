@@ -24,9 +24,20 @@ const (
 	ContextKeyCluster      = "_context_key_cluster"
 	ContextKeyClusterShard = "_context_key_cluster_shard"
 	ContextKeyRaftNode     = "_context_key_raft_node"
+
+	ContextKeyServeLocalReads = "_context_key_serve_local_reads"
+	ContextKeyLocalRead       = "_context_key_local_read"
+	ContextKeyTokenNamespaces = "_context_key_token_namespaces"
+	ContextKeyMaxStaleness    = "_context_key_max_staleness"
+	ContextKeyCommandLabels   = "_context_key_command_labels"
 )
 
 const (
 	HeaderIsRedirect           = "X-Is-Redirect"
 	HeaderDontCheckClusterMode = "X-Dont-Check-Cluster-Mode"
+	HeaderETag                 = "ETag"
+	HeaderIfMatch              = "If-Match"
+	HeaderLastEventID          = "Last-Event-ID"
+	HeaderConfirmProtected     = "X-Confirm-Protected"
+	HeaderStaleness            = "X-Kvrocks-Controller-Staleness"
 )
@@ -20,13 +20,21 @@
 
 package consts
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidArgument                  = errors.New("invalid argument")
 	ErrNotFound                         = errors.New("not found")
 	ErrForbidden                        = errors.New("forbidden")
+	ErrUnauthorized                     = errors.New("unauthorized")
 	ErrAlreadyExists                    = errors.New("already exists")
+	ErrConflict                         = errors.New("conflict")
+	ErrVersionMismatch                  = errors.New("version mismatch")
+	ErrPreconditionFailed               = errors.New("precondition failed")
+	ErrUnavailable                      = errors.New("unavailable")
 	ErrIndexOutOfRange                  = errors.New("index out of range")
 	ErrShardIsSame                      = errors.New("source and target shard is same")
 	ErrSlotOutOfRange                   = errors.New("slot out of range")
@@ -37,6 +45,90 @@ var (
 	ErrShardNoReplica                   = errors.New("no replica in shard")
 	ErrShardIsServicing                 = errors.New("shard is servicing")
 	ErrShardSlotIsMigrating             = errors.New("shard slot is migrating")
+	ErrShardIsFrozen                    = errors.New("shard is frozen")
 	ErrShardNoMatchNewMaster            = errors.New("no match new master in shard")
 	ErrSlotStartAndStopEqual            = errors.New("start and stop of a range cannot be equal")
 )
+
+// NotFoundError is returned when the resource doesn't exist, it matches ErrNotFound
+type NotFoundError struct {
+	Resource string `json:"resource"`
+	Key      string `json:"key"`
+}
+
+func (err *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s: %s", err.Resource, err.Key, ErrNotFound.Error())
+}
+
+func (err *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (err *NotFoundError) Details() interface{} {
+	return map[string]interface{}{"resource": err.Resource, "key": err.Key}
+}
+
+// VersionMismatchError is returned when the resource has been updated by others,
+// it matches ErrVersionMismatch and carries the current version to retry with.
+type VersionMismatchError struct {
+	Resource       string `json:"resource"`
+	CurrentVersion int64  `json:"current_version"`
+}
+
+func (err *VersionMismatchError) Error() string {
+	return fmt.Sprintf("the %s has been updated by others, current version is %d: %s",
+		err.Resource, err.CurrentVersion, ErrVersionMismatch.Error())
+}
+
+func (err *VersionMismatchError) Is(target error) bool {
+	return target == ErrVersionMismatch
+}
+
+func (err *VersionMismatchError) Details() interface{} {
+	return map[string]interface{}{"resource": err.Resource, "current_version": err.CurrentVersion}
+}
+
+// UnavailableError is returned when the store engine failed to serve the request,
+// it matches ErrUnavailable and wraps the error from the engine.
+type UnavailableError struct {
+	Engine string
+	Err    error
+}
+
+func (err *UnavailableError) Error() string {
+	return fmt.Sprintf("%s engine is %s: %s", err.Engine, ErrUnavailable.Error(), err.Err.Error())
+}
+
+func (err *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+func (err *UnavailableError) Unwrap() error {
+	return err.Err
+}
+
+func (err *UnavailableError) Details() interface{} {
+	return map[string]interface{}{"engine": err.Engine}
+}
+
+// NotLeaderError is returned when the follower refuses to serve the write request,
+// it matches ErrUnavailable and carries the address of the current leader.
+type NotLeaderError struct {
+	Leader string `json:"leader"`
+}
+
+func (err *NotLeaderError) Error() string {
+	if err.Leader == "" {
+		return "no leader now, please retry later"
+	}
+	return fmt.Sprintf("not the leader, please send the request to the leader %s", err.Leader)
+}
+
+func (err *NotLeaderError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// Details marks the refusal by not_leader, so the client can retry the request which wasn't served
+func (err *NotLeaderError) Details() interface{} {
+	return map[string]interface{}{"leader": err.Leader, "not_leader": true}
+}
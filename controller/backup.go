@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// StartBackup starts the backup job of the cluster in background, the shards are backed up
+// in sequence on the replica. It returns the conflict error if the previous job is running.
+func (c *ClusterChecker) StartBackup(ctx context.Context) (*store.Job, error) {
+	if !c.backupRunning.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("%w: the backup job is running", consts.ErrConflict)
+	}
+	cluster, err := c.clusterStore.GetCluster(ctx, c.namespace, c.clusterName)
+	if err != nil {
+		c.backupRunning.Store(false)
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &store.Job{
+		ID:        strconv.FormatInt(now.UnixMilli(), 10),
+		Type:      store.JobTypeBackup,
+		Status:    store.JobStatusRunning,
+		StartedAt: now.Unix(),
+		Tasks:     make([]*store.JobTask, 0, len(cluster.Shards)),
+	}
+	nodes := make([]store.Node, 0, len(cluster.Shards))
+	for i, shard := range cluster.Shards {
+		node := shard.BackupCandidate()
+		if node == nil {
+			continue
+		}
+		nodes = append(nodes, node)
+		job.Tasks = append(job.Tasks, &store.JobTask{
+			ShardIndex: i,
+			NodeID:     node.ID(),
+			Addr:       node.Addr(),
+			Status:     store.JobTaskStatusPending,
+		})
+	}
+	if err := c.clusterStore.SaveJob(ctx, c.namespace, c.clusterName, job); err != nil {
+		c.backupRunning.Store(false)
+		return nil, err
+	}
+
+	// the job will be modified while running, so return a snapshot of it
+	snapshot := *job
+	snapshot.Tasks = make([]*store.JobTask, len(job.Tasks))
+	for i, task := range job.Tasks {
+		taskCopy := *task
+		snapshot.Tasks[i] = &taskCopy
+	}
+	c.goTracked(&c.wg, func() {
+		defer c.backupRunning.Store(false)
+		c.runBackup(c.ctx, job, nodes)
+	})
+	return &snapshot, nil
+}
+
+func (c *ClusterChecker) runBackup(ctx context.Context, job *store.Job, nodes []store.Node) {
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName),
+		zap.String("job_id", job.ID))
+	log.Info("Start to back up the shards")
+
+	for i, task := range job.Tasks {
+		if ctx.Err() != nil {
+			task.Status = store.JobTaskStatusSkipped
+			continue
+		}
+		start := task.Start()
+		nodeCtx, cancel := context.WithTimeout(ctx, store.DefaultBackupNodeTimeout)
+		err := store.BackupNode(nodeCtx, nodes[i], c.options.jobPollInterval)
+		cancel()
+		task.Finish(start, err)
+		if err != nil {
+			log.With(
+				zap.String("node", task.Addr),
+				zap.Error(err),
+			).Warn("Failed to back up the node")
+		}
+	}
+	job.Finish()
+	c.saveFinishedJob(job)
+	if job.Status == store.JobStatusSucceeded {
+		c.lastBackupAt.Store(job.FinishedAt)
+		c.updateBackupAge()
+	}
+	log.With(zap.String("status", job.Status)).Info("Finish backing up the shards")
+}
+
+// loadLastBackup loads the finish time of the last succeeded backup job
+func (c *ClusterChecker) loadLastBackup(ctx context.Context) error {
+	jobs, err := c.clusterStore.ListJobs(ctx, c.namespace, c.clusterName, store.JobTypeBackup)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Status == store.JobStatusSucceeded {
+			c.lastBackupAt.CompareAndSwap(0, job.FinishedAt)
+			break
+		}
+	}
+	return nil
+}
+
+func (c *ClusterChecker) updateBackupAge() {
+	lastBackupAt := c.lastBackupAt.Load()
+	if lastBackupAt <= 0 {
+		return
+	}
+	age := time.Since(time.Unix(lastBackupAt, 0)).Seconds()
+	metrics.Get().LastBackupAge.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName)...).Set(age)
+}
+
+// backupAgeLoop refreshes the age of the last succeeded backup periodically
+func (c *ClusterChecker) backupAgeLoop() {
+	defer metrics.Get().LastBackupAge.DeletePartialMatch(metrics.ClusterLabels(c.namespace, c.clusterName))
+
+	if err := c.loadLastBackup(c.ctx); err != nil {
+		logger.Get().With(
+			zap.String("namespace", c.namespace),
+			zap.String("cluster", c.clusterName),
+			zap.Error(err),
+		).Warn("Failed to load the last backup")
+	}
+	c.updateBackupAge()
+
+	ticker, stopTicker := c.newTicker(c.options.backupAgeInterval)
+	defer stopTicker()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.updateBackupAge()
+		}
+	}
+}
+
+// Backup starts the backup job of the cluster in background
+func (c *Controller) Backup(ctx context.Context, namespace, clusterName string) (*store.Job, error) {
+	cluster, err := c.getCluster(namespace, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.StartBackup(ctx)
+}
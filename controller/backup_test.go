@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestClusterChecker_Backup(t *testing.T) {
+	ctx := context.Background()
+	newNode := func(role string) *store.ClusterMockNode {
+		node := store.NewClusterMockNode()
+		node.SetRole(role)
+		node.Replies = map[string]interface{}{
+			"BGSAVE": "OK",
+			"INFO":   "# Persistence\r\nbgsave_in_progress:0\r\nlast_bgsave_status:ok\r\n",
+		}
+		return node
+	}
+	replica := newNode(store.RoleSlave)
+	master := newNode(store.RoleMaster)
+	cluster := &store.Cluster{
+		Name: "test-cluster",
+		Shards: []*store.Shard{
+			{Nodes: []store.Node{newNode(store.RoleMaster), replica}},
+			{Nodes: []store.Node{master}},
+		},
+	}
+	clusterStore := NewMockClusterStore()
+	require.NoError(t, clusterStore.CreateCluster(ctx, "test-ns", cluster))
+	checker := NewClusterChecker(clusterStore, "test-ns", "test-cluster")
+	checker.options.jobPollInterval = 10 * time.Millisecond
+	defer checker.Close()
+
+	job, err := checker.StartBackup(ctx)
+	require.NoError(t, err)
+	require.Equal(t, store.JobStatusRunning, job.Status)
+	require.Len(t, job.Tasks, 2)
+	// the replica is preferred, and the master is used if there's no replica
+	require.Equal(t, replica.ID(), job.Tasks[0].NodeID)
+	require.Equal(t, master.ID(), job.Tasks[1].NodeID)
+
+	require.Eventually(t, func() bool {
+		jobs, err := clusterStore.ListJobs(ctx, "test-ns", "test-cluster", store.JobTypeBackup)
+		return err == nil && len(jobs) == 1 && jobs[0].Status == store.JobStatusSucceeded
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return !checker.backupRunning.Load()
+	}, time.Second, 10*time.Millisecond)
+	require.Greater(t, checker.lastBackupAt.Load(), int64(0))
+
+	checker.backupRunning.Store(true)
+	_, err = checker.StartBackup(ctx)
+	require.ErrorIs(t, err, consts.ErrConflict)
+	checker.backupRunning.Store(false)
+}
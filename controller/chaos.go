@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"errors"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+var ErrChaosNodeDown = errors.New("the node is down by the chaos fault")
+
+// ChaosFault is injected into the cluster checker to simulate failures, so that
+// operators can rehearse the failover and runbooks against the staging controllers.
+type ChaosFault struct {
+	// DownNodes are the node IDs or addresses which will fail to be probed
+	DownNodes []string `json:"down_nodes"`
+	// StaleNodes are the node IDs or addresses which will report a stale topology version
+	StaleNodes []string `json:"stale_nodes"`
+	// ProbeDelayMs delays each probe to simulate the slow nodes
+	ProbeDelayMs int64 `json:"probe_delay_ms"`
+}
+
+func (f *ChaosFault) Validate() error {
+	if f.ProbeDelayMs < 0 {
+		return errors.New("probe_delay_ms should NOT be negative")
+	}
+	return nil
+}
+
+func matchChaosNode(targets []string, node store.Node) bool {
+	for _, target := range targets {
+		if target == node.ID() || target == node.Addr() {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *ChaosFault) isNodeDown(node store.Node) bool {
+	return matchChaosNode(f.DownNodes, node)
+}
+
+func (f *ChaosFault) isNodeStale(node store.Node) bool {
+	return matchChaosNode(f.StaleNodes, node)
+}
+
+// SetChaosFault injects the fault into the cluster checker, nil clears the fault.
+func (c *ClusterChecker) SetChaosFault(fault *ChaosFault) {
+	c.chaosFault.Store(fault)
+}
+
+func (c *ClusterChecker) ChaosFault() *ChaosFault {
+	return c.chaosFault.Load()
+}
+
+// SetChaosFault injects the fault into the checker of the cluster, nil clears the fault.
+func (c *Controller) SetChaosFault(namespace, clusterName string, fault *ChaosFault) error {
+	cluster, err := c.getCluster(namespace, clusterName)
+	if err != nil {
+		return err
+	}
+	cluster.SetChaosFault(fault)
+	return nil
+}
+
+func (c *Controller) GetChaosFault(namespace, clusterName string) (*ChaosFault, error) {
+	cluster, err := c.getCluster(namespace, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.ChaosFault(), nil
+}
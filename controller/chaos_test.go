@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestClusterChecker_ChaosFault(t *testing.T) {
+	ctx := context.Background()
+	node0 := store.NewClusterMockNode()
+	node1 := store.NewClusterMockNode()
+	checker := NewClusterChecker(NewMockClusterStore(), "test-ns", "test-cluster")
+	defer checker.Close()
+
+	_, err := checker.probeNode(ctx, node0)
+	require.NoError(t, err)
+
+	checker.SetChaosFault(&ChaosFault{DownNodes: []string{node0.ID()}})
+	_, err = checker.probeNode(ctx, node0)
+	require.ErrorIs(t, err, ErrChaosNodeDown)
+	_, err = checker.probeNode(ctx, node1)
+	require.NoError(t, err)
+
+	checker.SetChaosFault(&ChaosFault{ProbeDelayMs: 100})
+	start := time.Now()
+	_, err = checker.probeNode(ctx, node1)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	checker.SetChaosFault(nil)
+	require.Nil(t, checker.ChaosFault())
+	_, err = checker.probeNode(ctx, node0)
+	require.NoError(t, err)
+
+	require.Error(t, (&ChaosFault{ProbeDelayMs: -1}).Validate())
+}
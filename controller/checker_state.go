@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// MigratingShardStatus is the migrating state of the shard observed by the migration loop
+type MigratingShardStatus struct {
+	Shard  int    `json:"shard"`
+	Slot   string `json:"slot"`
+	Target int    `json:"target"`
+	// State is the migrating state reported by the source node, it's empty if the node is unreachable
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// MigrationLoopStatus is the result of the last round of the migration loop
+type MigrationLoopStatus struct {
+	// CheckedAt is the time of the last round in seconds, 0 if the loop never ran
+	CheckedAt int64                  `json:"checked_at"`
+	Shards    []MigratingShardStatus `json:"shards"`
+}
+
+func (status *MigrationLoopStatus) observe(shardIndex int, shard *store.Shard, state string, err error) {
+	shardStatus := MigratingShardStatus{
+		Shard:  shardIndex,
+		Slot:   shard.MigratingSlot.String(),
+		Target: shard.TargetShardIndex,
+		State:  state,
+	}
+	if err != nil {
+		shardStatus.Error = err.Error()
+	}
+	status.Shards = append(status.Shards, shardStatus)
+}
+
+// CheckerState is the runtime state of the cluster checker, it's used to diagnose
+// why the cluster isn't probed, failed over or migrated as expected.
+type CheckerState struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// Version is the version of the last probed cluster, 0 if the cluster was never read
+	Version int64 `json:"version"`
+	// LastProbeAt is the start time of the last probe round in seconds
+	LastProbeAt int64 `json:"last_probe_at"`
+	// NodeProbedAt is the last probe time of each node in seconds
+	NodeProbedAt    map[string]int64    `json:"node_probed_at"`
+	FailureCounts   map[string]int64    `json:"failure_counts"`
+	MaxFailureCount int64               `json:"max_failure_count"`
+	Migration       MigrationLoopStatus `json:"migration"`
+	Resources       ResourceUsage       `json:"resources"`
+}
+
+func (c *ClusterChecker) recordProbe(nodeID string, now time.Time) {
+	c.failureMu.Lock()
+	c.probedAt[nodeID] = now.Unix()
+	c.failureMu.Unlock()
+}
+
+func (c *ClusterChecker) setMigrationStatus(status *MigrationLoopStatus) {
+	c.migrationMu.Lock()
+	c.migrationStatus = *status
+	c.migrationMu.Unlock()
+}
+
+// State returns the snapshot of the runtime state of the checker
+func (c *ClusterChecker) State() CheckerState {
+	state := CheckerState{
+		Namespace:       c.namespace,
+		Cluster:         c.clusterName,
+		LastProbeAt:     c.lastProbeAt.Load(),
+		NodeProbedAt:    make(map[string]int64),
+		FailureCounts:   make(map[string]int64),
+		MaxFailureCount: c.options.maxFailureCount,
+		Resources:       c.ResourceUsage(),
+	}
+	c.clusterMu.Lock()
+	if c.cluster != nil {
+		state.Version = c.cluster.Version.Load()
+	}
+	c.clusterMu.Unlock()
+
+	c.failureMu.Lock()
+	for id, probedAt := range c.probedAt {
+		state.NodeProbedAt[id] = probedAt
+	}
+	for id, count := range c.failureCounts {
+		state.FailureCounts[id] = count
+	}
+	c.failureMu.Unlock()
+
+	c.migrationMu.Lock()
+	state.Migration = c.migrationStatus
+	c.migrationMu.Unlock()
+	if state.Migration.Shards == nil {
+		state.Migration.Shards = make([]MigratingShardStatus, 0)
+	}
+	return state
+}
+
+// CheckerStates returns the runtime state of all active checkers sorted by the namespace and cluster
+func (c *Controller) CheckerStates() []CheckerState {
+	c.mu.Lock()
+	checkers := make([]*ClusterChecker, 0, len(c.clusters))
+	for _, checker := range c.clusters {
+		checkers = append(checkers, checker)
+	}
+	c.mu.Unlock()
+
+	states := make([]CheckerState, 0, len(checkers))
+	for _, checker := range checkers {
+		states = append(states, checker.State())
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Namespace != states[j].Namespace {
+			return states[i].Namespace < states[j].Namespace
+		}
+		return states[i].Cluster < states[j].Cluster
+	})
+	return states
+}
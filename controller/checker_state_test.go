@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestClusterChecker_State(t *testing.T) {
+	master := store.NewClusterMockNode()
+	master.SetRole(store.RoleMaster)
+	replica := store.NewClusterMockNode()
+	replica.SetRole(store.RoleSlave)
+	shard := store.NewShard()
+	shard.Nodes = []store.Node{master, replica}
+	shard.SlotRanges = []store.SlotRange{{Start: 0, Stop: 16383}}
+
+	checker := NewClusterChecker(NewMockClusterStore(), "test-ns", "test-state-cluster")
+	state := checker.State()
+	require.Equal(t, "test-ns", state.Namespace)
+	require.Equal(t, "test-state-cluster", state.Cluster)
+	require.Zero(t, state.Version)
+	require.Zero(t, state.Migration.CheckedAt)
+	require.Empty(t, state.Migration.Shards)
+
+	cluster := &store.Cluster{Name: "test-state-cluster", Shards: []*store.Shard{shard}}
+	cluster.Version.Store(3)
+	checker.cluster = cluster
+	checker.failureCounts[replica.ID()] = 2
+	now := time.Now()
+	checker.recordProbe(master.ID(), now)
+	checker.recordProbe(replica.ID(), now)
+
+	status := MigrationLoopStatus{CheckedAt: now.Unix()}
+	shard.MigratingSlot = store.FromSlotRange(store.SlotRange{Start: 1, Stop: 1})
+	shard.TargetShardIndex = 1
+	status.observe(0, shard, "", errors.New("connection refused"))
+	checker.setMigrationStatus(&status)
+
+	state = checker.State()
+	require.EqualValues(t, 3, state.Version)
+	require.Equal(t, map[string]int64{master.ID(): now.Unix(), replica.ID(): now.Unix()}, state.NodeProbedAt)
+	require.Equal(t, map[string]int64{replica.ID(): 2}, state.FailureCounts)
+	require.Equal(t, now.Unix(), state.Migration.CheckedAt)
+	require.Equal(t, []MigratingShardStatus{{Shard: 0, Slot: "1", Target: 1, Error: "connection refused"}}, state.Migration.Shards)
+
+	// the state is a snapshot which isn't changed by the checker
+	state.FailureCounts[master.ID()] = 1
+	require.NotContains(t, checker.State().FailureCounts, master.ID())
+
+	// the probe times of the removed nodes are swept with the failure counts
+	shard.Nodes = []store.Node{master}
+	checker.sweepFailureCounts()
+	require.Equal(t, map[string]int64{master.ID(): now.Unix()}, checker.State().NodeProbedAt)
+}
@@ -22,24 +22,48 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
+	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/store"
 )
 
 var (
 	ErrClusterNotInitialized = errors.New("CLUSTERDOWN The cluster is not initialized")
 	ErrRestoringBackUp       = errors.New("LOADING kvrocks is restoring the db from backup")
+	ErrReplicationStalled    = errors.New("the replication stream of the master was stalled")
 )
 
 type ClusterCheckOptions struct {
 	pingInterval    time.Duration
+	resolveInterval time.Duration
 	maxFailureCount int64
+
+	replicaMaxFailureCount int64
+	replicaAutoRemoveCount int64
+	maxReplicationStall    time.Duration
+
+	replicaSyncInterval time.Duration
+	replicaSyncMaxLag   int64
+
+	migrationVerifyKeys     int
+	migrationVerifyAttempts int
+
+	maxNodeConcurrency int
+
+	compactionInterval time.Duration
+	backupAgeInterval  time.Duration
+	jobPollInterval    time.Duration
 }
 
 type ClusterChecker struct {
@@ -53,7 +77,48 @@ type ClusterChecker struct {
 
 	failureMu     sync.Mutex
 	failureCounts map[string]int64
-	syncCh        chan struct{}
+	// probedAt is the last probe time of each node in seconds, it's guarded by failureMu
+	probedAt    map[string]int64
+	lastProbeAt atomic.Int64
+	// probeLogs and syncLogs throttle the repetitive probe and sync error logs of the nodes
+	probeLogs *logThrottle
+	syncLogs  *logThrottle
+	// migrationLogs throttles the repetitive failures of migrating the pending slots of each shard
+	migrationLogs *logThrottle
+	// healthCheckLogs throttles the repetitive unknown health check verdicts of the nodes
+	healthCheckLogs *logThrottle
+
+	// migrationUpdateMu serializes the migration updates of the migration loop and the reconciliation,
+	// otherwise both might act on the same migration state, e.g. finish the job twice.
+	migrationUpdateMu sync.Mutex
+	migrationMu       sync.Mutex
+	migrationStatus   MigrationLoopStatus
+	// verifyAttempts is the number of the failed verifications of each migration, it's guarded by migrationMu
+	verifyAttempts map[string]int
+	syncCh         chan struct{}
+
+	chaosFault atomic.Pointer[ChaosFault]
+
+	// highestVersion is the highest cluster version ever seen by the checker, the node-reported
+	// topology lower than it is a replay of the stale topology and won't be adopted.
+	highestVersion atomic.Int64
+
+	// breaker suspends the automatic failover if there're too many failovers, it's shared by all checkers
+	breaker *FailoverBreaker
+	// engineHealth pauses the automatic failover while the metadata store engine is unhealthy
+	engineHealth *EngineHealthChecker
+	// storeAvailability aggregates the failures of reading the clusters, it's shared by all checkers
+	storeAvailability *StoreAvailability
+
+	// nodeLimiter bounds the node goroutines of the cluster, and globalNodeLimiter is shared by all checkers
+	nodeLimiter       nodeLimiter
+	globalNodeLimiter nodeLimiter
+	goroutines        atomic.Int64
+	timers            atomic.Int64
+
+	backupRunning atomic.Bool
+	// lastBackupAt is the finish time of the last succeeded backup job in seconds
+	lastBackupAt atomic.Int64
 
 	ctx      context.Context
 	cancelFn context.CancelFunc
@@ -62,7 +127,7 @@ type ClusterChecker struct {
 }
 
 func NewClusterChecker(s store.Store, ns, cluster string) *ClusterChecker {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(store.WithCommandLabels(context.Background(), ns, cluster))
 	c := &ClusterChecker{
 		namespace:   ns,
 		clusterName: cluster,
@@ -70,10 +135,26 @@ func NewClusterChecker(s store.Store, ns, cluster string) *ClusterChecker {
 		clusterStore: s,
 		options: ClusterCheckOptions{
 			pingInterval:    time.Second * 3,
+			resolveInterval: time.Second * 30,
 			maxFailureCount: 5,
+
+			replicaSyncInterval: 5 * time.Second,
+			replicaSyncMaxLag:   store.DefaultReplicaSyncMaxLag,
+
+			compactionInterval: time.Minute,
+			backupAgeInterval:  30 * time.Second,
+			jobPollInterval:    time.Second,
+
+			migrationVerifyAttempts: defaultMigrationVerifyAttempts,
 		},
-		failureCounts: make(map[string]int64),
-		syncCh:        make(chan struct{}, 1),
+		failureCounts:   make(map[string]int64),
+		probedAt:        make(map[string]int64),
+		probeLogs:       newLogThrottle(defaultLogThrottleEvery),
+		syncLogs:        newLogThrottle(defaultLogThrottleEvery),
+		migrationLogs:   newLogThrottle(defaultLogThrottleEvery),
+		healthCheckLogs: newLogThrottle(defaultLogThrottleEvery),
+		syncCh:          make(chan struct{}, 1),
+		verifyAttempts:  make(map[string]int),
 
 		ctx:      ctx,
 		cancelFn: cancel,
@@ -82,10 +163,12 @@ func NewClusterChecker(s store.Store, ns, cluster string) *ClusterChecker {
 }
 
 func (c *ClusterChecker) Start() {
-	c.wg.Add(1)
-	go c.probeLoop()
-	c.wg.Add(1)
-	go c.migrationLoop()
+	c.goTracked(&c.wg, c.probeLoop)
+	c.goTracked(&c.wg, c.migrationLoop)
+	c.goTracked(&c.wg, c.resolveLoop)
+	c.goTracked(&c.wg, c.compactionLoop)
+	c.goTracked(&c.wg, c.backupAgeLoop)
+	c.goTracked(&c.wg, c.replicaSyncLoop)
 }
 
 func (c *ClusterChecker) WithPingInterval(interval time.Duration) *ClusterChecker {
@@ -96,6 +179,14 @@ func (c *ClusterChecker) WithPingInterval(interval time.Duration) *ClusterChecke
 	return c
 }
 
+func (c *ClusterChecker) WithResolveInterval(interval time.Duration) *ClusterChecker {
+	c.options.resolveInterval = interval
+	if c.options.resolveInterval < time.Second {
+		c.options.resolveInterval = time.Second
+	}
+	return c
+}
+
 func (c *ClusterChecker) WithMaxFailureCount(count int64) *ClusterChecker {
 	c.options.maxFailureCount = count
 	if c.options.maxFailureCount < 1 {
@@ -104,7 +195,99 @@ func (c *ClusterChecker) WithMaxFailureCount(count int64) *ClusterChecker {
 	return c
 }
 
+// WithReplicaMaxFailureCount sets the failure count to warn the failing replica,
+// it's the same as the max failure count if it's less than 1.
+func (c *ClusterChecker) WithReplicaMaxFailureCount(count int64) *ClusterChecker {
+	c.options.replicaMaxFailureCount = count
+	return c
+}
+
+// WithReplicaAutoRemoveCount sets the failure count to remove the failing
+// replica from the cluster, the replica won't be removed if it's less than 1.
+func (c *ClusterChecker) WithReplicaAutoRemoveCount(count int64) *ClusterChecker {
+	c.options.replicaAutoRemoveCount = count
+	return c
+}
+
+// WithMaxReplicationStall sets the max time that none of the replicas received the replication
+// stream before the master is counted as failed, the check is disabled if it's not positive.
+func (c *ClusterChecker) WithMaxReplicationStall(stall time.Duration) *ClusterChecker {
+	c.options.maxReplicationStall = stall
+	return c
+}
+
+// WithMigrationVerifyKeys sets the number of the leftover keys sampled when verifying the migrated slot
+// before committing it, the verification is disabled if it's not positive.
+func (c *ClusterChecker) WithMigrationVerifyKeys(keys int) *ClusterChecker {
+	c.options.migrationVerifyKeys = keys
+	return c
+}
+
+// WithMigrationVerifyAttempts sets the max verifications of the migrated slot before failing the migration,
+// the default attempts are used if it's not positive.
+func (c *ClusterChecker) WithMigrationVerifyAttempts(attempts int) *ClusterChecker {
+	if attempts > 0 {
+		c.options.migrationVerifyAttempts = attempts
+	}
+	return c
+}
+
+// WithLogThrottleEvery logs the first and every Nth repetitive error of each node,
+// the default interval is used if it's not positive.
+func (c *ClusterChecker) WithLogThrottleEvery(every int64) *ClusterChecker {
+	c.probeLogs = newLogThrottle(every)
+	c.syncLogs = newLogThrottle(every)
+	c.migrationLogs = newLogThrottle(every)
+	c.healthCheckLogs = newLogThrottle(every)
+	return c
+}
+
+// WithMaxNodeConcurrency limits the goroutines which probe or sync the nodes of the cluster, 0 means no limit
+func (c *ClusterChecker) WithMaxNodeConcurrency(concurrency int) *ClusterChecker {
+	c.options.maxNodeConcurrency = concurrency
+	c.nodeLimiter = newNodeLimiter(concurrency)
+	return c
+}
+
+// WithGlobalNodeLimiter limits the node goroutines across all checkers which share the limiter
+func (c *ClusterChecker) WithGlobalNodeLimiter(limiter nodeLimiter) *ClusterChecker {
+	c.globalNodeLimiter = limiter
+	return c
+}
+
+// WithFailoverBreaker sets the breaker to limit the automatic failovers
+func (c *ClusterChecker) WithFailoverBreaker(breaker *FailoverBreaker) *ClusterChecker {
+	c.breaker = breaker
+	return c
+}
+
+// WithEngineHealth sets the engine health checker to pause the automatic failover
+func (c *ClusterChecker) WithEngineHealth(engineHealth *EngineHealthChecker) *ClusterChecker {
+	c.engineHealth = engineHealth
+	return c
+}
+
+// WithStoreAvailability sets the store availability to report the failures of reading the cluster
+func (c *ClusterChecker) WithStoreAvailability(availability *StoreAvailability) *ClusterChecker {
+	c.storeAvailability = availability
+	return c
+}
+
 func (c *ClusterChecker) probeNode(ctx context.Context, node store.Node) (int64, error) {
+	fault := c.chaosFault.Load()
+	if fault != nil {
+		if fault.ProbeDelayMs > 0 {
+			select {
+			case <-time.After(time.Duration(fault.ProbeDelayMs) * time.Millisecond):
+			case <-ctx.Done():
+				return -1, ctx.Err()
+			}
+		}
+		if fault.isNodeDown(node) {
+			return -1, ErrChaosNodeDown
+		}
+	}
+
 	clusterInfo, err := node.GetClusterInfo(ctx)
 	if err != nil {
 		// We need to use the string contains to check the error message
@@ -119,6 +302,9 @@ func (c *ClusterChecker) probeNode(ctx context.Context, node store.Node) (int64,
 			return -1, err
 		}
 	}
+	if fault != nil && fault.isNodeStale(node) && clusterInfo.CurrentEpoch > 0 {
+		return clusterInfo.CurrentEpoch - 1, nil
+	}
 	return clusterInfo.CurrentEpoch, nil
 }
 
@@ -132,43 +318,176 @@ func (c *ClusterChecker) increaseFailureCount(shardIndex int, node store.Node) i
 	count := c.failureCounts[id]
 	c.failureMu.Unlock()
 
+	log := logger.Get().With(
+		zap.String("id", node.ID()),
+		zap.Bool("is_master", node.IsMaster()),
+		zap.String("addr", node.Addr()))
 	// don't add the node into the failover candidates if it's not a master node
 	if !node.IsMaster() {
+		c.handleReplicaFailure(log, shardIndex, node, count)
 		return count
 	}
 
-	log := logger.Get().With(
-		zap.String("id", node.ID()),
-		zap.Bool("is_master", node.IsMaster()),
-		zap.String("addr", node.Addr()))
 	if count%c.options.maxFailureCount == 0 {
+		if c.engineHealth != nil {
+			if err := c.engineHealth.AllowFailover(); err != nil {
+				c.recordFailoverDecision("paused")
+				log.Error("Skip promoting the new master", zap.Error(err))
+				return count
+			}
+		}
+		if c.breaker != nil {
+			if err := c.breaker.Allow(c.namespace, c.clusterName); err != nil {
+				c.recordFailoverDecision("suspended")
+				log.Error("Skip promoting the new master", zap.Error(err))
+				return count
+			}
+		}
 		cluster, err := c.clusterStore.GetCluster(c.ctx, c.namespace, c.clusterName)
 		if err != nil {
 			log.Error("Failed to get the clusterName info", zap.Error(err))
 			return count
 		}
-		newMasterID, err := cluster.PromoteNewMaster(c.ctx, shardIndex, node.ID(), "")
+		if shardIndex < len(cluster.Shards) && cluster.Shards[shardIndex].Frozen {
+			c.recordFailoverDecision("frozen")
+			log.Warn("Skip promoting the new master since the shard is frozen")
+			return count
+		}
+		var newMasterID string
+		updatedCluster, err := c.updateClusterWithRetry(c.ctx, cluster, func(clone *store.Cluster) error {
+			var err error
+			newMasterID, err = clone.PromoteNewMaster(c.ctx, shardIndex, node.ID(), "")
+			return err
+		})
 		if err == nil {
 			// the node is normal if it can be elected as the new master,
 			// because it requires the node is healthy.
 			c.resetFailureCount(newMasterID)
-			err = c.clusterStore.UpdateCluster(c.ctx, c.namespace, cluster)
 		}
 		if err != nil {
+			c.recordFailoverDecision("failed")
 			log.Error("Failed to promote the new master", zap.Error(err))
 		} else {
+			c.recordFailoverDecision("promoted")
 			log.With(zap.String("new_master_id", newMasterID)).Info("Promote the new master")
+			if c.breaker != nil {
+				c.breaker.Record(c.namespace, c.clusterName, time.Now())
+			}
+			// the hook is bounded by its own timeout and shouldn't be canceled with the checker,
+			// since the clients still need to be redirected to the new master.
+			go c.clusterStore.RunFailoverHook(context.Background(), c.namespace, updatedCluster,
+				shardIndex, node.ID(), newMasterID, store.FailoverTriggerAuto)
 		}
 	}
 	return count
 }
 
+// healthCheckFailure is the master which failed the custom health check in the probe round
+type healthCheckFailure struct {
+	shardIndex int
+	node       store.Node
+	err        error
+}
+
+// countHealthCheckFailures counts the health check failures of the masters as the probe failures, the
+// failures are ignored if more masters than the max failovers of the health check failed at the same
+// time, since the broken check shouldn't be able to fail over the whole cluster.
+func (c *ClusterChecker) countHealthCheckFailures(cluster *store.Cluster, failures []healthCheckFailure) {
+	if len(failures) == 0 {
+		return
+	}
+	if maxFailovers := cluster.HealthCheck.GetMaxFailovers(); len(failures) > maxFailovers {
+		logger.Get().With(
+			zap.String("namespace", c.namespace),
+			zap.String("cluster", c.clusterName),
+			zap.Int("failed_masters", len(failures)),
+			zap.Int("max_failovers", maxFailovers),
+		).Warn("Ignore the health check failures since too many masters failed", zap.Error(failures[0].err))
+		return
+	}
+	for _, failure := range failures {
+		failureCount := c.increaseFailureCount(failure.shardIndex, failure.node)
+		log := logger.Get().With(
+			zap.String("id", failure.node.ID()),
+			zap.Bool("is_master", true),
+			zap.String("addr", failure.node.Addr()),
+			zap.Int64("failure_count", failureCount))
+		c.probeLogs.Log(log, zapcore.WarnLevel, failure.node.ID(), failure.err, "Failed to probe the node")
+	}
+}
+
+func (c *ClusterChecker) handleReplicaFailure(log *zap.Logger, shardIndex int, node store.Node, count int64) {
+	maxFailureCount := c.options.replicaMaxFailureCount
+	if maxFailureCount < 1 {
+		maxFailureCount = c.options.maxFailureCount
+	}
+	if count%maxFailureCount == 0 {
+		log.With(zap.Int64("failure_count", count)).Warn("The replica keeps failing")
+	}
+	if c.options.replicaAutoRemoveCount < 1 || count < c.options.replicaAutoRemoveCount {
+		return
+	}
+
+	cluster, err := c.clusterStore.GetCluster(c.ctx, c.namespace, c.clusterName)
+	if err != nil {
+		log.Error("Failed to get the cluster info", zap.Error(err))
+		return
+	}
+	if shardIndex < len(cluster.Shards) && cluster.Shards[shardIndex].Frozen {
+		log.Warn("Skip removing the failing replica since the shard is frozen")
+		return
+	}
+	_, err = c.updateClusterWithRetry(c.ctx, cluster, func(clone *store.Cluster) error {
+		return clone.RemoveNode(shardIndex, node.ID())
+	})
+	if err != nil {
+		log.Error("Failed to remove the failing replica", zap.Error(err))
+		return
+	}
+	c.resetFailureCount(node.ID())
+	log.With(zap.Int64("failure_count", count)).Warn("Remove the failing replica from the cluster")
+}
+
 func (c *ClusterChecker) resetFailureCount(nodeID string) {
 	c.failureMu.Lock()
 	delete(c.failureCounts, nodeID)
 	c.failureMu.Unlock()
 }
 
+// sweepFailureCounts removes the failure counts of the nodes which are not in the cluster
+// anymore, and returns the number of the remaining failure counts.
+func (c *ClusterChecker) sweepFailureCounts() int {
+	c.clusterMu.Lock()
+	cluster := c.cluster
+	c.clusterMu.Unlock()
+
+	c.failureMu.Lock()
+	defer c.failureMu.Unlock()
+	if cluster == nil {
+		return len(c.failureCounts)
+	}
+	nodeIDs := make(map[string]bool)
+	for _, node := range cluster.GetNodes() {
+		nodeIDs[node.ID()] = true
+	}
+	for id := range c.failureCounts {
+		if !nodeIDs[id] {
+			delete(c.failureCounts, id)
+		}
+	}
+	for id := range c.probedAt {
+		if !nodeIDs[id] {
+			delete(c.probedAt, id)
+		}
+	}
+	keep := func(id string) bool { return nodeIDs[id] }
+	c.probeLogs.Sweep(keep)
+	c.syncLogs.Sweep(keep)
+	c.migrationLogs.Sweep(keep)
+	c.healthCheckLogs.Sweep(keep)
+	return len(c.failureCounts)
+}
+
 func (c *ClusterChecker) sendSyncEvent() {
 	select {
 	case c.syncCh <- struct{}{}:
@@ -185,7 +504,11 @@ func (c *ClusterChecker) syncClusterToNodes(ctx context.Context) error {
 	version := clusterInfo.Version.Load()
 	for _, shard := range clusterInfo.Shards {
 		for _, node := range shard.Nodes {
-			go func(n store.Node) {
+			if node.IsRestoring() {
+				continue
+			}
+			n := node
+			c.goNode(ctx, nil, func() {
 				log := logger.Get().With(
 					zap.String("namespace", c.namespace),
 					zap.String("cluster", c.clusterName),
@@ -194,33 +517,62 @@ func (c *ClusterChecker) syncClusterToNodes(ctx context.Context) error {
 					zap.String("addr", n.Addr()))
 				// sync the clusterName to the latest version
 				if err := n.SyncClusterInfo(ctx, clusterInfo); err != nil {
-					log.Error("Failed to sync the cluster topology to the node", zap.Error(err))
+					c.syncLogs.Log(log, zapcore.ErrorLevel, n.ID(), err, "Failed to sync the cluster topology to the node")
 				} else {
+					c.syncLogs.LogRecovery(log, n.ID(), "The node recovered from the sync failures")
 					log.Info("Succeed to sync the cluster topology to the node")
 				}
-			}(node)
+			})
 		}
 	}
 	return nil
 }
 
 func (c *ClusterChecker) parallelProbeNodes(ctx context.Context, cluster *store.Cluster) {
+	c.lastProbeAt.Store(time.Now().Unix())
 	var mu sync.Mutex
 	var latestNodeVersion int64 = 0
 	var latestClusterNodesStr string
+	var healthCheckFailures []healthCheckFailure
 	var wg sync.WaitGroup
 
 	for i, shard := range cluster.Shards {
 		for _, node := range shard.Nodes {
-			wg.Add(1)
-			go func(shardIdx int, n store.Node) {
-				defer wg.Done()
+			shardIdx, n := i, node
+			c.goNode(ctx, &wg, func() {
 				log := logger.Get().With(
 					zap.String("id", n.ID()),
 					zap.Bool("is_master", n.IsMaster()),
 					zap.String("addr", n.Addr()),
 				)
+				// the restoring node is marked by the operator, don't rely on detecting
+				// the error message which may vary across the kvrocks versions.
+				if n.IsRestoring() {
+					log.Debug("Skip probing the node which is restoring from backup")
+					return
+				}
 				version, err := c.probeNode(ctx, n)
+				c.recordProbe(n.ID(), time.Now())
+				if err == nil && !cluster.HealthCheck.IsEmpty() {
+					// the custom health check failure is counted as the probe failure
+					err = cluster.HealthCheck.Check(ctx, cluster.Name, n)
+					if errors.Is(err, store.ErrHealthCheckUnknown) {
+						c.healthCheckLogs.Log(log, zapcore.WarnLevel, n.ID(), err, "Ignore the unknown health check verdict")
+						err = nil
+					} else {
+						c.healthCheckLogs.LogRecovery(log, n.ID(), "The health check of the node recovered from the unknown verdicts")
+					}
+					if err != nil && n.IsMaster() {
+						// the failed masters are counted after all nodes were checked to cap the failovers
+						mu.Lock()
+						healthCheckFailures = append(healthCheckFailures, healthCheckFailure{shardIdx, n, err})
+						mu.Unlock()
+						return
+					}
+				}
+				if err == nil && n.IsMaster() && c.options.maxReplicationStall > 0 {
+					err = c.checkReplicationStall(ctx, cluster.Shards[shardIdx])
+				}
 				// Don't sync the cluster info to the node if it is restoring the db from backup
 				if errors.Is(err, ErrRestoringBackUp) {
 					log.Error("The node is restoring the db from backup")
@@ -228,9 +580,12 @@ func (c *ClusterChecker) parallelProbeNodes(ctx context.Context, cluster *store.
 				}
 				if err != nil && !errors.Is(err, ErrClusterNotInitialized) {
 					failureCount := c.increaseFailureCount(shardIdx, n)
-					log.With(zap.Error(err),
-						zap.Int64("failure_count", failureCount),
-					).Warn("Failed to probe the node")
+					level := zapcore.WarnLevel
+					if !n.IsMaster() {
+						// the failing replica will be warned by the replica failure count
+						level = zapcore.DebugLevel
+					}
+					c.probeLogs.Log(log.With(zap.Int64("failure_count", failureCount)), level, n.ID(), err, "Failed to probe the node")
 					return
 				}
 				log.Debug("Probe the clusterName node")
@@ -239,7 +594,9 @@ func (c *ClusterChecker) parallelProbeNodes(ctx context.Context, cluster *store.
 				if version < clusterVersion {
 					// sync the clusterName to the latest version
 					if err := n.SyncClusterInfo(ctx, cluster); err != nil {
-						log.With(zap.Error(err)).Error("Failed to sync the clusterName info")
+						c.syncLogs.Log(log, zapcore.ErrorLevel, n.ID(), err, "Failed to sync the clusterName info")
+					} else {
+						c.syncLogs.LogRecovery(log, n.ID(), "The node recovered from the sync failures")
 					}
 				} else if version > clusterVersion {
 					log.With(
@@ -260,20 +617,31 @@ func (c *ClusterChecker) parallelProbeNodes(ctx context.Context, cluster *store.
 					}
 					mu.Unlock()
 				}
+				c.probeLogs.LogRecovery(log, n.ID(), "The node recovered from the probe failures")
 				c.resetFailureCount(n.ID())
-			}(i, node)
+			})
 		}
 	}
 
 	wg.Wait()
+	c.countHealthCheckFailures(cluster, healthCheckFailures)
+	c.observeDetections()
 	if latestNodeVersion > cluster.Version.Load() && latestClusterNodesStr != "" {
 		latestClusterInfo, err := store.ParseCluster(latestClusterNodesStr)
 		if err != nil {
 			logger.Get().With(zap.String("cluster", latestClusterNodesStr), zap.Error(err)).Error("Failed to parse the cluster info")
 			return
 		}
-		latestClusterInfo.Name = cluster.Name
-		latestClusterInfo.SetPassword(cluster.Shards[0].Nodes[0].Password())
+		if highestVersion := c.highestVersion.Load(); latestClusterInfo.Version.Load() < highestVersion {
+			logger.Get().With(
+				zap.String("namespace", c.namespace),
+				zap.String("cluster", c.clusterName),
+				zap.Int64("version", latestClusterInfo.Version.Load()),
+				zap.Int64("highest_version", highestVersion),
+			).Error("Reject the node-reported topology since its version regressed")
+			return
+		}
+		latestClusterInfo.InheritClusterStates(cluster)
 		err = c.clusterStore.SetCluster(ctx, c.namespace, latestClusterInfo)
 		if err != nil {
 			logger.Get().With(zap.String("cluster", latestClusterNodesStr), zap.Error(err)).Error("Failed to update the cluster info")
@@ -283,27 +651,64 @@ func (c *ClusterChecker) parallelProbeNodes(ctx context.Context, cluster *store.
 	}
 }
 
+// observeVersion raises the highest version ever seen by the checker
+func (c *ClusterChecker) observeVersion(version int64) {
+	for {
+		highestVersion := c.highestVersion.Load()
+		if version <= highestVersion || c.highestVersion.CompareAndSwap(highestVersion, version) {
+			return
+		}
+	}
+}
+
 func (c *ClusterChecker) probeLoop() {
-	defer c.wg.Done()
+	defer c.clearDetectionMetrics()
+	defer c.clearResourceMetrics()
 	log := logger.Get().With(
 		zap.String("namespace", c.namespace),
 		zap.String("clusterName", c.clusterName),
 	)
 
-	probeTicker := time.NewTicker(c.options.pingInterval)
-	defer probeTicker.Stop()
+	probeTicker, stopTicker := c.newTicker(c.options.pingInterval)
+	defer stopTicker()
+	var backoff storeBackoff
 	for {
 		select {
 		case <-probeTicker.C:
+			if backoff.wait(time.Now(), c.storeAvailability) {
+				break
+			}
 			clusterInfo, err := c.clusterStore.GetCluster(c.ctx, c.namespace, c.clusterName)
 			if err != nil {
-				log.Error("Failed to get the clusterName info from the clusterStore", zap.Error(err))
+				if !isStoreUnavailable(err) {
+					log.Error("Failed to get the clusterName info from the clusterStore", zap.Error(err))
+					break
+				}
+				delay := backoff.fail(time.Now(), c.options.pingInterval)
+				if c.storeAvailability != nil {
+					c.storeAvailability.ReportFailure(backoff.failures, err)
+				}
+				// the outage is reported by the store availability, so only the first failure is logged as error
+				failureLog := log.With(zap.Int("failures", backoff.failures), zap.Duration("backoff", delay), zap.Error(err))
+				if backoff.failures == 1 {
+					failureLog.Error("Failed to get the clusterName info from the clusterStore")
+				} else {
+					failureLog.Debug("Failed to get the clusterName info from the clusterStore")
+				}
 				break
 			}
+			if failures := backoff.reset(); failures > 0 {
+				log.Info("Resume probing the cluster after reading it from the clusterStore", zap.Int("failures", failures))
+			}
+			if c.storeAvailability != nil {
+				c.storeAvailability.ReportSuccess()
+			}
 			c.clusterMu.Lock()
 			c.cluster = clusterInfo
 			c.clusterMu.Unlock()
+			c.observeVersion(clusterInfo.Version.Load())
 			c.parallelProbeNodes(c.ctx, clusterInfo)
+			c.observeResources()
 		case <-c.syncCh:
 			if err := c.syncClusterToNodes(c.ctx); err != nil {
 				log.Error("Failed to sync the clusterName to the nodes", zap.Error(err))
@@ -314,30 +719,87 @@ func (c *ClusterChecker) probeLoop() {
 	}
 }
 
+// resolveLoop re-resolves the nodes which were added by hostnames, and updates
+// the node addresses in the stored topology when the backends move.
+func (c *ClusterChecker) resolveLoop() {
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName),
+	)
+
+	ticker, stopTicker := c.newTicker(c.options.resolveInterval)
+	defer stopTicker()
+	for {
+		select {
+		case <-ticker.C:
+			cluster, err := c.clusterStore.GetCluster(c.ctx, c.namespace, c.clusterName)
+			if err != nil {
+				log.Error("Failed to get the cluster info from the clusterStore", zap.Error(err))
+				break
+			}
+			// the nodes are re-resolved on the latest cluster if it was updated by others concurrently
+			_, err = c.updateClusterWithRetry(c.ctx, cluster, func(clone *store.Cluster) error {
+				changed, err := clone.RefreshAddrs(c.ctx)
+				if err != nil {
+					log.Warn("Failed to re-resolve the node addresses", zap.Error(err))
+				}
+				if !changed {
+					return errAddrsUnchanged
+				}
+				return nil
+			})
+			if errors.Is(err, errAddrsUnchanged) {
+				break
+			} else if err != nil {
+				log.Error("Failed to update the node addresses", zap.Error(err))
+				break
+			}
+			log.Info("Updated the node addresses after re-resolving")
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
 func (c *ClusterChecker) updateCluster(cluster *store.Cluster) {
 	c.clusterMu.Lock()
 	c.cluster = cluster
 	c.clusterMu.Unlock()
+	c.observeVersion(cluster.Version.Load())
 }
 
-func (c *ClusterChecker) tryUpdateMigrationStatus(ctx context.Context, clonedCluster *store.Cluster) {
+// tryUpdateMigrationStatus updates the migrating shards by the state of the source nodes. The cluster
+// is shared with other goroutines, so it's never changed in place but replaced by the updated copy.
+func (c *ClusterChecker) tryUpdateMigrationStatus(ctx context.Context, cluster *store.Cluster) {
 	log := logger.Get().With(
 		zap.String("namespace", c.namespace),
 		zap.String("cluster", c.clusterName))
 
-	for i, shard := range clonedCluster.Shards {
+	status := MigrationLoopStatus{CheckedAt: time.Now().Unix(), Shards: make([]MigratingShardStatus, 0)}
+	defer c.setMigrationStatus(&status)
+	defer func() {
+		c.pruneVerifyAttempts(cluster)
+	}()
+	// the cluster might be replaced by the re-read one with fewer shards after retrying the update
+	for i := 0; i < len(cluster.Shards); i++ {
+		shard := cluster.Shards[i]
 		if !shard.IsMigrating() {
+			if shard.HasPendingSlots() {
+				cluster = c.tryMigratePendingSlots(ctx, cluster, i)
+			}
 			continue
 		}
 		sourceNode := shard.GetMasterNode()
 		sourceNodeClusterInfo, err := sourceNode.GetClusterInfo(ctx)
 		if err != nil {
+			status.observe(i, shard, "", err)
 			log.With(
 				zap.Int("shard_index", i),
 				zap.String("source_node", sourceNode.ID()),
 			).Error("Failed to get the cluster info from the source node", zap.Error(err))
 			continue
 		}
+		status.observe(i, shard, sourceNodeClusterInfo.MigratingState, nil)
 		if !sourceNodeClusterInfo.MigratingSlot.Equal(shard.MigratingSlot.SlotRange) {
 			log.Error("Mismatch migrating slot",
 				zap.Int("shard_index", i),
@@ -346,70 +808,250 @@ func (c *ClusterChecker) tryUpdateMigrationStatus(ctx context.Context, clonedClu
 			)
 			continue
 		}
-		if shard.TargetShardIndex < 0 || shard.TargetShardIndex >= len(clonedCluster.Shards) {
+		if shard.TargetShardIndex < 0 || shard.TargetShardIndex >= len(cluster.Shards) {
 			log.Error("Invalid target shard index", zap.Int("index", shard.TargetShardIndex))
 			return
 		}
+		// the migration of the frozen shard will be resumed after it's unfrozen
+		if shard.Frozen || cluster.Shards[shard.TargetShardIndex].Frozen {
+			continue
+		}
 
+		migrationID := shard.MigrationID
 		switch sourceNodeClusterInfo.MigratingState {
-		case "none", "start":
+		case "none":
+			continue
+		case "start":
+			c.updateMigrationJob(ctx, migrationID, func(job *store.Job) bool {
+				if job.Status != store.JobStatusQueued {
+					return false
+				}
+				job.Status = store.JobStatusRunning
+				return true
+			})
 			continue
 		case "fail":
 			migratingSlot := shard.MigratingSlot
-			clonedCluster.Shards[i].ClearMigrateState()
-			if err := c.clusterStore.UpdateCluster(ctx, c.namespace, clonedCluster); err != nil {
+			updatedCluster, err := c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+				return clearMigration(clone, i, migrationID, migratingSlot)
+			})
+			if err != nil {
 				log.Error("Failed to update the cluster", zap.Error(err))
 				return
 			}
-			c.updateCluster(clonedCluster)
+			cluster = updatedCluster
+			c.finishMigrationJob(ctx, migrationID, "the source node failed to migrate the slot")
 			log.Warn("Failed to migrate the slot", zap.String("slot", migratingSlot.String()))
 		case "success":
-			clonedCluster.Shards[i].SlotRanges = store.RemoveSlotFromSlotRanges(clonedCluster.Shards[i].SlotRanges, shard.MigratingSlot.SlotRange)
-			clonedCluster.Shards[shard.TargetShardIndex].SlotRanges = store.AddSlotToSlotRanges(
-				clonedCluster.Shards[shard.TargetShardIndex].SlotRanges, shard.MigratingSlot.SlotRange,
-			)
+			if c.options.migrationVerifyKeys > 0 {
+				passed, verifyErr := c.verifyMigratedSlot(ctx, cluster, i)
+				if verifyErr != nil {
+					// keep the slot in the source shard which still has the keys of it
+					migratingSlot := shard.MigratingSlot
+					updatedCluster, err := c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+						return clearMigration(clone, i, migrationID, migratingSlot)
+					})
+					if err != nil {
+						log.Error("Failed to update the cluster", zap.Error(err))
+						return
+					}
+					cluster = updatedCluster
+					c.finishMigrationJob(ctx, migrationID, verifyErr.Error())
+					continue
+				}
+				if !passed {
+					continue
+				}
+			}
 			migratedSlot := shard.MigratingSlot
-			clonedCluster.Shards[i].ClearMigrateState()
-			if err := c.clusterStore.UpdateCluster(ctx, c.namespace, clonedCluster); err != nil {
+			targetShardIndex := shard.TargetShardIndex
+			updatedCluster, err := c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+				if err := checkMigration(clone, i, migrationID, migratedSlot); err != nil {
+					return err
+				}
+				clone.MoveSlotToShard(migratedSlot.SlotRange, targetShardIndex)
+				// the pending slots of splitting or merging shards are kept to be migrated next
+				clone.Shards[i].ClearMigratingSlot()
+				return nil
+			})
+			if err != nil {
 				log.Error("Failed to update the cluster", zap.Error(err))
 				return
-			} else {
-				log.Info("Migrate the slot successfully", zap.String("slot", migratedSlot.String()))
 			}
-			c.updateCluster(clonedCluster)
+			log.Info("Migrate the slot successfully", zap.String("slot", migratedSlot.String()))
+			cluster = updatedCluster
+			reason := ""
+			if cluster.Shards[i].HasPendingSlots() {
+				updatedCluster, err := c.migratePendingSlots(ctx, cluster, i)
+				if err != nil {
+					// the pending slots are kept and retried in the next round
+					reason = "failed to migrate the pending slots: " + err.Error()
+					log.Error("Failed to migrate the pending slots",
+						zap.String("slot", cluster.Shards[i].PendingSlots[0].String()), zap.Error(err))
+				} else {
+					cluster = updatedCluster
+				}
+			}
+			c.finishMigrationJob(ctx, migrationID, reason)
 		default:
-			clonedCluster.Shards[i].ClearMigrateState()
-			if err := c.clusterStore.UpdateCluster(ctx, c.namespace, clonedCluster); err != nil {
+			migratingSlot := shard.MigratingSlot
+			updatedCluster, err := c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+				return clearMigration(clone, i, migrationID, migratingSlot)
+			})
+			if err != nil {
 				log.Error("Failed to update the cluster", zap.Error(err))
 				return
 			}
-			c.updateCluster(clonedCluster)
+			cluster = updatedCluster
+			c.finishMigrationJob(ctx, migrationID, "unknown migrating state: "+sourceNodeClusterInfo.MigratingState)
 			log.Error("Unknown migrating state", zap.String("state", sourceNodeClusterInfo.MigratingState))
 		}
 	}
 }
 
-func (c *ClusterChecker) migrationLoop() {
-	defer c.wg.Done()
+// tryMigratePendingSlots resumes migrating the pending slots of the shard which isn't migrating,
+// e.g. the split or merge was just persisted or the last attempt failed.
+func (c *ClusterChecker) tryMigratePendingSlots(ctx context.Context, cluster *store.Cluster, shardIndex int) *store.Cluster {
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName),
+		zap.Int("shard_index", shardIndex))
+	// the logs are keyed by the source master to be swept with the removed nodes
+	key := ""
+	if master := cluster.Shards[shardIndex].GetMasterNode(); master != nil {
+		key = master.ID()
+	}
+	updatedCluster, err := c.migratePendingSlots(ctx, cluster, shardIndex)
+	if err != nil {
+		c.migrationLogs.Log(log, zapcore.ErrorLevel, key, err, "Failed to migrate the pending slots")
+		return cluster
+	}
+	c.migrationLogs.LogRecovery(log, key, "Succeed to migrate the pending slots after the failures")
+	return updatedCluster
+}
 
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
+// migratePendingSlots starts migrating the next pending slot range of the shard. The persisted topology
+// is synced to the nodes first so the source node knows the target shard, and the started migration is
+// persisted without being sent again if the update is retried.
+func (c *ClusterChecker) migratePendingSlots(ctx context.Context, cluster *store.Cluster, shardIndex int) (*store.Cluster, error) {
+	shard := cluster.Shards[shardIndex]
+	if shard.TargetShardIndex >= len(cluster.Shards) {
+		return nil, fmt.Errorf("%w: invalid target shard index %d", consts.ErrIndexOutOfRange, shard.TargetShardIndex)
+	}
+	// the pending slots of the frozen shard will be migrated after it's unfrozen
+	if shard.Frozen || cluster.Shards[shard.TargetShardIndex].Frozen {
+		return cluster, nil
+	}
+	if err := cluster.SyncToNodes(ctx); err != nil {
+		return nil, err
+	}
+	pendingSlots := shard.PendingSlots
+	startedCluster, err := cluster.WithUpdate(func(clone *store.Cluster) error {
+		return clone.MigratePendingSlots(ctx, shardIndex)
+	})
+	if err != nil {
+		return nil, err
+	}
+	started := startedCluster.Shards[shardIndex]
+	updatedCluster, err := c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+		if shardIndex >= len(clone.Shards) {
+			return errMigrationChanged
+		}
+		shard := clone.Shards[shardIndex]
+		if shard.IsMigrating() || !slices.Equal(shard.PendingSlots, pendingSlots) {
+			return errMigrationChanged
+		}
+		shard.MigratingSlot = started.MigratingSlot
+		shard.TargetShardIndex = started.TargetShardIndex
+		shard.MigrationID = started.MigrationID
+		shard.PendingSlots = started.PendingSlots
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if job := updatedCluster.NewMigrationJob(shardIndex); job != nil {
+		c.saveMigrationJob(ctx, job)
+	}
+	return updatedCluster, nil
+}
+
+// clearMigration clears the migrating state of the shard if it's still migrating the slot
+func clearMigration(cluster *store.Cluster, shardIndex int, migrationID string, slot *store.MigratingSlot) error {
+	if err := checkMigration(cluster, shardIndex, migrationID, slot); err != nil {
+		return err
+	}
+	cluster.Shards[shardIndex].ClearMigrateState()
+	return nil
+}
+
+// updateMigrationJob changes the migration job by UpdateJob under the job lock, and returns whether the job
+// was saved. The migration without the ID has no job since it was started before the jobs were recorded.
+func (c *ClusterChecker) updateMigrationJob(ctx context.Context, id string, update func(job *store.Job) bool) bool {
+	if id == "" {
+		return false
+	}
+	updated := false
+	_, err := c.clusterStore.UpdateJob(ctx, c.namespace, c.clusterName, store.JobTypeMigration, id, func(job *store.Job) bool {
+		updated = update(job)
+		return updated
+	})
+	if err != nil {
+		logger.Get().With(
+			zap.String("namespace", c.namespace),
+			zap.String("cluster", c.clusterName),
+			zap.String("id", id),
+		).Warn("Failed to update the migration job", zap.Error(err))
+		return false
+	}
+	return updated
+}
+
+func (c *ClusterChecker) finishMigrationJob(ctx context.Context, id, reason string) {
+	// the job might be cancelled by the user, which shouldn't be overwritten
+	finished := c.updateMigrationJob(ctx, id, func(job *store.Job) bool {
+		if job.IsFinished() {
+			return false
+		}
+		job.FinishMigration(reason)
+		return true
+	})
+	if !finished {
+		return
+	}
+	status := store.JobStatusSucceeded
+	if reason != "" {
+		status = store.JobStatusFailed
+	}
+	metrics.Get().FinishedMigrations.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName, status)...).Inc()
+}
+
+func (c *ClusterChecker) saveMigrationJob(ctx context.Context, job *store.Job) {
+	if err := c.clusterStore.SaveJob(ctx, c.namespace, c.clusterName, job); err != nil {
+		logger.Get().With(
+			zap.String("namespace", c.namespace),
+			zap.String("cluster", c.clusterName),
+			zap.String("id", job.ID),
+		).Warn("Failed to save the migration job", zap.Error(err))
+	}
+}
+
+func (c *ClusterChecker) migrationLoop() {
+	ticker, stopTicker := c.newTicker(time.Second)
+	defer stopTicker()
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
 		case <-ticker.C:
+			c.migrationUpdateMu.Lock()
 			c.clusterMu.Lock()
-			if c.cluster == nil {
-				c.clusterMu.Unlock()
-				continue
-			}
-			clonedCluster := c.cluster.Clone()
+			cluster := c.cluster
 			c.clusterMu.Unlock()
-			if clonedCluster == nil {
-				continue
+			if cluster != nil {
+				c.tryUpdateMigrationStatus(c.ctx, cluster)
 			}
-			c.tryUpdateMigrationStatus(c.ctx, clonedCluster)
+			c.migrationUpdateMu.Unlock()
 		}
 	}
 }
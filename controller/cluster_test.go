@@ -22,7 +22,11 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,6 +35,7 @@ import (
 	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/store"
 	"github.com/apache/kvrocks-controller/store/engine"
+	"github.com/apache/kvrocks-controller/testutil/kvrockstest"
 )
 
 type MockClusterStore struct {
@@ -136,13 +141,16 @@ func TestCluster_FailureCount(t *testing.T) {
 	for i := int64(0); i < cluster.options.maxFailureCount; i++ {
 		require.EqualValues(t, i+1, cluster.increaseFailureCount(0, mockNode0))
 	}
-	require.False(t, mockNode0.IsMaster())
+	// the checker saves the updated copy of the cluster, so the nodes should be read from the store
+	gotCluster, err := s.GetCluster(ctx, ns, clusterName)
+	require.NoError(t, err)
+	newMaster := gotCluster.Shards[0].GetMasterNode()
 	// mockNode2 should become the new master since its sequence is the largest
-	require.True(t, mockNode2.IsMaster())
-	require.EqualValues(t, 2, clusterInfo.Version.Load())
+	require.Equal(t, mockNode2.ID(), newMaster.ID())
+	require.EqualValues(t, 2, gotCluster.Version.Load())
+	require.EqualValues(t, 1, clusterInfo.Version.Load())
 
 	require.EqualValues(t, 0, cluster.failureCounts[mockNode2.Addr()])
-	require.True(t, mockNode2.IsMaster())
 
 	// it will be always increase the failure count until the node is back again.
 	for i := int64(0); i < cluster.options.maxFailureCount*2; i++ {
@@ -150,13 +158,36 @@ func TestCluster_FailureCount(t *testing.T) {
 	}
 	cluster.resetFailureCount(mockNode3.ID())
 	require.EqualValues(t, 0, cluster.failureCounts[mockNode3.ID()])
+
+	// the failing replica should be removed after reaching the auto remove count
+	cluster.options.replicaAutoRemoveCount = 4
+	for i := int64(0); i < cluster.options.replicaAutoRemoveCount; i++ {
+		require.EqualValues(t, i+1, cluster.increaseFailureCount(0, mockNode3))
+	}
+	require.EqualValues(t, 0, cluster.failureCounts[mockNode3.ID()])
+	gotCluster, err = s.GetCluster(ctx, ns, clusterName)
+	require.NoError(t, err)
+	require.Len(t, gotCluster.Shards[0].Nodes, 3)
+	for _, node := range gotCluster.Shards[0].Nodes {
+		require.NotEqual(t, mockNode3.ID(), node.ID())
+	}
+
+	// the frozen shard shouldn't be failed over
+	gotCluster.Shards[0].Frozen = true
+	require.NoError(t, s.UpdateCluster(ctx, ns, gotCluster))
+	for i := int64(0); i < cluster.options.maxFailureCount; i++ {
+		require.EqualValues(t, i+1, cluster.increaseFailureCount(0, newMaster))
+	}
+	gotCluster, err = s.GetCluster(ctx, ns, clusterName)
+	require.NoError(t, err)
+	require.Equal(t, newMaster.ID(), gotCluster.Shards[0].GetMasterNode().ID())
 }
 
 func TestCluster_LoadAndProbe(t *testing.T) {
 	ctx := context.Background()
 	ns := "test-ns"
 	clusterName := "test-clusterProbe"
-	cluster, err := store.NewCluster(clusterName, []string{"127.0.0.1:7770", "127.0.0.1:7771"}, 2)
+	cluster, err := store.NewCluster(clusterName, kvrockstest.Start(t, 2), 2)
 	require.NoError(t, err)
 
 	nodes := make([]*store.ClusterNode, 0)
@@ -211,7 +242,7 @@ func TestCluster_MigrateSlot(t *testing.T) {
 	ctx := context.Background()
 	ns := "test-ns"
 	clusterName := "test-clusterProbe"
-	cluster, err := store.NewCluster(clusterName, []string{"127.0.0.1:7770", "127.0.0.1:7771"}, 1)
+	cluster, err := store.NewCluster(clusterName, kvrockstest.Start(t, 2), 1)
 	require.NoError(t, err)
 
 	require.NoError(t, cluster.Reset(ctx))
@@ -235,3 +266,229 @@ func TestCluster_MigrateSlot(t *testing.T) {
 	defer ticker.Stop()
 	<-ticker.C
 }
+
+func TestCluster_FinishCancelledMigrationJob(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := store.NewCluster("test-cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 1)
+	require.NoError(t, err)
+	cluster.Shards[0].MigratingSlot = store.FromSlotRange(store.SlotRange{Start: 0, Stop: 0})
+	cluster.Shards[0].TargetShardIndex = 1
+	cluster.Shards[0].MigrationID = "test-migration"
+	s := NewMockClusterStore()
+	job := cluster.NewMigrationJob(0)
+	job.CancelMigration("cancelled by the user")
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", job))
+	checker := NewClusterChecker(s, "test-ns", "test-cluster")
+	defer checker.Close()
+
+	// the job cancelled by the user shouldn't be overwritten by the controller
+	checker.finishMigrationJob(ctx, job.ID, "")
+	gotJob, err := s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.JobStatusCancelled, gotJob.Status)
+}
+
+func TestCluster_RestoringNode(t *testing.T) {
+	ctx := context.Background()
+	master := store.NewClusterMockNode()
+	master.SetRole(store.RoleMaster)
+	restoringNode := store.NewClusterMockNode()
+	restoringNode.SetRole(store.RoleSlave)
+	restoringNode.SetRestoring(true)
+	cluster := &store.Cluster{
+		Name:   "test-cluster",
+		Shards: []*store.Shard{{Nodes: []store.Node{master, restoringNode}}},
+	}
+
+	checker := NewClusterChecker(NewMockClusterStore(), "test-ns", "test-cluster")
+	defer checker.Close()
+	checker.SetChaosFault(&ChaosFault{DownNodes: []string{master.ID(), restoringNode.ID()}})
+	checker.parallelProbeNodes(ctx, cluster)
+
+	// the failure of the restoring node should NOT be counted
+	require.EqualValues(t, 1, checker.failureCounts[master.ID()])
+	_, ok := checker.failureCounts[restoringNode.ID()]
+	require.False(t, ok)
+}
+
+func TestCluster_ObserveVersion(t *testing.T) {
+	checker := NewClusterChecker(NewMockClusterStore(), "test-ns", "test-cluster")
+	defer checker.Close()
+
+	checker.observeVersion(3)
+	require.EqualValues(t, 3, checker.highestVersion.Load())
+	// the highest version should never go backward
+	checker.observeVersion(2)
+	require.EqualValues(t, 3, checker.highestVersion.Load())
+	checker.observeVersion(5)
+	require.EqualValues(t, 5, checker.highestVersion.Load())
+}
+
+func TestCluster_MigratePendingSlots(t *testing.T) {
+	ctx := context.Background()
+	newShard := func(slotRanges ...store.SlotRange) *store.Shard {
+		node := store.NewClusterMockNode()
+		node.SetRole(store.RoleMaster)
+		shard := store.NewShard()
+		shard.Nodes = []store.Node{node}
+		shard.SlotRanges = slotRanges
+		return shard
+	}
+	newCluster := func() *store.Cluster {
+		cluster := &store.Cluster{Name: "test-cluster", Shards: []*store.Shard{
+			newShard(store.SlotRange{Start: 0, Stop: 9}, store.SlotRange{Start: 20, Stop: 24}, store.SlotRange{Start: 30, Stop: 34}),
+			newShard(store.SlotRange{Start: 10, Stop: 19}, store.SlotRange{Start: 25, Stop: 29},
+				store.SlotRange{Start: 35, Stop: store.MaxSlotID}),
+		}}
+		cluster.Version.Store(1)
+		return cluster
+	}
+	// completeMigration reports the migration of the source master as succeeded,
+	// the mock nodes are copied with the cluster so the state is set on the persisted one.
+	completeMigration := func(cluster *store.Cluster) {
+		sourceMaster, _ := cluster.Shards[0].GetMasterNode().(*store.ClusterMockNode)
+		sourceMaster.ClusterInfo.MigratingSlot = cluster.Shards[0].MigratingSlot
+		sourceMaster.ClusterInfo.MigratingState = "success"
+	}
+
+	t.Run("split the shard", func(t *testing.T) {
+		s := NewMockClusterStore()
+		cluster := newCluster()
+		_, err := cluster.SplitShard(0, newShard())
+		require.NoError(t, err)
+		require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+		checker := NewClusterChecker(s, "test-ns", "test-cluster")
+		defer checker.Close()
+
+		migrationIDs := make([]string, 0)
+		for i := 0; i < 2; i++ {
+			checker.tryUpdateMigrationStatus(ctx, cluster)
+			cluster, err = s.GetCluster(ctx, "test-ns", "test-cluster")
+			require.NoError(t, err)
+			require.True(t, cluster.Shards[0].IsMigrating())
+			migrationIDs = append(migrationIDs, cluster.Shards[0].MigrationID)
+			completeMigration(cluster)
+		}
+		checker.tryUpdateMigrationStatus(ctx, cluster)
+
+		cluster, err = s.GetCluster(ctx, "test-ns", "test-cluster")
+		require.NoError(t, err)
+		require.False(t, cluster.Shards[0].IsMigrating())
+		require.False(t, cluster.Shards[0].HasPendingSlots())
+		require.Equal(t, []store.SlotRange{{Start: 0, Stop: 9}}, cluster.Shards[0].SlotRanges)
+		require.Equal(t, []store.SlotRange{{Start: 20, Stop: 24}, {Start: 30, Stop: 34}}, cluster.Shards[2].SlotRanges)
+		for _, id := range migrationIDs {
+			job, err := s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, id)
+			require.NoError(t, err)
+			require.Equal(t, store.JobStatusSucceeded, job.Status)
+		}
+	})
+
+	t.Run("merge the shard", func(t *testing.T) {
+		s := NewMockClusterStore()
+		cluster := newCluster()
+		require.NoError(t, cluster.MergeShard(0, 1))
+		require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+		checker := NewClusterChecker(s, "test-ns", "test-cluster")
+		defer checker.Close()
+
+		checker.tryUpdateMigrationStatus(ctx, cluster)
+		for cluster.Shards[0].IsServicing() {
+			cluster, _ = s.GetCluster(ctx, "test-ns", "test-cluster")
+			completeMigration(cluster)
+			checker.tryUpdateMigrationStatus(ctx, cluster)
+			cluster, _ = s.GetCluster(ctx, "test-ns", "test-cluster")
+		}
+		require.False(t, cluster.Shards[0].IsMigrating())
+		require.False(t, cluster.Shards[0].HasPendingSlots())
+		require.Equal(t, []store.SlotRange{{Start: 0, Stop: store.MaxSlotID}}, cluster.Shards[1].SlotRanges)
+	})
+
+	t.Run("keep the pending slots if failed to continue", func(t *testing.T) {
+		s := NewMockClusterStore()
+		cluster := newCluster()
+		_, err := cluster.SplitShard(0, newShard())
+		require.NoError(t, err)
+		require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+		checker := NewClusterChecker(s, "test-ns", "test-cluster")
+		defer checker.Close()
+
+		checker.tryUpdateMigrationStatus(ctx, cluster)
+		cluster, _ = s.GetCluster(ctx, "test-ns", "test-cluster")
+		migrationID := cluster.Shards[0].MigrationID
+		completeMigration(cluster)
+		sourceMaster, _ := cluster.Shards[0].GetMasterNode().(*store.ClusterMockNode)
+		sourceMaster.MigrateErr = errors.New("connection refused")
+		checker.tryUpdateMigrationStatus(ctx, cluster)
+
+		cluster, _ = s.GetCluster(ctx, "test-ns", "test-cluster")
+		require.False(t, cluster.Shards[0].IsMigrating())
+		require.Equal(t, []store.SlotRange{{Start: 30, Stop: 34}}, cluster.Shards[0].PendingSlots)
+		require.Equal(t, 2, cluster.Shards[0].TargetShardIndex)
+		require.Equal(t, []store.SlotRange{{Start: 20, Stop: 24}}, cluster.Shards[2].SlotRanges)
+		job, err := s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, migrationID)
+		require.NoError(t, err)
+		require.Equal(t, store.JobStatusFailed, job.Status)
+		require.Contains(t, job.Error, "pending slots")
+
+		// the pending slots are resumed once the node recovers
+		sourceMaster, _ = cluster.Shards[0].GetMasterNode().(*store.ClusterMockNode)
+		sourceMaster.MigrateErr = nil
+		checker.tryUpdateMigrationStatus(ctx, cluster)
+		cluster, _ = s.GetCluster(ctx, "test-ns", "test-cluster")
+		require.True(t, cluster.Shards[0].IsMigrating())
+		require.Empty(t, cluster.Shards[0].PendingSlots)
+	})
+}
+
+func TestCluster_HealthCheckFailures(t *testing.T) {
+	ctx := context.Background()
+	var status atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	masters := make([]*store.ClusterMockNode, 0)
+	cluster := &store.Cluster{Name: "test-cluster", HealthCheck: &store.HealthCheck{URL: server.URL}}
+	for i := 0; i < 2; i++ {
+		master := store.NewClusterMockNode()
+		master.SetRole(store.RoleMaster)
+		shard := store.NewShard()
+		shard.Nodes = []store.Node{master}
+		cluster.Shards = append(cluster.Shards, shard)
+		masters = append(masters, master)
+	}
+	cluster.Shards[0].SlotRanges = []store.SlotRange{{Start: 0, Stop: 8191}}
+	cluster.Shards[1].SlotRanges = []store.SlotRange{{Start: 8192, Stop: 16383}}
+	cluster.Version.Store(1)
+	s := NewMockClusterStore()
+	require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+	checker := NewClusterChecker(s, "test-ns", "test-cluster")
+	defer checker.Close()
+	failureCount := func(node store.Node) int64 {
+		checker.failureMu.Lock()
+		defer checker.failureMu.Unlock()
+		return checker.failureCounts[node.ID()]
+	}
+
+	// the check failing all masters is likely broken, so it can't fail over them
+	status.Store(http.StatusServiceUnavailable)
+	checker.parallelProbeNodes(ctx, cluster)
+	for _, master := range masters {
+		require.Zero(t, failureCount(master))
+	}
+
+	cluster.HealthCheck.MaxFailovers = 2
+	checker.parallelProbeNodes(ctx, cluster)
+	for _, master := range masters {
+		require.EqualValues(t, 1, failureCount(master))
+	}
+
+	// the unknown verdict doesn't fail the node
+	status.Store(http.StatusInternalServerError)
+	checker.parallelProbeNodes(ctx, cluster)
+	for _, master := range masters {
+		require.Zero(t, failureCount(master))
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// maxClusterUpdateAttempts bounds the attempts to save the update of the checker
+// if the cluster was updated by others concurrently, e.g. by the API requests.
+const maxClusterUpdateAttempts = 3
+
+// errMigrationChanged is returned if the migration was changed by others before the update was saved
+var errMigrationChanged = errors.New("the migration was changed by others")
+
+// errAddrsUnchanged is returned if no node address was changed after re-resolving
+var errAddrsUnchanged = errors.New("the node addresses were not changed")
+
+// updateClusterWithRetry saves the copy of the cluster changed by the update function, the update
+// will be re-applied to the latest cluster if the cluster was updated by others concurrently,
+// so the update function should return an error if the change doesn't apply to it anymore.
+func (c *ClusterChecker) updateClusterWithRetry(ctx context.Context, cluster *store.Cluster,
+	update func(clone *store.Cluster) error,
+) (*store.Cluster, error) {
+	for attempt := 1; ; attempt++ {
+		updatedCluster, err := cluster.WithUpdate(update)
+		if err != nil {
+			return nil, err
+		}
+		err = c.clusterStore.UpdateCluster(ctx, c.namespace, updatedCluster)
+		if err == nil {
+			c.updateCluster(updatedCluster)
+			return updatedCluster, nil
+		}
+		if !errors.Is(err, consts.ErrVersionMismatch) || attempt >= maxClusterUpdateAttempts {
+			return nil, err
+		}
+		logger.Get().With(
+			zap.String("namespace", c.namespace),
+			zap.String("cluster", c.clusterName),
+			zap.Int("attempt", attempt),
+		).Warn("Retry updating the cluster since it was updated by others", zap.Error(err))
+		if cluster, err = c.clusterStore.GetCluster(ctx, c.namespace, c.clusterName); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checkMigration returns errMigrationChanged if the shard isn't migrating the slot of the migration anymore
+func checkMigration(cluster *store.Cluster, shardIndex int, migrationID string, slot *store.MigratingSlot) error {
+	if shardIndex >= len(cluster.Shards) {
+		return errMigrationChanged
+	}
+	shard := cluster.Shards[shardIndex]
+	if !shard.IsMigrating() || shard.MigrationID != migrationID || !shard.MigratingSlot.Equal(slot.SlotRange) {
+		return errMigrationChanged
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// conflictClusterStore rejects the first updates as if the cluster was updated by others
+type conflictClusterStore struct {
+	*MockClusterStore
+	conflicts int
+}
+
+func (s *conflictClusterStore) UpdateCluster(ctx context.Context, ns string, cluster *store.Cluster) error {
+	if s.conflicts > 0 {
+		s.conflicts--
+		return &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: cluster.Version.Load() + 1}
+	}
+	return s.MockClusterStore.UpdateCluster(ctx, ns, cluster)
+}
+
+func TestCluster_UpdateClusterWithRetry(t *testing.T) {
+	ctx := context.Background()
+	slot := store.SlotRange{Start: 0, Stop: 0}
+	newCluster := func() *store.Cluster {
+		sourceMaster := store.NewClusterMockNode()
+		sourceMaster.SetRole(store.RoleMaster)
+		sourceMaster.ClusterInfo = store.ClusterInfo{MigratingSlot: store.FromSlotRange(slot), MigratingState: "success"}
+		targetMaster := store.NewClusterMockNode()
+		targetMaster.SetRole(store.RoleMaster)
+
+		sourceShard := store.NewShard()
+		sourceShard.Nodes = []store.Node{sourceMaster}
+		sourceShard.SlotRanges = []store.SlotRange{{Start: 0, Stop: 8191}}
+		sourceShard.MigratingSlot = store.FromSlotRange(slot)
+		sourceShard.TargetShardIndex = 1
+		sourceShard.MigrationID = "test-migration"
+		targetShard := store.NewShard()
+		targetShard.Nodes = []store.Node{targetMaster}
+		targetShard.SlotRanges = []store.SlotRange{{Start: 8192, Stop: 16383}}
+		cluster := &store.Cluster{Name: "test-cluster", Shards: []*store.Shard{sourceShard, targetShard}}
+		cluster.Version.Store(1)
+		return cluster
+	}
+
+	t.Run("re-apply the update to the latest cluster", func(t *testing.T) {
+		s := &conflictClusterStore{MockClusterStore: NewMockClusterStore(), conflicts: 1}
+		cluster := newCluster()
+		// the cluster was updated by others after the checker loaded it
+		latestCluster, err := cluster.WithUpdate(func(clone *store.Cluster) error {
+			clone.UpdateAnnotations(map[string]string{"owner": "test"})
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.CreateCluster(ctx, "test-ns", latestCluster))
+		checker := NewClusterChecker(s, "test-ns", "test-cluster")
+		defer checker.Close()
+
+		checker.tryUpdateMigrationStatus(ctx, cluster)
+		gotCluster, err := s.GetCluster(ctx, "test-ns", "test-cluster")
+		require.NoError(t, err)
+		require.False(t, gotCluster.Shards[0].IsMigrating())
+		require.True(t, (*store.SlotRanges)(&gotCluster.Shards[1].SlotRanges).Contains(0))
+		require.Equal(t, "test", gotCluster.Annotations["owner"])
+	})
+
+	t.Run("drop the update if the migration was changed", func(t *testing.T) {
+		s := &conflictClusterStore{MockClusterStore: NewMockClusterStore(), conflicts: 1}
+		cluster := newCluster()
+		latestCluster, err := cluster.WithUpdatedShard(0, func(shard *store.Shard) error {
+			shard.ClearMigrateState()
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.CreateCluster(ctx, "test-ns", latestCluster))
+		checker := NewClusterChecker(s, "test-ns", "test-cluster")
+		defer checker.Close()
+
+		_, err = checker.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+			return clearMigration(clone, 0, "test-migration", cluster.Shards[0].MigratingSlot)
+		})
+		require.ErrorIs(t, err, errMigrationChanged)
+	})
+
+	t.Run("give up after the max attempts", func(t *testing.T) {
+		s := &conflictClusterStore{MockClusterStore: NewMockClusterStore(), conflicts: maxClusterUpdateAttempts}
+		cluster := newCluster()
+		require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+		checker := NewClusterChecker(s, "test-ns", "test-cluster")
+		defer checker.Close()
+
+		_, err := checker.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+			return nil
+		})
+		require.ErrorIs(t, err, consts.ErrVersionMismatch)
+		require.Zero(t, s.conflicts)
+	})
+}
@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+const saveJobTimeout = 5 * time.Second
+
+// saveFinishedJob saves the job result even if the checker was closed
+func (c *ClusterChecker) saveFinishedJob(job *store.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveJobTimeout)
+	defer cancel()
+	if err := c.clusterStore.SaveJob(ctx, c.namespace, c.clusterName, job); err != nil {
+		logger.Get().With(
+			zap.String("namespace", c.namespace),
+			zap.String("cluster", c.clusterName),
+			zap.String("job_type", job.Type),
+			zap.String("job_id", job.ID),
+			zap.Error(err),
+		).Error("Failed to save the finished job")
+	}
+}
+
+type compactionTask struct {
+	node store.Node
+	task *store.JobTask
+}
+
+// compactionLoop checks the compaction schedule of the cluster periodically,
+// and runs the compaction job once in each window.
+func (c *ClusterChecker) compactionLoop() {
+	ticker, stopTicker := c.newTicker(c.options.compactionInterval)
+	defer stopTicker()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.tryCompact(c.ctx, time.Now())
+		}
+	}
+}
+
+func (c *ClusterChecker) tryCompact(ctx context.Context, now time.Time) *store.Job {
+	c.clusterMu.Lock()
+	cluster := c.cluster
+	c.clusterMu.Unlock()
+	if cluster == nil {
+		return nil
+	}
+	windowStart, windowStop, ok := cluster.Compaction.ActiveWindow(now)
+	if !ok {
+		return nil
+	}
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName))
+	jobs, err := c.clusterStore.ListJobs(ctx, c.namespace, c.clusterName, store.JobTypeCompaction)
+	if err != nil {
+		log.Error("Failed to list the compaction jobs", zap.Error(err))
+		return nil
+	}
+	if len(jobs) > 0 && jobs[0].StartedAt >= windowStart.Unix() {
+		// the cluster was compacted in the current window
+		return nil
+	}
+	return c.runCompaction(ctx, cluster, now, windowStop.Sub(now))
+}
+
+// runCompaction compacts the nodes in a rolling manner, the nodes in the same shard are
+// compacted one by one with the replicas first, so the master and its replicas are never
+// compacted simultaneously. The nodes which are not started in the time left will be skipped.
+func (c *ClusterChecker) runCompaction(ctx context.Context, cluster *store.Cluster, now time.Time, timeLeft time.Duration) *store.Job {
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName))
+
+	job := &store.Job{
+		ID:        strconv.FormatInt(now.UnixMilli(), 10),
+		Type:      store.JobTypeCompaction,
+		Status:    store.JobStatusRunning,
+		StartedAt: now.Unix(),
+		Tasks:     make([]*store.JobTask, 0),
+	}
+	shardTasks := make([][]compactionTask, len(cluster.Shards))
+	for i, shard := range cluster.Shards {
+		var master store.Node
+		for _, node := range shard.Nodes {
+			if node.IsMaster() {
+				master = node
+				continue
+			}
+			shardTasks[i] = append(shardTasks[i], compactionTask{node: node})
+		}
+		if master != nil {
+			shardTasks[i] = append(shardTasks[i], compactionTask{node: master})
+		}
+		for j := range shardTasks[i] {
+			task := &store.JobTask{
+				ShardIndex: i,
+				NodeID:     shardTasks[i][j].node.ID(),
+				Addr:       shardTasks[i][j].node.Addr(),
+				Status:     store.JobTaskStatusPending,
+			}
+			shardTasks[i][j].task = task
+			job.Tasks = append(job.Tasks, task)
+		}
+	}
+	if err := c.clusterStore.SaveJob(ctx, c.namespace, c.clusterName, job); err != nil {
+		log.Error("Failed to save the compaction job", zap.Error(err))
+		return nil
+	}
+	log.With(zap.String("job_id", job.ID)).Info("Start to compact the nodes")
+
+	deadline := time.Now().Add(timeLeft)
+	nodeTimeout := cluster.Compaction.NodeTimeout()
+	var wg sync.WaitGroup
+	for _, tasks := range shardTasks {
+		c.goTracked(&wg, func() {
+			for _, t := range tasks {
+				if ctx.Err() != nil || !time.Now().Before(deadline) {
+					t.task.Status = store.JobTaskStatusSkipped
+					continue
+				}
+				start := t.task.Start()
+				nodeCtx, cancel := context.WithTimeout(ctx, nodeTimeout)
+				err := store.CompactNode(nodeCtx, t.node, c.options.jobPollInterval)
+				cancel()
+				t.task.Finish(start, err)
+				if err != nil {
+					log.With(
+						zap.String("node", t.node.Addr()),
+						zap.Error(err),
+					).Warn("Failed to compact the node")
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	job.Finish()
+	c.saveFinishedJob(job)
+	log.With(
+		zap.String("job_id", job.ID),
+		zap.String("status", job.Status),
+	).Info("Finish compacting the nodes")
+	return job
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestClusterChecker_Compaction(t *testing.T) {
+	ctx := context.Background()
+	newNode := func(role string) *store.ClusterMockNode {
+		node := store.NewClusterMockNode()
+		node.SetRole(role)
+		node.Replies = map[string]interface{}{
+			"COMPACT": "OK",
+			"INFO":    "# RocksDB\r\nis_compacting:no\r\n",
+		}
+		return node
+	}
+	failedNode := newNode(store.RoleSlave)
+	failedNode.Replies["COMPACT"] = errors.New("ERR compaction failed")
+	cluster := &store.Cluster{
+		Name: "test-cluster",
+		Shards: []*store.Shard{
+			{Nodes: []store.Node{newNode(store.RoleMaster), newNode(store.RoleSlave)}},
+			{Nodes: []store.Node{newNode(store.RoleMaster), failedNode}},
+		},
+		Compaction: &store.CompactionSchedule{Windows: []string{"02:00-04:00"}},
+	}
+	clusterStore := NewMockClusterStore()
+	require.NoError(t, clusterStore.CreateCluster(ctx, "test-ns", cluster))
+	checker := NewClusterChecker(clusterStore, "test-ns", "test-cluster")
+	checker.options.jobPollInterval = 10 * time.Millisecond
+	checker.updateCluster(cluster)
+	defer checker.Close()
+
+	outOfWindow := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	require.Nil(t, checker.tryCompact(ctx, outOfWindow))
+
+	inWindow := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+	job := checker.tryCompact(ctx, inWindow)
+	require.NotNil(t, job)
+	require.Equal(t, store.JobStatusFailed, job.Status)
+	require.Len(t, job.Tasks, 4)
+	for _, task := range job.Tasks {
+		if task.NodeID == failedNode.ID() {
+			require.Equal(t, store.JobStatusFailed, task.Status)
+			require.NotEmpty(t, task.Error)
+		} else {
+			require.Equal(t, store.JobStatusSucceeded, task.Status)
+		}
+	}
+	// the replica should be compacted before the master in the same shard
+	require.Equal(t, store.RoleSlave, roleOf(t, cluster, job.Tasks[0].NodeID))
+	require.Equal(t, store.RoleMaster, roleOf(t, cluster, job.Tasks[1].NodeID))
+
+	// the cluster was compacted in the current window
+	require.Nil(t, checker.tryCompact(ctx, inWindow.Add(time.Minute)))
+	jobs, err := clusterStore.ListJobs(ctx, "test-ns", "test-cluster", store.JobTypeCompaction)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, job.ID, jobs[0].ID)
+
+	// the nodes should be skipped if there's no time left in the window
+	job = checker.runCompaction(ctx, cluster, inWindow.AddDate(0, 0, 1), 0)
+	require.NotNil(t, job)
+	require.Equal(t, store.JobStatusSucceeded, job.Status)
+	for _, task := range job.Tasks {
+		require.Equal(t, store.JobTaskStatusSkipped, task.Status)
+	}
+}
+
+func roleOf(t *testing.T, cluster *store.Cluster, nodeID string) string {
+	for _, node := range cluster.GetNodes() {
+		if node.ID() == nodeID {
+			if node.IsMaster() {
+				return store.RoleMaster
+			}
+			return store.RoleSlave
+		}
+	}
+	t.Fatalf("node %s not found", nodeID)
+	return ""
+}
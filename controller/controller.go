@@ -22,6 +22,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/apache/kvrocks-controller/config"
 	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/store"
 )
 
@@ -40,12 +42,28 @@ const (
 	stateClosed
 )
 
+// gcInterval is the interval to sweep the orphan entries of the in-memory maps
+const gcInterval = 5 * time.Minute
+
 type Controller struct {
 	config       *config.ControllerConfig
 	clusterStore *store.ClusterStore
 
 	mu       sync.Mutex
 	clusters map[string]*ClusterChecker
+	// mapSizes return the sizes of the in-memory maps which are exported periodically
+	mapSizes map[string]func() int
+	breaker  *FailoverBreaker
+
+	// uncheckedClusters are the clusters which aren't checked since the max clusters was reached
+	uncheckedClusters map[string]clusterRef
+	// nodeLimiter bounds the node goroutines of all checkers
+	nodeLimiter nodeLimiter
+
+	engineHealth      *EngineHealthChecker
+	storeAvailability *StoreAvailability
+	// lastEventID is the ID of the last persisted event applied by the supervisor
+	lastEventID atomic.Int64
 
 	wg      sync.WaitGroup
 	state   atomic.Int32
@@ -55,11 +73,34 @@ type Controller struct {
 
 func New(s *store.ClusterStore, config *config.ControllerConfig) (*Controller, error) {
 	c := &Controller{
-		config:       config,
-		clusterStore: s,
-		clusters:     make(map[string]*ClusterChecker),
-		readyCh:      make(chan struct{}, 1),
-		closeCh:      make(chan struct{}),
+		config:            config,
+		clusterStore:      s,
+		clusters:          make(map[string]*ClusterChecker),
+		uncheckedClusters: make(map[string]clusterRef),
+		mapSizes:          make(map[string]func() int),
+		readyCh:           make(chan struct{}, 1),
+		closeCh:           make(chan struct{}),
+	}
+	c.breaker = NewFailoverBreaker(
+		time.Duration(config.FailOver.FailoverWindowSeconds)*time.Second,
+		config.FailOver.MaxClusterFailovers,
+		config.FailOver.MaxGlobalFailovers,
+	)
+	c.breaker.onTrip = c.onFailoverBreakerTrip
+	c.breaker.onChange = c.saveFailoverBreakerState
+	c.engineHealth = NewEngineHealthChecker(s.GetEngine())
+	if health := config.EngineHealth; health != nil {
+		c.engineHealth.WithProbeInterval(time.Duration(health.ProbeIntervalSeconds) * time.Second).
+			WithMaxLatency(time.Duration(health.MaxLatencyMs) * time.Millisecond).
+			WithMaxErrorRate(health.MaxErrorRate).
+			WithPauseFailover(health.PauseFailover)
+	}
+	c.engineHealth.onChange = c.onEngineHealthChange
+	c.storeAvailability = NewStoreAvailability()
+	c.storeAvailability.onChange = c.onStoreAvailabilityChange
+	metrics.Get().StoreAvailable.WithLabelValues().Set(1)
+	if resources := config.Resources; resources != nil {
+		c.nodeLimiter = newNodeLimiter(resources.MaxGlobalNodeConcurrency)
 	}
 	c.state.Store(stateInit)
 	return c, nil
@@ -74,9 +115,70 @@ func (c *Controller) Start(ctx context.Context) error {
 	go c.syncLoop(ctx)
 	c.wg.Add(1)
 	go c.leaderEventLoop()
+	c.wg.Add(1)
+	go c.supervisorLoop(ctx)
+	c.wg.Add(1)
+	go c.gcLoop(ctx)
+	c.wg.Add(1)
+	go c.engineHealthLoop(ctx)
 	return nil
 }
 
+// RegisterMapSize registers the size of the in-memory map which will be exported
+// periodically by the gauge with the name, e.g. the locks which are removed once released.
+func (c *Controller) RegisterMapSize(name string, size func() int) {
+	c.mu.Lock()
+	c.mapSizes[name] = size
+	c.mu.Unlock()
+}
+
+func (c *Controller) sweep(ctx context.Context) {
+	c.mu.Lock()
+	mapSizes := make(map[string]func() int, len(c.mapSizes)+1)
+	for name, size := range c.mapSizes {
+		mapSizes[name] = size
+	}
+	checkers := make([]*ClusterChecker, 0, len(c.clusters))
+	for _, checker := range c.clusters {
+		checkers = append(checkers, checker)
+	}
+	c.mu.Unlock()
+
+	mapSizes["store_locks"] = c.clusterStore.CountLocks
+	for name, size := range mapSizes {
+		metrics.Get().MapSizes.WithLabelValues(name).Set(float64(size()))
+	}
+	// the follower mustn't write the engine, and its view of the clusters might be stale
+	if c.clusterStore.IsLeader() {
+		size, err := c.clusterStore.RebuildNodeIndex(ctx)
+		if err != nil {
+			logger.Get().With(zap.String("map", "node_index"), zap.Error(err)).Warn("Failed to sweep the orphan entries")
+		} else {
+			metrics.Get().MapSizes.WithLabelValues("node_index").Set(float64(size))
+		}
+	}
+	failureCounts := 0
+	for _, checker := range checkers {
+		failureCounts += checker.sweepFailureCounts()
+	}
+	metrics.Get().MapSizes.WithLabelValues("failure_counts").Set(float64(failureCounts))
+}
+
+func (c *Controller) gcLoop(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.sweep(ctx)
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
 func (c *Controller) WaitForReady() {
 	<-c.readyCh
 }
@@ -88,11 +190,27 @@ func (c *Controller) suspend() {
 		cluster.Close()
 		delete(c.clusters, key)
 	}
+	c.uncheckedClusters = make(map[string]clusterRef)
+	metrics.Get().UncheckedClusters.WithLabelValues().Set(0)
 	c.mu.Unlock()
 }
 
 // resume starts the controller to process events
 func (c *Controller) resume(ctx context.Context) error {
+	// the node index might be missing or stale if the cluster was written by the old versions
+	if _, err := c.clusterStore.RebuildNodeIndex(ctx); err != nil {
+		logger.Get().Warn("Failed to rebuild the node index", zap.Error(err))
+	}
+	if err := c.markEventsSeen(ctx); err != nil {
+		logger.Get().Warn("Failed to get the last event ID", zap.Error(err))
+	}
+	// the breaker must be restored before checking the clusters, or the failovers
+	// suspended by the previous leader would be resumed by the leadership change.
+	breakerState, err := c.clusterStore.GetFailoverBreakerState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the failover breaker state: %w", err)
+	}
+	c.breaker.Restore(breakerState)
 	namespaces, err := c.clusterStore.ListNamespace(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list namespaces: %w", err)
@@ -107,6 +225,8 @@ func (c *Controller) resume(ctx context.Context) error {
 			logger.Get().Debug("Resume the cluster", zap.String("namespace", ns), zap.String("cluster", cluster))
 		}
 	}
+	c.wg.Add(1)
+	go c.reconcileClusters()
 	return nil
 }
 
@@ -114,6 +234,9 @@ func (c *Controller) becomeLeader(ctx context.Context, prevTermLeader string) {
 	if prevTermLeader == c.clusterStore.ID() {
 		return
 	}
+	if err := c.clusterStore.RecordLeadership(ctx, prevTermLeader); err != nil {
+		logger.Get().Warn("Failed to record the leadership change", zap.Error(err))
+	}
 	if err := c.resume(ctx); err != nil {
 		logger.Get().Error("Failed to resume the controller", zap.Error(err))
 		return
@@ -168,6 +291,8 @@ func (c *Controller) leaderEventLoop() {
 				c.removeCluster(event.Namespace, event.Cluster)
 			case store.CommandUpdate:
 				c.updateCluster(event.Namespace, event.Cluster)
+			case store.CommandWarn, store.CommandCritical:
+				// the warning is recorded in the event log for the operators only
 			default:
 				logger.Get().Error("Unknown command", zap.Any("event", event))
 			}
@@ -183,18 +308,49 @@ func (c *Controller) buildClusterKey(namespace, clusterName string) string {
 
 func (c *Controller) addCluster(namespace, clusterName string) {
 	key := c.buildClusterKey(namespace, clusterName)
-	if cluster, err := c.getCluster(namespace, clusterName); err == nil && cluster != nil {
+	// the checker might be added by the event loop and the supervisor concurrently
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.clusters[key]; ok {
+		return
+	}
+	if c.config.Resources != nil && c.config.Resources.MaxClusters > 0 && len(c.clusters) >= c.config.Resources.MaxClusters {
+		if _, ok := c.uncheckedClusters[key]; !ok {
+			c.uncheckedClusters[key] = clusterRef{namespace: namespace, cluster: clusterName}
+			logger.Get().With(
+				zap.String("namespace", namespace),
+				zap.String("cluster", clusterName),
+				zap.Int("max_clusters", c.config.Resources.MaxClusters),
+			).Warn("Reached the max clusters, the cluster won't be checked")
+		}
+		metrics.Get().UncheckedClusters.WithLabelValues().Set(float64(len(c.uncheckedClusters)))
 		return
 	}
+	if _, ok := c.uncheckedClusters[key]; ok {
+		delete(c.uncheckedClusters, key)
+		metrics.Get().UncheckedClusters.WithLabelValues().Set(float64(len(c.uncheckedClusters)))
+	}
 
 	cluster := NewClusterChecker(c.clusterStore, namespace, clusterName).
 		WithPingInterval(time.Duration(c.config.FailOver.PingIntervalSeconds) * time.Second).
-		WithMaxFailureCount(c.config.FailOver.MaxPingCount)
+		WithMaxFailureCount(c.config.FailOver.MaxPingCount).
+		WithReplicaMaxFailureCount(c.config.FailOver.ReplicaMaxPingCount).
+		WithReplicaAutoRemoveCount(c.config.FailOver.ReplicaAutoRemoveCount).
+		WithMaxReplicationStall(time.Duration(c.config.FailOver.MaxReplicationStallSeconds) * time.Second).
+		WithFailoverBreaker(c.breaker).
+		WithEngineHealth(c.engineHealth).
+		WithStoreAvailability(c.storeAvailability).
+		WithGlobalNodeLimiter(c.nodeLimiter).
+		WithLogThrottleEvery(c.config.LogThrottleEvery)
+	if c.config.Resources != nil {
+		cluster.WithMaxNodeConcurrency(c.config.Resources.MaxNodeConcurrency)
+	}
+	if c.config.Migration != nil {
+		cluster.WithMigrationVerifyKeys(c.config.Migration.VerifySampleKeys).
+			WithMigrationVerifyAttempts(c.config.Migration.VerifyMaxAttempts)
+	}
 	cluster.Start()
-
-	c.mu.Lock()
 	c.clusters[key] = cluster
-	c.mu.Unlock()
 }
 
 func (c *Controller) getCluster(namespace, clusterName string) (*ClusterChecker, error) {
@@ -216,7 +372,33 @@ func (c *Controller) removeCluster(namespace, clusterName string) {
 		cluster.Close()
 		delete(c.clusters, key)
 	}
+	delete(c.uncheckedClusters, key)
+	metrics.Get().UncheckedClusters.WithLabelValues().Set(float64(len(c.uncheckedClusters)))
+	next, hasNext := c.nextUncheckedCluster()
 	c.mu.Unlock()
+
+	// the removed cluster made room for the unchecked cluster
+	if hasNext {
+		c.addCluster(next.namespace, next.cluster)
+	}
+}
+
+type clusterRef struct {
+	namespace string
+	cluster   string
+}
+
+// nextUncheckedCluster returns the first unchecked cluster by the key, it should be called with the lock held
+func (c *Controller) nextUncheckedCluster() (clusterRef, bool) {
+	if len(c.uncheckedClusters) == 0 {
+		return clusterRef{}, false
+	}
+	keys := make([]string, 0, len(c.uncheckedClusters))
+	for key := range c.uncheckedClusters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return c.uncheckedClusters[keys[0]], true
 }
 
 func (c *Controller) updateCluster(namespace, clusterName string) {
@@ -244,3 +426,42 @@ func (c *Controller) Close() {
 	close(c.closeCh)
 	c.wg.Wait()
 }
+
+func (c *Controller) onFailoverBreakerTrip(namespace, cluster, reason string) {
+	message := fmt.Sprintf("the automatic failover was suspended since %s, it requires to be acknowledged", reason)
+	logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", cluster),
+	).Error(message)
+	c.clusterStore.EmitEvent(store.EventPayload{
+		Namespace: namespace,
+		Cluster:   cluster,
+		Type:      store.EventCluster,
+		Command:   store.CommandCritical,
+		Message:   message,
+	})
+}
+
+func (c *Controller) saveFailoverBreakerState(state *store.FailoverBreakerState) error {
+	ctx, cancel := context.WithTimeout(context.Background(), saveJobTimeout)
+	defer cancel()
+	return c.clusterStore.SaveFailoverBreakerState(ctx, state)
+}
+
+// FailoverBreakerStatus returns the failovers in the window and the tripped breakers
+func (c *Controller) FailoverBreakerStatus() *FailoverBreakerStatus {
+	return c.breaker.Status(time.Now())
+}
+
+// AcknowledgeFailoverBreaker resumes the automatic failover of the cluster, the empty
+// namespace and cluster resume the automatic failover suspended by the global breaker.
+func (c *Controller) AcknowledgeFailoverBreaker(namespace, cluster string) error {
+	if err := c.breaker.Acknowledge(namespace, cluster); err != nil {
+		return err
+	}
+	logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", cluster),
+	).Info("The failover breaker was acknowledged")
+	return nil
+}
@@ -24,20 +24,24 @@ import (
 	"context"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/apache/kvrocks-controller/config"
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/store"
 	"github.com/apache/kvrocks-controller/store/engine"
+	"github.com/apache/kvrocks-controller/testutil/kvrockstest"
 )
 
 func TestController_Basics(t *testing.T) {
 	ctx := context.Background()
 	ns := "test-ns"
-	cluster0, err := store.NewCluster("test-cluster-0", []string{"127.0.0.1:7770"}, 1)
+	nodeAddrs := kvrockstest.Start(t, 2)
+	cluster0, err := store.NewCluster("test-cluster-0", nodeAddrs[:1], 1)
 	require.NoError(t, err)
-	cluster1, err := store.NewCluster("test-cluster-1", []string{"127.0.0.1:7771"}, 1)
+	cluster1, err := store.NewCluster("test-cluster-1", nodeAddrs[1:], 1)
 	require.NoError(t, err)
 
 	s := store.NewClusterStore(engine.NewMock())
@@ -76,3 +80,17 @@ func TestController_Basics(t *testing.T) {
 		require.ErrorIs(t, err, consts.ErrNotFound)
 	})
 }
+
+func TestController_SweepMapSizes(t *testing.T) {
+	s := store.NewClusterStore(engine.NewMock())
+	c, err := New(s, &config.ControllerConfig{FailOver: &config.FailOverConfig{PingIntervalSeconds: 1}})
+	require.NoError(t, err)
+	mapSize := func(name string) float64 {
+		return testutil.ToFloat64(metrics.Get().MapSizes.WithLabelValues(name))
+	}
+
+	c.RegisterMapSize("test_locks", func() int { return 3 })
+	c.sweep(context.Background())
+	require.EqualValues(t, 3, mapSize("test_locks"))
+	require.EqualValues(t, 0, mapSize("store_locks"))
+}
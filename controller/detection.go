@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"sort"
+
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// NodeDetection is the failure detection state of the node in the checker
+type NodeDetection struct {
+	ID    string `json:"id"`
+	Addr  string `json:"addr"`
+	Shard int    `json:"shard"`
+	Role  string `json:"role"`
+	// FailureCount is the number of the consecutive failed probes
+	FailureCount int64 `json:"failure_count"`
+	// MaxFailureCount is the failure count to failover the master or warn the replica
+	MaxFailureCount int64 `json:"max_failure_count"`
+	Restoring       bool  `json:"restoring"`
+}
+
+// Detections returns the failure detection state of all nodes in the last probed cluster
+func (c *ClusterChecker) Detections() []NodeDetection {
+	c.clusterMu.Lock()
+	cluster := c.cluster
+	c.clusterMu.Unlock()
+	if cluster == nil {
+		return []NodeDetection{}
+	}
+
+	c.failureMu.Lock()
+	defer c.failureMu.Unlock()
+	detections := make([]NodeDetection, 0)
+	for shardIndex, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			role, maxFailureCount := store.RoleMaster, c.options.maxFailureCount
+			if !node.IsMaster() {
+				role = store.RoleSlave
+				if c.options.replicaMaxFailureCount > 0 {
+					maxFailureCount = c.options.replicaMaxFailureCount
+				}
+			}
+			detections = append(detections, NodeDetection{
+				ID:              node.ID(),
+				Addr:            node.Addr(),
+				Shard:           shardIndex,
+				Role:            role,
+				FailureCount:    c.failureCounts[node.ID()],
+				MaxFailureCount: maxFailureCount,
+				Restoring:       node.IsRestoring(),
+			})
+		}
+	}
+	sort.SliceStable(detections, func(i, j int) bool {
+		return detections[i].FailureCount > detections[j].FailureCount
+	})
+	return detections
+}
+
+// observeDetections exports the number and the ratio of the failing nodes of the cluster
+func (c *ClusterChecker) observeDetections() {
+	detections := c.Detections()
+	failingNodes := 0
+	for _, detection := range detections {
+		if detection.FailureCount > 0 {
+			failingNodes++
+		}
+	}
+	ratio := 0.0
+	if len(detections) > 0 {
+		ratio = float64(failingNodes) / float64(len(detections))
+	}
+	metrics.Get().FailingNodes.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName)...).Set(float64(failingNodes))
+	metrics.Get().FailingNodeRatio.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName)...).Set(ratio)
+}
+
+func (c *ClusterChecker) recordFailoverDecision(result string) {
+	metrics.Get().FailoverDecisions.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName, result)...).Inc()
+}
+
+func (c *ClusterChecker) clearDetectionMetrics() {
+	metrics.Get().FailingNodes.DeletePartialMatch(metrics.ClusterLabels(c.namespace, c.clusterName))
+	metrics.Get().FailingNodeRatio.DeletePartialMatch(metrics.ClusterLabels(c.namespace, c.clusterName))
+	metrics.Get().FailoverDecisions.DeletePartialMatch(map[string]string{
+		"namespace": c.namespace,
+		"cluster":   c.clusterName,
+	})
+}
+
+// GetDetections returns the failure detection state of the cluster nodes
+func (c *Controller) GetDetections(namespace, clusterName string) ([]NodeDetection, error) {
+	cluster, err := c.getCluster(namespace, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Detections(), nil
+}
+
+// ClusterHealth returns the health state of the cluster by the last probe, it's degraded if any
+// node failed or the placement rules are broken, and unknown if the cluster isn't checked by
+// this controller, e.g. it's not the leader.
+func (c *Controller) ClusterHealth(namespace, clusterName string) string {
+	cluster, err := c.getCluster(namespace, clusterName)
+	if err != nil {
+		return store.ClusterHealthUnknown
+	}
+	return clusterHealth(cluster.Detections(), cluster.PlacementViolations())
+}
+
+// PlacementViolations returns the violations of the placement rules in the last probed cluster
+func (c *ClusterChecker) PlacementViolations() []string {
+	c.clusterMu.Lock()
+	cluster := c.cluster
+	c.clusterMu.Unlock()
+	if cluster == nil {
+		return nil
+	}
+	return cluster.PlacementViolations()
+}
+
+func clusterHealth(detections []NodeDetection, placementViolations []string) string {
+	if len(detections) == 0 {
+		return store.ClusterHealthUnknown
+	}
+	// the detections are sorted with the failing nodes first
+	if detections[0].FailureCount > 0 || len(placementViolations) > 0 {
+		return store.ClusterHealthDegraded
+	}
+	return store.ClusterHealthHealthy
+}
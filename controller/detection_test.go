@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestCluster_Detections(t *testing.T) {
+	master := store.NewClusterMockNode()
+	master.SetRole(store.RoleMaster)
+	replica := store.NewClusterMockNode()
+	replica.SetRole(store.RoleSlave)
+	shard := store.NewShard()
+	shard.Nodes = []store.Node{master, replica}
+	shard.SlotRanges = []store.SlotRange{{Start: 0, Stop: 16383}}
+
+	checker := NewClusterChecker(NewMockClusterStore(), "test-ns", "test-detection-cluster")
+	require.Empty(t, checker.Detections())
+	require.Equal(t, store.ClusterHealthUnknown, clusterHealth(checker.Detections(), nil))
+	checker.WithMaxFailureCount(5).WithReplicaMaxFailureCount(10)
+	checker.cluster = &store.Cluster{Name: "test-detection-cluster", Shards: []*store.Shard{shard}}
+	checker.failureCounts[replica.ID()] = 2
+
+	detections := checker.Detections()
+	require.Len(t, detections, 2)
+	// the failing node comes first
+	require.Equal(t, replica.ID(), detections[0].ID)
+	require.Equal(t, store.RoleSlave, detections[0].Role)
+	require.EqualValues(t, 2, detections[0].FailureCount)
+	require.EqualValues(t, 10, detections[0].MaxFailureCount)
+	require.Equal(t, master.ID(), detections[1].ID)
+	require.EqualValues(t, 0, detections[1].FailureCount)
+	require.EqualValues(t, 5, detections[1].MaxFailureCount)
+	require.Equal(t, store.ClusterHealthDegraded, clusterHealth(detections, nil))
+	checker.resetFailureCount(replica.ID())
+	require.Equal(t, store.ClusterHealthHealthy, clusterHealth(checker.Detections(), nil))
+	require.Empty(t, checker.PlacementViolations())
+	require.Equal(t, store.ClusterHealthDegraded, clusterHealth(checker.Detections(), []string{"zone-a exceeds the limit"}))
+	checker.failureCounts[replica.ID()] = 2
+
+	checker.observeDetections()
+	require.EqualValues(t, 1, testutil.ToFloat64(metrics.Get().FailingNodes.WithLabelValues("test-ns", "test-detection-cluster")))
+	require.EqualValues(t, 0.5, testutil.ToFloat64(metrics.Get().FailingNodeRatio.WithLabelValues("test-ns", "test-detection-cluster")))
+	checker.clearDetectionMetrics()
+}
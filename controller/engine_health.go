@@ -0,0 +1,221 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+var ErrEngineUnhealthy = errors.New("the metadata store engine is unhealthy")
+
+const (
+	defaultEngineProbeInterval = 5 * time.Second
+	defaultEngineMaxLatency    = time.Second
+	defaultEngineMaxErrorRate  = 0.5
+
+	// engineProbeWindow is the number of the recent probes to calculate the error rate
+	engineProbeWindow = 10
+	// engineProbeKey is probed by reading its existence, it's not required to exist
+	engineProbeKey = "/kvrocks/engine_health"
+)
+
+// EngineHealthStatus is the health of the metadata store engine by the last probe
+type EngineHealthStatus struct {
+	Healthy   bool    `json:"healthy"`
+	Reason    string  `json:"reason,omitempty"`
+	Leader    string  `json:"leader"`
+	LatencyMs int64   `json:"latency_ms"`
+	ErrorRate float64 `json:"error_rate"`
+	CheckedAt int64   `json:"checked_at"`
+}
+
+// EngineHealthChecker probes the metadata store engine periodically, the engine is unhealthy
+// if there's no leader, or the probe latency or the error rate of the recent probes exceeds
+// the limits. The automatic failover can be paused while the engine is unhealthy.
+type EngineHealthChecker struct {
+	e             engine.Engine
+	interval      time.Duration
+	maxLatency    time.Duration
+	maxErrorRate  float64
+	pauseFailover bool
+	// onChange is called without the lock held when the health was changed
+	onChange func(status EngineHealthStatus)
+
+	mu      sync.Mutex
+	results []bool
+	status  EngineHealthStatus
+}
+
+func NewEngineHealthChecker(e engine.Engine) *EngineHealthChecker {
+	return &EngineHealthChecker{
+		e:            e,
+		interval:     defaultEngineProbeInterval,
+		maxLatency:   defaultEngineMaxLatency,
+		maxErrorRate: defaultEngineMaxErrorRate,
+		// the engine is assumed to be healthy until the first probe
+		status: EngineHealthStatus{Healthy: true},
+	}
+}
+
+func (h *EngineHealthChecker) WithProbeInterval(interval time.Duration) *EngineHealthChecker {
+	if interval > 0 {
+		h.interval = interval
+	}
+	return h
+}
+
+func (h *EngineHealthChecker) WithMaxLatency(latency time.Duration) *EngineHealthChecker {
+	if latency > 0 {
+		h.maxLatency = latency
+	}
+	return h
+}
+
+func (h *EngineHealthChecker) WithMaxErrorRate(rate float64) *EngineHealthChecker {
+	if rate > 0 {
+		h.maxErrorRate = rate
+	}
+	return h
+}
+
+// WithPauseFailover pauses the automatic failover while the engine is unhealthy
+func (h *EngineHealthChecker) WithPauseFailover(pause bool) *EngineHealthChecker {
+	h.pauseFailover = pause
+	return h
+}
+
+// Probe checks the engine once and returns the health status
+func (h *EngineHealthChecker) Probe(ctx context.Context) EngineHealthStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+	start := time.Now()
+	_, err := h.e.Exists(ctx, engineProbeKey)
+	latency := time.Since(start)
+	leader := h.e.Leader()
+
+	h.mu.Lock()
+	h.results = append(h.results, err == nil)
+	if len(h.results) > engineProbeWindow {
+		h.results = h.results[len(h.results)-engineProbeWindow:]
+	}
+	failures := 0
+	for _, ok := range h.results {
+		if !ok {
+			failures++
+		}
+	}
+	status := EngineHealthStatus{
+		Healthy:   true,
+		Leader:    leader,
+		LatencyMs: latency.Milliseconds(),
+		ErrorRate: float64(failures) / float64(len(h.results)),
+		CheckedAt: time.Now().Unix(),
+	}
+	switch {
+	case leader == "":
+		status.Healthy, status.Reason = false, "no leader was elected"
+	case status.ErrorRate > h.maxErrorRate:
+		status.Healthy, status.Reason = false, fmt.Sprintf("%d of the recent %d probes failed, the last error: %v", failures, len(h.results), err)
+	case latency > h.maxLatency:
+		status.Healthy, status.Reason = false, fmt.Sprintf("the probe latency %s exceeded %s", latency, h.maxLatency)
+	}
+	changed := status.Healthy != h.status.Healthy
+	h.status = status
+	onChange := h.onChange
+	h.mu.Unlock()
+
+	healthy := 0.0
+	if status.Healthy {
+		healthy = 1
+	}
+	metrics.Get().EngineHealthy.WithLabelValues().Set(healthy)
+	metrics.Get().EngineProbeLatency.WithLabelValues().Set(latency.Seconds())
+	if changed && onChange != nil {
+		onChange(status)
+	}
+	return status
+}
+
+// Status returns the health status by the last probe
+func (h *EngineHealthChecker) Status() EngineHealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+// AllowFailover returns ErrEngineUnhealthy if the failover should be paused while the engine is unhealthy
+func (h *EngineHealthChecker) AllowFailover() error {
+	if !h.pauseFailover {
+		return nil
+	}
+	if status := h.Status(); !status.Healthy {
+		return fmt.Errorf("%w: %s", ErrEngineUnhealthy, status.Reason)
+	}
+	return nil
+}
+
+func (c *Controller) engineHealthLoop(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.engineHealth.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.engineHealth.Probe(ctx)
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+func (c *Controller) onEngineHealthChange(status EngineHealthStatus) {
+	event := store.EventPayload{
+		Type:    store.EventEngine,
+		Command: store.CommandUpdate,
+		Message: fmt.Sprintf("the metadata store engine recovered on the controller %s", c.clusterStore.ID()),
+	}
+	log := logger.Get().With(zap.String("leader", status.Leader))
+	if !status.Healthy {
+		event.Command = store.CommandCritical
+		event.Message = fmt.Sprintf("the metadata store engine degraded on the controller %s: %s",
+			c.clusterStore.ID(), status.Reason)
+		log.Error(event.Message)
+	} else {
+		log.Info(event.Message)
+	}
+	c.clusterStore.EmitEvent(event)
+}
+
+// EngineHealth returns the health status of the metadata store engine by the last probe
+func (c *Controller) EngineHealth() EngineHealthStatus {
+	return c.engineHealth.Status()
+}
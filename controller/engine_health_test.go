@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestEngineHealthChecker(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	h := NewEngineHealthChecker(mock).
+		WithMaxLatency(50 * time.Millisecond).
+		WithMaxErrorRate(0.3).
+		WithPauseFailover(true)
+	var changes []bool
+	h.onChange = func(status EngineHealthStatus) {
+		changes = append(changes, status.Healthy)
+	}
+
+	status := h.Probe(ctx)
+	require.True(t, status.Healthy)
+	require.Equal(t, mock.Leader(), status.Leader)
+	require.NoError(t, h.AllowFailover())
+
+	mock.WithLatency(engine.MockOpExists, 100*time.Millisecond)
+	status = h.Probe(ctx)
+	require.False(t, status.Healthy)
+	require.Contains(t, status.Reason, "latency")
+	require.ErrorIs(t, h.AllowFailover(), ErrEngineUnhealthy)
+
+	mock.ResetFaults()
+	require.True(t, h.Probe(ctx).Healthy)
+
+	// a single failure in the recent probes is under the error rate limit
+	mock.WithFailureRate(engine.MockOpExists, 1, nil)
+	require.True(t, h.Probe(ctx).Healthy)
+	status = h.Probe(ctx)
+	require.False(t, status.Healthy)
+	require.InDelta(t, 2.0/5, status.ErrorRate, 0.001)
+	require.Equal(t, []bool{false, true, false}, changes)
+}
+
+func TestEngineHealthChecker_NotPauseFailover(t *testing.T) {
+	mock := engine.NewMock().WithFailureRate(engine.MockOpExists, 1, nil)
+	h := NewEngineHealthChecker(mock)
+	require.False(t, h.Probe(context.Background()).Healthy)
+	require.NoError(t, h.AllowFailover())
+}
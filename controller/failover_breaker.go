@@ -0,0 +1,273 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+var ErrFailoverSuspended = errors.New("the automatic failover is suspended by the breaker")
+
+const defaultFailoverWindow = 10 * time.Minute
+
+// globalBreakerKey is the key of the global breaker which counts the failovers of all clusters
+const globalBreakerKey = ""
+
+// FailoverBreaker limits the automatic failovers of each cluster and all clusters in the rolling
+// window to protect against the promotion ping-pong caused by the flapping network. The automatic
+// failover is suspended after reaching the limit until it's acknowledged by the operator.
+type FailoverBreaker struct {
+	window        time.Duration
+	maxPerCluster int
+	maxGlobal     int
+	// onTrip is called without the lock held when the breaker is tripped
+	onTrip func(namespace, cluster, reason string)
+	// onChange persists the state after the failover was recorded or the breaker was acknowledged,
+	// it's called in the order of the changes without the lock held.
+	onChange func(state *store.FailoverBreakerState) error
+
+	changeMu  sync.Mutex
+	mu        sync.Mutex
+	failovers map[string][]time.Time
+	trippedAt map[string]time.Time
+}
+
+// FailoverBreakerCluster is the breaker status of the cluster, the global breaker has the empty key
+type FailoverBreakerCluster struct {
+	Cluster   string `json:"cluster"`
+	Failovers int    `json:"failovers"`
+	Tripped   bool   `json:"tripped"`
+	TrippedAt int64  `json:"tripped_at,omitempty"`
+}
+
+type FailoverBreakerStatus struct {
+	WindowSeconds int64                    `json:"window_seconds"`
+	MaxPerCluster int                      `json:"max_per_cluster"`
+	MaxGlobal     int                      `json:"max_global"`
+	Global        FailoverBreakerCluster   `json:"global"`
+	Clusters      []FailoverBreakerCluster `json:"clusters"`
+}
+
+// NewFailoverBreaker creates the breaker, the limit is disabled if it's less than 1
+func NewFailoverBreaker(window time.Duration, maxPerCluster, maxGlobal int) *FailoverBreaker {
+	if window <= 0 {
+		window = defaultFailoverWindow
+	}
+	return &FailoverBreaker{
+		window:        window,
+		maxPerCluster: maxPerCluster,
+		maxGlobal:     maxGlobal,
+		failovers:     make(map[string][]time.Time),
+		trippedAt:     make(map[string]time.Time),
+	}
+}
+
+func (b *FailoverBreaker) pruneLocked(key string, now time.Time) []time.Time {
+	failovers := b.failovers[key]
+	i := 0
+	for i < len(failovers) && now.Sub(failovers[i]) >= b.window {
+		i++
+	}
+	failovers = failovers[i:]
+	if len(failovers) == 0 {
+		delete(b.failovers, key)
+	} else {
+		b.failovers[key] = failovers
+	}
+	return failovers
+}
+
+func buildBreakerKey(namespace, cluster string) string {
+	if namespace == "" && cluster == "" {
+		return globalBreakerKey
+	}
+	return namespace + "/" + cluster
+}
+
+// Allow returns ErrFailoverSuspended if the breaker of the cluster or the global breaker was tripped
+func (b *FailoverBreaker) Allow(namespace, cluster string) error {
+	key := buildBreakerKey(namespace, cluster)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.trippedAt[globalBreakerKey]; ok {
+		return fmt.Errorf("%w: too many failovers of all clusters", ErrFailoverSuspended)
+	}
+	if _, ok := b.trippedAt[key]; ok {
+		return fmt.Errorf("%w: too many failovers of the cluster", ErrFailoverSuspended)
+	}
+	return nil
+}
+
+// Record records the automatic failover of the cluster, and trips the breaker
+// if the failovers in the window reach the limit.
+func (b *FailoverBreaker) Record(namespace, cluster string, now time.Time) {
+	key := buildBreakerKey(namespace, cluster)
+	var trips []string
+	b.changeMu.Lock()
+	defer b.changeMu.Unlock()
+	b.mu.Lock()
+	b.failovers[key] = append(b.failovers[key], now)
+	b.failovers[globalBreakerKey] = append(b.failovers[globalBreakerKey], now)
+	clusterCount := len(b.pruneLocked(key, now))
+	globalCount := len(b.pruneLocked(globalBreakerKey, now))
+	if _, ok := b.trippedAt[key]; !ok && b.maxPerCluster > 0 && clusterCount >= b.maxPerCluster {
+		b.trippedAt[key] = now
+		trips = append(trips, fmt.Sprintf("the cluster failed over %d times in %s", clusterCount, b.window))
+	}
+	if _, ok := b.trippedAt[globalBreakerKey]; !ok && b.maxGlobal > 0 && globalCount >= b.maxGlobal {
+		b.trippedAt[globalBreakerKey] = now
+		trips = append(trips, fmt.Sprintf("all clusters failed over %d times in %s", globalCount, b.window))
+	}
+	onTrip := b.onTrip
+	state := b.snapshotLocked()
+	b.mu.Unlock()
+
+	if err := b.saveState(state); err != nil {
+		logger.Get().With(
+			zap.String("namespace", namespace),
+			zap.String("cluster", cluster),
+			zap.Error(err),
+		).Error("Failed to persist the failover breaker state")
+	}
+	for _, reason := range trips {
+		if onTrip != nil {
+			onTrip(namespace, cluster, reason)
+		}
+	}
+}
+
+// Acknowledge resets the breaker of the cluster, the empty namespace and cluster reset the global
+// breaker. The failovers in the window are also cleared to avoid tripping again immediately.
+// It returns ErrNotFound if the breaker wasn't tripped.
+func (b *FailoverBreaker) Acknowledge(namespace, cluster string) error {
+	key := buildBreakerKey(namespace, cluster)
+	b.changeMu.Lock()
+	defer b.changeMu.Unlock()
+	b.mu.Lock()
+	if _, ok := b.trippedAt[key]; !ok {
+		b.mu.Unlock()
+		return fmt.Errorf("failover breaker %w", consts.ErrNotFound)
+	}
+	trippedAt, failovers := b.trippedAt[key], b.failovers[key]
+	delete(b.trippedAt, key)
+	delete(b.failovers, key)
+	state := b.snapshotLocked()
+	b.mu.Unlock()
+
+	if err := b.saveState(state); err != nil {
+		// keep the breaker tripped, otherwise it would be tripped again after the leadership change
+		b.mu.Lock()
+		b.trippedAt[key] = trippedAt
+		if len(failovers) > 0 {
+			b.failovers[key] = failovers
+		}
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (b *FailoverBreaker) saveState(state *store.FailoverBreakerState) error {
+	if b.onChange == nil {
+		return nil
+	}
+	return b.onChange(state)
+}
+
+func (b *FailoverBreaker) snapshotLocked() *store.FailoverBreakerState {
+	state := &store.FailoverBreakerState{
+		Failovers: make(map[string][]int64, len(b.failovers)),
+		TrippedAt: make(map[string]int64, len(b.trippedAt)),
+	}
+	for key, failovers := range b.failovers {
+		for _, failover := range failovers {
+			state.Failovers[key] = append(state.Failovers[key], failover.UnixMilli())
+		}
+	}
+	for key, trippedAt := range b.trippedAt {
+		state.TrippedAt[key] = trippedAt.UnixMilli()
+	}
+	return state
+}
+
+// Restore replaces the failovers and the tripped breakers with the persisted state,
+// it's called when the controller becomes the leader.
+func (b *FailoverBreaker) Restore(state *store.FailoverBreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failovers = make(map[string][]time.Time, len(state.Failovers))
+	b.trippedAt = make(map[string]time.Time, len(state.TrippedAt))
+	for key, failovers := range state.Failovers {
+		for _, failover := range failovers {
+			b.failovers[key] = append(b.failovers[key], time.UnixMilli(failover))
+		}
+	}
+	for key, trippedAt := range state.TrippedAt {
+		b.trippedAt[key] = time.UnixMilli(trippedAt)
+	}
+}
+
+func (b *FailoverBreaker) Status(now time.Time) *FailoverBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := make(map[string]bool)
+	for key := range b.failovers {
+		keys[key] = true
+	}
+	for key := range b.trippedAt {
+		keys[key] = true
+	}
+	status := &FailoverBreakerStatus{
+		WindowSeconds: int64(b.window.Seconds()),
+		MaxPerCluster: b.maxPerCluster,
+		MaxGlobal:     b.maxGlobal,
+		Clusters:      make([]FailoverBreakerCluster, 0, len(keys)),
+	}
+	for key := range keys {
+		cluster := FailoverBreakerCluster{
+			Cluster:   key,
+			Failovers: len(b.pruneLocked(key, now)),
+		}
+		if trippedAt, ok := b.trippedAt[key]; ok {
+			cluster.Tripped = true
+			cluster.TrippedAt = trippedAt.Unix()
+		}
+		if key == globalBreakerKey {
+			status.Global = cluster
+		} else {
+			status.Clusters = append(status.Clusters, cluster)
+		}
+	}
+	sort.Slice(status.Clusters, func(i, j int) bool {
+		return status.Clusters[i].Cluster < status.Clusters[j].Cluster
+	})
+	return status
+}
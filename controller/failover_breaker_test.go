@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestFailoverBreaker(t *testing.T) {
+	breaker := NewFailoverBreaker(10*time.Minute, 2, 3)
+	var trips []string
+	breaker.onTrip = func(namespace, cluster, reason string) {
+		trips = append(trips, namespace+"/"+cluster)
+	}
+
+	now := time.Now()
+	breaker.Record("ns", "c1", now)
+	require.NoError(t, breaker.Allow("ns", "c1"))
+	breaker.Record("ns", "c1", now.Add(time.Minute))
+	require.ErrorIs(t, breaker.Allow("ns", "c1"), ErrFailoverSuspended)
+	require.NoError(t, breaker.Allow("ns", "c2"))
+	require.Equal(t, []string{"ns/c1"}, trips)
+
+	// the third failover of all clusters trips the global breaker
+	breaker.Record("ns", "c2", now.Add(2*time.Minute))
+	require.ErrorIs(t, breaker.Allow("ns", "c2"), ErrFailoverSuspended)
+	require.Equal(t, []string{"ns/c1", "ns/c2"}, trips)
+
+	status := breaker.Status(now.Add(2 * time.Minute))
+	require.True(t, status.Global.Tripped)
+	require.Equal(t, 3, status.Global.Failovers)
+	require.Len(t, status.Clusters, 2)
+	require.Equal(t, "ns/c1", status.Clusters[0].Cluster)
+	require.True(t, status.Clusters[0].Tripped)
+	require.Equal(t, 2, status.Clusters[0].Failovers)
+	require.False(t, status.Clusters[1].Tripped)
+
+	require.NoError(t, breaker.Acknowledge("", ""))
+	require.NoError(t, breaker.Allow("ns", "c2"))
+	require.ErrorIs(t, breaker.Allow("ns", "c1"), ErrFailoverSuspended)
+	require.NoError(t, breaker.Acknowledge("ns", "c1"))
+	require.NoError(t, breaker.Allow("ns", "c1"))
+	require.ErrorIs(t, breaker.Acknowledge("ns", "c1"), consts.ErrNotFound)
+
+	// the failovers out of the window are not counted
+	breaker.Record("ns", "c2", now.Add(20*time.Minute))
+	require.NoError(t, breaker.Allow("ns", "c2"))
+	require.Equal(t, 1, breaker.Status(now.Add(20 * time.Minute)).Clusters[0].Failovers)
+}
+
+func TestFailoverBreaker_Persist(t *testing.T) {
+	breaker := NewFailoverBreaker(10*time.Minute, 1, 0)
+	var saved *store.FailoverBreakerState
+	breaker.onChange = func(state *store.FailoverBreakerState) error {
+		saved = state
+		return nil
+	}
+	now := time.Now()
+	breaker.Record("ns", "c1", now)
+	require.Equal(t, now.UnixMilli(), saved.TrippedAt["ns/c1"])
+	require.Len(t, saved.Failovers["ns/c1"], 1)
+
+	// the new leader keeps the breaker tripped by the previous leader
+	restored := NewFailoverBreaker(10*time.Minute, 1, 0)
+	restored.Restore(saved)
+	require.ErrorIs(t, restored.Allow("ns", "c1"), ErrFailoverSuspended)
+	require.Equal(t, 1, restored.Status(now).Clusters[0].Failovers)
+
+	restored.onChange = func(state *store.FailoverBreakerState) error {
+		return errors.New("store unavailable")
+	}
+	require.Error(t, restored.Acknowledge("ns", "c1"))
+	require.ErrorIs(t, restored.Allow("ns", "c1"), ErrFailoverSuspended)
+	restored.onChange = nil
+	require.NoError(t, restored.Acknowledge("ns", "c1"))
+	require.NoError(t, restored.Allow("ns", "c1"))
+}
+
+func TestFailoverBreaker_Disabled(t *testing.T) {
+	breaker := NewFailoverBreaker(0, 0, 0)
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		breaker.Record("ns", "c1", now)
+	}
+	require.NoError(t, breaker.Allow("ns", "c1"))
+	require.EqualValues(t, defaultFailoverWindow.Seconds(), breaker.Status(now).WindowSeconds)
+}
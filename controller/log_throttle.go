@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultLogThrottleEvery is the default interval of logging the repetitive errors
+const defaultLogThrottleEvery = 10
+
+// logThrottle suppresses the repetitive error logs of the nodes, otherwise the large outage
+// floods the logs with the identical warnings in every probe interval. The first occurrence
+// and every Nth occurrence of the error are logged, the changed error is logged immediately,
+// and a summary is logged once the node recovers.
+type logThrottle struct {
+	every int64
+
+	mu      sync.Mutex
+	entries map[string]*throttledLog
+}
+
+type throttledLog struct {
+	occurrences int64
+	suppressed  int64
+	lastError   string
+	firstAt     time.Time
+}
+
+// logThrottleSummary is the summary of the repetitive errors of the recovered key
+type logThrottleSummary struct {
+	Occurrences int64
+	Suppressed  int64
+	Duration    time.Duration
+}
+
+func newLogThrottle(every int64) *logThrottle {
+	if every <= 0 {
+		every = defaultLogThrottleEvery
+	}
+	return &logThrottle{
+		every:   every,
+		entries: make(map[string]*throttledLog),
+	}
+}
+
+// Allow records the error of the key, it returns whether the error should be logged and
+// the number of the suppressed logs since the last logged one.
+func (t *logThrottle) Allow(key string, err error) (bool, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &throttledLog{firstAt: time.Now()}
+		t.entries[key] = entry
+	}
+	entry.occurrences++
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if entry.occurrences == 1 || entry.occurrences%t.every == 0 || errMsg != entry.lastError {
+		entry.lastError = errMsg
+		suppressed := entry.suppressed
+		entry.suppressed = 0
+		return true, suppressed
+	}
+	entry.suppressed++
+	return false, 0
+}
+
+// Recover removes the key, it returns the summary if the key has the repetitive errors
+// which should be logged, the single error has nothing to summarize.
+func (t *logThrottle) Recover(key string) (*logThrottleSummary, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok {
+		return nil, false
+	}
+	delete(t.entries, key)
+	if entry.occurrences <= 1 {
+		return nil, false
+	}
+	return &logThrottleSummary{
+		Occurrences: entry.occurrences,
+		Suppressed:  entry.suppressed,
+		Duration:    time.Since(entry.firstAt),
+	}, true
+}
+
+// Log logs the error of the key at the level unless it's suppressed
+func (t *logThrottle) Log(log *zap.Logger, level zapcore.Level, key string, err error, msg string) {
+	allowed, suppressed := t.Allow(key, err)
+	if !allowed {
+		return
+	}
+	if suppressed > 0 {
+		log = log.With(zap.Int64("suppressed", suppressed))
+	}
+	log.Log(level, msg, zap.Error(err))
+}
+
+// LogRecovery logs the summary of the repetitive errors of the key once it recovers
+func (t *logThrottle) LogRecovery(log *zap.Logger, key string, msg string) {
+	summary, ok := t.Recover(key)
+	if !ok {
+		return
+	}
+	log.With(
+		zap.Int64("occurrences", summary.Occurrences),
+		zap.Int64("suppressed", summary.Suppressed),
+		zap.Duration("duration", summary.Duration),
+	).Info(msg)
+}
+
+// Sweep removes the keys which aren't kept, e.g. the nodes removed from the cluster
+func (t *logThrottle) Sweep(keep func(key string) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.entries {
+		if !keep(key) {
+			delete(t.entries, key)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogThrottle(t *testing.T) {
+	throttle := newLogThrottle(3)
+	errTimeout := errors.New("i/o timeout")
+
+	var logged []int64
+	for i := 1; i <= 7; i++ {
+		if allowed, suppressed := throttle.Allow("node0", errTimeout); allowed {
+			logged = append(logged, suppressed)
+		}
+	}
+	// the 1st, 3rd and 6th errors are logged
+	require.Equal(t, []int64{0, 1, 2}, logged)
+
+	// the changed error is logged immediately
+	allowed, suppressed := throttle.Allow("node0", errors.New("connection refused"))
+	require.True(t, allowed)
+	require.EqualValues(t, 1, suppressed)
+
+	// the other keys are throttled independently
+	allowed, _ = throttle.Allow("node1", errTimeout)
+	require.True(t, allowed)
+
+	summary, ok := throttle.Recover("node0")
+	require.True(t, ok)
+	require.EqualValues(t, 8, summary.Occurrences)
+	require.EqualValues(t, 0, summary.Suppressed)
+	_, ok = throttle.Recover("node0")
+	require.False(t, ok)
+	// the single error has nothing to summarize
+	_, ok = throttle.Recover("node1")
+	require.False(t, ok)
+
+	allowed, _ = throttle.Allow("node0", errTimeout)
+	require.True(t, allowed)
+	throttle.Allow("node2", errTimeout)
+	throttle.Sweep(func(key string) bool { return key == "node2" })
+	require.Len(t, throttle.entries, 1)
+	require.Contains(t, throttle.entries, "node2")
+}
+
+func TestLogThrottle_Log(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core)
+	throttle := newLogThrottle(0)
+	require.EqualValues(t, defaultLogThrottleEvery, throttle.every)
+
+	for i := 0; i < 2*defaultLogThrottleEvery; i++ {
+		throttle.Log(log, zapcore.WarnLevel, "node0", errors.New("i/o timeout"), "Failed to probe the node")
+	}
+	entries := logs.TakeAll()
+	require.Len(t, entries, 3)
+	require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	require.NotContains(t, entries[0].ContextMap(), "suppressed")
+	require.EqualValues(t, defaultLogThrottleEvery-2, entries[1].ContextMap()["suppressed"])
+	require.EqualValues(t, defaultLogThrottleEvery-1, entries[2].ContextMap()["suppressed"])
+
+	throttle.LogRecovery(log, "node0", "The node recovered from the probe failures")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	require.EqualValues(t, 2*defaultLogThrottleEvery, entries[0].ContextMap()["occurrences"])
+	require.EqualValues(t, 0, entries[0].ContextMap()["suppressed"])
+
+	throttle.LogRecovery(log, "node0", "The node recovered from the probe failures")
+	require.Zero(t, logs.Len())
+}
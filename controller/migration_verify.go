@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// defaultMigrationVerifyAttempts bounds the verifications of the migrated slot, the migration
+// fails if the keys are still left in the source slot after that.
+const defaultMigrationVerifyAttempts = 10
+
+// verifyMigratedSlot counts the keys left in the migrated slot of the source node and records the result
+// into the migration job, the slot move won't be committed until the verification passed. It returns
+// the error if the verification kept failing for the max attempts, then the migration should fail.
+func (c *ClusterChecker) verifyMigratedSlot(ctx context.Context, cluster *store.Cluster, shardIndex int) (bool, error) {
+	shard := cluster.Shards[shardIndex]
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName),
+		zap.Int("shard_index", shardIndex),
+		zap.String("slot", shard.MigratingSlot.String()))
+
+	source := shard.GetMasterNode()
+	if source == nil {
+		log.Error("Failed to verify the migrated slot since the source shard has no master")
+		return false, nil
+	}
+	verification := store.VerifyMigratedSlot(ctx, source, shard.MigratingSlot.SlotRange, c.options.migrationVerifyKeys)
+	maxAttempts := c.options.migrationVerifyAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMigrationVerifyAttempts
+	}
+	key := verifyAttemptsKey(shard)
+	c.migrationMu.Lock()
+	if c.verifyAttempts == nil {
+		c.verifyAttempts = make(map[string]int)
+	}
+	c.verifyAttempts[key]++
+	verification.Attempts = c.verifyAttempts[key]
+	if verification.Passed() || verification.Attempts >= maxAttempts {
+		delete(c.verifyAttempts, key)
+	}
+	c.migrationMu.Unlock()
+
+	c.updateMigrationJob(ctx, shard.MigrationID, func(job *store.Job) bool {
+		if job.Migration == nil {
+			return false
+		}
+		job.Migration.Verification = verification
+		return true
+	})
+	if verification.Passed() {
+		log.Info("The migrated slot passed the verification", zap.Int("checked_slots", verification.CheckedSlots))
+		return true, nil
+	}
+	log.Error("The migrated slot failed the verification",
+		zap.Int("attempts", verification.Attempts),
+		zap.Int64("leftover_count", verification.LeftoverCount),
+		zap.Strings("leftover_keys", verification.LeftoverKeys),
+		zap.String("error", verification.Error))
+	if verification.Attempts >= maxAttempts {
+		return false, fmt.Errorf("the migrated slot failed the verification after %d attempts", verification.Attempts)
+	}
+	return false, nil
+}
+
+func verifyAttemptsKey(shard *store.Shard) string {
+	return shard.MigrationID + "/" + shard.MigratingSlot.String()
+}
+
+// pruneVerifyAttempts removes the verification attempts of the migrations which aren't running anymore
+func (c *ClusterChecker) pruneVerifyAttempts(cluster *store.Cluster) {
+	running := make(map[string]bool)
+	for _, shard := range cluster.Shards {
+		if shard.IsMigrating() {
+			running[verifyAttemptsKey(shard)] = true
+		}
+	}
+	c.migrationMu.Lock()
+	defer c.migrationMu.Unlock()
+	for key := range c.verifyAttempts {
+		if !running[key] {
+			delete(c.verifyAttempts, key)
+		}
+	}
+}
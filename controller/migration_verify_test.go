@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/util"
+)
+
+func TestCluster_VerifyMigratedSlot(t *testing.T) {
+	ctx := context.Background()
+	slot := store.SlotRange{Start: 0, Stop: 0}
+	sourceMaster := store.NewClusterMockNode()
+	sourceMaster.SetRole(store.RoleMaster)
+	sourceMaster.ClusterInfo = store.ClusterInfo{MigratingSlot: store.FromSlotRange(slot), MigratingState: "success"}
+	// the migrated key is still in the slot of the source node
+	sourceMaster.Replies = map[string]interface{}{
+		"CLUSTER COUNTKEYSINSLOT": int64(1),
+		"CLUSTER GETKEYSINSLOT":   []interface{}{util.SlotTable[0]},
+	}
+	targetMaster := store.NewClusterMockNode()
+	targetMaster.SetRole(store.RoleMaster)
+
+	sourceShard := store.NewShard()
+	sourceShard.Nodes = []store.Node{sourceMaster}
+	sourceShard.SlotRanges = []store.SlotRange{{Start: 0, Stop: 8191}}
+	sourceShard.MigratingSlot = store.FromSlotRange(slot)
+	sourceShard.TargetShardIndex = 1
+	sourceShard.MigrationID = "test-migration"
+	targetShard := store.NewShard()
+	targetShard.Nodes = []store.Node{targetMaster}
+	targetShard.SlotRanges = []store.SlotRange{{Start: 8192, Stop: 16383}}
+	cluster := &store.Cluster{Name: "test-cluster", Shards: []*store.Shard{sourceShard, targetShard}}
+	cluster.Version.Store(1)
+
+	s := NewMockClusterStore()
+	require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", cluster.NewMigrationJob(0)))
+	checker := NewClusterChecker(s, "test-ns", "test-cluster").WithMigrationVerifyKeys(10)
+	defer checker.Close()
+
+	// the slot move shouldn't be committed if the verification failed
+	checker.tryUpdateMigrationStatus(ctx, cluster)
+	gotCluster, err := s.GetCluster(ctx, "test-ns", "test-cluster")
+	require.NoError(t, err)
+	require.True(t, gotCluster.Shards[0].IsMigrating())
+	job, err := s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, "test-migration")
+	require.NoError(t, err)
+	require.NotNil(t, job.Migration.Verification)
+	require.Equal(t, []string{util.SlotTable[0]}, job.Migration.Verification.LeftoverKeys)
+
+	require.Equal(t, 1, job.Migration.Verification.Attempts)
+
+	sourceMaster.Replies = map[string]interface{}{"CLUSTER COUNTKEYSINSLOT": int64(0)}
+	checker.tryUpdateMigrationStatus(ctx, cluster)
+	gotCluster, err = s.GetCluster(ctx, "test-ns", "test-cluster")
+	require.NoError(t, err)
+	require.False(t, gotCluster.Shards[0].IsMigrating())
+	require.True(t, (*store.SlotRanges)(&gotCluster.Shards[1].SlotRanges).Contains(0))
+	job, err = s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, "test-migration")
+	require.NoError(t, err)
+	require.Equal(t, store.JobStatusSucceeded, job.Status)
+	require.True(t, job.Migration.Verification.Passed())
+	require.Equal(t, 1, job.Migration.Verification.CheckedSlots)
+	require.Equal(t, 2, job.Migration.Verification.Attempts)
+}
+
+func TestCluster_VerifyMigratedSlotAttempts(t *testing.T) {
+	ctx := context.Background()
+	slot := store.SlotRange{Start: 0, Stop: 0}
+	sourceMaster := store.NewClusterMockNode()
+	sourceMaster.SetRole(store.RoleMaster)
+	sourceMaster.ClusterInfo = store.ClusterInfo{MigratingSlot: store.FromSlotRange(slot), MigratingState: "success"}
+	sourceMaster.Replies = map[string]interface{}{"CLUSTER COUNTKEYSINSLOT": int64(1)}
+	targetMaster := store.NewClusterMockNode()
+	targetMaster.SetRole(store.RoleMaster)
+
+	sourceShard := store.NewShard()
+	sourceShard.Nodes = []store.Node{sourceMaster}
+	sourceShard.SlotRanges = []store.SlotRange{{Start: 0, Stop: 8191}}
+	sourceShard.MigratingSlot = store.FromSlotRange(slot)
+	sourceShard.TargetShardIndex = 1
+	sourceShard.MigrationID = "test-migration"
+	targetShard := store.NewShard()
+	targetShard.Nodes = []store.Node{targetMaster}
+	targetShard.SlotRanges = []store.SlotRange{{Start: 8192, Stop: 16383}}
+	cluster := &store.Cluster{Name: "test-cluster", Shards: []*store.Shard{sourceShard, targetShard}}
+	cluster.Version.Store(1)
+
+	s := NewMockClusterStore()
+	require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", cluster.NewMigrationJob(0)))
+	checker := NewClusterChecker(s, "test-ns", "test-cluster").
+		WithMigrationVerifyKeys(10).
+		WithMigrationVerifyAttempts(2)
+	defer checker.Close()
+
+	checker.tryUpdateMigrationStatus(ctx, cluster)
+	gotCluster, err := s.GetCluster(ctx, "test-ns", "test-cluster")
+	require.NoError(t, err)
+	require.True(t, gotCluster.Shards[0].IsMigrating())
+
+	// the migration fails after the max attempts and the slot stays in the source shard
+	checker.tryUpdateMigrationStatus(ctx, gotCluster)
+	gotCluster, err = s.GetCluster(ctx, "test-ns", "test-cluster")
+	require.NoError(t, err)
+	require.False(t, gotCluster.Shards[0].IsMigrating())
+	require.True(t, (*store.SlotRanges)(&gotCluster.Shards[0].SlotRanges).Contains(0))
+	job, err := s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, "test-migration")
+	require.NoError(t, err)
+	require.Equal(t, store.JobStatusFailed, job.Status)
+	require.Equal(t, 2, job.Migration.Verification.Attempts)
+	require.Empty(t, checker.verifyAttempts)
+}
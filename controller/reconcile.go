@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// reconcileReport is the result of reconciling the cluster after becoming the leader
+type reconcileReport struct {
+	ResumedMigrations []string
+	ClearedMigrations []string
+	SyncedNodes       []string
+	Errors            []string
+}
+
+func (report *reconcileReport) addError(format string, args ...interface{}) {
+	report.Errors = append(report.Errors, fmt.Sprintf(format, args...))
+}
+
+// reconcile converges the cluster once instead of waiting for the loops to converge it by ticks.
+// It resumes the interrupted migrations, clears the migrating flags which the source nodes
+// report no migration, and syncs the topology to the out-of-date nodes.
+func (c *ClusterChecker) reconcile(ctx context.Context) *reconcileReport {
+	report := &reconcileReport{}
+	// the migration loop was started with the checker, so the migrations
+	// are reconciled exclusively with it.
+	c.migrationUpdateMu.Lock()
+	defer c.migrationUpdateMu.Unlock()
+	cluster, err := c.clusterStore.GetCluster(ctx, c.namespace, c.clusterName)
+	if err != nil {
+		report.addError("failed to get the cluster: %v", err)
+		return report
+	}
+	c.updateCluster(cluster)
+
+	resumed := false
+	for i := 0; i < len(cluster.Shards); i++ {
+		shard := cluster.Shards[i]
+		if !shard.IsMigrating() {
+			continue
+		}
+		info, err := shard.GetMasterNode().GetClusterInfo(ctx)
+		if err != nil {
+			report.addError("failed to get the migrating state of shard %d: %v", i, err)
+			continue
+		}
+		if info.MigratingState != "none" {
+			// the migration state will be updated by the migration status below
+			report.ResumedMigrations = append(report.ResumedMigrations, fmt.Sprintf("slot %s of shard %d is %s",
+				shard.MigratingSlot.String(), i, info.MigratingState))
+			resumed = true
+			continue
+		}
+		// the source node might lose the migration after restarting, so the flag would never be cleared
+		migratingSlot, migrationID := shard.MigratingSlot, shard.MigrationID
+		updatedCluster, err := c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+			return clearMigration(clone, i, migrationID, migratingSlot)
+		})
+		if err != nil {
+			report.addError("failed to clear the migrating slot %s of shard %d: %v", migratingSlot.String(), i, err)
+			continue
+		}
+		cluster = updatedCluster
+		c.finishMigrationJob(ctx, migrationID, "the source node reported no migration")
+		report.ClearedMigrations = append(report.ClearedMigrations, fmt.Sprintf("slot %s of shard %d", migratingSlot, i))
+	}
+	if resumed {
+		c.tryUpdateMigrationStatus(ctx, cluster)
+		c.clusterMu.Lock()
+		cluster = c.cluster
+		c.clusterMu.Unlock()
+	}
+
+	for _, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			if node.IsRestoring() {
+				continue
+			}
+			info, err := node.GetClusterInfo(ctx)
+			if err != nil {
+				report.addError("failed to get the cluster info of node %s: %v", node.Addr(), err)
+				continue
+			}
+			if info.CurrentEpoch >= cluster.Version.Load() {
+				continue
+			}
+			if err := node.SyncClusterInfo(ctx, cluster); err != nil {
+				report.addError("failed to sync the topology to node %s: %v", node.Addr(), err)
+				continue
+			}
+			report.SyncedNodes = append(report.SyncedNodes, node.Addr())
+		}
+	}
+	return report
+}
+
+// reconcileClusters reconciles all clusters once after becoming the leader, and logs
+// the report of each cluster.
+func (c *Controller) reconcileClusters() {
+	defer c.wg.Done()
+
+	c.mu.Lock()
+	checkers := make([]*ClusterChecker, 0, len(c.clusters))
+	for _, checker := range c.clusters {
+		checkers = append(checkers, checker)
+	}
+	c.mu.Unlock()
+
+	for _, checker := range checkers {
+		report := checker.reconcile(checker.ctx)
+		log := logger.Get().With(
+			zap.String("namespace", checker.namespace),
+			zap.String("cluster", checker.clusterName),
+			zap.Strings("resumed_migrations", report.ResumedMigrations),
+			zap.Strings("cleared_migrations", report.ClearedMigrations),
+			zap.Strings("synced_nodes", report.SyncedNodes),
+		)
+		if len(report.Errors) > 0 {
+			log.Warn("Reconcile the cluster on startup with errors", zap.Strings("errors", report.Errors))
+		} else {
+			log.Info("Reconcile the cluster on startup")
+		}
+	}
+}
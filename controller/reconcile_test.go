@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestCluster_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	sourceMaster := store.NewClusterMockNode()
+	sourceMaster.SetRole(store.RoleMaster)
+	// the source node lost the migration after restarting
+	sourceMaster.ClusterInfo = store.ClusterInfo{CurrentEpoch: 1, MigratingState: "none"}
+	targetMaster := store.NewClusterMockNode()
+	targetMaster.SetRole(store.RoleMaster)
+	// the version will be bumped to 6 after clearing the migrating flag
+	targetMaster.ClusterInfo = store.ClusterInfo{CurrentEpoch: 6}
+
+	sourceShard := store.NewShard()
+	sourceShard.Nodes = []store.Node{sourceMaster}
+	sourceShard.SlotRanges = []store.SlotRange{{Start: 0, Stop: 8191}}
+	sourceShard.MigratingSlot = store.FromSlotRange(store.SlotRange{Start: 0, Stop: 0})
+	sourceShard.TargetShardIndex = 1
+	sourceShard.MigrationID = "test-migration"
+	targetShard := store.NewShard()
+	targetShard.Nodes = []store.Node{targetMaster}
+	targetShard.SlotRanges = []store.SlotRange{{Start: 8192, Stop: 16383}}
+	cluster := &store.Cluster{Name: "test-cluster", Shards: []*store.Shard{sourceShard, targetShard}}
+	cluster.Version.Store(5)
+
+	s := NewMockClusterStore()
+	require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", cluster.NewMigrationJob(0)))
+	checker := NewClusterChecker(s, "test-ns", "test-cluster")
+	defer checker.Close()
+
+	// the reconciliation waits for the running migration update of the migration loop
+	checker.migrationUpdateMu.Lock()
+	reportCh := make(chan *reconcileReport, 1)
+	go func() {
+		reportCh <- checker.reconcile(ctx)
+	}()
+	select {
+	case <-reportCh:
+		require.Fail(t, "the reconciliation should wait for the migration loop")
+	case <-time.After(50 * time.Millisecond):
+	}
+	checker.migrationUpdateMu.Unlock()
+	report := <-reportCh
+	require.Empty(t, report.Errors)
+	require.Empty(t, report.ResumedMigrations)
+	require.Len(t, report.ClearedMigrations, 1)
+	// only the source master is out of date
+	require.Equal(t, []string{sourceMaster.Addr()}, report.SyncedNodes)
+
+	updatedCluster, err := s.GetCluster(ctx, "test-ns", "test-cluster")
+	require.NoError(t, err)
+	require.False(t, updatedCluster.Shards[0].IsMigrating())
+	require.EqualValues(t, 6, updatedCluster.Version.Load())
+	job, err := s.GetJob(ctx, "test-ns", "test-cluster", store.JobTypeMigration, "test-migration")
+	require.NoError(t, err)
+	require.Equal(t, store.JobStatusFailed, job.Status)
+}
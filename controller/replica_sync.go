@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// replicaSyncLoop tracks the initial replication sync of the added replicas,
+// and marks them as ready to be promoted after they caught up with the master.
+func (c *ClusterChecker) replicaSyncLoop() {
+	ticker, stopTicker := c.newTicker(c.options.replicaSyncInterval)
+	defer stopTicker()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.trackReplicaSyncs(c.ctx)
+		}
+	}
+}
+
+func (c *ClusterChecker) trackReplicaSyncs(ctx context.Context) {
+	c.clusterMu.Lock()
+	cluster := c.cluster
+	c.clusterMu.Unlock()
+	if cluster == nil {
+		return
+	}
+	for i, shard := range cluster.Shards {
+		master := shard.GetMasterNode()
+		if master == nil {
+			continue
+		}
+		for _, node := range shard.Nodes {
+			if node.IsSyncing() && !node.IsMaster() {
+				c.trackReplicaSync(ctx, i, master, node)
+			}
+		}
+	}
+}
+
+func (c *ClusterChecker) trackReplicaSync(ctx context.Context, shardIndex int, master, node store.Node) {
+	log := logger.Get().With(
+		zap.String("namespace", c.namespace),
+		zap.String("cluster", c.clusterName),
+		zap.Int("shard", shardIndex),
+		zap.String("node", node.Addr()),
+	)
+	job, err := store.GetReplicaSyncJob(ctx, c.clusterStore, c.namespace, c.clusterName, node.ID())
+	created := false
+	if errors.Is(err, consts.ErrNotFound) {
+		// the job might fail to be saved after the replica was added
+		job, created = store.NewReplicaSyncJob(shardIndex, node), true
+	} else if err != nil {
+		log.Warn("Failed to get the replica sync job", zap.Error(err))
+		return
+	} else if job.Status != store.JobStatusRunning {
+		// the job was finished but the cluster of the checker isn't refreshed yet
+		return
+	}
+	prev := *job.ReplicaSync
+	if err := job.ReplicaSync.Update(ctx, master, node); err != nil {
+		log.Warn("Failed to get the replica sync progress", zap.Error(err))
+		return
+	}
+	if !job.ReplicaSync.Ready(c.options.replicaSyncMaxLag) {
+		// only the status changes are saved rather than the offsets of every round
+		if created {
+			err = c.clusterStore.SaveJob(ctx, c.namespace, c.clusterName, job)
+		} else if job.ReplicaSync.StatusChanged(&prev) {
+			_, err = c.clusterStore.UpdateJob(ctx, c.namespace, c.clusterName, store.JobTypeReplicaSync, job.ID,
+				func(saved *store.Job) bool {
+					if saved.Status != store.JobStatusRunning {
+						return false
+					}
+					saved.ReplicaSync = job.ReplicaSync
+					return true
+				})
+		}
+		if err != nil {
+			log.Warn("Failed to save the replica sync job", zap.Error(err))
+		}
+		return
+	}
+
+	cluster, err := c.clusterStore.GetCluster(ctx, c.namespace, c.clusterName)
+	if err != nil {
+		log.Error("Failed to get the cluster info", zap.Error(err))
+		return
+	}
+	_, err = c.updateClusterWithRetry(ctx, cluster, func(clone *store.Cluster) error {
+		_, err := clone.SetNodeSyncing(shardIndex, node.ID(), false)
+		return err
+	})
+	if err != nil {
+		log.Error("Failed to mark the replica as synced", zap.Error(err))
+		return
+	}
+	job.FinishReplicaSync("")
+	c.saveFinishedJob(job)
+	log.With(
+		zap.Int64("master_offset", job.ReplicaSync.MasterOffset),
+		zap.Int64("replica_offset", job.ReplicaSync.ReplicaOffset),
+	).Info("The replica caught up with the master")
+}
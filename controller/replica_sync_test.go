@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func TestCluster_ReplicaSync(t *testing.T) {
+	ctx := context.Background()
+	ns, clusterName := "test-ns", "test-cluster"
+	master := store.NewClusterMockNode()
+	master.Replies = map[string]interface{}{"INFO": "# Replication\r\nrole:master\r\nmaster_repl_offset:5000\r\n"}
+	replica := store.NewClusterMockNode()
+	replica.SetRole(store.RoleSlave)
+	replica.SetSyncing(true)
+	replica.Replies = map[string]interface{}{
+		"INFO": "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:1\r\nslave_repl_offset:0\r\n",
+	}
+	cluster := &store.Cluster{Name: clusterName, Shards: []*store.Shard{{
+		Nodes:            []store.Node{master, replica},
+		SlotRanges:       []store.SlotRange{{Start: 0, Stop: store.MaxSlotID}},
+		TargetShardIndex: -1,
+	}}}
+	s := NewMockClusterStore()
+	require.NoError(t, s.CreateCluster(ctx, ns, cluster))
+
+	checker := NewClusterChecker(s, ns, clusterName)
+	checker.updateCluster(cluster)
+	checker.trackReplicaSyncs(ctx)
+	jobs, err := s.ListJobs(ctx, ns, clusterName, store.JobTypeReplicaSync)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, store.JobStatusRunning, jobs[0].Status)
+	require.True(t, jobs[0].ReplicaSync.SyncInProgress)
+
+	// the job isn't rewritten if only the offsets changed
+	replica.Replies["INFO"] = "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:1\r\nslave_repl_offset:100\r\n"
+	checker.trackReplicaSyncs(ctx)
+	jobs, err = s.ListJobs(ctx, ns, clusterName, store.JobTypeReplicaSync)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.EqualValues(t, 0, jobs[0].ReplicaSync.ReplicaOffset)
+
+	replica.Replies["INFO"] = "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\nmaster_sync_in_progress:1\r\nslave_repl_offset:200\r\n"
+	checker.trackReplicaSyncs(ctx)
+	jobs, err = s.ListJobs(ctx, ns, clusterName, store.JobTypeReplicaSync)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, "down", jobs[0].ReplicaSync.LinkStatus)
+	require.EqualValues(t, 200, jobs[0].ReplicaSync.ReplicaOffset)
+
+	replica.Replies["INFO"] = "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:0\r\nslave_repl_offset:4900\r\n"
+	checker.trackReplicaSyncs(ctx)
+	jobs, err = s.ListJobs(ctx, ns, clusterName, store.JobTypeReplicaSync)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, store.JobStatusSucceeded, jobs[0].Status)
+	require.EqualValues(t, 4900, jobs[0].ReplicaSync.ReplicaOffset)
+
+	updated, err := s.GetCluster(ctx, ns, clusterName)
+	require.NoError(t, err)
+	require.False(t, updated.Shards[0].Nodes[1].IsSyncing())
+	// the shared cluster shouldn't be changed in place
+	require.True(t, replica.IsSyncing())
+
+	// the finished job isn't recreated before the cluster of the checker is refreshed
+	checker.trackReplicaSyncs(ctx)
+	jobs, err = s.ListJobs(ctx, ns, clusterName, store.JobTypeReplicaSync)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, store.JobStatusSucceeded, jobs[0].Status)
+}
@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// checkReplicationStall returns ErrReplicationStalled if none of the connected replicas in the shard
+// received the replication stream within the max replication stall, the master may stall on writing
+// the disk while still answering the PING.
+func (c *ClusterChecker) checkReplicationStall(ctx context.Context, shard *store.Shard) error {
+	maxAge := int64(c.options.maxReplicationStall.Seconds())
+	stalledReplicas := 0
+	for _, node := range shard.Nodes {
+		if node.IsMaster() || node.IsRestoring() {
+			continue
+		}
+		age, err := store.ReplicationIOAge(ctx, node)
+		if err != nil || age < 0 {
+			// the unreachable or disconnected replica is judged by its own probe
+			continue
+		}
+		if age <= maxAge {
+			return nil
+		}
+		stalledReplicas++
+	}
+	if stalledReplicas == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d replicas didn't receive it in %s",
+		ErrReplicationStalled, stalledReplicas, c.options.maxReplicationStall)
+}
@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+)
+
+func replicationInfo(lastIOSecondsAgo string) string {
+	return "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:" + lastIOSecondsAgo + "\r\n"
+}
+
+func TestCluster_ReplicationStall(t *testing.T) {
+	ctx := context.Background()
+	master := store.NewClusterMockNode()
+	master.SetRole(store.RoleMaster)
+	replica0 := store.NewClusterMockNode()
+	replica0.SetRole(store.RoleSlave)
+	replica0.Replies = map[string]interface{}{"INFO": replicationInfo("120")}
+	replica1 := store.NewClusterMockNode()
+	replica1.SetRole(store.RoleSlave)
+	// the disconnected replica is ignored
+	replica1.Replies = map[string]interface{}{"INFO": "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\n"}
+	shard := store.NewShard()
+	shard.Nodes = []store.Node{master, replica0, replica1}
+
+	checker := NewClusterChecker(NewMockClusterStore(), "test-ns", "test-cluster").
+		WithMaxReplicationStall(time.Minute)
+	err := checker.checkReplicationStall(ctx, shard)
+	require.ErrorIs(t, err, ErrReplicationStalled)
+
+	// the master is healthy if any replica received the replication stream recently
+	replica1.Replies["INFO"] = replicationInfo("1")
+	require.NoError(t, checker.checkReplicationStall(ctx, shard))
+
+	// the unreachable replicas can't tell whether the master stalls
+	replica0.Replies["INFO"] = errors.New("connection refused")
+	replica1.Replies["INFO"] = errors.New("connection refused")
+	require.NoError(t, checker.checkReplicationStall(ctx, shard))
+}
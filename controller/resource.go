@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// nodeLimiter bounds the concurrent goroutines which probe or sync the nodes, the nil limiter means no limit.
+type nodeLimiter chan struct{}
+
+func newNodeLimiter(size int) nodeLimiter {
+	if size <= 0 {
+		return nil
+	}
+	return make(nodeLimiter, size)
+}
+
+func (l nodeLimiter) acquire(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l nodeLimiter) release() {
+	if l != nil {
+		<-l
+	}
+}
+
+// ResourceUsage is the resources used by the cluster checker, so that the operators
+// can find out the expensive clusters when the controller is checking many clusters.
+type ResourceUsage struct {
+	Goroutines int64 `json:"goroutines"`
+	Timers     int64 `json:"timers"`
+	RedisConns int64 `json:"redis_conns"`
+}
+
+// goTracked runs the function in the goroutine counted by the checker, the wait group is optional
+func (c *ClusterChecker) goTracked(wg *sync.WaitGroup, fn func()) {
+	if wg != nil {
+		wg.Add(1)
+	}
+	c.goroutines.Add(1)
+	go func() {
+		defer func() {
+			c.goroutines.Add(-1)
+			if wg != nil {
+				wg.Done()
+			}
+		}()
+		fn()
+	}()
+}
+
+// goNode runs the node task in the tracked goroutine bounded by the node limiters of the cluster
+// and the controller, it blocks until both limiters were acquired and returns false if the context
+// was canceled while waiting.
+func (c *ClusterChecker) goNode(ctx context.Context, wg *sync.WaitGroup, fn func()) bool {
+	if !c.nodeLimiter.acquire(ctx) {
+		return false
+	}
+	if !c.globalNodeLimiter.acquire(ctx) {
+		c.nodeLimiter.release()
+		return false
+	}
+	c.goTracked(wg, func() {
+		defer c.nodeLimiter.release()
+		defer c.globalNodeLimiter.release()
+		fn()
+	})
+	return true
+}
+
+// newTicker returns the ticker counted by the checker, the stop function should be called to release it
+func (c *ClusterChecker) newTicker(interval time.Duration) (*time.Ticker, func()) {
+	c.timers.Add(1)
+	ticker := time.NewTicker(interval)
+	return ticker, func() {
+		ticker.Stop()
+		c.timers.Add(-1)
+	}
+}
+
+// ResourceUsage returns the resources used by the checker, the redis connections
+// are counted from the client pools of the cluster nodes.
+func (c *ClusterChecker) ResourceUsage() ResourceUsage {
+	c.clusterMu.Lock()
+	cluster := c.cluster
+	c.clusterMu.Unlock()
+
+	usage := ResourceUsage{
+		Goroutines: c.goroutines.Load(),
+		Timers:     c.timers.Load(),
+	}
+	if cluster == nil {
+		return usage
+	}
+	for _, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			usage.RedisConns += int64(store.NodeConnCount(node))
+		}
+	}
+	return usage
+}
+
+func (c *ClusterChecker) observeResources() {
+	usage := c.ResourceUsage()
+	metrics.Get().CheckerGoroutines.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName)...).Set(float64(usage.Goroutines))
+	metrics.Get().CheckerTimers.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName)...).Set(float64(usage.Timers))
+	metrics.Get().CheckerRedisConns.WithLabelValues(metrics.ClusterLabelValues(c.namespace, c.clusterName)...).Set(float64(usage.RedisConns))
+}
+
+func (c *ClusterChecker) clearResourceMetrics() {
+	metrics.Get().CheckerGoroutines.DeletePartialMatch(metrics.ClusterLabels(c.namespace, c.clusterName))
+	metrics.Get().CheckerTimers.DeletePartialMatch(metrics.ClusterLabels(c.namespace, c.clusterName))
+	metrics.Get().CheckerRedisConns.DeletePartialMatch(metrics.ClusterLabels(c.namespace, c.clusterName))
+}
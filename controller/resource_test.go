@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterChecker_NodeConcurrency(t *testing.T) {
+	ctx := context.Background()
+	global := newNodeLimiter(3)
+	checker0 := NewClusterChecker(NewMockClusterStore(), "ns", "cluster0").WithMaxNodeConcurrency(2).WithGlobalNodeLimiter(global)
+	checker1 := NewClusterChecker(NewMockClusterStore(), "ns", "cluster1").WithMaxNodeConcurrency(2).WithGlobalNodeLimiter(global)
+
+	// the max running tasks of the controller and each checker
+	var running, maxRunning atomic.Int64
+	var checkerRunning, checkerMaxRunning [2]atomic.Int64
+	observeMax := func(maxValue *atomic.Int64, value int64) {
+		for {
+			observed := maxValue.Load()
+			if value <= observed || maxValue.CompareAndSwap(observed, value) {
+				return
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	var spawnWg sync.WaitGroup
+	for i, checker := range []*ClusterChecker{checker0, checker1} {
+		spawnWg.Add(1)
+		go func() {
+			defer spawnWg.Done()
+			for j := 0; j < 5; j++ {
+				checker.goNode(ctx, &wg, func() {
+					observeMax(&maxRunning, running.Add(1))
+					observeMax(&checkerMaxRunning[i], checkerRunning[i].Add(1))
+					time.Sleep(10 * time.Millisecond)
+					checkerRunning[i].Add(-1)
+					running.Add(-1)
+				})
+			}
+		}()
+	}
+	spawnWg.Wait()
+	wg.Wait()
+	require.EqualValues(t, 3, maxRunning.Load())
+	require.EqualValues(t, 2, checkerMaxRunning[0].Load())
+	require.EqualValues(t, 2, checkerMaxRunning[1].Load())
+	require.Zero(t, checker0.ResourceUsage().Goroutines)
+	require.Zero(t, checker1.ResourceUsage().Goroutines)
+
+	// the task is skipped if the limiter can't be acquired before the context was canceled
+	blocked := newNodeLimiter(1)
+	require.True(t, blocked.acquire(ctx))
+	checker := NewClusterChecker(NewMockClusterStore(), "ns", "cluster2").WithGlobalNodeLimiter(blocked)
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.False(t, checker.goNode(cancelCtx, &wg, func() {}))
+}
+
+func TestClusterChecker_ResourceUsage(t *testing.T) {
+	s := NewMockClusterStore()
+	cluster, err := store.NewCluster("cluster", []string{"127.0.0.1:1111"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(context.Background(), "ns", cluster))
+
+	checker := NewClusterChecker(s, "ns", "cluster")
+	checker.Start()
+	require.Eventually(t, func() bool {
+		usage := checker.ResourceUsage()
+		return usage.Goroutines == 6 && usage.Timers == 6
+	}, time.Second, 10*time.Millisecond)
+	checker.Close()
+	usage := checker.ResourceUsage()
+	require.Zero(t, usage.Goroutines)
+	require.Zero(t, usage.Timers)
+}
+
+func TestController_MaxClusters(t *testing.T) {
+	ns := "test-ns"
+	s := store.NewClusterStore(engine.NewMock())
+	c, err := New(s, &config.ControllerConfig{
+		FailOver:  &config.FailOverConfig{PingIntervalSeconds: 1},
+		Resources: &config.ResourceConfig{MaxClusters: 1},
+	})
+	require.NoError(t, err)
+	defer c.suspend()
+
+	c.addCluster(ns, "cluster0")
+	c.addCluster(ns, "cluster1")
+	_, err = c.getCluster(ns, "cluster0")
+	require.NoError(t, err)
+	_, err = c.getCluster(ns, "cluster1")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	// the unchecked cluster will be checked after the checked cluster was removed
+	c.removeCluster(ns, "cluster0")
+	_, err = c.getCluster(ns, "cluster1")
+	require.NoError(t, err)
+	require.Empty(t, c.uncheckedClusters)
+
+	// the removed unchecked cluster won't be checked
+	c.addCluster(ns, "cluster2")
+	require.Len(t, c.uncheckedClusters, 1)
+	c.removeCluster(ns, "cluster2")
+	require.Empty(t, c.uncheckedClusters)
+}
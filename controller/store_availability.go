@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+const (
+	// storeUnavailableFailures is the consecutive failures of a checker to regard the store as unavailable
+	storeUnavailableFailures = 3
+	// maxStoreBackoff is the max interval of the checker to retry reading the cluster from the store
+	maxStoreBackoff = 30 * time.Second
+)
+
+// StoreAvailability aggregates the failures of the checkers reading the clusters from the metadata
+// store, so the outage is reported once instead of every checker logging it on every tick.
+type StoreAvailability struct {
+	// onChange is called without the lock held when the availability was changed, the err
+	// and unavailableAt are the first error and the start time of the outage.
+	onChange func(available bool, err error, unavailableAt time.Time)
+
+	mu            sync.Mutex
+	available     bool
+	recoveredAt   time.Time
+	unavailableAt time.Time
+	err           error
+}
+
+func NewStoreAvailability() *StoreAvailability {
+	return &StoreAvailability{available: true}
+}
+
+// Available returns whether the store is available
+func (a *StoreAvailability) Available() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.available
+}
+
+// RecoveredSince returns whether the store recovered from the outage after the time
+func (a *StoreAvailability) RecoveredSince(t time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.available && a.recoveredAt.After(t)
+}
+
+// ReportFailure marks the store unavailable if the checker failed too many times in a row
+func (a *StoreAvailability) ReportFailure(failures int, err error) {
+	if failures < storeUnavailableFailures {
+		return
+	}
+	a.mu.Lock()
+	changed := a.available
+	if changed {
+		a.available = false
+		a.unavailableAt = time.Now()
+		a.err = err
+	}
+	unavailableAt, onChange := a.unavailableAt, a.onChange
+	a.mu.Unlock()
+	if changed && onChange != nil {
+		onChange(false, err, unavailableAt)
+	}
+}
+
+// ReportSuccess marks the store available once any checker read the cluster successfully
+func (a *StoreAvailability) ReportSuccess() {
+	a.mu.Lock()
+	changed := !a.available
+	if changed {
+		a.available = true
+		a.recoveredAt = time.Now()
+	}
+	err, unavailableAt, onChange := a.err, a.unavailableAt, a.onChange
+	a.mu.Unlock()
+	if changed && onChange != nil {
+		onChange(true, err, unavailableAt)
+	}
+}
+
+// storeBackoff delays the next attempt of the checker to read the cluster from the store exponentially
+// with the jitter, which avoids all checkers hammering the store at the same time after the outage.
+type storeBackoff struct {
+	failures int
+	failedAt time.Time
+	retryAt  time.Time
+}
+
+// fail records the failure and returns the delay of the next attempt
+func (b *storeBackoff) fail(now time.Time, interval time.Duration) time.Duration {
+	b.failures++
+	b.failedAt = now
+	backoff := interval
+	for i := 1; i < b.failures && backoff < maxStoreBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxStoreBackoff)
+	// the jitter is up to the half of the backoff
+	delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	b.retryAt = now.Add(delay)
+	return delay
+}
+
+// wait returns true if the attempt should be skipped, the backoff is reset if the store
+// recovered since the last failure which was probably detected by other checkers.
+func (b *storeBackoff) wait(now time.Time, availability *StoreAvailability) bool {
+	if b.failures == 0 || !now.Before(b.retryAt) {
+		return false
+	}
+	if availability != nil && availability.RecoveredSince(b.failedAt) {
+		b.retryAt = now
+		return false
+	}
+	return true
+}
+
+func (b *storeBackoff) reset() int {
+	failures := b.failures
+	*b = storeBackoff{}
+	return failures
+}
+
+// isStoreUnavailable returns whether the error is caused by the store rather than the cluster itself
+func isStoreUnavailable(err error) bool {
+	return err != nil && !errors.Is(err, consts.ErrNotFound)
+}
+
+// onStoreAvailabilityChange only logs and updates the gauge while the store is unavailable, since
+// the event can't be written to the store either, the outage is recorded as an event after recovery.
+func (c *Controller) onStoreAvailabilityChange(available bool, err error, unavailableAt time.Time) {
+	if !available {
+		metrics.Get().StoreAvailable.WithLabelValues().Set(0)
+		logger.Get().Error(fmt.Sprintf("the cluster checkers failed to read the metadata store on the controller %s, "+
+			"they will retry with the backoff: %v", c.clusterStore.ID(), err))
+		return
+	}
+	metrics.Get().StoreAvailable.WithLabelValues().Set(1)
+	message := fmt.Sprintf("the cluster checkers resumed reading the metadata store on the controller %s, "+
+		"which was unavailable for %s since %s: %v", c.clusterStore.ID(),
+		time.Since(unavailableAt).Round(time.Millisecond), unavailableAt.Format(time.RFC3339), err)
+	logger.Get().Info(message)
+	c.clusterStore.EmitEvent(store.EventPayload{
+		Type:    store.EventEngine,
+		Command: store.CommandUpdate,
+		Message: message,
+	})
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestStoreBackoff(t *testing.T) {
+	var backoff storeBackoff
+	now := time.Now()
+	interval := time.Second
+	require.False(t, backoff.wait(now, nil))
+
+	expected := interval
+	for i := 0; i < 10; i++ {
+		delay := backoff.fail(now, interval)
+		require.GreaterOrEqual(t, delay, expected/2)
+		require.LessOrEqual(t, delay, expected)
+		require.True(t, backoff.wait(now, nil))
+		require.False(t, backoff.wait(now.Add(delay), nil))
+		expected = min(expected*2, maxStoreBackoff)
+	}
+	require.Equal(t, 10, backoff.reset())
+	require.False(t, backoff.wait(now, nil))
+
+	// resume promptly once the store recovered after the last failure
+	availability := NewStoreAvailability()
+	backoff.fail(time.Now(), time.Minute)
+	require.True(t, backoff.wait(time.Now(), availability))
+	availability.ReportFailure(storeUnavailableFailures, errors.New("timeout"))
+	require.True(t, backoff.wait(time.Now(), availability))
+	availability.ReportSuccess()
+	require.False(t, backoff.wait(time.Now(), availability))
+}
+
+func TestStoreAvailability(t *testing.T) {
+	availability := NewStoreAvailability()
+	err := errors.New("context deadline exceeded")
+	var changes []bool
+	var outageStart time.Time
+	availability.onChange = func(available bool, changeErr error, unavailableAt time.Time) {
+		changes = append(changes, available)
+		// the recovery reports the error and the start time of the outage
+		require.Equal(t, err, changeErr)
+		if !available {
+			outageStart = unavailableAt
+		}
+		require.Equal(t, outageStart, unavailableAt)
+	}
+
+	for failures := 1; failures < storeUnavailableFailures; failures++ {
+		availability.ReportFailure(failures, err)
+		require.True(t, availability.Available())
+	}
+	// the outage is reported once by all checkers
+	availability.ReportFailure(storeUnavailableFailures, err)
+	availability.ReportFailure(storeUnavailableFailures+1, errors.New("connection refused"))
+	require.False(t, availability.Available())
+	require.Equal(t, []bool{false}, changes)
+
+	availability.ReportSuccess()
+	availability.ReportSuccess()
+	require.True(t, availability.Available())
+	require.Equal(t, []bool{false, true}, changes)
+
+	require.True(t, isStoreUnavailable(err))
+	require.False(t, isStoreUnavailable(consts.ErrNotFound))
+	require.False(t, isStoreUnavailable(nil))
+}
+
+func TestController_OnStoreAvailabilityChange(t *testing.T) {
+	s := store.NewClusterStore(engine.NewMock())
+	c, err := New(s, &config.ControllerConfig{FailOver: &config.FailOverConfig{PingIntervalSeconds: 1}})
+	require.NoError(t, err)
+
+	// no event is emitted while the store is unavailable
+	err = errors.New("context deadline exceeded")
+	unavailableAt := time.Now().Add(-time.Minute)
+	c.onStoreAvailabilityChange(false, err, unavailableAt)
+	require.Len(t, s.Notify(), 0)
+
+	c.onStoreAvailabilityChange(true, err, unavailableAt)
+	require.Len(t, s.Notify(), 1)
+	event := <-s.Notify()
+	require.Equal(t, store.EventEngine, event.Type)
+	require.Contains(t, event.Message, err.Error())
+}
@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// supervisorInterval is the interval to replay the cluster events persisted by the other controllers
+const supervisorInterval = 5 * time.Second
+
+// The leader creates and destroys the cluster checkers by the cluster events. The events emitted by
+// this controller are received from the store notify channel in leaderEventLoop, but the clusters
+// might be created or removed by the other controller instances, e.g. the previous leader during the
+// leadership transition, whose events can only be observed from the persisted event log through the
+// engine watch. So the supervisor replays the event log since the last seen event, and resyncs the
+// checkers with the stored clusters if some events were missed since they fell out of the window,
+// or were dropped from the event log which is marked by the gap event.
+
+func (c *Controller) supervisorLoop(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(supervisorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !c.clusterStore.IsLeader() {
+				continue
+			}
+			if err := c.replayClusterEvents(ctx); err != nil {
+				logger.Get().Warn("Failed to replay the cluster events", zap.Error(err))
+			}
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// markEventsSeen skips the persisted events before resuming the clusters, since the
+// checkers of all clusters will be created by listing the clusters.
+func (c *Controller) markEventsSeen(ctx context.Context) error {
+	lastEventID, err := c.clusterStore.Events().LastID(ctx)
+	if err != nil {
+		return err
+	}
+	c.lastEventID.Store(lastEventID)
+	return nil
+}
+
+// replayClusterEvents applies the cluster events which were appended after the last seen event,
+// only the latest event of each cluster is applied since the checker is created or destroyed
+// by the final state of the cluster.
+func (c *Controller) replayClusterEvents(ctx context.Context) error {
+	lastEventID := c.lastEventID.Load()
+	events, err := c.clusterStore.Events().Since(ctx, lastEventID)
+	if err != nil {
+		return fmt.Errorf("failed to list the events: %w", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if events[0].ID > lastEventID+1 || hasEventGap(events) {
+		if err := c.resyncCheckers(ctx); err != nil {
+			return err
+		}
+		c.lastEventID.Store(events[len(events)-1].ID)
+		return nil
+	}
+
+	keys := make([]string, 0)
+	latestEvents := make(map[string]store.Event)
+	for _, event := range events {
+		if event.Type != store.EventCluster.String() {
+			continue
+		}
+		key := c.buildClusterKey(event.Namespace, event.Cluster)
+		if _, ok := latestEvents[key]; !ok {
+			keys = append(keys, key)
+		}
+		latestEvents[key] = event
+	}
+	for _, key := range keys {
+		event := latestEvents[key]
+		switch event.Command {
+		case store.Command(store.CommandCreate).String(), store.Command(store.CommandUpdate).String():
+			// the cluster might be removed after listing the events
+			if _, err := c.clusterStore.GetCluster(ctx, event.Namespace, event.Cluster); errors.Is(err, consts.ErrNotFound) {
+				c.removeCluster(event.Namespace, event.Cluster)
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to get the cluster %s: %w", key, err)
+			}
+			c.addCluster(event.Namespace, event.Cluster)
+		case store.Command(store.CommandRemove).String():
+			c.removeCluster(event.Namespace, event.Cluster)
+		}
+	}
+	c.lastEventID.Store(events[len(events)-1].ID)
+	return nil
+}
+
+// hasEventGap returns true if some events were dropped from the event log in between
+func hasEventGap(events []store.Event) bool {
+	for _, event := range events {
+		if event.Command == store.Command(store.CommandGap).String() {
+			return true
+		}
+	}
+	return false
+}
+
+// resyncCheckers creates the checkers of the stored clusters and destroys the ones whose clusters were removed
+func (c *Controller) resyncCheckers(ctx context.Context) error {
+	namespaces, err := c.clusterStore.ListNamespace(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	stored := make(map[string]struct{})
+	for _, ns := range namespaces {
+		clusters, err := c.clusterStore.ListCluster(ctx, ns)
+		if err != nil {
+			return fmt.Errorf("failed to list clusters: %w", err)
+		}
+		for _, cluster := range clusters {
+			stored[c.buildClusterKey(ns, cluster)] = struct{}{}
+			c.addCluster(ns, cluster)
+		}
+	}
+
+	c.mu.Lock()
+	removed := make([]clusterRef, 0)
+	for key, checker := range c.clusters {
+		if _, ok := stored[key]; !ok {
+			removed = append(removed, clusterRef{namespace: checker.namespace, cluster: checker.clusterName})
+		}
+	}
+	for key, cluster := range c.uncheckedClusters {
+		if _, ok := stored[key]; !ok {
+			removed = append(removed, cluster)
+		}
+	}
+	c.mu.Unlock()
+	for _, cluster := range removed {
+		c.removeCluster(cluster.namespace, cluster.cluster)
+	}
+	logger.Get().With(
+		zap.Int("clusters", len(stored)),
+		zap.Int("removed", len(removed)),
+	).Info("Resynced the cluster checkers since some events were missed")
+	return nil
+}
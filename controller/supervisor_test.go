@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestController_ReplayClusterEvents(t *testing.T) {
+	ctx := context.Background()
+	ns := "test-ns"
+	s := store.NewClusterStore(engine.NewMock())
+	c, err := New(s, &config.ControllerConfig{
+		FailOver: &config.FailOverConfig{
+			PingIntervalSeconds: 1,
+		},
+	})
+	require.NoError(t, err)
+	defer c.suspend()
+	// the events are appended asynchronously
+	waitForEvents := func(lastID int64) {
+		require.Eventually(t, func() bool {
+			id, err := s.Events().LastID(ctx)
+			return err == nil && id >= lastID
+		}, time.Second, 5*time.Millisecond)
+	}
+	require.NoError(t, s.CreateNamespace(ctx, ns))
+	waitForEvents(1)
+	require.NoError(t, c.markEventsSeen(ctx))
+
+	// the events were appended by the other controller
+	cluster0, err := store.NewCluster("test-cluster-0", []string{"127.0.0.1:1111"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, ns, cluster0))
+	waitForEvents(2)
+	require.NoError(t, c.replayClusterEvents(ctx))
+	_, err = c.getCluster(ns, "test-cluster-0")
+	require.NoError(t, err)
+
+	require.NoError(t, s.RemoveCluster(ctx, ns, "test-cluster-0"))
+	waitForEvents(3)
+	require.NoError(t, c.replayClusterEvents(ctx))
+	_, err = c.getCluster(ns, "test-cluster-0")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	// the created and removed cluster won't be checked
+	cluster1, err := store.NewCluster("test-cluster-1", []string{"127.0.0.1:2222"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, ns, cluster1))
+	require.NoError(t, s.RemoveCluster(ctx, ns, "test-cluster-1"))
+	waitForEvents(5)
+	require.NoError(t, c.replayClusterEvents(ctx))
+	_, err = c.getCluster(ns, "test-cluster-1")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	// the checkers are resynced with the stored clusters if the events fell out of the window
+	cluster2, err := store.NewCluster("test-cluster-2", []string{"127.0.0.1:3333"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, ns, cluster2))
+	waitForEvents(6)
+	c.addCluster(ns, "test-cluster-3")
+	for i := 0; i < store.MaxEventLogSize; i++ {
+		_, err := s.Events().Append(ctx, store.EventPayload{Namespace: ns, Type: store.EventNamespace, Command: store.CommandUpdate})
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.replayClusterEvents(ctx))
+	_, err = c.getCluster(ns, "test-cluster-2")
+	require.NoError(t, err)
+	_, err = c.getCluster(ns, "test-cluster-3")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	lastEventID, err := s.Events().LastID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, lastEventID, c.lastEventID.Load())
+
+	// the checkers are also resynced if some events were dropped from the event log
+	c.addCluster(ns, "test-cluster-4")
+	_, err = s.Events().Append(ctx, store.EventPayload{Type: store.EventEngine, Command: store.CommandGap})
+	require.NoError(t, err)
+	require.NoError(t, c.replayClusterEvents(ctx))
+	_, err = c.getCluster(ns, "test-cluster-4")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+}
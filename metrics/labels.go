@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package metrics
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// staticLabels are the labels of the namespaces and clusters which are added to the per-cluster
+// metrics, e.g. the team and environment. Only the configured keys are added since the label
+// names of the metric are fixed after it's registered.
+var staticLabels = struct {
+	mu     sync.RWMutex
+	keys   []string
+	values map[string][]string
+}{values: make(map[string][]string)}
+
+func staticLabelsKey(ns, cluster string) string {
+	return ns + "/" + cluster
+}
+
+// clusterRegistry is the registry of the per-cluster metrics
+var clusterRegistry atomic.Pointer[prometheus.Registry]
+
+// clusterVecs are the per-cluster metrics, they're used to delete the series of the cluster
+var clusterVecs atomic.Pointer[[]*prometheus.MetricVec]
+
+// SetStaticLabelKeys recreates the per-cluster metrics with the static label keys,
+// it should be called before emitting any metric since the old series are dropped.
+func SetStaticLabelKeys(keys []string) {
+	staticLabels.mu.Lock()
+	staticLabels.keys = append([]string(nil), keys...)
+	staticLabels.values = make(map[string][]string)
+	staticLabels.mu.Unlock()
+
+	setupClusterMetrics(prometheus.NewRegistry())
+}
+
+// SetStaticLabels sets the values of the static label keys of the cluster, the value of the absent
+// key is empty. The series with the old values are deleted if the values were changed, otherwise
+// they would be exported forever.
+func SetStaticLabels(ns, cluster string, labels map[string]string) {
+	staticLabels.mu.Lock()
+	defer staticLabels.mu.Unlock()
+	if len(staticLabels.keys) == 0 {
+		return
+	}
+	values := make([]string, len(staticLabels.keys))
+	for i, key := range staticLabels.keys {
+		values[i] = labels[key]
+	}
+	key := staticLabelsKey(ns, cluster)
+	oldValues, ok := staticLabels.values[key]
+	staticLabels.values[key] = values
+	if !ok || slices.Equal(oldValues, values) {
+		return
+	}
+	oldLabels := ClusterLabels(ns, cluster)
+	for i, key := range staticLabels.keys {
+		oldLabels[key] = oldValues[i]
+	}
+	if vecs := clusterVecs.Load(); vecs != nil {
+		for _, vec := range *vecs {
+			vec.DeletePartialMatch(oldLabels)
+		}
+	}
+}
+
+// DeleteStaticLabels removes the static label values of the removed cluster
+func DeleteStaticLabels(ns, cluster string) {
+	staticLabels.mu.Lock()
+	defer staticLabels.mu.Unlock()
+	delete(staticLabels.values, staticLabelsKey(ns, cluster))
+}
+
+// ClusterLabelValues returns the label values of the per-cluster metrics,
+// which are the namespace, cluster, values and the static label values.
+func ClusterLabelValues(ns, cluster string, values ...string) []string {
+	staticLabels.mu.RLock()
+	defer staticLabels.mu.RUnlock()
+	labelValues := make([]string, 0, 2+len(values)+len(staticLabels.keys))
+	labelValues = append(labelValues, ns, cluster)
+	labelValues = append(labelValues, values...)
+	if staticValues, ok := staticLabels.values[staticLabelsKey(ns, cluster)]; ok {
+		return append(labelValues, staticValues...)
+	}
+	return append(labelValues, make([]string, len(staticLabels.keys))...)
+}
+
+// ClusterLabels matches the series of the cluster no matter what the static label values are,
+// it's used to delete the series of the removed cluster.
+func ClusterLabels(ns, cluster string) prometheus.Labels {
+	return prometheus.Labels{"namespace": ns, "cluster": cluster}
+}
+
+func clusterLabelNames(names ...string) []string {
+	staticLabels.mu.RLock()
+	defer staticLabels.mu.RUnlock()
+	labelNames := make([]string, 0, 2+len(names)+len(staticLabels.keys))
+	labelNames = append(labelNames, "namespace", "cluster")
+	labelNames = append(labelNames, names...)
+	return append(labelNames, staticLabels.keys...)
+}
@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticLabels(t *testing.T) {
+	SetStaticLabelKeys([]string{"team"})
+	defer SetStaticLabelKeys(nil)
+
+	require.Equal(t, []string{"ns", "cluster", "failed", ""}, ClusterLabelValues("ns", "cluster", "failed"))
+	SetStaticLabels("ns", "cluster", map[string]string{"team": "storage", "unlisted": "ignored"})
+	require.Equal(t, []string{"ns", "cluster", "failed", "storage"}, ClusterLabelValues("ns", "cluster", "failed"))
+
+	Get().FinishedMigrations.WithLabelValues(ClusterLabelValues("ns", "cluster", "failed")...).Inc()
+	require.EqualValues(t, 1, testutil.ToFloat64(Get().FinishedMigrations.WithLabelValues("ns", "cluster", "failed", "storage")))
+	require.Equal(t, 1, Get().FinishedMigrations.DeletePartialMatch(ClusterLabels("ns", "cluster")))
+
+	// the series with the old label values are deleted after the values were changed
+	Get().FinishedMigrations.WithLabelValues(ClusterLabelValues("ns", "cluster", "failed")...).Inc()
+	Get().FailingNodes.WithLabelValues(ClusterLabelValues("ns", "cluster")...).Set(1)
+	Get().FailingNodes.WithLabelValues(ClusterLabelValues("ns", "other-cluster")...).Set(1)
+	SetStaticLabels("ns", "cluster", map[string]string{"team": "storage"})
+	require.Equal(t, 1, testutil.CollectAndCount(Get().FinishedMigrations))
+	SetStaticLabels("ns", "cluster", map[string]string{"team": "cache"})
+	require.Equal(t, 0, testutil.CollectAndCount(Get().FinishedMigrations))
+	require.Equal(t, 1, testutil.CollectAndCount(Get().FailingNodes))
+	require.Equal(t, []string{"ns", "cluster", "cache"}, ClusterLabelValues("ns", "cluster"))
+
+	DeleteStaticLabels("ns", "cluster")
+	require.Equal(t, []string{"ns", "cluster", ""}, ClusterLabelValues("ns", "cluster"))
+}
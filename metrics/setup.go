@@ -30,6 +30,43 @@ type performanceMetrics struct {
 	HTTPCodes        *prometheus.CounterVec
 	Payload          *prometheus.CounterVec
 	HTTPServerPanics *prometheus.CounterVec
+	// MapSizes is the size of the in-memory maps which are exported or swept periodically
+	MapSizes *prometheus.GaugeVec
+	// SlowNodeCommands counts the node commands which exceed the slow command threshold
+	SlowNodeCommands *prometheus.CounterVec
+	// LastBackupAge is the age in seconds of the last succeeded backup of the cluster
+	LastBackupAge *prometheus.GaugeVec
+	// FailingNodes is the number of the nodes which failed the last probe in the cluster
+	FailingNodes *prometheus.GaugeVec
+	// FailingNodeRatio is the ratio of the failing nodes to all nodes in the cluster
+	FailingNodeRatio *prometheus.GaugeVec
+	// FailoverDecisions counts the automatic failover decisions of the cluster by the result
+	FailoverDecisions *prometheus.CounterVec
+	// EngineHealthy is 1 if the metadata store engine is healthy, otherwise 0
+	EngineHealthy *prometheus.GaugeVec
+	// EngineProbeLatency is the latency in seconds of the last engine health probe
+	EngineProbeLatency *prometheus.GaugeVec
+	// StoreAvailable is 0 if the cluster checkers failed to read the clusters from the store, otherwise 1
+	StoreAvailable *prometheus.GaugeVec
+	// CheckerGoroutines, CheckerTimers and CheckerRedisConns are the resources used by the cluster checker
+	CheckerGoroutines *prometheus.GaugeVec
+	CheckerTimers     *prometheus.GaugeVec
+	CheckerRedisConns *prometheus.GaugeVec
+	// UncheckedClusters is the number of the clusters which aren't checked since the max clusters was reached
+	UncheckedClusters *prometheus.GaugeVec
+	// StoreEvents counts the events emitted by the store by the event type and command
+	StoreEvents *prometheus.CounterVec
+	// ClusterUpdateMutations and ClusterUpdateWrites count the coalesced cluster mutations and
+	// the engine writes of them, the mutations were coalesced if there're more mutations than writes.
+	ClusterUpdateMutations *prometheus.CounterVec
+	ClusterUpdateWrites    *prometheus.CounterVec
+	// FinishedMigrations counts the finished slot migration jobs by the status,
+	// which is one of succeeded, failed and cancelled.
+	FinishedMigrations *prometheus.CounterVec
+	// ClusterCodecLatency and ClusterCodecBytes are the latency in seconds and the size
+	// of the cluster documents encoded and decoded by the store, labeled by the codec and op.
+	ClusterCodecLatency *prometheus.HistogramVec
+	ClusterCodecBytes   *prometheus.HistogramVec
 }
 
 var _metrics *performanceMetrics
@@ -69,6 +106,20 @@ func NewCounterHelper(ns, subsystem, name string, labels ...string) *prometheus.
 	return counters
 }
 
+// NewGaugeHelper was used to fast create and register prometheus gauge metric
+func NewGaugeHelper(ns, subsystem, name string, labels ...string) *prometheus.GaugeVec {
+	ns = strings.ReplaceAll(ns, "-", "_")
+	subsystem = strings.ReplaceAll(subsystem, "-", "_")
+	opts := prometheus.GaugeOpts{}
+	opts.Namespace = ns
+	opts.Subsystem = subsystem
+	opts.Name = name
+	opts.Help = name
+	gauges := prometheus.NewGaugeVec(opts, labels)
+	prometheus.MustRegister(gauges)
+	return gauges
+}
+
 func setupMetrics() {
 	labels := []string{"host", "uri", "method", "code"}
 	buckets := prometheus.ExponentialBuckets(1, 2, 16)
@@ -82,7 +133,67 @@ func setupMetrics() {
 		Latencies: newHistogram("request_latency", labels...),
 		HTTPCodes: newCounter("http_code", labels...),
 		Payload:   newCounter("http_payload", labels...),
+
+		MapSizes: NewGaugeHelper(_namespace, _subsystem, "map_size", "map"),
+
+		EngineHealthy:      NewGaugeHelper(_namespace, _subsystem, "store_engine_healthy"),
+		EngineProbeLatency: NewGaugeHelper(_namespace, _subsystem, "store_engine_probe_latency_seconds"),
+		StoreAvailable:     NewGaugeHelper(_namespace, _subsystem, "store_available"),
+
+		UncheckedClusters: NewGaugeHelper(_namespace, _subsystem, "unchecked_clusters"),
+		StoreEvents:       newCounter("store_event", "type", "command"),
+
+		ClusterCodecLatency: NewHistogramHelper(_namespace, _subsystem, "cluster_codec_latency_seconds",
+			prometheus.ExponentialBuckets(0.00001, 2, 16), "codec", "op"),
+		ClusterCodecBytes: NewHistogramHelper(_namespace, _subsystem, "cluster_codec_bytes",
+			prometheus.ExponentialBuckets(256, 2, 16), "codec", "op"),
+	}
+	setupClusterMetrics(prometheus.NewRegistry())
+}
+
+// setupClusterMetrics creates the per-cluster metrics in the registry, their label names start
+// with the namespace and cluster, and end with the static label keys. The registry is replaced
+// if the static label keys changed, since the label names of the registered metric can't be changed.
+func setupClusterMetrics(registry *prometheus.Registry) {
+	vecs := make([]*prometheus.MetricVec, 0)
+	newCounter := func(name string, labels ...string) *prometheus.CounterVec {
+		counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace, Subsystem: _subsystem, Name: name, Help: name,
+		}, clusterLabelNames(labels...))
+		registry.MustRegister(counter)
+		vecs = append(vecs, counter.MetricVec)
+		return counter
+	}
+	newGauge := func(name string, labels ...string) *prometheus.GaugeVec {
+		gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: _namespace, Subsystem: _subsystem, Name: name, Help: name,
+		}, clusterLabelNames(labels...))
+		registry.MustRegister(gauge)
+		vecs = append(vecs, gauge.MetricVec)
+		return gauge
 	}
+	_metrics.SlowNodeCommands = newCounter("slow_node_command", "node", "command")
+	_metrics.LastBackupAge = newGauge("last_backup_age_seconds")
+
+	_metrics.FailingNodes = newGauge("failing_nodes")
+	_metrics.FailingNodeRatio = newGauge("failing_node_ratio")
+	_metrics.FailoverDecisions = newCounter("failover_decision", "result")
+
+	_metrics.CheckerGoroutines = newGauge("checker_goroutines")
+	_metrics.CheckerTimers = newGauge("checker_timers")
+	_metrics.CheckerRedisConns = newGauge("checker_redis_conns")
+
+	_metrics.ClusterUpdateMutations = newCounter("cluster_update_mutation")
+	_metrics.ClusterUpdateWrites = newCounter("cluster_update_write")
+	_metrics.FinishedMigrations = newCounter("migration_finished", "status")
+	clusterVecs.Store(&vecs)
+	clusterRegistry.Store(registry)
+}
+
+// Gatherer gathers the metrics of the default registry and the per-cluster metrics,
+// it should be called after the static label keys were set.
+func Gatherer() prometheus.Gatherer {
+	return prometheus.Gatherers{prometheus.DefaultGatherer, clusterRegistry.Load()}
 }
 
 func Get() *performanceMetrics {
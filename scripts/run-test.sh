@@ -28,4 +28,7 @@ if [ "$GITHUB_ACTIONS" == "true" ]; then
   FORMAT="github-actions"
 fi
 
+# Use the kvrocks nodes started by setup.sh instead of starting the docker containers
+export KVROCKS_TEST_ADDRS=${KVROCKS_TEST_ADDRS:-"127.0.0.1:7770,127.0.0.1:7771"}
+
 gotestsum --format "$FORMAT" -- -covermode=atomic -coverprofile=coverage.out -race -p 1 ./...
@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/controller"
+	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+type BackupHandler struct {
+	s store.Store
+	c *controller.Controller
+}
+
+// Create starts the backup job of the cluster, the job runs in background
+// and its result can be fetched by listing the backup jobs.
+func (handler *BackupHandler) Create(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	job, err := handler.c.Backup(c, c.Param("namespace"), cluster.Name)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseCreated(c, gin.H{"job": job})
+}
+
+// List returns the latest backup jobs of the cluster, the newest job comes first
+func (handler *BackupHandler) List(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	jobs, err := handler.s.ListJobs(c, c.Param("namespace"), cluster.Name, store.JobTypeBackup)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"jobs": jobs})
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/controller"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/server/helper"
+)
+
+// ChaosHandler serves the fault injection API, which is only registered
+// when the chaos is enabled in the controller config.
+type ChaosHandler struct {
+	c *controller.Controller
+}
+
+func (handler *ChaosHandler) Get(c *gin.Context) {
+	fault, err := handler.c.GetChaosFault(c.Param("namespace"), c.Param("cluster"))
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"fault": fault})
+}
+
+func (handler *ChaosHandler) Set(c *gin.Context) {
+	var fault controller.ChaosFault
+	if err := c.ShouldBindJSON(&fault); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if err := fault.Validate(); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	ns, cluster := c.Param("namespace"), c.Param("cluster")
+	if err := handler.c.SetChaosFault(ns, cluster, &fault); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster),
+		zap.Any("fault", fault),
+	).Warn("Inject the chaos fault")
+	helper.ResponseOK(c, gin.H{"fault": fault})
+}
+
+func (handler *ChaosHandler) Clear(c *gin.Context) {
+	ns, cluster := c.Param("namespace"), c.Param("cluster")
+	if err := handler.c.SetChaosFault(ns, cluster, nil); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster),
+	).Info("Clear the chaos fault")
+	helper.ResponseNoContent(c)
+}
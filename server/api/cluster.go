@@ -23,42 +23,151 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/controller"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/server/helper"
 	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/util"
 )
 
 type MigrateSlotRequest struct {
 	Target   int             `json:"target" validate:"required"`
 	Slot     store.SlotRange `json:"slot" validate:"required"` // we don't use store.MigratingSlot here because we expect a valid SlotRange
 	SlotOnly bool            `json:"slot_only"`
+	// Force migrates the slot even if the target would exceed the headroom limits or the placement rules
+	Force bool `json:"force"`
+	// Source is the explicit source shard index, it's inferred from the slot ownership if nil
+	Source *int `json:"source"`
+	// ForceSource skips checking whether the source shard owns the slot
+	ForceSource bool `json:"force_source"`
 }
 
 type CreateClusterRequest struct {
 	Name     string   `json:"name" validate:"required"`
 	Nodes    []string `json:"nodes" validate:"required"`
 	Password string   `json:"password"`
-	Replicas int      `json:"replicas"`
+	// MasterAuth is the masterauth of the nodes if it's different from the password
+	MasterAuth  string            `json:"master_auth"`
+	Replicas    int               `json:"replicas"`
+	Description string            `json:"description"`
+	Annotations map[string]string `json:"annotations"`
+	// Labels are added to the metrics and events of the cluster
+	Labels map[string]string `json:"labels"`
+	// Weights are the weights of the shards to distribute the slots,
+	// the slots will be distributed evenly if it's empty.
+	Weights   []int `json:"weights"`
+	Protected bool  `json:"protected"`
+}
+
+type UpdateClusterRequest struct {
+	// Description won't be changed if it's nil
+	Description *string `json:"description"`
+	// Annotations will be merged into the existing annotations,
+	// the annotation will be removed if its value is empty.
+	Annotations map[string]string `json:"annotations"`
+	// Labels will be merged into the existing labels like the annotations
+	Labels map[string]string `json:"labels"`
+	// HealthCheck won't be changed if it's nil, and it will be removed if it's empty
+	HealthCheck *store.HealthCheck `json:"health_check"`
+	// Compaction won't be changed if it's nil, and it will be removed if it's empty
+	Compaction *store.CompactionSchedule `json:"compaction"`
+	// Placement won't be changed if it's nil, and it will be removed if it's empty
+	Placement *store.PlacementRules `json:"placement"`
+	// Protected won't be changed if it's nil, unprotecting the cluster requires the confirmation
+	Protected *bool `json:"protected"`
+}
+
+type RotatePasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+	// MasterAuth is the new masterauth of the nodes, it's the same as the password if empty
+	MasterAuth string `json:"master_auth"`
 }
 
 type ClusterHandler struct {
-	s     store.Store
-	locks sync.Map
+	s              store.Store
+	c              *controller.Controller
+	locks          store.ClusterLocks
+	headroomLimits store.HeadroomLimits
+	nameRules      store.NameRules
+}
+
+// CountLocks returns the number of the cluster locks in use, it's only used to export the map size
+func (handler *ClusterHandler) CountLocks() int {
+	return handler.locks.Len()
 }
 
-func (handler *ClusterHandler) getLock(ns, cluster string) *sync.RWMutex {
-	value, _ := handler.locks.LoadOrStore(fmt.Sprintf("%s/%s", ns, cluster), &sync.RWMutex{})
-	lock, _ := value.(*sync.RWMutex)
-	return lock
+// confirmProtected responds the forbidden error and returns false if the cluster is protected
+// and the operation isn't confirmed by the `X-Confirm-Protected: yes` header.
+func confirmProtected(c *gin.Context, cluster *store.Cluster, operation string) bool {
+	if !cluster.Protected || strings.ToLower(c.GetHeader(consts.HeaderConfirmProtected)) == "yes" {
+		return true
+	}
+	helper.ResponseError(c, fmt.Errorf("%w: the cluster %s is protected, set the %s header to 'yes' to %s",
+		consts.ErrForbidden, cluster.Name, consts.HeaderConfirmProtected, operation))
+	return false
+}
+
+// allowPlacement responds the forbidden error and returns false if the migration breaks
+// the placement rules, it's allowed with the warning if the migration is forced.
+func allowPlacement(c *gin.Context, namespace, clusterName string, violations []string, force bool) bool {
+	if len(violations) == 0 {
+		return true
+	}
+	if !force {
+		helper.ResponseError(c, fmt.Errorf("%w: %s, use force to migrate anyway",
+			consts.ErrForbidden, strings.Join(violations, "; ")))
+		return false
+	}
+	logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", clusterName),
+		zap.Strings("violations", violations),
+	).Warn("Force to migrate the slots against the placement rules")
+	return true
 }
 
+// List returns the cluster names under the namespace, or the clusters with the details
+// if the `detail` query is `full`, or the overviews of the clusters if it's `summary`.
 func (handler *ClusterHandler) List(c *gin.Context) {
 	namespace := c.Param("namespace")
+	switch detail := c.Query("detail"); detail {
+	case "":
+	case "full", "summary":
+		clusters, err := handler.s.GetClusters(c, namespace)
+		if err != nil && !errors.Is(err, consts.ErrNotFound) {
+			helper.ResponseError(c, err)
+			return
+		}
+		if detail == "full" {
+			if clusters == nil {
+				clusters = []*store.Cluster{}
+			}
+			helper.ResponseOK(c, gin.H{"clusters": clusters})
+			return
+		}
+		summaries := make([]store.ClusterSummary, 0, len(clusters))
+		for _, cluster := range clusters {
+			summary := cluster.Summary()
+			if handler.c != nil {
+				summary.Health = handler.c.ClusterHealth(namespace, cluster.Name)
+			}
+			summaries = append(summaries, summary)
+		}
+		helper.ResponseOK(c, gin.H{"clusters": summaries})
+		return
+	default:
+		helper.ResponseBadRequest(c, fmt.Errorf("invalid detail: %s, should be full or summary", detail))
+		return
+	}
 	clusters, err := handler.s.ListCluster(c, namespace)
 	if err != nil && !errors.Is(err, consts.ErrNotFound) {
 		helper.ResponseError(c, err)
@@ -69,7 +178,47 @@ func (handler *ClusterHandler) List(c *gin.Context) {
 
 func (handler *ClusterHandler) Get(c *gin.Context) {
 	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
-	helper.ResponseOK(c, gin.H{"cluster": cluster})
+	c.Header(consts.HeaderETag, helper.ClusterETag(cluster.Version.Load()))
+	projected, err := helper.ProjectQueryFields(c, cluster)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"cluster": projected})
+}
+
+// Endpoints returns the slot ranges with the serving nodes, the format can be
+// `json`(default), `slots` or `shards`, the latter two are in the RESP format of
+// the `CLUSTER SLOTS` and `CLUSTER SHARDS` replies, so the sidecar proxies can source
+// the routing table from the controller. The ETag is the version of the cluster.
+func (handler *ClusterHandler) Endpoints(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	c.Header(consts.HeaderETag, helper.ClusterETag(cluster.Version.Load()))
+	var reply []interface{}
+	switch strings.ToLower(c.DefaultQuery("format", "json")) {
+	case "json":
+		helper.ResponseOK(c, gin.H{"version": cluster.Version.Load(), "endpoints": cluster.Endpoints()})
+		return
+	case "slots":
+		reply = cluster.ClusterSlotsReply()
+	case "shards":
+		reply = cluster.ClusterShardsReply()
+	default:
+		helper.ResponseBadRequest(c, errors.New("format must be one of [json,slots,shards]"))
+		return
+	}
+	data, err := util.EncodeRESP(reply)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// Check audits the consistency between the stored topology and the nodes
+func (handler *ClusterHandler) Check(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	helper.ResponseOK(c, gin.H{"report": cluster.Check(c)})
 }
 
 func (handler *ClusterHandler) Create(c *gin.Context) {
@@ -79,60 +228,200 @@ func (handler *ClusterHandler) Create(c *gin.Context) {
 		helper.ResponseBadRequest(c, err)
 		return
 	}
-
-	clusterStore := handler.s
-	if err := clusterStore.CheckNewNodes(c, req.Nodes); err != nil {
+	if err := handler.nameRules.Validate("cluster", req.Name); err != nil {
 		helper.ResponseError(c, err)
 		return
 	}
 
-	cluster, err := store.NewCluster(req.Name, req.Nodes, req.Replicas)
+	cluster, err := store.NewResolvedCluster(c, req.Name, req.Nodes, req.Replicas)
 	if err != nil {
 		helper.ResponseBadRequest(c, err)
 		return
 	}
-	cluster.SetPassword(req.Password)
-	checkClusterMode := strings.ToLower(c.GetHeader(consts.HeaderDontCheckClusterMode)) == "yes"
-	for _, node := range cluster.GetNodes() {
-		if !checkClusterMode {
-			break
+	if len(req.Weights) > 0 {
+		if err := cluster.DistributeSlotsByWeights(req.Weights); err != nil {
+			helper.ResponseBadRequest(c, err)
+			return
 		}
+	}
+	newNodes := make([]string, 0)
+	for _, node := range cluster.GetNodes() {
+		newNodes = append(newNodes, node.Addr())
+	}
+	clusterStore := handler.s
+	if err := clusterStore.CheckNewNodes(c, newNodes); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	cluster.SetPassword(req.Password)
+	cluster.SetMasterAuth(req.MasterAuth)
+	cluster.Description = req.Description
+	cluster.UpdateAnnotations(req.Annotations)
+	cluster.UpdateLabels(req.Labels)
+	cluster.Protected = req.Protected
+	if err := checkNodesClusterMode(c, cluster.GetNodes()); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+
+	if err := clusterStore.CreateCluster(c, namespace, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseCreated(c, gin.H{"cluster": cluster})
+}
+
+// checkNodesClusterMode rejects the nodes which already have the cluster topology, it's only
+// checked if the request has the X-Dont-Check-Cluster-Mode header with yes.
+func checkNodesClusterMode(c *gin.Context, nodes []store.Node) error {
+	if strings.ToLower(c.GetHeader(consts.HeaderDontCheckClusterMode)) != "yes" {
+		return nil
+	}
+	for _, node := range nodes {
 		version, err := node.CheckClusterMode(c)
 		if err != nil {
+			return err
+		}
+		if version != -1 {
+			return fmt.Errorf("%w: node %s is already in cluster mode", consts.ErrInvalidArgument, node.Addr())
+		}
+	}
+	return nil
+}
+
+// Update changes the description, annotations, labels, health check, compaction schedule
+// and placement rules of the cluster
+func (handler *ClusterHandler) Update(c *gin.Context) {
+	namespace := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+
+	var req UpdateClusterRequest
+	if err := c.BindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if req.Description != nil {
+		cluster.Description = *req.Description
+	}
+	if req.Protected != nil {
+		if !*req.Protected && !confirmProtected(c, cluster, "unprotect it") {
+			return
+		}
+		cluster.Protected = *req.Protected
+	}
+	cluster.UpdateAnnotations(req.Annotations)
+	cluster.UpdateLabels(req.Labels)
+	if req.HealthCheck != nil {
+		if err := req.HealthCheck.Validate(); err != nil {
 			helper.ResponseError(c, err)
 			return
 		}
-		if version != -1 {
-			helper.ResponseBadRequest(c, errors.New("node is already in cluster mode"))
+		cluster.HealthCheck = req.HealthCheck
+		if req.HealthCheck.IsEmpty() {
+			cluster.HealthCheck = nil
+		}
+	}
+	if req.Compaction != nil {
+		if err := req.Compaction.Validate(); err != nil {
+			helper.ResponseError(c, err)
 			return
 		}
+		cluster.Compaction = req.Compaction
+		if req.Compaction.IsEmpty() {
+			cluster.Compaction = nil
+		}
 	}
+	if req.Placement != nil {
+		if err := req.Placement.Validate(); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		cluster.Placement = req.Placement
+		if req.Placement.IsEmpty() {
+			cluster.Placement = nil
+		}
+	}
+	if err := handler.s.UpdateCluster(c, namespace, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"cluster": cluster})
+}
 
-	if err := clusterStore.CreateCluster(c, namespace, cluster); err != nil {
+// Compactions returns the latest compaction jobs of the cluster, the newest job comes first
+func (handler *ClusterHandler) Compactions(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	jobs, err := handler.s.ListJobs(c, c.Param("namespace"), cluster.Name, store.JobTypeCompaction)
+	if err != nil {
 		helper.ResponseError(c, err)
 		return
 	}
-	helper.ResponseCreated(c, gin.H{"cluster": cluster})
+	helper.ResponseOK(c, gin.H{"jobs": jobs})
 }
 
+// Remove deletes the cluster from the store. The nodes will be reset before deleting
+// if `reset_nodes=true`, and the data will also be flushed if `flush_data=true` and
+// `confirm` is the cluster name. The cluster won't be deleted if any node failed to reset.
 func (handler *ClusterHandler) Remove(c *gin.Context) {
 	namespace := c.Param("namespace")
-	cluster := c.Param("cluster")
-	err := handler.s.RemoveCluster(c, namespace, cluster)
+	clusterName := c.Param("cluster")
+	resetNodes := strings.ToLower(c.Query("reset_nodes")) == "true"
+	flushData := strings.ToLower(c.Query("flush_data")) == "true"
+	if flushData && !resetNodes {
+		helper.ResponseBadRequest(c, errors.New("flush_data requires reset_nodes=true"))
+		return
+	}
+	if flushData && c.Query("confirm") != clusterName {
+		helper.ResponseBadRequest(c, errors.New("flush_data requires confirm to be the cluster name"))
+		return
+	}
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	if !confirmProtected(c, cluster, "remove it") {
+		return
+	}
+	if !resetNodes {
+		if err := handler.s.RemoveCluster(c, namespace, clusterName); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		helper.ResponseNoContent(c)
+		return
+	}
+
+	unlock := handler.locks.Lock(namespace, clusterName)
+	defer unlock()
+
+	cluster, err := handler.s.GetCluster(c, namespace, clusterName)
 	if err != nil {
 		helper.ResponseError(c, err)
 		return
 	}
-	helper.ResponseNoContent(c)
+	results, ok := cluster.ResetNodes(c, flushData)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, helper.Response{
+			Error: &helper.Error{Message: "failed to reset some nodes, the cluster was NOT removed"},
+			Data:  gin.H{"nodes": results},
+		})
+		return
+	}
+	if err := handler.s.RemoveCluster(c, namespace, clusterName); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", clusterName),
+		zap.Bool("flush_data", flushData),
+	).Info("Remove the cluster with resetting nodes")
+	helper.ResponseOK(c, gin.H{"nodes": results})
 }
 
 func (handler *ClusterHandler) MigrateSlot(c *gin.Context) {
 	namespace := c.Param("namespace")
 	clusterName := c.Param("cluster")
 
-	lock := handler.getLock(namespace, clusterName)
-	lock.Lock()
-	defer lock.Unlock()
+	unlock := handler.locks.Lock(namespace, clusterName)
+	defer unlock()
 
 	s, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
 	cluster, err := s.GetCluster(c, namespace, clusterName)
@@ -140,14 +429,53 @@ func (handler *ClusterHandler) MigrateSlot(c *gin.Context) {
 		helper.ResponseError(c, err)
 		return
 	}
+	// the cluster might be changed after CheckIfMatch and before the lock was acquired,
+	// and the update below fails if it's changed after this read.
+	if err := helper.CheckIfMatch(c, cluster.Version.Load()); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
 
 	var req MigrateSlotRequest
 	if err := c.BindJSON(&req); err != nil {
 		helper.ResponseBadRequest(c, err)
 		return
 	}
+	if (req.SlotOnly || req.Force || req.ForceSource) && !confirmProtected(c, cluster, "migrate the slot only or by force") {
+		return
+	}
 
-	err = cluster.MigrateSlot(c, req.Slot, req.Target, req.SlotOnly)
+	var headroom *store.MigrationHeadroom
+	if !req.SlotOnly && (handler.headroomLimits.MaxDiskUsage > 0 || handler.headroomLimits.MaxMemoryBytes > 0) {
+		headroom, err = cluster.CheckMigrationHeadroom(c, req.Slot, req.Target, handler.headroomLimits)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		if len(headroom.Violations) > 0 {
+			if !req.Force {
+				helper.ResponseError(c, fmt.Errorf("%w: %s, use force to migrate anyway",
+					consts.ErrConflict, strings.Join(headroom.Violations, "; ")))
+				return
+			}
+			logger.Get().With(
+				zap.String("namespace", namespace),
+				zap.String("cluster", clusterName),
+				zap.Strings("violations", headroom.Violations),
+			).Warn("Force to migrate the slot without enough headroom")
+		}
+	}
+
+	// the placement rules also apply to the slot only migration since it changes the distribution as well
+	if !allowPlacement(c, namespace, clusterName, cluster.CheckMigrationPlacement(req.Slot, req.Target), req.Force) {
+		return
+	}
+
+	if req.Source != nil {
+		err = cluster.MigrateSlotFromShard(c, req.Slot, *req.Source, req.Target, req.SlotOnly, req.ForceSource)
+	} else {
+		err = cluster.MigrateSlot(c, req.Slot, req.Target, req.SlotOnly)
+	}
 	if err != nil {
 		helper.ResponseError(c, err)
 		return
@@ -158,37 +486,417 @@ func (handler *ClusterHandler) MigrateSlot(c *gin.Context) {
 		helper.ResponseError(c, err)
 		return
 	}
-	helper.ResponseOK(c, gin.H{"cluster": cluster})
+	migrationID := saveMigrationJob(c, handler.s, namespace, cluster, cluster.MigratingShardIndex(req.Slot))
+	if req.Source != nil && req.ForceSource {
+		message := fmt.Sprintf("force to migrate the slot %s from shard %d to shard %d", req.Slot.String(), *req.Source, req.Target)
+		logger.Get().With(
+			zap.String("namespace", namespace),
+			zap.String("cluster", clusterName),
+		).Warn(message)
+		s.EmitEvent(store.EventPayload{
+			Namespace: namespace,
+			Cluster:   clusterName,
+			Type:      store.EventCluster,
+			Command:   store.CommandWarn,
+			Message:   message,
+		})
+	}
+	helper.ResponseOK(c, gin.H{"cluster": cluster, "headroom": headroom, "migration_id": migrationID})
 }
 
-func (handler *ClusterHandler) Import(c *gin.Context) {
+// saveMigrationJob records the queued job of the migration started by the shard, and returns
+// the migration ID. It's empty if the shard isn't migrating, e.g. only the slot was moved.
+func saveMigrationJob(c *gin.Context, s store.Store, ns string, cluster *store.Cluster, shardIndex int) string {
+	job := cluster.NewMigrationJob(shardIndex)
+	if job == nil {
+		return ""
+	}
+	// the migration was started, so only warn if failed to record the job
+	if err := s.SaveJob(c, ns, cluster.Name, job); err != nil {
+		logger.Get().With(
+			zap.String("namespace", ns),
+			zap.String("cluster", cluster.Name),
+			zap.String("id", job.ID),
+		).Warn("Failed to save the migration job", zap.Error(err))
+	}
+	return job.ID
+}
+
+// Migrations returns the latest slot migration jobs of the cluster
+func (handler *ClusterHandler) Migrations(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	jobs, err := handler.s.ListJobs(c, c.Param("namespace"), cluster.Name, store.JobTypeMigration)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"jobs": jobs})
+}
+
+// GetMigration returns the slot migration job with the ID
+func (handler *ClusterHandler) GetMigration(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	job, err := handler.s.GetJob(c, c.Param("namespace"), cluster.Name, store.JobTypeMigration, c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"job": job})
+}
+
+// CancelMigration cancels the queued or running slot migration job, the migrating state is
+// cleared so the slot stays in the source shard, and the topology is synced to the source
+// master eagerly to stop it from moving the slot. The keys which were already copied to the
+// target won't be served since the target doesn't own the slot.
+func (handler *ClusterHandler) CancelMigration(c *gin.Context) {
+	namespace := c.Param("namespace")
+	clusterName := c.Param("cluster")
+	id := c.Param("id")
+
+	unlock := handler.locks.Lock(namespace, clusterName)
+	defer unlock()
+
+	job, err := handler.s.GetJob(c, namespace, clusterName, store.JobTypeMigration, id)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if job.IsFinished() {
+		helper.ResponseError(c, fmt.Errorf("%w: the migration %s was already %s", consts.ErrConflict, id, job.Status))
+		return
+	}
+	cluster, err := handler.s.GetCluster(c, namespace, clusterName)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	shardIndex, err := cluster.CancelMigration(c, id)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.UpdateCluster(c, namespace, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	log := logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", clusterName),
+		zap.String("id", id),
+	)
+	// the other nodes will be synced by the controller after the version was bumped
+	if source := cluster.Shards[shardIndex].GetMasterNode(); source != nil {
+		if err := source.SyncClusterInfo(c, cluster); err != nil {
+			log.Warn("Failed to sync the cluster info to the source node", zap.Error(err))
+		}
+	}
+
+	// the job might be finished by the controller since it was read
+	cancelled := false
+	savedJob, err := handler.s.UpdateJob(c, namespace, clusterName, store.JobTypeMigration, id, func(job *store.Job) bool {
+		if job.IsFinished() {
+			return false
+		}
+		job.CancelMigration("cancelled by the user")
+		cancelled = true
+		return true
+	})
+	if err != nil {
+		log.Warn("Failed to save the migration job", zap.Error(err))
+		job.CancelMigration("cancelled by the user")
+	} else if !cancelled {
+		helper.ResponseError(c, fmt.Errorf("%w: the migration %s was already %s", consts.ErrConflict, id, savedJob.Status))
+		return
+	} else {
+		job = savedJob
+	}
+	metrics.Get().FinishedMigrations.WithLabelValues(metrics.ClusterLabelValues(namespace, clusterName, store.JobStatusCancelled)...).Inc()
+	log.Info("Cancel the slot migration")
+	helper.ResponseOK(c, gin.H{"job": job})
+}
+
+// HistoryDiff returns the topology changes which bumped the cluster to the revision
+func (handler *ClusterHandler) HistoryDiff(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	version, err := strconv.ParseInt(c.Param("rev"), 10, 64)
+	if err != nil {
+		helper.ResponseBadRequest(c, fmt.Errorf("invalid revision: %s", c.Param("rev")))
+		return
+	}
+	revision, err := handler.s.GetClusterRevision(c, c.Param("namespace"), cluster.Name, version)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"revision": revision, "summary": revision.Summary()})
+}
+
+// RotatePassword changes the password of all nodes in the cluster and
+// updates the stored password after all nodes were changed, the nodes are
+// rolled back if the new password couldn't be persisted.
+func (handler *ClusterHandler) RotatePassword(c *gin.Context) {
+	namespace := c.Param("namespace")
+	clusterName := c.Param("cluster")
+
+	var req RotatePasswordRequest
+	if err := c.BindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if req.Password == "" {
+		helper.ResponseBadRequest(c, errors.New("password should NOT be empty"))
+		return
+	}
+
+	unlock := handler.locks.Lock(namespace, clusterName)
+	defer unlock()
+
+	cluster, err := handler.s.GetCluster(c, namespace, clusterName)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	// the cluster might be changed after CheckIfMatch and before the lock was acquired
+	if err := helper.CheckIfMatch(c, cluster.Version.Load()); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	rotation, err := cluster.RotatePassword(c, req.Password, req.MasterAuth)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.UpdateCluster(c, namespace, cluster); err != nil {
+		// the nodes must keep the stored password, otherwise the controller can't connect them
+		helper.ResponseError(c, rotation.Rollback(c, err))
+		return
+	}
+	rotation.Finish()
+	logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", clusterName),
+	).Info("Rotate the cluster password success")
+	helper.ResponseOK(c, nil)
+}
+
+// Reconcile converges the cluster toward the desired spec, the cluster will be
+// created if it doesn't exist. It's idempotent and should be called until the
+// returned phase is converged.
+func (handler *ClusterHandler) Reconcile(c *gin.Context) {
+	namespace := c.Param("namespace")
+	clusterName := c.Param("cluster")
+
+	var spec store.ClusterSpec
+	if err := c.BindJSON(&spec); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if _, err := spec.Validate(); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+
+	unlock := handler.locks.Lock(namespace, clusterName)
+	defer unlock()
+
+	cluster, err := handler.s.GetCluster(c, namespace, clusterName)
+	if err != nil && !errors.Is(err, consts.ErrNotFound) {
+		helper.ResponseError(c, err)
+		return
+	}
+	if cluster == nil {
+		if err := handler.nameRules.Validate("cluster", clusterName); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+	}
+	existingNodes := make(map[string]bool)
+	if cluster != nil {
+		for _, node := range cluster.GetNodes() {
+			existingNodes[node.Addr()] = true
+		}
+	}
+	newNodes := make([]string, 0)
+	for _, shard := range spec.Shards {
+		for _, addr := range shard.Nodes {
+			if !existingNodes[addr] {
+				newNodes = append(newNodes, addr)
+			}
+		}
+	}
+	if err := handler.s.CheckNewNodes(c, newNodes); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+
+	if cluster == nil {
+		cluster, err = store.NewClusterFromSpec(clusterName, &spec)
+		if err != nil {
+			helper.ResponseBadRequest(c, err)
+			return
+		}
+		if err := checkNodesClusterMode(c, cluster.GetNodes()); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		if err := handler.s.CreateCluster(c, namespace, cluster); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		helper.ResponseOK(c, gin.H{"status": &store.ReconcileStatus{
+			Phase:   store.ReconcilePhaseConverged,
+			Changed: true,
+			Actions: []string{"create cluster"},
+			Pending: []string{},
+		}})
+		return
+	}
+
+	status, err := cluster.Reconcile(c, &spec)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	addedNodes := make([]store.Node, 0)
+	for _, node := range cluster.GetNodes() {
+		if !existingNodes[node.Addr()] {
+			addedNodes = append(addedNodes, node)
+		}
+	}
+	if err := checkNodesClusterMode(c, addedNodes); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if status.Changed {
+		if err := handler.s.UpdateCluster(c, namespace, cluster); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		logger.Get().With(
+			zap.String("namespace", namespace),
+			zap.String("cluster", clusterName),
+			zap.Strings("actions", status.Actions),
+		).Info("Reconcile the cluster")
+	}
+	helper.ResponseOK(c, gin.H{"status": status})
+}
+
+// Convert creates the cluster from the standalone nodes, it resets the stale topology of the
+// empty nodes, assigns the node ids and syncs the topology to the nodes. The nodes are only
+// inspected and the plan is returned with the blockers if dry_run is true.
+func (handler *ClusterHandler) Convert(c *gin.Context) {
 	namespace := c.Param("namespace")
 	clusterName := c.Param("cluster")
 	var req struct {
-		Nodes    []string `json:"nodes" validate:"required"`
-		Password string   `json:"password"`
+		store.ClusterSpec
+		MasterAuth string `json:"master_auth"`
+		DryRun     bool   `json:"dry_run"`
 	}
 	if err := c.BindJSON(&req); err != nil {
 		helper.ResponseBadRequest(c, err)
 		return
 	}
-	if len(req.Nodes) == 0 {
-		helper.ResponseBadRequest(c, errors.New("nodes should NOT be empty"))
+	if err := handler.nameRules.Validate("cluster", clusterName); err != nil {
+		helper.ResponseError(c, err)
 		return
 	}
 
-	firstNode := store.NewClusterNode(req.Nodes[0], req.Password)
-	clusterNodesStr, err := firstNode.GetClusterNodesString(c)
+	unlock := handler.locks.Lock(namespace, clusterName)
+	defer unlock()
+
+	plan, err := store.PlanConversion(c, clusterName, &req.ClusterSpec)
 	if err != nil {
 		helper.ResponseError(c, err)
 		return
 	}
+	newNodes := make([]string, 0)
+	for _, node := range plan.Cluster.GetNodes() {
+		newNodes = append(newNodes, node.Addr())
+	}
+	if err := handler.s.CheckNewNodes(c, newNodes); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if req.DryRun {
+		helper.ResponseOK(c, gin.H{"plan": plan, "blocked": plan.Blocked()})
+		return
+	}
+	if plan.Blocked() {
+		helper.ResponseError(c, &store.ConversionBlockedError{Plan: plan})
+		return
+	}
+
+	cluster := plan.Cluster
+	cluster.SetMasterAuth(req.MasterAuth)
+	if err := plan.Prepare(c); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.CreateCluster(c, namespace, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	// the controller will retry syncing the nodes which are not synced
+	if err := cluster.SyncToNodes(c); err != nil {
+		logger.Get().With(
+			zap.String("namespace", namespace),
+			zap.String("cluster", clusterName),
+			zap.Error(err),
+		).Warn("Failed to sync the topology to the converted nodes")
+	}
+	logger.Get().With(
+		zap.String("namespace", namespace),
+		zap.String("cluster", clusterName),
+		zap.Int("shards", len(cluster.Shards)),
+	).Info("Convert the standalone nodes into the cluster")
+	helper.ResponseCreated(c, gin.H{"cluster": cluster, "plan": plan})
+}
+
+func (handler *ClusterHandler) Import(c *gin.Context) {
+	namespace := c.Param("namespace")
+	clusterName := c.Param("cluster")
+	var req struct {
+		Nodes      []string `json:"nodes"`
+		Password   string   `json:"password"`
+		MasterAuth string   `json:"master_auth"`
+		// ClusterNodes is the saved output of `CLUSTER NODES`, the topology will be
+		// parsed from it instead of the live nodes if it's not empty.
+		ClusterNodes string `json:"cluster_nodes"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if err := handler.nameRules.Validate("cluster", clusterName); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if len(req.Nodes) == 0 && req.ClusterNodes == "" {
+		helper.ResponseBadRequest(c, errors.New("nodes and cluster_nodes should NOT be both empty"))
+		return
+	}
+
+	clusterNodesStr := strings.TrimSpace(strings.ReplaceAll(req.ClusterNodes, "\r\n", "\n"))
+	if clusterNodesStr == "" {
+		firstNode := store.NewClusterNode(req.Nodes[0], req.Password)
+		nodesStr, err := firstNode.GetClusterNodesString(c)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		clusterNodesStr = nodesStr
+	}
 	cluster, err := store.ParseCluster(clusterNodesStr)
 	if err != nil {
-		helper.ResponseError(c, err)
+		if req.ClusterNodes != "" {
+			helper.ResponseBadRequest(c, err)
+		} else {
+			helper.ResponseError(c, err)
+		}
 		return
 	}
 	cluster.SetPassword(req.Password)
+	cluster.SetMasterAuth(req.MasterAuth)
 
 	newNodes := make([]string, 0)
 	for _, node := range cluster.GetNodes() {
@@ -26,6 +26,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,6 +41,7 @@ import (
 	"github.com/apache/kvrocks-controller/server/middleware"
 	"github.com/apache/kvrocks-controller/store"
 	"github.com/apache/kvrocks-controller/store/engine"
+	"github.com/apache/kvrocks-controller/testutil/kvrockstest"
 	"github.com/apache/kvrocks-controller/util"
 )
 
@@ -79,9 +82,10 @@ func TestClusterBasics(t *testing.T) {
 		require.Equal(t, expectedStatusCode, recorder.Code)
 	}
 
-	runRemove := func(t *testing.T, name string, expectedStatusCode int) {
+	runRemove := func(t *testing.T, name, query string, expectedStatusCode int) {
 		recorder := httptest.NewRecorder()
 		ctx := GetTestContext(recorder)
+		ctx.Request.URL.RawQuery = query
 		ctx.Set(consts.ContextKeyStore, handler.s)
 		ctx.Params = []gin.Param{{Key: "namespace", Value: ns}, {Key: "cluster", Value: name}}
 
@@ -96,6 +100,8 @@ func TestClusterBasics(t *testing.T) {
 	t.Run("create cluster", func(t *testing.T) {
 		runCreate(t, "test-cluster", http.StatusCreated)
 		runCreate(t, "test-cluster", http.StatusConflict)
+		runCreate(t, "test/cluster", http.StatusBadRequest)
+		runCreate(t, " test-cluster", http.StatusBadRequest)
 	})
 
 	t.Run("get cluster", func(t *testing.T) {
@@ -121,6 +127,76 @@ func TestClusterBasics(t *testing.T) {
 		require.ElementsMatch(t, []string{"test-cluster"}, rsp.Data.Clusters)
 	})
 
+	t.Run("list cluster with details", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Request.URL.RawQuery = "detail=full"
+		ctx.Set(consts.ContextKeyStore, handler.s)
+		ctx.Params = []gin.Param{{Key: "namespace", Value: ns}}
+
+		handler.List(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp struct {
+			Data struct {
+				Clusters []*store.Cluster `json:"clusters"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp.Data.Clusters, 1)
+		require.Equal(t, "test-cluster", rsp.Data.Clusters[0].Name)
+		require.Len(t, rsp.Data.Clusters[0].Shards, 2)
+	})
+
+	t.Run("list cluster summaries", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Request.URL.RawQuery = "detail=summary"
+		ctx.Set(consts.ContextKeyStore, handler.s)
+		ctx.Params = []gin.Param{{Key: "namespace", Value: ns}}
+
+		handler.List(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp struct {
+			Data struct {
+				Clusters []store.ClusterSummary `json:"clusters"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Len(t, rsp.Data.Clusters, 1)
+		require.Equal(t, "test-cluster", rsp.Data.Clusters[0].Name)
+		require.Equal(t, 2, rsp.Data.Clusters[0].Shards)
+		require.Equal(t, 4, rsp.Data.Clusters[0].Nodes)
+		require.Equal(t, store.ClusterHealthUnknown, rsp.Data.Clusters[0].Health)
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		runEndpoints := func(query string) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			ctx := GetTestContext(recorder)
+			ctx.Request.URL.RawQuery = query
+			ctx.Set(consts.ContextKeyStore, handler.s)
+			ctx.Params = []gin.Param{{Key: "namespace", Value: ns}, {Key: "cluster", Value: "test-cluster"}}
+			middleware.RequiredCluster(ctx)
+			handler.Endpoints(ctx)
+			return recorder
+		}
+
+		recorder := runEndpoints("")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NotEmpty(t, recorder.Header().Get(consts.HeaderETag))
+		require.Contains(t, recorder.Body.String(), "endpoints")
+
+		recorder = runEndpoints("format=slots")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NotEmpty(t, recorder.Header().Get(consts.HeaderETag))
+		require.True(t, strings.HasPrefix(recorder.Body.String(), "*2\r\n"))
+		require.Contains(t, recorder.Body.String(), "127.0.0.1")
+
+		require.Equal(t, http.StatusBadRequest, runEndpoints("format=xml").Code)
+	})
+
 	t.Run("migrate slot only", func(t *testing.T) {
 		handler := &ClusterHandler{s: store.NewClusterStore(engine.NewMock())}
 		clusterName := "test-migrate-slot-only-cluster"
@@ -164,8 +240,65 @@ func TestClusterBasics(t *testing.T) {
 	})
 
 	t.Run("remove cluster", func(t *testing.T) {
-		runRemove(t, "test-cluster", http.StatusNoContent)
-		runRemove(t, "not-exist", http.StatusNotFound)
+		runRemove(t, "test-cluster", "flush_data=true", http.StatusBadRequest)
+		runRemove(t, "test-cluster", "reset_nodes=true&flush_data=true&confirm=other", http.StatusBadRequest)
+		runRemove(t, "test-cluster", "", http.StatusNoContent)
+		runRemove(t, "not-exist", "", http.StatusNotFound)
+	})
+}
+
+func TestClusterProtected(t *testing.T) {
+	ns := "test-ns"
+	clusterName := "test-protected-cluster"
+	handler := &ClusterHandler{s: store.NewClusterStore(engine.NewMock())}
+	cluster, err := store.NewCluster(clusterName, []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 1)
+	require.NoError(t, err)
+	cluster.Protected = true
+	require.NoError(t, handler.s.CreateCluster(context.Background(), ns, cluster))
+
+	newContext := func(recorder *httptest.ResponseRecorder, body interface{}, confirmed bool) *gin.Context {
+		ctx := GetTestContext(recorder)
+		ctx.Set(consts.ContextKeyStore, handler.s)
+		ctx.Params = []gin.Param{{Key: "namespace", Value: ns}, {Key: "cluster", Value: clusterName}}
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(data))
+		}
+		if confirmed {
+			ctx.Request.Header.Set(consts.HeaderConfirmProtected, "yes")
+		}
+		middleware.RequiredCluster(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return ctx
+	}
+
+	t.Run("migrate slot only", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.MigrateSlot(newContext(recorder, &MigrateSlotRequest{
+			Slot:     store.SlotRange{Start: 3, Stop: 3},
+			SlotOnly: true,
+			Target:   1,
+		}, false))
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.Contains(t, recorder.Body.String(), consts.HeaderConfirmProtected)
+	})
+
+	t.Run("unprotect", func(t *testing.T) {
+		protected := false
+		recorder := httptest.NewRecorder()
+		handler.Update(newContext(recorder, &UpdateClusterRequest{Protected: &protected}, false))
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.Remove(newContext(recorder, nil, false))
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+
+		recorder = httptest.NewRecorder()
+		handler.Remove(newContext(recorder, nil, true))
+		require.Equal(t, http.StatusNoContent, recorder.Code)
 	})
 }
 
@@ -174,7 +307,7 @@ func TestClusterImport(t *testing.T) {
 	clusterName := "test-cluster-import"
 	handler := &ClusterHandler{s: store.NewClusterStore(engine.NewMock())}
 	// cluster import must be done on a real cluster
-	testNodeAddr := "127.0.0.1:7770"
+	testNodeAddr := kvrockstest.Start(t, 1)[0]
 	clusterNode := store.NewClusterNode(testNodeAddr, "")
 	cluster, err := store.NewCluster(clusterName, []string{testNodeAddr}, 1)
 	require.NoError(t, err)
@@ -212,6 +345,148 @@ func TestClusterImport(t *testing.T) {
 	require.Equal(t, testNodeAddr, rsp.Data.Cluster.Shards[0].Nodes[0].Addr())
 }
 
+func TestClusterImportFromNodesString(t *testing.T) {
+	handler := &ClusterHandler{s: store.NewClusterStore(engine.NewMock())}
+	clusterNodes := strings.Join([]string{
+		"07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected",
+		"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-16383",
+	}, "\r\n") + "\n"
+
+	runImport := func(body string, expectedStatusCode int) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		testCtx := GetTestContext(recorder)
+		testCtx.Request.Body = io.NopCloser(bytes.NewBufferString(body))
+		testCtx.Params = []gin.Param{{Key: "namespace", Value: "test-ns"}, {Key: "cluster", Value: "test-cluster"}}
+		handler.Import(testCtx)
+		require.Equal(t, expectedStatusCode, recorder.Code)
+		return recorder
+	}
+
+	runImport(`{"cluster_nodes":"invalid"}`, http.StatusBadRequest)
+	body, err := json.Marshal(map[string]string{"cluster_nodes": clusterNodes})
+	require.NoError(t, err)
+	recorder := runImport(string(body), http.StatusOK)
+
+	var rsp struct {
+		Data struct {
+			Cluster *store.Cluster `json:"cluster"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Data.Cluster.Shards, 1)
+	require.Len(t, rsp.Data.Cluster.Shards[0].Nodes, 2)
+	require.Equal(t, "127.0.0.1:30001", rsp.Data.Cluster.Shards[0].Nodes[0].Addr())
+	require.Equal(t, "127.0.0.1:30004", rsp.Data.Cluster.Shards[0].Nodes[1].Addr())
+}
+
+func TestClusterConvert(t *testing.T) {
+	ns := "test-ns"
+	clusterName := "test-cluster-convert"
+	handler := &ClusterHandler{s: store.NewClusterStore(engine.NewMock())}
+	// the conversion must be done on the real nodes
+	nodeAddrs := kvrockstest.Start(t, 2)
+	ctx := context.Background()
+	staleCluster, err := store.NewCluster(clusterName, nodeAddrs, 2)
+	require.NoError(t, err)
+	require.NoError(t, staleCluster.Reset(ctx))
+	defer func() {
+		// clean up the cluster information to avoid affecting other tests
+		require.NoError(t, staleCluster.Reset(ctx))
+	}()
+
+	runConvert := func(body string, expectedStatusCode int) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		testCtx := GetTestContext(recorder)
+		testCtx.Request.Body = io.NopCloser(bytes.NewBufferString(body))
+		testCtx.Params = []gin.Param{{Key: "namespace", Value: ns}, {Key: "cluster", Value: clusterName}}
+		handler.Convert(testCtx)
+		require.Equal(t, expectedStatusCode, recorder.Code)
+		return recorder
+	}
+
+	runConvert(`{"shards":[]}`, http.StatusBadRequest)
+	body, err := json.Marshal(map[string]interface{}{
+		"shards":  []map[string]interface{}{{"nodes": nodeAddrs}},
+		"dry_run": true,
+	})
+	require.NoError(t, err)
+	recorder := runConvert(string(body), http.StatusOK)
+	var dryRunRsp struct {
+		Data struct {
+			Blocked bool                  `json:"blocked"`
+			Plan    *store.ConversionPlan `json:"plan"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &dryRunRsp))
+	require.False(t, dryRunRsp.Data.Blocked)
+	require.Len(t, dryRunRsp.Data.Plan.Nodes, 2)
+	_, err = handler.s.GetCluster(ctx, ns, clusterName)
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	body, err = json.Marshal(map[string]interface{}{
+		"shards": []map[string]interface{}{{"nodes": nodeAddrs}},
+	})
+	require.NoError(t, err)
+	runConvert(string(body), http.StatusCreated)
+	cluster, err := handler.s.GetCluster(ctx, ns, clusterName)
+	require.NoError(t, err)
+	require.Len(t, cluster.Shards, 1)
+	require.Equal(t, nodeAddrs[0], cluster.Shards[0].Nodes[0].Addr())
+	version, err := store.NewClusterNode(nodeAddrs[1], "").CheckClusterMode(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, cluster.Version.Load(), version)
+}
+
+func TestClusterCancelMigration(t *testing.T) {
+	ns := "test-ns"
+	ctx := context.Background()
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ClusterHandler{s: clusterStore}
+	// the migrating state of the source node is checked before cancelling
+	nodeAddrs := kvrockstest.Start(t, 2)
+	cluster, err := store.NewCluster("test-cluster", nodeAddrs, 1)
+	require.NoError(t, err)
+	cluster.Shards[0].MigratingSlot = store.FromSlotRange(store.SlotRange{Start: 0, Stop: 0})
+	cluster.Shards[0].TargetShardIndex = 1
+	cluster.Shards[0].MigrationID = "1-1"
+	require.NoError(t, clusterStore.CreateCluster(ctx, ns, cluster))
+	job := cluster.NewMigrationJob(0)
+	require.NoError(t, clusterStore.SaveJob(ctx, ns, cluster.Name, job))
+
+	runCancel := func(id string, expectedStatusCode int) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		testCtx := GetTestContext(recorder)
+		testCtx.Params = []gin.Param{
+			{Key: "namespace", Value: ns},
+			{Key: "cluster", Value: cluster.Name},
+			{Key: "id", Value: id},
+		}
+		handler.CancelMigration(testCtx)
+		require.Equal(t, expectedStatusCode, recorder.Code)
+		return recorder
+	}
+
+	runCancel("not-exists", http.StatusNotFound)
+	recorder := runCancel(job.ID, http.StatusOK)
+	var rsp struct {
+		Data struct {
+			Job *store.Job `json:"job"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, store.JobStatusCancelled, rsp.Data.Job.Status)
+
+	gotCluster, err := clusterStore.GetCluster(ctx, ns, cluster.Name)
+	require.NoError(t, err)
+	require.False(t, gotCluster.Shards[0].IsMigrating())
+	require.Greater(t, gotCluster.Version.Load(), cluster.Version.Load())
+	savedJob, err := clusterStore.GetJob(ctx, ns, cluster.Name, store.JobTypeMigration, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.JobStatusCancelled, savedJob.Status)
+	// the finished migration can't be cancelled again
+	runCancel(job.ID, http.StatusConflict)
+}
+
 func TestClusterMigrateData(t *testing.T) {
 	ns := "test-ns"
 	clusterName := "test-cluster"
@@ -219,7 +494,7 @@ func TestClusterMigrateData(t *testing.T) {
 	handler := &ClusterHandler{s: clusterStore}
 
 	ctx := context.Background()
-	nodeAddrs := []string{"127.0.0.1:7770", "127.0.0.1:7771"}
+	nodeAddrs := kvrockstest.Start(t, 2)
 	sourceRedisClient := redis.NewClient(&redis.Options{Addr: nodeAddrs[0]})
 	targetRedisClient := redis.NewClient(&redis.Options{Addr: nodeAddrs[1]})
 
@@ -303,3 +578,132 @@ func TestClusterMigrateData(t *testing.T) {
 		}
 	})
 }
+
+func TestClusterIfMatch(t *testing.T) {
+	ns := "test-ns"
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ClusterHandler{s: clusterStore}
+	cluster, err := store.NewCluster("test-cluster", []string{"node0", "node1"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, cluster))
+
+	newContext := func(recorder *httptest.ResponseRecorder, ifMatch string) *gin.Context {
+		ctx := GetTestContext(recorder)
+		ctx.Set(consts.ContextKeyStore, clusterStore)
+		ctx.Params = []gin.Param{{Key: "namespace", Value: ns}, {Key: "cluster", Value: "test-cluster"}}
+		ctx.Request.Header.Set(consts.HeaderIfMatch, ifMatch)
+		return ctx
+	}
+
+	recorder := httptest.NewRecorder()
+	ctx := newContext(recorder, "")
+	middleware.RequiredCluster(ctx)
+	handler.Get(ctx)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get(consts.HeaderETag)
+	require.Equal(t, `"1"`, etag)
+
+	recorder = httptest.NewRecorder()
+	ctx = newContext(recorder, `"2"`)
+	middleware.CheckIfMatch(ctx)
+	require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	require.True(t, ctx.IsAborted())
+
+	recorder = httptest.NewRecorder()
+	ctx = newContext(recorder, etag)
+	middleware.CheckIfMatch(ctx)
+	require.False(t, ctx.IsAborted())
+
+	// the cluster was changed after the middleware check, the migration must re-check it
+	middleware.RequiredCluster(ctx)
+	changed, err := clusterStore.GetCluster(context.Background(), ns, "test-cluster")
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.UpdateCluster(context.Background(), ns, changed))
+	body, err := json.Marshal(&MigrateSlotRequest{Slot: store.SlotRange{Start: 3, Stop: 3}, SlotOnly: true, Target: 1})
+	require.NoError(t, err)
+	ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	handler.MigrateSlot(ctx)
+	require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"current_version":2`)
+
+	// the password rotation must re-check it as well
+	recorder = httptest.NewRecorder()
+	ctx = newContext(recorder, etag)
+	body, err = json.Marshal(&RotatePasswordRequest{Password: "new-password"})
+	require.NoError(t, err)
+	ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	handler.RotatePassword(ctx)
+	require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"current_version":2`)
+}
+
+func TestClusterGetFields(t *testing.T) {
+	ns := "test-ns"
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ClusterHandler{s: clusterStore}
+	cluster, err := store.NewCluster("test-cluster", []string{"node0", "node1"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, cluster))
+
+	runGet := func(t *testing.T, fields string, expectedStatusCode int) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Set(consts.ContextKeyStore, clusterStore)
+		ctx.Params = []gin.Param{{Key: "namespace", Value: ns}, {Key: "cluster", Value: "test-cluster"}}
+		ctx.Request.URL.RawQuery = url.Values{"fields": []string{fields}}.Encode()
+		middleware.RequiredCluster(ctx)
+		handler.Get(ctx)
+		require.Equal(t, expectedStatusCode, recorder.Code)
+		return recorder
+	}
+
+	recorder := runGet(t, "shards.nodes.addr,shards.slot_ranges", http.StatusOK)
+	require.JSONEq(t, `{"data":{"cluster":{"shards":[`+
+		`{"nodes":[{"addr":"node0"}],"slot_ranges":["0-8191"]},`+
+		`{"nodes":[{"addr":"node1"}],"slot_ranges":["8192-16383"]}]}}}`, recorder.Body.String())
+	runGet(t, "shards..addr", http.StatusBadRequest)
+}
+
+func TestClusterHistoryDiff(t *testing.T) {
+	ns := "test-ns"
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ClusterHandler{s: clusterStore}
+	cluster, err := store.NewCluster("test-cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, cluster))
+	_, err = cluster.AddNode(1, "127.0.0.1:3333", store.RoleSlave, "")
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.UpdateCluster(context.Background(), ns, cluster))
+
+	runDiff := func(t *testing.T, rev string, expectedStatusCode int) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Set(consts.ContextKeyStore, clusterStore)
+		ctx.Params = []gin.Param{
+			{Key: "namespace", Value: ns},
+			{Key: "cluster", Value: "test-cluster"},
+			{Key: "rev", Value: rev},
+		}
+		middleware.RequiredCluster(ctx)
+		handler.HistoryDiff(ctx)
+		require.Equal(t, expectedStatusCode, recorder.Code)
+		return recorder
+	}
+
+	recorder := runDiff(t, "2", http.StatusOK)
+	var rsp struct {
+		Data struct {
+			Revision store.ClusterRevision `json:"revision"`
+			Summary  []string              `json:"summary"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.EqualValues(t, 2, rsp.Data.Revision.Version)
+	require.Len(t, rsp.Data.Revision.Changes, 1)
+	require.Equal(t, store.ChangeNodeAdded, rsp.Data.Revision.Changes[0].Type)
+	require.Len(t, rsp.Data.Summary, 1)
+	require.Contains(t, rsp.Data.Summary[0], "127.0.0.1:3333")
+
+	runDiff(t, "1", http.StatusNotFound)
+	runDiff(t, "abc", http.StatusBadRequest)
+}
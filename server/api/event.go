@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// eventTailInterval is the interval of the event stream to tail the persisted event log
+const eventTailInterval = time.Second
+
+type EventHandler struct {
+	events *store.EventLog
+}
+
+// lastEventID returns the last event ID from the `Last-Event-ID` header which is sent by
+// the reconnecting SSE client, or the `last_event_id` query for the polling consumers.
+func lastEventID(c *gin.Context) (int64, error) {
+	value := c.GetHeader(consts.HeaderLastEventID)
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("%w: invalid last event id: %s", consts.ErrInvalidArgument, value)
+	}
+	return id, nil
+}
+
+func writeEvent(w io.Writer, event *store.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// List returns the events after the last event ID, or streams them as the server-sent events
+// if the client accepts `text/event-stream`. The persisted events are replayed first, so the
+// consumers can reconnect with the last event ID to receive the missed events at least once.
+func (handler *EventHandler) List(c *gin.Context) {
+	lastID, err := lastEventID(c)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		events, err := handler.events.Since(c, lastID)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		helper.ResponseOK(c, gin.H{"events": events})
+		return
+	}
+
+	// subscribe before replaying to avoid missing the events appended in between
+	liveEvents, cancel := handler.events.Subscribe()
+	defer cancel()
+	events, err := handler.events.Since(c, lastID)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	for i := range events {
+		if err := writeEvent(c.Writer, &events[i]); err != nil {
+			return
+		}
+		lastID = events[i].ID
+	}
+	c.Writer.Flush()
+
+	// the live events are only appended by this controller, so the event log is also tailed
+	// periodically to stream the events appended by the leader on the follower.
+	tail := func() error {
+		events, err := handler.events.Since(c, lastID)
+		if err != nil {
+			// retry at the next tick, only the broken stream is closed
+			return nil
+		}
+		for i := range events {
+			if err := writeEvent(c.Writer, &events[i]); err != nil {
+				return err
+			}
+			lastID = events[i].ID
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	ticker := time.NewTicker(eventTailInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-liveEvents:
+			if event.ID <= lastID {
+				continue
+			}
+			if event.ID > lastID+1 {
+				// the events in between were missed, e.g. dropped by the slow subscription
+				if err := tail(); err != nil {
+					return
+				}
+				continue
+			}
+			if err := writeEvent(c.Writer, &event); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			lastID = event.ID
+		case <-ticker.C:
+			if err := tail(); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
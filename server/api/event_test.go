@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestEventHandler_TailOnFollower(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := engine.NewMock()
+	leaderEvents := store.NewEventLog(e)
+	// the follower has its own event log without the live events appended by the leader
+	handler := &EventHandler{events: store.NewEventLog(e)}
+	router := gin.New()
+	router.GET("/events", handler.List)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := leaderEvents.Append(ctx, store.EventPayload{Namespace: "ns", Type: store.EventNamespace, Command: store.CommandCreate})
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+	reader := bufio.NewReader(rsp.Body)
+	nextID := func() string {
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if strings.HasPrefix(line, "id: ") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "id: "))
+			}
+		}
+	}
+	require.Equal(t, "1", nextID())
+
+	_, err = leaderEvents.Append(ctx, store.EventPayload{Namespace: "ns", Type: store.EventNamespace, Command: store.CommandUpdate})
+	require.NoError(t, err)
+	require.Equal(t, "2", nextID())
+}
@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/apache/kvrocks-controller/controller"
+	"github.com/apache/kvrocks-controller/server/helper"
+)
+
+// FailoverBreakerHandler serves the status and the acknowledgment of the failover breaker
+type FailoverBreakerHandler struct {
+	c *controller.Controller
+}
+
+func (handler *FailoverBreakerHandler) Get(c *gin.Context) {
+	helper.ResponseOK(c, gin.H{"breaker": handler.c.FailoverBreakerStatus()})
+}
+
+// Acknowledge resumes the automatic failover suspended by the global breaker
+func (handler *FailoverBreakerHandler) Acknowledge(c *gin.Context) {
+	if err := handler.c.AcknowledgeFailoverBreaker("", ""); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseNoContent(c)
+}
+
+// AcknowledgeCluster resumes the automatic failover of the cluster
+func (handler *FailoverBreakerHandler) AcknowledgeCluster(c *gin.Context) {
+	if err := handler.c.AcknowledgeFailoverBreaker(c.Param("namespace"), c.Param("cluster")); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseNoContent(c)
+}
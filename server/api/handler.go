@@ -21,23 +21,69 @@
 package api
 
 import (
+	"regexp"
+	"time"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/controller"
 	"github.com/apache/kvrocks-controller/store"
 )
 
 type Handler struct {
-	Namespace *NamespaceHandler
-	Cluster   *ClusterHandler
-	Shard     *ShardHandler
-	Node      *NodeHandler
-	Raft      *RaftHandler
+	Namespace  *NamespaceHandler
+	Cluster    *ClusterHandler
+	Shard      *ShardHandler
+	Node       *NodeHandler
+	Raft       *RaftHandler
+	Chaos      *ChaosHandler
+	Prometheus *PrometheusHandler
+	Recover    *RecoverHandler
+	Event      *EventHandler
+	Backup     *BackupHandler
+	Breaker    *FailoverBreakerHandler
+	Leadership *LeadershipHandler
+	Detection  *DetectionHandler
+	Session    *SessionHandler
+	Engine     *EngineHealthHandler
+	Support    *SupportBundleHandler
+	Checker    *CheckerHandler
 }
 
-func NewHandler(s *store.ClusterStore) *Handler {
+func NewHandler(s *store.ClusterStore, ctrl *controller.Controller, cfg *config.Config) *Handler {
+	var headroomLimits store.HeadroomLimits
+	if cfg != nil && cfg.Controller != nil && cfg.Controller.Migration != nil {
+		headroomLimits.MaxDiskUsage = cfg.Controller.Migration.MaxTargetDiskUsage
+		headroomLimits.MaxMemoryBytes = cfg.Controller.Migration.MaxTargetMemoryBytes
+	}
+	var nameRules store.NameRules
+	if cfg != nil && cfg.Names != nil {
+		nameRules.MaxLength = cfg.Names.MaxLength
+		if cfg.Names.Pattern != "" {
+			// the pattern was validated when loading the config
+			nameRules.Pattern = regexp.MustCompile(cfg.Names.Pattern)
+		}
+	}
+	var coalesceWindow time.Duration
+	if cfg != nil && cfg.Controller != nil {
+		coalesceWindow = time.Duration(cfg.Controller.UpdateCoalesceWindowMs) * time.Millisecond
+	}
 	return &Handler{
-		Namespace: &NamespaceHandler{s: s},
-		Cluster:   &ClusterHandler{s: s},
-		Shard:     &ShardHandler{s: s},
-		Node:      &NodeHandler{s: s},
-		Raft:      &RaftHandler{},
+		Namespace:  &NamespaceHandler{s: s, nameRules: nameRules},
+		Cluster:    &ClusterHandler{s: s, c: ctrl, headroomLimits: headroomLimits, nameRules: nameRules},
+		Shard:      &ShardHandler{s: s},
+		Node:       &NodeHandler{s: s, updater: store.NewClusterUpdater(s, coalesceWindow)},
+		Raft:       &RaftHandler{},
+		Chaos:      &ChaosHandler{c: ctrl},
+		Prometheus: &PrometheusHandler{s: s},
+		Recover:    &RecoverHandler{s: s, nameRules: nameRules},
+		Event:      &EventHandler{events: s.Events()},
+		Backup:     &BackupHandler{s: s, c: ctrl},
+		Breaker:    &FailoverBreakerHandler{c: ctrl},
+		Leadership: &LeadershipHandler{s: s},
+		Detection:  &DetectionHandler{c: ctrl},
+		Session:    &SessionHandler{s: s},
+		Engine:     &EngineHealthHandler{c: ctrl},
+		Support:    &SupportBundleHandler{s: s, c: ctrl, config: cfg},
+		Checker:    &CheckerHandler{c: ctrl},
 	}
 }
@@ -21,11 +21,14 @@
 package api
 
 import (
-	"errors"
+	"fmt"
 
-	"github.com/apache/kvrocks-controller/consts"
+	"go.uber.org/zap"
 
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
 	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/server/middleware"
 
 	"github.com/gin-gonic/gin"
 
@@ -33,7 +36,8 @@ import (
 )
 
 type NamespaceHandler struct {
-	s store.Store
+	s         store.Store
+	nameRules store.NameRules
 }
 
 func (handler *NamespaceHandler) List(c *gin.Context) {
@@ -45,31 +49,32 @@ func (handler *NamespaceHandler) List(c *gin.Context) {
 	helper.ResponseOK(c, gin.H{"namespaces": namespaces})
 }
 
+// Exists returns the metadata of the namespace if it exists
 func (handler *NamespaceHandler) Exists(c *gin.Context) {
-	namespace := c.Param("namespace")
-	ok, err := handler.s.ExistsNamespace(c, namespace)
+	namespace, err := handler.s.GetNamespace(c, c.Param("namespace"))
 	if err != nil {
 		helper.ResponseError(c, err)
 		return
 	}
-	if !ok {
-		helper.ResponseError(c, consts.ErrNotFound)
-		return
-	}
-	helper.ResponseOK(c, nil)
+	helper.ResponseOK(c, gin.H{"namespace": namespace})
 }
 
 func (handler *NamespaceHandler) Create(c *gin.Context) {
 	var request struct {
-		Namespace string `json:"namespace" validate:"required"`
+		Namespace   string `json:"namespace" validate:"required"`
+		Owner       string `json:"owner"`
+		Description string `json:"description"`
+		Contact     string `json:"contact"`
+		// Labels are added to the metrics and events of the clusters in the namespace
+		Labels map[string]string `json:"labels"`
 	}
 	if err := c.BindJSON(&request); err != nil {
 		helper.ResponseBadRequest(c, err)
 		return
 	}
 
-	if len(request.Namespace) == 0 {
-		helper.ResponseBadRequest(c, errors.New("namespace should NOT be empty"))
+	if err := handler.nameRules.Validate("namespace", request.Namespace); err != nil {
+		helper.ResponseError(c, err)
 		return
 	}
 
@@ -77,9 +82,87 @@ func (handler *NamespaceHandler) Create(c *gin.Context) {
 		helper.ResponseError(c, err)
 		return
 	}
+	if request.Owner != "" || request.Description != "" || request.Contact != "" || len(request.Labels) > 0 {
+		namespace := &store.Namespace{
+			Name:        request.Namespace,
+			Owner:       request.Owner,
+			Description: request.Description,
+			Contact:     request.Contact,
+		}
+		namespace.UpdateLabels(request.Labels)
+		if err := handler.s.UpdateNamespace(c, namespace); err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+	}
 	helper.ResponseCreated(c, gin.H{"namespace": request.Namespace})
 }
 
+// Update changes the metadata of the namespace, the field won't be changed if it's absent
+func (handler *NamespaceHandler) Update(c *gin.Context) {
+	var request struct {
+		Owner       *string `json:"owner"`
+		Description *string `json:"description"`
+		Contact     *string `json:"contact"`
+		// Labels will be merged into the existing labels, the label will be removed if its value is empty
+		Labels map[string]string `json:"labels"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	namespace, err := handler.s.GetNamespace(c, c.Param("namespace"))
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if request.Owner != nil {
+		namespace.Owner = *request.Owner
+	}
+	if request.Description != nil {
+		namespace.Description = *request.Description
+	}
+	if request.Contact != nil {
+		namespace.Contact = *request.Contact
+	}
+	namespace.UpdateLabels(request.Labels)
+	if err := handler.s.UpdateNamespace(c, namespace); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"namespace": namespace})
+}
+
+// Rename moves the namespace and its clusters to the new name
+func (handler *NamespaceHandler) Rename(c *gin.Context) {
+	var request struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if err := handler.nameRules.Validate("namespace", request.Name); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if !middleware.CanAccessNamespace(c, request.Name) {
+		helper.ResponseError(c, fmt.Errorf("%w: the token can't access the namespace '%s'",
+			consts.ErrForbidden, request.Name))
+		return
+	}
+	ns := c.Param("namespace")
+	if err := handler.s.RenameNamespace(c, ns, request.Name); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("new_namespace", request.Name),
+	).Info("Rename the namespace")
+	helper.ResponseOK(c, gin.H{"namespace": request.Name})
+}
+
 func (handler *NamespaceHandler) Remove(c *gin.Context) {
 	namespace := c.Param("namespace")
 	if err := handler.s.RemoveNamespace(c, namespace); err != nil {
@@ -78,6 +78,9 @@ func TestNamespaceBasics(t *testing.T) {
 		runCreate(t, "test0", http.StatusCreated)
 		runCreate(t, "test1", http.StatusCreated)
 		runCreate(t, "test0", http.StatusConflict)
+		runCreate(t, "", http.StatusBadRequest)
+		runCreate(t, "test/2", http.StatusBadRequest)
+		runCreate(t, "test 2", http.StatusBadRequest)
 	})
 
 	t.Run("exits namespace", func(t *testing.T) {
@@ -98,8 +101,37 @@ func TestNamespaceBasics(t *testing.T) {
 		require.ElementsMatch(t, []string{"test0", "test1"}, rsp.Data["namespaces"])
 	})
 
+	t.Run("update namespace", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Params = []gin.Param{{Key: "namespace", Value: "test1"}}
+		ctx.Request.Body = io.NopCloser(bytes.NewBufferString(`{"owner":"team-a","contact":"team-a@example.com"}`))
+		handler.Update(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		namespace, err := handler.s.GetNamespace(ctx, "test1")
+		require.NoError(t, err)
+		require.Equal(t, &store.Namespace{Name: "test1", Owner: "team-a", Contact: "team-a@example.com"}, namespace)
+	})
+
+	t.Run("rename namespace", func(t *testing.T) {
+		runRename := func(t *testing.T, ns, newNs string, expectedStatusCode int) {
+			recorder := httptest.NewRecorder()
+			ctx := GetTestContext(recorder)
+			ctx.Params = []gin.Param{{Key: "namespace", Value: ns}}
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(fmt.Sprintf("{\"name\":\"%s\"}", newNs)))
+			handler.Rename(ctx)
+			require.Equal(t, expectedStatusCode, recorder.Code)
+		}
+		runRename(t, "test1", "test0", http.StatusConflict)
+		runRename(t, "test1", "test/0", http.StatusBadRequest)
+		runRename(t, "test1", "test2", http.StatusOK)
+		runExists(t, "test1", http.StatusNotFound)
+		runExists(t, "test2", http.StatusOK)
+	})
+
 	t.Run("remove namespace", func(t *testing.T) {
-		for _, ns := range []string{"test0", "test1"} {
+		for _, ns := range []string{"test0", "test2"} {
 			runRemove(t, ns, http.StatusNoContent)
 			runRemove(t, ns, http.StatusNotFound)
 		}
@@ -21,17 +21,48 @@
 package api
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
 	"github.com/apache/kvrocks-controller/server/helper"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"github.com/apache/kvrocks-controller/store"
 )
 
 type NodeHandler struct {
 	s store.Store
+	// updater coalesces the node additions and removals of the same cluster
+	updater *store.ClusterUpdater
+}
+
+// Inventory returns the locations of all nodes in the node index
+func (handler *NodeHandler) Inventory(c *gin.Context) {
+	locations, err := handler.s.ListNodes(c)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"nodes": locations})
+}
+
+// Search returns the namespace, cluster, shard and role of the node address in all clusters
+func (handler *NodeHandler) Search(c *gin.Context) {
+	addr := c.Query("addr")
+	if addr == "" {
+		helper.ResponseBadRequest(c, errors.New("addr is required"))
+		return
+	}
+	locations, err := handler.s.SearchNode(c, addr)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"nodes": locations})
 }
 
 func (handler *NodeHandler) List(c *gin.Context) {
@@ -39,13 +70,26 @@ func (handler *NodeHandler) List(c *gin.Context) {
 	helper.ResponseOK(c, gin.H{"nodes": shard.Nodes})
 }
 
+// ifMatchVersion returns the cluster version which was validated by If-Match,
+// it's 0 if the request has no precondition on the version.
+func ifMatchVersion(c *gin.Context, cluster *store.Cluster) int64 {
+	ifMatch := strings.TrimSpace(c.GetHeader(consts.HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0
+	}
+	return cluster.Version.Load()
+}
+
 func (handler *NodeHandler) Create(c *gin.Context) {
 	ns := c.Param("namespace")
 	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
 	var req struct {
-		Addr     string `json:"addr" binding:"required"`
-		Role     string `json:"role"`
-		Password string `json:"password"`
+		Addr       string `json:"addr" binding:"required"`
+		Role       string `json:"role"`
+		Password   string `json:"password"`
+		MasterAuth string `json:"master_auth"`
+		// Labels describe the node, e.g. the `host` label identifies the physical host
+		Labels map[string]string `json:"labels"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.ResponseBadRequest(c, err)
@@ -55,7 +99,110 @@ func (handler *NodeHandler) Create(c *gin.Context) {
 		req.Role = store.RoleSlave
 	}
 	shardIndex, _ := strconv.Atoi(c.Param("shard"))
-	newNode, err := cluster.AddNode(shardIndex, req.Addr, req.Role, req.Password)
+	var newNode *store.ClusterNode
+	err := handler.updater.Update(c, ns, cluster.Name, ifMatchVersion(c, cluster), func(cluster *store.Cluster) error {
+		node, err := cluster.AddResolvedNode(c, shardIndex, req.Addr, req.Role, req.Password)
+		if err != nil {
+			return err
+		}
+		node.SetMasterAuth(req.MasterAuth)
+		// the replica won't be promoted until it caught up with the master
+		if !node.IsMaster() {
+			node.SetSyncing(true)
+		}
+		if len(req.Labels) > 0 {
+			if _, err := cluster.UpdateNodeLabels(shardIndex, node.ID(), req.Labels); err != nil {
+				return err
+			}
+		}
+		newNode = node
+		return nil
+	})
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if newNode.IsSyncing() {
+		if err := handler.s.SaveJob(c, ns, cluster.Name, store.NewReplicaSyncJob(shardIndex, newNode)); err != nil {
+			logger.Get().With(
+				zap.String("namespace", ns),
+				zap.String("cluster", cluster.Name),
+				zap.String("node", newNode.Addr()),
+				zap.Error(err),
+			).Warn("Failed to save the replica sync job")
+		}
+	}
+	helper.ResponseCreated(c, newNode.ID())
+}
+
+func (handler *NodeHandler) Remove(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	err := handler.updater.Update(c, ns, cluster.Name, ifMatchVersion(c, cluster), func(cluster *store.Cluster) error {
+		return cluster.RemoveNode(shardIndex, c.Param("id"))
+	})
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseNoContent(c)
+}
+
+// Replace changes the address of the node while keeping its ID and shard membership,
+// the new node will be synced with the topology and replicate from the master.
+func (handler *NodeHandler) Replace(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		NewAddr string `json:"new_addr" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	nodeID := c.Param("id")
+	node, err := cluster.ReplaceNodeAddr(c, shardIndex, nodeID, req.NewAddr)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.CheckNewNodes(c, []string{node.Addr()}); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	// the other nodes will be synced by the controller after the version was bumped,
+	// sync the new node eagerly so that it can start to replicate as soon as possible.
+	if err := node.SyncClusterInfo(c, cluster); err != nil {
+		logger.Get().With(
+			zap.String("namespace", ns),
+			zap.String("cluster", cluster.Name),
+			zap.String("node", node.Addr()),
+			zap.Error(err),
+		).Warn("Failed to sync the cluster info to the replaced node")
+	}
+	helper.ResponseOK(c, gin.H{"node": node})
+}
+
+// SetRestoring marks whether the node is restoring from the backup, the checker won't
+// count the failures of the restoring node or sync the topology to it.
+func (handler *NodeHandler) SetRestoring(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		Restoring *bool `json:"restoring" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	node, err := cluster.SetNodeRestoring(shardIndex, c.Param("id"), *req.Restoring)
 	if err != nil {
 		helper.ResponseError(c, err)
 		return
@@ -64,14 +211,59 @@ func (handler *NodeHandler) Create(c *gin.Context) {
 		helper.ResponseError(c, err)
 		return
 	}
-	helper.ResponseCreated(c, newNode.ID())
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster.Name),
+		zap.String("node", node.Addr()),
+		zap.Bool("restoring", *req.Restoring),
+	).Info("Mark the node restoring state")
+	helper.ResponseOK(c, gin.H{"node": node})
 }
 
-func (handler *NodeHandler) Remove(c *gin.Context) {
+// SetReplicaOf makes the replica replicate from another replica in the same shard,
+// the replica will replicate from the master if the replica_of is empty.
+func (handler *NodeHandler) SetReplicaOf(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		ReplicaOf string `json:"replica_of"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	node, err := cluster.SetNodeReplicaOf(shardIndex, c.Param("id"), req.ReplicaOf)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster.Name),
+		zap.String("node", node.Addr()),
+		zap.String("replica_of", req.ReplicaOf),
+	).Info("Change the upstream of the replica")
+	helper.ResponseOK(c, gin.H{"node": node})
+}
+
+// UpdateLabels merges the labels into the node, the label will be removed if its value is empty
+func (handler *NodeHandler) UpdateLabels(c *gin.Context) {
 	ns := c.Param("namespace")
 	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		Labels map[string]string `json:"labels" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
 	shardIndex, _ := strconv.Atoi(c.Param("shard"))
-	err := cluster.RemoveNode(shardIndex, c.Param("id"))
+	node, err := cluster.UpdateNodeLabels(shardIndex, c.Param("id"), req.Labels)
 	if err != nil {
 		helper.ResponseError(c, err)
 		return
@@ -80,5 +272,5 @@ func (handler *NodeHandler) Remove(c *gin.Context) {
 		helper.ResponseError(c, err)
 		return
 	}
-	helper.ResponseNoContent(c)
+	helper.ResponseOK(c, gin.H{"node": node})
 }
@@ -41,7 +41,8 @@ func TestNodeBasics(t *testing.T) {
 	cluster, err := store.NewCluster("test-cluster", []string{"127.0.0.1:1234", "127.0.0.1:1235"}, 2)
 	require.NoError(t, err)
 
-	handler := &NodeHandler{s: store.NewClusterStore(engine.NewMock())}
+	s := store.NewClusterStore(engine.NewMock())
+	handler := &NodeHandler{s: s, updater: store.NewClusterUpdater(s, 0)}
 	require.NoError(t, handler.s.CreateCluster(context.Background(), ns, cluster))
 
 	runCreate := func(t *testing.T, addr, role string, expectedStatusCode int) {
@@ -119,6 +120,36 @@ func TestNodeBasics(t *testing.T) {
 		require.Len(t, rsp.Data.Nodes, 3)
 	})
 
+	t.Run("set node restoring", func(t *testing.T) {
+		runSetRestoring := func(t *testing.T, nodeID, body string, expectedStatusCode int) {
+			recorder := httptest.NewRecorder()
+			ctx := GetTestContext(recorder)
+			ctx.Set(consts.ContextKeyStore, handler.s)
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(body))
+			ctx.Params = []gin.Param{{Key: "namespace", Value: ns},
+				{Key: "cluster", Value: cluster.Name},
+				{Key: "shard", Value: "0"},
+				{Key: "id", Value: nodeID}}
+			middleware.RequiredClusterShard(ctx)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			handler.SetRestoring(ctx)
+			require.Equal(t, expectedStatusCode, recorder.Code)
+		}
+
+		nodeID := cluster.Shards[0].Nodes[1].ID()
+		runSetRestoring(t, nodeID, `{}`, http.StatusBadRequest)
+		runSetRestoring(t, "not-exists", `{"restoring": true}`, http.StatusNotFound)
+		runSetRestoring(t, nodeID, `{"restoring": true}`, http.StatusOK)
+		updated, err := handler.s.GetCluster(context.Background(), ns, cluster.Name)
+		require.NoError(t, err)
+		require.True(t, updated.Shards[0].Nodes[1].IsRestoring())
+
+		runSetRestoring(t, nodeID, `{"restoring": false}`, http.StatusOK)
+		updated, err = handler.s.GetCluster(context.Background(), ns, cluster.Name)
+		require.NoError(t, err)
+		require.False(t, updated.Shards[0].Nodes[1].IsRestoring())
+	})
+
 	t.Run("remove node", func(t *testing.T) {
 		runRemove(t, cluster.Shards[0].Nodes[0].ID(), http.StatusBadRequest)
 		runRemove(t, cluster.Shards[0].Nodes[1].ID(), http.StatusNoContent)
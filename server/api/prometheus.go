@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// TargetGroup is the target group in the Prometheus http_sd format,
+// see https://prometheus.io/docs/prometheus/latest/http_sd/
+type TargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+type PrometheusHandler struct {
+	s store.Store
+}
+
+// Targets returns all kvrocks nodes of the managed clusters in the Prometheus
+// http_sd format, so that Prometheus can discover the nodes to scrape.
+func (handler *PrometheusHandler) Targets(c *gin.Context) {
+	namespaces, err := handler.s.ListNamespace(c)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	groups := make([]TargetGroup, 0)
+	for _, ns := range namespaces {
+		clusters, err := handler.s.GetClusters(c, ns)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		for _, cluster := range clusters {
+			for i, shard := range cluster.Shards {
+				for _, node := range shard.Nodes {
+					role := store.RoleSlave
+					if node.IsMaster() {
+						role = store.RoleMaster
+					}
+					groups = append(groups, TargetGroup{
+						Targets: []string{node.Addr()},
+						Labels: map[string]string{
+							"namespace": ns,
+							"cluster":   cluster.Name,
+							"shard":     strconv.Itoa(i),
+							"role":      role,
+							"node_id":   node.ID(),
+						},
+					})
+				}
+			}
+		}
+	}
+	c.JSON(http.StatusOK, groups)
+}
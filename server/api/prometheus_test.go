@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestPrometheusTargets(t *testing.T) {
+	ctx := context.Background()
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	require.NoError(t, clusterStore.CreateNamespace(ctx, "test-ns"))
+	cluster, err := store.NewCluster("test-cluster", []string{"node0", "node1", "node2", "node3"}, 2)
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.CreateCluster(ctx, "test-ns", cluster))
+
+	handler := &PrometheusHandler{s: clusterStore}
+	recorder := httptest.NewRecorder()
+	handler.Targets(GetTestContext(recorder))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var groups []TargetGroup
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &groups))
+	require.Len(t, groups, 4)
+	require.Equal(t, []string{"node2"}, groups[2].Targets)
+	require.Equal(t, "test-ns", groups[2].Labels["namespace"])
+	require.Equal(t, "test-cluster", groups[2].Labels["cluster"])
+	require.Equal(t, "1", groups[2].Labels["shard"])
+	require.Equal(t, store.RoleMaster, groups[2].Labels["role"])
+	require.Equal(t, store.RoleSlave, groups[3].Labels["role"])
+}
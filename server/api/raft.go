@@ -21,9 +21,14 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/logger"
@@ -37,8 +42,11 @@ import (
 const (
 	OperationAdd    = "add"
 	OperationRemove = "remove"
+	OperationUpdate = "update"
 )
 
+const transferLeadershipTimeout = 30 * time.Second
+
 type RaftHandler struct{}
 
 type MemberRequest struct {
@@ -49,12 +57,163 @@ type MemberRequest struct {
 
 func (r *MemberRequest) validate() error {
 	r.Operation = strings.ToLower(r.Operation)
-	if r.Operation != OperationAdd && r.Operation != OperationRemove {
+	if r.Operation != OperationAdd && r.Operation != OperationRemove && r.Operation != OperationUpdate {
 		return fmt.Errorf("operation must be one of [%s]",
-			strings.Join([]string{OperationAdd, OperationRemove}, ","))
+			strings.Join([]string{OperationAdd, OperationRemove, OperationUpdate}, ","))
+	}
+	if r.Operation == OperationAdd || r.Operation == OperationUpdate {
+		if r.Peer == "" {
+			return fmt.Errorf("peer should NOT be empty")
+		}
+		return validatePeerURL(r.Peer)
+	}
+	return nil
+}
+
+// validatePeerURL returns an error if the peer isn't the URL with the scheme and host:port,
+// e.g. http://127.0.0.1:6001, which can't be dialed by the raft transport.
+func validatePeerURL(peer string) error {
+	peerURL, err := url.Parse(peer)
+	if err != nil {
+		return fmt.Errorf("invalid peer '%s': %w", peer, err)
+	}
+	if peerURL.Scheme == "" {
+		return fmt.Errorf("invalid peer '%s': the scheme is required", peer)
+	}
+	host, port, err := net.SplitHostPort(peerURL.Host)
+	if err != nil || host == "" || port == "" {
+		return fmt.Errorf("invalid peer '%s': the host should be host:port", peer)
+	}
+	return nil
+}
+
+// ThresholdsRequest tunes the snapshot and compact thresholds of the local raft node,
+// the omitted threshold won't be changed.
+type ThresholdsRequest struct {
+	SnapshotThreshold *uint64 `json:"snapshot_threshold"`
+	CompactThreshold  *uint64 `json:"compact_threshold"`
+}
+
+type TransferLeadershipRequest struct {
+	TargetID uint64 `json:"target_id" validate:"required,gt=0"`
+}
+
+func (handler *RaftHandler) Snapshot(c *gin.Context) {
+	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
+	index, err := raftNode.TriggerSnapshot(c)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(zap.Uint64("index", index)).Info("Trigger snapshot success")
+	helper.ResponseOK(c, gin.H{"index": index})
+}
+
+// DownloadSnapshot returns the latest snapshot in raw bytes, which is used to
+// bootstrap the fresh node before joining the cluster.
+func (handler *RaftHandler) DownloadSnapshot(c *gin.Context) {
+	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
+	snapshot, err := raftNode.LatestSnapshot(c)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	snapshotBytes, err := snapshot.Marshal()
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/octet-stream", snapshotBytes)
+}
+
+func (handler *RaftHandler) TransferLeadership(c *gin.Context) {
+	var req TransferLeadershipRequest
+	if err := c.BindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if req.TargetID == 0 {
+		helper.ResponseBadRequest(c, errors.New("target_id should NOT be empty"))
+		return
+	}
+
+	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
+	target := findPeer(raftNode.Peers(), req.TargetID)
+	if target == nil {
+		helper.ResponseBadRequest(c, errors.New("peer not exists"))
+		return
+	}
+	if target.IsLearner {
+		helper.ResponseBadRequest(c, errors.New("can't transfer the leadership to the learner"))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, transferLeadershipTimeout)
+	defer cancel()
+	if err := raftNode.TransferLeadership(ctx, req.TargetID); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(zap.Uint64("target_id", req.TargetID)).Info("Transfer leadership success")
+	helper.ResponseOK(c, gin.H{"leader": raftNode.GetRaftLead()})
+}
+
+func (handler *RaftHandler) Status(c *gin.Context) {
+	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
+	status, err := raftNode.Status()
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"status": status})
+}
+
+func (handler *RaftHandler) GetThresholds(c *gin.Context) {
+	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
+	snapshotThreshold, compactThreshold := raftNode.Thresholds()
+	helper.ResponseOK(c, gin.H{
+		"snapshot_threshold": snapshotThreshold,
+		"compact_threshold":  compactThreshold,
+	})
+}
+
+// UpdateThresholds changes the thresholds of the local raft node at runtime,
+// the change is not persisted and only applies to the node which serves the request.
+func (handler *RaftHandler) UpdateThresholds(c *gin.Context) {
+	var req ThresholdsRequest
+	if err := c.BindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if (req.SnapshotThreshold != nil && *req.SnapshotThreshold == 0) ||
+		(req.CompactThreshold != nil && *req.CompactThreshold == 0) {
+		helper.ResponseBadRequest(c, errors.New("threshold should be greater than 0"))
+		return
 	}
-	if r.Operation == OperationAdd && r.Peer == "" {
-		return fmt.Errorf("peer should NOT be empty")
+
+	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
+	if req.SnapshotThreshold != nil {
+		raftNode.SetSnapshotThreshold(*req.SnapshotThreshold)
+	}
+	if req.CompactThreshold != nil {
+		raftNode.SetCompactThreshold(*req.CompactThreshold)
+	}
+	snapshotThreshold, compactThreshold := raftNode.Thresholds()
+	logger.Get().With(
+		zap.Uint64("snapshot_threshold", snapshotThreshold),
+		zap.Uint64("compact_threshold", compactThreshold),
+	).Info("Update the raft thresholds")
+	helper.ResponseOK(c, gin.H{
+		"snapshot_threshold": snapshotThreshold,
+		"compact_threshold":  compactThreshold,
+	})
+}
+
+// findPeer returns the peer with the ID, it's nil if the peer doesn't exist
+func findPeer(peers []raft.PeerInfo, id uint64) *raft.PeerInfo {
+	for i := range peers {
+		if peers[i].ID == id {
+			return &peers[i]
+		}
 	}
 	return nil
 }
@@ -63,7 +222,7 @@ func (handler *RaftHandler) ListPeers(c *gin.Context) {
 	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
 	helper.ResponseOK(c, gin.H{
 		"leader": raftNode.GetRaftLead(),
-		"peers":  raftNode.ListPeers(),
+		"peers":  raftNode.Peers(),
 	})
 }
 
@@ -79,19 +238,36 @@ func (handler *RaftHandler) UpdatePeer(c *gin.Context) {
 	}
 
 	raftNode, _ := c.MustGet(consts.ContextKeyRaftNode).(*raft.Node)
-	peers := raftNode.ListPeers()
+	peers := raftNode.Peers()
+	peer := findPeer(peers, req.ID)
 
 	var err error
 	if req.Operation == OperationAdd {
 		for _, peer := range peers {
-			if peer == req.Peer {
+			if peer.Addr == req.Peer {
 				helper.ResponseError(c, fmt.Errorf("peer '%s' already exists", req.Peer))
 				return
 			}
 		}
 		err = raftNode.AddPeer(c, req.ID, req.Peer)
+	} else if req.Operation == OperationUpdate {
+		if peer == nil {
+			helper.ResponseBadRequest(c, errors.New("peer not exists"))
+			return
+		}
+		if peer.Addr == req.Peer {
+			helper.ResponseOK(c, nil)
+			return
+		}
+		for _, other := range peers {
+			if other.ID != req.ID && other.Addr == req.Peer {
+				helper.ResponseError(c, fmt.Errorf("peer '%s' already exists", req.Peer))
+				return
+			}
+		}
+		err = raftNode.UpdatePeer(c, req.ID, req.Peer)
 	} else {
-		if _, ok := peers[req.ID]; !ok {
+		if peer == nil {
 			helper.ResponseBadRequest(c, errors.New("peer not exists"))
 			return
 		}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+const (
+	RecoverStatusCreated = "created"
+	RecoverStatusExists  = "exists"
+	RecoverStatusFailed  = "failed"
+)
+
+type RecoverRequest struct {
+	Namespace string   `json:"namespace" validate:"required"`
+	Nodes     []string `json:"nodes" validate:"required"`
+	Password  string   `json:"password"`
+	// Prefix is the prefix of the recovered cluster names, default is `cluster`
+	Prefix string `json:"prefix"`
+}
+
+type RecoveredCluster struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Nodes   int    `json:"nodes"`
+}
+
+type RecoverHandler struct {
+	s         store.Store
+	nameRules store.NameRules
+}
+
+// Recover rebuilds the namespace and clusters in the metadata store from the live nodes,
+// it's the recovery path after the metadata was lost. It's safe to rerun since the existing
+// clusters will be skipped.
+func (handler *RecoverHandler) Recover(c *gin.Context) {
+	var req RecoverRequest
+	if err := c.BindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if err := handler.nameRules.Validate("namespace", req.Namespace); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if len(req.Nodes) == 0 {
+		helper.ResponseBadRequest(c, errors.New("nodes should NOT be empty"))
+		return
+	}
+	if req.Prefix == "" {
+		req.Prefix = "cluster"
+	}
+
+	clusters, failures := store.DiscoverClusters(c, req.Nodes, req.Password)
+	if len(clusters) == 0 {
+		helper.ResponseBadRequest(c, errors.New("no cluster was discovered from the nodes"))
+		return
+	}
+	if err := handler.s.CreateNamespace(c, req.Namespace); err != nil && !errors.Is(err, consts.ErrAlreadyExists) {
+		helper.ResponseError(c, err)
+		return
+	}
+
+	results := make([]RecoveredCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		cluster.Name = store.RecoveredClusterName(req.Prefix, cluster)
+		result := RecoveredCluster{Name: cluster.Name, Nodes: len(cluster.GetNodes())}
+		newNodes := make([]string, 0)
+		for _, node := range cluster.GetNodes() {
+			newNodes = append(newNodes, node.Addr())
+		}
+		err := handler.nameRules.Validate("cluster", cluster.Name)
+		if err == nil {
+			err = handler.s.CheckNewNodes(c, newNodes)
+		}
+		if err == nil {
+			err = handler.s.CreateCluster(c, req.Namespace, cluster)
+		}
+		switch {
+		case err == nil:
+			result.Status = RecoverStatusCreated
+		case errors.Is(err, consts.ErrAlreadyExists):
+			result.Status = RecoverStatusExists
+			result.Message = err.Error()
+		default:
+			result.Status = RecoverStatusFailed
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+	logger.Get().With(
+		zap.String("namespace", req.Namespace),
+		zap.Any("clusters", results),
+		zap.Any("failures", failures),
+	).Info("Recover the clusters from nodes")
+	helper.ResponseOK(c, gin.H{"clusters": results, "failures": failures})
+}
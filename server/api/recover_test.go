@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestRecoverHandler_InvalidNamespace(t *testing.T) {
+	s := store.NewClusterStore(engine.NewMock())
+	handler := &RecoverHandler{s: s}
+	for _, ns := range []string{"", "bad/ns", "bad ns"} {
+		body, err := json.Marshal(&RecoverRequest{Namespace: ns, Nodes: []string{"127.0.0.1:1111"}})
+		require.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		handler.Recover(ctx)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, ns)
+	}
+	namespaces, err := s.ListNamespace(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, namespaces)
+}
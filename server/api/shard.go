@@ -20,13 +20,17 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
 	"github.com/apache/kvrocks-controller/server/helper"
 	"github.com/apache/kvrocks-controller/store"
 )
@@ -44,43 +48,91 @@ type CreateShardRequest struct {
 	Slaves []store.ClusterNode `json:"slaves"`
 }
 
+// newShardFromAddrs creates the shard with the resolved addresses,
+// the first node would be the master and others are the slaves.
+func newShardFromAddrs(c *gin.Context, addrs []string, password, masterAuth string) (*store.Shard, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("nodes should NOT be empty")
+	}
+	resolvedAddrs, err := store.ResolveNodeAddrs(c, addrs)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]store.Node, 0, len(resolvedAddrs))
+	for i, resolvedAddr := range resolvedAddrs {
+		node := store.NewClusterNode(resolvedAddr.Addr, password)
+		node.SetMasterAuth(masterAuth)
+		node.SetHostname(resolvedAddr.Hostname)
+		if i == 0 {
+			node.SetRole(store.RoleMaster)
+		} else {
+			node.SetRole(store.RoleSlave)
+		}
+		nodes = append(nodes, node)
+	}
+	newShard := store.NewShard()
+	newShard.Nodes = nodes
+	return newShard, nil
+}
+
 func (handler *ShardHandler) List(c *gin.Context) {
 	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
-	helper.ResponseOK(c, gin.H{"shards": cluster.Shards})
+	projected, err := helper.ProjectQueryFields(c, cluster.Shards)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	helper.ResponseOK(c, gin.H{"shards": projected})
 }
 
+// Get returns the shard with the initial replication sync progress of the syncing replicas
 func (handler *ShardHandler) Get(c *gin.Context) {
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
 	shard, _ := c.MustGet(consts.ContextKeyClusterShard).(*store.Shard)
-	helper.ResponseOK(c, gin.H{"shard": shard})
+	projected, err := helper.ProjectQueryFields(c, shard)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	syncingNodes := make(map[string]bool)
+	for _, node := range shard.Nodes {
+		if node.IsSyncing() {
+			syncingNodes[node.ID()] = true
+		}
+	}
+	replicaSyncs := make([]*store.JobReplicaSync, 0)
+	if len(syncingNodes) > 0 {
+		jobs, err := handler.s.ListJobs(c, c.Param("namespace"), cluster.Name, store.JobTypeReplicaSync)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		for _, job := range jobs {
+			if job.Status == store.JobStatusRunning && job.ReplicaSync != nil && syncingNodes[job.ReplicaSync.NodeID] {
+				replicaSyncs = append(replicaSyncs, job.ReplicaSync)
+			}
+		}
+	}
+	helper.ResponseOK(c, gin.H{"shard": projected, "replica_syncs": replicaSyncs})
 }
 
 func (handler *ShardHandler) Create(c *gin.Context) {
 	ns := c.Param("namespace")
 	var req struct {
-		Nodes    []string `json:"nodes" validate:"required"`
-		Password string   `json:"password"`
+		Nodes      []string `json:"nodes" validate:"required"`
+		Password   string   `json:"password"`
+		MasterAuth string   `json:"master_auth"`
 	}
 	if err := c.BindJSON(&req); err != nil {
 		helper.ResponseBadRequest(c, err)
 		return
 	}
-	if len(req.Nodes) == 0 {
-		helper.ResponseBadRequest(c, errors.New("nodes should NOT be empty"))
+	newShard, err := newShardFromAddrs(c, req.Nodes, req.Password, req.MasterAuth)
+	if err != nil {
+		helper.ResponseBadRequest(c, err)
 		return
 	}
-	nodes := make([]store.Node, 0, len(req.Nodes))
-	for i, addr := range req.Nodes {
-		node := store.NewClusterNode(addr, req.Password)
-		if i == 0 {
-			node.SetRole(store.RoleMaster)
-		} else {
-			node.SetRole(store.RoleSlave)
-		}
-		nodes = append(nodes, node)
-	}
 	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
-	newShard := store.NewShard()
-	newShard.Nodes = nodes
 	cluster.Shards = append(cluster.Shards, newShard)
 	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
 		helper.ResponseError(c, err)
@@ -106,6 +158,14 @@ func (handler *ShardHandler) Remove(c *gin.Context) {
 		helper.ResponseBadRequest(c, consts.ErrShardIsServicing)
 		return
 	}
+	if err := cluster.CheckShardFrozen(shardIdx); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := cluster.CheckShardRemovable(shardIdx); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
 	cluster.Shards = append(cluster.Shards[:shardIdx], cluster.Shards[shardIdx+1:]...)
 	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
 		helper.ResponseError(c, err)
@@ -133,6 +193,20 @@ func (handler *ShardHandler) Failover(c *gin.Context) {
 	}
 	// We have checked this if statement in middleware.RequiredClusterShard
 	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	// the simulation evaluates the candidates in the same way but won't promote the new master
+	if strings.ToLower(c.Query("simulate")) == "true" {
+		simulation, err := cluster.SimulateFailover(c, shardIndex, req.PreferredNodeID)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		helper.ResponseOK(c, gin.H{"simulation": simulation})
+		return
+	}
+	var oldMasterNodeID string
+	if master := cluster.Shards[shardIndex].GetMasterNode(); master != nil {
+		oldMasterNodeID = master.ID()
+	}
 	newMasterNodeID, err := cluster.PromoteNewMaster(c, shardIndex, "", req.PreferredNodeID)
 	if err != nil {
 		helper.ResponseError(c, err)
@@ -142,5 +216,189 @@ func (handler *ShardHandler) Failover(c *gin.Context) {
 		helper.ResponseError(c, err)
 		return
 	}
+	// the hook may take a while with the retries, so the result is recorded in the cluster revision
+	go handler.s.RunFailoverHook(context.Background(), ns, cluster.Clone(), shardIndex,
+		oldMasterNodeID, newMasterNodeID, store.FailoverTriggerManual)
 	helper.ResponseOK(c, gin.H{"new_master_id": newMasterNodeID})
 }
+
+// Freeze marks whether the shard is frozen, the controller won't fail over or migrate
+// the frozen shard and the topology-changing operations on it will be rejected.
+func (handler *ShardHandler) Freeze(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		Frozen *bool `json:"frozen" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	shard, err := cluster.SetShardFrozen(shardIndex, *req.Frozen)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster.Name),
+		zap.Int("shard", shardIndex),
+		zap.Bool("frozen", *req.Frozen),
+	).Info("Mark the shard frozen state")
+	helper.ResponseOK(c, gin.H{"shard": shard})
+}
+
+// Split moves the upper half slots of the shard into the new shard with the given nodes,
+// the slots will be migrated one range at a time in the background.
+func (handler *ShardHandler) Split(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		Nodes      []string `json:"nodes"`
+		Password   string   `json:"password"`
+		MasterAuth string   `json:"master_auth"`
+		// Force splits the shard even if the new shard would break the placement rules
+		Force bool `json:"force"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	newShard, err := newShardFromAddrs(c, req.Nodes, req.Password, req.MasterAuth)
+	if err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	newNodeAddrs := make([]string, 0, len(newShard.Nodes))
+	for _, node := range newShard.Nodes {
+		newNodeAddrs = append(newNodeAddrs, node.Addr())
+	}
+	if err := handler.s.CheckNewNodes(c, newNodeAddrs); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	newShardIndex, err := cluster.SplitShard(shardIndex, newShard)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if !allowPlacement(c, ns, cluster.Name, cluster.CheckPendingPlacement(shardIndex), req.Force) {
+		return
+	}
+	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	syncPendingMigration(c, ns, cluster)
+	helper.ResponseCreated(c, gin.H{
+		"source": cluster.Shards[shardIndex],
+		"target": newShard,
+		"index":  newShardIndex,
+	})
+}
+
+// Merge moves all slots of the shard into the target shard, the slots will be migrated
+// one range at a time in the background, and the shard can be removed after that.
+func (handler *ShardHandler) Merge(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		Target *int `json:"target" binding:"required"`
+		// Force merges the shard even if the target would break the placement rules
+		Force bool `json:"force"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	if err := cluster.MergeShard(shardIndex, *req.Target); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	if !allowPlacement(c, ns, cluster.Name, cluster.CheckPendingPlacement(shardIndex), req.Force) {
+		return
+	}
+	if err := handler.s.UpdateCluster(c, ns, cluster); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	syncPendingMigration(c, ns, cluster)
+	helper.ResponseOK(c, gin.H{
+		"source": cluster.Shards[shardIndex],
+		"target": cluster.Shards[*req.Target],
+	})
+}
+
+// syncPendingMigration syncs the persisted topology to the nodes eagerly, the pending slots are
+// migrated by the controller which syncs the nodes again before sending the migration to them.
+func syncPendingMigration(c *gin.Context, ns string, cluster *store.Cluster) {
+	if err := cluster.SyncToNodes(c); err != nil {
+		logger.Get().With(
+			zap.String("namespace", ns),
+			zap.String("cluster", cluster.Name),
+			zap.Error(err),
+		).Warn("Failed to sync the topology before migrating the pending slots")
+	}
+}
+
+// syncMovedShard syncs the topology to the nodes of the cluster after moving the shard
+func syncMovedShard(c *gin.Context, ns string, cluster *store.Cluster) {
+	for _, node := range cluster.GetNodes() {
+		if err := node.SyncClusterInfo(c, cluster); err != nil {
+			logger.Get().With(
+				zap.String("namespace", ns),
+				zap.String("cluster", cluster.Name),
+				zap.String("node", node.Addr()),
+				zap.Error(err),
+			).Warn("Failed to sync the cluster info to the node after moving the shard")
+		}
+	}
+}
+
+// Move detaches the shard with its nodes and slots from the cluster and attaches it to the target
+// cluster in the same namespace, e.g. to split a large cluster into two. The slots of the shard
+// won't be served by the source cluster anymore.
+func (handler *ShardHandler) Move(c *gin.Context) {
+	ns := c.Param("namespace")
+	cluster, _ := c.MustGet(consts.ContextKeyCluster).(*store.Cluster)
+	var req struct {
+		Target string `json:"target" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseBadRequest(c, err)
+		return
+	}
+	if !confirmProtected(c, cluster, "move the shard out of it") {
+		return
+	}
+	shardIndex, _ := strconv.Atoi(c.Param("shard"))
+	targetShardIndex, err := handler.s.MoveShard(c, ns, cluster.Name, shardIndex, req.Target)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	targetCluster, err := handler.s.GetCluster(c, ns, req.Target)
+	if err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	// the moved nodes are no longer checked by the source cluster and the remaining nodes still
+	// serve the moved slots, so sync both topologies eagerly instead of waiting for the controller,
+	// the failed nodes will be synced by it later.
+	syncMovedShard(c, ns, targetCluster)
+	if sourceCluster, err := handler.s.GetCluster(c, ns, cluster.Name); err == nil {
+		syncMovedShard(c, ns, sourceCluster)
+	}
+	helper.ResponseOK(c, gin.H{
+		"cluster": targetCluster,
+		"index":   targetShardIndex,
+	})
+}
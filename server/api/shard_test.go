@@ -41,6 +41,7 @@ import (
 	"github.com/apache/kvrocks-controller/server/middleware"
 	"github.com/apache/kvrocks-controller/store"
 	"github.com/apache/kvrocks-controller/store/engine"
+	"github.com/apache/kvrocks-controller/testutil/kvrockstest"
 )
 
 func TestShardBasics(t *testing.T) {
@@ -156,6 +157,43 @@ func TestShardBasics(t *testing.T) {
 		require.Len(t, rsp.Data.Shards, 2)
 	})
 
+	runFreeze := func(t *testing.T, shardIndex int, frozen bool) {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Set(consts.ContextKeyStore, handler.s)
+		ctx.Params = []gin.Param{
+			{Key: "namespace", Value: ns},
+			{Key: "cluster", Value: clusterName},
+			{Key: "shard", Value: strconv.Itoa(shardIndex)},
+		}
+		body, err := json.Marshal(map[string]bool{"frozen": frozen})
+		require.NoError(t, err)
+		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		middleware.RequiredClusterShard(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		handler.Freeze(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp struct {
+			Data struct {
+				Shard *store.Shard `json:"shard"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Equal(t, frozen, rsp.Data.Shard.Frozen)
+	}
+
+	t.Run("freeze shard", func(t *testing.T) {
+		runFreeze(t, 1, true)
+		cluster, err := handler.s.GetCluster(context.Background(), ns, clusterName)
+		require.NoError(t, err)
+		require.True(t, cluster.Shards[1].Frozen)
+		// the frozen shard can't be removed
+		runRemove(t, 1, http.StatusForbidden)
+		runFreeze(t, 1, false)
+	})
+
 	t.Run("remove shard", func(t *testing.T) {
 		// shard 0 is servicing
 		runRemove(t, 0, http.StatusBadRequest)
@@ -168,7 +206,7 @@ func TestClusterFailover(t *testing.T) {
 	clusterName := "test-cluster-failover"
 	clusterStore := store.NewClusterStore(engine.NewMock())
 	handler := &ShardHandler{s: clusterStore}
-	cluster, err := store.NewCluster(clusterName, []string{"127.0.0.1:7770", "127.0.0.1:7771"}, 2)
+	cluster, err := store.NewCluster(clusterName, kvrockstest.Start(t, 2), 2)
 	require.NoError(t, err)
 	node0, _ := cluster.Shards[0].Nodes[0].(*store.ClusterNode)
 	node1, _ := cluster.Shards[0].Nodes[1].(*store.ClusterNode)
@@ -283,3 +321,134 @@ func TestClusterFailover(t *testing.T) {
 		require.Equal(t, http.StatusOK, recorder.Code)
 	})
 }
+
+func TestShardFailoverSimulate(t *testing.T) {
+	ns := "test-ns"
+	clusterName := "test-cluster-simulate"
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ShardHandler{s: clusterStore}
+	cluster, err := store.NewCluster(clusterName, []string{"127.0.0.1:1111", "127.0.0.1:1112"}, 2)
+	require.NoError(t, err)
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, cluster))
+
+	recorder := httptest.NewRecorder()
+	ctx := GetTestContext(recorder)
+	ctx.Set(consts.ContextKeyStore, handler.s)
+	ctx.Request.URL.RawQuery = "simulate=true"
+	ctx.Params = []gin.Param{
+		{Key: "namespace", Value: ns},
+		{Key: "cluster", Value: clusterName},
+		{Key: "shard", Value: "0"},
+	}
+	middleware.RequiredClusterShard(ctx)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	handler.Failover(ctx)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp struct {
+		Data struct {
+			Simulation store.FailoverSimulation `json:"simulation"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	simulation := rsp.Data.Simulation
+	require.Equal(t, cluster.Shards[0].Nodes[0].ID(), simulation.OldMasterID)
+	require.Empty(t, simulation.NewMasterID)
+	require.Equal(t, consts.ErrShardNoMatchNewMaster.Error(), simulation.Error)
+	require.Len(t, simulation.Candidates, 1)
+	require.Contains(t, simulation.Candidates[0].Reason, "failed to get the cluster info")
+
+	// the cluster isn't changed by the simulation
+	gotCluster, err := clusterStore.GetCluster(context.Background(), ns, clusterName)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, gotCluster.Version.Load())
+	require.True(t, gotCluster.Shards[0].Nodes[0].IsMaster())
+}
+
+func TestShardMove(t *testing.T) {
+	ns := "test-ns"
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ShardHandler{s: clusterStore}
+	source, err := store.NewCluster("test-cluster-source", []string{"127.0.0.1:1111", "127.0.0.1:1112"}, 1)
+	require.NoError(t, err)
+	source.Protected = true
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, source))
+	target := &store.Cluster{Name: "test-cluster-target", Shards: store.Shards{store.NewShard()}}
+	target.Shards[0].Nodes = []store.Node{store.NewClusterNode("127.0.0.1:1113", "")}
+	target.Shards[0].Nodes[0].SetRole(store.RoleMaster)
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, target))
+
+	runMove := func(t *testing.T, body string, confirmed bool, expectedStatusCode int) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx := GetTestContext(recorder)
+		ctx.Set(consts.ContextKeyStore, handler.s)
+		ctx.Request.Body = io.NopCloser(bytes.NewBufferString(body))
+		if confirmed {
+			ctx.Request.Header.Set(consts.HeaderConfirmProtected, "yes")
+		}
+		ctx.Params = []gin.Param{
+			{Key: "namespace", Value: ns},
+			{Key: "cluster", Value: source.Name},
+			{Key: "shard", Value: "1"},
+		}
+		middleware.RequiredClusterShard(ctx)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		handler.Move(ctx)
+		require.Equal(t, expectedStatusCode, recorder.Code)
+		return recorder
+	}
+
+	runMove(t, `{}`, true, http.StatusBadRequest)
+	runMove(t, `{"target": "test-cluster-target"}`, false, http.StatusForbidden)
+	runMove(t, `{"target": "not-exists"}`, true, http.StatusNotFound)
+	recorder := runMove(t, `{"target": "test-cluster-target"}`, true, http.StatusOK)
+
+	var rsp struct {
+		Data struct {
+			Cluster *store.Cluster `json:"cluster"`
+			Index   int            `json:"index"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, 1, rsp.Data.Index)
+	require.Equal(t, source.Shards[1].SlotRanges, rsp.Data.Cluster.Shards[1].SlotRanges)
+
+	gotSource, err := clusterStore.GetCluster(context.Background(), ns, source.Name)
+	require.NoError(t, err)
+	require.Len(t, gotSource.Shards, 1)
+}
+
+func TestShardMergePlacement(t *testing.T) {
+	ns := "test-ns"
+	clusterStore := store.NewClusterStore(engine.NewMock())
+	handler := &ShardHandler{s: clusterStore}
+	cluster, err := store.NewCluster("test-cluster-placement",
+		[]string{"127.0.0.1:1111", "127.0.0.1:1112", "127.0.0.1:1113"}, 1)
+	require.NoError(t, err)
+	for i, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+		_, err = cluster.UpdateNodeLabels(i, cluster.Shards[i].Nodes[0].ID(), map[string]string{store.LabelZone: zone})
+		require.NoError(t, err)
+	}
+	cluster.Placement = &store.PlacementRules{MaxZoneSlotsPercent: 50}
+	require.NoError(t, clusterStore.CreateCluster(context.Background(), ns, cluster))
+
+	recorder := httptest.NewRecorder()
+	ctx := GetTestContext(recorder)
+	ctx.Set(consts.ContextKeyStore, handler.s)
+	ctx.Request.Body = io.NopCloser(bytes.NewBufferString(`{"target": 0}`))
+	ctx.Params = []gin.Param{
+		{Key: "namespace", Value: ns},
+		{Key: "cluster", Value: cluster.Name},
+		{Key: "shard", Value: "1"},
+	}
+	middleware.RequiredClusterShard(ctx)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	// zone-a would serve 2/3 of the slots after merging
+	handler.Merge(ctx)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "zone-a")
+
+	got, err := clusterStore.GetCluster(context.Background(), ns, cluster.Name)
+	require.NoError(t, err)
+	require.False(t, got.Shards[1].HasPendingSlots())
+}
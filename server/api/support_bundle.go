@@ -0,0 +1,280 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/common/expfmt"
+	"gopkg.in/yaml.v1"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/controller"
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// maxBundleLogBytes is the size of the latest logs collected into the support bundle
+const maxBundleLogBytes = 4 << 20
+
+// SupportBundleHandler collects the state of the controller into a tarball
+// which can be attached to the bug reports.
+type SupportBundleHandler struct {
+	s      *store.ClusterStore
+	c      *controller.Controller
+	config *config.Config
+}
+
+// bundleWriter writes the files into the tarball, the files which failed to be collected
+// are recorded in errors.txt instead of failing the whole bundle.
+type bundleWriter struct {
+	tw     *tar.Writer
+	now    time.Time
+	errors []string
+}
+
+func (w *bundleWriter) writeFile(name string, data []byte) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: w.now,
+	}); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+func (w *bundleWriter) writeJSON(name string, v any, err error) error {
+	if err != nil {
+		w.errors = append(w.errors, fmt.Sprintf("%s: %v", name, err))
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return w.writeFile(name, data)
+}
+
+// Get returns the support bundle with the controller status, the failover breaker, the redacted config,
+// the latest logs, the cluster documents, the migration jobs, the failover history, the events and
+// the metrics snapshot.
+func (handler *SupportBundleHandler) Get(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := handler.writeBundle(c, &buf, time.Now()); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("kvrocks-controller-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/gzip", buf.Bytes())
+}
+
+func (handler *SupportBundleHandler) writeBundle(ctx context.Context, out io.Writer, now time.Time) error {
+	gw := gzip.NewWriter(out)
+	w := &bundleWriter{tw: tar.NewWriter(gw), now: now}
+
+	if err := handler.writeController(ctx, w); err != nil {
+		return err
+	}
+	if handler.config != nil {
+		data, err := yaml.Marshal(handler.config.Redacted())
+		if err != nil {
+			return err
+		}
+		if err := w.writeFile("config.yaml", data); err != nil {
+			return err
+		}
+		if err := handler.writeLogs(w); err != nil {
+			return err
+		}
+	}
+	if err := handler.writeClusters(ctx, w); err != nil {
+		return err
+	}
+	events, err := handler.s.Events().Since(ctx, 0)
+	if err := w.writeJSON("events.json", events, err); err != nil {
+		return err
+	}
+	if err := handler.writeMetrics(w); err != nil {
+		return err
+	}
+	if len(w.errors) > 0 {
+		if err := w.writeFile("errors.txt", []byte(strings.Join(w.errors, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func (handler *SupportBundleHandler) writeController(ctx context.Context, w *bundleWriter) error {
+	status := gin.H{
+		"id":           handler.s.ID(),
+		"leader":       handler.s.Leader(),
+		"is_leader":    handler.s.IsLeader(),
+		"generated_at": w.now.Unix(),
+	}
+	if handler.c != nil {
+		status["engine"] = handler.c.EngineHealth()
+		status["failover_breaker"] = handler.c.FailoverBreakerStatus()
+	}
+	if err := w.writeJSON("controller.json", status, nil); err != nil {
+		return err
+	}
+	breaker, err := handler.s.GetFailoverBreakerState(ctx)
+	if err := w.writeJSON("failover_breaker.json", breaker, err); err != nil {
+		return err
+	}
+	sessions, err := handler.s.ListSessions(ctx)
+	if err := w.writeJSON("sessions.json", sessions, err); err != nil {
+		return err
+	}
+	history, err := handler.s.LeadershipHistory(ctx)
+	return w.writeJSON("leadership_history.json", history, err)
+}
+
+func (handler *SupportBundleHandler) writeClusters(ctx context.Context, w *bundleWriter) error {
+	namespaces, err := handler.s.ListNamespace(ctx)
+	if err != nil {
+		return w.writeJSON("clusters", nil, err)
+	}
+	for _, ns := range namespaces {
+		clusters, err := handler.s.GetClusters(ctx, ns)
+		if err != nil {
+			if err := w.writeJSON(filepath.Join("clusters", ns), nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, cluster := range clusters {
+			dir := filepath.Join("clusters", ns, cluster.Name)
+			redacted := cluster.Clone()
+			redacted.SetPassword("")
+			redacted.SetMasterAuth("")
+			if err := w.writeJSON(filepath.Join(dir, "cluster.json"), redacted, nil); err != nil {
+				return err
+			}
+			jobs, err := handler.s.ListJobs(ctx, ns, cluster.Name, store.JobTypeMigration)
+			if err := w.writeJSON(filepath.Join(dir, "migrations.json"), jobs, err); err != nil {
+				return err
+			}
+			failovers, err := handler.failoverRevisions(ctx, ns, cluster.Name)
+			if err := w.writeJSON(filepath.Join(dir, "failovers.json"), failovers, err); err != nil {
+				return err
+			}
+			if handler.c == nil {
+				continue
+			}
+			detections, err := handler.c.GetDetections(ns, cluster.Name)
+			if err := w.writeJSON(filepath.Join(dir, "detections.json"), detections, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// failoverRevisions returns the revisions of the cluster which invoked the failover hooks
+func (handler *SupportBundleHandler) failoverRevisions(ctx context.Context, ns, cluster string) ([]*store.ClusterRevision, error) {
+	revisions, err := handler.s.ListClusterRevisions(ctx, ns, cluster)
+	if err != nil {
+		return nil, err
+	}
+	failovers := make([]*store.ClusterRevision, 0)
+	for _, revision := range revisions {
+		if len(revision.FailoverHooks) > 0 {
+			failovers = append(failovers, revision)
+		}
+	}
+	return failovers, nil
+}
+
+// writeLogs collects the latest logs if the logs were written to the file
+func (handler *SupportBundleHandler) writeLogs(w *bundleWriter) error {
+	if handler.config.Log == nil || handler.config.Log.Filename == "" {
+		return nil
+	}
+	data, err := tailFile(handler.config.Log.Filename, maxBundleLogBytes)
+	if err != nil {
+		w.errors = append(w.errors, fmt.Sprintf("logs: %v", err))
+		return nil
+	}
+	return w.writeFile(filepath.Join("logs", filepath.Base(handler.config.Log.Filename)), data)
+}
+
+func (handler *SupportBundleHandler) writeMetrics(w *bundleWriter) error {
+	families, err := metrics.Gatherer().Gather()
+	if err != nil {
+		w.errors = append(w.errors, fmt.Sprintf("metrics.txt: %v", err))
+	}
+	var buf bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return err
+		}
+	}
+	return w.writeFile("metrics.txt", buf.Bytes())
+}
+
+// tailFile returns the last maxBytes of the file, the first partial line is dropped
+func tailFile(filename string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - maxBytes
+	if offset <= 0 {
+		return io.ReadAll(f)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if index := bytes.IndexByte(data, '\n'); index >= 0 {
+		data = data[index+1:]
+	}
+	return data, nil
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package api
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestSupportBundle(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewClusterStore(engine.NewMock())
+	require.NoError(t, s.CreateNamespace(ctx, "test-ns"))
+	cluster, err := store.NewCluster("test-cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+	require.NoError(t, err)
+	cluster.SetPassword("node-secret")
+	require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+	oldMaster, newMaster := cluster.Shards[0].Nodes[0], cluster.Shards[0].Nodes[1]
+	oldMaster.SetRole(store.RoleSlave)
+	newMaster.SetRole(store.RoleMaster)
+	require.NoError(t, s.UpdateCluster(ctx, "test-ns", cluster))
+	store.SetFailoverHook(&store.FailoverHook{Command: []string{"true"}})
+	defer store.SetFailoverHook(nil)
+	s.RunFailoverHook(ctx, "test-ns", cluster, 0, oldMaster.ID(), newMaster.ID(), store.FailoverTriggerManual)
+
+	logFile := filepath.Join(t.TempDir(), "kvctl.log")
+	require.NoError(t, os.WriteFile(logFile, []byte("first line\nsecond line\n"), 0o644))
+	cfg := config.Default()
+	cfg.BasicAuth = config.BasicAuthConfig{Username: "admin", Password: "api-secret"}
+	cfg.Log = &config.LogConfig{Filename: logFile}
+
+	handler := &SupportBundleHandler{s: s, config: cfg}
+	recorder := httptest.NewRecorder()
+	handler.Get(GetTestContext(recorder))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/gzip", recorder.Header().Get("Content-Type"))
+
+	gr, err := gzip.NewReader(recorder.Body)
+	require.NoError(t, err)
+	files := make(map[string]string)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+	for _, name := range []string{
+		"controller.json", "failover_breaker.json", "config.yaml", "logs/kvctl.log", "events.json", "metrics.txt",
+		"clusters/test-ns/test-cluster/cluster.json", "clusters/test-ns/test-cluster/migrations.json",
+		"clusters/test-ns/test-cluster/failovers.json",
+	} {
+		require.Contains(t, files, name)
+	}
+	require.Equal(t, "first line\nsecond line\n", files["logs/kvctl.log"])
+	require.Contains(t, files["clusters/test-ns/test-cluster/cluster.json"], "127.0.0.1:1111")
+	require.Contains(t, files["clusters/test-ns/test-cluster/failovers.json"], newMaster.ID())
+	require.NotContains(t, files, "errors.txt")
+	for name, data := range files {
+		require.False(t, strings.Contains(data, "node-secret"), name)
+		require.False(t, strings.Contains(data, "api-secret"), name)
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(filename, []byte("line1\nline2\nline3\n"), 0o644))
+
+	data, err := tailFile(filename, 100)
+	require.NoError(t, err)
+	require.Equal(t, "line1\nline2\nline3\n", string(data))
+
+	// the partial line should be dropped
+	data, err = tailFile(filename, 8)
+	require.NoError(t, err)
+	require.Equal(t, "line3\n", string(data))
+
+	_, err = tailFile(filepath.Join(t.TempDir(), "not-exists.log"), 100)
+	require.Error(t, err)
+}
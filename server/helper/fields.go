@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// fieldSet is the tree of the selected fields, a nil subtree means
+// the whole field is selected.
+type fieldSet map[string]fieldSet
+
+func parseFields(fields string) (fieldSet, error) {
+	root := make(fieldSet)
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := root
+		segments := strings.Split(path, ".")
+		for i, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("%w: invalid field path '%s'", consts.ErrInvalidArgument, path)
+			}
+			sub, ok := node[segment]
+			if ok && sub == nil {
+				// the parent field was selected as a whole
+				break
+			}
+			if i == len(segments)-1 {
+				node[segment] = nil
+				break
+			}
+			if !ok {
+				sub = make(fieldSet)
+				node[segment] = sub
+			}
+			node = sub
+		}
+	}
+	if len(root) == 0 {
+		return nil, fmt.Errorf("%w: no fields were specified", consts.ErrInvalidArgument)
+	}
+	return root, nil
+}
+
+func (set fieldSet) project(value interface{}) interface{} {
+	if set == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(set))
+		for name, sub := range set {
+			if fieldValue, ok := v[name]; ok {
+				projected[name] = sub.project(fieldValue)
+			}
+		}
+		return projected
+	case []interface{}:
+		projected := make([]interface{}, len(v))
+		for i, elem := range v {
+			projected[i] = set.project(elem)
+		}
+		return projected
+	default:
+		return v
+	}
+}
+
+// ProjectFields keeps only the fields of the JSON form of the data which are listed
+// in the comma separated paths like `shards.nodes.addr,shards.slot_ranges`. The path
+// is applied to each element of the arrays and the missing fields are omitted.
+func ProjectFields(data interface{}, fields string) (interface{}, error) {
+	set, err := parseFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	// keep the numbers as they were to avoid losing the precision of int64
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return set.project(value), nil
+}
+
+// ProjectQueryFields projects the data with the `fields` query parameter if it's present
+func ProjectQueryFields(c *gin.Context, data interface{}) (interface{}, error) {
+	fields, ok := c.GetQuery("fields")
+	if !ok {
+		return data, nil
+	}
+	return ProjectFields(data, fields)
+}
@@ -24,7 +24,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -75,20 +78,72 @@ func ResponseError(c *gin.Context, err error) {
 		code = http.StatusConflict
 	} else if errors.Is(err, consts.ErrForbidden) {
 		code = http.StatusForbidden
+	} else if errors.Is(err, consts.ErrUnauthorized) {
+		code = http.StatusUnauthorized
 	} else if errors.Is(err, consts.ErrInvalidArgument) {
 		code = http.StatusBadRequest
+	} else if errors.Is(err, consts.ErrPreconditionFailed) {
+		// only the failed If-Match is 412, it's checked before the version mismatch which it may also match
+		code = http.StatusPreconditionFailed
+	} else if errors.Is(err, consts.ErrConflict) || errors.Is(err, consts.ErrVersionMismatch) {
+		code = http.StatusConflict
+	} else if errors.Is(err, consts.ErrUnavailable) {
+		code = http.StatusServiceUnavailable
 	}
-	c.JSON(code, Response{
-		Error: &Error{Message: err.Error()},
-	})
+	rsp := Response{Error: &Error{Message: err.Error()}}
+	// the error can carry the details to help the client to resolve it
+	var detailedErr interface{ Details() interface{} }
+	if errors.As(err, &detailedErr) {
+		rsp.Data = detailedErr.Details()
+	}
+	c.JSON(code, rsp)
 	c.Abort()
 }
 
+// ClusterETag returns the ETag of the cluster which is the quoted cluster version
+func ClusterETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// MatchETag returns whether the If-Match header value matches the cluster version,
+// the value can be `*` or a list of ETags separated by comma.
+func MatchETag(ifMatch string, version int64) bool {
+	etag := ClusterETag(version)
+	for _, value := range strings.Split(ifMatch, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckIfMatch returns the precondition error with the current version if the If-Match
+// header doesn't match the cluster version, it's nil if the header is absent.
+func CheckIfMatch(c *gin.Context, version int64) error {
+	ifMatch := c.GetHeader(consts.HeaderIfMatch)
+	if ifMatch == "" || MatchETag(ifMatch, version) {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", consts.ErrPreconditionFailed, &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: version})
+}
+
+// StartedAt is the start time of the controller process
+var StartedAt = time.Now()
+
 // generateSessionID encodes the addr to a session ID,
 // which is used to identify the session. And then can be used to
-// parse the leader listening address back.
+// parse the leader listening address back. The session ID is
+// identified by the hostname, pid and start time of the process,
+// so it's stable during the run.
 func GenerateSessionID(addr string) string {
-	return fmt.Sprintf("%s/%s", util.RandString(8), addr)
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = util.RandString(8)
+	}
+	// the slash is used to separate the addr
+	hostname = strings.ReplaceAll(hostname, "/", "_")
+	return fmt.Sprintf("%s-%d-%d/%s", hostname, os.Getpid(), StartedAt.Unix(), addr)
 }
 
 // extractAddrFromSessionID decodes the session ID to the addr.
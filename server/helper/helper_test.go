@@ -21,9 +21,18 @@
 package helper
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
 )
 
 func TestGenerateSessionID(t *testing.T) {
@@ -31,7 +40,90 @@ func TestGenerateSessionID(t *testing.T) {
 	sessionID := GenerateSessionID(testAddr)
 	decodedAddr := ExtractAddrFromSessionID(sessionID)
 	require.Equal(t, testAddr, decodedAddr)
+	// the session ID is stable during the run
+	require.Equal(t, sessionID, GenerateSessionID(testAddr))
+	require.Contains(t, sessionID, fmt.Sprintf("-%d-%d/", os.Getpid(), StartedAt.Unix()))
 
 	// old format
 	require.Equal(t, testAddr, ExtractAddrFromSessionID(testAddr))
 }
+
+func TestMatchETag(t *testing.T) {
+	require.Equal(t, `"3"`, ClusterETag(3))
+	require.True(t, MatchETag(`"3"`, 3))
+	require.True(t, MatchETag(`W/"3"`, 3))
+	require.True(t, MatchETag(`"1", "3"`, 3))
+	require.True(t, MatchETag("*", 3))
+	require.False(t, MatchETag(`"2"`, 3))
+	require.False(t, MatchETag("3", 3))
+}
+
+func TestResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		err     error
+		code    int
+		details map[string]interface{}
+	}{
+		{&consts.NotFoundError{Resource: "cluster", Key: "ns/c0"}, http.StatusNotFound,
+			map[string]interface{}{"resource": "cluster", "key": "ns/c0"}},
+		{fmt.Errorf("update: %w", &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: 3}), http.StatusConflict,
+			map[string]interface{}{"resource": "cluster", "current_version": float64(3)}},
+		{fmt.Errorf("%w: %w", consts.ErrPreconditionFailed, &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: 3}),
+			http.StatusPreconditionFailed, map[string]interface{}{"resource": "cluster", "current_version": float64(3)}},
+		{&consts.UnavailableError{Engine: "etcd", Err: errors.New("context deadline exceeded")}, http.StatusServiceUnavailable,
+			map[string]interface{}{"engine": "etcd"}},
+		{&consts.NotLeaderError{Leader: "127.0.0.1:9379"}, http.StatusServiceUnavailable,
+			map[string]interface{}{"leader": "127.0.0.1:9379", "not_leader": true}},
+		{errors.New("unknown"), http.StatusInternalServerError, nil},
+	} {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ResponseError(ctx, tc.err)
+		require.Equal(t, tc.code, recorder.Code)
+
+		var rsp struct {
+			Error *Error                 `json:"error"`
+			Data  map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		require.Equal(t, tc.err.Error(), rsp.Error.Message)
+		require.Equal(t, tc.details, rsp.Data)
+	}
+}
+
+func TestProjectFields(t *testing.T) {
+	data := map[string]interface{}{
+		"name":    "test-cluster",
+		"version": int64(1) << 60,
+		"shards": []map[string]interface{}{
+			{
+				"nodes":       []map[string]interface{}{{"id": "n0", "addr": "127.0.0.1:6666"}},
+				"slot_ranges": []string{"0-8191"},
+			},
+			{
+				"nodes":       []map[string]interface{}{{"id": "n1", "addr": "127.0.0.1:6667"}},
+				"slot_ranges": []string{"8192-16383"},
+			},
+		},
+	}
+	projected, err := ProjectFields(data, "shards.nodes.addr, shards.slot_ranges,version,not_exists")
+	require.NoError(t, err)
+	payload, err := json.Marshal(projected)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version":1152921504606846976,"shards":[`+
+		`{"nodes":[{"addr":"127.0.0.1:6666"}],"slot_ranges":["0-8191"]},`+
+		`{"nodes":[{"addr":"127.0.0.1:6667"}],"slot_ranges":["8192-16383"]}]}`, string(payload))
+
+	// the whole field wins over its subfields
+	projected, err = ProjectFields(data, "shards.nodes.addr,shards.nodes")
+	require.NoError(t, err)
+	payload, err = json.Marshal(projected)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"shards":[{"nodes":[{"id":"n0","addr":"127.0.0.1:6666"}]},`+
+		`{"nodes":[{"id":"n1","addr":"127.0.0.1:6667"}]}]}`, string(payload))
+
+	_, err = ProjectFields(data, "shards..addr")
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+	_, err = ProjectFields(data, " , ")
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+}
@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/apache/kvrocks-controller/config"
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/server/helper"
+)
+
+const bearerPrefix = "Bearer "
+
+// Authenticate authenticates the request by the bearer token or the basic auth. The token can only
+// access the routes with the namespace parameter of its namespaces, and the basic auth can access all.
+// It must be installed before registering the routes, so that the namespace parameter is available.
+func Authenticate(tokens []config.TokenConfig, basicAuth *config.BasicAuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if strings.HasPrefix(authorization, bearerPrefix) {
+			namespaces := matchToken(tokens, strings.TrimPrefix(authorization, bearerPrefix))
+			if namespaces == nil {
+				helper.ResponseError(c, fmt.Errorf("%w: invalid token", consts.ErrUnauthorized))
+				return
+			}
+			namespace := c.Param("namespace")
+			if !canAccessNamespace(namespaces, namespace) {
+				helper.ResponseError(c, fmt.Errorf("%w: the token can't access the namespace '%s'",
+					consts.ErrForbidden, namespace))
+				return
+			}
+			c.Set(consts.ContextKeyTokenNamespaces, namespaces)
+			c.Next()
+			return
+		}
+		if username, password, ok := c.Request.BasicAuth(); ok && basicAuth.Username != "" &&
+			secureEqual(username, basicAuth.Username) && secureEqual(password, basicAuth.Password) {
+			c.Next()
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="kvrocks-controller"`)
+		helper.ResponseError(c, consts.ErrUnauthorized)
+	}
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// matchToken returns the namespaces of the token, or nil if the token doesn't exist
+func matchToken(tokens []config.TokenConfig, token string) []string {
+	var namespaces []string
+	for _, t := range tokens {
+		if secureEqual(t.Token, token) {
+			namespaces = t.Namespaces
+		}
+	}
+	return namespaces
+}
+
+func canAccessNamespace(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns == config.AllNamespaces || (namespace != "" && ns == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessNamespace returns whether the request can access the namespace which isn't in the
+// route parameters, e.g. the target of the rename. It's always true if not authenticated by token.
+func CanAccessNamespace(c *gin.Context, namespace string) bool {
+	namespaces, ok := c.Value(consts.ContextKeyTokenNamespaces).([]string)
+	if !ok {
+		return true
+	}
+	return canAccessNamespace(namespaces, namespace)
+}
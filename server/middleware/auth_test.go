@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/config"
+)
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Authenticate([]config.TokenConfig{
+		{Token: "token-a", Namespaces: []string{"ns-a"}},
+		{Token: "token-all", Namespaces: []string{config.AllNamespaces}},
+	}, &config.BasicAuthConfig{Username: "admin", Password: "secret"}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/api/v1/namespaces", ok)
+	engine.GET("/api/v1/namespaces/:namespace/clusters", ok)
+	engine.GET("/api/v1/namespaces/:namespace/access/:target", func(c *gin.Context) {
+		if !CanAccessNamespace(c, c.Param("target")) {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	run := func(path string, setAuth func(r *http.Request)) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if setAuth != nil {
+			setAuth(req)
+		}
+		engine.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	bearer := func(token string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	require.Equal(t, http.StatusUnauthorized, run("/api/v1/namespaces", nil))
+	require.Equal(t, http.StatusUnauthorized, run("/api/v1/namespaces/ns-a/clusters", bearer("invalid")))
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces/ns-a/clusters", bearer("token-a")))
+	require.Equal(t, http.StatusForbidden, run("/api/v1/namespaces/ns-b/clusters", bearer("token-a")))
+	require.Equal(t, http.StatusForbidden, run("/api/v1/namespaces", bearer("token-a")))
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces", bearer("token-all")))
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces/ns-b/clusters", bearer("token-all")))
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces/ns-a/access/ns-a", bearer("token-a")))
+	require.Equal(t, http.StatusForbidden, run("/api/v1/namespaces/ns-a/access/ns-b", bearer("token-a")))
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces/ns-a/access/ns-b", bearer("token-all")))
+
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces", func(r *http.Request) {
+		r.SetBasicAuth("admin", "secret")
+	}))
+	require.Equal(t, http.StatusOK, run("/api/v1/namespaces/ns-a/access/ns-b", func(r *http.Request) {
+		r.SetBasicAuth("admin", "secret")
+	}))
+	require.Equal(t, http.StatusUnauthorized, run("/api/v1/namespaces", func(r *http.Request) {
+		r.SetBasicAuth("admin", "wrong")
+	}))
+}
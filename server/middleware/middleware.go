@@ -22,6 +22,7 @@ package middleware
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -62,9 +63,14 @@ func CollectMetrics(c *gin.Context) {
 
 func RedirectIfNotLeader(c *gin.Context) {
 	storage, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
+	// the read requests can be served by the local engine on the follower,
+	// and only the write requests need to go to the leader.
+	if c.GetBool(consts.ContextKeyServeLocalReads) && isReadRequest(c) {
+		serveLocalRead(c, storage)
+		return
+	}
 	if storage.Leader() == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no leader now, please retry later"})
-		c.Abort()
+		helper.ResponseError(c, &consts.NotLeaderError{})
 		return
 	}
 
@@ -78,14 +84,69 @@ func RedirectIfNotLeader(c *gin.Context) {
 			c.Redirect(http.StatusTemporaryRedirect, "http://"+peerAddr+c.Request.RequestURI)
 			c.Redirect(http.StatusTemporaryRedirect, "http://"+storage.Leader()+c.Request.RequestURI)
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no leader now, please retry later"})
-			c.Abort()
+			helper.ResponseError(c, &consts.NotLeaderError{})
 		}
 		return
 	}
 	c.Next()
 }
 
+// RequiredLeader rejects the write requests on the follower with the address of the leader,
+// it's used instead of RedirectIfNotLeader when the leader forwarding is disabled.
+func RequiredLeader(c *gin.Context) {
+	storage, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
+	if storage.IsLeader() {
+		c.Next()
+		return
+	}
+	if isReadRequest(c) {
+		serveLocalRead(c, storage)
+		return
+	}
+	// Raft engine will forward the proposal to the leader node under the hood
+	if _, isRaftMode := storage.GetEngine().(*raft.Node); isRaftMode {
+		c.Next()
+		return
+	}
+	var leaderAddr string
+	if leader := storage.Leader(); leader != "" {
+		leaderAddr = helper.ExtractAddrFromSessionID(leader)
+	}
+	helper.ResponseError(c, &consts.NotLeaderError{Leader: leaderAddr})
+}
+
+// serveLocalRead sets the staleness header of the local engine on the follower, and rejects
+// the read request with 503 if the local engine is staler than the max staleness.
+func serveLocalRead(c *gin.Context, storage *store.ClusterStore) {
+	if storage.IsLeader() {
+		c.Next()
+		return
+	}
+	c.Set(consts.ContextKeyLocalRead, true)
+	maxStaleness := c.GetDuration(consts.ContextKeyMaxStaleness)
+	staleness, err := storage.GetStaleness(c)
+	if err != nil {
+		// the staleness is unknown if the leader hasn't written any heartbeat yet
+		if maxStaleness > 0 {
+			helper.ResponseError(c, fmt.Errorf("%w: unknown staleness of the local engine: %v", consts.ErrUnavailable, err))
+			return
+		}
+		c.Next()
+		return
+	}
+	c.Header(consts.HeaderStaleness, staleness.String())
+	if maxStaleness > 0 && staleness.Age > maxStaleness {
+		helper.ResponseError(c, fmt.Errorf("%w: the local engine is stale by %s which exceeds the max staleness %s",
+			consts.ErrUnavailable, staleness.Age.Round(time.Millisecond), maxStaleness))
+		return
+	}
+	c.Next()
+}
+
+func isReadRequest(c *gin.Context) bool {
+	return c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+}
+
 func RequiredNamespace(c *gin.Context) {
 	s, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
 	ok, err := s.ExistsNamespace(c, c.Param("namespace"))
@@ -103,6 +164,7 @@ func RequiredNamespace(c *gin.Context) {
 
 func RequiredCluster(c *gin.Context) {
 	s, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
+	store.SetCommandLabels(c, c.Param("namespace"), c.Param("cluster"))
 	cluster, err := s.GetCluster(c, c.Param("namespace"), c.Param("cluster"))
 	if err != nil {
 		helper.ResponseError(c, err)
@@ -115,6 +177,7 @@ func RequiredCluster(c *gin.Context) {
 
 func RequiredClusterShard(c *gin.Context) {
 	s, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
+	store.SetCommandLabels(c, c.Param("namespace"), c.Param("cluster"))
 	cluster, err := s.GetCluster(c, c.Param("namespace"), c.Param("cluster"))
 	if err != nil {
 		helper.ResponseError(c, err)
@@ -137,6 +200,37 @@ func RequiredClusterShard(c *gin.Context) {
 	c.Next()
 }
 
+// CheckIfMatch rejects the request with 412 if the If-Match header doesn't
+// match the cluster version, it should be placed after RequiredCluster if any.
+func CheckIfMatch(c *gin.Context) {
+	ifMatch := c.GetHeader(consts.HeaderIfMatch)
+	if ifMatch == "" {
+		c.Next()
+		return
+	}
+	value, _ := c.Get(consts.ContextKeyCluster)
+	cluster, ok := value.(*store.Cluster)
+	if !ok {
+		s, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
+		var err error
+		cluster, err = s.GetCluster(c, c.Param("namespace"), c.Param("cluster"))
+		if errors.Is(err, consts.ErrNotFound) {
+			// If-Match can't match the resource which doesn't exist
+			helper.ResponseError(c, fmt.Errorf("cluster not found: %w", consts.ErrPreconditionFailed))
+			return
+		}
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+	}
+	if err := helper.CheckIfMatch(c, cluster.Version.Load()); err != nil {
+		helper.ResponseError(c, err)
+		return
+	}
+	c.Next()
+}
+
 func RequiredRaftEngine(c *gin.Context) {
 	storage, _ := c.MustGet(consts.ContextKeyStore).(*store.ClusterStore)
 	raftNode, ok := storage.GetEngine().(*raft.Node)
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+// followerEngine is the mock engine which isn't the leader
+type followerEngine struct {
+	*engine.Mock
+}
+
+func (e *followerEngine) Leader() string {
+	return "the_leader"
+}
+
+func TestServeLocalReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	leaderStore := store.NewClusterStore(engine.NewMock())
+	followerStore := store.NewClusterStore(&followerEngine{Mock: engine.NewMock()})
+
+	var localRead bool
+	run := func(s *store.ClusterStore, maxStaleness time.Duration) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set(consts.ContextKeyStore, s)
+			c.Set(consts.ContextKeyMaxStaleness, maxStaleness)
+			c.Next()
+		}, RequiredLeader)
+		router.GET("/api/v1/namespaces", func(c *gin.Context) {
+			localRead = c.GetBool(consts.ContextKeyLocalRead)
+			c.Status(http.StatusOK)
+		})
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/namespaces", nil))
+		return recorder
+	}
+
+	// the leader doesn't set the staleness header
+	recorder := run(leaderStore, time.Second)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Header().Get(consts.HeaderStaleness))
+	require.False(t, localRead)
+
+	// the staleness is unknown before the first heartbeat
+	require.Equal(t, http.StatusOK, run(followerStore, 0).Code)
+	require.Equal(t, http.StatusServiceUnavailable, run(followerStore, time.Second).Code)
+
+	require.NoError(t, followerStore.WriteLeaderHeartbeat(context.Background()))
+	recorder = run(followerStore, time.Minute)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get(consts.HeaderStaleness), "revision=1; age=")
+	require.True(t, localRead)
+
+	time.Sleep(20 * time.Millisecond)
+	recorder = run(followerStore, 10*time.Millisecond)
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.Contains(t, recorder.Header().Get(consts.HeaderStaleness), "revision=1; age=")
+}
@@ -20,26 +20,41 @@
 package server
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/apache/kvrocks-controller/server/helper"
 
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/server/api"
 	"github.com/apache/kvrocks-controller/server/middleware"
 )
 
 func (srv *Server) initHandlers() {
 	engine := srv.engine
+	leaderMiddleware := middleware.RedirectIfNotLeader
+	// the standby is never the leader, so it serves the read requests only
+	if srv.config.DisableLeaderForward || srv.config.Standby {
+		leaderMiddleware = middleware.RequiredLeader
+	}
 	engine.Use(middleware.CollectMetrics, func(c *gin.Context) {
 		c.Set(consts.ContextKeyStore, srv.store)
+		c.Set(consts.ContextKeyServeLocalReads, srv.config.ServeLocalReads)
+		c.Set(consts.ContextKeyMaxStaleness, time.Duration(srv.config.MaxStalenessMs)*time.Millisecond)
 		c.Next()
-	}, middleware.RedirectIfNotLeader)
-	handler := api.NewHandler(srv.store)
+	}, leaderMiddleware)
+	handler := api.NewHandler(srv.store, srv.controller, srv.config)
+	srv.controller.RegisterMapSize("cluster_handler_locks", handler.Cluster.CountLocks)
 
-	engine.Any("/debug/pprof/*profile", PProf)
-	engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	srv.adminEngine.Any("/debug/pprof/*profile", PProf)
+	srv.adminEngine.GET("/admin/checkers", handler.Checker.List)
+	srv.metricsEngine.GET("/metrics", gin.WrapH(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer, promhttp.HandlerFor(metrics.Gatherer(), promhttp.HandlerOpts{}),
+	)))
 	engine.NoRoute(func(c *gin.Context) {
 		helper.ResponseError(c, consts.ErrNotFound)
 		c.Abort()
@@ -52,13 +67,35 @@ func (srv *Server) initHandlers() {
 			raftAPI.Use(middleware.RequiredRaftEngine)
 			raftAPI.POST("/peers", handler.Raft.UpdatePeer)
 			raftAPI.GET("/peers", handler.Raft.ListPeers)
+			raftAPI.GET("/status", handler.Raft.Status)
+			raftAPI.POST("/snapshot", handler.Raft.Snapshot)
+			// downloading the snapshot triggers a new one, so it is not a GET
+			raftAPI.POST("/snapshot/download", handler.Raft.DownloadSnapshot)
+			raftAPI.POST("/transfer-leadership", handler.Raft.TransferLeadership)
+			raftAPI.GET("/thresholds", handler.Raft.GetThresholds)
+			raftAPI.PUT("/thresholds", handler.Raft.UpdateThresholds)
 		}
 
+		apiV1.GET("prometheus/targets", handler.Prometheus.Targets)
+		apiV1.POST("recover", handler.Recover.Recover)
+		apiV1.GET("events", handler.Event.List)
+		apiV1.GET("nodes", handler.Node.Inventory)
+		apiV1.GET("nodes/search", handler.Node.Search)
+		apiV1.GET("failover-breaker", handler.Breaker.Get)
+		apiV1.DELETE("failover-breaker", handler.Breaker.Acknowledge)
+		apiV1.GET("controller/leadership-history", handler.Leadership.History)
+		apiV1.GET("controller/sessions", handler.Session.List)
+		apiV1.GET("controller/engine-health", handler.Engine.Get)
+		// the bundle has no namespace parameter, so only the tokens bound to `*` can access it
+		apiV1.GET("controller/support-bundle", handler.Support.Get)
+
 		namespaces := apiV1.Group("namespaces")
 		{
 			namespaces.GET("", handler.Namespace.List)
 			namespaces.GET("/:namespace", handler.Namespace.Exists)
 			namespaces.POST("", handler.Namespace.Create)
+			namespaces.PATCH("/:namespace", handler.Namespace.Update)
+			namespaces.POST("/:namespace/rename", handler.Namespace.Rename)
 			namespaces.DELETE("/:namespace", handler.Namespace.Remove)
 		}
 
@@ -67,25 +104,57 @@ func (srv *Server) initHandlers() {
 			clusters.GET("", middleware.RequiredNamespace, handler.Cluster.List)
 			clusters.POST("", middleware.RequiredNamespace, handler.Cluster.Create)
 			clusters.POST("/:cluster/import", middleware.RequiredNamespace, handler.Cluster.Import)
+			clusters.POST("/:cluster/convert", middleware.RequiredNamespace, handler.Cluster.Convert)
 			clusters.GET("/:cluster", middleware.RequiredCluster, handler.Cluster.Get)
-			clusters.DELETE("/:cluster", middleware.RequiredCluster, handler.Cluster.Remove)
-			clusters.POST("/:cluster/migrate", handler.Cluster.MigrateSlot)
+			clusters.GET("/:cluster/endpoints", middleware.RequiredCluster, handler.Cluster.Endpoints)
+			clusters.PATCH("/:cluster", middleware.RequiredCluster, middleware.CheckIfMatch, handler.Cluster.Update)
+			clusters.DELETE("/:cluster", middleware.RequiredCluster, middleware.CheckIfMatch, handler.Cluster.Remove)
+			clusters.POST("/:cluster/migrate", middleware.CheckIfMatch, handler.Cluster.MigrateSlot)
+			clusters.POST("/:cluster/check", middleware.RequiredCluster, handler.Cluster.Check)
+			clusters.GET("/:cluster/compactions", middleware.RequiredCluster, handler.Cluster.Compactions)
+			clusters.GET("/:cluster/migrations", middleware.RequiredCluster, handler.Cluster.Migrations)
+			clusters.GET("/:cluster/migrations/:id", middleware.RequiredCluster, handler.Cluster.GetMigration)
+			clusters.DELETE("/:cluster/migrations/:id", middleware.RequiredCluster, handler.Cluster.CancelMigration)
+			clusters.GET("/:cluster/history/:rev/diff", middleware.RequiredCluster, handler.Cluster.HistoryDiff)
+			clusters.GET("/:cluster/detections", middleware.RequiredCluster, handler.Detection.List)
+			clusters.GET("/:cluster/backups", middleware.RequiredCluster, handler.Backup.List)
+			clusters.POST("/:cluster/backups", middleware.RequiredCluster, handler.Backup.Create)
+			clusters.DELETE("/:cluster/failover-breaker", middleware.RequiredCluster, handler.Breaker.AcknowledgeCluster)
+			clusters.POST("/:cluster/rotate-password", middleware.RequiredCluster, middleware.CheckIfMatch, handler.Cluster.RotatePassword)
+			clusters.PUT("/:cluster/spec", middleware.RequiredNamespace, middleware.CheckIfMatch, handler.Cluster.Reconcile)
+		}
+
+		if srv.config.Controller != nil && srv.config.Controller.EnableChaos {
+			chaos := clusters.Group("/:cluster/chaos")
+			{
+				chaos.GET("", middleware.RequiredCluster, handler.Chaos.Get)
+				chaos.PUT("", middleware.RequiredCluster, handler.Chaos.Set)
+				chaos.DELETE("", middleware.RequiredCluster, handler.Chaos.Clear)
+			}
 		}
 
 		shards := clusters.Group("/:cluster/shards")
 		{
 			shards.GET("", middleware.RequiredCluster, handler.Shard.List)
-			shards.POST("", middleware.RequiredCluster, handler.Shard.Create)
+			shards.POST("", middleware.RequiredCluster, middleware.CheckIfMatch, handler.Shard.Create)
 			shards.GET("/:shard", middleware.RequiredClusterShard, handler.Shard.Get)
-			shards.DELETE("/:shard", middleware.RequiredCluster, handler.Shard.Remove)
-			shards.POST("/:shard/failover", middleware.RequiredClusterShard, handler.Shard.Failover)
+			shards.DELETE("/:shard", middleware.RequiredCluster, middleware.CheckIfMatch, handler.Shard.Remove)
+			shards.POST("/:shard/failover", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Shard.Failover)
+			shards.POST("/:shard/split", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Shard.Split)
+			shards.POST("/:shard/merge", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Shard.Merge)
+			shards.POST("/:shard/freeze", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Shard.Freeze)
+			shards.POST("/:shard/move", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Shard.Move)
 		}
 
 		nodes := shards.Group("/:shard/nodes")
 		{
 			nodes.GET("", middleware.RequiredClusterShard, handler.Node.List)
-			nodes.POST("", middleware.RequiredClusterShard, handler.Node.Create)
-			nodes.DELETE("/:id", middleware.RequiredClusterShard, handler.Node.Remove)
+			nodes.POST("", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Node.Create)
+			nodes.DELETE("/:id", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Node.Remove)
+			nodes.POST("/:id/replace", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Node.Replace)
+			nodes.POST("/:id/restoring", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Node.SetRestoring)
+			nodes.POST("/:id/replica-of", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Node.SetReplicaOf)
+			nodes.POST("/:id/labels", middleware.RequiredClusterShard, middleware.CheckIfMatch, handler.Node.UpdateLabels)
 		}
 	}
 }
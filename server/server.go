@@ -37,7 +37,9 @@ import (
 	"github.com/apache/kvrocks-controller/config"
 	"github.com/apache/kvrocks-controller/controller"
 	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/server/middleware"
 	"github.com/apache/kvrocks-controller/store"
 	"github.com/apache/kvrocks-controller/store/engine"
 	"github.com/apache/kvrocks-controller/store/engine/etcd"
@@ -45,11 +47,17 @@ import (
 )
 
 type Server struct {
-	engine     *gin.Engine
-	store      *store.ClusterStore
-	controller *controller.Controller
-	config     *config.Config
-	httpServer *http.Server
+	engine        *gin.Engine
+	adminEngine   *gin.Engine
+	metricsEngine *gin.Engine
+	store         *store.ClusterStore
+	controller    *controller.Controller
+	config        *config.Config
+	httpServers   []*http.Server
+
+	sessionCancel context.CancelFunc
+	sessionDone   chan struct{}
+	heartbeatDone chan struct{}
 }
 
 func NewServer(cfg *config.Config) (*Server, error) {
@@ -58,21 +66,28 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	sessionID := helper.GenerateSessionID(cfg.Addr)
 	storageType := strings.ToLower(cfg.StorageType)
+	if cfg.Standby {
+		logger.Get().Info("Run the controller in the standby mode")
+	}
 	switch storageType {
 	case "etcd":
 		logger.Get().Info("Use Etcd as store")
+		cfg.Etcd.Standby = cfg.Standby
 		persist, err = etcd.New(sessionID, cfg.Etcd)
 	case "zookeeper":
 		logger.Get().Info("Use Zookeeper as store")
+		cfg.Zookeeper.Standby = cfg.Standby
 		persist, err = zookeeper.New(sessionID, cfg.Zookeeper)
 	case "raft":
 		logger.Get().Info("Use Raft as store")
 		persist, err = raft.New(cfg.Raft)
 	case "consul":
 		logger.Get().Info("Use Consul as store")
+		cfg.Consul.Standby = cfg.Standby
 		persist, err = consul.New(sessionID, cfg.Consul)
 	default:
 		logger.Get().Info("Use Etcd as default store")
+		cfg.Etcd.Standby = cfg.Standby
 		persist, err = etcd.New(sessionID, cfg.Etcd)
 	}
 
@@ -83,35 +98,129 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("no found any store config")
 	}
 
+	// the label names of the metrics must be fixed before the store and controller emit them
+	if len(cfg.StaticLabels) > 0 {
+		metrics.SetStaticLabelKeys(cfg.StaticLabels)
+	}
+	if err := store.SetClusterCodec(cfg.ClusterCodec); err != nil {
+		return nil, err
+	}
 	clusterStore := store.NewClusterStore(persist)
+	if cfg.Controller != nil {
+		store.SetSlowCommandThreshold(time.Duration(cfg.Controller.SlowCommandThresholdMs) * time.Millisecond)
+		if retry := cfg.Controller.NodeRetry; retry != nil {
+			policy := store.DefaultNodeRetryPolicy()
+			if retry.MaxAttempts > 0 {
+				policy.Attempts = retry.MaxAttempts
+			}
+			if retry.BackoffMs > 0 {
+				policy.Backoff = time.Duration(retry.BackoffMs) * time.Millisecond
+			}
+			if retry.BreakerFailures > 0 {
+				policy.BreakerFailures = retry.BreakerFailures
+			}
+			if retry.BreakerCooldownSeconds > 0 {
+				policy.BreakerCooldown = time.Duration(retry.BreakerCooldownSeconds) * time.Second
+			}
+			store.SetNodeRetryPolicy(policy)
+		}
+		if failover := cfg.Controller.FailOver; failover != nil && failover.Hook != nil {
+			hook := &store.FailoverHook{
+				Command: failover.Hook.Command,
+				URL:     failover.Hook.URL,
+				Timeout: store.DefaultFailoverHookTimeout,
+				Retries: failover.Hook.Retries,
+				Backoff: store.DefaultFailoverHookBackoff,
+			}
+			if failover.Hook.TimeoutMs > 0 {
+				hook.Timeout = time.Duration(failover.Hook.TimeoutMs) * time.Millisecond
+			}
+			if failover.Hook.RetryBackoffMs > 0 {
+				hook.Backoff = time.Duration(failover.Hook.RetryBackoffMs) * time.Millisecond
+			}
+			store.SetFailoverHook(hook)
+		}
+	}
 	ctrl, err := controller.New(clusterStore, cfg.Controller)
 	if err != nil {
 		return nil, err
 	}
 	gin.SetMode(gin.ReleaseMode)
-	return &Server{
+	srv := &Server{
 		store:      clusterStore,
 		controller: ctrl,
 		config:     cfg,
 		engine:     gin.New(),
-	}, nil
+	}
+	// The admin and metrics routes will be registered into the API engine
+	// if they don't have their own listening address.
+	srv.adminEngine = srv.engine
+	if cfg.Admin.Enabled() {
+		srv.adminEngine = gin.New()
+	}
+	srv.metricsEngine = srv.engine
+	if cfg.Metrics.Enabled() {
+		srv.metricsEngine = gin.New()
+	}
+	return srv, nil
 }
 
-func (srv *Server) startAPIServer() {
-	srv.initHandlers()
+func useBasicAuth(engine *gin.Engine, auth *config.BasicAuthConfig) {
+	if auth.Username == "" {
+		return
+	}
+	engine.Use(gin.BasicAuth(gin.Accounts{auth.Username: auth.Password}))
+}
+
+func (srv *Server) startHTTPServer(name string, listener *config.ListenerConfig, handler http.Handler) {
 	httpServer := &http.Server{
-		Addr:    srv.config.Addr,
-		Handler: srv.engine,
+		Addr:    listener.Addr,
+		Handler: handler,
 	}
 	go func() {
-		if err := httpServer.ListenAndServe(); err != nil {
+		var err error
+		if listener.TLS.Enable {
+			err = httpServer.ListenAndServeTLS(listener.TLS.CertFile, listener.TLS.KeyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil {
 			if errors.Is(err, http.ErrServerClosed) {
 				return
 			}
-			panic(fmt.Errorf("API server: %w", err))
+			panic(fmt.Errorf("%s server: %w", name, err))
 		}
 	}()
-	srv.httpServer = httpServer
+	srv.httpServers = append(srv.httpServers, httpServer)
+}
+
+func (srv *Server) startAPIServer() {
+	apiListener := &config.ListenerConfig{
+		Addr:      srv.config.Addr,
+		TLS:       srv.config.TLS,
+		BasicAuth: srv.config.BasicAuth,
+	}
+	// Middlewares must be installed before registering the routes
+	if len(srv.config.Tokens) > 0 {
+		srv.engine.Use(middleware.Authenticate(srv.config.Tokens, &apiListener.BasicAuth))
+	} else {
+		useBasicAuth(srv.engine, &apiListener.BasicAuth)
+	}
+	if srv.config.Admin.Enabled() {
+		useBasicAuth(srv.adminEngine, &srv.config.Admin.BasicAuth)
+	}
+	if srv.config.Metrics.Enabled() {
+		useBasicAuth(srv.metricsEngine, &srv.config.Metrics.BasicAuth)
+	}
+	srv.initHandlers()
+
+	srv.startHTTPServer("API", apiListener, srv.engine)
+	if srv.config.Admin.Enabled() {
+		srv.startHTTPServer("admin", &srv.config.Admin, srv.adminEngine)
+	}
+	if srv.config.Metrics.Enabled() {
+		srv.startHTTPServer("metrics", &srv.config.Metrics, srv.metricsEngine)
+	}
 }
 
 func PProf(c *gin.Context) {
@@ -138,12 +247,30 @@ func (srv *Server) Start(ctx context.Context) error {
 	}
 	srv.controller.WaitForReady()
 	srv.startAPIServer()
+
+	sessionCtx, cancel := context.WithCancel(context.Background())
+	srv.sessionCancel = cancel
+	srv.sessionDone = make(chan struct{})
+	srv.heartbeatDone = make(chan struct{})
+	go srv.sessionLoop(sessionCtx)
+	go srv.leaderHeartbeatLoop(sessionCtx)
 	return nil
 }
 
 func (srv *Server) Stop() error {
+	if srv.sessionCancel != nil {
+		srv.sessionCancel()
+		<-srv.sessionDone
+		<-srv.heartbeatDone
+	}
 	srv.controller.Close()
 	gracefulCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	return srv.httpServer.Shutdown(gracefulCtx)
+	var errs []error
+	for _, httpServer := range srv.httpServers {
+		if err := httpServer.Shutdown(gracefulCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
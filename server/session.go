@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/server/helper"
+	"github.com/apache/kvrocks-controller/store"
+)
+
+// sessionLoop registers the session of the controller and refreshes it periodically,
+// the leader also purges the dead sessions.
+func (srv *Server) sessionLoop(ctx context.Context) {
+	defer close(srv.sessionDone)
+	hostname, _ := os.Hostname()
+	session := &store.ControllerSession{
+		Addr:      srv.config.Addr,
+		Hostname:  hostname,
+		Pid:       os.Getpid(),
+		Zone:      srv.config.Zone,
+		Standby:   srv.config.Standby,
+		StartedAt: helper.StartedAt.Unix(),
+	}
+	log := logger.Get().With(zap.String("session", srv.store.ID()))
+
+	ticker := time.NewTicker(store.SessionHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		if err := srv.store.SaveSession(ctx, session); err != nil {
+			log.Warn("Failed to refresh the controller session", zap.Error(err))
+		}
+		if srv.store.IsLeader() {
+			if purged, err := srv.store.PurgeSessions(ctx); err != nil {
+				log.Warn("Failed to purge the dead controller sessions", zap.Error(err))
+			} else if purged > 0 {
+				log.Info("Purge the dead controller sessions", zap.Int("count", purged))
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			removeCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			if err := srv.store.RemoveSession(removeCtx); err != nil {
+				log.Warn("Failed to remove the controller session", zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+// leaderHeartbeatLoop writes the leader heartbeat periodically if this controller is the leader,
+// the followers measure the staleness of their local engine by it.
+func (srv *Server) leaderHeartbeatLoop(ctx context.Context) {
+	defer close(srv.heartbeatDone)
+	ticker := time.NewTicker(store.LeaderHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !srv.store.IsLeader() {
+				continue
+			}
+			if err := srv.store.WriteLeaderHeartbeat(ctx); err != nil {
+				logger.Get().With(zap.Error(err)).Warn("Failed to write the leader heartbeat")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -37,6 +37,22 @@ type Cluster struct {
 	Name    string       `json:"name"`
 	Version atomic.Int64 `json:"-"`
 	Shards  []*Shard     `json:"shards"`
+
+	// Description and Annotations are free-form metadata, e.g. the ownership
+	// and the environment, they are NOT used by the controller.
+	Description string            `json:"description,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Labels are the static labels which are added to the metrics and events of the cluster,
+	// they override the labels of the namespace with the same key.
+	Labels map[string]string `json:"labels,omitempty"`
+	// HealthCheck is the custom health check which augments the probe verdict of the nodes
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+	// Compaction is the schedule to compact the nodes in the maintenance windows
+	Compaction *CompactionSchedule `json:"compaction,omitempty"`
+	// Placement are the rules of the slot distribution across the zones of the masters
+	Placement *PlacementRules `json:"placement,omitempty"`
+	// Protected clusters can't be removed, migrated slot only or forced without the confirmation
+	Protected bool `json:"protected,omitempty"`
 }
 
 func NewCluster(name string, nodes []string, replicas int) (*Cluster, error) {
@@ -77,6 +93,23 @@ func NewCluster(name string, nodes []string, replicas int) (*Cluster, error) {
 	return cluster, nil
 }
 
+// DistributeSlotsByWeights reassigns the slots of the shards in proportion to the weights,
+// it should only be used before the cluster is serving.
+func (cluster *Cluster) DistributeSlotsByWeights(weights []int) error {
+	if len(weights) != len(cluster.Shards) {
+		return fmt.Errorf("the number of weights(%d) should be equal to the number of shards(%d)",
+			len(weights), len(cluster.Shards))
+	}
+	slotRanges, err := CalculateWeightedSlotRanges(weights)
+	if err != nil {
+		return err
+	}
+	for i, shard := range cluster.Shards {
+		shard.SlotRanges = []SlotRange{slotRanges[i]}
+	}
+	return nil
+}
+
 func (cluster *Cluster) Clone() *Cluster {
 	clone := &Cluster{
 		Name:   cluster.Name,
@@ -86,9 +119,60 @@ func (cluster *Cluster) Clone() *Cluster {
 	for _, shard := range cluster.Shards {
 		clone.Shards = append(clone.Shards, shard.Clone())
 	}
+	clone.Description = cluster.Description
+	clone.HealthCheck = cluster.HealthCheck
+	clone.Compaction = cluster.Compaction
+	clone.Placement = cluster.Placement
+	clone.Protected = cluster.Protected
+	// the labels are copied on write, so it's safe to share them
+	clone.Labels = cluster.Labels
+	if len(cluster.Annotations) > 0 {
+		clone.Annotations = make(map[string]string, len(cluster.Annotations))
+		for key, value := range cluster.Annotations {
+			clone.Annotations[key] = value
+		}
+	}
 	return clone
 }
 
+// WithUpdate returns a copy of the cluster which was changed by the update function,
+// the cluster itself won't be changed, so it's safe to be read by other goroutines.
+func (cluster *Cluster) WithUpdate(update func(cluster *Cluster) error) (*Cluster, error) {
+	clone := cluster.Clone()
+	if err := update(clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// WithUpdatedShard is the same as WithUpdate but only the shard will be changed
+func (cluster *Cluster) WithUpdatedShard(shardIndex int, update func(shard *Shard) error) (*Cluster, error) {
+	if _, err := cluster.GetShard(shardIndex); err != nil {
+		return nil, err
+	}
+	return cluster.WithUpdate(func(clone *Cluster) error {
+		return update(clone.Shards[shardIndex])
+	})
+}
+
+// UpdateAnnotations merges the annotations into the cluster,
+// the annotation will be removed if its value is empty.
+func (cluster *Cluster) UpdateAnnotations(annotations map[string]string) {
+	for key, value := range annotations {
+		if value == "" {
+			delete(cluster.Annotations, key)
+			continue
+		}
+		if cluster.Annotations == nil {
+			cluster.Annotations = make(map[string]string)
+		}
+		cluster.Annotations[key] = value
+	}
+	if len(cluster.Annotations) == 0 {
+		cluster.Annotations = nil
+	}
+}
+
 // SetPassword will set the password for all nodes in the cluster.
 func (cluster *Cluster) SetPassword(password string) {
 	for i := 0; i < len(cluster.Shards); i++ {
@@ -98,6 +182,120 @@ func (cluster *Cluster) SetPassword(password string) {
 	}
 }
 
+// SetMasterAuth will set the masterauth for all nodes in the cluster, it should be called
+// after SetPassword and the masterauth is the same as the password if it's empty.
+func (cluster *Cluster) SetMasterAuth(masterAuth string) {
+	for i := 0; i < len(cluster.Shards); i++ {
+		for j := 0; j < len(cluster.Shards[i].Nodes); j++ {
+			cluster.Shards[i].Nodes[j].SetMasterAuth(masterAuth)
+		}
+	}
+}
+
+// PasswordRotationError is returned when the password rotation failed, it carries the state
+// of each node after the rollback so the operator knows which nodes need to be fixed by hand.
+type PasswordRotationError struct {
+	Err error
+	// Nodes are keyed by the node address, the state is unchanged, rolled_back or rollback_failed with the error
+	Nodes map[string]string
+}
+
+func (err *PasswordRotationError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *PasswordRotationError) Unwrap() error {
+	return err.Err
+}
+
+func (err *PasswordRotationError) Details() interface{} {
+	return map[string]interface{}{"nodes": err.Nodes}
+}
+
+// PasswordRotation is the rotation whose new password was set to all nodes, the controller still
+// keeps the clients of the old password until Finish so that it can roll back the nodes.
+type PasswordRotation struct {
+	nodes          []Node
+	oldPasswords   []string
+	oldMasterAuths []string
+	// steps is the number of the steps applied to each node: 1 for the masterauth and 2 for the password
+	steps []int
+}
+
+// RotatePassword changes the password and masterauth of all nodes in two steps, the masterauth
+// is set to all nodes first and then the password, replicas go first and masters last, so that
+// the replication links are broken as short as possible. The masterauth is the same as the password
+// if it's empty. The changed nodes will be rolled back if any node fails, and the returned rotation
+// should be rolled back if the new password couldn't be persisted or finished after that.
+func (cluster *Cluster) RotatePassword(ctx context.Context, password, masterAuth string) (*PasswordRotation, error) {
+	if masterAuth == "" {
+		masterAuth = password
+	}
+	rotation := &PasswordRotation{}
+	for _, isMaster := range []bool{false, true} {
+		for _, shard := range cluster.Shards {
+			for _, node := range shard.Nodes {
+				if node.IsMaster() == isMaster {
+					rotation.nodes = append(rotation.nodes, node)
+					rotation.oldPasswords = append(rotation.oldPasswords, node.Password())
+					rotation.oldMasterAuths = append(rotation.oldMasterAuths, node.MasterAuth())
+				}
+			}
+		}
+	}
+	rotation.steps = make([]int, len(rotation.nodes))
+
+	for i, node := range rotation.nodes {
+		// the failed node may have been partially changed, so it's counted to roll back as well
+		rotation.steps[i] = 1
+		if err := node.ChangeMasterAuth(ctx, masterAuth); err != nil {
+			return nil, rotation.Rollback(ctx, fmt.Errorf("set the masterauth of node %s: %w", node.Addr(), err))
+		}
+	}
+	for i, node := range rotation.nodes {
+		rotation.steps[i] = 2
+		if err := node.ChangePassword(ctx, password); err != nil {
+			return nil, rotation.Rollback(ctx, fmt.Errorf("set the password of node %s: %w", node.Addr(), err))
+		}
+	}
+	return rotation, nil
+}
+
+// Rollback restores the old password and masterauth of the changed nodes in the reverse order,
+// the returned error wraps the cause and reports the state of each node.
+func (rotation *PasswordRotation) Rollback(ctx context.Context, cause error) error {
+	states := make(map[string]string, len(rotation.nodes))
+	for i := len(rotation.nodes) - 1; i >= 0; i-- {
+		node := rotation.nodes[i]
+		var err error
+		if rotation.steps[i] >= 2 {
+			err = node.ChangePassword(ctx, rotation.oldPasswords[i])
+		}
+		if err == nil && rotation.steps[i] >= 1 {
+			err = node.ChangeMasterAuth(ctx, rotation.oldMasterAuths[i])
+		}
+		switch {
+		case rotation.steps[i] == 0:
+			states[node.Addr()] = "unchanged"
+		case err != nil:
+			states[node.Addr()] = "rollback_failed: " + err.Error()
+		default:
+			states[node.Addr()] = "rolled_back"
+			rotation.steps[i] = 0
+		}
+	}
+	return &PasswordRotationError{Err: cause, Nodes: states}
+}
+
+// Finish drops the clients of the old password after the new password was persisted.
+func (rotation *PasswordRotation) Finish() {
+	for i, node := range rotation.nodes {
+		if rotation.oldPasswords[i] != node.Password() {
+			node.ReleasePassword(rotation.oldPasswords[i])
+		}
+	}
+}
+
 func (cluster *Cluster) ToSlotString() (string, error) {
 	var builder strings.Builder
 	for i, shard := range cluster.Shards {
@@ -121,6 +319,9 @@ func (cluster *Cluster) AddNode(shardIndex int, addr, role, password string) (*C
 	if shardIndex < 0 || shardIndex >= len(cluster.Shards) {
 		return nil, consts.ErrIndexOutOfRange
 	}
+	if err := cluster.CheckShardFrozen(shardIndex); err != nil {
+		return nil, err
+	}
 	return cluster.Shards[shardIndex].addNode(addr, role, password)
 }
 
@@ -128,9 +329,124 @@ func (cluster *Cluster) RemoveNode(shardIndex int, nodeID string) error {
 	if shardIndex < 0 || shardIndex >= len(cluster.Shards) {
 		return consts.ErrIndexOutOfRange
 	}
+	if err := cluster.CheckShardFrozen(shardIndex); err != nil {
+		return err
+	}
 	return cluster.Shards[shardIndex].removeNode(nodeID)
 }
 
+// SetShardFrozen marks whether the topology of the shard is frozen
+func (cluster *Cluster) SetShardFrozen(shardIndex int, frozen bool) (*Shard, error) {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil {
+		return nil, err
+	}
+	shard.Frozen = frozen
+	return shard, nil
+}
+
+// SetNodeRestoring marks whether the node is restoring from the backup
+func (cluster *Cluster) SetNodeRestoring(shardIndex int, nodeID string, restoring bool) (Node, error) {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range shard.Nodes {
+		if node.ID() == nodeID {
+			node.SetRestoring(restoring)
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("node %s: %w", nodeID, consts.ErrNotFound)
+}
+
+// SetNodeSyncing marks whether the replica is catching up with the master
+func (cluster *Cluster) SetNodeSyncing(shardIndex int, nodeID string, syncing bool) (Node, error) {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range shard.Nodes {
+		if node.ID() == nodeID {
+			node.SetSyncing(syncing)
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("node %s: %w", nodeID, consts.ErrNotFound)
+}
+
+// UpdateNodeLabels merges the labels into the node, the label will be removed if its value is empty
+func (cluster *Cluster) UpdateNodeLabels(shardIndex int, nodeID string, labels map[string]string) (Node, error) {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := shard.getNode(nodeID).(*ClusterNode)
+	if !ok {
+		return nil, fmt.Errorf("node %s: %w", nodeID, consts.ErrNotFound)
+	}
+	node.UpdateLabels(labels)
+	return node, nil
+}
+
+// SetNodeReplicaOf makes the replica replicate from the upstream replica in the same shard
+func (cluster *Cluster) SetNodeReplicaOf(shardIndex int, nodeID, upstreamID string) (Node, error) {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.CheckShardFrozen(shardIndex); err != nil {
+		return nil, err
+	}
+	if err := shard.SetReplicaOf(nodeID, upstreamID); err != nil {
+		return nil, err
+	}
+	return shard.getNode(nodeID), nil
+}
+
+// ReplaceNodeAddr changes the address of the node while keeping its ID and role,
+// it's used when the node was restored on a new host from the backup.
+func (cluster *Cluster) ReplaceNodeAddr(ctx context.Context, shardIndex int, nodeID, newAddr string) (*ClusterNode, error) {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.CheckShardFrozen(shardIndex); err != nil {
+		return nil, err
+	}
+	var target *ClusterNode
+	for _, node := range shard.Nodes {
+		if node.ID() != nodeID {
+			continue
+		}
+		clusterNode, ok := node.(*ClusterNode)
+		if !ok {
+			return nil, fmt.Errorf("%w: node %s doesn't support replacing the address", consts.ErrInvalidArgument, nodeID)
+		}
+		target = clusterNode
+	}
+	if target == nil {
+		return nil, fmt.Errorf("node %s: %w", nodeID, consts.ErrNotFound)
+	}
+
+	resolvedAddrs, err := ResolveNodeAddrs(ctx, []string{newAddr})
+	if err != nil {
+		return nil, err
+	}
+	if len(resolvedAddrs) != 1 {
+		return nil, fmt.Errorf("%w: %s should be resolved to exactly one node, but got %d",
+			consts.ErrInvalidArgument, newAddr, len(resolvedAddrs))
+	}
+	for _, node := range cluster.GetNodes() {
+		if node.ID() != nodeID && node.Addr() == resolvedAddrs[0].Addr {
+			return nil, fmt.Errorf("node %s: %w", resolvedAddrs[0].Addr, consts.ErrAlreadyExists)
+		}
+	}
+	target.SetAddr(resolvedAddrs[0].Addr)
+	target.SetHostname(resolvedAddrs[0].Hostname)
+	return target, nil
+}
+
 func (cluster *Cluster) PromoteNewMaster(ctx context.Context,
 	shardIdx int, masterNodeID, preferredNodeID string,
 ) (string, error) {
@@ -138,6 +454,9 @@ func (cluster *Cluster) PromoteNewMaster(ctx context.Context,
 	if err != nil {
 		return "", err
 	}
+	if err := cluster.CheckShardFrozen(shardIdx); err != nil {
+		return "", err
+	}
 	newMasterNodeID, err := shard.promoteNewMaster(ctx, masterNodeID, preferredNodeID)
 	if err != nil {
 		return "", err
@@ -168,7 +487,7 @@ func (cluster *Cluster) GetNodes() []Node {
 func (cluster *Cluster) Reset(ctx context.Context) error {
 	for i := 0; i < len(cluster.Shards); i++ {
 		for _, node := range cluster.Shards[i].Nodes {
-			if err := node.Reset(ctx); err != nil {
+			if err := node.Reset(ctx, true); err != nil {
 				return err
 			}
 		}
@@ -176,6 +495,28 @@ func (cluster *Cluster) Reset(ctx context.Context) error {
 	return nil
 }
 
+type NodeResetResult struct {
+	ID    string `json:"id"`
+	Addr  string `json:"addr"`
+	Error string `json:"error,omitempty"`
+}
+
+// ResetNodes resets the topology of all nodes and continues on failures,
+// the failed nodes can be found by the error in the results.
+func (cluster *Cluster) ResetNodes(ctx context.Context, flush bool) ([]NodeResetResult, bool) {
+	results := make([]NodeResetResult, 0)
+	allSucceeded := true
+	for _, node := range cluster.GetNodes() {
+		result := NodeResetResult{ID: node.ID(), Addr: node.Addr()}
+		if err := node.Reset(ctx, flush); err != nil {
+			result.Error = err.Error()
+			allSucceeded = false
+		}
+		results = append(results, result)
+	}
+	return results, allSucceeded
+}
+
 func (cluster *Cluster) findShardIndexBySlot(slot SlotRange) (int, error) {
 	sourceShardIdx := -1
 	for i := 0; i < len(cluster.Shards); i++ {
@@ -195,6 +536,73 @@ func (cluster *Cluster) findShardIndexBySlot(slot SlotRange) (int, error) {
 	return sourceShardIdx, nil
 }
 
+// MigrationConflictError is returned when the operation conflicts with the active data migration
+type MigrationConflictError struct {
+	ShardIndex       int            `json:"shard_index"`
+	TargetShardIndex int            `json:"target_shard_index"`
+	MigratingSlot    *MigratingSlot `json:"migrating_slot"`
+}
+
+func (err *MigrationConflictError) Error() string {
+	return fmt.Sprintf("%s: slot %s is migrating from shard %d to shard %d",
+		consts.ErrShardSlotIsMigrating.Error(), err.MigratingSlot.String(), err.ShardIndex, err.TargetShardIndex)
+}
+
+func (err *MigrationConflictError) Unwrap() []error {
+	return []error{consts.ErrConflict, consts.ErrShardSlotIsMigrating}
+}
+
+func (err *MigrationConflictError) Details() interface{} {
+	return map[string]interface{}{"migration": err}
+}
+
+func (cluster *Cluster) newMigrationConflictError(shardIdx int) error {
+	shard := cluster.Shards[shardIdx]
+	migratingSlot := shard.MigratingSlot
+	if migratingSlot == nil && len(shard.PendingSlots) > 0 {
+		migratingSlot = FromSlotRange(shard.PendingSlots[0])
+	}
+	return &MigrationConflictError{
+		ShardIndex:       shardIdx,
+		TargetShardIndex: shard.TargetShardIndex,
+		MigratingSlot:    migratingSlot,
+	}
+}
+
+// CheckSlotMigrationConflict returns the MigrationConflictError if the slot
+// is overlapped with the slot which is migrating in any shard.
+func (cluster *Cluster) CheckSlotMigrationConflict(slot SlotRange) error {
+	for i, shard := range cluster.Shards {
+		if shard.IsMigrating() && shard.MigratingSlot.HasOverlap(slot) {
+			return cluster.newMigrationConflictError(i)
+		}
+	}
+	return nil
+}
+
+// CheckShardRemovable returns the MigrationConflictError if the shard is involved
+// in the active migration, or removing it would shift the index of the target shard.
+func (cluster *Cluster) CheckShardRemovable(shardIdx int) error {
+	for i, shard := range cluster.Shards {
+		if !shard.IsMigrating() && !shard.HasPendingSlots() {
+			continue
+		}
+		if i == shardIdx || shard.TargetShardIndex >= shardIdx {
+			return cluster.newMigrationConflictError(i)
+		}
+	}
+	return nil
+}
+
+// CheckShardFrozen returns the forbidden error if the shard was frozen,
+// the topology of the frozen shard can't be changed until it's unfrozen.
+func (cluster *Cluster) CheckShardFrozen(shardIdx int) error {
+	if cluster.Shards[shardIdx].Frozen {
+		return fmt.Errorf("%w: %w, shard index: %d", consts.ErrForbidden, consts.ErrShardIsFrozen, shardIdx)
+	}
+	return nil
+}
+
 func (cluster *Cluster) MigrateSlot(ctx context.Context, slot SlotRange, targetShardIdx int, slotOnly bool) error {
 	if targetShardIdx < 0 || targetShardIdx >= len(cluster.Shards) {
 		return consts.ErrIndexOutOfRange
@@ -203,14 +611,52 @@ func (cluster *Cluster) MigrateSlot(ctx context.Context, slot SlotRange, targetS
 	if err != nil {
 		return err
 	}
+	return cluster.migrateSlot(ctx, slot, sourceShardIdx, targetShardIdx, slotOnly)
+}
+
+// MigrateSlotFromShard migrates the slot from the explicit source shard which should own the slot,
+// the ownership check is skipped if forceSource is true, it's used when the slot ownership
+// is inconsistent between the nodes and the metadata.
+func (cluster *Cluster) MigrateSlotFromShard(ctx context.Context, slot SlotRange, sourceShardIdx, targetShardIdx int,
+	slotOnly, forceSource bool,
+) error {
+	if sourceShardIdx < 0 || sourceShardIdx >= len(cluster.Shards) ||
+		targetShardIdx < 0 || targetShardIdx >= len(cluster.Shards) {
+		return consts.ErrIndexOutOfRange
+	}
+	if !forceSource {
+		ownerShardIdx, err := cluster.findShardIndexBySlot(slot)
+		if err != nil {
+			return err
+		}
+		if ownerShardIdx != sourceShardIdx {
+			return fmt.Errorf("%w: slot %s is owned by shard %d instead of the source shard %d",
+				consts.ErrInvalidArgument, slot.String(), ownerShardIdx, sourceShardIdx)
+		}
+	}
+	return cluster.migrateSlot(ctx, slot, sourceShardIdx, targetShardIdx, slotOnly)
+}
+
+func (cluster *Cluster) migrateSlot(ctx context.Context, slot SlotRange, sourceShardIdx, targetShardIdx int, slotOnly bool) error {
 	if sourceShardIdx == targetShardIdx {
 		return consts.ErrShardIsSame
 	}
+	for _, shardIdx := range []int{sourceShardIdx, targetShardIdx} {
+		if err := cluster.CheckShardFrozen(shardIdx); err != nil {
+			return err
+		}
+	}
+	// the slots of the splitting or merging shard are migrated in the order of the pending slots
+	if cluster.Shards[sourceShardIdx].HasPendingSlots() {
+		return cluster.newMigrationConflictError(sourceShardIdx)
+	}
 	if slotOnly {
+		if err := cluster.CheckSlotMigrationConflict(slot); err != nil {
+			return err
+		}
 		// clear source migrating info to avoid mismatch migrating slot error
 		cluster.Shards[sourceShardIdx].ClearMigrateState()
-		cluster.Shards[sourceShardIdx].SlotRanges = RemoveSlotFromSlotRanges(cluster.Shards[sourceShardIdx].SlotRanges, slot)
-		cluster.Shards[targetShardIdx].SlotRanges = AddSlotToSlotRanges(cluster.Shards[targetShardIdx].SlotRanges, slot)
+		cluster.MoveSlotToShard(slot, targetShardIdx)
 		return nil
 	}
 
@@ -230,9 +676,22 @@ func (cluster *Cluster) MigrateSlot(ctx context.Context, slot SlotRange, targetS
 	// Will start the data migration in the background
 	cluster.Shards[sourceShardIdx].MigratingSlot = FromSlotRange(slot)
 	cluster.Shards[sourceShardIdx].TargetShardIndex = targetShardIdx
+	cluster.Shards[sourceShardIdx].MigrationID = newMigrationID()
 	return nil
 }
 
+// MoveSlotToShard assigns the slot to the target shard and removes it from the other shards,
+// the slot might be owned by a shard other than the source if the source was forced.
+func (cluster *Cluster) MoveSlotToShard(slot SlotRange, targetShardIdx int) {
+	for i, shard := range cluster.Shards {
+		if i == targetShardIdx {
+			shard.SlotRanges = AddSlotToSlotRanges(shard.SlotRanges, slot)
+		} else {
+			shard.SlotRanges = RemoveSlotFromSlotRanges(shard.SlotRanges, slot)
+		}
+	}
+}
+
 func (cluster *Cluster) SetSlot(ctx context.Context, slot int, targetNodeID string) error {
 	version := cluster.Version.Add(1)
 	for i := 0; i < len(cluster.Shards); i++ {
@@ -308,8 +767,23 @@ func ParseCluster(clusterStr string) (*Cluster, error) {
 	}
 	sort.Sort(shards)
 	for i := 0; i < len(shards); i++ {
-		masterNode := shards[i].Nodes[0]
-		shards[i].Nodes = append(shards[i].Nodes, slaveNodes[masterNode.ID()]...)
+		masterID := shards[i].Nodes[0].ID()
+		// the cascaded replicas are in the shard of their upstream replicas
+		upstreamIDs := []string{masterID}
+		for len(upstreamIDs) > 0 {
+			upstreamID := upstreamIDs[0]
+			upstreamIDs = upstreamIDs[1:]
+			for _, slaveNode := range slaveNodes[upstreamID] {
+				shards[i].Nodes = append(shards[i].Nodes, slaveNode)
+				if upstreamID != masterID {
+					if shards[i].ReplicaOf == nil {
+						shards[i].ReplicaOf = make(map[string]string)
+					}
+					shards[i].ReplicaOf[slaveNode.ID()] = upstreamID
+				}
+				upstreamIDs = append(upstreamIDs, slaveNode.ID())
+			}
+		}
 	}
 
 	clusterInfo := &Cluster{
@@ -325,18 +799,26 @@ func (cluster *Cluster) MarshalJSON() ([]byte, error) {
 	type Alias Cluster // to avoid recursion
 
 	return json.Marshal(&struct {
-		Version int64 `json:"version"`
+		SchemaVersion int   `json:"schema_version"`
+		Version       int64 `json:"version"`
 		*Alias
 	}{
-		Version: cluster.Version.Load(),
-		Alias:   (*Alias)(cluster),
+		SchemaVersion: ClusterSchemaVersion,
+		Version:       cluster.Version.Load(),
+		Alias:         (*Alias)(cluster),
 	})
 }
 
-// UnmarshalJSON is a custom function since the atomic.Int64 type does not directly implement JSON unmarshaling.
+// UnmarshalJSON is a custom function since the atomic.Int64 type does not directly implement JSON unmarshaling,
+// the document written by the older controllers is upgraded to the current schema version first.
 func (cluster *Cluster) UnmarshalJSON(data []byte) error {
 	type Alias Cluster
 
+	data, err := upgradeClusterDocument(data)
+	if err != nil {
+		return err
+	}
+
 	aux := &struct {
 		Version int64 `json:"version"`
 		*Alias
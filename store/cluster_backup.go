@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const DefaultBackupNodeTimeout = time.Hour
+
+// BackupCandidate returns the node to back up the shard, the replica is preferred
+// to avoid affecting the master, and the master is used if there's no available replica.
+func (shard *Shard) BackupCandidate() Node {
+	var master Node
+	for _, node := range shard.Nodes {
+		if node.IsRestoring() {
+			continue
+		}
+		if !node.IsMaster() {
+			return node
+		}
+		master = node
+	}
+	return master
+}
+
+// BackupNode triggers the backup on the node by BGSAVE and waits until it's done,
+// the result is checked with the `bgsave_in_progress` and `last_bgsave_status` fields.
+func BackupNode(ctx context.Context, node Node, pollInterval time.Duration) error {
+	if _, err := node.Do(ctx, "BGSAVE"); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		inProgress, err := getInfoField(ctx, node, "persistence", "bgsave_in_progress")
+		if err != nil {
+			return err
+		}
+		if inProgress != "1" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	status, err := getInfoField(ctx, node, "persistence", "last_bgsave_status")
+	if err != nil {
+		return err
+	}
+	if status != "ok" {
+		return fmt.Errorf("the last bgsave status is %q", status)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShard_BackupCandidate(t *testing.T) {
+	shard := NewShard()
+	require.Nil(t, shard.BackupCandidate())
+
+	master := NewClusterMockNode()
+	master.SetRole(RoleMaster)
+	shard.Nodes = append(shard.Nodes, master)
+	require.Equal(t, master.ID(), shard.BackupCandidate().ID())
+
+	slave := NewClusterMockNode()
+	slave.SetRole(RoleSlave)
+	shard.Nodes = append(shard.Nodes, slave)
+	require.Equal(t, slave.ID(), shard.BackupCandidate().ID())
+}
+
+func TestBackupNode(t *testing.T) {
+	ctx := context.Background()
+	node := NewClusterMockNode()
+	node.Replies = map[string]interface{}{
+		"BGSAVE": "OK",
+		"INFO":   "# Persistence\r\nbgsave_in_progress:0\r\nlast_bgsave_status:ok\r\n",
+	}
+	require.NoError(t, BackupNode(ctx, node, time.Millisecond))
+
+	node.Replies["INFO"] = "# Persistence\r\nbgsave_in_progress:0\r\nlast_bgsave_status:err\r\n"
+	require.Error(t, BackupNode(ctx, node, time.Millisecond))
+
+	node.Replies["INFO"] = "# Persistence\r\nbgsave_in_progress:1\r\nlast_bgsave_status:ok\r\n"
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, BackupNode(timeoutCtx, node, time.Millisecond), context.DeadlineExceeded)
+
+	node.Replies["BGSAVE"] = errors.New("ERR backup is in progress")
+	require.Error(t, BackupNode(ctx, node, time.Millisecond))
+}
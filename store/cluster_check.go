@@ -0,0 +1,285 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	CheckSeverityInfo    = "info"
+	CheckSeverityWarning = "warning"
+	CheckSeverityError   = "error"
+)
+
+// CheckFinding is an inconsistency found by the cluster check,
+// Shard is -1 if the finding doesn't belong to any shard.
+type CheckFinding struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Shard    int    `json:"shard"`
+	NodeID   string `json:"node_id,omitempty"`
+	Addr     string `json:"addr,omitempty"`
+	Message  string `json:"message"`
+}
+
+type CheckReport struct {
+	Cluster  string         `json:"cluster"`
+	Version  int64          `json:"version"`
+	Healthy  bool           `json:"healthy"`
+	Findings []CheckFinding `json:"findings"`
+}
+
+func (report *CheckReport) add(finding CheckFinding) {
+	report.Findings = append(report.Findings, finding)
+	if finding.Severity == CheckSeverityError {
+		report.Healthy = false
+	}
+}
+
+// nodeView is how a node is placed in the cluster topology
+type nodeView struct {
+	role       string
+	masterID   string
+	slotRanges SlotRanges
+}
+
+func slotRangesString(slotRanges SlotRanges) string {
+	fields := make([]string, 0, len(slotRanges))
+	for _, slotRange := range slotRanges {
+		fields = append(fields, slotRange.String())
+	}
+	return strings.Join(fields, ",")
+}
+
+func buildNodeViews(cluster *Cluster) map[string]nodeView {
+	views := make(map[string]nodeView)
+	for _, shard := range cluster.Shards {
+		master := shard.GetMasterNode()
+		if master == nil {
+			continue
+		}
+		slotRanges := SlotRanges(shard.SlotRanges).Normalize()
+		for _, node := range shard.Nodes {
+			if node.IsMaster() {
+				views[node.ID()] = nodeView{role: RoleMaster, slotRanges: slotRanges}
+			} else if upstreamID, ok := shard.ReplicaOf[node.ID()]; ok {
+				// the cascaded replica is attached to its upstream replica
+				views[node.ID()] = nodeView{role: RoleSlave, masterID: upstreamID}
+			} else {
+				views[node.ID()] = nodeView{role: RoleSlave, masterID: master.ID()}
+			}
+		}
+	}
+	return views
+}
+
+// Check audits the consistency between the stored topology and the view of each node,
+// including the slot coverage, replica attachment, epochs and migrating flags. It also
+// warns if the master and replicas of a shard reside on the same host, or the slot
+// distribution breaks the placement rules.
+func (cluster *Cluster) Check(ctx context.Context) *CheckReport {
+	report := &CheckReport{
+		Cluster:  cluster.Name,
+		Version:  cluster.Version.Load(),
+		Healthy:  true,
+		Findings: make([]CheckFinding, 0),
+	}
+	cluster.checkTopology(report)
+	cluster.checkSharedHosts(report)
+	cluster.checkPlacement(report)
+	expectedViews := buildNodeViews(cluster)
+	for i, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			cluster.checkNode(ctx, report, i, shard, node, expectedViews)
+		}
+	}
+	return report
+}
+
+func (cluster *Cluster) checkTopology(report *CheckReport) {
+	var served, overlapped SlotRanges
+	for i, shard := range cluster.Shards {
+		masterCount := 0
+		for _, node := range shard.Nodes {
+			if node.IsMaster() {
+				masterCount++
+			}
+		}
+		if masterCount != 1 {
+			report.add(CheckFinding{
+				Severity: CheckSeverityError,
+				Code:     "master_count",
+				Shard:    i,
+				Message:  fmt.Sprintf("shard should have exactly one master, but got %d", masterCount),
+			})
+		}
+		if len(shard.Nodes) == 1 && shard.IsServicing() {
+			report.add(CheckFinding{
+				Severity: CheckSeverityWarning,
+				Code:     "no_replica",
+				Shard:    i,
+				Message:  "servicing shard has no replica",
+			})
+		}
+		if shard.IsMigrating() {
+			if shard.TargetShardIndex < 0 || shard.TargetShardIndex >= len(cluster.Shards) {
+				report.add(CheckFinding{
+					Severity: CheckSeverityError,
+					Code:     "invalid_migration_target",
+					Shard:    i,
+					Message:  fmt.Sprintf("invalid target shard index %d", shard.TargetShardIndex),
+				})
+			} else {
+				report.add(CheckFinding{
+					Severity: CheckSeverityInfo,
+					Code:     "migrating",
+					Shard:    i,
+					Message: fmt.Sprintf("slot %s is migrating to shard %d",
+						shard.MigratingSlot.String(), shard.TargetShardIndex),
+				})
+			}
+		}
+		for _, slotRange := range shard.SlotRanges {
+			if slotRange.Start < MinSlotID || slotRange.Stop > MaxSlotID || slotRange.Start > slotRange.Stop {
+				report.add(CheckFinding{
+					Severity: CheckSeverityError,
+					Code:     "invalid_slot_range",
+					Shard:    i,
+					Message:  fmt.Sprintf("invalid slot range %s", slotRange.String()),
+				})
+				continue
+			}
+			overlapped = append(overlapped, served.Intersect(SlotRanges{slotRange})...)
+			served = append(served, slotRange)
+		}
+	}
+
+	overlapped = overlapped.Normalize()
+	uncovered := SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}}.Difference(served)
+	if len(uncovered) > 0 {
+		report.add(CheckFinding{
+			Severity: CheckSeverityError,
+			Code:     "slot_not_covered",
+			Shard:    -1,
+			Message:  fmt.Sprintf("slots %s are not served by any shard", slotRangesString(uncovered)),
+		})
+	}
+	if len(overlapped) > 0 {
+		report.add(CheckFinding{
+			Severity: CheckSeverityError,
+			Code:     "slot_overlapped",
+			Shard:    -1,
+			Message:  fmt.Sprintf("slots %s are served by multiple shards", slotRangesString(overlapped)),
+		})
+	}
+}
+
+func (cluster *Cluster) checkNode(ctx context.Context, report *CheckReport,
+	shardIndex int, shard *Shard, node Node, expectedViews map[string]nodeView,
+) {
+	newFinding := func(severity, code, message string) CheckFinding {
+		return CheckFinding{
+			Severity: severity,
+			Code:     code,
+			Shard:    shardIndex,
+			NodeID:   node.ID(),
+			Addr:     node.Addr(),
+			Message:  message,
+		}
+	}
+
+	clusterInfo, err := node.GetClusterInfo(ctx)
+	if err != nil {
+		report.add(newFinding(CheckSeverityError, "node_unreachable",
+			fmt.Sprintf("failed to get the cluster info: %v", err)))
+		return
+	}
+	if clusterInfo.CurrentEpoch != cluster.Version.Load() {
+		report.add(newFinding(CheckSeverityWarning, "epoch_mismatch",
+			fmt.Sprintf("node epoch is %d, but the cluster version is %d",
+				clusterInfo.CurrentEpoch, cluster.Version.Load())))
+	}
+	if node.IsMaster() {
+		var expectedSlot, actualSlot string
+		if shard.IsMigrating() {
+			expectedSlot = shard.MigratingSlot.String()
+		}
+		if clusterInfo.MigratingSlot != nil && clusterInfo.MigratingState == "start" {
+			actualSlot = clusterInfo.MigratingSlot.String()
+		}
+		if expectedSlot != actualSlot {
+			report.add(newFinding(CheckSeverityWarning, "migrating_mismatch",
+				fmt.Sprintf("expected migrating slot is '%s', but the node is migrating '%s'",
+					expectedSlot, actualSlot)))
+		}
+	}
+
+	clusterNodesStr, err := node.GetClusterNodesString(ctx)
+	if err != nil {
+		report.add(newFinding(CheckSeverityError, "node_unreachable",
+			fmt.Sprintf("failed to get the cluster nodes: %v", err)))
+		return
+	}
+	nodeCluster, err := ParseCluster(clusterNodesStr)
+	if err != nil {
+		report.add(newFinding(CheckSeverityError, "topology_mismatch",
+			fmt.Sprintf("failed to parse the cluster nodes: %v", err)))
+		return
+	}
+	for _, message := range diffNodeViews(expectedViews, buildNodeViews(nodeCluster)) {
+		report.add(newFinding(CheckSeverityError, "topology_mismatch", message))
+	}
+}
+
+func diffNodeViews(expected, actual map[string]nodeView) []string {
+	messages := make([]string, 0)
+	for id, expectedView := range expected {
+		actualView, ok := actual[id]
+		if !ok {
+			messages = append(messages, fmt.Sprintf("node %s is missing", id))
+			continue
+		}
+		if expectedView.role != actualView.role {
+			messages = append(messages, fmt.Sprintf("node %s should be %s, but got %s",
+				id, expectedView.role, actualView.role))
+			continue
+		}
+		if expectedView.masterID != actualView.masterID {
+			messages = append(messages, fmt.Sprintf("replica %s should be attached to %s, but got %s",
+				id, expectedView.masterID, actualView.masterID))
+		}
+		if slotRangesString(expectedView.slotRanges) != slotRangesString(actualView.slotRanges) {
+			messages = append(messages, fmt.Sprintf("master %s should serve slots '%s', but got '%s'",
+				id, slotRangesString(expectedView.slotRanges), slotRangesString(actualView.slotRanges)))
+		}
+	}
+	for id := range actual {
+		if _, ok := expected[id]; !ok {
+			messages = append(messages, fmt.Sprintf("unknown node %s", id))
+		}
+	}
+	sort.Strings(messages)
+	return messages
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func findCheckCodes(report *CheckReport) []string {
+	codes := make([]string, 0, len(report.Findings))
+	for _, finding := range report.Findings {
+		codes = append(codes, finding.Code)
+	}
+	return codes
+}
+
+func TestCluster_CheckTopology(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1", "node2", "node3"}, 2)
+	require.NoError(t, err)
+
+	report := &CheckReport{Healthy: true}
+	cluster.checkTopology(report)
+	require.True(t, report.Healthy)
+	require.Empty(t, report.Findings)
+
+	cluster.Shards[0].SlotRanges = []SlotRange{{Start: 0, Stop: 100}}
+	cluster.Shards[1].SlotRanges = append(cluster.Shards[1].SlotRanges, SlotRange{Start: 50, Stop: 60})
+	cluster.Shards[1].Nodes[1].SetRole(RoleMaster)
+	report = &CheckReport{Healthy: true}
+	cluster.checkTopology(report)
+	require.False(t, report.Healthy)
+	require.ElementsMatch(t, []string{"master_count", "slot_not_covered", "slot_overlapped"}, findCheckCodes(report))
+	for _, finding := range report.Findings {
+		if finding.Code == "slot_not_covered" {
+			require.Contains(t, finding.Message, "101-8191")
+		}
+	}
+}
+
+func TestDiffNodeViews(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1", "node2", "node3"}, 2)
+	require.NoError(t, err)
+	expected := buildNodeViews(cluster)
+	require.Empty(t, diffNodeViews(expected, buildNodeViews(cluster)))
+
+	actualCluster := cluster.Clone()
+	actualCluster.Shards[0].SlotRanges = []SlotRange{{Start: 0, Stop: 100}, {Start: 101, Stop: 8191}}
+	require.Empty(t, diffNodeViews(expected, buildNodeViews(actualCluster)))
+
+	actualCluster.Shards[1].Nodes = actualCluster.Shards[1].Nodes[:1]
+	require.Len(t, diffNodeViews(expected, buildNodeViews(actualCluster)), 1)
+}
+
+func TestDiffNodeViews_CascadedReplica(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1", "node2"}, 3)
+	require.NoError(t, err)
+	shard := cluster.Shards[0]
+	require.NoError(t, shard.SetReplicaOf(shard.Nodes[2].ID(), shard.Nodes[1].ID()))
+	expected := buildNodeViews(cluster)
+
+	// the node reports the cascaded replica is attached to the upstream replica
+	slotsString, err := cluster.ToSlotString()
+	require.NoError(t, err)
+	require.Contains(t, slotsString, "slave "+shard.Nodes[1].ID())
+	actualCluster := cluster.Clone()
+	actualCluster.Shards[0].ReplicaOf = nil
+	require.Len(t, diffNodeViews(expected, buildNodeViews(actualCluster)), 1)
+	require.Empty(t, diffNodeViews(expected, buildNodeViews(cluster.Clone())))
+}
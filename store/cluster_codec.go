@@ -0,0 +1,335 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/metrics"
+)
+
+const (
+	ClusterCodecJSON    = "json"
+	ClusterCodecMsgpack = "msgpack"
+)
+
+// clusterCodecMagic prefixes the cluster documents which aren't encoded by JSON, it's followed
+// by the codec ID. The JSON documents are stored without the prefix, so the documents written
+// by the older controllers are still readable, and they always start with '{'.
+const clusterCodecMagic = 0x00
+
+// ClusterCodec encodes the cluster documents stored in the engine
+type ClusterCodec interface {
+	Name() string
+	Marshal(cluster *Cluster) ([]byte, error)
+	Unmarshal(data []byte, cluster *Cluster) error
+}
+
+type jsonClusterCodec struct{}
+
+func (jsonClusterCodec) Name() string {
+	return ClusterCodecJSON
+}
+
+func (jsonClusterCodec) Marshal(cluster *Cluster) ([]byte, error) {
+	return json.Marshal(cluster)
+}
+
+func (jsonClusterCodec) Unmarshal(data []byte, cluster *Cluster) error {
+	return json.Unmarshal(data, cluster)
+}
+
+// clusterCodecs are the codecs with the IDs, the ID is stored in the document so it must not be changed
+var clusterCodecs = map[byte]ClusterCodec{
+	1: msgpackClusterCodec{},
+}
+
+var _clusterCodec atomic.Pointer[ClusterCodec]
+
+// SetClusterCodec changes the codec of the cluster documents written by the store, the documents
+// encoded by any codec can be read no matter which codec is used for writing.
+func SetClusterCodec(name string) error {
+	var clusterCodec ClusterCodec = jsonClusterCodec{}
+	if name != "" && name != ClusterCodecJSON {
+		clusterCodec = nil
+		for _, c := range clusterCodecs {
+			if c.Name() == name {
+				clusterCodec = c
+			}
+		}
+		if clusterCodec == nil {
+			return fmt.Errorf("%w: unknown cluster codec: %s", consts.ErrInvalidArgument, name)
+		}
+	}
+	_clusterCodec.Store(&clusterCodec)
+	return nil
+}
+
+func getClusterCodec() ClusterCodec {
+	if clusterCodec := _clusterCodec.Load(); clusterCodec != nil {
+		return *clusterCodec
+	}
+	return jsonClusterCodec{}
+}
+
+func observeClusterCodec(clusterCodec ClusterCodec, op string, start time.Time, size int) {
+	metrics.Get().ClusterCodecLatency.WithLabelValues(clusterCodec.Name(), op).Observe(time.Since(start).Seconds())
+	metrics.Get().ClusterCodecBytes.WithLabelValues(clusterCodec.Name(), op).Observe(float64(size))
+}
+
+// encodeCluster encodes the cluster document by the codec of the store
+func encodeCluster(cluster *Cluster) ([]byte, error) {
+	start := time.Now()
+	clusterCodec := getClusterCodec()
+	data, err := clusterCodec.Marshal(cluster)
+	if err != nil {
+		return nil, err
+	}
+	for id, c := range clusterCodecs {
+		if c.Name() == clusterCodec.Name() {
+			data = append([]byte{clusterCodecMagic, id}, data...)
+			break
+		}
+	}
+	observeClusterCodec(clusterCodec, "encode", start, len(data))
+	return data, nil
+}
+
+// decodeCluster decodes the cluster document by the codec which encoded it
+func decodeCluster(data []byte, cluster *Cluster) error {
+	start := time.Now()
+	var clusterCodec ClusterCodec = jsonClusterCodec{}
+	payload := data
+	if len(data) > 0 && data[0] == clusterCodecMagic {
+		if len(data) < 2 || clusterCodecs[data[1]] == nil {
+			return fmt.Errorf("unknown cluster codec of the document")
+		}
+		clusterCodec, payload = clusterCodecs[data[1]], data[2:]
+	}
+	if err := clusterCodec.Unmarshal(payload, cluster); err != nil {
+		return err
+	}
+	observeClusterCodec(clusterCodec, "decode", start, len(data))
+	return nil
+}
+
+// isJSONClusterDocument returns whether the document is encoded by JSON, so it's readable in the logs
+func isJSONClusterDocument(data []byte) bool {
+	return len(data) == 0 || data[0] != clusterCodecMagic
+}
+
+// msgpackClusterCodec encodes the cluster by MessagePack, which is more compact and
+// faster than JSON for the large clusters. The documents mirror the JSON documents
+// since the nodes have unexported fields and the version is atomic.
+type msgpackClusterCodec struct{}
+
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// msgpackSchemaVersion is the schema version in which the MessagePack documents were introduced.
+// The JSON migrations don't apply to them, so bumping ClusterSchemaVersion requires adding the
+// migration of the MessagePack documents as well, and they're refused until then.
+const msgpackSchemaVersion = 1
+
+type msgpackNode struct {
+	ID         string            `codec:"id"`
+	Addr       string            `codec:"addr"`
+	Hostname   string            `codec:"hostname,omitempty"`
+	Role       string            `codec:"role"`
+	Password   string            `codec:"password,omitempty"`
+	MasterAuth string            `codec:"master_auth,omitempty"`
+	CreatedAt  int64             `codec:"created_at"`
+	Restoring  bool              `codec:"restoring,omitempty"`
+	Syncing    bool              `codec:"syncing,omitempty"`
+	Labels     map[string]string `codec:"labels,omitempty"`
+}
+
+type msgpackShard struct {
+	Nodes            []msgpackNode     `codec:"nodes"`
+	SlotRanges       []SlotRange       `codec:"slot_ranges"`
+	TargetShardIndex int               `codec:"target_shard_index"`
+	MigratingSlot    *SlotRange        `codec:"migrating_slot,omitempty"`
+	PendingSlots     []SlotRange       `codec:"pending_slots,omitempty"`
+	ReplicaOf        map[string]string `codec:"replica_of,omitempty"`
+	MigrationID      string            `codec:"migration_id,omitempty"`
+	Frozen           bool              `codec:"frozen,omitempty"`
+}
+
+type msgpackCluster struct {
+	SchemaVersion int                 `codec:"schema_version"`
+	Name          string              `codec:"name"`
+	Version       int64               `codec:"version"`
+	Shards        []msgpackShard      `codec:"shards"`
+	Description   string              `codec:"description,omitempty"`
+	Annotations   map[string]string   `codec:"annotations,omitempty"`
+	Labels        map[string]string   `codec:"labels,omitempty"`
+	HealthCheck   *HealthCheck        `codec:"health_check,omitempty"`
+	Compaction    *CompactionSchedule `codec:"compaction,omitempty"`
+	Placement     *PlacementRules     `codec:"placement,omitempty"`
+	Protected     bool                `codec:"protected,omitempty"`
+}
+
+// clusterNodeOf returns the concrete node, the unknown node type is converted by JSON
+func clusterNodeOf(node Node) (*ClusterNode, error) {
+	switch n := node.(type) {
+	case *ClusterNode:
+		return n, nil
+	case *ClusterMockNode:
+		return n.ClusterNode, nil
+	}
+	data, err := node.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var clusterNode ClusterNode
+	if err := clusterNode.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return &clusterNode, nil
+}
+
+func (msgpackClusterCodec) Name() string {
+	return ClusterCodecMsgpack
+}
+
+func (msgpackClusterCodec) encodeDocument(doc *msgpackCluster) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(doc); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (msgpackClusterCodec) decodeDocument(data []byte, doc *msgpackCluster) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(doc)
+}
+
+func (c msgpackClusterCodec) Marshal(cluster *Cluster) ([]byte, error) {
+	doc := msgpackCluster{
+		SchemaVersion: ClusterSchemaVersion,
+		Name:          cluster.Name,
+		Version:       cluster.Version.Load(),
+		Shards:        make([]msgpackShard, 0, len(cluster.Shards)),
+		Description:   cluster.Description,
+		Annotations:   cluster.Annotations,
+		Labels:        cluster.Labels,
+		HealthCheck:   cluster.HealthCheck,
+		Compaction:    cluster.Compaction,
+		Placement:     cluster.Placement,
+		Protected:     cluster.Protected,
+	}
+	for _, shard := range cluster.Shards {
+		shardDoc := msgpackShard{
+			Nodes:            make([]msgpackNode, 0, len(shard.Nodes)),
+			SlotRanges:       shard.SlotRanges,
+			TargetShardIndex: shard.TargetShardIndex,
+			PendingSlots:     shard.PendingSlots,
+			ReplicaOf:        shard.ReplicaOf,
+			MigrationID:      shard.MigrationID,
+			Frozen:           shard.Frozen,
+		}
+		if shard.MigratingSlot != nil && shard.MigratingSlot.IsMigrating {
+			slotRange := shard.MigratingSlot.SlotRange
+			shardDoc.MigratingSlot = &slotRange
+		}
+		for _, node := range shard.Nodes {
+			n, err := clusterNodeOf(node)
+			if err != nil {
+				return nil, err
+			}
+			shardDoc.Nodes = append(shardDoc.Nodes, msgpackNode{
+				ID:         n.id,
+				Addr:       n.addr,
+				Hostname:   n.hostname,
+				Role:       n.role,
+				Password:   n.password,
+				MasterAuth: n.masterAuth,
+				CreatedAt:  n.createdAt,
+				Restoring:  n.restoring,
+				Syncing:    n.syncing,
+				Labels:     n.labels,
+			})
+		}
+		doc.Shards = append(doc.Shards, shardDoc)
+	}
+
+	return c.encodeDocument(&doc)
+}
+
+func (c msgpackClusterCodec) Unmarshal(data []byte, cluster *Cluster) error {
+	var doc msgpackCluster
+	if err := c.decodeDocument(data, &doc); err != nil {
+		return err
+	}
+	if doc.SchemaVersion > ClusterSchemaVersion {
+		return fmt.Errorf("%w: the cluster schema version is %d but only %d is supported, "+
+			"please upgrade the controller", ErrSchemaTooNew, doc.SchemaVersion, ClusterSchemaVersion)
+	}
+	if doc.SchemaVersion < ClusterSchemaVersion {
+		return fmt.Errorf("no migration of the msgpack cluster document from the schema version %d to %d",
+			doc.SchemaVersion, ClusterSchemaVersion)
+	}
+	cluster.Name = doc.Name
+	cluster.Version.Store(doc.Version)
+	cluster.Description = doc.Description
+	cluster.Annotations = doc.Annotations
+	cluster.Labels = doc.Labels
+	cluster.HealthCheck = doc.HealthCheck
+	cluster.Compaction = doc.Compaction
+	cluster.Placement = doc.Placement
+	cluster.Protected = doc.Protected
+	cluster.Shards = make([]*Shard, 0, len(doc.Shards))
+	for _, shardDoc := range doc.Shards {
+		shard := &Shard{
+			Nodes:            make([]Node, 0, len(shardDoc.Nodes)),
+			SlotRanges:       shardDoc.SlotRanges,
+			TargetShardIndex: shardDoc.TargetShardIndex,
+			PendingSlots:     shardDoc.PendingSlots,
+			ReplicaOf:        shardDoc.ReplicaOf,
+			MigrationID:      shardDoc.MigrationID,
+			Frozen:           shardDoc.Frozen,
+		}
+		if shardDoc.MigratingSlot != nil {
+			shard.MigratingSlot = FromSlotRange(*shardDoc.MigratingSlot)
+		}
+		for _, n := range shardDoc.Nodes {
+			shard.Nodes = append(shard.Nodes, &ClusterNode{
+				id:         n.ID,
+				addr:       n.Addr,
+				hostname:   n.Hostname,
+				role:       n.Role,
+				password:   n.Password,
+				masterAuth: n.MasterAuth,
+				createdAt:  n.CreatedAt,
+				restoring:  n.Restoring,
+				syncing:    n.Syncing,
+				labels:     n.Labels,
+			})
+		}
+		cluster.Shards = append(cluster.Shards, shard)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func newCodecTestCluster(t *testing.T) *Cluster {
+	cluster, err := NewCluster("test-cluster", []string{"127.0.0.1:6379", "127.0.0.1:6380", "127.0.0.1:6381"}, 3)
+	require.NoError(t, err)
+	cluster.Version.Store(7)
+	cluster.Description = "the cluster"
+	cluster.Annotations = map[string]string{"owner": "team-a"}
+	cluster.Labels = map[string]string{"env": "prod"}
+	cluster.HealthCheck = &HealthCheck{
+		Commands:  []HealthCheckCommand{{Args: []string{"PING"}, Expect: "PONG"}},
+		TimeoutMs: 100,
+	}
+	cluster.Compaction = &CompactionSchedule{Windows: []string{"23:00-01:00"}}
+	cluster.Placement = &PlacementRules{MaxZoneSlotsPercent: 60}
+	cluster.Protected = true
+
+	shard := cluster.Shards[0]
+	shard.MigratingSlot = FromSlotRange(SlotRange{Start: 1, Stop: 10})
+	shard.TargetShardIndex = 0
+	shard.PendingSlots = []SlotRange{{Start: 11, Stop: 20}}
+	shard.MigrationID = "migration-1"
+	shard.Frozen = true
+	master := shard.Nodes[0].(*ClusterNode)
+	master.SetHostname("host-0")
+	master.SetPassword("secret")
+	master.UpdateLabels(map[string]string{LabelZone: "zone-a"})
+	replica := shard.Nodes[1].(*ClusterNode)
+	replica.SetMasterAuth("master-secret")
+	replica.SetRestoring(true)
+	replica.SetSyncing(true)
+	shard.ReplicaOf = map[string]string{shard.Nodes[2].ID(): replica.ID()}
+	return cluster
+}
+
+func TestClusterCodec(t *testing.T) {
+	defer func() {
+		require.NoError(t, SetClusterCodec(ClusterCodecJSON))
+	}()
+
+	cluster := newCodecTestCluster(t)
+	expected, err := json.Marshal(cluster)
+	require.NoError(t, err)
+
+	for _, name := range []string{ClusterCodecJSON, ClusterCodecMsgpack} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, SetClusterCodec(name))
+			data, err := encodeCluster(cluster)
+			require.NoError(t, err)
+			require.Equal(t, name == ClusterCodecJSON, isJSONClusterDocument(data))
+
+			var decoded Cluster
+			require.NoError(t, decodeCluster(data, &decoded))
+			got, err := json.Marshal(&decoded)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), string(got))
+		})
+	}
+
+	t.Run("msgpack is smaller than json", func(t *testing.T) {
+		jsonData, err := jsonClusterCodec{}.Marshal(cluster)
+		require.NoError(t, err)
+		msgpackData, err := msgpackClusterCodec{}.Marshal(cluster)
+		require.NoError(t, err)
+		require.Less(t, len(msgpackData), len(jsonData))
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		require.ErrorIs(t, SetClusterCodec("protobuf"), consts.ErrInvalidArgument)
+		var decoded Cluster
+		require.Error(t, decodeCluster([]byte{clusterCodecMagic, 0xff, 0x01}, &decoded))
+		require.Error(t, decodeCluster([]byte{clusterCodecMagic}, &decoded))
+	})
+
+	t.Run("schema too new", func(t *testing.T) {
+		data, err := msgpackClusterCodec{}.Marshal(cluster)
+		require.NoError(t, err)
+		var doc msgpackCluster
+		require.NoError(t, msgpackClusterCodec{}.decodeDocument(data, &doc))
+		doc.SchemaVersion = ClusterSchemaVersion + 1
+		data, err = msgpackClusterCodec{}.encodeDocument(&doc)
+		require.NoError(t, err)
+		var decoded Cluster
+		require.ErrorIs(t, msgpackClusterCodec{}.Unmarshal(data, &decoded), ErrSchemaTooNew)
+
+		// the older documents are refused since there's no migration of them
+		doc.SchemaVersion = ClusterSchemaVersion - 1
+		data, err = msgpackClusterCodec{}.encodeDocument(&doc)
+		require.NoError(t, err)
+		require.Error(t, msgpackClusterCodec{}.Unmarshal(data, &decoded))
+	})
+
+	t.Run("schema version gating", func(t *testing.T) {
+		require.Equal(t, msgpackSchemaVersion, ClusterSchemaVersion,
+			"add the migration of the msgpack documents before bumping the schema version")
+	})
+}
+
+func TestClusterStore_ClusterCodec(t *testing.T) {
+	defer func() {
+		require.NoError(t, SetClusterCodec(ClusterCodecJSON))
+	}()
+
+	ctx := context.Background()
+	store := NewClusterStore(engine.NewMock())
+	ns := "ns0"
+	require.NoError(t, store.CreateNamespace(ctx, ns))
+
+	// the legacy json document is still readable after switching to msgpack
+	cluster := newCodecTestCluster(t)
+	require.NoError(t, store.CreateCluster(ctx, ns, cluster))
+	require.NoError(t, SetClusterCodec(ClusterCodecMsgpack))
+	got, err := store.GetCluster(ctx, ns, cluster.Name)
+	require.NoError(t, err)
+	require.Equal(t, cluster.Version.Load(), got.Version.Load())
+
+	got.Description = "updated"
+	require.NoError(t, store.UpdateCluster(ctx, ns, got))
+	value, err := store.e.Get(ctx, buildClusterKey(ns, cluster.Name))
+	require.NoError(t, err)
+	require.False(t, isJSONClusterDocument(value))
+
+	// the msgpack document is still readable after switching back to json
+	require.NoError(t, SetClusterCodec(ClusterCodecJSON))
+	clusters, err := store.GetClusters(ctx, ns)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	require.Equal(t, "updated", clusters[0].Description)
+	require.Equal(t, got.Version.Load(), clusters[0].Version.Load())
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const (
+	defaultCompactionNodeTimeout = time.Hour
+	minutesPerDay                = 24 * 60
+)
+
+// CompactionSchedule is the maintenance schedule of the cluster, the controller
+// will compact the nodes in a rolling manner once in each window.
+type CompactionSchedule struct {
+	// Windows are the daily windows in UTC with the format `HH:MM-HH:MM`, the window
+	// crosses midnight if the stop time is earlier than the start time, e.g. `23:00-01:00`.
+	Windows []string `json:"windows"`
+	// NodeTimeoutMs is the max time to wait for the compaction of a single node
+	NodeTimeoutMs int64 `json:"node_timeout_ms,omitempty"`
+}
+
+func parseDayMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseCompactionWindow returns the start minute of the day and the length in minutes
+func parseCompactionWindow(window string) (int, int, error) {
+	fields := strings.Split(window, "-")
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("%w: invalid compaction window: %s", consts.ErrInvalidArgument, window)
+	}
+	start, err := parseDayMinutes(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid compaction window: %s", consts.ErrInvalidArgument, window)
+	}
+	stop, err := parseDayMinutes(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid compaction window: %s", consts.ErrInvalidArgument, window)
+	}
+	if start == stop {
+		return 0, 0, fmt.Errorf("%w: empty compaction window: %s", consts.ErrInvalidArgument, window)
+	}
+	return start, (stop - start + minutesPerDay) % minutesPerDay, nil
+}
+
+func (schedule *CompactionSchedule) IsEmpty() bool {
+	return schedule == nil || len(schedule.Windows) == 0
+}
+
+func (schedule *CompactionSchedule) Validate() error {
+	for _, window := range schedule.Windows {
+		if _, _, err := parseCompactionWindow(window); err != nil {
+			return err
+		}
+	}
+	if schedule.NodeTimeoutMs < 0 {
+		return fmt.Errorf("%w: compaction node timeout should be positive", consts.ErrInvalidArgument)
+	}
+	return nil
+}
+
+func (schedule *CompactionSchedule) NodeTimeout() time.Duration {
+	if schedule.NodeTimeoutMs > 0 {
+		return time.Duration(schedule.NodeTimeoutMs) * time.Millisecond
+	}
+	return defaultCompactionNodeTimeout
+}
+
+// ActiveWindow returns the start and stop time of the window which contains now
+func (schedule *CompactionSchedule) ActiveWindow(now time.Time) (time.Time, time.Time, bool) {
+	if schedule.IsEmpty() {
+		return time.Time{}, time.Time{}, false
+	}
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, window := range schedule.Windows {
+		startMinutes, lengthMinutes, err := parseCompactionWindow(window)
+		if err != nil {
+			continue
+		}
+		// the window which starts yesterday may cross midnight
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			start := day.Add(time.Duration(startMinutes) * time.Minute)
+			stop := start.Add(time.Duration(lengthMinutes) * time.Minute)
+			if !now.Before(start) && now.Before(stop) {
+				return start, stop, true
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func isCompacting(ctx context.Context, node Node) (bool, error) {
+	value, err := getInfoField(ctx, node, "rocksdb", "is_compacting")
+	if err != nil {
+		return false, err
+	}
+	return value == "yes", nil
+}
+
+// CompactNode triggers the compaction on the node and waits until it's done,
+// the compaction is checked with the `is_compacting` field of the rocksdb info.
+func CompactNode(ctx context.Context, node Node, pollInterval time.Duration) error {
+	if _, err := node.Do(ctx, "COMPACT"); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		compacting, err := isCompacting(ctx, node)
+		if err != nil {
+			return err
+		}
+		if !compacting {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestCompactionSchedule(t *testing.T) {
+	require.True(t, (*CompactionSchedule)(nil).IsEmpty())
+	for _, window := range []string{"02:00", "02:00-02:00", "25:00-03:00", "a-b"} {
+		require.ErrorIs(t, (&CompactionSchedule{Windows: []string{window}}).Validate(), consts.ErrInvalidArgument)
+	}
+
+	schedule := &CompactionSchedule{Windows: []string{"02:00-04:00", "23:00-01:00"}}
+	require.NoError(t, schedule.Validate())
+	require.Equal(t, defaultCompactionNodeTimeout, schedule.NodeTimeout())
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	_, _, ok := schedule.ActiveWindow(day.Add(5 * time.Hour))
+	require.False(t, ok)
+	start, stop, ok := schedule.ActiveWindow(day.Add(3 * time.Hour))
+	require.True(t, ok)
+	require.Equal(t, day.Add(2*time.Hour), start)
+	require.Equal(t, day.Add(4*time.Hour), stop)
+	_, _, ok = schedule.ActiveWindow(day.Add(4 * time.Hour))
+	require.False(t, ok)
+
+	// the window crosses midnight
+	start, stop, ok = schedule.ActiveWindow(day.Add(30 * time.Minute))
+	require.True(t, ok)
+	require.Equal(t, day.Add(-time.Hour), start)
+	require.Equal(t, day.Add(time.Hour), stop)
+	start, _, ok = schedule.ActiveWindow(day.Add(23*time.Hour + 30*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, day.Add(23*time.Hour), start)
+}
+
+func TestCompactNode(t *testing.T) {
+	ctx := context.Background()
+	node := NewClusterMockNode()
+	node.Replies = map[string]interface{}{
+		"COMPACT": "OK",
+		"INFO":    "# RocksDB\r\nis_compacting:no\r\n",
+	}
+	require.NoError(t, CompactNode(ctx, node, time.Millisecond))
+
+	node.Replies["INFO"] = "# RocksDB\r\nis_compacting:yes\r\n"
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, CompactNode(timeoutCtx, node, time.Millisecond), context.DeadlineExceeded)
+
+	node.Replies["COMPACT"] = errors.New("ERR compaction failed")
+	require.Error(t, CompactNode(ctx, node, time.Millisecond))
+}
+
+func TestClusterStore_Jobs(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	jobs, err := s.ListJobs(ctx, "ns", "cluster", JobTypeCompaction)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+
+	for i := 0; i < MaxJobHistorySize+5; i++ {
+		job := &Job{ID: fmt.Sprint(i), Type: JobTypeCompaction, Status: JobStatusRunning}
+		require.NoError(t, s.SaveJob(ctx, "ns", "cluster", job))
+	}
+	job := &Job{ID: fmt.Sprint(MaxJobHistorySize + 4), Type: JobTypeCompaction, Status: JobStatusSucceeded}
+	require.NoError(t, s.SaveJob(ctx, "ns", "cluster", job))
+
+	jobs, err = s.ListJobs(ctx, "ns", "cluster", JobTypeCompaction)
+	require.NoError(t, err)
+	require.Len(t, jobs, MaxJobHistorySize)
+	require.Equal(t, job.ID, jobs[0].ID)
+	require.Equal(t, JobStatusSucceeded, jobs[0].Status)
+	require.Equal(t, "5", jobs[MaxJobHistorySize-1].ID)
+
+	require.NoError(t, s.e.(engine.Transactional).Txn(ctx, removeJobsOps("ns", "cluster")))
+	jobs, err = s.ListJobs(ctx, "ns", "cluster", JobTypeCompaction)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+
+	t.Run("save the jobs concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < MaxJobHistorySize; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				job := &Job{ID: fmt.Sprint(i), Type: JobTypeCompaction, Status: JobStatusRunning}
+				require.NoError(t, s.SaveJob(ctx, "ns", "cluster", job))
+			}(i)
+		}
+		wg.Wait()
+		jobs, err := s.ListJobs(ctx, "ns", "cluster", JobTypeCompaction)
+		require.NoError(t, err)
+		require.Len(t, jobs, MaxJobHistorySize)
+	})
+}
@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// NodeConversion is the inspection result of the standalone node which will be
+// converted into the cluster node, the node can't be converted if Blocker isn't empty.
+type NodeConversion struct {
+	Addr  string `json:"addr"`
+	Shard int    `json:"shard"`
+	Role  string `json:"role"`
+	// Version is the version of the stale topology on the node, -1 if not initialized
+	Version int64    `json:"version"`
+	Keys    int64    `json:"keys"`
+	Actions []string `json:"actions"`
+	Blocker string   `json:"blocker,omitempty"`
+}
+
+func (conversion *NodeConversion) addAction(format string, args ...interface{}) {
+	conversion.Actions = append(conversion.Actions, fmt.Sprintf(format, args...))
+}
+
+// ConversionPlan is the plan to convert the standalone nodes into the cluster
+type ConversionPlan struct {
+	Cluster *Cluster          `json:"cluster"`
+	Nodes   []*NodeConversion `json:"nodes"`
+}
+
+// ConversionBlockedError is returned if any node of the plan can't be converted
+type ConversionBlockedError struct {
+	Plan *ConversionPlan
+}
+
+func (err *ConversionBlockedError) Error() string {
+	blockers := make([]string, 0)
+	for _, node := range err.Plan.Nodes {
+		if node.Blocker != "" {
+			blockers = append(blockers, fmt.Sprintf("%s: %s", node.Addr, node.Blocker))
+		}
+	}
+	return fmt.Sprintf("%s: %s", consts.ErrInvalidArgument.Error(), strings.Join(blockers, "; "))
+}
+
+func (err *ConversionBlockedError) Unwrap() error {
+	return consts.ErrInvalidArgument
+}
+
+func (err *ConversionBlockedError) Details() interface{} {
+	return map[string]interface{}{"plan": err.Plan}
+}
+
+// PlanConversion builds the cluster from the spec and inspects the standalone nodes,
+// the cluster mode must have been enabled on the nodes since it can't be changed at runtime.
+func PlanConversion(ctx context.Context, name string, spec *ClusterSpec) (*ConversionPlan, error) {
+	cluster, err := NewClusterFromSpec(name, spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", consts.ErrInvalidArgument, err.Error())
+	}
+	return newConversionPlan(ctx, cluster), nil
+}
+
+func newConversionPlan(ctx context.Context, cluster *Cluster) *ConversionPlan {
+	plan := &ConversionPlan{Cluster: cluster, Nodes: make([]*NodeConversion, 0)}
+	for i, shard := range cluster.Shards {
+		master := shard.Nodes[0]
+		for _, node := range shard.Nodes {
+			conversion := inspectNode(ctx, node, i)
+			if conversion.Blocker == "" {
+				conversion.addAction("assign the node id %s", node.ID())
+				if node.IsMaster() {
+					conversion.addAction("serve the slots %s", slotRangesString(shard.SlotRanges))
+				} else {
+					conversion.addAction("replicate from %s", master.Addr())
+					if conversion.Keys > 0 {
+						conversion.addAction("discard %d keys by the full sync from the master", conversion.Keys)
+					}
+				}
+			}
+			plan.Nodes = append(plan.Nodes, conversion)
+		}
+	}
+	return plan
+}
+
+func inspectNode(ctx context.Context, node Node, shardIndex int) *NodeConversion {
+	conversion := &NodeConversion{
+		Addr:    node.Addr(),
+		Shard:   shardIndex,
+		Role:    RoleSlave,
+		Version: -1,
+		Actions: make([]string, 0),
+	}
+	if node.IsMaster() {
+		conversion.Role = RoleMaster
+	}
+
+	reply, err := node.Do(ctx, "CONFIG", "GET", "cluster-enabled")
+	if err != nil {
+		conversion.Blocker = fmt.Sprintf("failed to get the config: %s", err.Error())
+		return conversion
+	}
+	if values, ok := reply.([]interface{}); !ok || len(values) != 2 || fmt.Sprint(values[1]) != "yes" {
+		conversion.Blocker = "the cluster mode is disabled, restart the node with `cluster-enabled yes`"
+		return conversion
+	}
+	if keys, err := node.Do(ctx, "DBSIZE"); err != nil {
+		conversion.Blocker = fmt.Sprintf("failed to get the number of keys: %s", err.Error())
+		return conversion
+	} else if n, ok := keys.(int64); ok {
+		conversion.Keys = n
+	}
+
+	info, err := node.GetClusterInfo(ctx)
+	if err != nil && !strings.Contains(err.Error(), "cluster is not initialized") {
+		conversion.Blocker = fmt.Sprintf("failed to get the cluster info: %s", err.Error())
+		return conversion
+	}
+	if err == nil && info.CurrentEpoch != -1 {
+		conversion.Version = info.CurrentEpoch
+		// the slots of the stale topology may not match the data, only the empty node is safe to reset
+		if conversion.Keys > 0 {
+			conversion.Blocker = fmt.Sprintf("the node has %d keys and the topology of version %d, "+
+				"reset it manually if it isn't in use", conversion.Keys, conversion.Version)
+			return conversion
+		}
+		conversion.addAction("reset the stale topology of version %d", conversion.Version)
+	}
+	return conversion
+}
+
+// Blocked returns whether any node of the plan can't be converted
+func (plan *ConversionPlan) Blocked() bool {
+	for _, node := range plan.Nodes {
+		if node.Blocker != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Prepare resets the stale topology of the nodes, it should be called before creating the cluster
+func (plan *ConversionPlan) Prepare(ctx context.Context) error {
+	if plan.Blocked() {
+		return &ConversionBlockedError{Plan: plan}
+	}
+	staleNodes := make(map[string]bool)
+	for _, node := range plan.Nodes {
+		if node.Version != -1 {
+			staleNodes[node.Addr] = true
+		}
+	}
+	for _, node := range plan.Cluster.GetNodes() {
+		if !staleNodes[node.Addr()] {
+			continue
+		}
+		if err := node.Reset(ctx, false); err != nil {
+			return fmt.Errorf("reset the topology of node %s: %w", node.Addr(), err)
+		}
+	}
+	return nil
+}
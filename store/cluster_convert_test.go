@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+func TestConversionPlan(t *testing.T) {
+	ctx := context.Background()
+	newNode := func(addr, role string, version, keys int64) *ClusterMockNode {
+		node := NewClusterMockNode()
+		node.SetAddr(addr)
+		node.SetRole(role)
+		node.ClusterInfo.CurrentEpoch = version
+		node.Replies = map[string]interface{}{
+			"CONFIG": []interface{}{"cluster-enabled", "yes"},
+			"DBSIZE": keys,
+		}
+		return node
+	}
+	newCluster := func(nodes ...Node) *Cluster {
+		return &Cluster{
+			Name: "test-cluster",
+			Shards: []*Shard{{
+				Nodes:      nodes,
+				SlotRanges: []SlotRange{{Start: MinSlotID, Stop: MaxSlotID}},
+			}},
+		}
+	}
+
+	t.Run("convert the standalone nodes", func(t *testing.T) {
+		master := newNode("127.0.0.1:6666", RoleMaster, -1, 10)
+		replica := newNode("127.0.0.1:6667", RoleSlave, 3, 0)
+		plan := newConversionPlan(ctx, newCluster(master, replica))
+		require.False(t, plan.Blocked())
+		require.Len(t, plan.Nodes, 2)
+		require.EqualValues(t, -1, plan.Nodes[0].Version)
+		require.EqualValues(t, 10, plan.Nodes[0].Keys)
+		require.Equal(t, []string{
+			"assign the node id " + master.ID(),
+			"serve the slots 0-16383",
+		}, plan.Nodes[0].Actions)
+		require.EqualValues(t, 3, plan.Nodes[1].Version)
+		require.Equal(t, []string{
+			"reset the stale topology of version 3",
+			"assign the node id " + replica.ID(),
+			"replicate from 127.0.0.1:6666",
+		}, plan.Nodes[1].Actions)
+		require.NoError(t, plan.Prepare(ctx))
+	})
+
+	t.Run("block the nodes which can't be converted", func(t *testing.T) {
+		disabled := newNode("127.0.0.1:6666", RoleMaster, -1, 0)
+		disabled.Replies["CONFIG"] = []interface{}{"cluster-enabled", "no"}
+		stale := newNode("127.0.0.1:6667", RoleSlave, 3, 5)
+		unreachable := newNode("127.0.0.1:6668", RoleSlave, -1, 0)
+		unreachable.Replies["CONFIG"] = errors.New("connection refused")
+		plan := newConversionPlan(ctx, newCluster(disabled, stale, unreachable))
+		require.True(t, plan.Blocked())
+		require.Contains(t, plan.Nodes[0].Blocker, "cluster-enabled yes")
+		require.Contains(t, plan.Nodes[1].Blocker, "has 5 keys")
+		require.Contains(t, plan.Nodes[2].Blocker, "connection refused")
+		require.Empty(t, plan.Nodes[0].Actions)
+
+		err := plan.Prepare(ctx)
+		require.ErrorIs(t, err, consts.ErrInvalidArgument)
+		var blockedErr *ConversionBlockedError
+		require.ErrorAs(t, err, &blockedErr)
+		require.Contains(t, err.Error(), "127.0.0.1:6667")
+	})
+
+	t.Run("reject the invalid spec", func(t *testing.T) {
+		_, err := PlanConversion(ctx, "test-cluster", &ClusterSpec{})
+		require.ErrorIs(t, err, consts.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"net"
+	"sort"
+	"strconv"
+)
+
+type EndpointNode struct {
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+}
+
+// SlotEndpoint maps the slot range to the master and replicas which serve it
+type SlotEndpoint struct {
+	Start    int            `json:"start"`
+	Stop     int            `json:"stop"`
+	Master   EndpointNode   `json:"master"`
+	Replicas []EndpointNode `json:"replicas"`
+}
+
+func splitEndpointAddr(addr string) (string, int) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, 0
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+// Endpoints returns the slot ranges with the serving nodes in order of the slot,
+// smart clients and proxies can bootstrap from it.
+func (cluster *Cluster) Endpoints() []SlotEndpoint {
+	endpoints := make([]SlotEndpoint, 0)
+	for _, shard := range cluster.Shards {
+		master := shard.GetMasterNode()
+		if master == nil {
+			continue
+		}
+		replicas := make([]EndpointNode, 0)
+		for _, node := range shard.Nodes {
+			if !node.IsMaster() {
+				replicas = append(replicas, EndpointNode{ID: node.ID(), Addr: node.Addr()})
+			}
+		}
+		for _, slotRange := range shard.SlotRanges {
+			endpoints = append(endpoints, SlotEndpoint{
+				Start:    slotRange.Start,
+				Stop:     slotRange.Stop,
+				Master:   EndpointNode{ID: master.ID(), Addr: master.Addr()},
+				Replicas: replicas,
+			})
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Start < endpoints[j].Start
+	})
+	return endpoints
+}
+
+// ClusterSlotsReply returns the reply of the `CLUSTER SLOTS` command
+func (cluster *Cluster) ClusterSlotsReply() []interface{} {
+	reply := make([]interface{}, 0)
+	for _, endpoint := range cluster.Endpoints() {
+		item := []interface{}{endpoint.Start, endpoint.Stop}
+		for _, node := range append([]EndpointNode{endpoint.Master}, endpoint.Replicas...) {
+			host, port := splitEndpointAddr(node.Addr)
+			item = append(item, []interface{}{host, port, node.ID})
+		}
+		reply = append(reply, item)
+	}
+	return reply
+}
+
+// ClusterShardsReply returns the reply of the `CLUSTER SHARDS` command
+func (cluster *Cluster) ClusterShardsReply() []interface{} {
+	reply := make([]interface{}, 0)
+	for _, shard := range cluster.Shards {
+		slots := make([]interface{}, 0)
+		for _, slotRange := range shard.SlotRanges {
+			slots = append(slots, slotRange.Start, slotRange.Stop)
+		}
+		nodes := make([]interface{}, 0)
+		for _, node := range shard.Nodes {
+			host, port := splitEndpointAddr(node.Addr())
+			role := RoleMaster
+			if !node.IsMaster() {
+				role = "replica"
+			}
+			nodes = append(nodes, []interface{}{
+				"id", node.ID(),
+				"port", port,
+				"ip", host,
+				"endpoint", host,
+				"role", role,
+				"replication-offset", 0,
+				"health", "online",
+			})
+		}
+		reply = append(reply, []interface{}{"slots", slots, "nodes", nodes})
+	}
+	return reply
+}
@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// FailoverCandidate is the evaluation of the replica to be promoted as the new master
+type FailoverCandidate struct {
+	NodeID    string `json:"node_id"`
+	Addr      string `json:"addr"`
+	Sequence  uint64 `json:"sequence"`
+	Preferred bool   `json:"preferred"`
+	// Reason is why the replica can't be promoted, it's empty if the replica is eligible
+	Reason   string `json:"reason,omitempty"`
+	Selected bool   `json:"selected"`
+}
+
+// FailoverSimulation is the result of the failover evaluated without being executed
+type FailoverSimulation struct {
+	Shard       int                 `json:"shard"`
+	OldMasterID string              `json:"old_master_id"`
+	NewMasterID string              `json:"new_master_id,omitempty"`
+	Candidates  []FailoverCandidate `json:"candidates"`
+	// Error is why the failover would fail if none of the candidates is eligible
+	Error string `json:"error,omitempty"`
+}
+
+// SimulateFailover evaluates the failover of the shard in the same way as PromoteNewMaster,
+// and returns which replica would be promoted and why without changing the cluster.
+func (cluster *Cluster) SimulateFailover(ctx context.Context, shardIdx int, preferredNodeID string) (*FailoverSimulation, error) {
+	shard, err := cluster.GetShard(shardIdx)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.CheckShardFrozen(shardIdx); err != nil {
+		return nil, err
+	}
+	masterNodeIndex, err := shard.getFailoverMasterIndex("")
+	if err != nil {
+		return nil, err
+	}
+	candidates, newMasterNodeIndex := shard.evaluateFailoverCandidates(ctx, masterNodeIndex, preferredNodeID)
+	simulation := &FailoverSimulation{
+		Shard:       shardIdx,
+		OldMasterID: shard.Nodes[masterNodeIndex].ID(),
+		Candidates:  candidates,
+	}
+	if newMasterNodeIndex == -1 {
+		simulation.Error = consts.ErrShardNoMatchNewMaster.Error()
+	} else {
+		simulation.NewMasterID = shard.Nodes[newMasterNodeIndex].ID()
+	}
+	return simulation, nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+func TestCluster_SimulateFailover(t *testing.T) {
+	ctx := context.Background()
+	newMockNode := func(role string, sequence uint64) *ClusterMockNode {
+		node := NewClusterMockNode()
+		node.SetRole(role)
+		node.Sequence = sequence
+		return node
+	}
+	master := newMockNode(RoleMaster, 100)
+	restoring := newMockNode(RoleSlave, 100)
+	restoring.SetRestoring(true)
+	behind := newMockNode(RoleSlave, 80)
+	newest := newMockNode(RoleSlave, 90)
+	empty := newMockNode(RoleSlave, 0)
+	shard := NewShard()
+	shard.Nodes = []Node{master, restoring, behind, newest, empty}
+	cluster := &Cluster{Name: "cluster", Shards: Shards{shard}}
+
+	simulation, err := cluster.SimulateFailover(ctx, 0, "")
+	require.NoError(t, err)
+	require.Equal(t, master.ID(), simulation.OldMasterID)
+	require.Equal(t, newest.ID(), simulation.NewMasterID)
+	require.Empty(t, simulation.Error)
+	require.Len(t, simulation.Candidates, 4)
+	require.Equal(t, "the node is restoring from backup", simulation.Candidates[0].Reason)
+	require.Empty(t, simulation.Candidates[1].Reason)
+	require.False(t, simulation.Candidates[1].Selected)
+	require.True(t, simulation.Candidates[2].Selected)
+	require.Equal(t, "the replication sequence is 0", simulation.Candidates[3].Reason)
+
+	// the eligible preferred node is promoted even if it's behind
+	simulation, err = cluster.SimulateFailover(ctx, 0, behind.ID())
+	require.NoError(t, err)
+	require.Equal(t, behind.ID(), simulation.NewMasterID)
+	require.True(t, simulation.Candidates[1].Preferred)
+
+	// the simulation has the same result as the failover, and won't change the cluster
+	require.True(t, master.IsMaster())
+	newMasterID, err := cluster.PromoteNewMaster(ctx, 0, "", behind.ID())
+	require.NoError(t, err)
+	require.Equal(t, behind.ID(), newMasterID)
+
+	shard = NewShard()
+	shard.Nodes = []Node{newMockNode(RoleMaster, 100), restoring}
+	cluster = &Cluster{Name: "cluster", Shards: Shards{shard}}
+	simulation, err = cluster.SimulateFailover(ctx, 0, "")
+	require.NoError(t, err)
+	require.Empty(t, simulation.NewMasterID)
+	require.Equal(t, consts.ErrShardNoMatchNewMaster.Error(), simulation.Error)
+
+	cluster.Shards[0].Frozen = true
+	_, err = cluster.SimulateFailover(ctx, 0, "")
+	require.ErrorIs(t, err, consts.ErrShardIsFrozen)
+}
@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// HeadroomLimits are the limits of the target shard master, the disk usage is checked after
+// the migration and the memory before it, the zero value means no limit.
+type HeadroomLimits struct {
+	MaxDiskUsage   float64
+	MaxMemoryBytes int64
+}
+
+type MigrationHeadroom struct {
+	// ProjectedBytes is the estimated data size on the disk of the migrating slots
+	ProjectedBytes    int64    `json:"projected_bytes"`
+	TargetDiskUsage   float64  `json:"target_disk_usage"`
+	TargetMemoryBytes int64    `json:"target_memory_bytes"`
+	Violations        []string `json:"violations"`
+}
+
+// CheckMigrationHeadroom projects the data size of the migrating slots by the proportion of
+// the source shard's slots, and checks whether the target shard master would exceed the disk limit.
+// The memory of the target isn't proportional to the migrated data, so its current RSS is compared
+// with the memory limit alone.
+func (cluster *Cluster) CheckMigrationHeadroom(ctx context.Context, slot SlotRange,
+	targetShardIdx int, limits HeadroomLimits,
+) (*MigrationHeadroom, error) {
+	if targetShardIdx < 0 || targetShardIdx >= len(cluster.Shards) {
+		return nil, fmt.Errorf("target shard: %w", consts.ErrIndexOutOfRange)
+	}
+	sourceShardIdx, err := cluster.findShardIndexBySlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	sourceMaster := cluster.Shards[sourceShardIdx].GetMasterNode()
+	targetMaster := cluster.Shards[targetShardIdx].GetMasterNode()
+	if sourceMaster == nil || targetMaster == nil {
+		return nil, fmt.Errorf("master node: %w", consts.ErrNotFound)
+	}
+	sourceInfo, err := sourceMaster.GetClusterNodeInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get the source node info: %w", err)
+	}
+	targetInfo, err := targetMaster.GetClusterNodeInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get the target node info: %w", err)
+	}
+
+	headroom := &MigrationHeadroom{Violations: make([]string, 0)}
+	if sourceSlots := SlotRanges(cluster.Shards[sourceShardIdx].SlotRanges).Count(); sourceSlots > 0 {
+		headroom.ProjectedBytes = sourceInfo.UsedDBSize * int64(SlotRanges{slot}.Count()) / int64(sourceSlots)
+	}
+	headroom.TargetMemoryBytes = targetInfo.UsedMemoryRSS
+	if targetInfo.DiskCapacity > 0 {
+		headroom.TargetDiskUsage = float64(targetInfo.UsedDiskSize+headroom.ProjectedBytes) * 100 / float64(targetInfo.DiskCapacity)
+	}
+	if limits.MaxDiskUsage > 0 && headroom.TargetDiskUsage > limits.MaxDiskUsage {
+		headroom.Violations = append(headroom.Violations, fmt.Sprintf(
+			"the disk usage of the target would be %.2f%%, exceeds the limit %.2f%%",
+			headroom.TargetDiskUsage, limits.MaxDiskUsage))
+	}
+	if limits.MaxMemoryBytes > 0 && headroom.TargetMemoryBytes > limits.MaxMemoryBytes {
+		headroom.Violations = append(headroom.Violations, fmt.Sprintf(
+			"the memory of the target is %d bytes, exceeds the limit %d bytes",
+			headroom.TargetMemoryBytes, limits.MaxMemoryBytes))
+	}
+	return headroom, nil
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_CheckMigrationHeadroom(t *testing.T) {
+	source := NewClusterMockNode()
+	source.SetRole(RoleMaster)
+	source.StorageInfo.UsedDBSize = 1000
+	target := NewClusterMockNode()
+	target.SetRole(RoleMaster)
+	target.StorageInfo = ClusterNodeInfo{UsedMemoryRSS: 100, UsedDiskSize: 700, DiskCapacity: 1000}
+
+	sourceShard := NewShard()
+	sourceShard.Nodes = []Node{source}
+	sourceShard.SlotRanges = []SlotRange{{Start: 0, Stop: 99}}
+	targetShard := NewShard()
+	targetShard.Nodes = []Node{target}
+	targetShard.SlotRanges = []SlotRange{{Start: 100, Stop: MaxSlotID}}
+	cluster := &Cluster{Shards: Shards{sourceShard, targetShard}}
+
+	ctx := context.Background()
+	headroom, err := cluster.CheckMigrationHeadroom(ctx, SlotRange{Start: 0, Stop: 9}, 1, HeadroomLimits{})
+	require.NoError(t, err)
+	require.EqualValues(t, 100, headroom.ProjectedBytes)
+	// the projected data is on the disk rather than in the memory
+	require.EqualValues(t, 100, headroom.TargetMemoryBytes)
+	require.InDelta(t, 80, headroom.TargetDiskUsage, 0.001)
+	require.Empty(t, headroom.Violations)
+
+	headroom, err = cluster.CheckMigrationHeadroom(ctx, SlotRange{Start: 0, Stop: 49}, 1,
+		HeadroomLimits{MaxDiskUsage: 85, MaxMemoryBytes: 500})
+	require.NoError(t, err)
+	require.Len(t, headroom.Violations, 1)
+	require.Contains(t, headroom.Violations[0], "disk usage")
+
+	headroom, err = cluster.CheckMigrationHeadroom(ctx, SlotRange{Start: 0, Stop: 9}, 1,
+		HeadroomLimits{MaxDiskUsage: 85, MaxMemoryBytes: 50})
+	require.NoError(t, err)
+	require.Len(t, headroom.Violations, 1)
+	require.Contains(t, headroom.Violations[0], "memory")
+
+	_, err = cluster.CheckMigrationHeadroom(ctx, SlotRange{Start: 0, Stop: 9}, 2, HeadroomLimits{})
+	require.Error(t, err)
+}
@@ -0,0 +1,214 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const (
+	defaultHealthCheckTimeout = 3 * time.Second
+	maxHealthCheckTimeout     = 30 * time.Second
+)
+
+// ErrHealthCheckUnknown is returned if the health check can't tell whether the node is healthy,
+// e.g. the hook is unreachable, so it shouldn't be counted as the node failure.
+var ErrHealthCheckUnknown = errors.New("the health check verdict is unknown")
+
+// healthCheckCommands are the read-only commands allowed in the health check,
+// the health check runs against every node in each probe so it mustn't change the data.
+var healthCheckCommands = map[string]bool{
+	"PING": true, "ECHO": true, "TIME": true, "INFO": true, "DBSIZE": true, "ROLE": true,
+	"GET": true, "EXISTS": true, "TYPE": true, "TTL": true, "STRLEN": true,
+	"HGET": true, "HEXISTS": true, "HLEN": true, "LLEN": true,
+	"SCARD": true, "SISMEMBER": true, "ZCARD": true, "ZSCORE": true,
+}
+
+// healthCheckClient is dedicated to the health check hook, it doesn't follow the redirects
+// so the hook can't bounce the requests of the controller to other addresses, and every phase
+// of the request is bounded in case the hook hangs.
+var healthCheckClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: defaultHealthCheckTimeout}).DialContext,
+		TLSHandshakeTimeout:   defaultHealthCheckTimeout,
+		ResponseHeaderTimeout: maxHealthCheckTimeout,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       time.Minute,
+	},
+	Timeout: maxHealthCheckTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// HealthCheckCommand is the command executed against the node in the health check
+type HealthCheckCommand struct {
+	Args []string `json:"args"`
+	// Role limits the command to the master or slave nodes, it's executed on all nodes if empty
+	Role string `json:"role,omitempty"`
+	// Expect is the expected reply of the command, the reply won't be checked if empty
+	Expect string `json:"expect,omitempty"`
+}
+
+// HealthCheck is the custom health check of the cluster which augments the probe verdict, the node
+// is regarded as failed if the HTTP hook returns 503 or any command fails. The other replies of
+// the hook and the transport errors are unknown verdicts which don't fail the node.
+type HealthCheck struct {
+	// URL is the HTTP hook which will receive the node in the POST body
+	URL       string               `json:"url,omitempty"`
+	Commands  []HealthCheckCommand `json:"commands,omitempty"`
+	TimeoutMs int64                `json:"timeout_ms,omitempty"`
+	// MaxFailovers is the max number of the masters failed by the health check at the same time, the
+	// failures are ignored if more masters failed since the check itself is likely broken, it's 1 if not set.
+	MaxFailovers int `json:"max_failovers,omitempty"`
+}
+
+// healthCheckRequest is the POST body of the HTTP hook
+type healthCheckRequest struct {
+	Cluster string `json:"cluster"`
+	NodeID  string `json:"node_id"`
+	Addr    string `json:"addr"`
+	Role    string `json:"role"`
+}
+
+func (check *HealthCheck) IsEmpty() bool {
+	return check == nil || (check.URL == "" && len(check.Commands) == 0)
+}
+
+func (check *HealthCheck) Validate() error {
+	if check.URL != "" {
+		u, err := url.Parse(check.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: invalid health check url: %s", consts.ErrInvalidArgument, check.URL)
+		}
+	}
+	for i, command := range check.Commands {
+		if len(command.Args) == 0 {
+			return fmt.Errorf("%w: health check command[%d] is empty", consts.ErrInvalidArgument, i)
+		}
+		if !healthCheckCommands[strings.ToUpper(command.Args[0])] {
+			return fmt.Errorf("%w: health check command[%d] %s isn't a read-only command",
+				consts.ErrInvalidArgument, i, command.Args[0])
+		}
+		if command.Role != "" && command.Role != RoleMaster && command.Role != RoleSlave {
+			return fmt.Errorf("%w: invalid role of health check command[%d]: %s", consts.ErrInvalidArgument, i, command.Role)
+		}
+	}
+	if check.TimeoutMs < 0 || time.Duration(check.TimeoutMs)*time.Millisecond > maxHealthCheckTimeout {
+		return fmt.Errorf("%w: health check timeout should be positive and at most %s",
+			consts.ErrInvalidArgument, maxHealthCheckTimeout)
+	}
+	if check.MaxFailovers < 0 {
+		return fmt.Errorf("%w: health check max failovers should be positive", consts.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// GetMaxFailovers returns the max number of the masters which can be failed by the health check at the same time
+func (check *HealthCheck) GetMaxFailovers() int {
+	if check.MaxFailovers > 0 {
+		return check.MaxFailovers
+	}
+	return 1
+}
+
+func (check *HealthCheck) timeout() time.Duration {
+	if check.TimeoutMs > 0 {
+		return time.Duration(check.TimeoutMs) * time.Millisecond
+	}
+	return defaultHealthCheckTimeout
+}
+
+// Check runs the HTTP hook and the commands against the node
+func (check *HealthCheck) Check(ctx context.Context, clusterName string, node Node) error {
+	ctx, cancel := context.WithTimeout(ctx, check.timeout())
+	defer cancel()
+
+	role := RoleSlave
+	if node.IsMaster() {
+		role = RoleMaster
+	}
+	if check.URL != "" {
+		if err := check.callHook(ctx, &healthCheckRequest{
+			Cluster: clusterName,
+			NodeID:  node.ID(),
+			Addr:    node.Addr(),
+			Role:    role,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, command := range check.Commands {
+		if command.Role != "" && command.Role != role {
+			continue
+		}
+		args := make([]interface{}, len(command.Args))
+		for i, arg := range command.Args {
+			args[i] = arg
+		}
+		reply, err := node.Do(ctx, args...)
+		if err != nil {
+			return fmt.Errorf("health check command %v: %w", command.Args, err)
+		}
+		if command.Expect != "" && fmt.Sprint(reply) != command.Expect {
+			return fmt.Errorf("health check command %v: expect %s but got %v", command.Args, command.Expect, reply)
+		}
+	}
+	return nil
+}
+
+// callHook returns ErrHealthCheckUnknown unless the hook replies the verdict,
+// i.e. 2xx for the healthy node and 503 for the unhealthy one.
+func (check *HealthCheck) callHook(ctx context.Context, body *healthCheckRequest) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, check.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := healthCheckClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: health check hook: %w", ErrHealthCheckUnknown, err)
+	}
+	defer rsp.Body.Close()
+	switch {
+	case rsp.StatusCode >= 200 && rsp.StatusCode < 300:
+		return nil
+	case rsp.StatusCode == http.StatusServiceUnavailable:
+		return fmt.Errorf("health check hook: %s", rsp.Status)
+	default:
+		return fmt.Errorf("%w: health check hook: %s", ErrHealthCheckUnknown, rsp.Status)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+func TestHealthCheck(t *testing.T) {
+	require.True(t, (*HealthCheck)(nil).IsEmpty())
+	require.True(t, (&HealthCheck{TimeoutMs: 10}).IsEmpty())
+	require.ErrorIs(t, (&HealthCheck{URL: "127.0.0.1:8080"}).Validate(), consts.ErrInvalidArgument)
+	require.ErrorIs(t, (&HealthCheck{Commands: []HealthCheckCommand{{}}}).Validate(), consts.ErrInvalidArgument)
+	require.ErrorIs(t, (&HealthCheck{Commands: []HealthCheckCommand{{Args: []string{"PING"}, Role: "leader"}}}).Validate(),
+		consts.ErrInvalidArgument)
+	require.ErrorIs(t, (&HealthCheck{Commands: []HealthCheckCommand{{Args: []string{"set", "canary", "1"}}}}).Validate(),
+		consts.ErrInvalidArgument)
+	require.ErrorIs(t, (&HealthCheck{Commands: []HealthCheckCommand{{Args: []string{"FLUSHALL"}}}}).Validate(),
+		consts.ErrInvalidArgument)
+	require.ErrorIs(t, (&HealthCheck{URL: "http://127.0.0.1:8080", TimeoutMs: 60000}).Validate(), consts.ErrInvalidArgument)
+	require.ErrorIs(t, (&HealthCheck{URL: "http://127.0.0.1:8080", MaxFailovers: -1}).Validate(), consts.ErrInvalidArgument)
+	require.Equal(t, 1, (&HealthCheck{}).GetMaxFailovers())
+	require.Equal(t, 2, (&HealthCheck{MaxFailovers: 2}).GetMaxFailovers())
+
+	failedNodes := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+			return
+		}
+		var req healthCheckRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "test-cluster", req.Cluster)
+		if status := failedNodes[req.NodeID]; status != 0 {
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	check := &HealthCheck{
+		URL: server.URL,
+		Commands: []HealthCheckCommand{
+			{Args: []string{"PING"}, Role: RoleMaster, Expect: "PONG"},
+			{Args: []string{"GET", "canary"}, Role: RoleSlave, Expect: "1"},
+		},
+	}
+	require.NoError(t, check.Validate())
+
+	ctx := context.Background()
+	master := NewClusterMockNode()
+	master.SetRole(RoleMaster)
+	master.Replies = map[string]interface{}{"PING": "PONG"}
+	slave := NewClusterMockNode()
+	slave.SetRole(RoleSlave)
+	slave.Replies = map[string]interface{}{"GET": "1"}
+	require.NoError(t, check.Check(ctx, "test-cluster", master))
+	require.NoError(t, check.Check(ctx, "test-cluster", slave))
+
+	slave.Replies["GET"] = errors.New("LOADING")
+	require.Error(t, check.Check(ctx, "test-cluster", slave))
+	slave.Replies["GET"] = "0"
+	require.Error(t, check.Check(ctx, "test-cluster", slave))
+
+	// only 503 is the verdict of the unhealthy node
+	failedNodes[master.ID()] = http.StatusServiceUnavailable
+	err := check.Check(ctx, "test-cluster", master)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrHealthCheckUnknown)
+	failedNodes[master.ID()] = http.StatusInternalServerError
+	require.ErrorIs(t, check.Check(ctx, "test-cluster", master), ErrHealthCheckUnknown)
+
+	// the redirect isn't followed and the unreachable hook is unknown as well
+	delete(failedNodes, master.ID())
+	redirectCheck := &HealthCheck{URL: server.URL + "/redirect"}
+	require.ErrorIs(t, redirectCheck.Check(ctx, "test-cluster", master), ErrHealthCheckUnknown)
+	unreachableCheck := &HealthCheck{URL: "http://127.0.0.1:1/health", TimeoutMs: 100}
+	require.ErrorIs(t, unreachableCheck.Check(ctx, "test-cluster", master), ErrHealthCheckUnknown)
+}
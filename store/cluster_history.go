@@ -0,0 +1,331 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+)
+
+const (
+	clusterHistoryPrefix = "/kvrocks/cluster_history"
+
+	// MaxClusterHistorySize is the number of the latest revisions kept for each cluster
+	MaxClusterHistorySize = 50
+)
+
+const (
+	ChangeShardAdded       = "shard_added"
+	ChangeShardRemoved     = "shard_removed"
+	ChangeNodeAdded        = "node_added"
+	ChangeNodeRemoved      = "node_removed"
+	ChangeRoleChanged      = "role_changed"
+	ChangeAddrChanged      = "addr_changed"
+	ChangeSlotsMoved       = "slots_moved"
+	ChangeMigrationChanged = "migration_changed"
+)
+
+// Change is a topology change between two versions of the cluster
+type Change struct {
+	Type string `json:"type"`
+	// Shard is the index of the changed shard, it's the target shard of the moved slots
+	Shard  int    `json:"shard"`
+	NodeID string `json:"node_id,omitempty"`
+	Addr   string `json:"addr,omitempty"`
+	// From and To are the old and new values, e.g. the roles of the node or
+	// the shard indexes of the moved slots which are empty if no shard owns them.
+	From  string      `json:"from,omitempty"`
+	To    string      `json:"to,omitempty"`
+	Slots []SlotRange `json:"slots,omitempty"`
+}
+
+func (change *Change) String() string {
+	switch change.Type {
+	case ChangeShardAdded:
+		return fmt.Sprintf("shard %d was added", change.Shard)
+	case ChangeShardRemoved:
+		return fmt.Sprintf("shard %d was removed", change.Shard)
+	case ChangeNodeAdded:
+		return fmt.Sprintf("node %s(%s) was added to shard %d as %s", change.NodeID, change.Addr, change.Shard, change.To)
+	case ChangeNodeRemoved:
+		return fmt.Sprintf("node %s(%s) was removed from shard %d", change.NodeID, change.Addr, change.Shard)
+	case ChangeRoleChanged:
+		return fmt.Sprintf("node %s(%s) of shard %d changed the role from %s to %s",
+			change.NodeID, change.Addr, change.Shard, change.From, change.To)
+	case ChangeAddrChanged:
+		return fmt.Sprintf("node %s of shard %d changed the address from %s to %s",
+			change.NodeID, change.Shard, change.From, change.To)
+	case ChangeSlotsMoved:
+		slots := make([]string, 0, len(change.Slots))
+		for i := range change.Slots {
+			slots = append(slots, change.Slots[i].String())
+		}
+		return fmt.Sprintf("slots %s were moved from shard %s to shard %s",
+			strings.Join(slots, ","), orNone(change.From), orNone(change.To))
+	case ChangeMigrationChanged:
+		return fmt.Sprintf("the migrating slot of shard %d changed from %s to %s",
+			change.Shard, orNone(change.From), orNone(change.To))
+	}
+	return change.Type
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// DiffClusters returns the topology changes from the old cluster to the new one,
+// the shards are identified by the indexes and the nodes are identified by the IDs.
+func DiffClusters(oldCluster, newCluster *Cluster) []Change {
+	changes := make([]Change, 0)
+	for i := len(oldCluster.Shards); i < len(newCluster.Shards); i++ {
+		changes = append(changes, Change{Type: ChangeShardAdded, Shard: i})
+	}
+	for i := len(newCluster.Shards); i < len(oldCluster.Shards); i++ {
+		changes = append(changes, Change{Type: ChangeShardRemoved, Shard: i})
+	}
+
+	oldNodes, newNodes := shardNodes(oldCluster), shardNodes(newCluster)
+	for _, id := range sortedNodeIDs(newNodes) {
+		newNode := newNodes[id]
+		oldNode, ok := oldNodes[id]
+		if !ok || oldNode.shard != newNode.shard {
+			changes = append(changes, Change{Type: ChangeNodeAdded, Shard: newNode.shard,
+				NodeID: id, Addr: newNode.Addr(), To: nodeRole(newNode)})
+			continue
+		}
+		if nodeRole(oldNode) != nodeRole(newNode) {
+			changes = append(changes, Change{Type: ChangeRoleChanged, Shard: newNode.shard,
+				NodeID: id, Addr: newNode.Addr(), From: nodeRole(oldNode), To: nodeRole(newNode)})
+		}
+		if oldNode.Addr() != newNode.Addr() {
+			changes = append(changes, Change{Type: ChangeAddrChanged, Shard: newNode.shard,
+				NodeID: id, From: oldNode.Addr(), To: newNode.Addr()})
+		}
+	}
+	for _, id := range sortedNodeIDs(oldNodes) {
+		oldNode := oldNodes[id]
+		if newNode, ok := newNodes[id]; !ok || oldNode.shard != newNode.shard {
+			changes = append(changes, Change{Type: ChangeNodeRemoved, Shard: oldNode.shard,
+				NodeID: id, Addr: oldNode.Addr()})
+		}
+	}
+
+	changes = append(changes, diffSlotOwners(slotOwners(oldCluster), slotOwners(newCluster))...)
+	for i := 0; i < len(oldCluster.Shards) && i < len(newCluster.Shards); i++ {
+		from, to := oldCluster.Shards[i].MigratingSlot.String(), newCluster.Shards[i].MigratingSlot.String()
+		if from != to {
+			changes = append(changes, Change{Type: ChangeMigrationChanged, Shard: i, From: from, To: to})
+		}
+	}
+	return changes
+}
+
+type shardNode struct {
+	Node
+	shard int
+}
+
+func shardNodes(cluster *Cluster) map[string]shardNode {
+	nodes := make(map[string]shardNode)
+	for i, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			nodes[node.ID()] = shardNode{Node: node, shard: i}
+		}
+	}
+	return nodes
+}
+
+func sortedNodeIDs(nodes map[string]shardNode) []string {
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if nodes[ids[i]].shard != nodes[ids[j]].shard {
+			return nodes[ids[i]].shard < nodes[ids[j]].shard
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+func nodeRole(node Node) string {
+	if node.IsMaster() {
+		return RoleMaster
+	}
+	return RoleSlave
+}
+
+// slotOwners returns the index of the shard which owns each slot, it's -1 if no shard owns it
+func slotOwners(cluster *Cluster) []int {
+	owners := make([]int, MaxSlotID+1)
+	for i := range owners {
+		owners[i] = -1
+	}
+	for i, shard := range cluster.Shards {
+		for _, slotRange := range shard.SlotRanges {
+			for slot := max(slotRange.Start, MinSlotID); slot <= min(slotRange.Stop, MaxSlotID); slot++ {
+				owners[slot] = i
+			}
+		}
+	}
+	return owners
+}
+
+// diffSlotOwners groups the slots whose owners were changed by the old and new owners
+func diffSlotOwners(oldOwners, newOwners []int) []Change {
+	changes := make([]Change, 0)
+	indexes := make(map[[2]int]int)
+	for slot := MinSlotID; slot <= MaxSlotID; slot++ {
+		from, to := oldOwners[slot], newOwners[slot]
+		if from == to {
+			continue
+		}
+		index, ok := indexes[[2]int{from, to}]
+		if !ok {
+			index = len(changes)
+			indexes[[2]int{from, to}] = index
+			change := Change{Type: ChangeSlotsMoved, Shard: to, From: shardIndexString(from), To: shardIndexString(to)}
+			if to < 0 {
+				change.Shard = from
+			}
+			changes = append(changes, change)
+		}
+		slots := changes[index].Slots
+		if len(slots) > 0 && slots[len(slots)-1].Stop == slot-1 {
+			slots[len(slots)-1].Stop = slot
+		} else {
+			changes[index].Slots = append(slots, SlotRange{Start: slot, Stop: slot})
+		}
+	}
+	return changes
+}
+
+func shardIndexString(index int) string {
+	if index < 0 {
+		return ""
+	}
+	return strconv.Itoa(index)
+}
+
+// ClusterRevision is the topology changes which bumped the cluster to the version
+type ClusterRevision struct {
+	Version   int64    `json:"version"`
+	UpdatedAt int64    `json:"updated_at"`
+	Changes   []Change `json:"changes"`
+	// FailoverHooks are the results of the failover hooks invoked after the new master was promoted
+	FailoverHooks []FailoverHookResult `json:"failover_hooks,omitempty"`
+}
+
+// Summary returns the human-readable changes of the revision
+func (revision *ClusterRevision) Summary() []string {
+	summary := make([]string, 0, len(revision.Changes))
+	for i := range revision.Changes {
+		summary = append(summary, revision.Changes[i].String())
+	}
+	return summary
+}
+
+func buildClusterHistoryKey(ns, cluster string) string {
+	return fmt.Sprintf("%s/%s/%s", clusterHistoryPrefix, ns, cluster)
+}
+
+// ListClusterRevisions returns the latest revisions of the cluster, the newest revision comes first
+func (s *ClusterStore) ListClusterRevisions(ctx context.Context, ns, cluster string) ([]*ClusterRevision, error) {
+	value, err := s.e.Get(ctx, buildClusterHistoryKey(ns, cluster))
+	if errors.Is(err, consts.ErrNotFound) {
+		return []*ClusterRevision{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var revisions []*ClusterRevision
+	if err := json.Unmarshal(value, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetClusterRevision returns the revision of the cluster version
+func (s *ClusterStore) GetClusterRevision(ctx context.Context, ns, cluster string, version int64) (*ClusterRevision, error) {
+	revisions, err := s.ListClusterRevisions(ctx, ns, cluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, revision := range revisions {
+		if revision.Version == version {
+			return revision, nil
+		}
+	}
+	return nil, &consts.NotFoundError{Resource: "revision", Key: strconv.FormatInt(version, 10)}
+}
+
+// saveClusterRevision records the changes from the old cluster to the new one,
+// only the latest MaxClusterHistorySize revisions will be kept.
+func (s *ClusterStore) saveClusterRevision(ctx context.Context, ns string, oldCluster, newCluster *Cluster) (*ClusterRevision, error) {
+	revision := &ClusterRevision{
+		Version:   newCluster.Version.Load(),
+		UpdatedAt: time.Now().Unix(),
+		Changes:   DiffClusters(oldCluster, newCluster),
+	}
+	revisions, err := s.ListClusterRevisions(ctx, ns, newCluster.Name)
+	if err != nil {
+		return revision, err
+	}
+	revisions = append([]*ClusterRevision{revision}, revisions...)
+	if len(revisions) > MaxClusterHistorySize {
+		revisions = revisions[:MaxClusterHistorySize]
+	}
+	value, err := json.Marshal(revisions)
+	if err != nil {
+		return revision, err
+	}
+	return revision, s.e.Set(ctx, buildClusterHistoryKey(ns, newCluster.Name), value)
+}
+
+// tryRecordClusterRevision saves the revision of the updated cluster and logs its changes,
+// the cluster was updated already so the failure is logged only.
+func (s *ClusterStore) tryRecordClusterRevision(ctx context.Context, ns string, oldCluster, newCluster *Cluster) {
+	log := logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", newCluster.Name),
+		zap.Int64("version", newCluster.Version.Load()))
+	revision, err := s.saveClusterRevision(ctx, ns, oldCluster, newCluster)
+	if err != nil {
+		log.Warn("Failed to save the revision of the cluster", zap.Error(err))
+	}
+	if len(revision.Changes) > 0 {
+		log.With(zap.Strings("changes", revision.Summary())).Info("Changed the cluster topology")
+	}
+}
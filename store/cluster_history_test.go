@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestDiffClusters(t *testing.T) {
+	oldCluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222", "127.0.0.1:3333", "127.0.0.1:4444"}, 2)
+	require.NoError(t, err)
+	require.Empty(t, DiffClusters(oldCluster, oldCluster.Clone()))
+
+	newCluster := oldCluster.Clone()
+	oldMaster := newCluster.Shards[0].Nodes[0]
+	newMaster := newCluster.Shards[0].Nodes[1]
+	oldMaster.SetRole(RoleSlave)
+	newMaster.SetRole(RoleMaster)
+	newCluster.Shards[0].SlotRanges = SlotRanges{{Start: 0, Stop: 8189}}
+	newCluster.Shards[1].SlotRanges = SlotRanges{{Start: 8190, Stop: MaxSlotID}}
+	newCluster.Shards[1].MigratingSlot = FromSlotRange(SlotRange{Start: 100, Stop: 100})
+	removedNode := newCluster.Shards[1].Nodes[1]
+	newCluster.Shards[1].Nodes = newCluster.Shards[1].Nodes[:1]
+	newCluster.Shards = append(newCluster.Shards, NewShard())
+
+	changes := DiffClusters(oldCluster, newCluster)
+	require.ElementsMatch(t, []Change{
+		{Type: ChangeShardAdded, Shard: 2},
+		{Type: ChangeRoleChanged, Shard: 0, NodeID: oldMaster.ID(), Addr: oldMaster.Addr(), From: RoleMaster, To: RoleSlave},
+		{Type: ChangeRoleChanged, Shard: 0, NodeID: newMaster.ID(), Addr: newMaster.Addr(), From: RoleSlave, To: RoleMaster},
+		{Type: ChangeNodeRemoved, Shard: 1, NodeID: removedNode.ID(), Addr: removedNode.Addr()},
+		{Type: ChangeSlotsMoved, Shard: 1, From: "0", To: "1", Slots: []SlotRange{{Start: 8190, Stop: 8191}}},
+		{Type: ChangeMigrationChanged, Shard: 1, To: "100"},
+	}, changes)
+	revision := &ClusterRevision{Changes: changes}
+	require.Contains(t, revision.Summary(), "slots 8190-8191 were moved from shard 0 to shard 1")
+	require.Contains(t, revision.Summary(), "the migrating slot of shard 1 changed from none to 100")
+}
+
+func TestClusterStore_ClusterRevisions(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+
+	_, err = cluster.AddNode(0, "127.0.0.1:3333", RoleSlave, "")
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateCluster(ctx, "ns", cluster))
+	revision, err := s.GetClusterRevision(ctx, "ns", "cluster", cluster.Version.Load())
+	require.NoError(t, err)
+	require.Len(t, revision.Changes, 1)
+	require.Equal(t, ChangeNodeAdded, revision.Changes[0].Type)
+	require.Equal(t, "127.0.0.1:3333", revision.Changes[0].Addr)
+
+	for i := 0; i < MaxClusterHistorySize; i++ {
+		require.NoError(t, s.UpdateCluster(ctx, "ns", cluster))
+	}
+	revisions, err := s.ListClusterRevisions(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	require.Len(t, revisions, MaxClusterHistorySize)
+	require.Equal(t, cluster.Version.Load(), revisions[0].Version)
+	require.Empty(t, revisions[0].Changes)
+	_, err = s.GetClusterRevision(ctx, "ns", "cluster", 2)
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	require.NoError(t, s.RemoveCluster(ctx, "ns", "cluster"))
+	revisions, err = s.ListClusterRevisions(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	require.Empty(t, revisions)
+}
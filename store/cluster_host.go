@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// LabelHost is the node label to identify the physical host or VM of the node,
+// it takes precedence over the IP of the node address when detecting the shared hosts.
+const LabelHost = "host"
+
+// nodeHost returns the identifier of the host where the node resides
+func nodeHost(node Node) string {
+	if clusterNode, ok := node.(*ClusterNode); ok {
+		if host := clusterNode.Labels()[LabelHost]; host != "" {
+			return host
+		}
+	}
+	host, _, err := net.SplitHostPort(node.Addr())
+	if err != nil {
+		return node.Addr()
+	}
+	return host
+}
+
+// checkSharedHosts finds the nodes in the same shard which reside on the same host,
+// the failover can't help if the host of the master and its replicas went down.
+func (cluster *Cluster) checkSharedHosts(report *CheckReport) {
+	for i, shard := range cluster.Shards {
+		hostNodes := make(map[string][]Node)
+		for _, node := range shard.Nodes {
+			host := nodeHost(node)
+			hostNodes[host] = append(hostNodes[host], node)
+		}
+		hosts := make([]string, 0, len(hostNodes))
+		for host := range hostNodes {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			nodes := hostNodes[host]
+			if len(nodes) < 2 {
+				continue
+			}
+			addrs := make([]string, 0, len(nodes))
+			hasMaster := false
+			for _, node := range nodes {
+				addrs = append(addrs, node.Addr())
+				hasMaster = hasMaster || node.IsMaster()
+			}
+			finding := CheckFinding{
+				Severity: CheckSeverityInfo,
+				Code:     "shared_host",
+				Shard:    i,
+				Message:  fmt.Sprintf("replicas [%s] reside on the same host %s", strings.Join(addrs, ","), host),
+			}
+			if hasMaster {
+				finding.Severity = CheckSeverityWarning
+				finding.Message = fmt.Sprintf("master and replicas [%s] reside on the same host %s, "+
+					"the failover can't survive the host failure", strings.Join(addrs, ","), host)
+			}
+			report.add(finding)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_CheckSharedHosts(t *testing.T) {
+	cluster, err := NewCluster("test-cluster",
+		[]string{"10.0.0.1:6666", "10.0.0.2:6666", "10.0.0.3:6666", "10.0.0.4:6666", "10.0.0.5:6666", "10.0.0.5:6667"}, 3)
+	require.NoError(t, err)
+	report := &CheckReport{Healthy: true}
+	cluster.checkSharedHosts(report)
+	require.True(t, report.Healthy)
+	require.Len(t, report.Findings, 1)
+	require.Equal(t, CheckSeverityInfo, report.Findings[0].Severity)
+	require.Equal(t, 1, report.Findings[0].Shard)
+	require.Contains(t, report.Findings[0].Message, "10.0.0.5")
+
+	// the host label takes precedence over the address
+	_, err = cluster.UpdateNodeLabels(0, cluster.Shards[0].Nodes[0].ID(), map[string]string{LabelHost: "vm-1"})
+	require.NoError(t, err)
+	_, err = cluster.UpdateNodeLabels(0, cluster.Shards[0].Nodes[2].ID(), map[string]string{LabelHost: "vm-1"})
+	require.NoError(t, err)
+	report = &CheckReport{Healthy: true}
+	cluster.checkSharedHosts(report)
+	require.True(t, report.Healthy)
+	require.Len(t, report.Findings, 2)
+	require.Equal(t, "shared_host", report.Findings[0].Code)
+	require.Equal(t, CheckSeverityWarning, report.Findings[0].Severity)
+	require.Contains(t, report.Findings[0].Message, "vm-1")
+
+	_, err = cluster.UpdateNodeLabels(0, cluster.Shards[0].Nodes[0].ID(), map[string]string{LabelHost: ""})
+	require.NoError(t, err)
+	require.Empty(t, cluster.Shards[0].Nodes[0].(*ClusterNode).Labels())
+	_, err = cluster.UpdateNodeLabels(0, "not-exists", map[string]string{LabelHost: "vm-1"})
+	require.Error(t, err)
+}
@@ -0,0 +1,218 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+const (
+	jobPrefix = "/kvrocks/jobs"
+
+	// MaxJobHistorySize is the number of the latest jobs kept for each cluster and job type
+	MaxJobHistorySize = 20
+)
+
+const (
+	JobTypeCompaction = "compaction"
+	JobTypeBackup     = "backup"
+	JobTypeMigration  = "migration"
+	// JobTypeReplicaSync tracks the initial replication sync of the added replica
+	JobTypeReplicaSync = "replica_sync"
+)
+
+// jobTypes are used to remove the job histories of the removed cluster
+var jobTypes = []string{JobTypeCompaction, JobTypeBackup, JobTypeMigration, JobTypeReplicaSync}
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+
+	JobTaskStatusPending = "pending"
+	JobTaskStatusSkipped = "skipped"
+)
+
+// JobTask is the result of the job on a single node
+type JobTask struct {
+	ShardIndex int    `json:"shard_index"`
+	NodeID     string `json:"node_id"`
+	Addr       string `json:"addr"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  int64  `json:"started_at,omitempty"`
+	FinishedAt int64  `json:"finished_at,omitempty"`
+	ElapsedMs  int64  `json:"elapsed_ms,omitempty"`
+}
+
+// Job is the maintenance job which is run by the controller against the nodes of the cluster
+type Job struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	StartedAt  int64      `json:"started_at"`
+	FinishedAt int64      `json:"finished_at,omitempty"`
+	Tasks      []*JobTask `json:"tasks"`
+	// Error is the failure reason of the job which isn't run against the nodes
+	Error       string          `json:"error,omitempty"`
+	Migration   *JobMigration   `json:"migration,omitempty"`
+	ReplicaSync *JobReplicaSync `json:"replica_sync,omitempty"`
+}
+
+// Start marks the task as running and returns the start time
+func (task *JobTask) Start() time.Time {
+	now := time.Now()
+	task.Status = JobStatusRunning
+	task.StartedAt = now.Unix()
+	return now
+}
+
+// Finish records the result of the task which was started at the start time
+func (task *JobTask) Finish(start time.Time, err error) {
+	now := time.Now()
+	task.FinishedAt = now.Unix()
+	task.ElapsedMs = now.Sub(start).Milliseconds()
+	if err != nil {
+		task.Status = JobStatusFailed
+		task.Error = err.Error()
+		return
+	}
+	task.Status = JobStatusSucceeded
+}
+
+// Finish marks the job as failed if any task failed, otherwise succeeded
+func (job *Job) Finish() {
+	job.Status = JobStatusSucceeded
+	for _, task := range job.Tasks {
+		if task.Status == JobStatusFailed {
+			job.Status = JobStatusFailed
+			break
+		}
+	}
+	job.FinishedAt = time.Now().Unix()
+}
+
+// IsFinished returns whether the job was finished, no matter succeeded or not
+func (job *Job) IsFinished() bool {
+	return job.Status != JobStatusQueued && job.Status != JobStatusRunning
+}
+
+func buildJobKey(ns, cluster, jobType string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", jobPrefix, ns, cluster, jobType)
+}
+
+// GetJob returns the job of the cluster with the job type and ID
+func (s *ClusterStore) GetJob(ctx context.Context, ns, cluster, jobType, id string) (*Job, error) {
+	jobs, err := s.ListJobs(ctx, ns, cluster, jobType)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return nil, &consts.NotFoundError{Resource: jobType, Key: id}
+}
+
+// ListJobs returns the latest jobs of the cluster with the job type, the newest job comes first.
+func (s *ClusterStore) ListJobs(ctx context.Context, ns, cluster, jobType string) ([]*Job, error) {
+	value, err := s.e.Get(ctx, buildJobKey(ns, cluster, jobType))
+	if errors.Is(err, consts.ErrNotFound) {
+		return []*Job{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var jobs []*Job
+	if err := json.Unmarshal(value, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// UpdateJob changes the job with the ID under the job lock of the cluster, so the concurrent updates
+// can't overwrite each other. The job is saved only if the update returns true, and it's returned anyway.
+func (s *ClusterStore) UpdateJob(ctx context.Context, ns, cluster, jobType, id string, update func(job *Job) bool) (*Job, error) {
+	unlock := s.jobLocks.Lock(ns, cluster)
+	defer unlock()
+
+	job, err := s.GetJob(ctx, ns, cluster, jobType, id)
+	if err != nil {
+		return nil, err
+	}
+	if !update(job) {
+		return job, nil
+	}
+	return job, s.saveJob(ctx, ns, cluster, job)
+}
+
+// SaveJob inserts or replaces the job with the same ID, only the latest
+// MaxJobHistorySize jobs will be kept.
+func (s *ClusterStore) SaveJob(ctx context.Context, ns, cluster string, job *Job) error {
+	// all jobs of the same type are kept in one key, so the read-modify-write
+	// must be serialized to avoid losing the concurrent updates
+	unlock := s.jobLocks.Lock(ns, cluster)
+	defer unlock()
+	return s.saveJob(ctx, ns, cluster, job)
+}
+
+func (s *ClusterStore) saveJob(ctx context.Context, ns, cluster string, job *Job) error {
+	jobs, err := s.ListJobs(ctx, ns, cluster, job.Type)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, existing := range jobs {
+		if existing.ID == job.ID {
+			jobs[i] = job
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		jobs = append([]*Job{job}, jobs...)
+	}
+	if len(jobs) > MaxJobHistorySize {
+		jobs = jobs[:MaxJobHistorySize]
+	}
+	value, err := json.Marshal(jobs)
+	if err != nil {
+		return err
+	}
+	return s.e.Set(ctx, buildJobKey(ns, cluster, job.Type), value)
+}
+
+// removeJobsOps returns the deletes of the job histories of the cluster, the caller should hold the job lock
+func removeJobsOps(ns, cluster string) []engine.Op {
+	ops := make([]engine.Op, 0, len(jobTypes))
+	for _, jobType := range jobTypes {
+		ops = append(ops, engine.OpDelete(buildJobKey(ns, cluster, jobType)))
+	}
+	return ops
+}
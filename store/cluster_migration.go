@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// JobMigration is the slot migration of the migration job
+type JobMigration struct {
+	Slot        SlotRange `json:"slot"`
+	SourceShard int       `json:"source_shard"`
+	TargetShard int       `json:"target_shard"`
+	// Verification is the latest result of verifying the migrated slot
+	Verification *JobMigrationVerification `json:"verification,omitempty"`
+}
+
+// JobMigrationVerification is the result of counting the keys left in the migrated slot
+// on the source node before committing the slot move in the metadata.
+type JobMigrationVerification struct {
+	CheckedSlots  int   `json:"checked_slots"`
+	LeftoverCount int64 `json:"leftover_count"`
+	// LeftoverKeys are the sampled keys which are still in the slot of the source node
+	LeftoverKeys []string `json:"leftover_keys,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	// Attempts is the number of the verifications of the migration so far
+	Attempts   int   `json:"attempts"`
+	VerifiedAt int64 `json:"verified_at"`
+}
+
+// Passed returns true if no key was left in the migrated slot of the source node
+func (verification *JobMigrationVerification) Passed() bool {
+	return verification.Error == "" && verification.LeftoverCount == 0
+}
+
+var migrationSeq atomic.Int64
+
+// newMigrationID returns the unique ID of the migration, the sequence avoids the
+// conflicts of the migrations which were started within the same millisecond.
+func newMigrationID() string {
+	return strconv.FormatInt(time.Now().UnixMilli(), 10) + "-" + strconv.FormatInt(migrationSeq.Add(1), 10)
+}
+
+// NewMigrationJob returns the queued migration job of the shard which is migrating the slot,
+// it returns nil if the shard isn't migrating.
+func (cluster *Cluster) NewMigrationJob(shardIndex int) *Job {
+	shard, err := cluster.GetShard(shardIndex)
+	if err != nil || !shard.IsMigrating() || shard.MigrationID == "" {
+		return nil
+	}
+	return &Job{
+		ID:        shard.MigrationID,
+		Type:      JobTypeMigration,
+		Status:    JobStatusQueued,
+		StartedAt: time.Now().Unix(),
+		Tasks:     []*JobTask{},
+		Migration: &JobMigration{
+			Slot:        shard.MigratingSlot.SlotRange,
+			SourceShard: shardIndex,
+			TargetShard: shard.TargetShardIndex,
+		},
+	}
+}
+
+// MigratingShardIndex returns the index of the shard which is migrating the slot, or -1 if not found
+func (cluster *Cluster) MigratingShardIndex(slot SlotRange) int {
+	for i, shard := range cluster.Shards {
+		if shard.IsMigrating() && shard.MigratingSlot.Equal(slot) {
+			return i
+		}
+	}
+	return -1
+}
+
+// FinishMigration marks the migration job as succeeded, or failed with the reason if it's not empty
+func (job *Job) FinishMigration(reason string) {
+	job.Status = JobStatusSucceeded
+	if reason != "" {
+		job.Status = JobStatusFailed
+		job.Error = reason
+	}
+	job.FinishedAt = time.Now().Unix()
+}
+
+// CancelMigration marks the migration job as cancelled with the reason
+func (job *Job) CancelMigration(reason string) {
+	job.Status = JobStatusCancelled
+	job.Error = reason
+	job.FinishedAt = time.Now().Unix()
+}
+
+// CancelMigration clears the migrating state of the shard which is running the migration with
+// the ID, the pending slots of the splitting or merging shard are dropped as well, so the slots
+// stay in the source shard. It returns the index of the source shard. The cancel is refused if
+// the source master is moving the slot or has moved it, since the move can't be stopped.
+func (cluster *Cluster) CancelMigration(ctx context.Context, id string) (int, error) {
+	for i, shard := range cluster.Shards {
+		if shard.IsMigrating() && shard.MigrationID == id {
+			if err := checkMigrationCancelable(ctx, shard); err != nil {
+				return -1, err
+			}
+			shard.ClearMigrateState()
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: the migration %s isn't in progress", consts.ErrConflict, id)
+}
+
+func checkMigrationCancelable(ctx context.Context, shard *Shard) error {
+	source := shard.GetMasterNode()
+	if source == nil {
+		return nil
+	}
+	info, err := source.GetClusterInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get the migrating state of the source node: %s", consts.ErrUnavailable, err)
+	}
+	// the source node hasn't started moving the slot
+	if info.MigratingSlot == nil || !info.MigratingSlot.Equal(shard.MigratingSlot.SlotRange) {
+		return nil
+	}
+	switch info.MigratingState {
+	case "start":
+		return fmt.Errorf("%w: the source node is moving the slot %s, it can't be cancelled until the move is finished",
+			consts.ErrConflict, shard.MigratingSlot.String())
+	case "success":
+		return fmt.Errorf("%w: the source node has moved the slot %s, it will be finalized by the controller",
+			consts.ErrConflict, shard.MigratingSlot.String())
+	}
+	return nil
+}
+
+// VerifyMigratedSlot counts the keys left in each slot of the migrated range on the source node by
+// CLUSTER COUNTKEYSINSLOT, and samples at most sampleKeys of the leftover keys by CLUSTER GETKEYSINSLOT.
+func VerifyMigratedSlot(ctx context.Context, source Node, slot SlotRange, sampleKeys int) *JobMigrationVerification {
+	verification := &JobMigrationVerification{VerifiedAt: time.Now().Unix()}
+	for slotID := slot.Start; slotID <= slot.Stop; slotID++ {
+		reply, err := source.Do(ctx, "CLUSTER", "COUNTKEYSINSLOT", slotID)
+		if err != nil {
+			verification.Error = fmt.Sprintf("failed to count the keys of slot %d on the source node: %s", slotID, err)
+			return verification
+		}
+		count, ok := reply.(int64)
+		if !ok {
+			verification.Error = fmt.Sprintf("unexpected COUNTKEYSINSLOT reply of slot %d: %v", slotID, reply)
+			return verification
+		}
+		verification.CheckedSlots++
+		if count == 0 {
+			continue
+		}
+		verification.LeftoverCount += count
+		remaining := int64(sampleKeys - len(verification.LeftoverKeys))
+		if remaining <= 0 {
+			continue
+		}
+		reply, err = source.Do(ctx, "CLUSTER", "GETKEYSINSLOT", slotID, min(count, remaining))
+		if err != nil {
+			verification.Error = fmt.Sprintf("failed to get the keys of slot %d on the source node: %s", slotID, err)
+			return verification
+		}
+		keys, _ := reply.([]interface{})
+		for _, key := range keys {
+			verification.LeftoverKeys = append(verification.LeftoverKeys, fmt.Sprint(key))
+		}
+	}
+	return verification
+}
@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+	"github.com/apache/kvrocks-controller/util"
+)
+
+func TestCluster_NewMigrationJob(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1"}, 1)
+	require.NoError(t, err)
+	slot := SlotRange{Start: 10, Stop: 20}
+	require.Nil(t, cluster.NewMigrationJob(0))
+	require.Equal(t, -1, cluster.MigratingShardIndex(slot))
+
+	cluster.Shards[0].MigratingSlot = FromSlotRange(slot)
+	cluster.Shards[0].TargetShardIndex = 1
+	cluster.Shards[0].MigrationID = newMigrationID()
+	require.NotEqual(t, cluster.Shards[0].MigrationID, newMigrationID())
+	require.Equal(t, 0, cluster.MigratingShardIndex(slot))
+
+	// the migration ID should be kept in the cluster metadata
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	var got Cluster
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, cluster.Shards[0].MigrationID, got.Shards[0].MigrationID)
+	require.Equal(t, cluster.Shards[0].MigrationID, cluster.Clone().Shards[0].MigrationID)
+
+	job := cluster.NewMigrationJob(0)
+	require.NotNil(t, job)
+	require.Equal(t, JobStatusQueued, job.Status)
+	require.Equal(t, &JobMigration{Slot: slot, SourceShard: 0, TargetShard: 1}, job.Migration)
+	require.NoError(t, s.SaveJob(ctx, "ns", cluster.Name, job))
+
+	_, err = s.GetJob(ctx, "ns", cluster.Name, JobTypeMigration, "not-exists")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	job.FinishMigration("the source node failed to migrate the slot")
+	require.NoError(t, s.SaveJob(ctx, "ns", cluster.Name, job))
+	savedJob, err := s.GetJob(ctx, "ns", cluster.Name, JobTypeMigration, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusFailed, savedJob.Status)
+	require.Equal(t, "the source node failed to migrate the slot", savedJob.Error)
+
+	cluster.Shards[0].ClearMigrateState()
+	require.Empty(t, cluster.Shards[0].MigrationID)
+}
+
+func TestCluster_CancelMigration(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1"}, 1)
+	require.NoError(t, err)
+	source := NewClusterMockNode()
+	source.SetRole(RoleMaster)
+	cluster.Shards[0].Nodes = []Node{source}
+	slot := SlotRange{Start: 0, Stop: 0}
+	cluster.Shards[0].MigratingSlot = FromSlotRange(slot)
+	cluster.Shards[0].TargetShardIndex = 1
+	cluster.Shards[0].MigrationID = newMigrationID()
+	cluster.Shards[0].PendingSlots = SlotRanges{{Start: 1, Stop: 100}}
+	job := cluster.NewMigrationJob(0)
+	require.False(t, job.IsFinished())
+
+	_, err = cluster.CancelMigration(ctx, "not-exists")
+	require.ErrorIs(t, err, consts.ErrConflict)
+	// the source node is moving the slot or has moved it
+	source.ClusterInfo.MigratingSlot = FromSlotRange(slot)
+	for _, state := range []string{"start", "success"} {
+		source.ClusterInfo.MigratingState = state
+		_, err = cluster.CancelMigration(ctx, job.ID)
+		require.ErrorIs(t, err, consts.ErrConflict)
+		require.True(t, cluster.Shards[0].IsMigrating())
+	}
+	// the source node failed to move the slot
+	source.ClusterInfo.MigratingState = "fail"
+	shardIndex, err := cluster.CancelMigration(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, shardIndex)
+	require.False(t, cluster.Shards[0].IsMigrating())
+	require.Empty(t, cluster.Shards[0].PendingSlots)
+	require.True(t, cluster.Shards[0].SlotRanges[0].Contains(0))
+
+	job.CancelMigration("cancelled by the user")
+	require.True(t, job.IsFinished())
+	require.Equal(t, JobStatusCancelled, job.Status)
+	require.NotZero(t, job.FinishedAt)
+}
+
+func TestVerifyMigratedSlot(t *testing.T) {
+	ctx := context.Background()
+	slot := SlotRange{Start: 100, Stop: 101}
+	source := NewClusterMockNode()
+
+	source.Replies = map[string]interface{}{"CLUSTER COUNTKEYSINSLOT": int64(0)}
+	verification := VerifyMigratedSlot(ctx, source, slot, 10)
+	require.True(t, verification.Passed())
+	require.Equal(t, 2, verification.CheckedSlots)
+
+	// the leftover keys are counted in the source slot and the sampled keys are bounded
+	source.Replies = map[string]interface{}{
+		"CLUSTER COUNTKEYSINSLOT": int64(2),
+		"CLUSTER GETKEYSINSLOT":   []interface{}{util.SlotTable[100]},
+	}
+	verification = VerifyMigratedSlot(ctx, source, slot, 1)
+	require.False(t, verification.Passed())
+	require.EqualValues(t, 4, verification.LeftoverCount)
+	require.Equal(t, []string{util.SlotTable[100]}, verification.LeftoverKeys)
+
+	source.Replies = map[string]interface{}{"CLUSTER COUNTKEYSINSLOT": redis.ErrClosed}
+	verification = VerifyMigratedSlot(ctx, source, slot, 10)
+	require.False(t, verification.Passed())
+	require.NotEmpty(t, verification.Error)
+
+	source.Replies = map[string]interface{}{"CLUSTER COUNTKEYSINSLOT": "OK"}
+	require.NotEmpty(t, VerifyMigratedSlot(ctx, source, slot, 10).Error)
+}
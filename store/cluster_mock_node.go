@@ -20,7 +20,11 @@
 
 package store
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // ClusterMockNode is a mock implementation of the Node interface,
 // it is used for testing purposes.
@@ -28,6 +32,17 @@ type ClusterMockNode struct {
 	*ClusterNode
 
 	Sequence uint64
+	// StorageInfo is the storage stats returned by GetClusterNodeInfo
+	StorageInfo ClusterNodeInfo
+	// ClusterInfo is the cluster info returned by GetClusterInfo
+	ClusterInfo ClusterInfo
+	// Replies are the replies returned by Do which are keyed by the upper case command name,
+	// or the command and subcommand names separated by a space
+	Replies map[string]interface{}
+	// MigrateErr is returned by MigrateSlot, otherwise the migration is started in the cluster info
+	MigrateErr error
+	// ChangePasswordErr is returned by ChangePassword, otherwise the password is changed
+	ChangePasswordErr error
 }
 
 var _ Node = (*ClusterMockNode)(nil)
@@ -39,17 +54,61 @@ func NewClusterMockNode() *ClusterMockNode {
 }
 
 func (mock *ClusterMockNode) GetClusterNodeInfo(ctx context.Context) (*ClusterNodeInfo, error) {
-	return &ClusterNodeInfo{Sequence: mock.Sequence, Role: mock.role}, nil
+	info := mock.StorageInfo
+	info.Sequence = mock.Sequence
+	info.Role = mock.role
+	return &info, nil
 }
 
 func (mock *ClusterMockNode) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
-	return &ClusterInfo{}, nil
+	info := mock.ClusterInfo
+	return &info, nil
+}
+
+func (mock *ClusterMockNode) ChangeMasterAuth(ctx context.Context, masterAuth string) error {
+	mock.SetMasterAuth(masterAuth)
+	return nil
+}
+
+func (mock *ClusterMockNode) ChangePassword(ctx context.Context, password string) error {
+	if mock.ChangePasswordErr != nil {
+		return mock.ChangePasswordErr
+	}
+	mock.SetPassword(password)
+	return nil
+}
+
+func (mock *ClusterMockNode) MigrateSlot(ctx context.Context, slot SlotRange, targetNodeID string) error {
+	if mock.MigrateErr != nil {
+		return mock.MigrateErr
+	}
+	mock.ClusterInfo.MigratingSlot = FromSlotRange(slot)
+	mock.ClusterInfo.MigratingState = "start"
+	return nil
 }
 
 func (mock *ClusterMockNode) SyncClusterInfo(ctx context.Context, cluster *Cluster) error {
 	return nil
 }
 
-func (mock *ClusterMockNode) Reset(ctx context.Context) error {
+func (mock *ClusterMockNode) Reset(ctx context.Context, flush bool) error {
 	return nil
 }
+
+func (mock *ClusterMockNode) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	name := strings.ToUpper(fmt.Sprint(args[0]))
+	reply := mock.Replies[name]
+	if len(args) > 1 {
+		// the reply of the subcommand is keyed by the command and subcommand, e.g. "CLUSTER COUNTKEYSINSLOT"
+		if subReply, ok := mock.Replies[name+" "+strings.ToUpper(fmt.Sprint(args[1]))]; ok {
+			reply = subReply
+		}
+	}
+	if err, ok := reply.(error); ok {
+		return nil, err
+	}
+	return reply, nil
+}
@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+// DetachShard removes the shard with its nodes and slots from the cluster and returns it,
+// the slots of the shard won't be served by the cluster anymore.
+func (cluster *Cluster) DetachShard(shardIdx int) (*Shard, error) {
+	shard, err := cluster.GetShard(shardIdx)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.CheckShardFrozen(shardIdx); err != nil {
+		return nil, err
+	}
+	if err := cluster.CheckShardRemovable(shardIdx); err != nil {
+		return nil, err
+	}
+	if len(shard.PendingSlots) > 0 {
+		return nil, cluster.newMigrationConflictError(shardIdx)
+	}
+	if len(cluster.Shards) == 1 {
+		return nil, fmt.Errorf("%w: can't detach the last shard of the cluster", consts.ErrInvalidArgument)
+	}
+	cluster.Shards = append(cluster.Shards[:shardIdx], cluster.Shards[shardIdx+1:]...)
+	shard.ClearMigrateState()
+	return shard, nil
+}
+
+// AttachShard appends the shard with its nodes and slots to the cluster and returns its index,
+// the slots of the shard should NOT overlap with the slots of the existing shards.
+func (cluster *Cluster) AttachShard(shard *Shard) (int, error) {
+	if shard.GetMasterNode() == nil {
+		return -1, fmt.Errorf("%w: the shard has no master node", consts.ErrInvalidArgument)
+	}
+	for i, existingShard := range cluster.Shards {
+		for _, slotRange := range shard.SlotRanges {
+			for _, existingRange := range existingShard.SlotRanges {
+				if slotRange.HasOverlap(existingRange) {
+					return -1, fmt.Errorf("%w: the slot range %s overlaps with the slot range %s of shard %d",
+						consts.ErrInvalidArgument, slotRange.String(), existingRange.String(), i)
+				}
+			}
+		}
+	}
+	cluster.Shards = append(cluster.Shards, shard)
+	return len(cluster.Shards) - 1, nil
+}
+
+// MoveShard detaches the shard with its nodes and slots from the source cluster and attaches it to
+// the target cluster in the same namespace, and returns the shard index in the target cluster.
+// The nodes keep their data, so they can't be reset by CLUSTER RESET, instead the version of the
+// target cluster is raised above the source cluster to make the nodes accept its topology.
+// Both clusters are written in one transaction, so the interrupted move never leaves the shard
+// in both clusters or neither. It's rejected if the engine doesn't support the transaction.
+func (s *ClusterStore) MoveShard(ctx context.Context, ns, source string, shardIdx int, target string) (int, error) {
+	if source == target {
+		return -1, fmt.Errorf("%w: the target cluster is the same as the source", consts.ErrInvalidArgument)
+	}
+	txn, ok := s.e.(engine.Transactional)
+	if !ok {
+		return -1, fmt.Errorf("%w: the engine doesn't support the transaction to move the shard", consts.ErrForbidden)
+	}
+	names := []string{source, target}
+	sort.Strings(names)
+	for _, name := range names {
+		unlock := s.locks.Lock(ns, name)
+		defer unlock()
+	}
+
+	sourceCluster, err := s.getClusterWithoutLock(ctx, ns, source)
+	if err != nil {
+		return -1, err
+	}
+	targetCluster, err := s.getClusterWithoutLock(ctx, ns, target)
+	if err != nil {
+		return -1, err
+	}
+	var shard *Shard
+	newSourceCluster, err := sourceCluster.WithUpdate(func(cluster *Cluster) error {
+		shard, err = cluster.DetachShard(shardIdx)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+	targetShardIdx := -1
+	newTargetCluster, err := targetCluster.WithUpdate(func(cluster *Cluster) error {
+		targetShardIdx, err = cluster.AttachShard(shard)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+	if sourceVersion := sourceCluster.Version.Load(); sourceVersion > newTargetCluster.Version.Load() {
+		newTargetCluster.Version.Store(sourceVersion)
+	}
+
+	targetValue, err := prepareClusterUpdate(targetCluster, newTargetCluster)
+	if err != nil {
+		return -1, fmt.Errorf("attach the shard to cluster %s: %w", target, err)
+	}
+	sourceValue, err := prepareClusterUpdate(sourceCluster, newSourceCluster)
+	if err != nil {
+		return -1, fmt.Errorf("detach the shard from cluster %s: %w", source, err)
+	}
+	unlockNodes := s.lockNodeIndex(sourceCluster, targetCluster)
+	defer unlockNodes()
+	targetSetOps, targetClusterOps, targetDeleteOps, err := s.clusterWriteOps(ctx, ns, targetCluster, newTargetCluster, targetValue)
+	if err != nil {
+		return -1, fmt.Errorf("attach the shard to cluster %s: %w", target, err)
+	}
+	sourceSetOps, sourceClusterOps, sourceDeleteOps, err := s.clusterWriteOps(ctx, ns, sourceCluster, newSourceCluster, sourceValue)
+	if err != nil {
+		return -1, fmt.Errorf("detach the shard from cluster %s: %w", source, err)
+	}
+	// the index entries of the moved nodes are re-pointed to the target cluster instead of being removed
+	movedKeys := make(map[string]bool, len(targetSetOps))
+	for _, op := range targetSetOps {
+		movedKeys[op.Key] = true
+	}
+	ops := make([]engine.Op, 0)
+	ops = append(append(ops, targetSetOps...), sourceSetOps...)
+	ops = append(append(ops, targetClusterOps...), sourceClusterOps...)
+	ops = append(ops, targetDeleteOps...)
+	for _, op := range sourceDeleteOps {
+		if !movedKeys[op.Key] {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) > maxTxnOps {
+		return -1, fmt.Errorf("%w: too many nodes to move in one transaction, %d writes exceed the limit %d",
+			consts.ErrForbidden, len(ops), maxTxnOps)
+	}
+	if err := txn.Txn(ctx, ops); err != nil {
+		return -1, fmt.Errorf("move the shard to cluster %s: %w", target, err)
+	}
+	s.finishClusterUpdate(ctx, ns, targetCluster, newTargetCluster, targetValue)
+	s.finishClusterUpdate(ctx, ns, sourceCluster, newSourceCluster, sourceValue)
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", source),
+		zap.Int("shard", shardIdx),
+		zap.String("target_cluster", target),
+		zap.Int("target_shard", targetShardIdx),
+	).Info("Moved the shard to another cluster")
+	return targetShardIdx, nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_MoveShard(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	ns := "ns0"
+
+	source, err := NewCluster("source", []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3", "127.0.0.1:4"}, 2)
+	require.NoError(t, err)
+	source.Version.Store(10)
+	require.NoError(t, s.CreateCluster(ctx, ns, source))
+	full, err := NewCluster("full", []string{"127.0.0.1:5"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, ns, full))
+	targetShard := NewShard()
+	targetMaster := NewClusterNode("127.0.0.1:6", "")
+	targetMaster.SetRole(RoleMaster)
+	targetShard.Nodes = []Node{targetMaster}
+	target := &Cluster{Name: "target", Shards: Shards{targetShard}}
+	target.Version.Store(1)
+	require.NoError(t, s.CreateCluster(ctx, ns, target))
+	movedShard := source.Shards[1]
+
+	t.Run("reject the invalid moves", func(t *testing.T) {
+		_, err := s.MoveShard(ctx, ns, "source", 1, "source")
+		require.ErrorIs(t, err, consts.ErrInvalidArgument)
+		_, err = s.MoveShard(ctx, ns, "source", 2, "target")
+		require.ErrorIs(t, err, consts.ErrIndexOutOfRange)
+		_, err = s.MoveShard(ctx, ns, "source", 1, "not-exists")
+		require.ErrorIs(t, err, consts.ErrNotFound)
+		// the slots of the full cluster overlap with the moved shard
+		_, err = s.MoveShard(ctx, ns, "source", 1, "full")
+		require.ErrorIs(t, err, consts.ErrInvalidArgument)
+		// the last shard can't be detached
+		_, err = s.MoveShard(ctx, ns, "full", 0, "target")
+		require.ErrorIs(t, err, consts.ErrInvalidArgument)
+
+		gotSource, err := s.GetCluster(ctx, ns, "source")
+		require.NoError(t, err)
+		require.Len(t, gotSource.Shards, 2)
+		require.EqualValues(t, 10, gotSource.Version.Load())
+	})
+
+	t.Run("move both clusters atomically", func(t *testing.T) {
+		mock := s.e.(*engine.Mock)
+		// the writes fail after both clusters were read
+		mock.WithFailureRate(engine.MockOpSet, 1, engine.ErrInjected)
+		_, err := s.MoveShard(ctx, ns, "source", 1, "target")
+		require.ErrorIs(t, err, engine.ErrInjected)
+		mock.ResetFaults()
+
+		gotSource, err := s.GetCluster(ctx, ns, "source")
+		require.NoError(t, err)
+		require.Len(t, gotSource.Shards, 2)
+		gotTarget, err := s.GetCluster(ctx, ns, "target")
+		require.NoError(t, err)
+		require.Len(t, gotTarget.Shards, 1)
+
+		nonTxnStore := NewClusterStore(nonTransactionalEngine{mock})
+		_, err = nonTxnStore.MoveShard(ctx, ns, "source", 1, "target")
+		require.ErrorIs(t, err, consts.ErrForbidden)
+	})
+
+	t.Run("move the shard with its nodes and slots", func(t *testing.T) {
+		shardIdx, err := s.MoveShard(ctx, ns, "source", 1, "target")
+		require.NoError(t, err)
+		require.Equal(t, 1, shardIdx)
+
+		gotSource, err := s.GetCluster(ctx, ns, "source")
+		require.NoError(t, err)
+		require.Len(t, gotSource.Shards, 1)
+		require.EqualValues(t, 11, gotSource.Version.Load())
+
+		gotTarget, err := s.GetCluster(ctx, ns, "target")
+		require.NoError(t, err)
+		require.Len(t, gotTarget.Shards, 2)
+		// the version was raised above the source to make the moved nodes accept the topology
+		require.EqualValues(t, 11, gotTarget.Version.Load())
+		require.Equal(t, movedShard.SlotRanges, gotTarget.Shards[1].SlotRanges)
+		require.Equal(t, movedShard.GetMasterNode().ID(), gotTarget.Shards[1].GetMasterNode().ID())
+
+		locations, err := s.ListNodes(ctx)
+		require.NoError(t, err)
+		movedNodes := 0
+		for _, location := range locations {
+			for _, node := range movedShard.Nodes {
+				if location.Addr == node.Addr() {
+					require.Equal(t, "target", location.Cluster)
+					require.Equal(t, 1, location.Shard)
+					movedNodes++
+				}
+			}
+		}
+		require.Equal(t, len(movedShard.Nodes), movedNodes)
+	})
+}
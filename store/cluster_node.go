@@ -57,18 +57,28 @@ var (
 type Node interface {
 	ID() string
 	Password() string
+	MasterAuth() string
 	Addr() string
 	IsMaster() bool
 
 	SetRole(string)
 	SetPassword(string)
-
-	Reset(ctx context.Context) error
+	SetMasterAuth(string)
+	IsRestoring() bool
+	SetRestoring(bool)
+	IsSyncing() bool
+	SetSyncing(bool)
+	ChangeMasterAuth(ctx context.Context, masterAuth string) error
+	ChangePassword(ctx context.Context, password string) error
+	ReleasePassword(password string)
+
+	Reset(ctx context.Context, flush bool) error
 	GetClusterNodeInfo(ctx context.Context) (*ClusterNodeInfo, error)
 	GetClusterInfo(ctx context.Context) (*ClusterInfo, error)
 	SyncClusterInfo(ctx context.Context, cluster *Cluster) error
 	CheckClusterMode(ctx context.Context) (int64, error)
 	MigrateSlot(ctx context.Context, slot SlotRange, NodeID string) error
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
 
 	MarshalJSON() ([]byte, error)
 	UnmarshalJSON(data []byte) error
@@ -77,11 +87,24 @@ type Node interface {
 }
 
 type ClusterNode struct {
-	id        string
-	addr      string
-	role      string
-	password  string
-	createdAt int64
+	id   string
+	addr string
+	// hostname is the original address if the node was added by the DNS name
+	hostname string
+	role     string
+	password string
+	// masterAuth is the masterauth to authenticate with the master if it's
+	// different from the requirepass, it's the same as the password if empty.
+	masterAuth string
+	createdAt  int64
+	// restoring is marked by the operator when the node is restoring from the backup,
+	// the checker won't count its failures or sync the topology to it.
+	restoring bool
+	// syncing is marked when the replica was added and is catching up with the master,
+	// it won't be promoted until the initial replication sync was finished.
+	syncing bool
+	// labels are provided by the operator to describe the node, e.g. the physical host
+	labels map[string]string
 }
 
 type ClusterInfo struct {
@@ -93,6 +116,11 @@ type ClusterInfo struct {
 type ClusterNodeInfo struct {
 	Sequence uint64 `json:"sequence"`
 	Role     string `json:"role"`
+
+	UsedMemoryRSS int64 `json:"used_memory_rss"`
+	UsedDBSize    int64 `json:"used_db_size"`
+	UsedDiskSize  int64 `json:"used_disk_size"`
+	DiskCapacity  int64 `json:"disk_capacity"`
 }
 
 func NewClusterNode(addr, password string) *ClusterNode {
@@ -105,6 +133,22 @@ func NewClusterNode(addr, password string) *ClusterNode {
 	}
 }
 
+// cloneNode returns a copy of the node, so the copy can be changed without
+// affecting the clusters which are sharing the node.
+func cloneNode(node Node) Node {
+	switch n := node.(type) {
+	case *ClusterNode:
+		clone := *n
+		return &clone
+	case *ClusterMockNode:
+		clone := *n
+		clusterNode := *n.ClusterNode
+		clone.ClusterNode = &clusterNode
+		return &clone
+	}
+	return node
+}
+
 func (n *ClusterNode) ID() string {
 	return n.id
 }
@@ -117,10 +161,80 @@ func (n *ClusterNode) SetPassword(password string) {
 	n.password = password
 }
 
+// MasterAuth returns the masterauth of the node, it's the password if the masterauth wasn't set
+func (n *ClusterNode) MasterAuth() string {
+	if n.masterAuth == "" {
+		return n.password
+	}
+	return n.masterAuth
+}
+
+// SetMasterAuth sets the masterauth of the node, it should be called after SetPassword
+// since the masterauth which is the same as the password won't be stored separately.
+func (n *ClusterNode) SetMasterAuth(masterAuth string) {
+	if masterAuth == n.password {
+		masterAuth = ""
+	}
+	n.masterAuth = masterAuth
+}
+
+func (n *ClusterNode) Labels() map[string]string {
+	return n.labels
+}
+
+// UpdateLabels merges the labels into the node, the label will be removed if its value is empty.
+// The labels are copied on write since the node may be shared by the cloned clusters.
+func (n *ClusterNode) UpdateLabels(labels map[string]string) {
+	merged := make(map[string]string, len(n.labels)+len(labels))
+	for key, value := range n.labels {
+		merged[key] = value
+	}
+	for key, value := range labels {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	if len(merged) == 0 {
+		merged = nil
+	}
+	n.labels = merged
+}
+
+func (n *ClusterNode) Hostname() string {
+	return n.hostname
+}
+
+func (n *ClusterNode) SetHostname(hostname string) {
+	n.hostname = hostname
+}
+
+// SetAddr updates the address after the hostname was re-resolved
+func (n *ClusterNode) SetAddr(addr string) {
+	n.addr = addr
+}
+
 func (n *ClusterNode) SetRole(role string) {
 	n.role = role
 }
 
+func (n *ClusterNode) IsRestoring() bool {
+	return n.restoring
+}
+
+func (n *ClusterNode) SetRestoring(restoring bool) {
+	n.restoring = restoring
+}
+
+func (n *ClusterNode) IsSyncing() bool {
+	return n.syncing
+}
+
+func (n *ClusterNode) SetSyncing(syncing bool) {
+	n.syncing = syncing
+}
+
 func (n *ClusterNode) Addr() string {
 	return n.addr
 }
@@ -142,8 +256,18 @@ func (n *ClusterNode) IsMaster() bool {
 	return n.role == RoleMaster
 }
 
+func (n *ClusterNode) clientKey() string {
+	// the address and password are part of the key to avoid reusing the client
+	// with the stale address or password after they were changed.
+	return nodeClientKey(n.id, n.addr, n.password)
+}
+
+func nodeClientKey(id, addr, password string) string {
+	return id + "/" + addr + "/" + password
+}
+
 func (n *ClusterNode) GetClient() *redis.Client {
-	if client, ok := clients.Load(n.ID()); ok {
+	if client, ok := clients.Load(n.clientKey()); ok {
 		if rdsClient, ok := client.(*redis.Client); ok {
 			return rdsClient
 		}
@@ -158,10 +282,33 @@ func (n *ClusterNode) GetClient() *redis.Client {
 		MaxRetries:   -1, // don't retry inside the client
 		MinIdleConns: minIdleConns,
 	})
-	clients.Store(n.ID(), client)
+	client.AddHook(&slowCommandHook{addr: n.addr})
+	clients.Store(n.clientKey(), client)
 	return client
 }
 
+// ConnCount returns the number of the connections in the client pool of the node,
+// it's 0 if the client wasn't created yet.
+func (n *ClusterNode) ConnCount() int {
+	client, ok := clients.Load(n.clientKey())
+	if !ok {
+		return 0
+	}
+	rdsClient, ok := client.(*redis.Client)
+	if !ok {
+		return 0
+	}
+	return int(rdsClient.PoolStats().TotalConns)
+}
+
+// NodeConnCount returns the number of the pooled connections to the node
+func NodeConnCount(node Node) int {
+	if counter, ok := node.(interface{ ConnCount() int }); ok {
+		return counter.ConnCount()
+	}
+	return 0
+}
+
 func (n *ClusterNode) CheckClusterMode(ctx context.Context) (int64, error) {
 	clusterInfo, err := n.GetClusterInfo(ctx)
 	if err != nil {
@@ -228,11 +375,47 @@ func (n *ClusterNode) GetClusterNodeInfo(ctx context.Context) (*ClusterNodeInfo,
 			}
 		case "role":
 			clusterNodeInfo.Role = fields[1]
+		case "used_memory_rss":
+			clusterNodeInfo.UsedMemoryRSS, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "used_db_size":
+			clusterNodeInfo.UsedDBSize, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "used_disk_size":
+			clusterNodeInfo.UsedDiskSize, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "disk_capacity":
+			clusterNodeInfo.DiskCapacity, _ = strconv.ParseInt(fields[1], 10, 64)
 		}
 	}
 	return clusterNodeInfo, nil
 }
 
+// getInfoField returns the field value in the INFO section of the node, it's empty if not found
+func getInfoField(ctx context.Context, node Node, section, field string) (string, error) {
+	fields, err := getInfoFields(ctx, node, section)
+	if err != nil {
+		return "", err
+	}
+	return fields[field], nil
+}
+
+// getInfoFields returns all fields of the INFO section
+func getInfoFields(ctx context.Context, node Node, section string) (map[string]string, error) {
+	reply, err := node.Do(ctx, "INFO", section)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected INFO reply type: %T", reply)
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\r\n") {
+		if key, value, found := strings.Cut(line, ":"); found {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	return fields, nil
+}
+
 func (n *ClusterNode) GetClusterNodesString(ctx context.Context) (string, error) {
 	clusterNodesStr, err := n.GetClient().ClusterNodes(ctx).Result()
 	if err != nil {
@@ -241,47 +424,159 @@ func (n *ClusterNode) GetClusterNodesString(ctx context.Context) (string, error)
 	return strings.TrimRight(clusterNodesStr, "\n"), nil
 }
 
+// SyncClusterInfo syncs the topology to the node, only the moved slot will be synced
+// by CLUSTERX SETSLOT if it's the next version of the last synced topology, otherwise
+// the full topology will be synced by CLUSTERX SETNODES.
 func (n *ClusterNode) SyncClusterInfo(ctx context.Context, cluster *Cluster) error {
-	clusterStr, err := cluster.ToSlotString()
+	return _nodeRetrier.do(ctx, n.addr, isRetryableNodeError, func() error {
+		return n.syncClusterInfo(ctx, cluster)
+	})
+}
+
+func (n *ClusterNode) syncClusterInfo(ctx context.Context, cluster *Cluster) error {
+	topology, err := newSyncedTopology(cluster)
 	if err != nil {
 		return err
 	}
 	redisCli := n.GetClient()
+	if value, ok := syncedTopologies.Load(n.clientKey()); ok {
+		lastTopology, _ := value.(*syncedTopology)
+		if slot, nodeID, ok := lastTopology.incrementalSlot(topology); ok {
+			// fallback to the full sync if the node was reset or doesn't support it
+			if err := redisCli.Do(ctx, "CLUSTERX", "SETSLOT", slot, "NODE", nodeID, topology.version).Err(); err == nil {
+				syncedTopologies.Store(n.clientKey(), topology)
+				return nil
+			}
+		}
+	}
+
+	syncedTopologies.Delete(n.clientKey())
+	clusterStr, err := cluster.ToSlotString()
+	if err != nil {
+		return err
+	}
+	// the role of the node may be changed by the full sync, so the replica must be able to
+	// authenticate with its new master by the distinct masterauth before the topology was changed.
+	if n.masterAuth != "" {
+		if err := redisCli.ConfigSet(ctx, "masterauth", n.masterAuth).Err(); err != nil {
+			return fmt.Errorf("set masterauth: %w", err)
+		}
+	}
 	err = redisCli.Do(ctx, "CLUSTERX", "SETNODEID", n.id).Err()
 	if err != nil {
 		return err
 	}
-	return redisCli.Do(ctx, "CLUSTERX", "SETNODES", clusterStr, cluster.Version.Load()).Err()
+	if err := redisCli.Do(ctx, "CLUSTERX", "SETNODES", clusterStr, topology.version).Err(); err != nil {
+		return err
+	}
+	syncedTopologies.Store(n.clientKey(), topology)
+	return nil
+}
+
+// Do executes the command against the node and returns the reply
+func (n *ClusterNode) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return n.GetClient().Do(ctx, args...).Result()
+}
+
+// Reset issues CLUSTER RESET to make the node forget the cluster topology,
+// the data of the master node will be flushed before resetting if flush is true.
+func (n *ClusterNode) Reset(ctx context.Context, flush bool) error {
+	syncedTopologies.Delete(n.clientKey())
+	if flush && n.IsMaster() {
+		if err := n.GetClient().FlushAll(ctx).Err(); err != nil {
+			return fmt.Errorf("flush all: %w", err)
+		}
+	}
+	if err := n.GetClient().ClusterResetHard(ctx).Err(); err != nil {
+		return fmt.Errorf("cluster reset: %w", err)
+	}
+	return nil
+}
+
+// ChangeMasterAuth sets the masterauth of the node, it's used by the replica to authenticate
+// with its master when the replication link is reconnected.
+func (n *ClusterNode) ChangeMasterAuth(ctx context.Context, masterAuth string) error {
+	if err := n.GetClient().ConfigSet(ctx, "masterauth", masterAuth).Err(); err != nil {
+		return fmt.Errorf("set masterauth: %w", err)
+	}
+	n.SetMasterAuth(masterAuth)
+	return nil
+}
+
+// ChangePassword sets the requirepass of the node to the new password and verifies it
+// with a new client. The client of the old password is kept to roll back the password,
+// and it should be closed by ReleasePassword after the new password was persisted.
+func (n *ClusterNode) ChangePassword(ctx context.Context, password string) error {
+	if err := n.GetClient().ConfigSet(ctx, "requirepass", password).Err(); err != nil {
+		return fmt.Errorf("set requirepass: %w", err)
+	}
+	oldPassword := n.password
+	n.password = password
+	if err := n.GetClient().Ping(ctx).Err(); err != nil {
+		n.password = oldPassword
+		return fmt.Errorf("verify the new password: %w", err)
+	}
+	return nil
 }
 
-func (n *ClusterNode) Reset(ctx context.Context) error {
-	if n.IsMaster() {
-		_ = n.GetClient().FlushAll(ctx).Err()
+// ReleasePassword closes the client of the given password if it's no longer used.
+func (n *ClusterNode) ReleasePassword(password string) {
+	if password == n.password {
+		return
+	}
+	if client, ok := clients.LoadAndDelete(nodeClientKey(n.id, n.addr, password)); ok {
+		if rdsClient, ok := client.(*redis.Client); ok {
+			_ = rdsClient.Close()
+		}
 	}
-	return n.GetClient().ClusterResetHard(ctx).Err()
 }
 
+// MigrateSlot starts migrating the slot to the target node, it's only retried if the connection
+// couldn't be established since the command might be applied even if the reply was lost.
 func (n *ClusterNode) MigrateSlot(ctx context.Context, slot SlotRange, targetNodeID string) error {
-	return n.GetClient().Do(ctx, "CLUSTERX", "MIGRATE", slot.String(), targetNodeID).Err()
+	return _nodeRetrier.do(ctx, n.addr, isDialError, func() error {
+		return n.GetClient().Do(ctx, "CLUSTERX", "MIGRATE", slot.String(), targetNodeID).Err()
+	})
 }
 
 func (n *ClusterNode) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	fields := map[string]interface{}{
 		"id":         n.id,
 		"addr":       n.addr,
 		"role":       n.role,
 		"password":   n.password,
 		"created_at": n.createdAt,
-	})
+	}
+	if n.masterAuth != "" {
+		fields["master_auth"] = n.masterAuth
+	}
+	if n.hostname != "" {
+		fields["hostname"] = n.hostname
+	}
+	if n.restoring {
+		fields["restoring"] = true
+	}
+	if n.syncing {
+		fields["syncing"] = true
+	}
+	if len(n.labels) > 0 {
+		fields["labels"] = n.labels
+	}
+	return json.Marshal(fields)
 }
 
 func (n *ClusterNode) UnmarshalJSON(bytes []byte) error {
 	var data struct {
-		ID        string `json:"id"`
-		Addr      string `json:"addr"`
-		Role      string `json:"role"`
-		Password  string `json:"password"`
-		CreatedAt int64  `json:"created_at"`
+		ID         string            `json:"id"`
+		Addr       string            `json:"addr"`
+		Hostname   string            `json:"hostname"`
+		Role       string            `json:"role"`
+		Password   string            `json:"password"`
+		MasterAuth string            `json:"master_auth"`
+		CreatedAt  int64             `json:"created_at"`
+		Restoring  bool              `json:"restoring"`
+		Syncing    bool              `json:"syncing"`
+		Labels     map[string]string `json:"labels"`
 	}
 	if err := json.Unmarshal(bytes, &data); err != nil {
 		return err
@@ -289,8 +584,13 @@ func (n *ClusterNode) UnmarshalJSON(bytes []byte) error {
 
 	n.id = data.ID
 	n.addr = data.Addr
+	n.hostname = data.Hostname
 	n.role = data.Role
 	n.password = data.Password
+	n.masterAuth = data.MasterAuth
 	n.createdAt = data.CreatedAt
+	n.restoring = data.Restoring
+	n.syncing = data.Syncing
+	n.labels = data.Labels
 	return nil
 }
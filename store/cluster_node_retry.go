@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var ErrNodeCircuitOpen = errors.New("the circuit breaker of the node is open")
+
+const (
+	defaultNodeCommandAttempts = 3
+	defaultNodeCommandBackoff  = 100 * time.Millisecond
+	defaultNodeBreakerFailures = 5
+	defaultNodeBreakerCooldown = 10 * time.Second
+)
+
+// NodeRetryPolicy is the retry and the circuit breaker of the node commands which change the
+// node state like the topology sync and the slot migration, it's NOT used by the probes.
+type NodeRetryPolicy struct {
+	// Attempts is the max attempts of the command, the command isn't retried if it's 1
+	Attempts int
+	// Backoff is the base backoff between the attempts, it's doubled after each attempt with the jitter
+	Backoff time.Duration
+	// BreakerFailures is the consecutive failed commands to open the breaker, 0 means disabled
+	BreakerFailures int
+	// BreakerCooldown is the duration to reject the commands before trying the node again
+	BreakerCooldown time.Duration
+}
+
+func DefaultNodeRetryPolicy() NodeRetryPolicy {
+	return NodeRetryPolicy{
+		Attempts:        defaultNodeCommandAttempts,
+		Backoff:         defaultNodeCommandBackoff,
+		BreakerFailures: defaultNodeBreakerFailures,
+		BreakerCooldown: defaultNodeBreakerCooldown,
+	}
+}
+
+type nodeBreakerState struct {
+	failures int
+	openedAt time.Time
+	// trying is true if the trial command is running after the cooldown
+	trying bool
+}
+
+type nodeRetrier struct {
+	mu       sync.Mutex
+	policy   NodeRetryPolicy
+	breakers map[string]*nodeBreakerState
+	now      func() time.Time
+}
+
+var _nodeRetrier = newNodeRetrier(DefaultNodeRetryPolicy())
+
+func newNodeRetrier(policy NodeRetryPolicy) *nodeRetrier {
+	return &nodeRetrier{
+		policy:   policy,
+		breakers: make(map[string]*nodeBreakerState),
+		now:      time.Now,
+	}
+}
+
+// SetNodeRetryPolicy changes the retry and the circuit breaker of the node commands
+func SetNodeRetryPolicy(policy NodeRetryPolicy) {
+	_nodeRetrier.mu.Lock()
+	defer _nodeRetrier.mu.Unlock()
+	if policy.Attempts < 1 {
+		policy.Attempts = 1
+	}
+	_nodeRetrier.policy = policy
+	_nodeRetrier.breakers = make(map[string]*nodeBreakerState)
+}
+
+// isRetryableNodeError returns true if the error is likely caused by the transient network
+// issue, the error replied by the node and the cancellation of the caller aren't retried.
+func isRetryableNodeError(err error) bool {
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// isDialError returns true if the connection to the node couldn't be established, so the
+// command wasn't sent and it's safe to retry the command which isn't idempotent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// allow returns ErrNodeCircuitOpen if the breaker of the node is open, and lets only one
+// command try the node after the cooldown until its result was recorded.
+func (r *nodeRetrier) allow(addr string) (NodeRetryPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.breakers[addr]
+	if !ok || state.openedAt.IsZero() {
+		return r.policy, nil
+	}
+	if state.trying || r.now().Sub(state.openedAt) < r.policy.BreakerCooldown {
+		return r.policy, fmt.Errorf("%w: %s", ErrNodeCircuitOpen, addr)
+	}
+	state.trying = true
+	return r.policy, nil
+}
+
+func (r *nodeRetrier) record(addr string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !failed {
+		delete(r.breakers, addr)
+		return
+	}
+	if r.policy.BreakerFailures <= 0 {
+		return
+	}
+	state, ok := r.breakers[addr]
+	if !ok {
+		state = &nodeBreakerState{}
+		r.breakers[addr] = state
+	}
+	if state.trying {
+		// the breaker is opened again since the trial command failed
+		state.trying = false
+		state.openedAt = r.now()
+		return
+	}
+	state.failures++
+	if state.failures >= r.policy.BreakerFailures && state.openedAt.IsZero() {
+		state.openedAt = r.now()
+	}
+}
+
+// do runs the command against the node with the retries of the errors which are retryable, the breaker
+// of the node counts the commands which failed after all attempts by the retryable errors.
+func (r *nodeRetrier) do(ctx context.Context, addr string, retryable func(err error) bool, fn func() error) error {
+	policy, err := r.allow(addr)
+	if err != nil {
+		return err
+	}
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !retryable(err) {
+			r.record(addr, false)
+			return err
+		}
+		if attempt >= policy.Attempts {
+			break
+		}
+		// the full jitter spreads the retries of the nodes which failed at the same time
+		var delay time.Duration
+		if backoff > 0 {
+			delay = time.Duration(rand.Int63n(int64(backoff)) + 1)
+		}
+		select {
+		case <-ctx.Done():
+			r.record(addr, true)
+			return err
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+	r.record(addr, true)
+	return err
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeRetrier(t *testing.T) {
+	ctx := context.Background()
+	errNetwork := errors.New("connection reset by peer")
+	retrier := newNodeRetrier(NodeRetryPolicy{
+		Attempts:        3,
+		Backoff:         time.Millisecond,
+		BreakerFailures: 2,
+		BreakerCooldown: time.Minute,
+	})
+	now := time.Now()
+	retrier.now = func() time.Time { return now }
+
+	t.Run("retry the transient errors", func(t *testing.T) {
+		calls := 0
+		err := retrier.do(ctx, "127.0.0.1:6666", isRetryableNodeError, func() error {
+			calls++
+			if calls < 3 {
+				return errNetwork
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("don't retry the replied errors", func(t *testing.T) {
+		calls := 0
+		err := retrier.do(ctx, "127.0.0.1:6666", isRetryableNodeError, func() error {
+			calls++
+			return redis.Nil
+		})
+		require.ErrorIs(t, err, redis.Nil)
+		require.Equal(t, 1, calls)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		calls = 0
+		err = retrier.do(canceled, "127.0.0.1:6666", isRetryableNodeError, func() error {
+			calls++
+			return canceled.Err()
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("open the breaker after the consecutive failures", func(t *testing.T) {
+		addr := "127.0.0.1:7777"
+		calls := 0
+		failing := func() error {
+			calls++
+			return errNetwork
+		}
+		for i := 0; i < 2; i++ {
+			require.ErrorIs(t, retrier.do(ctx, addr, isRetryableNodeError, failing), errNetwork)
+		}
+		require.Equal(t, 6, calls)
+		require.ErrorIs(t, retrier.do(ctx, addr, isRetryableNodeError, failing), ErrNodeCircuitOpen)
+		require.Equal(t, 6, calls)
+		// other nodes won't be affected
+		require.NoError(t, retrier.do(ctx, "127.0.0.1:8888", isRetryableNodeError, func() error { return nil }))
+
+		// the trial command after the cooldown opens the breaker again if it failed
+		now = now.Add(time.Minute)
+		require.ErrorIs(t, retrier.do(ctx, addr, isRetryableNodeError, failing), errNetwork)
+		require.ErrorIs(t, retrier.do(ctx, addr, isRetryableNodeError, failing), ErrNodeCircuitOpen)
+
+		// only one trial command is allowed until its result was recorded
+		now = now.Add(time.Minute)
+		_, err := retrier.allow(addr)
+		require.NoError(t, err)
+		_, err = retrier.allow(addr)
+		require.ErrorIs(t, err, ErrNodeCircuitOpen)
+		retrier.record(addr, false)
+		require.NoError(t, retrier.do(ctx, addr, isRetryableNodeError, func() error { return nil }))
+		require.Empty(t, retrier.breakers)
+	})
+
+	t.Run("retry the non-idempotent command only if it wasn't sent", func(t *testing.T) {
+		calls := 0
+		err := retrier.do(ctx, "127.0.0.1:6666", isDialError, func() error {
+			calls++
+			if calls == 1 {
+				return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+			}
+			return errNetwork
+		})
+		require.ErrorIs(t, err, errNetwork)
+		require.Equal(t, 2, calls)
+	})
+}
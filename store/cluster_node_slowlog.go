@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
+)
+
+// slowCommandThreshold is the threshold in nanoseconds, 0 means disabled.
+var slowCommandThreshold atomic.Int64
+
+// SetSlowCommandThreshold sets the threshold to log the slow node commands,
+// the slow command logging is disabled if it's not positive.
+func SetSlowCommandThreshold(threshold time.Duration) {
+	slowCommandThreshold.Store(int64(threshold))
+}
+
+type commandLabelsKey struct{}
+
+type commandLabels struct {
+	namespace string
+	cluster   string
+}
+
+// WithCommandLabels attaches the namespace and cluster to the context,
+// so that the slow node commands can be attributed to the cluster.
+func WithCommandLabels(ctx context.Context, ns, cluster string) context.Context {
+	return context.WithValue(ctx, commandLabelsKey{}, commandLabels{namespace: ns, cluster: cluster})
+}
+
+// SetCommandLabels attaches the namespace and cluster to the gin context, the request context
+// isn't used since the gin context doesn't fall back to it to avoid canceling the mutations.
+func SetCommandLabels(c interface{ Set(key string, value any) }, ns, cluster string) {
+	c.Set(consts.ContextKeyCommandLabels, commandLabels{namespace: ns, cluster: cluster})
+}
+
+func commandLabelsFromContext(ctx context.Context) commandLabels {
+	if labels, ok := ctx.Value(commandLabelsKey{}).(commandLabels); ok {
+		return labels
+	}
+	// the gin context only looks up the values set by SetCommandLabels with the string key
+	labels, _ := ctx.Value(consts.ContextKeyCommandLabels).(commandLabels)
+	return labels
+}
+
+type commandStartKey struct{}
+
+// slowCommandHook records the topology and info commands which exceed the slow command threshold
+type slowCommandHook struct {
+	addr string
+}
+
+var _ redis.Hook = (*slowCommandHook)(nil)
+
+// slowCommandName returns the name of the command if it should be watched
+func slowCommandName(cmd redis.Cmder) (string, bool) {
+	switch cmd.Name() {
+	case "info":
+		return "INFO", true
+	case "clusterx":
+		args := cmd.Args()
+		if len(args) < 2 {
+			return "", false
+		}
+		subCommand, ok := args[1].(string)
+		if !ok {
+			return "", false
+		}
+		switch subCommand = strings.ToUpper(subCommand); subCommand {
+		case "SETNODES", "SETSLOT", "MIGRATE":
+			return "CLUSTERX " + subCommand, true
+		}
+	}
+	return "", false
+}
+
+func (hook *slowCommandHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if slowCommandThreshold.Load() <= 0 {
+		return ctx, nil
+	}
+	if _, ok := slowCommandName(cmd); !ok {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, commandStartKey{}, time.Now()), nil
+}
+
+func (hook *slowCommandHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	start, ok := ctx.Value(commandStartKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	threshold := time.Duration(slowCommandThreshold.Load())
+	elapsed := time.Since(start)
+	if threshold <= 0 || elapsed < threshold {
+		return nil
+	}
+	name, _ := slowCommandName(cmd)
+	labels := commandLabelsFromContext(ctx)
+	metrics.Get().SlowNodeCommands.WithLabelValues(metrics.ClusterLabelValues(labels.namespace, labels.cluster, hook.addr, name)...).Inc()
+	logger.Get().With(
+		zap.String("namespace", labels.namespace),
+		zap.String("cluster", labels.cluster),
+		zap.String("node", hook.addr),
+		zap.String("command", name),
+		zap.Duration("elapsed", elapsed),
+		zap.Error(cmd.Err()),
+	).Warn("Slow node command")
+	return nil
+}
+
+func (hook *slowCommandHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (hook *slowCommandHook) AfterProcessPipeline(_ context.Context, _ []redis.Cmder) error {
+	return nil
+}
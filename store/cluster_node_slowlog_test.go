@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/metrics"
+)
+
+func TestSlowCommandHook(t *testing.T) {
+	ctx := WithCommandLabels(context.Background(), "test-ns", "test-cluster")
+	for _, args := range [][]interface{}{{"INFO"}, {"clusterx", "setnodes", "", 1}, {"CLUSTERX", "MIGRATE", "1", "id"}} {
+		_, ok := slowCommandName(redis.NewCmd(ctx, args...))
+		require.True(t, ok)
+	}
+	for _, args := range [][]interface{}{{"PING"}, {"CLUSTERX", "SETNODEID", "id"}, {"CLUSTERX"}} {
+		_, ok := slowCommandName(redis.NewCmd(ctx, args...))
+		require.False(t, ok)
+	}
+
+	hook := &slowCommandHook{addr: "127.0.0.1:6666"}
+	counter := metrics.Get().SlowNodeCommands.WithLabelValues("test-ns", "test-cluster", hook.addr, "CLUSTERX SETNODES")
+	runCommand := func() {
+		cmd := redis.NewCmd(ctx, "CLUSTERX", "SETNODES", "", 1)
+		cmdCtx, err := hook.BeforeProcess(ctx, cmd)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, hook.AfterProcess(cmdCtx, cmd))
+	}
+
+	runCommand()
+	require.EqualValues(t, 0, testutil.ToFloat64(counter))
+
+	SetSlowCommandThreshold(time.Millisecond)
+	defer SetSlowCommandThreshold(0)
+	runCommand()
+	require.EqualValues(t, 1, testutil.ToFloat64(counter))
+
+	SetSlowCommandThreshold(time.Hour)
+	runCommand()
+	require.EqualValues(t, 1, testutil.ToFloat64(counter))
+
+	// the gin context doesn't fall back to the request context
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	SetCommandLabels(c, "test-ns", "test-cluster")
+	require.Equal(t, commandLabels{namespace: "test-ns", cluster: "test-cluster"}, commandLabelsFromContext(c))
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// syncedTopologies are the topologies which were synced to the nodes last time,
+// they're keyed by the client key so that the changed node won't reuse them,
+// and deleted once the node or its cluster was removed, see forgetSyncedTopologies.
+var syncedTopologies sync.Map
+
+// forgetSyncedTopologies deletes the synced topologies of the nodes which aren't in the new cluster,
+// including the nodes whose address or password was changed, the new cluster is nil if it was removed.
+func forgetSyncedTopologies(oldCluster, newCluster *Cluster) {
+	if oldCluster == nil {
+		return
+	}
+	keys := make(map[string]bool)
+	for _, shard := range oldCluster.Shards {
+		for _, node := range shard.Nodes {
+			if clusterNode, ok := node.(*ClusterNode); ok {
+				keys[clusterNode.clientKey()] = true
+			}
+		}
+	}
+	if newCluster != nil {
+		for _, shard := range newCluster.Shards {
+			for _, node := range shard.Nodes {
+				if clusterNode, ok := node.(*ClusterNode); ok {
+					delete(keys, clusterNode.clientKey())
+				}
+			}
+		}
+	}
+	for key := range keys {
+		syncedTopologies.Delete(key)
+	}
+}
+
+// syncedTopology is the compact form of the cluster topology to find
+// the slot changes between two versions.
+type syncedTopology struct {
+	version int64
+	// layout is the nodes and their roles without the slots
+	layout string
+	// slots are the slot ranges of the master nodes
+	slots map[string]SlotRanges
+}
+
+func newSyncedTopology(cluster *Cluster) (*syncedTopology, error) {
+	var builder strings.Builder
+	topology := &syncedTopology{
+		version: cluster.Version.Load(),
+		slots:   make(map[string]SlotRanges),
+	}
+	for _, shard := range cluster.Shards {
+		master := shard.GetMasterNode()
+		if master == nil {
+			return nil, errors.New("missing master node")
+		}
+		for _, node := range shard.Nodes {
+			builder.WriteString(node.ID() + " " + node.Addr() + " " + master.ID() + "\n")
+		}
+		topology.slots[master.ID()] = shard.SlotRanges
+	}
+	topology.layout = builder.String()
+	return topology, nil
+}
+
+// diffSlots returns the slot ranges which are in the slot ranges a but not in b
+func diffSlots(a, b SlotRanges) SlotRanges {
+	// the slot range of the shard without slots is [-1, -1]
+	return a.Difference(b).Intersect(SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}})
+}
+
+// incrementalSlot returns the slot and its new owner if the new topology is the next version
+// and only one slot was moved between the masters, so it can be synced by CLUSTERX SETSLOT.
+func (topology *syncedTopology) incrementalSlot(newTopology *syncedTopology) (int, string, bool) {
+	if newTopology.version != topology.version+1 || newTopology.layout != topology.layout {
+		return -1, "", false
+	}
+	var removedSlots, addedSlots SlotRanges
+	newOwner := ""
+	for nodeID, newSlotRanges := range newTopology.slots {
+		oldSlotRanges := topology.slots[nodeID]
+		removedSlots = append(removedSlots, diffSlots(oldSlotRanges, newSlotRanges)...)
+		if added := diffSlots(newSlotRanges, oldSlotRanges); len(added) > 0 {
+			addedSlots = append(addedSlots, added...)
+			newOwner = nodeID
+		}
+		if len(removedSlots) > 1 || len(addedSlots) > 1 {
+			return -1, "", false
+		}
+	}
+	if len(removedSlots) != 1 || len(addedSlots) != 1 || removedSlots[0] != addedSlots[0] ||
+		addedSlots[0].Start != addedSlots[0].Stop {
+		return -1, "", false
+	}
+	return addedSlots[0].Start, newOwner, true
+}
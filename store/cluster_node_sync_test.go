@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestSyncedTopology_IncrementalSlot(t *testing.T) {
+	cluster, err := NewCluster("test", []string{"127.0.0.1:1111", "127.0.0.1:2222", "127.0.0.1:3333"}, 1)
+	require.NoError(t, err)
+	cluster.Version.Store(1)
+	lastTopology, err := newSyncedTopology(cluster)
+	require.NoError(t, err)
+
+	nextTopology := func(update func(cluster *Cluster)) *syncedTopology {
+		cloned := cluster.Clone()
+		update(cloned)
+		cloned.Version.Store(cluster.Version.Load() + 1)
+		topology, err := newSyncedTopology(cloned)
+		require.NoError(t, err)
+		return topology
+	}
+	moveSlot := func(slotRange SlotRange) func(cluster *Cluster) {
+		return func(cluster *Cluster) {
+			cluster.Shards[0].SlotRanges = RemoveSlotFromSlotRanges(cluster.Shards[0].SlotRanges, slotRange)
+			cluster.Shards[1].SlotRanges = AddSlotToSlotRanges(cluster.Shards[1].SlotRanges, slotRange)
+		}
+	}
+
+	slot, nodeID, ok := lastTopology.incrementalSlot(nextTopology(moveSlot(SlotRange{Start: 0, Stop: 0})))
+	require.True(t, ok)
+	require.Equal(t, 0, slot)
+	require.Equal(t, cluster.Shards[1].GetMasterNode().ID(), nodeID)
+
+	// more than one slot was moved
+	_, _, ok = lastTopology.incrementalSlot(nextTopology(moveSlot(SlotRange{Start: 0, Stop: 1})))
+	require.False(t, ok)
+	// nothing was changed
+	_, _, ok = lastTopology.incrementalSlot(nextTopology(func(cluster *Cluster) {}))
+	require.False(t, ok)
+	// the nodes were changed
+	_, _, ok = lastTopology.incrementalSlot(nextTopology(func(cluster *Cluster) {
+		moveSlot(SlotRange{Start: 0, Stop: 0})(cluster)
+		cluster.Shards[2].Nodes = append(cluster.Shards[2].Nodes, NewClusterNode("127.0.0.1:4444", ""))
+	}))
+	require.False(t, ok)
+	// the version is not the next one
+	topology := nextTopology(moveSlot(SlotRange{Start: 0, Stop: 0}))
+	topology.version++
+	_, _, ok = lastTopology.incrementalSlot(topology)
+	require.False(t, ok)
+}
+
+func TestForgetSyncedTopologies(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("test", []string{"127.0.0.1:1111", "127.0.0.1:2222", "127.0.0.1:3333"}, 3)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	nodes := cluster.Shards[0].Nodes
+	for _, node := range nodes {
+		syncedTopologies.Store(node.(*ClusterNode).clientKey(), &syncedTopology{})
+	}
+	isSynced := func(node Node) bool {
+		_, ok := syncedTopologies.Load(node.(*ClusterNode).clientKey())
+		return ok
+	}
+
+	require.NoError(t, cluster.RemoveNode(0, nodes[2].ID()))
+	require.NoError(t, s.UpdateCluster(ctx, "ns", cluster))
+	require.True(t, isSynced(nodes[0]))
+	require.True(t, isSynced(nodes[1]))
+	require.False(t, isSynced(nodes[2]))
+
+	require.NoError(t, s.RemoveCluster(ctx, "ns", "test"))
+	require.False(t, isSynced(nodes[0]))
+	require.False(t, isSynced(nodes[1]))
+}
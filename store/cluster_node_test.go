@@ -21,16 +21,20 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/testutil/kvrockstest"
 )
 
 func TestClusterNode(t *testing.T) {
 	ctx := context.Background()
-	nodeAddr0 := "127.0.0.1:7770"
-	nodeAddr1 := "127.0.0.1:7771"
+	nodeAddrs := kvrockstest.Start(t, 2)
+	nodeAddr0, nodeAddr1 := nodeAddrs[0], nodeAddrs[1]
 	node0 := NewClusterNode(nodeAddr0, "")
 	node1 := NewClusterNode(nodeAddr1, "")
 	redisCli := node0.GetClient()
@@ -93,6 +97,55 @@ func TestClusterNode(t *testing.T) {
 	})
 }
 
+func TestClusterNode_Restoring(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"127.0.0.1:1234", "127.0.0.1:1235"}, 2)
+	require.NoError(t, err)
+	nodeID := cluster.Shards[0].Nodes[1].ID()
+	_, err = cluster.SetNodeRestoring(1, nodeID, true)
+	require.ErrorIs(t, err, consts.ErrIndexOutOfRange)
+	_, err = cluster.SetNodeRestoring(0, "not-exists", true)
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	node, err := cluster.SetNodeRestoring(0, nodeID, true)
+	require.NoError(t, err)
+	require.True(t, node.IsRestoring())
+
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	var restored Cluster
+	require.NoError(t, json.Unmarshal(data, &restored))
+	require.False(t, restored.Shards[0].Nodes[0].IsRestoring())
+	require.True(t, restored.Shards[0].Nodes[1].IsRestoring())
+}
+
+func TestClusterNode_MasterAuth(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"127.0.0.1:1234", "127.0.0.1:1235"}, 2)
+	require.NoError(t, err)
+	cluster.SetPassword("secret")
+	cluster.SetMasterAuth("")
+	for _, node := range cluster.GetNodes() {
+		require.Equal(t, "secret", node.MasterAuth())
+	}
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "master_auth")
+
+	cluster.SetMasterAuth("replication")
+	data, err = json.Marshal(cluster)
+	require.NoError(t, err)
+	var restored Cluster
+	require.NoError(t, json.Unmarshal(data, &restored))
+	for _, node := range restored.GetNodes() {
+		require.Equal(t, "secret", node.Password())
+		require.Equal(t, "replication", node.MasterAuth())
+	}
+
+	// the masterauth which is the same as the password won't be stored separately
+	cluster.SetMasterAuth("secret")
+	data, err = json.Marshal(cluster)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "master_auth")
+}
+
 func TestNodeInfo_Validate(t *testing.T) {
 	node := &ClusterNode{}
 	require.EqualError(t, node.Validate(), "node id shouldn't be empty")
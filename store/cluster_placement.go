@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// LabelZone is the node label to identify the availability zone of the node,
+// the masters without the zone label are ignored by the placement rules.
+const LabelZone = "zone"
+
+// PlacementRules are the constraints of the slot distribution across the zones,
+// they're evaluated by the cluster check and the slot migration.
+type PlacementRules struct {
+	// MaxZoneSlotsPercent is the max percent of the slots served by the masters in the same zone,
+	// e.g. 40 means no zone can serve more than 40% of the slots, 0 means no limit.
+	MaxZoneSlotsPercent float64 `json:"max_zone_slots_percent,omitempty"`
+}
+
+func (rules *PlacementRules) IsEmpty() bool {
+	return rules == nil || rules.MaxZoneSlotsPercent == 0
+}
+
+func (rules *PlacementRules) Validate() error {
+	if rules.MaxZoneSlotsPercent < 0 || rules.MaxZoneSlotsPercent > 100 {
+		return fmt.Errorf("%w: max zone slots percent should be between 0 and 100", consts.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// nodeZone returns the zone of the node, it's empty if the node has no zone label
+func nodeZone(node Node) string {
+	if clusterNode, ok := node.(*ClusterNode); ok {
+		return clusterNode.Labels()[LabelZone]
+	}
+	return ""
+}
+
+// shardZone returns the zone of the master of the shard
+func shardZone(shard *Shard) string {
+	master := shard.GetMasterNode()
+	if master == nil {
+		return ""
+	}
+	return nodeZone(master)
+}
+
+func slotsPercent(slots int) float64 {
+	return float64(slots) * 100 / float64(MaxSlotID+1)
+}
+
+// ZoneSlots returns the number of slots served by the masters in each zone
+func (cluster *Cluster) ZoneSlots() map[string]int {
+	zoneSlots := make(map[string]int)
+	for _, shard := range cluster.Shards {
+		zone := shardZone(shard)
+		if zone == "" {
+			continue
+		}
+		zoneSlots[zone] += SlotRanges(shard.SlotRanges).Count()
+	}
+	return zoneSlots
+}
+
+// PlacementViolations returns the zones which break the placement rules of the cluster
+func (cluster *Cluster) PlacementViolations() []string {
+	if cluster.Placement.IsEmpty() {
+		return nil
+	}
+	zoneSlots := cluster.ZoneSlots()
+	zones := make([]string, 0, len(zoneSlots))
+	for zone := range zoneSlots {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	violations := make([]string, 0)
+	for _, zone := range zones {
+		percent := slotsPercent(zoneSlots[zone])
+		if percent > cluster.Placement.MaxZoneSlotsPercent {
+			violations = append(violations, fmt.Sprintf("zone %s serves %.1f%% of the slots, exceeds the limit %.1f%%",
+				zone, percent, cluster.Placement.MaxZoneSlotsPercent))
+		}
+	}
+	return violations
+}
+
+// CheckMigrationPlacement returns the violations of the placement rules if the slot was migrated
+// to the target shard. Only the zone of the target gains the slots, so the migration is allowed
+// as long as it doesn't push the target zone over the limit, even if other zones are still violating.
+func (cluster *Cluster) CheckMigrationPlacement(slot SlotRange, target int) []string {
+	return cluster.checkSlotsPlacement(SlotRanges{slot}, target)
+}
+
+// CheckPendingPlacement returns the violations of the placement rules if the pending slots
+// of the shard were migrated to its target shard, e.g. after splitting or merging the shard.
+func (cluster *Cluster) CheckPendingPlacement(shardIdx int) []string {
+	shard, err := cluster.GetShard(shardIdx)
+	if err != nil || !shard.HasPendingSlots() {
+		return nil
+	}
+	return cluster.checkSlotsPlacement(shard.PendingSlots, shard.TargetShardIndex)
+}
+
+func (cluster *Cluster) checkSlotsPlacement(slotRanges SlotRanges, target int) []string {
+	if cluster.Placement.IsEmpty() || target < 0 || target >= len(cluster.Shards) {
+		return nil
+	}
+	targetZone := shardZone(cluster.Shards[target])
+	if targetZone == "" {
+		return nil
+	}
+	gained := 0
+	for i, shard := range cluster.Shards {
+		if i == target || shardZone(shard) == targetZone {
+			continue
+		}
+		gained += SlotRanges(shard.SlotRanges).Intersect(slotRanges).Count()
+	}
+	if gained == 0 {
+		return nil
+	}
+	percent := slotsPercent(cluster.ZoneSlots()[targetZone] + gained)
+	if percent <= cluster.Placement.MaxZoneSlotsPercent {
+		return nil
+	}
+	return []string{fmt.Sprintf("zone %s would serve %.1f%% of the slots, exceeds the limit %.1f%%",
+		targetZone, percent, cluster.Placement.MaxZoneSlotsPercent)}
+}
+
+// checkPlacement warns if the slot distribution breaks the placement rules
+func (cluster *Cluster) checkPlacement(report *CheckReport) {
+	for _, violation := range cluster.PlacementViolations() {
+		report.add(CheckFinding{
+			Severity: CheckSeverityWarning,
+			Code:     "placement_violation",
+			Shard:    -1,
+			Message:  violation,
+		})
+	}
+}
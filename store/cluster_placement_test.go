@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacementRules_Validate(t *testing.T) {
+	require.NoError(t, (&PlacementRules{MaxZoneSlotsPercent: 40}).Validate())
+	require.Error(t, (&PlacementRules{MaxZoneSlotsPercent: -1}).Validate())
+	require.Error(t, (&PlacementRules{MaxZoneSlotsPercent: 101}).Validate())
+	require.True(t, (*PlacementRules)(nil).IsEmpty())
+	require.True(t, (&PlacementRules{}).IsEmpty())
+}
+
+func TestCluster_Placement(t *testing.T) {
+	cluster, err := NewCluster("test-cluster",
+		[]string{"10.0.0.1:6666", "10.0.0.2:6666", "10.0.0.3:6666", "10.0.0.4:6666"}, 1)
+	require.NoError(t, err)
+	for i, zone := range []string{"zone-a", "zone-a", "zone-b", ""} {
+		_, err = cluster.UpdateNodeLabels(i, cluster.Shards[i].Nodes[0].ID(), map[string]string{LabelZone: zone})
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[string]int{"zone-a": 8192, "zone-b": 4096}, cluster.ZoneSlots())
+	// no rules, no violations
+	require.Empty(t, cluster.PlacementViolations())
+	require.Empty(t, cluster.CheckMigrationPlacement(SlotRange{Start: 12288, Stop: 12288}, 0))
+
+	cluster.Placement = &PlacementRules{MaxZoneSlotsPercent: 40}
+	violations := cluster.PlacementViolations()
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0], "zone-a")
+	report := &CheckReport{Healthy: true}
+	cluster.checkPlacement(report)
+	require.True(t, report.Healthy)
+	require.Len(t, report.Findings, 1)
+	require.Equal(t, "placement_violation", report.Findings[0].Code)
+	require.Equal(t, CheckSeverityWarning, report.Findings[0].Severity)
+
+	// moving the slots into the violating zone is rejected
+	require.Len(t, cluster.CheckMigrationPlacement(SlotRange{Start: 12288, Stop: 12288}, 0), 1)
+	// moving the slots within the same zone or out of it is allowed
+	require.Empty(t, cluster.CheckMigrationPlacement(SlotRange{Start: 0, Stop: 100}, 1))
+	require.Empty(t, cluster.CheckMigrationPlacement(SlotRange{Start: 0, Stop: 100}, 2))
+	// moving the slots to the shard without the zone is allowed
+	require.Empty(t, cluster.CheckMigrationPlacement(SlotRange{Start: 0, Stop: 100}, 3))
+	// zone-b would exceed the limit with 4096 more slots
+	require.Len(t, cluster.CheckMigrationPlacement(SlotRange{Start: 12288, Stop: 16383}, 2), 1)
+	require.Empty(t, cluster.CheckMigrationPlacement(SlotRange{Start: 12288, Stop: 12288}, 2))
+
+	// merging the shards checks all pending slots of the source shard
+	merged := cluster.Clone()
+	require.NoError(t, merged.MergeShard(3, 2))
+	require.Len(t, merged.CheckPendingPlacement(3), 1)
+	merged = cluster.Clone()
+	require.NoError(t, merged.MergeShard(0, 1))
+	require.Empty(t, merged.CheckPendingPlacement(0))
+	require.Empty(t, merged.CheckPendingPlacement(1))
+
+	clone := cluster.Clone()
+	require.Equal(t, cluster.Placement, clone.Placement)
+}
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiscoverClusters queries `CLUSTER NODES` on the seed nodes and groups the nodes
+// into clusters, seeds belonging to a discovered cluster won't be queried again.
+// The failures are the seed addresses which can't be discovered with the reason.
+func DiscoverClusters(ctx context.Context, seeds []string, password string) ([]*Cluster, map[string]string) {
+	clusters := make([]*Cluster, 0)
+	failures := make(map[string]string)
+	discovered := make(map[string]bool)
+	for _, seed := range seeds {
+		if discovered[seed] {
+			continue
+		}
+		clusterNodesStr, err := NewClusterNode(seed, password).GetClusterNodesString(ctx)
+		if err != nil {
+			failures[seed] = err.Error()
+			continue
+		}
+		cluster, err := ParseCluster(clusterNodesStr)
+		if err != nil {
+			failures[seed] = err.Error()
+			continue
+		}
+		if len(cluster.Shards) == 0 {
+			failures[seed] = "no master node in the cluster"
+			continue
+		}
+		cluster.SetPassword(password)
+		for _, node := range cluster.GetNodes() {
+			discovered[node.Addr()] = true
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, failures
+}
+
+// RecoveredClusterName generates a stable name for the discovered cluster,
+// so that rerunning the recovery won't create the same cluster twice.
+func RecoveredClusterName(prefix string, cluster *Cluster) string {
+	masterID := cluster.Shards[0].GetMasterNode().ID()
+	if len(masterID) > 8 {
+		masterID = masterID[:8]
+	}
+	return fmt.Sprintf("%s-%s", prefix, masterID)
+}
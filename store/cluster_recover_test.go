@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/testutil/kvrockstest"
+)
+
+func TestDiscoverClusters(t *testing.T) {
+	ctx := context.Background()
+	nodeAddrs := kvrockstest.Start(t, 2)
+	cluster, err := NewCluster("test-cluster", nodeAddrs, 1)
+	require.NoError(t, err)
+	require.NoError(t, cluster.Reset(ctx))
+	require.NoError(t, cluster.SyncToNodes(ctx))
+	defer func() {
+		require.NoError(t, cluster.Reset(ctx))
+	}()
+
+	clusters, failures := DiscoverClusters(ctx, append(nodeAddrs, "127.0.0.1:1"), "")
+	require.Len(t, clusters, 1)
+	require.Len(t, failures, 1)
+	require.Contains(t, failures, "127.0.0.1:1")
+	require.Len(t, clusters[0].Shards, 2)
+	require.Equal(t, cluster.Shards[0].GetMasterNode().ID(), clusters[0].Shards[0].GetMasterNode().ID())
+	require.Equal(t, "cluster-"+cluster.Shards[0].GetMasterNode().ID()[:8], RecoveredClusterName("cluster", clusters[0]))
+}
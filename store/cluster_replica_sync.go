@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+)
+
+// DefaultReplicaSyncMaxLag is the max sequences the replica can fall behind the master to
+// be considered as caught up, since the master keeps writing during the initial sync.
+const DefaultReplicaSyncMaxLag = 1000
+
+const replicaLinkStatusUp = "up"
+
+// JobReplicaSync is the initial replication sync progress of the added replica
+type JobReplicaSync struct {
+	ShardIndex     int    `json:"shard_index"`
+	NodeID         string `json:"node_id"`
+	Addr           string `json:"addr"`
+	LinkStatus     string `json:"link_status"`
+	SyncInProgress bool   `json:"sync_in_progress"`
+	MasterOffset   int64  `json:"master_offset"`
+	ReplicaOffset  int64  `json:"replica_offset"`
+	UpdatedAt      int64  `json:"updated_at,omitempty"`
+}
+
+// NewReplicaSyncJob returns the running job which tracks the initial replication sync of the replica
+func NewReplicaSyncJob(shardIndex int, node Node) *Job {
+	now := time.Now()
+	return &Job{
+		ID:        fmt.Sprintf("%s-%d", node.ID(), now.UnixMilli()),
+		Type:      JobTypeReplicaSync,
+		Status:    JobStatusRunning,
+		StartedAt: now.Unix(),
+		Tasks:     []*JobTask{},
+		ReplicaSync: &JobReplicaSync{
+			ShardIndex: shardIndex,
+			NodeID:     node.ID(),
+			Addr:       node.Addr(),
+		},
+	}
+}
+
+func parseInfoInt(fields map[string]string, key string) (int64, error) {
+	value, ok := fields[key]
+	if !ok || value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Update refreshes the progress by the INFO replication of the master and the replica
+func (sync *JobReplicaSync) Update(ctx context.Context, master, replica Node) error {
+	replicaFields, err := getInfoFields(ctx, replica, "replication")
+	if err != nil {
+		return fmt.Errorf("get the replication info of the replica: %w", err)
+	}
+	masterFields, err := getInfoFields(ctx, master, "replication")
+	if err != nil {
+		return fmt.Errorf("get the replication info of the master: %w", err)
+	}
+	replicaOffset, err := parseInfoInt(replicaFields, "slave_repl_offset")
+	if err != nil {
+		return fmt.Errorf("parse the replica offset: %w", err)
+	}
+	masterOffset, err := parseInfoInt(masterFields, "master_repl_offset")
+	if err != nil {
+		return fmt.Errorf("parse the master offset: %w", err)
+	}
+	sync.LinkStatus = replicaFields["master_link_status"]
+	sync.SyncInProgress = replicaFields["master_sync_in_progress"] == "1"
+	sync.MasterOffset = masterOffset
+	sync.ReplicaOffset = replicaOffset
+	sync.UpdatedAt = time.Now().Unix()
+	return nil
+}
+
+// Ready returns true if the replica is connected to the master and
+// its offset is within the max lag after the initial sync was finished.
+func (sync *JobReplicaSync) Ready(maxLag int64) bool {
+	return sync.LinkStatus == replicaLinkStatusUp && !sync.SyncInProgress &&
+		sync.ReplicaOffset > 0 && sync.MasterOffset-sync.ReplicaOffset <= maxLag
+}
+
+// FinishReplicaSync marks the replica sync job as succeeded, or failed with the reason if it's not empty
+func (job *Job) FinishReplicaSync(reason string) {
+	job.Status = JobStatusSucceeded
+	if reason != "" {
+		job.Status = JobStatusFailed
+		job.Error = reason
+	}
+	job.FinishedAt = time.Now().Unix()
+}
+
+// StatusChanged returns true if the link status or the sync state differs from the previous progress,
+// the offsets keep changing during the sync so they alone don't make the job worth rewriting.
+func (sync *JobReplicaSync) StatusChanged(prev *JobReplicaSync) bool {
+	return sync.LinkStatus != prev.LinkStatus || sync.SyncInProgress != prev.SyncInProgress
+}
+
+// GetReplicaSyncJob returns the latest replica sync job of the node
+func GetReplicaSyncJob(ctx context.Context, s Store, ns, cluster, nodeID string) (*Job, error) {
+	jobs, err := s.ListJobs(ctx, ns, cluster, JobTypeReplicaSync)
+	if err != nil {
+		return nil, err
+	}
+	// the newest job comes first
+	for _, job := range jobs {
+		if job.ReplicaSync != nil && job.ReplicaSync.NodeID == nodeID {
+			return job, nil
+		}
+	}
+	return nil, &consts.NotFoundError{Resource: JobTypeReplicaSync, Key: nodeID}
+}
+
+// cancelRemovedReplicaSyncs cancels the running sync jobs of the syncing replicas which were removed
+// from the cluster, otherwise the jobs would be left running forever.
+func (s *ClusterStore) cancelRemovedReplicaSyncs(ctx context.Context, ns string, oldCluster, newCluster *Cluster) {
+	removed := make(map[string]bool)
+	for _, shard := range oldCluster.Shards {
+		for _, node := range shard.Nodes {
+			if node.IsSyncing() {
+				removed[node.ID()] = true
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	for _, shard := range newCluster.Shards {
+		for _, node := range shard.Nodes {
+			delete(removed, node.ID())
+		}
+	}
+	for nodeID := range removed {
+		job, err := GetReplicaSyncJob(ctx, s, ns, newCluster.Name, nodeID)
+		if err == nil && job.Status == JobStatusRunning {
+			_, err = s.UpdateJob(ctx, ns, newCluster.Name, JobTypeReplicaSync, job.ID, func(job *Job) bool {
+				if job.Status != JobStatusRunning {
+					return false
+				}
+				job.Status = JobStatusCancelled
+				job.Error = "the node was removed"
+				job.FinishedAt = time.Now().Unix()
+				return true
+			})
+		}
+		if err != nil && !errors.Is(err, consts.ErrNotFound) {
+			logger.Get().With(
+				zap.String("namespace", ns),
+				zap.String("cluster", newCluster.Name),
+				zap.String("node", nodeID),
+				zap.Error(err),
+			).Warn("Failed to cancel the replica sync job of the removed node")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestReplicaSync(t *testing.T) {
+	ctx := context.Background()
+	master := NewClusterMockNode()
+	master.Replies = map[string]interface{}{"INFO": "# Replication\r\nrole:master\r\nmaster_repl_offset:5000\r\n"}
+	replica := NewClusterMockNode()
+	replica.SetRole(RoleSlave)
+	replica.Replies = map[string]interface{}{
+		"INFO": "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:1\r\nslave_repl_offset:0\r\n",
+	}
+
+	job := NewReplicaSyncJob(0, replica)
+	require.Equal(t, JobStatusRunning, job.Status)
+	require.NoError(t, job.ReplicaSync.Update(ctx, master, replica))
+	require.True(t, job.ReplicaSync.SyncInProgress)
+	require.EqualValues(t, 5000, job.ReplicaSync.MasterOffset)
+	require.False(t, job.ReplicaSync.Ready(DefaultReplicaSyncMaxLag))
+
+	replica.Replies["INFO"] = "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:0\r\nslave_repl_offset:3000\r\n"
+	require.NoError(t, job.ReplicaSync.Update(ctx, master, replica))
+	require.False(t, job.ReplicaSync.Ready(DefaultReplicaSyncMaxLag))
+	require.True(t, job.ReplicaSync.Ready(2000))
+
+	replica.Replies["INFO"] = "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\nslave_repl_offset:5000\r\n"
+	require.NoError(t, job.ReplicaSync.Update(ctx, master, replica))
+	require.False(t, job.ReplicaSync.Ready(DefaultReplicaSyncMaxLag))
+
+	master.Replies["INFO"] = errors.New("connection refused")
+	require.Error(t, job.ReplicaSync.Update(ctx, master, replica))
+
+	s := NewClusterStore(engine.NewMock())
+	_, err := GetReplicaSyncJob(ctx, s, "test-ns", "test-cluster", replica.ID())
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", job))
+	got, err := GetReplicaSyncJob(ctx, s, "test-ns", "test-cluster", replica.ID())
+	require.NoError(t, err)
+	require.Equal(t, job.ID, got.ID)
+
+	job.FinishReplicaSync("")
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", job))
+	got, err = GetReplicaSyncJob(ctx, s, "test-ns", "test-cluster", replica.ID())
+	require.NoError(t, err)
+	require.Equal(t, JobStatusSucceeded, got.Status)
+
+	prev := *job.ReplicaSync
+	job.ReplicaSync.MasterOffset += 100
+	require.False(t, job.ReplicaSync.StatusChanged(&prev))
+	job.ReplicaSync.LinkStatus = replicaLinkStatusUp
+	require.True(t, job.ReplicaSync.StatusChanged(&prev))
+}
+
+func TestReplicaSync_CancelRemoved(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("test-cluster", []string{"127.0.0.1:1111", "127.0.0.1:1112", "127.0.0.1:1113"}, 3)
+	require.NoError(t, err)
+	replica := cluster.Shards[0].Nodes[2]
+	replica.SetSyncing(true)
+	require.NoError(t, s.CreateCluster(ctx, "test-ns", cluster))
+	job := NewReplicaSyncJob(0, replica)
+	require.NoError(t, s.SaveJob(ctx, "test-ns", "test-cluster", job))
+
+	// the job is kept running while the replica is in the cluster
+	cluster.Shards[0].Nodes[1].SetRestoring(true)
+	require.NoError(t, s.UpdateCluster(ctx, "test-ns", cluster))
+	got, err := GetReplicaSyncJob(ctx, s, "test-ns", "test-cluster", replica.ID())
+	require.NoError(t, err)
+	require.Equal(t, JobStatusRunning, got.Status)
+
+	require.NoError(t, cluster.RemoveNode(0, replica.ID()))
+	require.NoError(t, s.UpdateCluster(ctx, "test-ns", cluster))
+	got, err = GetReplicaSyncJob(ctx, s, "test-ns", "test-cluster", replica.ID())
+	require.NoError(t, err)
+	require.Equal(t, JobStatusCancelled, got.Status)
+	require.NotZero(t, got.FinishedAt)
+}
+
+func TestReplicaSync_NotPromoted(t *testing.T) {
+	ctx := context.Background()
+	master := NewClusterMockNode()
+	replica0 := NewClusterMockNode()
+	replica0.SetRole(RoleSlave)
+	replica0.Sequence = 100
+	replica1 := NewClusterMockNode()
+	replica1.SetRole(RoleSlave)
+	replica1.Sequence = 200
+	replica1.SetSyncing(true)
+	shard := NewShard()
+	shard.Nodes = []Node{master, replica0, replica1}
+
+	// the syncing replica won't be promoted even if it has the newest sequence
+	newMasterID, err := shard.promoteNewMaster(ctx, master.ID(), "")
+	require.NoError(t, err)
+	require.Equal(t, replica0.ID(), newMasterID)
+}
@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"strconv"
+)
+
+// ReplicationIOAge returns the seconds since the replica received the replication stream
+// from its master last time, it's -1 if the replica isn't connected to the master.
+func ReplicationIOAge(ctx context.Context, node Node) (int64, error) {
+	value, err := getInfoField(ctx, node, "replication", "master_last_io_seconds_ago")
+	if err != nil {
+		return 0, err
+	}
+	if value == "" {
+		return -1, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ClusterSchemaVersion is the schema version of the cluster documents written by this controller.
+// It must be bumped with a migration in clusterSchemaMigrations when the format changes in a way
+// which the older controllers can't read correctly, e.g. a field changes its type or meaning.
+// Adding an optional field doesn't need it since the unknown fields are ignored. The MessagePack
+// documents need their own migration, see msgpackSchemaVersion.
+const ClusterSchemaVersion = 1
+
+// ErrSchemaTooNew is returned when the cluster document was written by a newer controller,
+// the older controller refuses to read it instead of dropping the fields it doesn't know
+// and writing the document back.
+var ErrSchemaTooNew = errors.New("the schema version is too new")
+
+// clusterSchemaMigrations[i] upgrades the cluster document from the schema version i to i+1,
+// they're applied in order at read time so the rest of the code only sees the latest format.
+var clusterSchemaMigrations = []func(doc map[string]json.RawMessage) error{
+	migrateClusterSchemaV0,
+}
+
+// migrateClusterSchemaV0 upgrades the documents written before the schema version was introduced,
+// which used an integer migrating slot and -1 to denote the shard was not migrating.
+func migrateClusterSchemaV0(doc map[string]json.RawMessage) error {
+	rawShards, ok := doc["shards"]
+	if !ok {
+		return nil
+	}
+	var shards []map[string]json.RawMessage
+	if err := json.Unmarshal(rawShards, &shards); err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		rawSlot, ok := shard["migrating_slot"]
+		if !ok {
+			continue
+		}
+		var slot interface{}
+		if err := json.Unmarshal(rawSlot, &slot); err != nil {
+			return err
+		}
+		slotID, ok := slot.(float64)
+		if !ok {
+			// it's already in the slot range format
+			continue
+		}
+		if slotID == NotMigratingInt {
+			shard["migrating_slot"] = json.RawMessage("null")
+		} else {
+			shard["migrating_slot"] = json.RawMessage(strconv.Quote(strconv.Itoa(int(slotID))))
+		}
+	}
+	upgradedShards, err := json.Marshal(shards)
+	if err != nil {
+		return err
+	}
+	doc["shards"] = upgradedShards
+	return nil
+}
+
+// upgradeClusterDocument migrates the cluster document to the current schema version
+func upgradeClusterDocument(data []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return data, nil
+	}
+	schemaVersion := 0
+	if rawVersion, ok := doc["schema_version"]; ok {
+		if err := json.Unmarshal(rawVersion, &schemaVersion); err != nil {
+			return nil, fmt.Errorf("invalid schema version: %w", err)
+		}
+	}
+	if schemaVersion == ClusterSchemaVersion {
+		return data, nil
+	}
+	if schemaVersion < 0 {
+		return nil, fmt.Errorf("invalid schema version: %d", schemaVersion)
+	}
+	if schemaVersion > ClusterSchemaVersion {
+		return nil, fmt.Errorf("%w: the cluster schema version is %d but only %d is supported, "+
+			"please upgrade the controller", ErrSchemaTooNew, schemaVersion, ClusterSchemaVersion)
+	}
+	for version := schemaVersion; version < ClusterSchemaVersion; version++ {
+		if err := clusterSchemaMigrations[version](doc); err != nil {
+			return nil, fmt.Errorf("migrate the cluster schema from version %d: %w", version, err)
+		}
+	}
+	return json.Marshal(doc)
+}
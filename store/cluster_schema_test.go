@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterSchemaUpgrade(t *testing.T) {
+	legacy := `{"name":"test-cluster","version":3,"shards":[
+		{"nodes":[],"slot_ranges":["0-8191"],"target_shard_index":1,"migrating_slot":100},
+		{"nodes":[],"slot_ranges":["8192-16383"],"target_shard_index":-1,"migrating_slot":-1}]}`
+	var cluster Cluster
+	require.NoError(t, json.Unmarshal([]byte(legacy), &cluster))
+	require.EqualValues(t, 3, cluster.Version.Load())
+	require.Len(t, cluster.Shards, 2)
+	require.True(t, cluster.Shards[0].IsMigrating())
+	require.Equal(t, SlotRange{Start: 100, Stop: 100}, cluster.Shards[0].MigratingSlot.SlotRange)
+	require.False(t, cluster.Shards[1].IsMigrating())
+
+	// the document is always written with the current schema version
+	data, err := json.Marshal(&cluster)
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.EqualValues(t, ClusterSchemaVersion, doc["schema_version"])
+	require.Equal(t, "100", doc["shards"].([]interface{})[0].(map[string]interface{})["migrating_slot"])
+
+	var decoded Cluster
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, cluster.Shards[0].MigratingSlot, decoded.Shards[0].MigratingSlot)
+
+	// refuse to read the document written by a newer controller
+	newer := fmt.Sprintf(`{"schema_version":%d,"name":"test-cluster","shards":[]}`, ClusterSchemaVersion+1)
+	require.ErrorIs(t, json.Unmarshal([]byte(newer), &decoded), ErrSchemaTooNew)
+}
@@ -44,6 +44,18 @@ type Shard struct {
 	SlotRanges       []SlotRange    `json:"slot_ranges"`
 	TargetShardIndex int            `json:"target_shard_index"`
 	MigratingSlot    *MigratingSlot `json:"migrating_slot"`
+	// PendingSlots are the slot ranges waiting to be migrated to the target shard
+	// after the migrating slot is done, it's used by splitting and merging shards.
+	PendingSlots []SlotRange `json:"pending_slots,omitempty"`
+	// ReplicaOf maps the cascaded replica ID to its upstream replica ID, it's used
+	// to build the cross-region read copies without replicating from the master.
+	// The replica which is not in it replicates from the master directly.
+	ReplicaOf map[string]string `json:"replica_of,omitempty"`
+	// MigrationID is the ID of the migration job of the migrating slot
+	MigrationID string `json:"migration_id,omitempty"`
+	// Frozen stops the failover and migrations of the shard, it's used to keep
+	// the topology of the shard unchanged while investigating it.
+	Frozen bool `json:"frozen,omitempty"`
 }
 
 type Shards []*Shard
@@ -80,14 +92,30 @@ func (shard *Shard) Clone() *Shard {
 	copy(clone.SlotRanges, shard.SlotRanges)
 	clone.TargetShardIndex = shard.TargetShardIndex
 	clone.MigratingSlot = shard.MigratingSlot
-	clone.Nodes = make([]Node, len(shard.Nodes))
-	copy(clone.Nodes, shard.Nodes)
+	clone.MigrationID = shard.MigrationID
+	clone.Frozen = shard.Frozen
+	if len(shard.PendingSlots) > 0 {
+		clone.PendingSlots = make([]SlotRange, len(shard.PendingSlots))
+		copy(clone.PendingSlots, shard.PendingSlots)
+	}
+	if len(shard.ReplicaOf) > 0 {
+		clone.ReplicaOf = make(map[string]string, len(shard.ReplicaOf))
+		for nodeID, upstreamID := range shard.ReplicaOf {
+			clone.ReplicaOf[nodeID] = upstreamID
+		}
+	}
+	clone.Nodes = make([]Node, 0, len(shard.Nodes))
+	for _, node := range shard.Nodes {
+		clone.Nodes = append(clone.Nodes, cloneNode(node))
+	}
 	return clone
 }
 
 func (shard *Shard) ClearMigrateState() {
 	shard.MigratingSlot = nil
 	shard.TargetShardIndex = -1
+	shard.PendingSlots = nil
+	shard.MigrationID = ""
 }
 
 func (shard *Shard) IsServicing() bool {
@@ -121,6 +149,20 @@ func (shard *Shard) IsMigrating() bool {
 	return shard.MigratingSlot != nil && shard.MigratingSlot.IsMigrating && shard.TargetShardIndex != -1
 }
 
+// HasPendingSlots returns true if the shard has the slots waiting to be migrated to the target shard
+func (shard *Shard) HasPendingSlots() bool {
+	return len(shard.PendingSlots) > 0 && shard.TargetShardIndex != -1
+}
+
+// ClearMigratingSlot clears the migrated slot but keeps the pending slots and their target shard
+func (shard *Shard) ClearMigratingSlot() {
+	shard.MigratingSlot = nil
+	shard.MigrationID = ""
+	if len(shard.PendingSlots) == 0 {
+		shard.TargetShardIndex = -1
+	}
+}
+
 func (shard *Shard) GetMasterNode() Node {
 	for _, node := range shard.Nodes {
 		if node.IsMaster() {
@@ -145,65 +187,156 @@ func (shard *Shard) removeNode(nodeID string) error {
 	if !isFound {
 		return consts.ErrNotFound
 	}
+	// the cascaded replicas of the removed node will replicate from the master
+	shard.normalizeReplicaOf()
 	return nil
 }
 
-func (shard *Shard) getNewMasterNodeIndex(ctx context.Context, masterNodeIndex int, preferredNodeID string) int {
-	newMasterNodeIndex := -1
-	var newestOffset uint64
-	for i, node := range shard.Nodes {
-		// don't promote the current master node
-		if i == masterNodeIndex {
-			continue
+func (shard *Shard) getNode(nodeID string) Node {
+	for _, node := range shard.Nodes {
+		if node.ID() == nodeID {
+			return node
 		}
+	}
+	return nil
+}
 
-		_, err := node.GetClusterInfo(ctx)
-		if err != nil {
-			logger.Get().With(
-				zap.Error(err),
-				zap.String("id", node.ID()),
-				zap.String("addr", node.Addr()),
-			).Warn("Skip the node due to failed to get cluster info")
-			continue
+// IsCascaded returns whether the node replicates from another replica instead of the master
+func (shard *Shard) IsCascaded(nodeID string) bool {
+	_, ok := shard.ReplicaOf[nodeID]
+	return ok
+}
+
+// SetReplicaOf makes the replica replicate from the upstream replica, the replica
+// will replicate from the master if the upstream is empty or the master.
+func (shard *Shard) SetReplicaOf(nodeID, upstreamID string) error {
+	node := shard.getNode(nodeID)
+	if node == nil {
+		return fmt.Errorf("node %s: %w", nodeID, consts.ErrNotFound)
+	}
+	if node.IsMaster() {
+		return fmt.Errorf("%w: the master can't replicate from others", consts.ErrInvalidArgument)
+	}
+	if upstreamID != "" {
+		if upstreamID == nodeID {
+			return fmt.Errorf("%w: the node can't replicate from itself", consts.ErrInvalidArgument)
 		}
+		if shard.getNode(upstreamID) == nil {
+			return fmt.Errorf("upstream node %s: %w", upstreamID, consts.ErrNotFound)
+		}
+		// the upstream chain must end at the master instead of looping back to the node
+		for id, ok := upstreamID, true; ok; id, ok = shard.ReplicaOf[id] {
+			if id == nodeID {
+				return fmt.Errorf("%w: the replication chain would be a loop", consts.ErrInvalidArgument)
+			}
+		}
+	}
+	if shard.ReplicaOf == nil {
+		shard.ReplicaOf = make(map[string]string)
+	}
+	shard.ReplicaOf[nodeID] = upstreamID
+	shard.normalizeReplicaOf()
+	return nil
+}
 
-		clusterNodeInfo, err := node.GetClusterNodeInfo(ctx)
-		if err != nil {
-			logger.Get().With(
-				zap.Error(err),
-				zap.String("id", node.ID()),
-				zap.String("addr", node.Addr()),
-			).Warn("Skip the node due to failed to get info of node")
-			continue
+// normalizeReplicaOf removes the entries of the masters, the missing nodes and
+// the replicas which replicate from the master directly.
+func (shard *Shard) normalizeReplicaOf() {
+	for nodeID, upstreamID := range shard.ReplicaOf {
+		node, upstream := shard.getNode(nodeID), shard.getNode(upstreamID)
+		if node == nil || node.IsMaster() || upstream == nil || upstream.IsMaster() {
+			delete(shard.ReplicaOf, nodeID)
 		}
-		if clusterNodeInfo.Role != RoleSlave || clusterNodeInfo.Sequence == 0 {
-			logger.Get().With(
-				zap.String("id", node.ID()),
-				zap.String("addr", node.Addr()),
-				zap.String("role", clusterNodeInfo.Role),
-				zap.Uint64("sequence", clusterNodeInfo.Sequence),
-			).Warn("Skip the node due to role or sequence invalid")
-			continue
+	}
+	if len(shard.ReplicaOf) == 0 {
+		shard.ReplicaOf = nil
+	}
+}
+
+func (shard *Shard) getNewMasterNodeIndex(ctx context.Context, masterNodeIndex int, preferredNodeID string) int {
+	candidates, newMasterNodeIndex := shard.evaluateFailoverCandidates(ctx, masterNodeIndex, preferredNodeID)
+	for _, candidate := range candidates {
+		log := logger.Get().With(
+			zap.String("id", candidate.NodeID),
+			zap.String("addr", candidate.Addr),
+			zap.Uint64("sequence", candidate.Sequence),
+		)
+		if candidate.Reason != "" {
+			log.Warn("Skip the node to be promoted", zap.String("reason", candidate.Reason))
+		} else {
+			log.Info("Get slave node info successfully")
 		}
+	}
+	return newMasterNodeIndex
+}
 
-		logger.Get().With(
-			zap.String("id", node.ID()),
-			zap.String("addr", node.Addr()),
-			zap.String("role", clusterNodeInfo.Role),
-			zap.Uint64("sequence", clusterNodeInfo.Sequence),
-		).Info("Get slave node info successfully")
+// evaluateFailoverCandidates evaluates all replicas of the shard, and returns the evaluations with
+// the index of the node to be promoted which is -1 if none of them is eligible. The preferred node
+// is promoted if it's eligible, otherwise the eligible node with the highest sequence is promoted.
+func (shard *Shard) evaluateFailoverCandidates(ctx context.Context, masterNodeIndex int, preferredNodeID string) ([]FailoverCandidate, int) {
+	candidates := make([]FailoverCandidate, 0, len(shard.Nodes))
+	indexes := make([]int, 0, len(shard.Nodes))
+	for i, node := range shard.Nodes {
+		if i == masterNodeIndex {
+			continue
+		}
+		candidate := FailoverCandidate{NodeID: node.ID(), Addr: node.Addr(), Preferred: node.ID() == preferredNodeID}
+		candidate.Sequence, candidate.Reason = shard.checkFailoverCandidate(ctx, node, preferredNodeID)
+		candidates = append(candidates, candidate)
+		indexes = append(indexes, i)
+	}
 
-		// If the preferredNodeID is not empty, we will use it as the new master node.
-		if preferredNodeID != "" && node.ID() == preferredNodeID {
-			newMasterNodeIndex = i
+	selected := -1
+	var newestOffset uint64
+	for i, candidate := range candidates {
+		if candidate.Reason != "" {
+			continue
+		}
+		if candidate.Preferred {
+			selected = i
 			break
 		}
-		if clusterNodeInfo.Sequence >= newestOffset {
-			newMasterNodeIndex = i
-			newestOffset = clusterNodeInfo.Sequence
+		if candidate.Sequence >= newestOffset {
+			selected = i
+			newestOffset = candidate.Sequence
 		}
 	}
-	return newMasterNodeIndex
+	if selected == -1 {
+		return candidates, -1
+	}
+	candidates[selected].Selected = true
+	return candidates, indexes[selected]
+}
+
+// checkFailoverCandidate returns the replication sequence of the node and the reason why
+// the node can't be promoted, the reason is empty if the node is eligible.
+func (shard *Shard) checkFailoverCandidate(ctx context.Context, node Node, preferredNodeID string) (uint64, string) {
+	// don't promote the node which is restoring from backup
+	// or the replica which hasn't caught up with the master after it was added
+	if node.IsRestoring() {
+		return 0, "the node is restoring from backup"
+	}
+	if node.IsSyncing() {
+		return 0, "the replica hasn't caught up with the master"
+	}
+	// the cascaded replica is usually in the remote region, so it's promoted only if it's preferred
+	if shard.IsCascaded(node.ID()) && node.ID() != preferredNodeID {
+		return 0, "the cascaded replica isn't preferred"
+	}
+	if _, err := node.GetClusterInfo(ctx); err != nil {
+		return 0, fmt.Sprintf("failed to get the cluster info: %v", err)
+	}
+	clusterNodeInfo, err := node.GetClusterNodeInfo(ctx)
+	if err != nil {
+		return 0, fmt.Sprintf("failed to get the node info: %v", err)
+	}
+	if clusterNodeInfo.Role != RoleSlave {
+		return clusterNodeInfo.Sequence, fmt.Sprintf("the node role is %s", clusterNodeInfo.Role)
+	}
+	if clusterNodeInfo.Sequence == 0 {
+		return 0, "the replication sequence is 0"
+	}
+	return clusterNodeInfo.Sequence, ""
 }
 
 // PromoteNewMaster promotes a new master node in the shard,
@@ -213,22 +346,9 @@ func (shard *Shard) getNewMasterNodeIndex(ctx context.Context, masterNodeIndex i
 // The preferredNodeID is used to specify the preferred node to be promoted as the new master node,
 // it will choose the node with the highest sequence number if the preferredNodeID is empty.
 func (shard *Shard) promoteNewMaster(ctx context.Context, masterNodeID, preferredNodeID string) (string, error) {
-	if len(shard.Nodes) <= 1 {
-		return "", consts.ErrShardNoReplica
-	}
-
-	oldMasterNodeIndex := -1
-	for i, node := range shard.Nodes {
-		if node.IsMaster() {
-			oldMasterNodeIndex = i
-			break
-		}
-	}
-	if oldMasterNodeIndex == -1 {
-		return "", consts.ErrOldMasterNodeNotFound
-	}
-	if masterNodeID != "" && shard.Nodes[oldMasterNodeIndex].ID() != masterNodeID {
-		return "", consts.ErrNodeIsNotMaster
+	oldMasterNodeIndex, err := shard.getFailoverMasterIndex(masterNodeID)
+	if err != nil {
+		return "", err
 	}
 	newMasterNodeIndex := shard.getNewMasterNodeIndex(ctx, oldMasterNodeIndex, preferredNodeID)
 	if newMasterNodeIndex == -1 {
@@ -236,10 +356,28 @@ func (shard *Shard) promoteNewMaster(ctx context.Context, masterNodeID, preferre
 	}
 	shard.Nodes[oldMasterNodeIndex].SetRole(RoleSlave)
 	shard.Nodes[newMasterNodeIndex].SetRole(RoleMaster)
+	shard.normalizeReplicaOf()
 	preferredNewMasterNode := shard.Nodes[newMasterNodeIndex]
 	return preferredNewMasterNode.ID(), nil
 }
 
+// getFailoverMasterIndex returns the index of the master to be failed over
+func (shard *Shard) getFailoverMasterIndex(masterNodeID string) (int, error) {
+	if len(shard.Nodes) <= 1 {
+		return -1, consts.ErrShardNoReplica
+	}
+	for i, node := range shard.Nodes {
+		if !node.IsMaster() {
+			continue
+		}
+		if masterNodeID != "" && node.ID() != masterNodeID {
+			return -1, consts.ErrNodeIsNotMaster
+		}
+		return i, nil
+	}
+	return -1, consts.ErrOldMasterNodeNotFound
+}
+
 func (shard *Shard) HasOverlap(slotRange SlotRange) bool {
 	for _, shardSlotRange := range shard.SlotRanges {
 		if shardSlotRange.HasOverlap(slotRange) {
@@ -281,7 +419,11 @@ func (shard *Shard) ToSlotsString() (string, error) {
 		} else {
 			builder.WriteString(RoleSlave)
 			builder.WriteByte(' ')
-			builder.WriteString(shard.Nodes[masterNodeIndex].ID())
+			if upstreamID, ok := shard.ReplicaOf[node.ID()]; ok {
+				builder.WriteString(upstreamID)
+			} else {
+				builder.WriteString(shard.Nodes[masterNodeIndex].ID())
+			}
 		}
 		builder.WriteByte('\n')
 	}
@@ -293,10 +435,14 @@ func (shard *Shard) ToSlotsString() (string, error) {
 // So we need to take into a concrete type.
 func (shard *Shard) UnmarshalJSON(bytes []byte) error {
 	var data struct {
-		SlotRanges       []SlotRange    `json:"slot_ranges"`
-		TargetShardIndex int            `json:"target_shard_index"`
-		MigratingSlot    *MigratingSlot `json:"migrating_slot"`
-		Nodes            []*ClusterNode `json:"nodes"`
+		SlotRanges       []SlotRange       `json:"slot_ranges"`
+		TargetShardIndex int               `json:"target_shard_index"`
+		MigratingSlot    *MigratingSlot    `json:"migrating_slot"`
+		PendingSlots     []SlotRange       `json:"pending_slots"`
+		ReplicaOf        map[string]string `json:"replica_of"`
+		MigrationID      string            `json:"migration_id"`
+		Frozen           bool              `json:"frozen"`
+		Nodes            []*ClusterNode    `json:"nodes"`
 	}
 	if err := json.Unmarshal(bytes, &data); err != nil {
 		return err
@@ -304,6 +450,10 @@ func (shard *Shard) UnmarshalJSON(bytes []byte) error {
 	shard.SlotRanges = data.SlotRanges
 	shard.TargetShardIndex = data.TargetShardIndex
 	shard.MigratingSlot = data.MigratingSlot
+	shard.PendingSlots = data.PendingSlots
+	shard.ReplicaOf = data.ReplicaOf
+	shard.MigrationID = data.MigrationID
+	shard.Frozen = data.Frozen
 	shard.Nodes = make([]Node, len(data.Nodes))
 	for i, node := range data.Nodes {
 		shard.Nodes[i] = node
@@ -21,10 +21,14 @@
 package store
 
 import (
+	"context"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
 )
 
 func TestShard_HasOverlap(t *testing.T) {
@@ -78,3 +82,53 @@ func TestShard_IsServicing(t *testing.T) {
 	shard.SlotRanges = []SlotRange{{Start: -1, Stop: -1}}
 	require.False(t, shard.IsServicing())
 }
+
+func TestShard_ReplicaOf(t *testing.T) {
+	shard := NewShard()
+	master, err := shard.addNode("127.0.0.1:6666", RoleMaster, "")
+	require.NoError(t, err)
+	replica0, err := shard.addNode("127.0.0.1:6667", RoleSlave, "")
+	require.NoError(t, err)
+	replica1, err := shard.addNode("127.0.0.1:6668", RoleSlave, "")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, shard.SetReplicaOf(master.ID(), replica0.ID()), consts.ErrInvalidArgument)
+	require.ErrorIs(t, shard.SetReplicaOf(replica0.ID(), replica0.ID()), consts.ErrInvalidArgument)
+	require.ErrorIs(t, shard.SetReplicaOf(replica0.ID(), "not-exists"), consts.ErrNotFound)
+
+	require.NoError(t, shard.SetReplicaOf(replica1.ID(), replica0.ID()))
+	require.True(t, shard.IsCascaded(replica1.ID()))
+	require.ErrorIs(t, shard.SetReplicaOf(replica0.ID(), replica1.ID()), consts.ErrInvalidArgument)
+	slotsString, err := shard.ToSlotsString()
+	require.NoError(t, err)
+	require.Contains(t, slotsString, replica1.ID()+" 127.0.0.1 6668 slave "+replica0.ID())
+	require.Contains(t, slotsString, replica0.ID()+" 127.0.0.1 6667 slave "+master.ID())
+
+	// the cascaded replica won't be promoted unless it's preferred
+	require.Equal(t, -1, shard.getNewMasterNodeIndex(context.Background(), 0, ""))
+
+	// replicating from the master is the same as not cascaded
+	require.NoError(t, shard.SetReplicaOf(replica1.ID(), master.ID()))
+	require.False(t, shard.IsCascaded(replica1.ID()))
+	require.Nil(t, shard.ReplicaOf)
+
+	// the cascaded replica will replicate from the master after its upstream was removed
+	require.NoError(t, shard.SetReplicaOf(replica1.ID(), replica0.ID()))
+	require.NoError(t, shard.removeNode(replica0.ID()))
+	require.False(t, shard.IsCascaded(replica1.ID()))
+}
+
+func TestParseCluster_ReplicaOf(t *testing.T) {
+	clusterNodesStr := strings.Join([]string{
+		"m0 127.0.0.1:6666@16666 myself,master - 0 0 3 connected 0-16383",
+		"r1 127.0.0.1:6668@16668 slave r0 0 0 3 connected",
+		"r0 127.0.0.1:6667@16667 slave m0 0 0 3 connected",
+	}, "\n")
+	cluster, err := ParseCluster(clusterNodesStr)
+	require.NoError(t, err)
+	require.Len(t, cluster.Shards, 1)
+	require.Len(t, cluster.Shards[0].Nodes, 3)
+	require.Equal(t, "r0", cluster.Shards[0].Nodes[1].ID())
+	require.Equal(t, "r1", cluster.Shards[0].Nodes[2].ID())
+	require.Equal(t, map[string]string{"r1": "r0"}, cluster.Shards[0].ReplicaOf)
+}
@@ -0,0 +1,283 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const (
+	ReconcilePhaseConverged   = "converged"
+	ReconcilePhaseProgressing = "progressing"
+)
+
+type ShardSpec struct {
+	// Nodes are the desired nodes of the shard, the first node
+	// will be the master when the shard is created.
+	Nodes []string `json:"nodes"`
+	// SlotRanges are the desired slot ranges of the shard, the slots will be
+	// distributed evenly if none of the shards specify the slot ranges.
+	SlotRanges []SlotRange `json:"slot_ranges"`
+}
+
+// ClusterSpec is the desired state of the cluster, the cluster will be
+// converged toward it by the reconciling.
+type ClusterSpec struct {
+	Password string      `json:"password"`
+	Shards   []ShardSpec `json:"shards"`
+}
+
+type ReconcileStatus struct {
+	Phase   string   `json:"phase"`
+	Changed bool     `json:"changed"`
+	Actions []string `json:"actions"`
+	Pending []string `json:"pending"`
+}
+
+func (status *ReconcileStatus) addAction(format string, args ...interface{}) {
+	status.Actions = append(status.Actions, fmt.Sprintf(format, args...))
+	status.Changed = true
+}
+
+func (status *ReconcileStatus) addPending(format string, args ...interface{}) {
+	status.Pending = append(status.Pending, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the spec and returns the desired owner shard of each slot
+func (spec *ClusterSpec) Validate() ([]int, error) {
+	if len(spec.Shards) == 0 {
+		return nil, errors.New("shards should NOT be empty")
+	}
+	seen := make(map[string]bool)
+	hasSlotRanges := false
+	for i, shard := range spec.Shards {
+		if len(shard.Nodes) == 0 {
+			return nil, fmt.Errorf("nodes of shard %d should NOT be empty", i)
+		}
+		for _, node := range shard.Nodes {
+			if seen[node] {
+				return nil, fmt.Errorf("node %s is duplicated", node)
+			}
+			seen[node] = true
+		}
+		if len(shard.SlotRanges) > 0 {
+			hasSlotRanges = true
+		}
+	}
+
+	owners := make([]int, MaxSlotID+1)
+	var assigned SlotRanges
+	for i, shard := range spec.Shards {
+		slotRanges := shard.SlotRanges
+		if !hasSlotRanges {
+			slotRanges = []SlotRange{CalculateSlotRanges(len(spec.Shards))[i]}
+		}
+		for _, slotRange := range slotRanges {
+			if slotRange.Start < MinSlotID || slotRange.Stop > MaxSlotID || slotRange.Start > slotRange.Stop {
+				return nil, fmt.Errorf("invalid slot range %s", slotRange.String())
+			}
+			if overlapped := assigned.Intersect(SlotRanges{slotRange}); len(overlapped) > 0 {
+				return nil, fmt.Errorf("slots %s are assigned to multiple shards", slotRangesString(overlapped))
+			}
+			assigned = append(assigned, slotRange)
+			for slot := slotRange.Start; slot <= slotRange.Stop; slot++ {
+				owners[slot] = i
+			}
+		}
+	}
+	if unassigned := (SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}}).Difference(assigned); len(unassigned) > 0 {
+		return nil, fmt.Errorf("slots %s are not assigned to any shard", slotRangesString(unassigned))
+	}
+	return owners, nil
+}
+
+// NewClusterFromSpec creates the cluster with the desired state
+func NewClusterFromSpec(name string, spec *ClusterSpec) (*Cluster, error) {
+	owners, err := spec.Validate()
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{Name: name, Shards: make([]*Shard, 0, len(spec.Shards))}
+	for _, shardSpec := range spec.Shards {
+		shard := NewShard()
+		for j, addr := range shardSpec.Nodes {
+			role := RoleMaster
+			if j != 0 {
+				role = RoleSlave
+			}
+			if _, err := shard.addNode(addr, role, spec.Password); err != nil {
+				return nil, err
+			}
+		}
+		cluster.Shards = append(cluster.Shards, shard)
+	}
+	for slot, owner := range owners {
+		shard := cluster.Shards[owner]
+		shard.SlotRanges = AddSlotToSlotRanges(shard.SlotRanges, SlotRange{Start: slot, Stop: slot})
+	}
+	cluster.Version.Store(1)
+	return cluster, nil
+}
+
+// Reconcile converges the cluster toward the spec by one step, it adds/removes the nodes
+// and shards, and schedules at most one slot migration per shard. It's idempotent and should
+// be called repeatedly until the phase becomes converged.
+func (cluster *Cluster) Reconcile(ctx context.Context, spec *ClusterSpec) (*ReconcileStatus, error) {
+	owners, err := spec.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", consts.ErrInvalidArgument, err.Error())
+	}
+	status := &ReconcileStatus{Actions: make([]string, 0), Pending: make([]string, 0)}
+
+	// converge the nodes of the existing shards
+	for i := 0; i < len(cluster.Shards) && i < len(spec.Shards); i++ {
+		if err := cluster.reconcileShardNodes(i, spec, status); err != nil {
+			return nil, err
+		}
+	}
+	// add the new shards without slots, the slots will be migrated to them later
+	for i := len(cluster.Shards); i < len(spec.Shards); i++ {
+		shard := NewShard()
+		for j, addr := range spec.Shards[i].Nodes {
+			role := RoleMaster
+			if j != 0 {
+				role = RoleSlave
+			}
+			if _, err := shard.addNode(addr, role, spec.Password); err != nil {
+				return nil, err
+			}
+		}
+		cluster.Shards = append(cluster.Shards, shard)
+		status.addAction("add shard %d", i)
+	}
+	if err := cluster.reconcileSlots(ctx, owners, status); err != nil {
+		return nil, err
+	}
+	// remove the trailing shards which are not in the spec after their slots were migrated
+	for i := len(cluster.Shards) - 1; i >= len(spec.Shards); i-- {
+		if cluster.Shards[i].IsServicing() || cluster.isMigrationTarget(i) {
+			status.addPending("remove shard %d after its slots were migrated", i)
+			break
+		}
+		cluster.Shards = cluster.Shards[:i]
+		status.addAction("remove shard %d", i)
+	}
+
+	status.Phase = ReconcilePhaseConverged
+	if len(status.Pending) > 0 {
+		status.Phase = ReconcilePhaseProgressing
+	}
+	return status, nil
+}
+
+func (cluster *Cluster) reconcileShardNodes(shardIndex int, spec *ClusterSpec, status *ReconcileStatus) error {
+	shard := cluster.Shards[shardIndex]
+	desired := make(map[string]bool)
+	for _, addr := range spec.Shards[shardIndex].Nodes {
+		desired[addr] = true
+	}
+	existing := make(map[string]bool)
+	for _, node := range shard.Nodes {
+		existing[node.Addr()] = true
+	}
+	for _, addr := range spec.Shards[shardIndex].Nodes {
+		if existing[addr] {
+			continue
+		}
+		if _, err := shard.addNode(addr, RoleSlave, spec.Password); err != nil {
+			return err
+		}
+		status.addAction("add node %s to shard %d", addr, shardIndex)
+	}
+	removedNodes := make([]Node, 0)
+	for _, node := range shard.Nodes {
+		if desired[node.Addr()] {
+			continue
+		}
+		if node.IsMaster() {
+			status.addPending("failover the master %s of shard %d before removing it", node.Addr(), shardIndex)
+			continue
+		}
+		removedNodes = append(removedNodes, node)
+	}
+	for _, node := range removedNodes {
+		if err := shard.removeNode(node.ID()); err != nil {
+			return err
+		}
+		status.addAction("remove node %s from shard %d", node.Addr(), shardIndex)
+	}
+	return nil
+}
+
+func (cluster *Cluster) isMigrationTarget(shardIndex int) bool {
+	for _, shard := range cluster.Shards {
+		if shard.IsMigrating() && shard.TargetShardIndex == shardIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func (cluster *Cluster) reconcileSlots(ctx context.Context, owners []int, status *ReconcileStatus) error {
+	for i, shard := range cluster.Shards {
+		if shard.IsMigrating() {
+			status.addPending("wait for shard %d to migrate slot %s", i, shard.MigratingSlot.String())
+			continue
+		}
+		misplaced, target := findMisplacedSlots(shard.SlotRanges, owners, i)
+		if target == -1 {
+			continue
+		}
+		if cluster.Shards[target].IsMigrating() {
+			status.addPending("migrate slot %s from shard %d to shard %d", misplaced.String(), i, target)
+			continue
+		}
+		if err := cluster.MigrateSlot(ctx, misplaced, target, false); err != nil {
+			return fmt.Errorf("migrate slot %s from shard %d to shard %d: %w", misplaced.String(), i, target, err)
+		}
+		status.addAction("migrate slot %s from shard %d to shard %d", misplaced.String(), i, target)
+		status.addPending("wait for shard %d to migrate slot %s", i, misplaced.String())
+	}
+	return nil
+}
+
+// findMisplacedSlots returns the first contiguous slots of the shard which should
+// be owned by another shard, the target is -1 if all slots are in place.
+func findMisplacedSlots(slotRanges []SlotRange, owners []int, shardIndex int) (SlotRange, int) {
+	for _, slotRange := range slotRanges {
+		for slot := slotRange.Start; slot <= slotRange.Stop; slot++ {
+			if owners[slot] == shardIndex {
+				continue
+			}
+			target := owners[slot]
+			stop := slot
+			for stop+1 <= slotRange.Stop && owners[stop+1] == target {
+				stop++
+			}
+			return SlotRange{Start: slot, Stop: stop}, target
+		}
+	}
+	return SlotRange{}, -1
+}
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterSpec_Validate(t *testing.T) {
+	spec := &ClusterSpec{Shards: []ShardSpec{{Nodes: []string{"node0"}}, {Nodes: []string{"node1"}}}}
+	owners, err := spec.Validate()
+	require.NoError(t, err)
+	require.Equal(t, 0, owners[0])
+	require.Equal(t, 1, owners[MaxSlotID])
+
+	spec.Shards[1].Nodes = []string{"node0"}
+	_, err = spec.Validate()
+	require.ErrorContains(t, err, "duplicated")
+
+	spec.Shards[1].Nodes = []string{"node1"}
+	spec.Shards[0].SlotRanges = []SlotRange{{Start: 0, Stop: 100}}
+	spec.Shards[1].SlotRanges = []SlotRange{{Start: 100, Stop: MaxSlotID}}
+	_, err = spec.Validate()
+	require.ErrorContains(t, err, "multiple shards")
+
+	spec.Shards[1].SlotRanges = []SlotRange{{Start: 200, Stop: MaxSlotID}}
+	_, err = spec.Validate()
+	require.ErrorContains(t, err, "not assigned")
+}
+
+func TestCluster_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	spec := &ClusterSpec{Shards: []ShardSpec{
+		{Nodes: []string{"node0", "node1"}},
+		{Nodes: []string{"node2", "node3"}},
+	}}
+	cluster, err := NewClusterFromSpec("test-cluster", spec)
+	require.NoError(t, err)
+	require.Len(t, cluster.Shards, 2)
+	require.EqualValues(t, CalculateSlotRanges(2)[1], cluster.Shards[1].SlotRanges[0])
+
+	status, err := cluster.Reconcile(ctx, spec)
+	require.NoError(t, err)
+	require.Equal(t, ReconcilePhaseConverged, status.Phase)
+	require.False(t, status.Changed)
+
+	spec.Shards[0].Nodes = []string{"node0", "node4"}
+	spec.Shards[1].Nodes = []string{"node3"}
+	status, err = cluster.Reconcile(ctx, spec)
+	require.NoError(t, err)
+	require.True(t, status.Changed)
+	require.Equal(t, ReconcilePhaseProgressing, status.Phase)
+	require.Len(t, status.Pending, 1)
+	require.Equal(t, "node4", cluster.Shards[0].Nodes[1].Addr())
+	require.Len(t, cluster.Shards[0].Nodes, 2)
+	require.Len(t, cluster.Shards[1].Nodes, 2)
+
+	_, target := findMisplacedSlots(cluster.Shards[0].SlotRanges, make([]int, MaxSlotID+1), 0)
+	require.Equal(t, -1, target)
+	owners := make([]int, MaxSlotID+1)
+	for i := 100; i <= 200; i++ {
+		owners[i] = 1
+	}
+	misplaced, target := findMisplacedSlots(cluster.Shards[0].SlotRanges, owners, 0)
+	require.Equal(t, 1, target)
+	require.Equal(t, SlotRange{Start: 100, Stop: 200}, misplaced)
+}
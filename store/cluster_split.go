@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+// AddPendingSlots records the slot ranges as the pending slots of the source shard which owns the
+// first range, nothing is sent to the nodes until the cluster was persisted and MigratePendingSlots
+// is called, so the target shard is always known by the nodes before the slots are migrated.
+func (cluster *Cluster) AddPendingSlots(slotRanges []SlotRange, targetShardIdx int) error {
+	if len(slotRanges) == 0 {
+		return fmt.Errorf("%w: no slots to migrate", consts.ErrInvalidArgument)
+	}
+	if targetShardIdx < 0 || targetShardIdx >= len(cluster.Shards) {
+		return consts.ErrIndexOutOfRange
+	}
+	sourceShardIdx, err := cluster.findShardIndexBySlot(slotRanges[0])
+	if err != nil {
+		return err
+	}
+	if sourceShardIdx == targetShardIdx {
+		return consts.ErrShardIsSame
+	}
+	for _, shardIdx := range []int{sourceShardIdx, targetShardIdx} {
+		if err := cluster.CheckShardFrozen(shardIdx); err != nil {
+			return err
+		}
+		if cluster.Shards[shardIdx].IsMigrating() || cluster.Shards[shardIdx].HasPendingSlots() {
+			return cluster.newMigrationConflictError(shardIdx)
+		}
+	}
+	pendingSlots := make([]SlotRange, len(slotRanges))
+	copy(pendingSlots, slotRanges)
+	cluster.Shards[sourceShardIdx].PendingSlots = pendingSlots
+	cluster.Shards[sourceShardIdx].TargetShardIndex = targetShardIdx
+	return nil
+}
+
+// MigratePendingSlots starts migrating the first pending slot range of the shard,
+// the rest are kept pending until the controller sees the migration succeeded.
+func (cluster *Cluster) MigratePendingSlots(ctx context.Context, shardIdx int) error {
+	shard, err := cluster.GetShard(shardIdx)
+	if err != nil {
+		return err
+	}
+	if !shard.HasPendingSlots() {
+		return fmt.Errorf("%w: no pending slots to migrate", consts.ErrInvalidArgument)
+	}
+	pendingSlots, targetShardIdx := shard.PendingSlots, shard.TargetShardIndex
+	// the pending slots are cleared to pass the conflict check of the migration
+	shard.PendingSlots = nil
+	if err := cluster.MigrateSlotFromShard(ctx, pendingSlots[0], shardIdx, targetShardIdx, false, false); err != nil {
+		shard.PendingSlots = pendingSlots
+		shard.TargetShardIndex = targetShardIdx
+		return err
+	}
+	if len(pendingSlots) > 1 {
+		shard.PendingSlots = make([]SlotRange, len(pendingSlots)-1)
+		copy(shard.PendingSlots, pendingSlots[1:])
+	}
+	return nil
+}
+
+// upperHalfSlots collects the slot ranges from the tail until reaching the count of slots
+func upperHalfSlots(slotRanges []SlotRange, count int) []SlotRange {
+	slotRanges = SlotRanges(slotRanges).Normalize()
+	movedSlots := make([]SlotRange, 0)
+	for i := len(slotRanges) - 1; i >= 0 && count > 0; i-- {
+		slotRange := slotRanges[i]
+		if slotRange.Stop-slotRange.Start+1 > count {
+			slotRange.Start = slotRange.Stop - count + 1
+		}
+		count -= slotRange.Stop - slotRange.Start + 1
+		movedSlots = append([]SlotRange{slotRange}, movedSlots...)
+	}
+	return movedSlots
+}
+
+// SplitShard appends the new shard into the cluster and records the upper half slots of the shard
+// as the pending slots to be moved into it, the first node of the new shard should be the master.
+func (cluster *Cluster) SplitShard(shardIdx int, newShard *Shard) (int, error) {
+	shard, err := cluster.GetShard(shardIdx)
+	if err != nil {
+		return -1, err
+	}
+	if shard.IsMigrating() || shard.HasPendingSlots() {
+		return -1, cluster.newMigrationConflictError(shardIdx)
+	}
+	if newShard.GetMasterNode() == nil {
+		return -1, fmt.Errorf("%w: the new shard has no master node", consts.ErrInvalidArgument)
+	}
+	total := SlotRanges(shard.SlotRanges).Count()
+	if total < 2 {
+		return -1, fmt.Errorf("%w: the shard should have at least 2 slots to split", consts.ErrInvalidArgument)
+	}
+
+	movedSlots := upperHalfSlots(shard.SlotRanges, total/2)
+	cluster.Shards = append(cluster.Shards, newShard)
+	targetShardIdx := len(cluster.Shards) - 1
+	if err := cluster.AddPendingSlots(movedSlots, targetShardIdx); err != nil {
+		cluster.Shards = cluster.Shards[:targetShardIdx]
+		return -1, err
+	}
+	return targetShardIdx, nil
+}
+
+// MergeShard records all slots of the shard as the pending slots to be moved into the target shard,
+// the shard can be removed after all slots were migrated.
+func (cluster *Cluster) MergeShard(shardIdx, targetShardIdx int) error {
+	if shardIdx == targetShardIdx {
+		return consts.ErrShardIsSame
+	}
+	for _, idx := range []int{shardIdx, targetShardIdx} {
+		shard, err := cluster.GetShard(idx)
+		if err != nil {
+			return err
+		}
+		if shard.IsMigrating() || shard.HasPendingSlots() {
+			return cluster.newMigrationConflictError(idx)
+		}
+	}
+	if !cluster.Shards[shardIdx].IsServicing() {
+		return fmt.Errorf("%w: the shard has no slots to merge", consts.ErrInvalidArgument)
+	}
+	slotRanges := make([]SlotRange, len(cluster.Shards[shardIdx].SlotRanges))
+	copy(slotRanges, cluster.Shards[shardIdx].SlotRanges)
+	return cluster.AddPendingSlots(slotRanges, targetShardIdx)
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+func TestUpperHalfSlots(t *testing.T) {
+	require.Equal(t, []SlotRange{{Start: 50, Stop: 99}}, upperHalfSlots([]SlotRange{{Start: 0, Stop: 99}}, 50))
+	require.Equal(t, []SlotRange{{Start: 18, Stop: 19}, {Start: 30, Stop: 39}},
+		upperHalfSlots([]SlotRange{{Start: 0, Stop: 19}, {Start: 30, Stop: 39}}, 12))
+	require.Equal(t, []SlotRange{{Start: 30, Stop: 34}},
+		upperHalfSlots([]SlotRange{{Start: 0, Stop: 4}, {Start: 30, Stop: 34}}, 5))
+}
+
+func TestCluster_SplitAndMergeShard(t *testing.T) {
+	newShard := func(slotRanges ...SlotRange) *Shard {
+		node := NewClusterMockNode()
+		node.SetRole(RoleMaster)
+		shard := NewShard()
+		shard.Nodes = []Node{node}
+		shard.SlotRanges = slotRanges
+		return shard
+	}
+	cluster := &Cluster{Shards: Shards{
+		newShard(SlotRange{Start: 0, Stop: 0}),
+		newShard(SlotRange{Start: 1, Stop: 100}),
+		newShard(SlotRange{Start: 101, Stop: MaxSlotID}),
+	}}
+	_, err := cluster.SplitShard(3, newShard())
+	require.ErrorIs(t, err, consts.ErrIndexOutOfRange)
+	_, err = cluster.SplitShard(0, newShard())
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+	_, err = cluster.SplitShard(1, NewShard())
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+
+	cluster.Shards[2].MigratingSlot = &MigratingSlot{SlotRange: SlotRange{Start: 101, Stop: 101}, IsMigrating: true}
+	cluster.Shards[2].TargetShardIndex = 1
+	_, err = cluster.SplitShard(2, newShard())
+	var conflictErr *MigrationConflictError
+	require.True(t, errors.As(err, &conflictErr))
+	require.ErrorIs(t, cluster.MergeShard(1, 2), consts.ErrConflict)
+	require.ErrorIs(t, cluster.MergeShard(1, 1), consts.ErrShardIsSame)
+	require.ErrorIs(t, cluster.MergeShard(1, 3), consts.ErrIndexOutOfRange)
+	require.Len(t, cluster.Shards, 3)
+
+	cluster.Shards[2].ClearMigrateState()
+	cluster.Shards[0].SlotRanges = nil
+	require.ErrorIs(t, cluster.MergeShard(0, 1), consts.ErrInvalidArgument)
+}
+
+func TestCluster_SplitShardPendingSlots(t *testing.T) {
+	ctx := context.Background()
+	newShard := func(slotRanges ...SlotRange) *Shard {
+		node := NewClusterMockNode()
+		node.SetRole(RoleMaster)
+		shard := NewShard()
+		shard.Nodes = []Node{node}
+		shard.SlotRanges = slotRanges
+		return shard
+	}
+	cluster := &Cluster{Shards: Shards{
+		newShard(SlotRange{Start: 0, Stop: 9}, SlotRange{Start: 20, Stop: 24}, SlotRange{Start: 30, Stop: 34}),
+		newShard(SlotRange{Start: 10, Stop: 19}, SlotRange{Start: 25, Stop: 29}, SlotRange{Start: 35, Stop: MaxSlotID}),
+	}}
+
+	// nothing is sent to the nodes until the pending slots are migrated
+	newShardIndex, err := cluster.SplitShard(0, newShard())
+	require.NoError(t, err)
+	require.Equal(t, 2, newShardIndex)
+	sourceMaster, _ := cluster.Shards[0].GetMasterNode().(*ClusterMockNode)
+	require.Nil(t, sourceMaster.ClusterInfo.MigratingSlot)
+	require.False(t, cluster.Shards[0].IsMigrating())
+	require.True(t, cluster.Shards[0].HasPendingSlots())
+	require.Equal(t, []SlotRange{{Start: 20, Stop: 24}, {Start: 30, Stop: 34}}, cluster.Shards[0].PendingSlots)
+	require.ErrorIs(t, cluster.MergeShard(0, 1), consts.ErrConflict)
+	require.ErrorIs(t, cluster.CheckShardRemovable(2), consts.ErrConflict)
+	require.ErrorIs(t, cluster.MigrateSlot(ctx, SlotRange{Start: 5, Stop: 5}, 1, true), consts.ErrConflict)
+
+	// the failed migration keeps the pending slots
+	sourceMaster.MigrateErr = errors.New("connection refused")
+	require.Error(t, cluster.MigratePendingSlots(ctx, 0))
+	require.Len(t, cluster.Shards[0].PendingSlots, 2)
+	require.Equal(t, 2, cluster.Shards[0].TargetShardIndex)
+	sourceMaster.MigrateErr = nil
+
+	for cluster.Shards[0].HasPendingSlots() {
+		require.NoError(t, cluster.MigratePendingSlots(ctx, 0))
+		require.True(t, cluster.Shards[0].IsMigrating())
+		require.True(t, sourceMaster.ClusterInfo.MigratingSlot.Equal(cluster.Shards[0].MigratingSlot.SlotRange))
+		cluster.MoveSlotToShard(cluster.Shards[0].MigratingSlot.SlotRange, 2)
+		cluster.Shards[0].ClearMigratingSlot()
+	}
+	require.False(t, cluster.Shards[0].IsMigrating())
+	require.Equal(t, -1, cluster.Shards[0].TargetShardIndex)
+	require.Equal(t, []SlotRange{{Start: 0, Stop: 9}}, cluster.Shards[0].SlotRanges)
+	require.Equal(t, []SlotRange{{Start: 20, Stop: 24}, {Start: 30, Stop: 34}}, cluster.Shards[2].SlotRanges)
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+const (
+	ClusterHealthHealthy  = "healthy"
+	ClusterHealthDegraded = "degraded"
+	ClusterHealthUnknown  = "unknown"
+)
+
+// ClusterSummary is the overview of the cluster which is cheap to render in the list
+type ClusterSummary struct {
+	Name    string `json:"name"`
+	Version int64  `json:"version"`
+	Shards  int    `json:"shards"`
+	Nodes   int    `json:"nodes"`
+	// MigratingSlots are the slots which are being migrated, it's empty if no migration
+	MigratingSlots []string `json:"migrating_slots"`
+	Protected      bool     `json:"protected,omitempty"`
+	// Health is the cached health state of the cluster nodes by the controller
+	Health string `json:"health"`
+	// PlacementViolations are the zones which break the placement rules of the cluster
+	PlacementViolations []string `json:"placement_violations,omitempty"`
+}
+
+// Summary returns the overview of the cluster, the health is unknown until it's filled by the caller
+func (cluster *Cluster) Summary() ClusterSummary {
+	summary := ClusterSummary{
+		Name:                cluster.Name,
+		Version:             cluster.Version.Load(),
+		Shards:              len(cluster.Shards),
+		MigratingSlots:      make([]string, 0),
+		Protected:           cluster.Protected,
+		Health:              ClusterHealthUnknown,
+		PlacementViolations: cluster.PlacementViolations(),
+	}
+	for _, shard := range cluster.Shards {
+		summary.Nodes += len(shard.Nodes)
+		if shard.IsMigrating() {
+			summary.MigratingSlots = append(summary.MigratingSlots, shard.MigratingSlot.String())
+		}
+	}
+	return summary
+}
@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_Summary(t *testing.T) {
+	cluster, err := NewCluster("test-cluster",
+		[]string{"127.0.0.1:1111", "127.0.0.1:2222", "127.0.0.1:3333", "127.0.0.1:4444"}, 2)
+	require.NoError(t, err)
+	cluster.Shards[0].MigratingSlot = FromSlotRange(SlotRange{Start: 10, Stop: 20})
+	cluster.Shards[0].TargetShardIndex = 1
+
+	summary := cluster.Summary()
+	require.Equal(t, "test-cluster", summary.Name)
+	require.EqualValues(t, 1, summary.Version)
+	require.Equal(t, 2, summary.Shards)
+	require.Equal(t, 4, summary.Nodes)
+	require.Equal(t, []string{"10-20"}, summary.MigratingSlots)
+	require.Equal(t, ClusterHealthUnknown, summary.Health)
+	require.Empty(t, summary.PlacementViolations)
+
+	_, err = cluster.UpdateNodeLabels(0, cluster.Shards[0].Nodes[0].ID(), map[string]string{LabelZone: "zone-a"})
+	require.NoError(t, err)
+	cluster.Placement = &PlacementRules{MaxZoneSlotsPercent: 40}
+	require.Len(t, cluster.Summary().PlacementViolations, 1)
+}
@@ -22,6 +22,8 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,6 +40,30 @@ func TestCluster_Clone(t *testing.T) {
 	require.Equal(t, cluster.Shards, clusterCopy.Shards)
 }
 
+func TestCluster_WithUpdatedShard(t *testing.T) {
+	cluster, err := NewCluster("test", []string{"node1", "node2", "node3", "node4"}, 2)
+	require.NoError(t, err)
+	slaveID := cluster.Shards[0].Nodes[1].ID()
+
+	updatedCluster, err := cluster.WithUpdatedShard(0, func(shard *Shard) error {
+		shard.Nodes[1].SetRole(RoleMaster)
+		shard.SlotRanges = AddSlotToSlotRanges(shard.SlotRanges, SlotRange{Start: 8192, Stop: 8192})
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, updatedCluster.Shards[0].Nodes[1].IsMaster())
+	require.Equal(t, []SlotRange{{Start: 0, Stop: 8192}}, updatedCluster.Shards[0].SlotRanges)
+	// the original cluster should NOT be changed
+	require.Equal(t, slaveID, cluster.Shards[0].Nodes[1].ID())
+	require.False(t, cluster.Shards[0].Nodes[1].IsMaster())
+	require.Equal(t, []SlotRange{{Start: 0, Stop: 8191}}, cluster.Shards[0].SlotRanges)
+
+	_, err = cluster.WithUpdatedShard(2, func(shard *Shard) error { return nil })
+	require.ErrorIs(t, err, consts.ErrIndexOutOfRange)
+	_, err = cluster.WithUpdate(func(cluster *Cluster) error { return consts.ErrInvalidArgument })
+	require.ErrorIs(t, err, consts.ErrInvalidArgument)
+}
+
 func TestCluster_FindIndexShardBySlot(t *testing.T) {
 	cluster, err := NewCluster("test", []string{"node1", "node2", "node3"}, 1)
 	require.NoError(t, err)
@@ -106,3 +132,220 @@ func TestCluster_PromoteNewMaster(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, node2.ID(), newMasterID)
 }
+
+func TestCluster_RotatePassword(t *testing.T) {
+	master := NewClusterMockNode()
+	master.SetAddr("127.0.0.1:6379")
+	master.SetPassword("old")
+	slave := NewClusterMockNode()
+	slave.SetAddr("127.0.0.1:6380")
+	slave.SetRole(RoleSlave)
+	slave.SetPassword("old")
+	cluster := &Cluster{Name: "test", Shards: []*Shard{{
+		Nodes:            []Node{master, slave},
+		SlotRanges:       []SlotRange{{Start: 0, Stop: MaxSlotID}},
+		TargetShardIndex: -1,
+	}}}
+
+	ctx := context.Background()
+	rotation, err := cluster.RotatePassword(ctx, "new", "")
+	require.NoError(t, err)
+	rotation.Finish()
+	for _, node := range cluster.GetNodes() {
+		require.Equal(t, "new", node.Password())
+		require.Equal(t, "new", node.MasterAuth())
+	}
+
+	rotation, err = cluster.RotatePassword(ctx, "new", "replication")
+	require.NoError(t, err)
+	rotation.Finish()
+	for _, node := range cluster.GetNodes() {
+		require.Equal(t, "new", node.Password())
+		require.Equal(t, "replication", node.MasterAuth())
+	}
+
+	t.Run("roll back the persisting failure", func(t *testing.T) {
+		rotation, err := cluster.RotatePassword(ctx, "newer", "")
+		require.NoError(t, err)
+		var rotationErr *PasswordRotationError
+		require.ErrorAs(t, rotation.Rollback(ctx, errors.New("persist")), &rotationErr)
+		require.Equal(t, map[string]string{master.Addr(): "rolled_back", slave.Addr(): "rolled_back"}, rotationErr.Nodes)
+		for _, node := range cluster.GetNodes() {
+			require.Equal(t, "new", node.Password())
+			require.Equal(t, "replication", node.MasterAuth())
+		}
+	})
+
+	t.Run("report the node states", func(t *testing.T) {
+		master.ChangePasswordErr = errors.New("injected")
+		defer func() { master.ChangePasswordErr = nil }()
+		_, err := cluster.RotatePassword(ctx, "newer", "")
+		var rotationErr *PasswordRotationError
+		require.ErrorAs(t, err, &rotationErr)
+		require.Equal(t, "rolled_back", rotationErr.Nodes[slave.Addr()])
+		require.Equal(t, "rollback_failed: injected", rotationErr.Nodes[master.Addr()])
+		require.Equal(t, "new", slave.Password())
+		require.Equal(t, "replication", slave.MasterAuth())
+	})
+}
+
+func TestCluster_Endpoints(t *testing.T) {
+	cluster, err := NewCluster("test", []string{"127.0.0.1:6379", "127.0.0.1:6380", "127.0.0.1:6381", "127.0.0.1:6382"}, 2)
+	require.NoError(t, err)
+
+	endpoints := cluster.Endpoints()
+	require.Len(t, endpoints, 2)
+	require.Equal(t, 0, endpoints[0].Start)
+	require.Equal(t, "127.0.0.1:6379", endpoints[0].Master.Addr)
+	require.Len(t, endpoints[0].Replicas, 1)
+	require.Equal(t, "127.0.0.1:6380", endpoints[0].Replicas[0].Addr)
+	require.Equal(t, MaxSlotID, endpoints[1].Stop)
+
+	slots := cluster.ClusterSlotsReply()
+	require.Len(t, slots, 2)
+	require.Equal(t, []interface{}{0, 8191,
+		[]interface{}{"127.0.0.1", 6379, cluster.Shards[0].Nodes[0].ID()},
+		[]interface{}{"127.0.0.1", 6380, cluster.Shards[0].Nodes[1].ID()},
+	}, slots[0])
+
+	shards := cluster.ClusterShardsReply()
+	require.Len(t, shards, 2)
+}
+
+func TestCluster_ResetNodes(t *testing.T) {
+	ctx := context.Background()
+	node0 := NewClusterMockNode()
+	node0.SetRole(RoleMaster)
+	node1 := NewClusterMockNode()
+	node1.SetRole(RoleSlave)
+	shard := NewShard()
+	shard.Nodes = []Node{node0, node1}
+	cluster := &Cluster{Shards: Shards{shard}}
+
+	results, ok := cluster.ResetNodes(ctx, true)
+	require.True(t, ok)
+	require.Len(t, results, 2)
+	require.Equal(t, node0.ID(), results[0].ID)
+	require.Empty(t, results[0].Error)
+
+	unreachableNode := NewClusterNode("127.0.0.1:1", "")
+	unreachableNode.SetRole(RoleSlave)
+	shard.Nodes = append(shard.Nodes, unreachableNode)
+	results, ok = cluster.ResetNodes(ctx, false)
+	require.False(t, ok)
+	require.Len(t, results, 3)
+	require.NotEmpty(t, results[2].Error)
+}
+
+func TestCluster_MigrationConflict(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1", "node2"}, 1)
+	require.NoError(t, err)
+	cluster.Shards[0].MigratingSlot = &MigratingSlot{SlotRange: SlotRange{Start: 10, Stop: 20}, IsMigrating: true}
+	cluster.Shards[0].TargetShardIndex = 1
+
+	err = cluster.MigrateSlot(context.Background(), SlotRange{Start: 15, Stop: 15}, 2, true)
+	var conflictErr *MigrationConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.ErrorIs(t, err, consts.ErrConflict)
+	require.Equal(t, 0, conflictErr.ShardIndex)
+	require.Equal(t, 1, conflictErr.TargetShardIndex)
+	require.ErrorIs(t, cluster.CheckShardRemovable(0), consts.ErrConflict)
+	require.ErrorIs(t, cluster.CheckShardRemovable(1), consts.ErrConflict)
+	require.NoError(t, cluster.CheckShardRemovable(2))
+	require.NoError(t, cluster.MigrateSlot(context.Background(), SlotRange{Start: 21, Stop: 21}, 2, true))
+}
+
+func TestCluster_MigrateSlotFromShard(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1", "node2"}, 1)
+	require.NoError(t, err)
+	slot := SlotRange{Start: 0, Stop: 0}
+
+	require.ErrorIs(t, cluster.MigrateSlotFromShard(ctx, slot, 3, 2, true, false), consts.ErrIndexOutOfRange)
+	// the source shard should own the slot unless it's forced
+	require.ErrorIs(t, cluster.MigrateSlotFromShard(ctx, slot, 1, 2, true, false), consts.ErrInvalidArgument)
+	require.ErrorIs(t, cluster.MigrateSlotFromShard(ctx, slot, 1, 1, true, true), consts.ErrShardIsSame)
+	require.NoError(t, cluster.MigrateSlotFromShard(ctx, slot, 1, 2, true, true))
+
+	// the slot should be removed from the shard which owns it in the metadata
+	require.False(t, (*SlotRanges)(&cluster.Shards[0].SlotRanges).Contains(0))
+	require.True(t, (*SlotRanges)(&cluster.Shards[2].SlotRanges).Contains(0))
+	ownerShardIdx, err := cluster.findShardIndexBySlot(slot)
+	require.NoError(t, err)
+	require.Equal(t, 2, ownerShardIdx)
+
+	require.NoError(t, cluster.MigrateSlotFromShard(ctx, slot, 2, 0, true, false))
+	require.True(t, (*SlotRanges)(&cluster.Shards[0].SlotRanges).Contains(0))
+}
+
+func TestCluster_FrozenShard(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster("test-cluster", []string{"node0", "node1", "node2"}, 1)
+	require.NoError(t, err)
+	_, err = cluster.SetShardFrozen(1, true)
+	require.NoError(t, err)
+	require.True(t, cluster.Clone().Shards[1].Frozen)
+
+	nodeID := cluster.Shards[1].Nodes[0].ID()
+	require.ErrorIs(t, cluster.CheckShardFrozen(1), consts.ErrShardIsFrozen)
+	require.NoError(t, cluster.CheckShardFrozen(0))
+	_, err = cluster.PromoteNewMaster(ctx, 1, nodeID, "")
+	require.ErrorIs(t, err, consts.ErrForbidden)
+	require.ErrorIs(t, cluster.RemoveNode(1, nodeID), consts.ErrForbidden)
+	_, err = cluster.AddNode(1, "node3", RoleSlave, "")
+	require.ErrorIs(t, err, consts.ErrForbidden)
+	// the slots can't be migrated from or to the frozen shard
+	slot := SlotRange{Start: 0, Stop: 0}
+	require.ErrorIs(t, cluster.MigrateSlot(ctx, slot, 1, true), consts.ErrShardIsFrozen)
+	require.ErrorIs(t, cluster.MigrateSlotFromShard(ctx, cluster.Shards[1].SlotRanges[0], 1, 2, true, false),
+		consts.ErrShardIsFrozen)
+	require.NoError(t, cluster.MigrateSlot(ctx, slot, 2, true))
+
+	_, err = cluster.SetShardFrozen(1, false)
+	require.NoError(t, err)
+	_, err = cluster.AddNode(1, "node3", RoleSlave, "")
+	require.NoError(t, err)
+}
+
+func TestCluster_Annotations(t *testing.T) {
+	cluster, err := NewCluster("test-cluster", []string{"node0"}, 1)
+	require.NoError(t, err)
+	cluster.Description = "user cache"
+	cluster.UpdateAnnotations(map[string]string{"owner": "team-a", "env": "prod"})
+	cluster.UpdateAnnotations(map[string]string{"env": "", "ticket": "OPS-1"})
+	require.Equal(t, map[string]string{"owner": "team-a", "ticket": "OPS-1"}, cluster.Annotations)
+
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	var got Cluster
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, "user cache", got.Description)
+	require.Equal(t, cluster.Annotations, got.Annotations)
+
+	cloned := cluster.Clone()
+	require.Equal(t, "user cache", cloned.Description)
+	require.Equal(t, cluster.Annotations, cloned.Annotations)
+	cloned.UpdateAnnotations(map[string]string{"owner": ""})
+	require.Equal(t, "team-a", cluster.Annotations["owner"])
+
+	cluster.UpdateAnnotations(map[string]string{"owner": "", "ticket": ""})
+	require.Nil(t, cluster.Annotations)
+}
+
+func TestCluster_ReplaceNodeAddr(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster("test-cluster", []string{"127.0.0.1:7000", "127.0.0.1:7001"}, 2)
+	require.NoError(t, err)
+	slave := cluster.Shards[0].Nodes[1]
+
+	_, err = cluster.ReplaceNodeAddr(ctx, 0, "not-exists", "127.0.0.1:7002")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	_, err = cluster.ReplaceNodeAddr(ctx, 0, slave.ID(), "127.0.0.1:7000")
+	require.ErrorIs(t, err, consts.ErrAlreadyExists)
+
+	node, err := cluster.ReplaceNodeAddr(ctx, 0, slave.ID(), "127.0.0.1:7002")
+	require.NoError(t, err)
+	require.Equal(t, slave.ID(), node.ID())
+	require.Equal(t, "127.0.0.1:7002", cluster.Shards[0].Nodes[1].Addr())
+	require.False(t, cluster.Shards[0].Nodes[1].IsMaster())
+}
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/metrics"
+)
+
+// ClusterMutation changes the cluster in memory, the cluster won't be changed if it returns an error
+type ClusterMutation func(cluster *Cluster) error
+
+type pendingMutation struct {
+	mutate ClusterMutation
+	// version is the cluster version which the mutation was validated against, 0 means any version
+	version int64
+	done    chan error
+}
+
+type mutationBatch struct {
+	ns        string
+	cluster   string
+	mutations []*pendingMutation
+}
+
+// ClusterUpdater coalesces the mutations of the same cluster within the window into a single
+// UpdateCluster, so the rapid successive changes like adding many nodes bump the version and
+// sync the topology to the nodes only once. The mutations are applied immediately if the window is 0.
+type ClusterUpdater struct {
+	s      Store
+	window time.Duration
+
+	mu      sync.Mutex
+	batches map[string]*mutationBatch
+}
+
+func NewClusterUpdater(s Store, window time.Duration) *ClusterUpdater {
+	return &ClusterUpdater{
+		s:       s,
+		window:  window,
+		batches: make(map[string]*mutationBatch),
+	}
+}
+
+// Update applies the mutation to the latest cluster and waits until it was persisted. The mutations
+// in the same batch are applied in order, and the failed mutation won't affect the others.
+// The version is the cluster version validated by If-Match, the mutation is applied alone and fails
+// with the version mismatch if the cluster was changed since then. It's 0 if there's no precondition.
+func (u *ClusterUpdater) Update(ctx context.Context, ns, cluster string, version int64, mutate ClusterMutation) error {
+	mutation := &pendingMutation{mutate: mutate, version: version, done: make(chan error, 1)}
+	metrics.Get().ClusterUpdateMutations.WithLabelValues(metrics.ClusterLabelValues(ns, cluster)...).Inc()
+	if u.window <= 0 || version > 0 {
+		u.flush(ctx, &mutationBatch{ns: ns, cluster: cluster, mutations: []*pendingMutation{mutation}})
+		return <-mutation.done
+	}
+
+	key := ns + "/" + cluster
+	u.mu.Lock()
+	batch, ok := u.batches[key]
+	if !ok {
+		batch = &mutationBatch{ns: ns, cluster: cluster}
+		u.batches[key] = batch
+		time.AfterFunc(u.window, func() {
+			u.mu.Lock()
+			delete(u.batches, key)
+			u.mu.Unlock()
+			u.flush(context.Background(), batch)
+		})
+	}
+	batch.mutations = append(batch.mutations, mutation)
+	u.mu.Unlock()
+	// the mutation can't be canceled after it was queued, so wait for the result regardless of the context
+	return <-mutation.done
+}
+
+func (u *ClusterUpdater) flush(ctx context.Context, batch *mutationBatch) {
+	finish := func(mutations []*pendingMutation, err error) {
+		for _, mutation := range mutations {
+			mutation.done <- err
+		}
+	}
+
+	cluster, err := u.s.GetCluster(ctx, batch.ns, batch.cluster)
+	if err != nil {
+		finish(batch.mutations, err)
+		return
+	}
+	applied := make([]*pendingMutation, 0, len(batch.mutations))
+	for _, mutation := range batch.mutations {
+		if mutation.version > 0 && mutation.version != cluster.Version.Load() {
+			// the version was validated by If-Match, so it's the failed precondition
+			mutation.done <- fmt.Errorf("%w: %w", consts.ErrPreconditionFailed,
+				&consts.VersionMismatchError{Resource: "cluster", CurrentVersion: cluster.Version.Load()})
+			continue
+		}
+		newCluster, err := cluster.WithUpdate(mutation.mutate)
+		if err != nil {
+			mutation.done <- err
+			continue
+		}
+		cluster = newCluster
+		applied = append(applied, mutation)
+	}
+	if len(applied) == 0 {
+		return
+	}
+
+	metrics.Get().ClusterUpdateWrites.WithLabelValues(metrics.ClusterLabelValues(batch.ns, batch.cluster)...).Inc()
+	err = u.s.UpdateCluster(ctx, batch.ns, cluster)
+	if len(applied) > 1 {
+		logger.Get().With(
+			zap.String("namespace", batch.ns),
+			zap.String("cluster", batch.cluster),
+			zap.Int("mutations", len(applied)),
+			zap.Error(err),
+		).Info("Coalesced the cluster mutations into a single update")
+	}
+	finish(applied, err)
+}
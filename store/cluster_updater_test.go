@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterUpdater(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("cluster0", []string{"127.0.0.1:6666"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns0", cluster))
+	version := cluster.Version.Load()
+
+	annotate := func(key string) ClusterMutation {
+		return func(cluster *Cluster) error {
+			cluster.UpdateAnnotations(map[string]string{key: "true"})
+			return nil
+		}
+	}
+
+	t.Run("update immediately without the window", func(t *testing.T) {
+		updater := NewClusterUpdater(s, 0)
+		require.NoError(t, updater.Update(ctx, "ns0", "cluster0", 0, annotate("a0")))
+		require.NoError(t, updater.Update(ctx, "ns0", "cluster0", 0, annotate("a1")))
+
+		gotCluster, err := s.GetCluster(ctx, "ns0", "cluster0")
+		require.NoError(t, err)
+		require.Equal(t, version+2, gotCluster.Version.Load())
+		require.Equal(t, map[string]string{"a0": "true", "a1": "true"}, gotCluster.Annotations)
+	})
+
+	t.Run("coalesce the mutations within the window", func(t *testing.T) {
+		updater := NewClusterUpdater(s, 100*time.Millisecond)
+		errs := make([]error, 5)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if i == 2 {
+					errs[i] = updater.Update(ctx, "ns0", "cluster0", 0, func(cluster *Cluster) error {
+						cluster.Description = "the failed mutation won't be applied"
+						return errors.New("mutation failed")
+					})
+					return
+				}
+				errs[i] = updater.Update(ctx, "ns0", "cluster0", 0, annotate(fmt.Sprintf("b%d", i)))
+			}()
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if i == 2 {
+				require.EqualError(t, err, "mutation failed")
+			} else {
+				require.NoError(t, err)
+			}
+		}
+
+		gotCluster, err := s.GetCluster(ctx, "ns0", "cluster0")
+		require.NoError(t, err)
+		require.Equal(t, version+3, gotCluster.Version.Load())
+		require.Empty(t, gotCluster.Description)
+		for _, key := range []string{"b0", "b1", "b3", "b4"} {
+			require.Equal(t, "true", gotCluster.Annotations[key])
+		}
+	})
+
+	t.Run("fail all mutations if the cluster doesn't exist", func(t *testing.T) {
+		updater := NewClusterUpdater(s, 10*time.Millisecond)
+		require.Error(t, updater.Update(ctx, "ns0", "not-exists", 0, annotate("c0")))
+	})
+
+	t.Run("apply the mutation alone if the version was validated", func(t *testing.T) {
+		updater := NewClusterUpdater(s, time.Hour)
+		gotCluster, err := s.GetCluster(ctx, "ns0", "cluster0")
+		require.NoError(t, err)
+		validated := gotCluster.Version.Load()
+		require.NoError(t, updater.Update(ctx, "ns0", "cluster0", validated, annotate("d0")))
+
+		// the cluster was changed since the version was validated
+		err = updater.Update(ctx, "ns0", "cluster0", validated, annotate("d1"))
+		require.ErrorIs(t, err, consts.ErrPreconditionFailed)
+		gotCluster, err = s.GetCluster(ctx, "ns0", "cluster0")
+		require.NoError(t, err)
+		require.Equal(t, validated+1, gotCluster.Version.Load())
+		require.Equal(t, "true", gotCluster.Annotations["d0"])
+		require.NotContains(t, gotCluster.Annotations, "d1")
+	})
+}
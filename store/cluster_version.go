@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+// clusterVersionPrefix is the prefix of the highest version ever persisted for each cluster,
+// it's used to reject the stale topology even if the cluster was rolled back by mistake.
+const clusterVersionPrefix = "/kvrocks/cluster_versions"
+
+func buildClusterVersionKey(ns, cluster string) string {
+	return fmt.Sprintf("%s/%s/%s", clusterVersionPrefix, ns, cluster)
+}
+
+func (s *ClusterStore) getHighestVersion(ctx context.Context, ns, cluster string) (int64, error) {
+	value, err := s.e.Get(ctx, buildClusterVersionKey(ns, cluster))
+	if errors.Is(err, consts.ErrNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+// highestVersionOps returns the write of the version if it's higher than the highest version,
+// it's written with the cluster so that the cluster can't be persisted without raising it.
+func (s *ClusterStore) highestVersionOps(ctx context.Context, ns, cluster string, version int64) ([]engine.Op, error) {
+	highestVersion, err := s.getHighestVersion(ctx, ns, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if version <= highestVersion {
+		return nil, nil
+	}
+	return []engine.Op{engine.OpSet(buildClusterVersionKey(ns, cluster), []byte(strconv.FormatInt(version, 10)))}, nil
+}
+
+// checkVersionRegression returns the conflict error if the version of the cluster
+// is lower than the highest version ever persisted.
+func (s *ClusterStore) checkVersionRegression(ctx context.Context, ns string, cluster *Cluster) error {
+	highestVersion, err := s.getHighestVersion(ctx, ns, cluster.Name)
+	if err != nil {
+		return err
+	}
+	version := cluster.Version.Load()
+	if version >= highestVersion {
+		return nil
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster.Name),
+		zap.Int64("version", version),
+		zap.Int64("highest_version", highestVersion),
+	).Error("Reject the cluster topology since its version regressed")
+	return &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: highestVersion}
+}
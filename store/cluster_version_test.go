@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_VersionRegression(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.UpdateCluster(ctx, "ns", cluster))
+	}
+	require.EqualValues(t, 4, cluster.Version.Load())
+
+	// the cluster was rolled back by mistake, e.g. restored from an old snapshot
+	staleCluster := cluster.Clone()
+	staleCluster.Version.Store(2)
+	data, err := staleCluster.MarshalJSON()
+	require.NoError(t, err)
+	require.NoError(t, s.e.Set(ctx, buildClusterKey("ns", "cluster"), data))
+
+	replayedCluster := cluster.Clone()
+	replayedCluster.Version.Store(3)
+	var mismatchErr *consts.VersionMismatchError
+	require.ErrorAs(t, s.SetCluster(ctx, "ns", replayedCluster), &mismatchErr)
+	require.EqualValues(t, 4, mismatchErr.CurrentVersion)
+	require.ErrorIs(t, mismatchErr, consts.ErrVersionMismatch)
+
+	replayedCluster.Version.Store(5)
+	require.NoError(t, s.SetCluster(ctx, "ns", replayedCluster))
+	highestVersion, err := s.getHighestVersion(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	require.EqualValues(t, 5, highestVersion)
+
+	// the recreated cluster starts from the initial version
+	require.NoError(t, s.RemoveCluster(ctx, "ns", "cluster"))
+	highestVersion, err = s.getHighestVersion(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, highestVersion)
+}
+
+func TestClusterStore_RaiseVersionWithCluster(t *testing.T) {
+	ctx := context.Background()
+	e := engine.NewMock()
+	s := NewClusterStore(e)
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+
+	// the cluster isn't written if the highest version can't be raised with it
+	e.WithKeyFailure(buildClusterVersionKey("ns", "cluster"), nil)
+	require.ErrorIs(t, s.UpdateCluster(ctx, "ns", cluster.Clone()), engine.ErrInjected)
+	got, err := s.GetCluster(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, got.Version.Load())
+
+	e.ResetFaults()
+	require.NoError(t, s.UpdateCluster(ctx, "ns", got))
+	highestVersion, err := s.getHighestVersion(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, highestVersion)
+}
+
+func TestClusterStore_RemoveClusterWithData(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	for _, s := range []*ClusterStore{NewClusterStore(mock), NewClusterStore(nonTransactionalEngine{mock})} {
+		cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111"}, 1)
+		require.NoError(t, err)
+		require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+		require.NoError(t, s.UpdateCluster(ctx, "ns", cluster.Clone()))
+		require.NoError(t, s.SaveJob(ctx, "ns", "cluster", &Job{ID: "0", Type: JobTypeCompaction, Status: JobStatusRunning}))
+
+		// the cluster isn't removed if its jobs can't be removed with it
+		mock.WithKeyFailure(buildJobKey("ns", "cluster", JobTypeCompaction), nil)
+		require.ErrorIs(t, s.RemoveCluster(ctx, "ns", "cluster"), engine.ErrInjected)
+		mock.ResetFaults()
+		_, err = s.GetCluster(ctx, "ns", "cluster")
+		require.NoError(t, err)
+
+		require.NoError(t, s.RemoveCluster(ctx, "ns", "cluster"))
+		_, err = s.GetCluster(ctx, "ns", "cluster")
+		require.ErrorIs(t, err, consts.ErrNotFound)
+		highestVersion, err := s.getHighestVersion(ctx, "ns", "cluster")
+		require.NoError(t, err)
+		require.Zero(t, highestVersion)
+		revisions, err := s.ListClusterRevisions(ctx, "ns", "cluster")
+		require.NoError(t, err)
+		require.Empty(t, revisions)
+		jobs, err := s.ListJobs(ctx, "ns", "cluster", JobTypeCompaction)
+		require.NoError(t, err)
+		require.Empty(t, jobs)
+	}
+}
@@ -0,0 +1,261 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+)
+
+// SRVScheme is the prefix of the node address which should be expanded by the SRV record,
+// e.g. srv://_kvrocks._tcp.kvrocks.default.svc.cluster.local
+const SRVScheme = "srv://"
+
+// ResolvedAddr is the node address resolved from the hostname,
+// Hostname is empty if the node was added by the IP.
+type ResolvedAddr struct {
+	Addr     string
+	Hostname string
+}
+
+func expandSRVAddr(ctx context.Context, addr string) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", strings.TrimPrefix(addr, SRVScheme))
+	if err != nil {
+		return nil, fmt.Errorf("lookup SRV record of %s: %w", addr, err)
+	}
+	addrs := make([]string, 0, len(records))
+	for _, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(target, strconv.Itoa(int(record.Port))))
+	}
+	// the order of SRV records is randomized by the weight, sort them to make it stable
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// ResolveNodeAddr resolves the hostname of the address into the IP,
+// the address will be returned as it is if the host is already an IP.
+func ResolveNodeAddr(ctx context.Context, addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid node address %s", err, addr)
+	}
+	if net.ParseIP(host) != nil {
+		return addr, nil
+	}
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("lookup host %s: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no IP found for host %s", host)
+	}
+	sort.Strings(ips)
+	return net.JoinHostPort(ips[0], port), nil
+}
+
+// ResolveNodeAddrs expands the SRV records and resolves the hostnames of the addresses
+func ResolveNodeAddrs(ctx context.Context, addrs []string) ([]ResolvedAddr, error) {
+	resolvedAddrs := make([]ResolvedAddr, 0, len(addrs))
+	for _, addr := range addrs {
+		hostAddrs := []string{addr}
+		if strings.HasPrefix(addr, SRVScheme) {
+			var err error
+			if hostAddrs, err = expandSRVAddr(ctx, addr); err != nil {
+				return nil, err
+			}
+		}
+		for _, hostAddr := range hostAddrs {
+			resolved, err := ResolveNodeAddr(ctx, hostAddr)
+			if err != nil {
+				return nil, err
+			}
+			resolvedAddr := ResolvedAddr{Addr: resolved}
+			if resolved != hostAddr {
+				resolvedAddr.Hostname = hostAddr
+			}
+			resolvedAddrs = append(resolvedAddrs, resolvedAddr)
+		}
+	}
+	return resolvedAddrs, nil
+}
+
+// NewResolvedCluster creates the cluster with the nodes which may be DNS names or SRV records,
+// the hostnames are kept in the nodes so that they can be re-resolved when the backends move.
+func NewResolvedCluster(ctx context.Context, name string, addrs []string, replicas int) (*Cluster, error) {
+	resolvedAddrs, err := ResolveNodeAddrs(ctx, addrs)
+	if err != nil {
+		return nil, err
+	}
+	nodeAddrs := make([]string, 0, len(resolvedAddrs))
+	for _, resolvedAddr := range resolvedAddrs {
+		nodeAddrs = append(nodeAddrs, resolvedAddr.Addr)
+	}
+	cluster, err := NewCluster(name, nodeAddrs, replicas)
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range cluster.GetNodes() {
+		if clusterNode, ok := node.(*ClusterNode); ok {
+			clusterNode.SetHostname(resolvedAddrs[i].Hostname)
+		}
+	}
+	return cluster, nil
+}
+
+// AddResolvedNode adds the node which may be a DNS name or a SRV record with exactly one target
+func (cluster *Cluster) AddResolvedNode(ctx context.Context, shardIndex int, addr, role, password string) (*ClusterNode, error) {
+	resolvedAddrs, err := ResolveNodeAddrs(ctx, []string{addr})
+	if err != nil {
+		return nil, err
+	}
+	if len(resolvedAddrs) != 1 {
+		return nil, fmt.Errorf("%w: %s should be resolved to exactly one node, but got %d",
+			consts.ErrInvalidArgument, addr, len(resolvedAddrs))
+	}
+	node, err := cluster.AddNode(shardIndex, resolvedAddrs[0].Addr, role, password)
+	if err != nil {
+		return nil, err
+	}
+	node.SetHostname(resolvedAddrs[0].Hostname)
+	return node, nil
+}
+
+// RefreshAddrs re-resolves the nodes which were added by hostnames, and returns true if any
+// node address was changed. The nodes of the frozen shards are skipped since their topology can't be changed.
+func (cluster *Cluster) RefreshAddrs(ctx context.Context) (bool, error) {
+	changed := false
+	var errs []error
+	for _, shard := range cluster.Shards {
+		if shard.Frozen {
+			continue
+		}
+		for _, node := range shard.Nodes {
+			clusterNode, ok := node.(*ClusterNode)
+			if !ok || clusterNode.Hostname() == "" {
+				continue
+			}
+			addr, err := ResolveNodeAddr(ctx, clusterNode.Hostname())
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if addr != clusterNode.Addr() {
+				logger.Get().With(
+					zap.String("cluster", cluster.Name),
+					zap.String("hostname", clusterNode.Hostname()),
+					zap.String("old_addr", clusterNode.Addr()),
+					zap.String("new_addr", addr),
+				).Info("The resolved address of the node was changed")
+				clusterNode.SetAddr(addr)
+				changed = true
+			}
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// InheritClusterStates copies the states which are only kept by the controller, e.g. the metadata of the cluster,
+// the frozen marks and the migrating states of the shards, and the node states by InheritNodeStates. It's used when
+// adopting the node-reported topology which only carries the nodes and the slots.
+func (cluster *Cluster) InheritClusterStates(from *Cluster) {
+	cluster.Name = from.Name
+	cluster.Description = from.Description
+	cluster.Annotations = maps.Clone(from.Annotations)
+	cluster.Labels = maps.Clone(from.Labels)
+	cluster.HealthCheck = from.HealthCheck
+	cluster.Compaction = from.Compaction
+	cluster.Placement = from.Placement
+	cluster.Protected = from.Protected
+	if len(from.Shards) > 0 && len(from.Shards[0].Nodes) > 0 {
+		cluster.SetPassword(from.Shards[0].Nodes[0].Password())
+		cluster.SetMasterAuth(from.Shards[0].Nodes[0].MasterAuth())
+	}
+
+	// the shards are matched by the node IDs since the node-reported shards are sorted by the slots
+	nodeShards := make(map[string]int)
+	for i, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			nodeShards[node.ID()] = i
+		}
+	}
+	shardIndexes := make(map[int]int)
+	for i, fromShard := range from.Shards {
+		for _, node := range fromShard.Nodes {
+			if index, ok := nodeShards[node.ID()]; ok {
+				shardIndexes[i] = index
+				break
+			}
+		}
+	}
+	for fromIndex, index := range shardIndexes {
+		fromShard, shard := from.Shards[fromIndex], cluster.Shards[index]
+		shard.Frozen = fromShard.Frozen
+		targetIndex, ok := shardIndexes[fromShard.TargetShardIndex]
+		if !ok || (fromShard.MigratingSlot == nil && !fromShard.HasPendingSlots()) {
+			continue
+		}
+		if fromShard.MigratingSlot != nil {
+			migratingSlot := *fromShard.MigratingSlot
+			shard.MigratingSlot = &migratingSlot
+		}
+		shard.PendingSlots = slices.Clone(fromShard.PendingSlots)
+		shard.MigrationID = fromShard.MigrationID
+		shard.TargetShardIndex = targetIndex
+	}
+	cluster.InheritNodeStates(from)
+}
+
+// InheritNodeStates copies the node states which are not carried by the topology, e.g. the hostname,
+// the labels, the restoring and syncing marks, from the nodes with the same ID. It's used when adopting the node-reported
+// topology, so that the nodes added by hostnames can still be re-resolved after that.
+func (cluster *Cluster) InheritNodeStates(from *Cluster) {
+	nodes := make(map[string]Node)
+	for _, node := range from.GetNodes() {
+		nodes[node.ID()] = node
+	}
+	for _, node := range cluster.GetNodes() {
+		fromNode, ok := nodes[node.ID()]
+		if !ok {
+			continue
+		}
+		node.SetRestoring(fromNode.IsRestoring())
+		node.SetSyncing(fromNode.IsSyncing())
+		clusterNode, ok := node.(*ClusterNode)
+		fromClusterNode, fromOK := fromNode.(*ClusterNode)
+		if ok && fromOK {
+			clusterNode.SetHostname(fromClusterNode.Hostname())
+			clusterNode.labels = fromClusterNode.labels
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveNodeAddrs(t *testing.T) {
+	ctx := context.Background()
+	resolvedAddrs, err := ResolveNodeAddrs(ctx, []string{"127.0.0.1:6379", "localhost:6380"})
+	require.NoError(t, err)
+	require.Equal(t, []ResolvedAddr{
+		{Addr: "127.0.0.1:6379"},
+		{Addr: "127.0.0.1:6380", Hostname: "localhost:6380"},
+	}, resolvedAddrs)
+
+	_, err = ResolveNodeAddrs(ctx, []string{"127.0.0.1"})
+	require.Error(t, err)
+}
+
+func TestCluster_RefreshAddrs(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewResolvedCluster(ctx, "test", []string{"localhost:6379", "127.0.0.1:6380"}, 1)
+	require.NoError(t, err)
+
+	changed, err := cluster.RefreshAddrs(ctx)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	node0, _ := cluster.Shards[0].Nodes[0].(*ClusterNode)
+	require.Equal(t, "localhost:6379", node0.Hostname())
+	node0.SetAddr("10.0.0.1:6379")
+	changed, err = cluster.RefreshAddrs(ctx)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "127.0.0.1:6379", node0.Addr())
+
+	// the nodes of the frozen shard are kept
+	cluster.Shards[0].Frozen = true
+	node0.SetAddr("10.0.0.1:6379")
+	changed, err = cluster.RefreshAddrs(ctx)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, "10.0.0.1:6379", node0.Addr())
+}
+
+func TestCluster_InheritNodeStates(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewResolvedCluster(ctx, "test", []string{"localhost:6379", "127.0.0.1:6380"}, 1)
+	require.NoError(t, err)
+	cluster.Shards[1].Nodes[0].SetRestoring(true)
+
+	// the node-reported topology only carries the resolved addresses
+	latestCluster := cluster.Clone()
+	for i, shard := range cluster.Shards {
+		node := NewClusterNode(shard.Nodes[0].Addr(), "")
+		node.id = shard.Nodes[0].ID()
+		node.SetRole(RoleMaster)
+		latestCluster.Shards[i].Nodes = []Node{node}
+	}
+	latestCluster.InheritNodeStates(cluster)
+
+	node0, _ := latestCluster.Shards[0].Nodes[0].(*ClusterNode)
+	require.Equal(t, "localhost:6379", node0.Hostname())
+	require.False(t, node0.IsRestoring())
+	node1, _ := latestCluster.Shards[1].Nodes[0].(*ClusterNode)
+	require.Empty(t, node1.Hostname())
+	require.True(t, node1.IsRestoring())
+}
+
+func TestCluster_InheritClusterStates(t *testing.T) {
+	cluster, err := NewCluster("test", []string{"127.0.0.1:6379", "127.0.0.1:6380", "127.0.0.1:6381"}, 1)
+	require.NoError(t, err)
+	cluster.Description = "user cache"
+	cluster.Annotations = map[string]string{"owner": "team-a"}
+	cluster.Labels = map[string]string{"env": "prod"}
+	cluster.Protected = true
+	cluster.SetPassword("secret")
+	cluster.Shards[0].MigratingSlot = FromSlotRange(SlotRange{Start: 0, Stop: 0})
+	cluster.Shards[0].PendingSlots = []SlotRange{{Start: 1, Stop: 100}}
+	cluster.Shards[0].TargetShardIndex = 2
+	cluster.Shards[0].MigrationID = "test-migration"
+	cluster.Shards[1].Frozen = true
+
+	// the node-reported topology only carries the nodes and slots, and its shards are in another order
+	latestCluster := &Cluster{}
+	for i := len(cluster.Shards) - 1; i >= 0; i-- {
+		node := NewClusterNode(cluster.Shards[i].Nodes[0].Addr(), "")
+		node.id = cluster.Shards[i].Nodes[0].ID()
+		node.SetRole(RoleMaster)
+		shard := NewShard()
+		shard.Nodes = []Node{node}
+		shard.SlotRanges = cluster.Shards[i].SlotRanges
+		latestCluster.Shards = append(latestCluster.Shards, shard)
+	}
+	latestCluster.InheritClusterStates(cluster)
+
+	require.Equal(t, "test", latestCluster.Name)
+	require.Equal(t, "user cache", latestCluster.Description)
+	require.Equal(t, map[string]string{"owner": "team-a"}, latestCluster.Annotations)
+	require.Equal(t, map[string]string{"env": "prod"}, latestCluster.Labels)
+	require.True(t, latestCluster.Protected)
+	require.Equal(t, "secret", latestCluster.Shards[0].Nodes[0].Password())
+	// the shard 0 is the last one and its target shard 2 is the first one
+	migratingShard := latestCluster.Shards[2]
+	require.True(t, migratingShard.IsMigrating())
+	require.Equal(t, "test-migration", migratingShard.MigrationID)
+	require.Equal(t, 0, migratingShard.TargetShardIndex)
+	require.Equal(t, []SlotRange{{Start: 1, Stop: 100}}, migratingShard.PendingSlots)
+	require.True(t, latestCluster.Shards[1].Frozen)
+	require.False(t, latestCluster.Shards[0].Frozen)
+	require.False(t, latestCluster.Shards[0].IsMigrating())
+}
@@ -52,6 +52,10 @@ type Config struct {
 		CAFile   string `yaml:"ca_file"`
 	} `yaml:"tls"`
 	ElectPath string `yaml:"elect_path"`
+	// OperationTimeoutMs bounds the operation if the caller has no deadline, default is 5000.
+	OperationTimeoutMs int64 `yaml:"operation_timeout_ms"`
+	// Standby observes the leader without campaigning, it's set by the standby mode of the controller.
+	Standby bool `yaml:"-"`
 }
 
 type Consul struct {
@@ -62,8 +66,11 @@ type Consul struct {
 	leaderID  string
 	myID      string
 	electPath string
+	standby   bool
 	isReady   atomic.Bool
 
+	operationTimeout time.Duration
+
 	leaderChangeCh chan bool
 	electionCh     chan bool
 	lockReleaseCh  chan bool
@@ -115,19 +122,24 @@ func New(id string, cfg *Config) (*Consul, error) {
 	}
 
 	c := &Consul{
-		myID:           id,
-		electPath:      electPath,
-		client:         client,
-		watchPlan:      watchPlan,
-		leaderChangeCh: make(chan bool),
-		lockReleaseCh:  make(chan bool),
-		electionCh:     make(chan bool),
-		quitCh:         make(chan bool),
+		myID:             id,
+		electPath:        electPath,
+		standby:          cfg.Standby,
+		operationTimeout: time.Duration(cfg.OperationTimeoutMs) * time.Millisecond,
+		client:           client,
+		watchPlan:        watchPlan,
+		leaderChangeCh:   make(chan bool),
+		lockReleaseCh:    make(chan bool),
+		electionCh:       make(chan bool),
+		quitCh:           make(chan bool),
 	}
 	c.watchPlan.Handler = c.watchHandler
 	c.isReady.Store(false)
-	c.wg.Add(2)
-	go c.electLoop()
+	if !c.standby {
+		c.wg.Add(1)
+		go c.electLoop()
+	}
+	c.wg.Add(1)
 	go c.runWatch()
 	return c, nil
 }
@@ -161,11 +173,28 @@ func (c *Consul) IsReady(ctx context.Context) bool {
 	}
 }
 
+// unavailable wraps the error from consul into the UnavailableError
+func unavailable(err error) error {
+	return &consts.UnavailableError{Engine: "consul", Err: err}
+}
+
+func (c *Consul) queryOptions(ctx context.Context) (*api.QueryOptions, context.CancelFunc) {
+	ctx, cancel := engine.WithOperationTimeout(ctx, c.operationTimeout)
+	return (&api.QueryOptions{}).WithContext(ctx), cancel
+}
+
+func (c *Consul) writeOptions(ctx context.Context) (*api.WriteOptions, context.CancelFunc) {
+	ctx, cancel := engine.WithOperationTimeout(ctx, c.operationTimeout)
+	return (&api.WriteOptions{}).WithContext(ctx), cancel
+}
+
 func (c *Consul) Get(ctx context.Context, key string) ([]byte, error) {
 	key = sanitizeKey(key)
-	rsp, _, err := c.client.KV().Get(key, nil)
+	opts, cancel := c.queryOptions(ctx)
+	defer cancel()
+	rsp, _, err := c.client.KV().Get(key, opts)
 	if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 	if rsp == nil {
 		return nil, consts.ErrNotFound
@@ -191,21 +220,57 @@ func (c *Consul) Set(ctx context.Context, key string, value []byte) error {
 		Key:   key,
 		Value: value,
 	}
-	_, err := c.client.KV().Put(kvPair, nil)
-	return err
+	opts, cancel := c.writeOptions(ctx)
+	defer cancel()
+	if _, err := c.client.KV().Put(kvPair, opts); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (c *Consul) Delete(ctx context.Context, key string) error {
 	key = sanitizeKey(key)
-	_, err := c.client.KV().Delete(key, nil)
-	return err
+	opts, cancel := c.writeOptions(ctx)
+	defer cancel()
+	if _, err := c.client.KV().Delete(key, opts); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+// Txn applies the writes in a single consul transaction
+func (c *Consul) Txn(ctx context.Context, ops []engine.Op) error {
+	txnOps := make(api.KVTxnOps, 0, len(ops))
+	for _, op := range ops {
+		txnOp := &api.KVTxnOp{Verb: api.KVSet, Key: sanitizeKey(op.Key), Value: op.Value}
+		if op.Delete {
+			txnOp = &api.KVTxnOp{Verb: api.KVDelete, Key: sanitizeKey(op.Key)}
+		}
+		txnOps = append(txnOps, txnOp)
+	}
+	opts, cancel := c.queryOptions(ctx)
+	defer cancel()
+	ok, rsp, _, err := c.client.KV().Txn(txnOps, opts)
+	if err != nil {
+		return unavailable(err)
+	}
+	if !ok {
+		errs := make([]string, 0, len(rsp.Errors))
+		for _, txnErr := range rsp.Errors {
+			errs = append(errs, txnErr.What)
+		}
+		return unavailable(fmt.Errorf("transaction was rolled back: %s", strings.Join(errs, "; ")))
+	}
+	return nil
 }
 
 func (c *Consul) List(ctx context.Context, prefix string) ([]engine.Entry, error) {
 	prefix = sanitizeKey(prefix)
-	rsp, _, err := c.client.KV().List(prefix, nil)
+	opts, cancel := c.queryOptions(ctx)
+	defer cancel()
+	rsp, _, err := c.client.KV().List(prefix, opts)
 	if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 
 	prefixLen := len(prefix)
@@ -235,12 +300,14 @@ func (c *Consul) electLoop() {
 		default:
 		}
 
+		opts, cancel := c.writeOptions(context.Background())
 		sessionID, _, err := c.client.Session().Create(&api.SessionEntry{
 			Name:      c.electPath,
 			Behavior:  "release",
 			TTL:       fmt.Sprintf("%v", sessionTTL),
 			LockDelay: lockDelay,
-		}, nil)
+		}, opts)
+		cancel()
 		if err != nil {
 			logger.Get().With(
 				zap.Error(err),
@@ -263,7 +330,10 @@ func (c *Consul) electLoop() {
 
 func (c *Consul) leaderElection(kvPair *api.KVPair) bool {
 	for {
-		if _, _, err := c.client.KV().Acquire(kvPair, nil); err != nil {
+		opts, cancel := c.writeOptions(context.Background())
+		_, _, err := c.client.KV().Acquire(kvPair, opts)
+		cancel()
+		if err != nil {
 			logger.Get().With(
 				zap.Error(err),
 			).Error("Failed to acquire the leader campaign")
@@ -297,7 +367,10 @@ func (c *Consul) watchHandler(index uint64, data interface{}) {
 	if kvPair, ok := data.(*api.KVPair); ok {
 
 		if kvPair.Session == "" {
-			c.lockReleaseCh <- true
+			// the standby doesn't run the election loop to acquire the released lock
+			if !c.standby {
+				c.lockReleaseCh <- true
+			}
 			return
 		}
 
@@ -22,13 +22,56 @@ package engine
 
 import (
 	"context"
+	"time"
 )
 
+// DefaultOperationTimeout bounds the engine operation if the caller's context has no deadline
+const DefaultOperationTimeout = 5 * time.Second
+
 type Entry struct {
 	Key   string `json:"key"`
 	Value []byte `json:"value"`
 }
 
+// TermProvider is implemented by the engines which can tell the term of the current leadership,
+// e.g. the raft term or the etcd revision of the election key.
+type TermProvider interface {
+	LeaderTerm() int64
+}
+
+// Op is a write of the transaction, the key is deleted if Delete is true
+type Op struct {
+	Key    string
+	Value  []byte
+	Delete bool
+}
+
+func OpSet(key string, value []byte) Op {
+	return Op{Key: key, Value: value}
+}
+
+func OpDelete(key string) Op {
+	return Op{Key: key, Delete: true}
+}
+
+// Transactional is implemented by the engines which can apply multiple writes atomically,
+// either all writes are applied or none of them. Deleting the missing key isn't an error.
+type Transactional interface {
+	Txn(ctx context.Context, ops []Op) error
+}
+
+// WithOperationTimeout returns the context bounded by the timeout if the context has no deadline,
+// so that the hung backend can't block the caller forever. The default timeout is used if it's 0.
+func WithOperationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	if timeout <= 0 {
+		timeout = DefaultOperationTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 type Engine interface {
 	ID() string
 	Leader() string
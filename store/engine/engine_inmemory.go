@@ -21,26 +21,157 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"math/rand"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/apache/kvrocks-controller/consts"
 )
 
 var _ Engine = (*Mock)(nil)
 
+// ErrInjected is the default error returned by the injected failures
+var ErrInjected = errors.New("injected failure")
+
+// MockOp is the operation of the mock engine which the failure or latency can be injected to
+type MockOp string
+
+const (
+	MockOpAll    MockOp = "*"
+	MockOpGet    MockOp = "get"
+	MockOpExists MockOp = "exists"
+	MockOpSet    MockOp = "set"
+	MockOpDelete MockOp = "delete"
+	MockOpList   MockOp = "list"
+)
+
+type mockFailure struct {
+	rate float64
+	err  error
+}
+
 type Mock struct {
 	mu     sync.Mutex
 	values map[string]string
+
+	faultMu      sync.Mutex
+	rand         *rand.Rand
+	opFailures   map[MockOp]mockFailure
+	keyFailures  map[string]error
+	opLatencies  map[MockOp]time.Duration
+	keyLatencies map[string]time.Duration
 }
 
 func NewMock() *Mock {
 	return &Mock{
-		values: make(map[string]string),
+		values:       make(map[string]string),
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		opFailures:   make(map[MockOp]mockFailure),
+		keyFailures:  make(map[string]error),
+		opLatencies:  make(map[MockOp]time.Duration),
+		keyLatencies: make(map[string]time.Duration),
+	}
+}
+
+// WithFailureRate makes the operation fail with the error at the rate in [0, 1],
+// MockOpAll applies to all operations. ErrInjected is used if err is nil.
+func (m *Mock) WithFailureRate(op MockOp, rate float64, err error) *Mock {
+	if err == nil {
+		err = ErrInjected
+	}
+	m.faultMu.Lock()
+	defer m.faultMu.Unlock()
+	m.opFailures[op] = mockFailure{rate: rate, err: err}
+	return m
+}
+
+// WithKeyFailure makes all operations on the key fail with the error,
+// the List operation fails if the key is under the prefix.
+func (m *Mock) WithKeyFailure(key string, err error) *Mock {
+	if err == nil {
+		err = ErrInjected
 	}
+	m.faultMu.Lock()
+	defer m.faultMu.Unlock()
+	m.keyFailures[key] = err
+	return m
 }
 
-func (m *Mock) Get(_ context.Context, key string) ([]byte, error) {
+// WithLatency delays the operation before it's executed, MockOpAll applies to all operations.
+func (m *Mock) WithLatency(op MockOp, latency time.Duration) *Mock {
+	m.faultMu.Lock()
+	defer m.faultMu.Unlock()
+	m.opLatencies[op] = latency
+	return m
+}
+
+// WithKeyLatency delays all operations on the key before they're executed.
+func (m *Mock) WithKeyLatency(key string, latency time.Duration) *Mock {
+	m.faultMu.Lock()
+	defer m.faultMu.Unlock()
+	m.keyLatencies[key] = latency
+	return m
+}
+
+// ResetFaults clears all injected failures and latencies.
+func (m *Mock) ResetFaults() *Mock {
+	m.faultMu.Lock()
+	defer m.faultMu.Unlock()
+	m.opFailures = make(map[MockOp]mockFailure)
+	m.keyFailures = make(map[string]error)
+	m.opLatencies = make(map[MockOp]time.Duration)
+	m.keyLatencies = make(map[string]time.Duration)
+	return m
+}
+
+func matchMockKey(op MockOp, target, key string) bool {
+	if op == MockOpList {
+		return strings.HasPrefix(target, key)
+	}
+	return target == key
+}
+
+func (m *Mock) injectFault(ctx context.Context, op MockOp, key string) error {
+	m.faultMu.Lock()
+	latency := m.opLatencies[MockOpAll] + m.opLatencies[op]
+	for target, d := range m.keyLatencies {
+		if matchMockKey(op, target, key) {
+			latency += d
+		}
+	}
+	var injectedErr error
+	for target, err := range m.keyFailures {
+		if matchMockKey(op, target, key) {
+			injectedErr = err
+			break
+		}
+	}
+	if injectedErr == nil {
+		for _, failure := range []mockFailure{m.opFailures[MockOpAll], m.opFailures[op]} {
+			if failure.err != nil && m.rand.Float64() < failure.rate {
+				injectedErr = failure.err
+				break
+			}
+		}
+	}
+	m.faultMu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return injectedErr
+}
+
+func (m *Mock) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := m.injectFault(ctx, MockOpGet, key); err != nil {
+		return nil, err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	v, ok := m.values[key]
@@ -50,28 +181,63 @@ func (m *Mock) Get(_ context.Context, key string) ([]byte, error) {
 	return []byte(v), nil
 }
 
-func (m *Mock) Exists(_ context.Context, key string) (bool, error) {
+func (m *Mock) Exists(ctx context.Context, key string) (bool, error) {
+	if err := m.injectFault(ctx, MockOpExists, key); err != nil {
+		return false, err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	_, ok := m.values[key]
 	return ok, nil
 }
 
-func (m *Mock) Set(_ context.Context, key string, value []byte) error {
+func (m *Mock) Set(ctx context.Context, key string, value []byte) error {
+	if err := m.injectFault(ctx, MockOpSet, key); err != nil {
+		return err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.values[key] = string(value)
 	return nil
 }
 
-func (m *Mock) Delete(_ context.Context, key string) error {
+func (m *Mock) Delete(ctx context.Context, key string) error {
+	if err := m.injectFault(ctx, MockOpDelete, key); err != nil {
+		return err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.values, key)
 	return nil
 }
 
-func (m *Mock) List(_ context.Context, prefix string) ([]Entry, error) {
+// Txn applies the writes atomically, the faults of all writes are injected before applying any of them
+func (m *Mock) Txn(ctx context.Context, ops []Op) error {
+	for _, op := range ops {
+		mockOp := MockOpSet
+		if op.Delete {
+			mockOp = MockOpDelete
+		}
+		if err := m.injectFault(ctx, mockOp, op.Key); err != nil {
+			return err
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, op := range ops {
+		if op.Delete {
+			delete(m.values, op.Key)
+		} else {
+			m.values[op.Key] = string(op.Value)
+		}
+	}
+	return nil
+}
+
+func (m *Mock) List(ctx context.Context, prefix string) ([]Entry, error) {
+	if err := m.injectFault(ctx, MockOpList, prefix); err != nil {
+		return nil, err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMock_InjectFault(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("failure rate", func(t *testing.T) {
+		m := NewMock().WithFailureRate(MockOpSet, 1, nil)
+		require.ErrorIs(t, m.Set(ctx, "foo", []byte("bar")), ErrInjected)
+		_, err := m.Get(ctx, "foo")
+		require.NotErrorIs(t, err, ErrInjected)
+
+		m.WithFailureRate(MockOpSet, 0, nil)
+		require.NoError(t, m.Set(ctx, "foo", []byte("bar")))
+	})
+
+	t.Run("key failure", func(t *testing.T) {
+		keyErr := errors.New("key failure")
+		m := NewMock().WithKeyFailure("/a/b", keyErr)
+		require.ErrorIs(t, m.Set(ctx, "/a/b", []byte("v")), keyErr)
+		require.NoError(t, m.Set(ctx, "/a/c", []byte("v")))
+		_, err := m.List(ctx, "/a")
+		require.ErrorIs(t, err, keyErr)
+
+		m.ResetFaults()
+		require.NoError(t, m.Set(ctx, "/a/b", []byte("v")))
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		m := NewMock().WithLatency(MockOpAll, 50*time.Millisecond)
+		start := time.Now()
+		require.NoError(t, m.Set(ctx, "foo", []byte("bar")))
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := m.Get(timeoutCtx, "foo")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestMock_Txn(t *testing.T) {
+	ctx := context.Background()
+	m := NewMock()
+	require.NoError(t, m.Set(ctx, "/a/b", []byte("v")))
+	require.NoError(t, m.Txn(ctx, []Op{OpSet("/a/c", []byte("v")), OpDelete("/a/b"), OpDelete("/a/missing")}))
+	exists, err := m.Exists(ctx, "/a/b")
+	require.NoError(t, err)
+	require.False(t, exists)
+	value, err := m.Get(ctx, "/a/c")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v"), value)
+
+	// none of the writes is applied if any of them failed
+	m.WithKeyFailure("/a/e", ErrInjected)
+	require.ErrorIs(t, m.Txn(ctx, []Op{OpSet("/a/d", []byte("v")), OpSet("/a/e", []byte("v"))}), ErrInjected)
+	exists, err = m.Exists(ctx, "/a/d")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOperationTimeout(t *testing.T) {
+	ctx, cancel := WithOperationTimeout(context.Background(), 0)
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(DefaultOperationTimeout), deadline, time.Second)
+	cancel()
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	ctx, cancel = WithOperationTimeout(context.Background(), time.Minute)
+	deadline, _ = ctx.Deadline()
+	require.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	cancel()
+
+	// the deadline of the caller is kept
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer parentCancel()
+	parentDeadline, _ := parent.Deadline()
+	ctx, cancel = WithOperationTimeout(parent, time.Minute)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	require.Equal(t, parentDeadline, deadline)
+}
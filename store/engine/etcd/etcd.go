@@ -57,18 +57,33 @@ type Config struct {
 		TrustedCAFile string `yaml:"ca_file"`
 	} `yaml:"tls"`
 	ElectPath string `yaml:"elect_path"`
+	// SerializableRead reads from the connected member without the quorum for the GET requests
+	// served locally by the follower, which is used to read from the local member in the remote region.
+	SerializableRead bool `yaml:"serializable_read"`
+	// OperationTimeoutMs bounds the operation if the caller has no deadline, default is 5000.
+	OperationTimeoutMs int64 `yaml:"operation_timeout_ms"`
+	// Standby observes the leader without campaigning, it's set by the standby mode of the controller.
+	Standby bool `yaml:"-"`
 }
 
 type Etcd struct {
 	client *clientv3.Client
 	kv     clientv3.KV
+	// serializableRead is only applied to the local reads on the follower, the leader
+	// always reads with the quorum since its reads are used to update the clusters.
+	serializableRead bool
 
 	leaderMu  sync.RWMutex
 	leaderID  string
 	myID      string
 	electPath string
+	standby   bool
 	isReady   atomic.Bool
 
+	operationTimeout time.Duration
+	// leaderRevision is the create revision of the election key of the current leader
+	leaderRevision int64
+
 	quitCh         chan struct{}
 	wg             sync.WaitGroup
 	electionCh     chan *concurrency.Election
@@ -114,13 +129,16 @@ func New(id string, cfg *Config) (*Etcd, error) {
 		electPath = cfg.ElectPath
 	}
 	e := &Etcd{
-		myID:           id,
-		electPath:      electPath,
-		client:         client,
-		kv:             clientv3.NewKV(client),
-		quitCh:         make(chan struct{}),
-		electionCh:     make(chan *concurrency.Election),
-		leaderChangeCh: make(chan bool),
+		myID:             id,
+		electPath:        electPath,
+		standby:          cfg.Standby,
+		operationTimeout: time.Duration(cfg.OperationTimeoutMs) * time.Millisecond,
+		serializableRead: cfg.SerializableRead,
+		client:           client,
+		kv:               clientv3.NewKV(client),
+		quitCh:           make(chan struct{}),
+		electionCh:       make(chan *concurrency.Election),
+		leaderChangeCh:   make(chan bool),
 	}
 	e.isReady.Store(false)
 	e.wg.Add(2)
@@ -139,6 +157,13 @@ func (e *Etcd) Leader() string {
 	return e.leaderID
 }
 
+// LeaderTerm returns the create revision of the election key of the current leader
+func (e *Etcd) LeaderTerm() int64 {
+	e.leaderMu.RLock()
+	defer e.leaderMu.RUnlock()
+	return e.leaderRevision
+}
+
 func (e *Etcd) LeaderChange() <-chan bool {
 	return e.leaderChangeCh
 }
@@ -158,10 +183,25 @@ func (e *Etcd) IsReady(ctx context.Context) bool {
 	}
 }
 
+// unavailable wraps the error from etcd into the UnavailableError
+func unavailable(err error) error {
+	return &consts.UnavailableError{Engine: "etcd", Err: err}
+}
+
+// readOpts appends WithSerializable to the options if the request is served by the follower locally
+func (e *Etcd) readOpts(ctx context.Context, opts ...clientv3.OpOption) []clientv3.OpOption {
+	if localRead, _ := ctx.Value(consts.ContextKeyLocalRead).(bool); e.serializableRead && localRead {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+	return opts
+}
+
 func (e *Etcd) Get(ctx context.Context, key string) ([]byte, error) {
-	rsp, err := e.kv.Get(ctx, key)
+	ctx, cancel := engine.WithOperationTimeout(ctx, e.operationTimeout)
+	defer cancel()
+	rsp, err := e.kv.Get(ctx, key, e.readOpts(ctx)...)
 	if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 	if len(rsp.Kvs) == 0 {
 		return nil, consts.ErrNotFound
@@ -181,19 +221,47 @@ func (e *Etcd) Exists(ctx context.Context, key string) (bool, error) {
 }
 
 func (e *Etcd) Set(ctx context.Context, key string, value []byte) error {
-	_, err := e.kv.Put(ctx, key, string(value))
-	return err
+	ctx, cancel := engine.WithOperationTimeout(ctx, e.operationTimeout)
+	defer cancel()
+	if _, err := e.kv.Put(ctx, key, string(value)); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (e *Etcd) Delete(ctx context.Context, key string) error {
-	_, err := e.kv.Delete(ctx, key)
-	return err
+	ctx, cancel := engine.WithOperationTimeout(ctx, e.operationTimeout)
+	defer cancel()
+	if _, err := e.kv.Delete(ctx, key); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+// Txn applies the writes in a single etcd transaction
+func (e *Etcd) Txn(ctx context.Context, ops []engine.Op) error {
+	ctx, cancel := engine.WithOperationTimeout(ctx, e.operationTimeout)
+	defer cancel()
+	etcdOps := make([]clientv3.Op, 0, len(ops))
+	for _, op := range ops {
+		if op.Delete {
+			etcdOps = append(etcdOps, clientv3.OpDelete(op.Key))
+		} else {
+			etcdOps = append(etcdOps, clientv3.OpPut(op.Key, string(op.Value)))
+		}
+	}
+	if _, err := e.kv.Txn(ctx).Then(etcdOps...).Commit(); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (e *Etcd) List(ctx context.Context, prefix string) ([]engine.Entry, error) {
-	rsp, err := e.kv.Get(ctx, prefix, clientv3.WithPrefix())
+	ctx, cancel := engine.WithOperationTimeout(ctx, e.operationTimeout)
+	defer cancel()
+	rsp, err := e.kv.Get(ctx, prefix, e.readOpts(ctx, clientv3.WithPrefix())...)
 	if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 
 	prefixLen := len(prefix)
@@ -234,6 +302,17 @@ func (e *Etcd) electLoop(ctx context.Context) {
 		}
 		election := concurrency.NewElection(session, e.electPath)
 		e.electionCh <- election
+		if e.standby {
+			// the standby only observes the leader with the session, and never campaigns
+			select {
+			case <-session.Done():
+				logger.Get().Warn("Standby session is done")
+				goto reset
+			case <-e.quitCh:
+				logger.Get().Info("Exit the leader election loop")
+				return
+			}
+		}
 		for {
 			if err := election.Campaign(ctx, e.myID); err != nil {
 				logger.Get().With(
@@ -272,6 +351,7 @@ func (e *Etcd) observeLeaderEvent(ctx context.Context) {
 				newLeaderID := string(resp.Kvs[0].Value)
 				e.leaderMu.Lock()
 				e.leaderID = newLeaderID
+				e.leaderRevision = resp.Kvs[0].CreateRevision
 				e.leaderMu.Unlock()
 				e.leaderChangeCh <- true
 				if newLeaderID != "" && newLeaderID == e.leaderID {
@@ -104,3 +104,53 @@ func TestElect(t *testing.T) {
 		return node1.Leader() == node1.myID
 	}, 15*time.Second, 100*time.Millisecond, "node1 should be the leader")
 }
+
+func TestElect_Standby(t *testing.T) {
+	endpoints := []string{addr}
+
+	testElectPath := util.RandString(32)
+	standby, err := New(util.RandString(40), &Config{
+		ElectPath: testElectPath,
+		Addrs:     endpoints,
+		Standby:   true,
+	})
+	require.NoError(t, err)
+	defer standby.Close()
+
+	node0, err := New(util.RandString(40), &Config{
+		ElectPath: testElectPath,
+		Addrs:     endpoints,
+	})
+	require.NoError(t, err)
+	node1, err := New(util.RandString(40), &Config{
+		ElectPath: testElectPath,
+		Addrs:     endpoints,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			select {
+			case <-standby.LeaderChange():
+			case <-node0.LeaderChange():
+			case <-node1.LeaderChange():
+			}
+		}
+	}()
+
+	require.Eventuallyf(t, func() bool {
+		leader := standby.Leader()
+		return leader == node0.myID || leader == node1.myID
+	}, 10*time.Second, 100*time.Millisecond, "standby should observe the leader")
+
+	// the standby should never be the leader even if the leader was closed
+	leader, follower := node0, node1
+	if standby.Leader() == node1.myID {
+		leader, follower = node1, node0
+	}
+	require.NoError(t, leader.Close())
+	require.Eventuallyf(t, func() bool {
+		return standby.Leader() == follower.myID
+	}, 15*time.Second, 100*time.Millisecond, "standby should observe the new leader")
+	require.NoError(t, follower.Close())
+}
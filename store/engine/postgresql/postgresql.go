@@ -51,6 +51,8 @@ type Config struct {
 	DBName        string   `yaml:"db_name"`
 	NotifyChannel string   `yaml:"notify_channel"`
 	ElectPath     string   `yaml:"elect_path"`
+	// OperationTimeoutMs bounds the operation if the caller has no deadline, default is 5000.
+	OperationTimeoutMs int64 `yaml:"operation_timeout_ms"`
 }
 
 type Postgresql struct {
@@ -63,6 +65,8 @@ type Postgresql struct {
 	electPath string
 	isReady   atomic.Bool
 
+	operationTimeout time.Duration
+
 	quitCh         chan struct{}
 	wg             sync.WaitGroup
 	lockReleaseCh  chan bool
@@ -92,13 +96,14 @@ func New(id string, cfg *Config) (*Postgresql, error) {
 	}
 
 	p := &Postgresql{
-		myID:           id,
-		electPath:      electPath,
-		db:             db,
-		listener:       listener,
-		quitCh:         make(chan struct{}),
-		lockReleaseCh:  make(chan bool),
-		leaderChangeCh: make(chan bool),
+		myID:             id,
+		electPath:        electPath,
+		operationTimeout: time.Duration(cfg.OperationTimeoutMs) * time.Millisecond,
+		db:               db,
+		listener:         listener,
+		quitCh:           make(chan struct{}),
+		lockReleaseCh:    make(chan bool),
+		leaderChangeCh:   make(chan bool),
 	}
 	err = p.initLeaderId()
 	if err != nil {
@@ -140,17 +145,24 @@ func (p *Postgresql) IsReady(ctx context.Context) bool {
 	}
 }
 
+// unavailable wraps the error from postgresql into the UnavailableError
+func unavailable(err error) error {
+	return &consts.UnavailableError{Engine: "postgresql", Err: err}
+}
+
 func (p *Postgresql) Get(ctx context.Context, key string) ([]byte, error) {
 	var value []byte
 	query := "SELECT value FROM kv WHERE key = $1"
 
-	row := p.db.QueryRow(query, key)
+	ctx, cancel := engine.WithOperationTimeout(ctx, p.operationTimeout)
+	defer cancel()
+	row := p.db.QueryRowContext(ctx, query, key)
 	err := row.Scan(&value)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, consts.ErrNotFound
 	}
 	if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 	return value, nil
 }
@@ -168,22 +180,58 @@ func (p *Postgresql) Exists(ctx context.Context, key string) (bool, error) {
 
 func (p *Postgresql) Set(ctx context.Context, key string, value []byte) error {
 	query := "INSERT INTO kv (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value"
-	_, err := p.db.Exec(query, key, value)
-	return err
+	ctx, cancel := engine.WithOperationTimeout(ctx, p.operationTimeout)
+	defer cancel()
+	if _, err := p.db.ExecContext(ctx, query, key, value); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (p *Postgresql) Delete(ctx context.Context, key string) error {
 	query := "DELETE FROM kv WHERE key = $1"
-	_, err := p.db.Exec(query, key)
-	return err
+	ctx, cancel := engine.WithOperationTimeout(ctx, p.operationTimeout)
+	defer cancel()
+	if _, err := p.db.ExecContext(ctx, query, key); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+// Txn applies the writes in a single SQL transaction
+func (p *Postgresql) Txn(ctx context.Context, ops []engine.Op) error {
+	ctx, cancel := engine.WithOperationTimeout(ctx, p.operationTimeout)
+	defer cancel()
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return unavailable(err)
+	}
+	for _, op := range ops {
+		if op.Delete {
+			_, err = tx.ExecContext(ctx, "DELETE FROM kv WHERE key = $1", op.Key)
+		} else {
+			_, err = tx.ExecContext(ctx, "INSERT INTO kv (key, value) VALUES ($1, $2) "+
+				"ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value", op.Key, op.Value)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return unavailable(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (p *Postgresql) List(ctx context.Context, prefix string) ([]engine.Entry, error) {
 	prefixWithWildcard := prefix + "%"
 	query := "SELECT key, value from kv WHERE key LIKE $1"
-	rows, err := p.db.Query(query, prefixWithWildcard)
+	ctx, cancel := engine.WithOperationTimeout(ctx, p.operationTimeout)
+	defer cancel()
+	rows, err := p.db.QueryContext(ctx, query, prefixWithWildcard)
 	if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 	defer rows.Close()
 
@@ -229,7 +277,9 @@ func (p *Postgresql) electLoop() {
 		}
 
 		query := "INSERT INTO locks (name, leaderID) VALUES ($1, $2) ON CONFLICT DO NOTHING"
-		_, err := p.db.Exec(query, p.electPath, p.myID)
+		ctx, cancel := engine.WithOperationTimeout(context.Background(), p.operationTimeout)
+		_, err := p.db.ExecContext(ctx, query, p.electPath, p.myID)
+		cancel()
 		if err != nil {
 			time.Sleep(lockTTL / 3)
 			continue
@@ -279,7 +329,9 @@ func (p *Postgresql) observeLeaderEvent() {
 func (p *Postgresql) initLeaderId() error {
 	var leaderId string
 	query := "SELECT leaderID FROM locks WHERE name = $1"
-	row := p.db.QueryRow(query, p.electPath)
+	ctx, cancel := engine.WithOperationTimeout(context.Background(), p.operationTimeout)
+	defer cancel()
+	row := p.db.QueryRowContext(ctx, query, p.electPath)
 	err := row.Scan(&leaderId)
 	if errors.Is(err, sql.ErrNoRows) {
 		p.leaderID = ""
@@ -43,6 +43,23 @@ type Config struct {
 	HeartbeatSeconds int `yaml:"heartbeat_seconds"`
 	// ElectionSeconds is the interval to start an election. Default is 10 * HeartBeat.
 	ElectionSeconds int `yaml:"election_seconds"`
+	// JoinSnapshotURL is the URL to download the latest snapshot from the existing cluster,
+	// e.g. http://127.0.0.1:9379/api/v1/raft/snapshot/download. The fresh node will be bootstrapped
+	// from the snapshot before starting raft to avoid replaying the full log on join.
+	JoinSnapshotURL string `yaml:"join_snapshot_url"`
+	// SnapshotThreshold is the number of the applied entries since the last snapshot
+	// to trigger a new snapshot. Default is 10000.
+	SnapshotThreshold uint64 `yaml:"snapshot_threshold"`
+	// CompactThreshold is the number of the entries kept in the raft log after
+	// compacting it on the snapshot. Default is 1024.
+	CompactThreshold uint64 `yaml:"compact_threshold"`
+	// SnapshotBytesThreshold is the total size of the applied entries since the last snapshot
+	// to trigger a new snapshot, which bounds the memory of the raft log. Default is 64 MiB.
+	SnapshotBytesThreshold uint64 `yaml:"snapshot_bytes_threshold"`
+	// MaxSnapshotFiles and MaxWALFiles are the number of the snapshot and WAL files
+	// kept on the disk, the older files will be purged. Default is 5.
+	MaxSnapshotFiles uint `yaml:"max_snapshot_files"`
+	MaxWALFiles      uint `yaml:"max_wal_files"`
 }
 
 func (c *Config) validate() error {
@@ -73,4 +90,19 @@ func (c *Config) init() {
 	if c.ElectionSeconds == 0 {
 		c.ElectionSeconds = c.HeartbeatSeconds * 10
 	}
+	if c.SnapshotThreshold == 0 {
+		c.SnapshotThreshold = defaultSnapshotThreshold
+	}
+	if c.CompactThreshold == 0 {
+		c.CompactThreshold = defaultCompactThreshold
+	}
+	if c.SnapshotBytesThreshold == 0 {
+		c.SnapshotBytesThreshold = defaultSnapshotBytesThreshold
+	}
+	if c.MaxSnapshotFiles == 0 {
+		c.MaxSnapshotFiles = defaultMaxFiles
+	}
+	if c.MaxWALFiles == 0 {
+		c.MaxWALFiles = defaultMaxFiles
+	}
 }
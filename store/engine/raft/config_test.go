@@ -55,12 +55,21 @@ func TestConfig_Init(t *testing.T) {
 	require.Equal(t, ".", c.DataDir)
 	require.Equal(t, 2, c.HeartbeatSeconds)
 	require.Equal(t, 20, c.ElectionSeconds)
+	require.EqualValues(t, defaultSnapshotThreshold, c.SnapshotThreshold)
+	require.EqualValues(t, defaultCompactThreshold, c.CompactThreshold)
+	require.EqualValues(t, defaultSnapshotBytesThreshold, c.SnapshotBytesThreshold)
+	require.EqualValues(t, defaultMaxFiles, c.MaxSnapshotFiles)
+	require.EqualValues(t, defaultMaxFiles, c.MaxWALFiles)
 
 	c.DataDir = "/tmp"
 	c.HeartbeatSeconds = 3
 	c.ElectionSeconds = 30
+	c.SnapshotThreshold = 100
+	c.CompactThreshold = 10
 	c.init()
 	require.Equal(t, "/tmp", c.DataDir)
 	require.Equal(t, 3, c.HeartbeatSeconds)
 	require.Equal(t, 30, c.ElectionSeconds)
+	require.EqualValues(t, 100, c.SnapshotThreshold)
+	require.EqualValues(t, 10, c.CompactThreshold)
 }
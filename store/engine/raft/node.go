@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -32,9 +33,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/logger"
 	"github.com/apache/kvrocks-controller/store/engine"
 
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/raft/v3/raftpb"
@@ -44,20 +47,45 @@ import (
 )
 
 const (
-	defaultSnapshotThreshold = 10000
-	defaultCompactThreshold  = 1024
+	defaultSnapshotThreshold      = 10000
+	defaultCompactThreshold       = 1024
+	defaultSnapshotBytesThreshold = 64 * 1024 * 1024
+	defaultMaxFiles               = 5
+
+	purgeFileInterval = 30 * time.Second
+	// proposalTimeout is the max time to wait for the proposal to be applied
+	proposalTimeout = 10 * time.Second
+	// proposalIDBits is the bits of the sequence in the proposal ID, the node ID is in the higher bits
+	proposalIDBits = 48
+
+	bootstrapSnapshotTimeout = 60 * time.Second
+)
+
+var (
+	ErrNodeStopped = errors.New("raft node was stopped")
+	ErrNoLeader    = errors.New("no leader now")
 )
 
 const (
 	opGet = iota + 1
 	opSet
 	opDelete
+	opTxn
 )
 
 type Event struct {
+	// ID identifies the proposal to notify the proposer after the event was applied
+	ID    uint64 `json:"id,omitempty"`
 	Op    int    `json:"op"`
 	Key   string `json:"key"`
 	Value []byte `json:"value"`
+	// Ops are the set and delete events which are applied atomically by the txn event
+	Ops []Event `json:"ops,omitempty"`
+}
+
+type snapshotResult struct {
+	index uint64
+	err   error
 }
 
 type Node struct {
@@ -78,6 +106,14 @@ type Node struct {
 	confState         raftpb.ConfState
 	snapshotThreshold atomic.Uint64
 	compactThreshold  atomic.Uint64
+	// appliedBytes is the total size of the applied entries since the last snapshot
+	appliedBytes uint64
+
+	snapshotReqCh chan chan snapshotResult
+
+	// proposals are the channels to notify the proposers, which are keyed by the proposal ID
+	proposals   sync.Map
+	proposalSeq atomic.Uint64
 
 	wg       sync.WaitGroup
 	shutdown chan struct{}
@@ -99,10 +135,13 @@ func New(config *Config) (*Node, error) {
 		leader:        raft.None,
 		dataStore:     NewDataStore(config.DataDir),
 		leaderChanged: make(chan bool),
+		snapshotReqCh: make(chan chan snapshotResult),
 		logger:        logger,
 	}
-	n.snapshotThreshold.Store(defaultSnapshotThreshold)
-	n.compactThreshold.Store(defaultCompactThreshold)
+	n.snapshotThreshold.Store(config.SnapshotThreshold)
+	n.compactThreshold.Store(config.CompactThreshold)
+	// start the sequence from the current time to avoid reusing the IDs after restarting
+	n.proposalSeq.Store(uint64(time.Now().UnixNano()))
 	if err := n.run(); err != nil {
 		return nil, err
 	}
@@ -113,6 +152,7 @@ func (n *Node) Addr() string {
 	return n.addr
 }
 
+// ListPeers returns the addresses of the peers keyed by the ID, see Peers for the details of the members.
 func (n *Node) ListPeers() map[uint64]string {
 	peers := make(map[uint64]string)
 	n.peers.Range(func(key, value interface{}) bool {
@@ -128,6 +168,15 @@ func (n *Node) SetSnapshotThreshold(threshold uint64) {
 	n.snapshotThreshold.Store(threshold)
 }
 
+func (n *Node) SetCompactThreshold(threshold uint64) {
+	n.compactThreshold.Store(threshold)
+}
+
+// Thresholds returns the snapshot and compact thresholds of the node
+func (n *Node) Thresholds() (snapshotThreshold, compactThreshold uint64) {
+	return n.snapshotThreshold.Load(), n.compactThreshold.Load()
+}
+
 func (n *Node) run() error {
 	// The node is already running
 	if !n.isRunning.CompareAndSwap(false, true) {
@@ -152,6 +201,12 @@ func (n *Node) run() error {
 		Logger:          Logger{SugaredLogger: n.logger.Sugar()},
 	}
 
+	if !n.dataStore.walExists() && n.config.JoinSnapshotURL != "" {
+		if err := n.bootstrapFromRemoteSnapshot(n.config.JoinSnapshotURL); err != nil {
+			return fmt.Errorf("failed to bootstrap from the remote snapshot: %w", err)
+		}
+	}
+
 	// WAL existing check must be done before replayWAL since it will create a new WAL if not exists
 	walExists := n.dataStore.walExists()
 	snapshot, err := n.dataStore.replayWAL()
@@ -172,9 +227,35 @@ func (n *Node) run() error {
 		return err
 	}
 	n.watchLeaderChange()
+	n.purgeFiles()
 	return n.runRaftMessages()
 }
 
+// purgeFiles removes the old snapshot and WAL files periodically, the WAL files are
+// locked until they're covered by the snapshot, so only the released ones are purged.
+func (n *Node) purgeFiles() {
+	purges := []struct {
+		dir    string
+		suffix string
+		max    uint
+	}{
+		{dir: n.dataStore.snapshotDir, suffix: ".snap", max: n.config.MaxSnapshotFiles},
+		{dir: n.dataStore.walDir, suffix: ".wal", max: n.config.MaxWALFiles},
+	}
+	for _, purge := range purges {
+		errCh := fileutil.PurgeFile(n.logger, purge.dir, purge.suffix, purge.max, purgeFileInterval, n.shutdown)
+		n.wg.Add(1)
+		go func(dir string) {
+			defer n.wg.Done()
+			select {
+			case err := <-errCh:
+				n.logger.Error("Failed to purge the files", zap.String("dir", dir), zap.Error(err))
+			case <-n.shutdown:
+			}
+		}(purge.dir)
+	}
+}
+
 func (n *Node) runTransport() error {
 	logger := logger.Get()
 	idString := fmt.Sprintf("%d", n.config.ID)
@@ -292,6 +373,11 @@ func (n *Node) runRaftMessages() error {
 					n.logger.Error("Failed to trigger snapshot", zap.Error(err))
 				}
 				n.raftNode.Advance()
+			case resultCh := <-n.snapshotReqCh:
+				// The snapshot must be created in the same goroutine with applying entries,
+				// or the applied index may be changed during the snapshot.
+				index, err := n.createSnapshot()
+				resultCh <- snapshotResult{index: index, err: err}
 			case err := <-n.transport.ErrorC:
 				n.logger.Fatal("Found transport error", zap.Error(err))
 				return
@@ -305,19 +391,31 @@ func (n *Node) runRaftMessages() error {
 }
 
 func (n *Node) triggerSnapshotIfNeed() error {
-	if n.appliedIndex-n.snapshotIndex <= n.snapshotThreshold.Load() {
+	if n.appliedIndex-n.snapshotIndex <= n.snapshotThreshold.Load() &&
+		n.appliedBytes <= n.config.SnapshotBytesThreshold {
 		return nil
 	}
+	_, err := n.createSnapshot()
+	return err
+}
+
+// createSnapshot creates a snapshot at the applied index and compacts the raft log,
+// it returns the index of the latest snapshot.
+func (n *Node) createSnapshot() (uint64, error) {
+	if n.appliedIndex == n.snapshotIndex {
+		// nothing changed since the last snapshot
+		return n.snapshotIndex, nil
+	}
 	snapshotBytes, err := n.dataStore.GetDataStoreSnapshot()
 	if err != nil {
-		return err
+		return 0, err
 	}
 	snap, err := n.dataStore.raftStorage.CreateSnapshot(n.appliedIndex, &n.confState, snapshotBytes)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if err := n.dataStore.saveSnapshot(snap); err != nil {
-		return err
+		return 0, err
 	}
 
 	compactIndex := uint64(1)
@@ -325,22 +423,165 @@ func (n *Node) triggerSnapshotIfNeed() error {
 		compactIndex = n.appliedIndex - n.compactThreshold.Load()
 	}
 	if err := n.dataStore.raftStorage.Compact(compactIndex); err != nil && !errors.Is(err, raft.ErrCompacted) {
-		return err
+		return 0, err
 	}
 	n.snapshotIndex = n.appliedIndex
+	n.appliedBytes = 0
+	return n.snapshotIndex, nil
+}
+
+// TriggerSnapshot forces the node to create a snapshot now regardless of the snapshot threshold,
+// it returns the index of the snapshot.
+func (n *Node) TriggerSnapshot(ctx context.Context) (uint64, error) {
+	resultCh := make(chan snapshotResult, 1)
+	select {
+	case n.snapshotReqCh <- resultCh:
+	case <-n.shutdown:
+		return 0, ErrNodeStopped
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	select {
+	case result := <-resultCh:
+		return result.index, result.err
+	case <-n.shutdown:
+		return 0, ErrNodeStopped
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// LatestSnapshot creates a snapshot at the applied index and returns it,
+// the fresh node can be bootstrapped from it when joining the cluster.
+func (n *Node) LatestSnapshot(ctx context.Context) (*raftpb.Snapshot, error) {
+	if _, err := n.TriggerSnapshot(ctx); err != nil {
+		return nil, err
+	}
+	snapshot, err := n.dataStore.raftStorage.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	if raft.IsEmptySnap(snapshot) {
+		return nil, errors.New("no snapshot available")
+	}
+	return &snapshot, nil
+}
+
+func (n *Node) bootstrapFromRemoteSnapshot(snapshotURL string) error {
+	httpClient := &http.Client{Timeout: bootstrapSnapshotTimeout}
+	rsp, err := httpClient.Post(snapshotURL, "", nil)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", rsp.StatusCode)
+	}
+	snapshotBytes, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	var snapshot raftpb.Snapshot
+	if err := snapshot.Unmarshal(snapshotBytes); err != nil {
+		return err
+	}
+	if raft.IsEmptySnap(snapshot) {
+		return errors.New("got an empty snapshot")
+	}
+	if err := n.dataStore.bootstrap(snapshot); err != nil {
+		return err
+	}
+	n.logger.Info("Bootstrap from the remote snapshot",
+		zap.String("url", snapshotURL),
+		zap.Uint64("index", snapshot.Metadata.Index),
+		zap.Uint64("term", snapshot.Metadata.Term))
 	return nil
 }
 
+// TransferLeadership transfers the leadership to the target peer and waits until
+// the target peer becomes the leader or the context is done.
+func (n *Node) TransferLeadership(ctx context.Context, targetID uint64) error {
+	if _, ok := n.peers.Load(targetID); !ok {
+		return fmt.Errorf("peer %d not exists", targetID)
+	}
+	lead := n.GetRaftLead()
+	if lead == raft.None {
+		return ErrNoLeader
+	}
+	if lead == targetID {
+		return nil
+	}
+	n.raftNode.TransferLeadership(ctx, lead, targetID)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if n.GetRaftLead() == targetID {
+				return nil
+			}
+		case <-n.shutdown:
+			return ErrNodeStopped
+		case <-ctx.Done():
+			return fmt.Errorf("wait for the leadership transfer: %w", ctx.Err())
+		}
+	}
+}
+
+func (n *Node) nextProposalID() uint64 {
+	seq := n.proposalSeq.Add(1) & (1<<proposalIDBits - 1)
+	return n.config.ID<<proposalIDBits | seq
+}
+
+// proposeAndWait proposes the event and waits until it's applied to the local state machine,
+// so that the write can be read from the node right after it returns.
+func (n *Node) proposeAndWait(ctx context.Context, event *Event) error {
+	event.ID = n.nextProposalID()
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	applied := make(chan struct{})
+	n.proposals.Store(event.ID, applied)
+	defer n.proposals.Delete(event.ID)
+
+	ctx, cancel := context.WithTimeout(ctx, proposalTimeout)
+	defer cancel()
+	if err := n.raftNode.Propose(ctx, bytes); err != nil {
+		return unavailable(err)
+	}
+	select {
+	case <-applied:
+		return nil
+	case <-n.shutdown:
+		return unavailable(ErrNodeStopped)
+	case <-ctx.Done():
+		return unavailable(fmt.Errorf("wait for applying the proposal: %w", ctx.Err()))
+	}
+}
+
+// unavailable wraps the error from raft into the UnavailableError
+func unavailable(err error) error {
+	return &consts.UnavailableError{Engine: "raft", Err: err}
+}
+
+// notifyProposal wakes up the proposer which is waiting for the event to be applied
+func (n *Node) notifyProposal(id uint64) {
+	if id == 0 {
+		return
+	}
+	if applied, ok := n.proposals.LoadAndDelete(id); ok {
+		close(applied.(chan struct{}))
+	}
+}
+
 func (n *Node) Set(ctx context.Context, key string, value []byte) error {
-	bytes, err := json.Marshal(&Event{
+	return n.proposeAndWait(ctx, &Event{
 		Op:    opSet,
 		Key:   key,
 		Value: value,
 	})
-	if err != nil {
-		return err
-	}
-	return n.raftNode.Propose(ctx, bytes)
 }
 
 func (n *Node) AddPeer(ctx context.Context, nodeID uint64, peer string) error {
@@ -349,7 +590,24 @@ func (n *Node) AddPeer(ctx context.Context, nodeID uint64, peer string) error {
 		NodeID:  nodeID,
 		Context: []byte(peer),
 	}
-	return n.raftNode.ProposeConfChange(ctx, cc)
+	if err := n.raftNode.ProposeConfChange(ctx, cc); err != nil {
+		return unavailable(err)
+	}
+	return nil
+}
+
+// UpdatePeer re-points the existing peer to the new address, it's useful when
+// the peer was rescheduled with a different address.
+func (n *Node) UpdatePeer(ctx context.Context, nodeID uint64, peer string) error {
+	cc := raftpb.ConfChange{
+		Type:    raftpb.ConfChangeUpdateNode,
+		NodeID:  nodeID,
+		Context: []byte(peer),
+	}
+	if err := n.raftNode.ProposeConfChange(ctx, cc); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (n *Node) RemovePeer(ctx context.Context, nodeID uint64) error {
@@ -357,7 +615,10 @@ func (n *Node) RemovePeer(ctx context.Context, nodeID uint64) error {
 		Type:   raftpb.ConfChangeRemoveNode,
 		NodeID: nodeID,
 	}
-	return n.raftNode.ProposeConfChange(ctx, cc)
+	if err := n.raftNode.ProposeConfChange(ctx, cc); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (n *Node) ID() string {
@@ -372,6 +633,11 @@ func (n *Node) GetRaftLead() uint64 {
 	return n.raftNode.Status().Lead
 }
 
+// LeaderTerm returns the current raft term
+func (n *Node) LeaderTerm() int64 {
+	return int64(n.raftNode.Status().Term)
+}
+
 func (n *Node) IsReady(ctx context.Context) bool {
 	tries := 0
 	for {
@@ -416,14 +682,23 @@ func (n *Node) Exists(_ context.Context, key string) (bool, error) {
 }
 
 func (n *Node) Delete(ctx context.Context, key string) error {
-	bytes, err := json.Marshal(&Event{
+	return n.proposeAndWait(ctx, &Event{
 		Op:  opDelete,
 		Key: key,
 	})
-	if err != nil {
-		return err
+}
+
+// Txn proposes the writes in a single event, so they're applied atomically
+func (n *Node) Txn(ctx context.Context, ops []engine.Op) error {
+	event := &Event{Op: opTxn, Ops: make([]Event, 0, len(ops))}
+	for _, op := range ops {
+		if op.Delete {
+			event.Ops = append(event.Ops, Event{Op: opDelete, Key: op.Key})
+		} else {
+			event.Ops = append(event.Ops, Event{Op: opSet, Key: op.Key, Value: op.Value})
+		}
 	}
-	return n.raftNode.Propose(ctx, bytes)
+	return n.proposeAndWait(ctx, event)
 }
 
 func (n *Node) List(_ context.Context, prefix string) ([]engine.Entry, error) {
@@ -464,6 +739,7 @@ func (n *Node) applyEntries(entries []raftpb.Entry) {
 		if err := n.applyEntry(entry); err != nil {
 			n.logger.Error("failed to apply entry", zap.Error(err))
 		}
+		n.appliedBytes += uint64(entry.Size())
 	}
 	n.appliedIndex = entries[len(entries)-1].Index
 }
@@ -471,7 +747,9 @@ func (n *Node) applyEntries(entries []raftpb.Entry) {
 func (n *Node) applyEntry(entry raftpb.Entry) error {
 	switch entry.Type {
 	case raftpb.EntryNormal:
-		return n.dataStore.applyDataEntry(entry)
+		id, err := n.dataStore.applyDataEntry(entry)
+		n.notifyProposal(id)
+		return err
 	case raftpb.EntryConfChangeV2, raftpb.EntryConfChange:
 		// apply config change to the state machine
 		var cc raftpb.ConfChange
@@ -481,9 +759,10 @@ func (n *Node) applyEntry(entry raftpb.Entry) error {
 
 		n.confState = *n.raftNode.ApplyConfChange(cc)
 		switch cc.Type {
-		case raftpb.ConfChangeAddNode:
+		case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
 			if cc.NodeID != n.config.ID && len(cc.Context) > 0 {
-				n.logger.Info("Add the new peer", zap.String("context", string(cc.Context)))
+				n.logger.Info("Add the new peer", zap.String("context", string(cc.Context)),
+					zap.Bool("learner", cc.Type == raftpb.ConfChangeAddLearnerNode))
 				n.transport.AddPeer(types.ID(cc.NodeID), []string{string(cc.Context)})
 				n.peers.Store(cc.NodeID, string(cc.Context))
 			}
@@ -501,8 +780,6 @@ func (n *Node) applyEntry(entry raftpb.Entry) error {
 			if _, ok := n.peers.Load(cc.NodeID); ok {
 				n.peers.Store(cc.NodeID, string(cc.Context))
 			}
-		case raftpb.ConfChangeAddLearnerNode:
-			// TODO: add the learner node
 		}
 	}
 	return nil
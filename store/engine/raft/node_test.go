@@ -177,6 +177,15 @@ func TestCluster_SingleNode(t *testing.T) {
 		gotBytes, _ := n.Get(ctx, "foo")
 		return string(gotBytes) == "bar"
 	}, 1*time.Second, 100*time.Millisecond)
+
+	// the write is applied to the node before returning
+	require.NoError(t, n.Set(ctx, "foo", []byte("bar-1")))
+	gotBytes, err := n.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, "bar-1", string(gotBytes))
+	require.NoError(t, n.Delete(ctx, "foo"))
+	_, err = n.Get(ctx, "foo")
+	require.ErrorIs(t, err, ErrKeyNotFound)
 }
 
 func TestCluster_MultiNodes(t *testing.T) {
@@ -249,6 +258,19 @@ func TestCluster_AddRemovePeer(t *testing.T) {
 			return string(got) == "bar-1"
 		}, 1*time.Second, 100*time.Millisecond)
 		require.Len(t, n1.ListPeers(), 4)
+
+		peers := n1.Peers()
+		require.Len(t, peers, 4)
+		leaders := 0
+		for i, peer := range peers {
+			require.EqualValues(t, i+1, peer.ID)
+			require.Equal(t, n1.ListPeers()[peer.ID], peer.Addr)
+			require.False(t, peer.IsLearner)
+			if peer.IsLeader {
+				leaders++
+			}
+		}
+		require.Equal(t, 1, leaders)
 	})
 
 	t.Run("remove a peer node", func(t *testing.T) {
@@ -302,3 +324,103 @@ func TestTriggerSnapshot(t *testing.T) {
 		require.Equal(t, "bar", string(gotBytes))
 	}
 }
+
+func TestForceSnapshot(t *testing.T) {
+	cluster := NewTestCluster(1)
+	defer cluster.Close()
+
+	ctx := context.Background()
+	require.Eventually(t, func() bool {
+		return cluster.IsReady(ctx)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	n := cluster.GetNode(0)
+	require.NoError(t, n.Set(ctx, "foo", []byte("bar")))
+	require.Eventually(t, func() bool {
+		got, _ := n.Get(ctx, "foo")
+		return string(got) == "bar"
+	}, 1*time.Second, 100*time.Millisecond)
+
+	index, err := n.TriggerSnapshot(ctx)
+	require.NoError(t, err)
+	require.Greater(t, index, uint64(0))
+
+	snapshot, err := n.dataStore.loadSnapshotFromDisk()
+	require.NoError(t, err)
+	require.Equal(t, index, snapshot.Metadata.Index)
+}
+
+func TestTransferLeadership(t *testing.T) {
+	cluster := NewTestCluster(3)
+	defer cluster.Close()
+
+	ctx := context.Background()
+	require.Eventually(t, func() bool {
+		return cluster.IsReady(ctx)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	leaderID := cluster.GetLeaderID(raft.None)
+	targetID := leaderID%3 + 1
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	require.NoError(t, cluster.GetNode(int(leaderID-1)).TransferLeadership(ctx, targetID))
+	require.Eventually(t, func() bool {
+		return cluster.GetLeaderID(raft.None) == targetID
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Error(t, cluster.GetNode(0).TransferLeadership(ctx, 100))
+}
+
+func TestCluster_UpdatePeer(t *testing.T) {
+	cluster := NewTestCluster(3)
+	defer cluster.Close()
+
+	ctx := context.Background()
+	require.Eventually(t, func() bool {
+		return cluster.IsReady(ctx)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	n1 := cluster.GetNode(0)
+	newAddr := fmt.Sprintf("http://127.0.0.1:%d", rand.Int31n(1024)+30000)
+	require.NoError(t, n1.UpdatePeer(ctx, 3, newAddr))
+	require.Eventually(t, func() bool {
+		return n1.ListPeers()[3] == newAddr
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Len(t, n1.ListPeers(), 3)
+	peers := n1.Peers()
+	require.Len(t, peers, 3)
+	require.EqualValues(t, 3, peers[2].ID)
+	require.Equal(t, newAddr, peers[2].Addr)
+}
+
+func TestCluster_Status(t *testing.T) {
+	cluster := NewTestCluster(3)
+	defer cluster.Close()
+
+	ctx := context.Background()
+	require.Eventually(t, func() bool {
+		return cluster.IsReady(ctx)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	var leaderID uint64
+	require.Eventually(t, func() bool {
+		leaderID = cluster.GetLeaderID(0)
+		return leaderID != raft.None
+	}, 10*time.Second, 100*time.Millisecond)
+	leader := cluster.GetNode(int(leaderID - 1))
+	require.NoError(t, leader.Set(ctx, "foo", []byte("bar")))
+
+	require.Eventually(t, func() bool {
+		status, err := leader.Status()
+		require.NoError(t, err)
+		if status.Leader != leaderID || status.State != "StateLeader" || len(status.Peers) != 3 {
+			return false
+		}
+		for _, peer := range status.Peers {
+			if !peer.Connected || peer.Match != status.CommitIndex {
+				return false
+			}
+		}
+		return status.AppliedIndex == status.CommitIndex
+	}, 10*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package raft
+
+import (
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3"
+)
+
+// PeerInfo is the member of the raft cluster, the members which are in the raft configuration
+// but whose addresses haven't been learned by the node yet are listed with the empty address.
+type PeerInfo struct {
+	ID        uint64 `json:"id"`
+	Addr      string `json:"addr"`
+	IsLeader  bool   `json:"is_leader"`
+	IsLearner bool   `json:"is_learner"`
+	// Match/Next/State/RecentActive are the replication progress of the peer,
+	// which are only available on the leader.
+	Match        uint64 `json:"match"`
+	Next         uint64 `json:"next"`
+	State        string `json:"state,omitempty"`
+	RecentActive bool   `json:"recent_active"`
+	// Connected indicates whether the transport stream to the peer is active
+	Connected   bool      `json:"connected"`
+	ActiveSince time.Time `json:"active_since,omitempty"`
+}
+
+type Status struct {
+	ID            uint64     `json:"id"`
+	Leader        uint64     `json:"leader"`
+	State         string     `json:"state"`
+	Term          uint64     `json:"term"`
+	CommitIndex   uint64     `json:"commit_index"`
+	AppliedIndex  uint64     `json:"applied_index"`
+	SnapshotIndex uint64     `json:"snapshot_index"`
+	Peers         []PeerInfo `json:"peers"`
+}
+
+// Peers returns the members of the raft cluster sorted by the ID
+func (n *Node) Peers() []PeerInfo {
+	return n.peerInfos(n.raftNode.Status())
+}
+
+func (n *Node) peerInfos(raftStatus raft.Status) []PeerInfo {
+	addrs := n.ListPeers()
+	ids := raftStatus.Config.Voters.IDs()
+	for id := range raftStatus.Config.Learners {
+		ids[id] = struct{}{}
+	}
+	for id := range raftStatus.Config.LearnersNext {
+		ids[id] = struct{}{}
+	}
+	for id := range addrs {
+		ids[id] = struct{}{}
+	}
+
+	peers := make([]PeerInfo, 0, len(ids))
+	for id := range ids {
+		peer := PeerInfo{ID: id, Addr: addrs[id], IsLeader: id == raftStatus.Lead}
+		_, isLearner := raftStatus.Config.Learners[id]
+		_, isNextLearner := raftStatus.Config.LearnersNext[id]
+		peer.IsLearner = isLearner || isNextLearner
+		if progress, ok := raftStatus.Progress[id]; ok {
+			peer.Match = progress.Match
+			peer.Next = progress.Next
+			peer.State = progress.State.String()
+			peer.RecentActive = progress.RecentActive
+		}
+		if id == n.config.ID {
+			peer.Connected = true
+		} else if activeSince := n.transport.ActiveSince(types.ID(id)); !activeSince.IsZero() {
+			peer.Connected = true
+			peer.ActiveSince = activeSince
+		}
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+	return peers
+}
+
+// Status returns the diagnostics of the raft node, it's helpful to find out
+// why the replication is stuck.
+func (n *Node) Status() (*Status, error) {
+	raftStatus := n.raftNode.Status()
+	snapshot, err := n.dataStore.raftStorage.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Status{
+		ID:            n.config.ID,
+		Leader:        raftStatus.Lead,
+		State:         raftStatus.RaftState.String(),
+		Term:          raftStatus.Term,
+		CommitIndex:   raftStatus.Commit,
+		AppliedIndex:  raftStatus.Applied,
+		SnapshotIndex: snapshot.Metadata.Index,
+		Peers:         n.peerInfos(raftStatus),
+	}, nil
+}
@@ -166,13 +166,36 @@ func (ds *DataStore) replayWAL() (*raftpb.Snapshot, error) {
 		return nil, fmt.Errorf("failed to reload snapshot: %w", err)
 	}
 	for _, entry := range entries {
-		if err := ds.applyDataEntry(entry); err != nil {
+		if _, err := ds.applyDataEntry(entry); err != nil {
 			return nil, fmt.Errorf("failed to apply data entry: %w", err)
 		}
 	}
 	return snapshot, nil
 }
 
+// bootstrap initializes the WAL and saves the snapshot into the empty data directory,
+// so that the node can be restarted from the snapshot instead of replaying the full log.
+func (ds *DataStore) bootstrap(snapshot raftpb.Snapshot) error {
+	if ds.walExists() {
+		return errors.New("the WAL already exists")
+	}
+	for _, dir := range []string{ds.walDir, ds.snapshotDir} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+	}
+	w, err := wal.Create(logger.Get(), ds.walDir, nil)
+	if err != nil {
+		return err
+	}
+	ds.wal = w
+	defer func() {
+		ds.wal.Close()
+		ds.wal = nil
+	}()
+	return ds.saveSnapshot(snapshot)
+}
+
 func (ds *DataStore) saveSnapshot(snapshot raftpb.Snapshot) error {
 	walSnap := walpb.Snapshot{
 		Index:     snapshot.Metadata.Index,
@@ -188,24 +211,48 @@ func (ds *DataStore) saveSnapshot(snapshot raftpb.Snapshot) error {
 	return ds.wal.ReleaseLockTo(snapshot.Metadata.Index)
 }
 
-func (ds *DataStore) applyDataEntry(entry raftpb.Entry) error {
+// applyDataEntry applies the event in the entry and returns the proposal ID of the event
+func (ds *DataStore) applyDataEntry(entry raftpb.Entry) (uint64, error) {
 	if entry.Type != raftpb.EntryNormal || len(entry.Data) == 0 {
-		return nil
+		return 0, nil
 	}
 
 	var e Event
 	if err := json.Unmarshal(entry.Data, &e); err != nil {
-		return err
+		return 0, err
 	}
 	switch e.Op {
 	case opSet:
 		ds.Set(e.Key, e.Value)
 	case opDelete:
 		ds.Delete(e.Key)
+	case opTxn:
+		if err := ds.applyTxn(e.Ops); err != nil {
+			return e.ID, err
+		}
 	case opGet:
 		// do nothing
 	default:
-		return fmt.Errorf("unknown operation type: %d", e.Op)
+		return e.ID, fmt.Errorf("unknown operation type: %d", e.Op)
+	}
+	return e.ID, nil
+}
+
+// applyTxn applies the set and delete events under the lock, so the readers see all of them or none
+func (ds *DataStore) applyTxn(ops []Event) error {
+	for _, op := range ops {
+		if op.Op != opSet && op.Op != opDelete {
+			return fmt.Errorf("unknown operation type in the txn: %d", op.Op)
+		}
+	}
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	for _, op := range ops {
+		if op.Op == opSet {
+			ds.kvs[op.Key] = op.Value
+		} else {
+			delete(ds.kvs, op.Key)
+		}
 	}
 	return nil
 }
@@ -246,7 +293,7 @@ func (ds *DataStore) List(prefix string) []engine.Entry {
 
 		entries = append(entries, engine.Entry{
 			Key:   trimmedKey,
-			Value: ds.kvs[trimmedKey],
+			Value: ds.kvs[key],
 		})
 	}
 	slices.SortFunc(entries, func(i, j engine.Entry) int {
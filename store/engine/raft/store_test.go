@@ -90,6 +90,8 @@ func TestDataStore(t *testing.T) {
 
 		entries := store.List("bar")
 		require.Len(t, entries, 2)
+		require.Equal(t, []byte("v1"), entries[0].Value)
+		require.Equal(t, []byte("v2"), entries[1].Value)
 
 		entries = store.List("baz")
 		require.Len(t, entries, 1)
@@ -111,3 +113,72 @@ func TestDataStore(t *testing.T) {
 		require.Len(t, entries, 1)
 	})
 }
+
+func TestDataStore_Bootstrap(t *testing.T) {
+	dir := "/tmp/kvrocks/raft/test-datastore-bootstrap"
+	defer os.RemoveAll(dir)
+
+	data, err := json.Marshal(map[string][]byte{"foo": []byte("bar")})
+	require.NoError(t, err)
+	snapshot := raftpb.Snapshot{
+		Data: data,
+		Metadata: raftpb.SnapshotMetadata{
+			Index:     10,
+			Term:      2,
+			ConfState: raftpb.ConfState{Voters: []uint64{1}},
+		},
+	}
+
+	store := NewDataStore(dir)
+	require.NoError(t, store.bootstrap(snapshot))
+	require.Error(t, store.bootstrap(snapshot))
+
+	got, err := store.replayWAL()
+	require.NoError(t, err)
+	defer store.Close()
+	require.EqualValues(t, 10, got.Metadata.Index)
+	require.EqualValues(t, 2, got.Metadata.Term)
+
+	firstIndex, err := store.raftStorage.FirstIndex()
+	require.NoError(t, err)
+	require.EqualValues(t, 11, firstIndex)
+
+	v, err := store.Get("foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), v)
+}
+
+func TestDataStore_ApplyTxn(t *testing.T) {
+	dir := "/tmp/kvrocks/raft/test-datastore-txn"
+	store := NewDataStore(dir)
+	defer func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}()
+
+	store.Set("key-0", []byte("value-0"))
+	payload, err := json.Marshal(Event{ID: 1, Op: opTxn, Ops: []Event{
+		{Op: opSet, Key: "key-1", Value: []byte("value-1")},
+		{Op: opDelete, Key: "key-0"},
+	}})
+	require.NoError(t, err)
+	id, err := store.applyDataEntry(raftpb.Entry{Type: raftpb.EntryNormal, Data: payload})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, id)
+	_, err = store.Get("key-0")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	value, err := store.Get("key-1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value-1"), value)
+
+	// the txn with the unknown operation is rejected without applying any write
+	payload, err = json.Marshal(Event{ID: 2, Op: opTxn, Ops: []Event{
+		{Op: opSet, Key: "key-2", Value: []byte("value-2")},
+		{Op: opGet, Key: "key-1"},
+	}})
+	require.NoError(t, err)
+	_, err = store.applyDataEntry(raftpb.Entry{Type: raftpb.EntryNormal, Data: payload})
+	require.Error(t, err)
+	_, err = store.Get("key-2")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
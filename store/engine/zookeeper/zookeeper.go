@@ -28,6 +28,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/apache/kvrocks-controller/consts"
 	"github.com/apache/kvrocks-controller/logger"
 	"github.com/apache/kvrocks-controller/store/engine"
 	"github.com/go-zookeeper/zk"
@@ -43,6 +44,8 @@ type Config struct {
 	Scheme    string   `yaml:"scheme"`
 	Auth      string   `yaml:"auth"`
 	ElectPath string   `yaml:"elect_path"`
+	// Standby observes the leader without campaigning, it's set by the standby mode of the controller.
+	Standby bool `yaml:"-"`
 }
 
 type Zookeeper struct {
@@ -52,6 +55,7 @@ type Zookeeper struct {
 	leaderID       string
 	myID           string
 	electPath      string
+	standby        bool
 	isReady        atomic.Bool
 	quitCh         chan struct{}
 	leaderChangeCh chan bool
@@ -83,6 +87,7 @@ func New(id string, cfg *Config) (*Zookeeper, error) {
 		myID:           id,
 		acl:            acl,
 		electPath:      electPath,
+		standby:        cfg.Standby,
 		conn:           conn,
 		quitCh:         make(chan struct{}),
 		leaderChangeCh: make(chan bool),
@@ -123,13 +128,18 @@ func (e *Zookeeper) IsReady(ctx context.Context) bool {
 	}
 }
 
+// unavailable wraps the error from zookeeper into the UnavailableError
+func unavailable(err error) error {
+	return &consts.UnavailableError{Engine: "zookeeper", Err: err}
+}
+
 func (e *Zookeeper) Get(ctx context.Context, key string) ([]byte, error) {
 	data, _, err := e.conn.Get(key)
 	if err != nil {
 		if errors.Is(err, zk.ErrNoNode) {
-			return nil, nil // Key does not exist
+			return nil, consts.ErrNotFound
 		}
-		return nil, err
+		return nil, unavailable(err)
 	}
 
 	return data, nil
@@ -138,7 +148,7 @@ func (e *Zookeeper) Get(ctx context.Context, key string) ([]byte, error) {
 func (e *Zookeeper) Exists(ctx context.Context, key string) (bool, error) {
 	exists, _, err := e.conn.Exists(key)
 	if err != nil {
-		return false, err
+		return false, unavailable(err)
 	}
 	return exists, nil
 }
@@ -147,8 +157,10 @@ func (e *Zookeeper) Exists(ctx context.Context, key string) (bool, error) {
 func (e *Zookeeper) Set(ctx context.Context, key string, value []byte) error {
 	exist, _ := e.Exists(ctx, key)
 	if exist {
-		_, err := e.conn.Set(key, value, -1)
-		return err
+		if _, err := e.conn.Set(key, value, -1); err != nil {
+			return unavailable(err)
+		}
+		return nil
 	}
 
 	return e.Create(ctx, key, value, 0)
@@ -167,16 +179,57 @@ func (e *Zookeeper) Create(ctx context.Context, key string, value []byte, flags
 			}
 		}
 	}
-	_, err := e.conn.Create(key, value, flags, e.acl)
-	return err
+	if _, err := e.conn.Create(key, value, flags, e.acl); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (e *Zookeeper) Delete(ctx context.Context, key string) error {
 	err := e.conn.Delete(key, -1)
 	if errors.Is(err, zk.ErrNoNode) {
 		return nil // Key does not exist
+	} else if err != nil {
+		return unavailable(err)
 	}
-	return err
+	return nil
+}
+
+// Txn applies the writes in a single multi request, the parents of the created keys
+// are created before the request since creating the empty parent is harmless.
+func (e *Zookeeper) Txn(ctx context.Context, ops []engine.Op) error {
+	requests := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		exists, err := e.Exists(ctx, op.Key)
+		if err != nil {
+			return err
+		}
+		switch {
+		case op.Delete && exists:
+			requests = append(requests, &zk.DeleteRequest{Path: op.Key, Version: -1})
+		case op.Delete:
+			// deleting the missing key would fail the whole request
+		case exists:
+			requests = append(requests, &zk.SetDataRequest{Path: op.Key, Data: op.Value, Version: -1})
+		default:
+			if lastSlashIndex := strings.LastIndex(op.Key, "/"); lastSlashIndex > 0 {
+				parent := op.Key[:lastSlashIndex]
+				if exist, _ := e.Exists(ctx, parent); !exist {
+					if err := e.Create(ctx, parent, []byte{}, 0); err != nil && !errors.Is(err, zk.ErrNodeExists) {
+						return err
+					}
+				}
+			}
+			requests = append(requests, &zk.CreateRequest{Path: op.Key, Data: op.Value, Acl: e.acl})
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	if _, err := e.conn.Multi(requests...); err != nil {
+		return unavailable(err)
+	}
+	return nil
 }
 
 func (e *Zookeeper) List(ctx context.Context, prefix string) ([]engine.Entry, error) {
@@ -184,7 +237,7 @@ func (e *Zookeeper) List(ctx context.Context, prefix string) ([]engine.Entry, er
 	if errors.Is(err, zk.ErrNoNode) {
 		return []engine.Entry{}, nil
 	} else if err != nil {
-		return nil, err
+		return nil, unavailable(err)
 	}
 
 	entries := make([]engine.Entry, 0)
@@ -192,7 +245,7 @@ func (e *Zookeeper) List(ctx context.Context, prefix string) ([]engine.Entry, er
 		key := prefix + "/" + child
 		data, _, err := e.conn.Get(key)
 		if err != nil {
-			return nil, err
+			return nil, unavailable(err)
 		}
 
 		entry := engine.Entry{
@@ -218,6 +271,14 @@ func (e *Zookeeper) SetleaderID(newLeaderID string) {
 	}
 }
 
+// campaign creates the ephemeral elect path with its ID, the standby never campaigns
+func (e *Zookeeper) campaign(ctx context.Context) error {
+	if e.standby {
+		return nil
+	}
+	return e.Create(ctx, e.electPath, []byte(e.myID), zk.FlagEphemeral)
+}
+
 func (e *Zookeeper) electLoop(ctx context.Context) {
 	defer e.wg.Done()
 reset:
@@ -226,11 +287,12 @@ reset:
 		return
 	default:
 	}
-	err := e.Create(ctx, e.electPath, []byte(e.myID), zk.FlagEphemeral)
+	err := e.campaign(ctx)
 	if err != nil && !errors.Is(err, zk.ErrNodeExists) {
 		time.Sleep(sessionTTL / 3)
 		goto reset
 	}
+	// the standby will retry until the leader was elected since the elect path doesn't exist
 	data, _, ch, err := e.conn.GetW(e.electPath)
 	if err != nil {
 		time.Sleep(sessionTTL / 3)
@@ -242,7 +304,7 @@ reset:
 		select {
 		case resp := <-ch:
 			if resp.Type == zk.EventNodeDeleted {
-				err := e.Create(ctx, e.electPath, []byte(e.myID), zk.FlagEphemeral)
+				err := e.campaign(ctx)
 				if err != nil && !errors.Is(err, zk.ErrNodeExists) {
 					time.Sleep(sessionTTL / 3)
 					goto reset
@@ -25,12 +25,22 @@ type Command int
 const (
 	EventNamespace EventType = iota + 1
 	EventCluster
+	// EventEngine records the health changes of the metadata store engine
+	EventEngine
 )
 
 const (
 	CommandCreate = iota + 1
 	CommandUpdate = iota + 1
 	CommandRemove
+	// CommandWarn records the warning of the cluster for the operators, e.g. the forced operations
+	CommandWarn
+	// CommandCritical records the critical condition which requires the operators to intervene,
+	// e.g. the automatic failover was suspended by the breaker
+	CommandCritical
+	// CommandGap marks that some events were dropped from the event log, the consumers
+	// should resync with the stored state since they can't be replayed
+	CommandGap
 )
 
 type EventPayload struct {
@@ -38,4 +48,7 @@ type EventPayload struct {
 	Cluster   string
 	Type      EventType
 	Command   Command
+	Message   string
+	// Labels are the static labels of the namespace and cluster
+	Labels map[string]string
 }
@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+const (
+	eventLogPrefix = "/kvrocks/events"
+	eventLogSeqKey = "/kvrocks/event_seq"
+
+	// MaxEventLogSize is the window of the persisted events which can be replayed
+	MaxEventLogSize = 1000
+
+	eventLogTimeout = 5 * time.Second
+	// eventLogEnqueueTimeout is the time to wait for the queue before the event is dropped
+	eventLogEnqueueTimeout = time.Second
+	// eventLogRetryInterval is the interval to retry appending the gap marker
+	eventLogRetryInterval = time.Second
+	// eventLogQueueSize is the number of the emitted events which are waiting to be appended
+	eventLogQueueSize = 1024
+)
+
+// Event is the persisted event which can be replayed by the consumers with the last event ID
+type Event struct {
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Command   string `json:"command"`
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// Labels are the static labels of the namespace and cluster when the event was emitted
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+func (t EventType) String() string {
+	switch t {
+	case EventNamespace:
+		return "namespace"
+	case EventCluster:
+		return "cluster"
+	case EventEngine:
+		return "engine"
+	default:
+		return "unknown"
+	}
+}
+
+func (c Command) String() string {
+	switch c {
+	case CommandCreate:
+		return "create"
+	case CommandUpdate:
+		return "update"
+	case CommandRemove:
+		return "remove"
+	case CommandWarn:
+		return "warn"
+	case CommandCritical:
+		return "critical"
+	case CommandGap:
+		return "gap"
+	default:
+		return "unknown"
+	}
+}
+
+func buildEventKey(id int64) string {
+	return fmt.Sprintf("%s/%020d", eventLogPrefix, id)
+}
+
+// EventLog persists the events into the engine within a bounded window,
+// and broadcasts the appended events to the local subscribers.
+type EventLog struct {
+	e engine.Engine
+
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewEventLog(e engine.Engine) *EventLog {
+	return &EventLog{
+		e:           e,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// LastID returns the ID of the latest appended event, it's 0 if no event was appended
+func (l *EventLog) LastID(ctx context.Context) (int64, error) {
+	return l.lastID(ctx)
+}
+
+func (l *EventLog) lastID(ctx context.Context) (int64, error) {
+	value, err := l.e.Get(ctx, eventLogSeqKey)
+	if errors.Is(err, consts.ErrNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+// Append persists the event with the next event ID, and the event which falls
+// out of the window will be deleted. The appends are serialized by the lock.
+func (l *EventLog) Append(ctx context.Context, payload EventPayload) (*Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lastID, err := l.lastID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("event seq: %w", err)
+	}
+	event := Event{
+		ID:        lastID + 1,
+		Type:      payload.Type.String(),
+		Command:   payload.Command.String(),
+		Namespace: payload.Namespace,
+		Cluster:   payload.Cluster,
+		Message:   payload.Message,
+		Labels:    payload.Labels,
+		Timestamp: time.Now().Unix(),
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	// the sequence is advanced with the event atomically, otherwise the event ID
+	// would be reused if the controller crashed after writing the event.
+	ops := []engine.Op{
+		engine.OpSet(eventLogSeqKey, []byte(strconv.FormatInt(event.ID, 10))),
+		engine.OpSet(buildEventKey(event.ID), value),
+	}
+	if expiredID := event.ID - MaxEventLogSize; expiredID > 0 {
+		ops = append(ops, engine.OpDelete(buildEventKey(expiredID)))
+	}
+	if txn, ok := l.e.(engine.Transactional); ok {
+		if err := txn.Txn(ctx, ops); err != nil {
+			return nil, err
+		}
+	} else {
+		// the sequence goes first, so the failed write leaves a gap instead of the reused ID
+		for _, op := range ops {
+			if err := applyOp(ctx, l.e, op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for ch := range l.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// the slow subscriber can catch up by replaying with the last event ID
+		}
+	}
+	return &event, nil
+}
+
+// Since returns the persisted events whose ID is greater than the last event ID in order
+func (l *EventLog) Since(ctx context.Context, lastEventID int64) ([]Event, error) {
+	entries, err := l.e.List(ctx, eventLogPrefix)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(entries))
+	for _, entry := range entries {
+		var event Event
+		if err := json.Unmarshal(entry.Value, &event); err != nil {
+			return nil, fmt.Errorf("event %s: %w", entry.Key, err)
+		}
+		if event.ID > lastEventID {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	return events, nil
+}
+
+// Subscribe returns the channel to receive the events appended by this controller,
+// the cancel function should be called to release the subscription.
+func (l *EventLog) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+	l.mu.Lock()
+	l.subscribers[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch, func() {
+		l.mu.Lock()
+		delete(l.subscribers, ch)
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestEventLog(t *testing.T) {
+	ctx := context.Background()
+	eventLog := NewEventLog(engine.NewMock())
+	events, err := eventLog.Since(ctx, 0)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	liveEvents, cancel := eventLog.Subscribe()
+	defer cancel()
+	event, err := eventLog.Append(ctx, EventPayload{Namespace: "ns", Cluster: "c0", Type: EventCluster, Command: CommandUpdate})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, event.ID)
+	require.Equal(t, "cluster", event.Type)
+	require.Equal(t, "update", event.Command)
+	require.Equal(t, *event, <-liveEvents)
+
+	for i := 1; i < MaxEventLogSize+5; i++ {
+		_, err := eventLog.Append(ctx, EventPayload{Namespace: "ns", Type: EventNamespace, Command: CommandCreate})
+		require.NoError(t, err)
+	}
+	events, err = eventLog.Since(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, events, MaxEventLogSize)
+	require.EqualValues(t, 6, events[0].ID)
+	lastID, err := eventLog.LastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, MaxEventLogSize+5, lastID)
+	require.EqualValues(t, MaxEventLogSize+5, events[len(events)-1].ID)
+
+	events, err = eventLog.Since(ctx, MaxEventLogSize+2)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.EqualValues(t, MaxEventLogSize+3, events[0].ID)
+}
+
+func TestEventLog_AppendAtomically(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	eventLog := NewEventLog(mock)
+	_, err := eventLog.Append(ctx, EventPayload{Namespace: "ns", Type: EventNamespace, Command: CommandCreate})
+	require.NoError(t, err)
+
+	// the sequence isn't advanced if the event failed to be written
+	mock.WithKeyFailure(buildEventKey(2), nil)
+	_, err = eventLog.Append(ctx, EventPayload{Namespace: "ns", Type: EventNamespace, Command: CommandUpdate})
+	require.ErrorIs(t, err, engine.ErrInjected)
+	lastID, err := eventLog.LastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, lastID)
+
+	mock.ResetFaults()
+	event, err := eventLog.Append(ctx, EventPayload{Namespace: "ns", Type: EventNamespace, Command: CommandUpdate})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, event.ID)
+}
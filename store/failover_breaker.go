@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const failoverBreakerKey = "/kvrocks/failover_breaker"
+
+// FailoverBreakerState is the persisted state of the failover breaker, so that the new leader
+// keeps the tripped breakers and counts the failovers in the window of the previous leader.
+type FailoverBreakerState struct {
+	// Failovers are the unix milliseconds of the failovers in the window keyed by the breaker
+	Failovers map[string][]int64 `json:"failovers"`
+	// TrippedAt is the unix milliseconds when the breaker was tripped keyed by the breaker
+	TrippedAt map[string]int64 `json:"tripped_at"`
+}
+
+// GetFailoverBreakerState returns the persisted state, it's empty if the state wasn't saved yet
+func (s *ClusterStore) GetFailoverBreakerState(ctx context.Context) (*FailoverBreakerState, error) {
+	state := &FailoverBreakerState{
+		Failovers: make(map[string][]int64),
+		TrippedAt: make(map[string]int64),
+	}
+	value, err := s.e.Get(ctx, failoverBreakerKey)
+	if errors.Is(err, consts.ErrNotFound) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(value, state); err != nil {
+		return nil, fmt.Errorf("failover breaker: %w", err)
+	}
+	return state, nil
+}
+
+// SaveFailoverBreakerState saves the state of the failover breaker, it should only be called by the leader
+func (s *ClusterStore) SaveFailoverBreakerState(ctx context.Context, state *FailoverBreakerState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.e.Set(ctx, failoverBreakerKey, value)
+}
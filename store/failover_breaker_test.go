@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestFailoverBreakerState(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	state, err := s.GetFailoverBreakerState(ctx)
+	require.NoError(t, err)
+	require.Empty(t, state.Failovers)
+	require.Empty(t, state.TrippedAt)
+
+	state.Failovers["ns/c1"] = []int64{1000, 2000}
+	state.TrippedAt["ns/c1"] = 2000
+	require.NoError(t, s.SaveFailoverBreakerState(ctx, state))
+	got, err := s.GetFailoverBreakerState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, state, got)
+}
@@ -0,0 +1,327 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+)
+
+const (
+	FailoverTriggerAuto   = "auto"
+	FailoverTriggerManual = "manual"
+
+	DefaultFailoverHookTimeout = 5 * time.Second
+	DefaultFailoverHookBackoff = time.Second
+
+	// maxFailoverHookOutput is the max length of the command output kept in the error
+	maxFailoverHookOutput = 256
+)
+
+// FailoverHook is the external command or webhook invoked with the JSON payload after
+// promoting a new master, e.g. to update the DNS, VIPs or the client config stores.
+// Both of them are invoked if the command and URL are set, and the hook fails if either fails.
+type FailoverHook struct {
+	// Command is executed with the payload in the stdin, it fails if the exit code isn't 0
+	Command []string
+	// URL receives the payload in the POST body, it fails if the status code isn't 2xx
+	URL string
+	// Timeout is the timeout of each attempt
+	Timeout time.Duration
+	// Retries is the number of the retries after the attempt failed
+	Retries int
+	Backoff time.Duration
+}
+
+var failoverHook atomic.Pointer[FailoverHook]
+
+// SetFailoverHook sets the hook invoked after promoting a new master, nil disables it
+func SetFailoverHook(hook *FailoverHook) {
+	failoverHook.Store(hook)
+}
+
+// FailoverHookPayload is the JSON payload passed to the failover hook
+type FailoverHookPayload struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// Version is the cluster version which promoted the new master
+	Version       int64  `json:"version"`
+	Shard         int    `json:"shard"`
+	OldMasterID   string `json:"old_master_id"`
+	OldMasterAddr string `json:"old_master_addr"`
+	NewMasterID   string `json:"new_master_id"`
+	NewMasterAddr string `json:"new_master_addr"`
+	// Trigger is auto if the controller failed over the failing master, or manual if it's requested via the API
+	Trigger   string            `json:"trigger"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// FailoverHookResult is the result of the failover hook which is recorded in the cluster revision
+type FailoverHookResult struct {
+	Shard       int    `json:"shard"`
+	NewMasterID string `json:"new_master_id"`
+	Attempts    int    `json:"attempts"`
+	// Error is the error of the last attempt, it's empty if the hook succeeded
+	Error      string `json:"error,omitempty"`
+	ElapsedMs  int64  `json:"elapsed_ms"`
+	FinishedAt int64  `json:"finished_at"`
+}
+
+// Run invokes the hook until it succeeds or the retries are exhausted
+func (hook *FailoverHook) Run(ctx context.Context, payload *FailoverHookPayload) *FailoverHookResult {
+	start := time.Now()
+	result := &FailoverHookResult{Shard: payload.Shard, NewMasterID: payload.NewMasterID}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for attempt := 0; attempt <= hook.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-time.After(hook.Backoff):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		result.Attempts++
+		if err = hook.invoke(ctx, data); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.ElapsedMs = time.Since(start).Milliseconds()
+	result.FinishedAt = time.Now().Unix()
+	return result
+}
+
+func (hook *FailoverHook) invoke(ctx context.Context, data []byte) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFailoverHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if len(hook.Command) > 0 {
+		cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			output = bytes.TrimSpace(output)
+			if len(output) > maxFailoverHookOutput {
+				output = output[:maxFailoverHookOutput]
+			}
+			return fmt.Errorf("command %s: %w: %s", strings.Join(hook.Command, " "), err, output)
+		}
+	}
+	if hook.URL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			// the URL in the error may carry the credentials in the query
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return fmt.Errorf("webhook %s: %w", req.URL.Host+req.URL.Path, err)
+		}
+		defer rsp.Body.Close()
+		if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s: %s", req.URL.Host+req.URL.Path, rsp.Status)
+		}
+	}
+	return nil
+}
+
+func newFailoverHookPayload(ns string, cluster *Cluster, shardIdx int, oldMasterID, newMasterID, trigger string) *FailoverHookPayload {
+	payload := &FailoverHookPayload{
+		Namespace:   ns,
+		Cluster:     cluster.Name,
+		Version:     cluster.Version.Load(),
+		Shard:       shardIdx,
+		OldMasterID: oldMasterID,
+		NewMasterID: newMasterID,
+		Trigger:     trigger,
+		Timestamp:   time.Now().Unix(),
+	}
+	if shardIdx >= 0 && shardIdx < len(cluster.Shards) {
+		for _, node := range cluster.Shards[shardIdx].Nodes {
+			switch node.ID() {
+			case oldMasterID:
+				payload.OldMasterAddr = node.Addr()
+			case newMasterID:
+				payload.NewMasterAddr = node.Addr()
+			}
+		}
+	}
+	return payload
+}
+
+// RunFailoverHook invokes the failover hook after the new master of the shard was promoted in the cluster,
+// and records the result in the revision of the cluster version. It does nothing if no hook was set.
+func (s *ClusterStore) RunFailoverHook(ctx context.Context, ns string, cluster *Cluster, shardIdx int, oldMasterID, newMasterID, trigger string) {
+	hook := failoverHook.Load()
+	if hook == nil {
+		return
+	}
+	payload := newFailoverHookPayload(ns, cluster, shardIdx, oldMasterID, newMasterID, trigger)
+	payload.Labels = s.StaticLabels(ns, cluster.Name)
+	log := logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("cluster", cluster.Name),
+		zap.Int64("version", payload.Version),
+		zap.Int("shard", shardIdx),
+		zap.String("new_master_id", newMasterID))
+
+	var result *FailoverHookResult
+	key := fmt.Sprintf("%s/%s/%d", ns, cluster.Name, shardIdx)
+	if !s.runFailoverHookInOrder(ctx, key, payload.Version, func(ctx context.Context) {
+		if s.isStaleMaster(ctx, ns, cluster.Name, shardIdx, newMasterID) {
+			return
+		}
+		result = hook.Run(ctx, payload)
+	}) || result == nil {
+		log.Warn("Skip the failover hook since the newer master was promoted")
+		return
+	}
+	log = log.With(zap.Int("attempts", result.Attempts), zap.Int64("elapsed_ms", result.ElapsedMs))
+	if result.Error != "" {
+		log.Error("Failed to run the failover hook", zap.String("error", result.Error))
+	} else {
+		log.Info("Run the failover hook")
+	}
+	if err := s.recordFailoverHookResult(ctx, ns, cluster.Name, payload.Version, result); err != nil {
+		log.Warn("Failed to record the result of the failover hook", zap.Error(err))
+	}
+}
+
+// failoverHookRunner serializes the failover hooks of the same shard, so that the external systems
+// end up with the latest master. The newer promotion cancels the running hook of the older one,
+// and the older hook is skipped if it starts after the newer one was started.
+type failoverHookRunner struct {
+	runMu sync.Mutex
+
+	mu      sync.Mutex
+	version int64
+	cancel  context.CancelFunc
+	// removed is set after the runner was removed from the store, and the new runner should be created
+	removed bool
+}
+
+// runFailoverHookInOrder runs the hook of the cluster version if no newer version of the shard was run,
+// it returns false if the hook was skipped.
+func (s *ClusterStore) runFailoverHookInOrder(ctx context.Context, key string, version int64, run func(ctx context.Context)) bool {
+	var runner *failoverHookRunner
+	for {
+		value, _ := s.failoverHookRunners.LoadOrStore(key, &failoverHookRunner{})
+		runner, _ = value.(*failoverHookRunner)
+		runner.mu.Lock()
+		if !runner.removed {
+			break
+		}
+		runner.mu.Unlock()
+	}
+	if version < runner.version {
+		runner.mu.Unlock()
+		return false
+	}
+	if runner.cancel != nil {
+		runner.cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runner.version, runner.cancel = version, cancel
+	runner.mu.Unlock()
+
+	runner.runMu.Lock()
+	defer runner.runMu.Unlock()
+	// the newer hook was started while waiting for the running one
+	if ctx.Err() != nil {
+		return false
+	}
+	run(ctx)
+
+	runner.mu.Lock()
+	if runner.version == version {
+		runner.removed = true
+		s.failoverHookRunners.Delete(key)
+	}
+	runner.mu.Unlock()
+	return true
+}
+
+// isStaleMaster returns true if the stored master of the shard isn't the promoted one, the hook
+// is run if the cluster couldn't be read since the clients still need to be redirected.
+func (s *ClusterStore) isStaleMaster(ctx context.Context, ns, cluster string, shardIdx int, newMasterID string) bool {
+	current, err := s.GetCluster(ctx, ns, cluster)
+	if err != nil || shardIdx < 0 || shardIdx >= len(current.Shards) {
+		return false
+	}
+	master := current.Shards[shardIdx].GetMasterNode()
+	return master != nil && master.ID() != newMasterID
+}
+
+// recordFailoverHookResult appends the hook result to the revision of the cluster version
+func (s *ClusterStore) recordFailoverHookResult(ctx context.Context, ns, cluster string, version int64, result *FailoverHookResult) error {
+	unlock := s.locks.Lock(ns, cluster)
+	defer unlock()
+
+	revisions, err := s.ListClusterRevisions(ctx, ns, cluster)
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		if revision.Version == version {
+			revision.FailoverHooks = append(revision.FailoverHooks, *result)
+			value, err := json.Marshal(revisions)
+			if err != nil {
+				return err
+			}
+			return s.e.Set(ctx, buildClusterHistoryKey(ns, cluster), value)
+		}
+	}
+	return fmt.Errorf("revision %d: %w", version, consts.ErrNotFound)
+}
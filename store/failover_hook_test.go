@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestFailoverHook_Run(t *testing.T) {
+	payload := &FailoverHookPayload{Namespace: "ns", Cluster: "cluster", Shard: 1, NewMasterID: "new-master"}
+
+	t.Run("command", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "payload.json")
+		hook := &FailoverHook{Command: []string{"sh", "-c", "cat > " + output}}
+		result := hook.Run(context.Background(), payload)
+		require.Empty(t, result.Error)
+		require.Equal(t, 1, result.Attempts)
+		require.Equal(t, "new-master", result.NewMasterID)
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		var received FailoverHookPayload
+		require.NoError(t, json.Unmarshal(data, &received))
+		require.Equal(t, *payload, received)
+
+		hook = &FailoverHook{Command: []string{"sh", "-c", "echo no route; exit 1"}, Retries: 2}
+		result = hook.Run(context.Background(), payload)
+		require.Equal(t, 3, result.Attempts)
+		require.Contains(t, result.Error, "no route")
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var received FailoverHookPayload
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			require.Equal(t, "new-master", received.NewMasterID)
+			// fail the first attempt
+			if requests.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		hook := &FailoverHook{URL: server.URL, Retries: 1, Backoff: 10 * time.Millisecond}
+		result := hook.Run(context.Background(), payload)
+		require.Empty(t, result.Error)
+		require.Equal(t, 2, result.Attempts)
+		require.EqualValues(t, 2, requests.Load())
+	})
+}
+
+func TestClusterStore_RunFailoverHook(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:1112"}, 2)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	oldMaster, newMaster := cluster.Shards[0].Nodes[0], cluster.Shards[0].Nodes[1]
+	oldMaster.SetRole(RoleSlave)
+	newMaster.SetRole(RoleMaster)
+	require.NoError(t, s.UpdateCluster(ctx, "ns", cluster))
+
+	// nothing is recorded without the hook
+	s.RunFailoverHook(ctx, "ns", cluster, 0, oldMaster.ID(), newMaster.ID(), FailoverTriggerManual)
+	revision, err := s.GetClusterRevision(ctx, "ns", "cluster", cluster.Version.Load())
+	require.NoError(t, err)
+	require.Empty(t, revision.FailoverHooks)
+
+	output := filepath.Join(t.TempDir(), "payload.json")
+	SetFailoverHook(&FailoverHook{Command: []string{"sh", "-c", "cat > " + output}})
+	defer SetFailoverHook(nil)
+	s.RunFailoverHook(ctx, "ns", cluster, 0, oldMaster.ID(), newMaster.ID(), FailoverTriggerManual)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var payload FailoverHookPayload
+	require.NoError(t, json.Unmarshal(data, &payload))
+	require.Equal(t, "cluster", payload.Cluster)
+	require.Equal(t, cluster.Version.Load(), payload.Version)
+	require.Equal(t, oldMaster.Addr(), payload.OldMasterAddr)
+	require.Equal(t, newMaster.Addr(), payload.NewMasterAddr)
+	require.Equal(t, FailoverTriggerManual, payload.Trigger)
+
+	revision, err = s.GetClusterRevision(ctx, "ns", "cluster", cluster.Version.Load())
+	require.NoError(t, err)
+	require.Len(t, revision.FailoverHooks, 1)
+	require.Equal(t, newMaster.ID(), revision.FailoverHooks[0].NewMasterID)
+	require.Equal(t, 1, revision.FailoverHooks[0].Attempts)
+	require.Empty(t, revision.FailoverHooks[0].Error)
+
+	// the hook is skipped if the master was changed again
+	require.NoError(t, os.Remove(output))
+	s.RunFailoverHook(ctx, "ns", cluster, 0, newMaster.ID(), oldMaster.ID(), FailoverTriggerManual)
+	require.NoFileExists(t, output)
+}
+
+func TestClusterStore_RunFailoverHookInOrder(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	started := make(chan struct{})
+	var results []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.True(t, s.runFailoverHookInOrder(ctx, "ns/cluster/0", 1, func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+			results = append(results, "canceled")
+		}))
+	}()
+	<-started
+
+	// the newer promotion cancels the running hook of the older one
+	require.True(t, s.runFailoverHookInOrder(ctx, "ns/cluster/0", 2, func(ctx context.Context) {
+		results = append(results, "latest")
+	}))
+	wg.Wait()
+	require.Equal(t, []string{"canceled", "latest"}, results)
+
+	// the runner is removed after the latest hook was run
+	_, ok := s.failoverHookRunners.Load("ns/cluster/0")
+	require.False(t, ok)
+}
+
+func TestFailoverHook_RedactWebhookURL(t *testing.T) {
+	hook := &FailoverHook{URL: "http://127.0.0.1:1/failover?token=secret", Timeout: time.Second}
+	result := hook.Run(context.Background(), &FailoverHookPayload{})
+	require.Contains(t, result.Error, "webhook 127.0.0.1:1/failover")
+	require.NotContains(t, result.Error, "secret")
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/kvrocks-controller/metrics"
+)
+
+// namespaceLabelsRefreshInterval bounds the staleness of the cached namespace labels,
+// since the namespace might be updated by another controller while this one is a follower.
+const namespaceLabelsRefreshInterval = time.Minute
+
+// mergeLabels merges the updates into the labels, the label will be removed if its value
+// is empty. The labels are copied on write since they may be shared by the clones.
+func mergeLabels(labels, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(labels)+len(updates))
+	for key, value := range labels {
+		merged[key] = value
+	}
+	for key, value := range updates {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// UpdateLabels merges the labels into the cluster, the label will be removed if its value is empty
+func (cluster *Cluster) UpdateLabels(labels map[string]string) {
+	cluster.Labels = mergeLabels(cluster.Labels, labels)
+}
+
+// UpdateLabels merges the labels into the namespace, the label will be removed if its value is empty
+func (namespace *Namespace) UpdateLabels(labels map[string]string) {
+	namespace.Labels = mergeLabels(namespace.Labels, labels)
+}
+
+type cachedNamespaceLabels struct {
+	labels   map[string]string
+	loadedAt time.Time
+}
+
+// labelCache caches the static labels of the namespaces and clusters which were read or
+// written by the store, they're added to the per-cluster metrics and the emitted events.
+type labelCache struct {
+	mu         sync.RWMutex
+	namespaces map[string]*cachedNamespaceLabels
+	clusters   map[string]map[string]string
+}
+
+// StaticLabels returns the cached labels of the cluster, the cluster labels
+// override the namespace labels with the same key.
+func (s *ClusterStore) StaticLabels(ns, cluster string) map[string]string {
+	s.labels.mu.RLock()
+	defer s.labels.mu.RUnlock()
+	var labels map[string]string
+	if cached, ok := s.labels.namespaces[ns]; ok {
+		labels = mergeLabels(labels, cached.labels)
+	}
+	if cluster != "" {
+		labels = mergeLabels(labels, s.labels.clusters[buildClusterKey(ns, cluster)])
+	}
+	return labels
+}
+
+func (s *ClusterStore) cacheNamespaceLabels(ns string, labels map[string]string) {
+	s.labels.mu.Lock()
+	if s.labels.namespaces == nil {
+		s.labels.namespaces = make(map[string]*cachedNamespaceLabels)
+	}
+	s.labels.namespaces[ns] = &cachedNamespaceLabels{labels: labels, loadedAt: time.Now()}
+	clusters := make([]string, 0)
+	prefix := buildClusterPrefix(ns) + "/"
+	for key := range s.labels.clusters {
+		if strings.HasPrefix(key, prefix) {
+			clusters = append(clusters, strings.TrimPrefix(key, prefix))
+		}
+	}
+	s.labels.mu.Unlock()
+
+	for _, cluster := range clusters {
+		metrics.SetStaticLabels(ns, cluster, s.StaticLabels(ns, cluster))
+	}
+}
+
+func (s *ClusterStore) cacheClusterLabels(ctx context.Context, ns, cluster string, labels map[string]string) {
+	s.labels.mu.Lock()
+	if s.labels.clusters == nil {
+		s.labels.clusters = make(map[string]map[string]string)
+	}
+	s.labels.clusters[buildClusterKey(ns, cluster)] = labels
+	cached, ok := s.labels.namespaces[ns]
+	s.labels.mu.Unlock()
+
+	if !ok || time.Since(cached.loadedAt) > namespaceLabelsRefreshInterval {
+		// the namespace labels are cached by GetNamespace
+		if _, err := s.GetNamespace(ctx, ns); err != nil {
+			s.cacheNamespaceLabels(ns, nil)
+		}
+	}
+	metrics.SetStaticLabels(ns, cluster, s.StaticLabels(ns, cluster))
+}
+
+func (s *ClusterStore) uncacheLabels(ns, cluster string) {
+	s.labels.mu.Lock()
+	if cluster == "" {
+		delete(s.labels.namespaces, ns)
+	} else {
+		delete(s.labels.clusters, buildClusterKey(ns, cluster))
+	}
+	s.labels.mu.Unlock()
+	if cluster != "" {
+		metrics.DeleteStaticLabels(ns, cluster)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/metrics"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_StaticLabels(t *testing.T) {
+	ctx := context.Background()
+	metrics.SetStaticLabelKeys([]string{"team", "environment"})
+	defer metrics.SetStaticLabelKeys(nil)
+	s := NewClusterStore(engine.NewMock())
+
+	require.NoError(t, s.CreateNamespace(ctx, "ns0"))
+	namespace, err := s.GetNamespace(ctx, "ns0")
+	require.NoError(t, err)
+	namespace.UpdateLabels(map[string]string{"team": "storage", "environment": "prod"})
+	require.NoError(t, s.UpdateNamespace(ctx, namespace))
+
+	cluster := &Cluster{Name: "cluster0", Shards: Shards{NewShard()}}
+	cluster.UpdateLabels(map[string]string{"environment": "staging"})
+	require.NoError(t, s.CreateCluster(ctx, "ns0", cluster))
+	require.Equal(t, map[string]string{"team": "storage", "environment": "staging"}, s.StaticLabels("ns0", "cluster0"))
+	require.Equal(t, []string{"ns0", "cluster0", "storage", "staging"}, metrics.ClusterLabelValues("ns0", "cluster0"))
+
+	// the removed label falls back to the namespace label
+	cluster.UpdateLabels(map[string]string{"environment": ""})
+	require.Nil(t, cluster.Labels)
+	require.NoError(t, s.UpdateCluster(ctx, "ns0", cluster))
+	require.Equal(t, []string{"ns0", "cluster0", "storage", "prod"}, metrics.ClusterLabelValues("ns0", "cluster0"))
+
+	// the events are appended asynchronously
+	require.Eventually(t, func() bool {
+		events, err := s.Events().Since(ctx, 0)
+		require.NoError(t, err)
+		if len(events) == 0 {
+			return false
+		}
+		lastEvent := events[len(events)-1]
+		return lastEvent.Cluster == "cluster0" && lastEvent.Command == "update" &&
+			maps.Equal(map[string]string{"team": "storage", "environment": "prod"}, lastEvent.Labels)
+	}, time.Second, 10*time.Millisecond)
+
+	// the labels of the namespace are loaded when the cluster is read by another store
+	other := NewClusterStore(s.GetEngine())
+	_, err = other.GetCluster(ctx, "ns0", "cluster0")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "storage", "environment": "prod"}, other.StaticLabels("ns0", "cluster0"))
+
+	require.NoError(t, s.RemoveCluster(ctx, "ns0", "cluster0"))
+	require.Equal(t, map[string]string{"team": "storage", "environment": "prod"}, s.StaticLabels("ns0", "cluster0"))
+	require.Equal(t, []string{"ns0", "cluster0", "", ""}, metrics.ClusterLabelValues("ns0", "cluster0"))
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const (
+	leaderHeartbeatKey = "/kvrocks/leader_heartbeat"
+
+	// LeaderHeartbeatInterval is the interval of the leader to write the heartbeat,
+	// the staleness measured by the followers is at most one interval more than the actual.
+	LeaderHeartbeatInterval = time.Second
+)
+
+// LeaderHeartbeat is written by the leader periodically, the followers measure how stale
+// their local engine is by the age of the last heartbeat which was replicated to them.
+type LeaderHeartbeat struct {
+	Leader   string `json:"leader"`
+	Revision int64  `json:"revision"`
+	// Timestamp is the unix time in milliseconds when the heartbeat was written
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Staleness is the revision and age of the last leader heartbeat in the local engine
+type Staleness struct {
+	Revision int64
+	Age      time.Duration
+}
+
+func (staleness *Staleness) String() string {
+	return fmt.Sprintf("revision=%d; age=%dms", staleness.Revision, staleness.Age.Milliseconds())
+}
+
+func (s *ClusterStore) getLeaderHeartbeat(ctx context.Context) (*LeaderHeartbeat, error) {
+	value, err := s.e.Get(ctx, leaderHeartbeatKey)
+	if err != nil {
+		return nil, err
+	}
+	var heartbeat LeaderHeartbeat
+	if err := json.Unmarshal(value, &heartbeat); err != nil {
+		return nil, fmt.Errorf("leader heartbeat: %w", err)
+	}
+	return &heartbeat, nil
+}
+
+// WriteLeaderHeartbeat writes the heartbeat with the next revision, it should only be called by the leader
+func (s *ClusterStore) WriteLeaderHeartbeat(ctx context.Context) error {
+	heartbeat, err := s.getLeaderHeartbeat(ctx)
+	if errors.Is(err, consts.ErrNotFound) {
+		heartbeat = &LeaderHeartbeat{}
+	} else if err != nil {
+		return err
+	}
+	heartbeat.Leader = s.ID()
+	heartbeat.Revision++
+	heartbeat.Timestamp = time.Now().UnixMilli()
+	value, err := json.Marshal(heartbeat)
+	if err != nil {
+		return err
+	}
+	return s.e.Set(ctx, leaderHeartbeatKey, value)
+}
+
+// GetStaleness returns the staleness of the local engine by the last leader heartbeat,
+// the age is measured by the local clock, so it's also affected by the clock skew.
+func (s *ClusterStore) GetStaleness(ctx context.Context) (*Staleness, error) {
+	heartbeat, err := s.getLeaderHeartbeat(ctx)
+	if err != nil {
+		return nil, err
+	}
+	age := time.Since(time.UnixMilli(heartbeat.Timestamp))
+	if age < 0 {
+		age = 0
+	}
+	return &Staleness{Revision: heartbeat.Revision, Age: age}, nil
+}
@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestLeaderHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	_, err := s.GetStaleness(ctx)
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	require.NoError(t, s.WriteLeaderHeartbeat(ctx))
+	require.NoError(t, s.WriteLeaderHeartbeat(ctx))
+	staleness, err := s.GetStaleness(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, staleness.Revision)
+	require.Less(t, staleness.Age, time.Second)
+
+	heartbeat, err := s.getLeaderHeartbeat(ctx)
+	require.NoError(t, err)
+	require.Equal(t, s.ID(), heartbeat.Leader)
+	require.Equal(t, "revision=3; age=1500ms", (&Staleness{Revision: 3, Age: 1500 * time.Millisecond}).String())
+}
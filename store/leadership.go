@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+const (
+	leadershipHistoryKey = "/kvrocks/leadership_history"
+
+	// MaxLeadershipHistorySize is the number of the latest leadership changes to be kept
+	MaxLeadershipHistorySize = 100
+)
+
+// LeadershipChange is recorded by the controller which became the leader
+type LeadershipChange struct {
+	Leader         string `json:"leader"`
+	PreviousLeader string `json:"previous_leader"`
+	// Term is the raft term or the etcd revision of the election key, it's 0 if the engine can't tell
+	Term      int64 `json:"term"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+// LeaderTerm returns the term of the current leadership, or 0 if the engine can't tell
+func (s *ClusterStore) LeaderTerm() int64 {
+	if provider, ok := s.e.(engine.TermProvider); ok {
+		return provider.LeaderTerm()
+	}
+	return 0
+}
+
+// LeadershipHistory returns the latest leadership changes, the newest change comes first
+func (s *ClusterStore) LeadershipHistory(ctx context.Context) ([]LeadershipChange, error) {
+	value, err := s.e.Get(ctx, leadershipHistoryKey)
+	if errors.Is(err, consts.ErrNotFound) {
+		return []LeadershipChange{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var changes []LeadershipChange
+	if err := json.Unmarshal(value, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// RecordLeadership records that this controller became the leader after the previous leader,
+// only the latest MaxLeadershipHistorySize changes will be kept.
+func (s *ClusterStore) RecordLeadership(ctx context.Context, previousLeader string) error {
+	changes, err := s.LeadershipHistory(ctx)
+	if err != nil {
+		return err
+	}
+	changes = append([]LeadershipChange{{
+		Leader:         s.ID(),
+		PreviousLeader: previousLeader,
+		Term:           s.LeaderTerm(),
+		Timestamp:      time.Now().Unix(),
+	}}, changes...)
+	if len(changes) > MaxLeadershipHistorySize {
+		changes = changes[:MaxLeadershipHistorySize]
+	}
+	value, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	return s.e.Set(ctx, leadershipHistoryKey, value)
+}
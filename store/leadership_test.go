@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_LeadershipHistory(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+
+	changes, err := s.LeadershipHistory(ctx)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	require.NoError(t, s.RecordLeadership(ctx, ""))
+	require.NoError(t, s.RecordLeadership(ctx, "other"))
+	changes, err = s.LeadershipHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, s.ID(), changes[0].Leader)
+	require.Equal(t, "other", changes[0].PreviousLeader)
+	require.Empty(t, changes[1].PreviousLeader)
+	require.Zero(t, changes[0].Term)
+
+	for i := 0; i < MaxLeadershipHistorySize; i++ {
+		require.NoError(t, s.RecordLeadership(ctx, "other"))
+	}
+	changes, err = s.LeadershipHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, changes, MaxLeadershipHistorySize)
+}
@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"sync"
+)
+
+// ClusterLocks are the per-cluster locks which are keyed by the namespace and cluster name.
+// The lock is reference counted and removed once it's neither held nor waited on, so the
+// locks of the removed clusters won't be kept forever and no lock in use is ever replaced.
+type ClusterLocks struct {
+	mu    sync.Mutex
+	locks map[string]*clusterLock
+}
+
+type clusterLock struct {
+	sync.RWMutex
+	// refs is the number of the holders and waiters of the lock, it's guarded by ClusterLocks.mu
+	refs int
+}
+
+func buildLockKey(ns, cluster string) string {
+	return ns + "/" + cluster
+}
+
+func (l *ClusterLocks) acquire(key string) *clusterLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks == nil {
+		l.locks = make(map[string]*clusterLock)
+	}
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &clusterLock{}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+func (l *ClusterLocks) release(key string, lock *clusterLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, key)
+	}
+}
+
+// Lock acquires the write lock of the cluster, and returns the function to release it
+func (l *ClusterLocks) Lock(ns, cluster string) func() {
+	key := buildLockKey(ns, cluster)
+	lock := l.acquire(key)
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.release(key, lock)
+	}
+}
+
+// RLock acquires the read lock of the cluster, and returns the function to release it
+func (l *ClusterLocks) RLock(ns, cluster string) func() {
+	key := buildLockKey(ns, cluster)
+	lock := l.acquire(key)
+	lock.RLock()
+	return func() {
+		lock.RUnlock()
+		l.release(key, lock)
+	}
+}
+
+// Len returns the number of the locks which are held or waited on
+func (l *ClusterLocks) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterLocks(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+	cluster, err := NewCluster("cluster0", []string{"127.0.0.1:6666"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	require.NoError(t, s.RemoveCluster(ctx, "ns", "cluster0"))
+	// the locks are removed once released
+	require.Equal(t, 0, s.locks.Len())
+
+	var locks ClusterLocks
+	unlock := locks.Lock("ns", "cluster0")
+	unlockOther := locks.RLock("ns", "cluster1")
+	require.Equal(t, 2, locks.Len())
+
+	// the waiter keeps the lock alive, so it must be exclusive with the holder
+	var holding atomic.Int32
+	holding.Store(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		unlockWaiter := locks.Lock("ns", "cluster0")
+		require.EqualValues(t, 0, holding.Load())
+		unlockWaiter()
+	}()
+	require.Eventually(t, func() bool {
+		locks.mu.Lock()
+		defer locks.mu.Unlock()
+		return locks.locks["ns/cluster0"].refs == 2
+	}, time.Second, 10*time.Millisecond)
+	holding.Store(0)
+	unlock()
+	wg.Wait()
+	require.Equal(t, 1, locks.Len())
+
+	unlockOther()
+	require.Equal(t, 0, locks.Len())
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+const DefaultNameMaxLength = 64
+
+// DefaultNamePattern allows the letters, digits, dots, underscores and hyphens,
+// and the name should start with a letter or digit.
+var DefaultNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// NameRules are the rules of the namespace and cluster names, the zero value uses
+// the default pattern and max length.
+type NameRules struct {
+	Pattern   *regexp.Regexp
+	MaxLength int
+}
+
+// Validate returns the invalid argument error if the name breaks the rules, the name with
+// the slashes or whitespaces is always rejected since it would break the key path in the engine.
+func (rules NameRules) Validate(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: %s name should NOT be empty", consts.ErrInvalidArgument, kind)
+	}
+	maxLength := rules.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultNameMaxLength
+	}
+	if len(name) > maxLength {
+		return fmt.Errorf("%w: %s name '%s' exceeds the max length %d",
+			consts.ErrInvalidArgument, kind, name, maxLength)
+	}
+	if strings.ContainsFunc(name, func(r rune) bool {
+		return r == '/' || unicode.IsSpace(r) || unicode.IsControl(r)
+	}) {
+		return fmt.Errorf("%w: %s name '%s' should NOT contain slashes or whitespaces",
+			consts.ErrInvalidArgument, kind, name)
+	}
+	pattern := rules.Pattern
+	if pattern == nil {
+		pattern = DefaultNamePattern
+	}
+	if !pattern.MatchString(name) {
+		return fmt.Errorf("%w: %s name '%s' should match the pattern %s",
+			consts.ErrInvalidArgument, kind, name, pattern.String())
+	}
+	return nil
+}
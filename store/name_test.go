@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+)
+
+func TestNameRules(t *testing.T) {
+	var rules NameRules
+	for _, name := range []string{"ns0", "test-cluster", "cluster_1.prod", strings.Repeat("a", DefaultNameMaxLength)} {
+		require.NoError(t, rules.Validate("cluster", name))
+	}
+	for _, name := range []string{"", "a/b", "a b", "a\tb", "-cluster", "集群", strings.Repeat("a", DefaultNameMaxLength+1)} {
+		require.ErrorIs(t, rules.Validate("cluster", name), consts.ErrInvalidArgument, name)
+	}
+
+	rules = NameRules{Pattern: regexp.MustCompile(`^[a-z:]+$`), MaxLength: 8}
+	require.NoError(t, rules.Validate("namespace", "team:ns"))
+	require.ErrorIs(t, rules.Validate("namespace", "ns0"), consts.ErrInvalidArgument)
+	require.ErrorIs(t, rules.Validate("namespace", "team:namespace"), consts.ErrInvalidArgument)
+	// the slashes are always rejected even if the pattern allows them
+	rules.Pattern = regexp.MustCompile(`.*`)
+	require.EqualError(t, rules.Validate("namespace", "a/b"),
+		"invalid argument: namespace name 'a/b' should NOT contain slashes or whitespaces")
+}
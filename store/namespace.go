@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+// Namespace is the metadata of the namespace, it's NOT used by the controller
+type Namespace struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner,omitempty"`
+	Description string `json:"description,omitempty"`
+	Contact     string `json:"contact,omitempty"`
+	// Labels are the static labels which are added to the metrics and events of the clusters
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (s *ClusterStore) setNamespace(ctx context.Context, namespace *Namespace) error {
+	value, err := json.Marshal(namespace)
+	if err != nil {
+		return fmt.Errorf("namespace: %w", err)
+	}
+	return s.e.Set(ctx, appendPrefix(namespace.Name), value)
+}
+
+// GetNamespace returns the metadata of the namespace
+func (s *ClusterStore) GetNamespace(ctx context.Context, ns string) (*Namespace, error) {
+	value, err := s.e.Get(ctx, appendPrefix(ns))
+	if errors.Is(err, consts.ErrNotFound) {
+		return nil, &consts.NotFoundError{Resource: "namespace", Key: ns}
+	} else if err != nil {
+		return nil, fmt.Errorf("namespace: %w", err)
+	}
+	namespace := &Namespace{}
+	// the value was the namespace name before the metadata was introduced
+	if err := json.Unmarshal(value, namespace); err != nil {
+		namespace = &Namespace{}
+	}
+	namespace.Name = ns
+	s.cacheNamespaceLabels(ns, namespace.Labels)
+	return namespace, nil
+}
+
+// UpdateNamespace replaces the metadata of the existing namespace
+func (s *ClusterStore) UpdateNamespace(ctx context.Context, namespace *Namespace) error {
+	has, err := s.ExistsNamespace(ctx, namespace.Name)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return &consts.NotFoundError{Resource: "namespace", Key: namespace.Name}
+	}
+	if err := s.setNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	s.cacheNamespaceLabels(namespace.Name, namespace.Labels)
+	s.EmitEvent(EventPayload{
+		Namespace: namespace.Name,
+		Type:      EventNamespace,
+		Command:   CommandUpdate,
+	})
+	return nil
+}
+
+// RenameNamespace moves the namespace and its clusters with the job histories to the new name.
+// All writes are applied in one transaction, so the interrupted rename leaves either namespace
+// but never both. It's rejected if the engine doesn't support the transaction.
+func (s *ClusterStore) RenameNamespace(ctx context.Context, ns, newNs string) error {
+	if ns == newNs {
+		return fmt.Errorf("%w: the new namespace is the same as the old one", consts.ErrInvalidArgument)
+	}
+	txn, ok := s.e.(engine.Transactional)
+	if !ok {
+		return fmt.Errorf("%w: the engine doesn't support the transaction to rename the namespace", consts.ErrForbidden)
+	}
+	// the clusters can't be created in both namespaces during the rename, the locks are
+	// taken in order to avoid the deadlock with the reverse rename
+	namespaces := []string{ns, newNs}
+	sort.Strings(namespaces)
+	for _, name := range namespaces {
+		unlock := s.locks.Lock(name, "")
+		defer unlock()
+	}
+
+	namespace, err := s.GetNamespace(ctx, ns)
+	if err != nil {
+		return err
+	}
+	if has, err := s.ExistsNamespace(ctx, newNs); err != nil {
+		return err
+	} else if has {
+		return fmt.Errorf("namespace %s: %w", newNs, consts.ErrAlreadyExists)
+	}
+	clusterNames, err := s.ListCluster(ctx, ns)
+	if err != nil {
+		return err
+	}
+	// the clusters can't be updated during the rename, the locks are released after returning
+	sort.Strings(clusterNames)
+	for _, name := range clusterNames {
+		unlock := s.locks.Lock(ns, name)
+		defer unlock()
+	}
+	clusters := make([]*Cluster, 0, len(clusterNames))
+	for _, name := range clusterNames {
+		cluster, err := s.getClusterWithoutLock(ctx, ns, name)
+		if err != nil {
+			return err
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	namespace.Name = newNs
+	value, err := json.Marshal(namespace)
+	if err != nil {
+		return fmt.Errorf("namespace: %w", err)
+	}
+	ops := []engine.Op{engine.OpSet(appendPrefix(newNs), value)}
+	for _, cluster := range clusters {
+		clusterOps, err := s.renameClusterOps(ctx, ns, newNs, cluster)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+		ops = append(ops, clusterOps...)
+	}
+	ops = append(ops, engine.OpDelete(appendPrefix(ns)))
+	if len(ops) > maxTxnOps {
+		return fmt.Errorf("%w: too many clusters to rename in one transaction, %d writes exceed the limit %d",
+			consts.ErrForbidden, len(ops), maxTxnOps)
+	}
+	if err := txn.Txn(ctx, ops); err != nil {
+		return err
+	}
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("new_namespace", newNs),
+		zap.Int("clusters", len(clusters)),
+	).Info("Renamed the namespace")
+
+	for _, cluster := range clusters {
+		s.moveNodeIndex(ctx, newNs, cluster)
+	}
+	s.cacheNamespaceLabels(newNs, namespace.Labels)
+	s.EmitEvent(EventPayload{Namespace: newNs, Type: EventNamespace, Command: CommandCreate})
+	for _, cluster := range clusters {
+		s.cacheClusterLabels(ctx, newNs, cluster.Name, cluster.Labels)
+		s.EmitEvent(EventPayload{Namespace: ns, Cluster: cluster.Name, Type: EventCluster, Command: CommandRemove})
+		s.EmitEvent(EventPayload{Namespace: newNs, Cluster: cluster.Name, Type: EventCluster, Command: CommandCreate})
+		s.uncacheLabels(ns, cluster.Name)
+	}
+	s.EmitEvent(EventPayload{Namespace: ns, Type: EventNamespace, Command: CommandRemove})
+	s.uncacheLabels(ns, "")
+	return nil
+}
+
+// renameClusterOps returns the writes which move the cluster with its highest version,
+// jobs and revisions to the new namespace.
+func (s *ClusterStore) renameClusterOps(ctx context.Context, ns, newNs string, cluster *Cluster) ([]engine.Op, error) {
+	value, err := encodeCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	highestVersion, err := s.getHighestVersion(ctx, ns, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	version := max(highestVersion, cluster.Version.Load())
+	ops := []engine.Op{
+		engine.OpSet(buildClusterKey(newNs, cluster.Name), value),
+		engine.OpDelete(buildClusterKey(ns, cluster.Name)),
+		engine.OpSet(buildClusterVersionKey(newNs, cluster.Name), []byte(strconv.FormatInt(version, 10))),
+		engine.OpDelete(buildClusterVersionKey(ns, cluster.Name)),
+	}
+	keys := make([][2]string, 0, len(jobTypes)+1)
+	for _, jobType := range jobTypes {
+		keys = append(keys, [2]string{buildJobKey(ns, cluster.Name, jobType), buildJobKey(newNs, cluster.Name, jobType)})
+	}
+	keys = append(keys, [2]string{buildClusterHistoryKey(ns, cluster.Name), buildClusterHistoryKey(newNs, cluster.Name)})
+	for _, key := range keys {
+		value, err := s.e.Get(ctx, key[0])
+		if errors.Is(err, consts.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		ops = append(ops, engine.OpSet(key[1], value), engine.OpDelete(key[0]))
+	}
+	return ops, nil
+}
+
+// moveNodeIndex points the index entries of the renamed cluster to the new namespace. It's written
+// after the rename since the entries are not required to be exact, the stale entries are verified
+// by CheckNewNodes and repaired by RebuildNodeIndex.
+func (s *ClusterStore) moveNodeIndex(ctx context.Context, newNs string, cluster *Cluster) {
+	unlock := s.lockNodeIndex(cluster)
+	defer unlock()
+	for _, location := range clusterNodeLocations(newNs, cluster) {
+		if err := s.setNodeLocation(ctx, location); err != nil {
+			logger.Get().With(
+				zap.String("namespace", newNs),
+				zap.String("cluster", cluster.Name),
+				zap.String("node", location.Addr),
+				zap.Error(err),
+			).Warn("Failed to move the node index entry of the renamed cluster")
+		}
+	}
+}
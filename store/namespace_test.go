@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_NamespaceMetadata(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	store := NewClusterStore(mock)
+
+	// the namespace created by the old versions stores its name as the value
+	require.NoError(t, mock.Set(ctx, appendPrefix("legacy-ns"), []byte("legacy-ns")))
+	namespace, err := store.GetNamespace(ctx, "legacy-ns")
+	require.NoError(t, err)
+	require.Equal(t, &Namespace{Name: "legacy-ns"}, namespace)
+
+	_, err = store.GetNamespace(ctx, "not-exists-ns")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	require.ErrorIs(t, store.UpdateNamespace(ctx, &Namespace{Name: "not-exists-ns"}), consts.ErrNotFound)
+
+	require.NoError(t, store.CreateNamespace(ctx, "ns0"))
+	expected := &Namespace{Name: "ns0", Owner: "team-a", Description: "cache", Contact: "team-a@example.com"}
+	require.NoError(t, store.UpdateNamespace(ctx, expected))
+	namespace, err = store.GetNamespace(ctx, "ns0")
+	require.NoError(t, err)
+	require.Equal(t, expected, namespace)
+}
+
+func TestClusterStore_RenameNamespace(t *testing.T) {
+	ctx := context.Background()
+	store := NewClusterStore(engine.NewMock())
+
+	require.NoError(t, store.CreateNamespace(ctx, "ns0"))
+	require.NoError(t, store.UpdateNamespace(ctx, &Namespace{Name: "ns0", Owner: "team-a"}))
+	require.NoError(t, store.CreateNamespace(ctx, "ns1"))
+	cluster, err := NewCluster("cluster0", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+	require.NoError(t, err)
+	cluster.Version.Store(5)
+	require.NoError(t, store.CreateCluster(ctx, "ns0", cluster))
+	require.NoError(t, store.SaveJob(ctx, "ns0", "cluster0", &Job{ID: "job0", Type: JobTypeBackup}))
+
+	require.ErrorIs(t, store.RenameNamespace(ctx, "ns0", "ns1"), consts.ErrAlreadyExists)
+	require.ErrorIs(t, store.RenameNamespace(ctx, "ns0", "ns0"), consts.ErrInvalidArgument)
+	require.ErrorIs(t, store.RenameNamespace(ctx, "not-exists-ns", "ns2"), consts.ErrNotFound)
+
+	require.NoError(t, store.RenameNamespace(ctx, "ns0", "ns2"))
+	exists, err := store.ExistsNamespace(ctx, "ns0")
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, err = store.GetCluster(ctx, "ns0", "cluster0")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	namespace, err := store.GetNamespace(ctx, "ns2")
+	require.NoError(t, err)
+	require.Equal(t, &Namespace{Name: "ns2", Owner: "team-a"}, namespace)
+	gotCluster, err := store.GetCluster(ctx, "ns2", "cluster0")
+	require.NoError(t, err)
+	require.EqualValues(t, 5, gotCluster.Version.Load())
+	jobs, err := store.ListJobs(ctx, "ns2", "cluster0", JobTypeBackup)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	jobs, err = store.ListJobs(ctx, "ns0", "cluster0", JobTypeBackup)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+
+	locations, err := store.SearchNode(ctx, "127.0.0.1:1111")
+	require.NoError(t, err)
+	require.Len(t, locations, 1)
+	require.Equal(t, "ns2", locations[0].Namespace)
+}
+
+func TestClusterStore_RenameNamespaceAtomically(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	store := NewClusterStore(mock)
+
+	require.NoError(t, store.CreateNamespace(ctx, "ns0"))
+	for _, name := range []string{"cluster0", "cluster1"} {
+		cluster, err := NewCluster(name, []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateCluster(ctx, "ns0", cluster))
+	}
+	require.NoError(t, store.SaveJob(ctx, "ns0", "cluster1", &Job{ID: "job0", Type: JobTypeBackup}))
+
+	// the rename fails at the last cluster, and nothing is written
+	mock.WithKeyFailure(buildJobKey("ns0", "cluster1", JobTypeBackup), nil)
+	require.ErrorIs(t, store.RenameNamespace(ctx, "ns0", "ns1"), engine.ErrInjected)
+	mock.ResetFaults()
+	exists, err := store.ExistsNamespace(ctx, "ns1")
+	require.NoError(t, err)
+	require.False(t, exists)
+	clusters, err := store.ListCluster(ctx, "ns1")
+	require.NoError(t, err)
+	require.Empty(t, clusters)
+	clusters, err = store.ListCluster(ctx, "ns0")
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+
+	require.NoError(t, store.RenameNamespace(ctx, "ns0", "ns1"))
+	clusters, err = store.ListCluster(ctx, "ns1")
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	clusters, err = store.ListCluster(ctx, "ns0")
+	require.NoError(t, err)
+	require.Empty(t, clusters)
+
+	nonTxnStore := NewClusterStore(nonTransactionalEngine{engine.NewMock()})
+	require.NoError(t, nonTxnStore.CreateNamespace(ctx, "ns0"))
+	require.ErrorIs(t, nonTxnStore.RenameNamespace(ctx, "ns0", "ns1"), consts.ErrForbidden)
+}
+
+func TestClusterStore_RemoveNamespaceLocked(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+
+	// the namespace can't be removed while creating the cluster in it
+	unlockNamespace := s.locks.RLock("ns", "")
+	removed := make(chan error, 1)
+	go func() {
+		removed <- s.RemoveNamespace(ctx, "ns")
+	}()
+	require.Never(t, func() bool {
+		return len(removed) > 0
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111"}, 1)
+	require.NoError(t, err)
+	value, err := encodeCluster(cluster)
+	require.NoError(t, err)
+	require.NoError(t, s.writeCluster(ctx, "ns", nil, cluster, value))
+	unlockNamespace()
+
+	require.ErrorIs(t, <-removed, consts.ErrForbidden)
+	has, err := s.ExistsNamespace(ctx, "ns")
+	require.NoError(t, err)
+	require.True(t, has)
+}
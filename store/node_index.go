@@ -0,0 +1,460 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/logger"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+// nodeIndexPrefix is the prefix of the node index which maps the node address to its location,
+// so that checking whether the node exists won't need to scan all clusters.
+const nodeIndexPrefix = "/kvrocks/nodes"
+
+// nodeIndexRebuiltKey marks the node index was rebuilt from all clusters at least once,
+// so the missing entry means the node isn't in any cluster.
+const nodeIndexRebuiltKey = "/kvrocks/node_index_rebuilt"
+
+func buildNodeIndexKey(addr string) string {
+	return nodeIndexPrefix + "/" + addr
+}
+
+func clusterNodeLocations(ns string, cluster *Cluster) map[string]NodeLocation {
+	locations := make(map[string]NodeLocation)
+	if cluster == nil {
+		return locations
+	}
+	for i, shard := range cluster.Shards {
+		for _, node := range shard.Nodes {
+			role := RoleSlave
+			if node.IsMaster() {
+				role = RoleMaster
+			}
+			locations[node.Addr()] = NodeLocation{
+				Namespace: ns,
+				Cluster:   cluster.Name,
+				Shard:     i,
+				NodeID:    node.ID(),
+				Addr:      node.Addr(),
+				Role:      role,
+			}
+		}
+	}
+	return locations
+}
+
+// lockNodeIndex locks the index entries of the nodes in the clusters in order of the address, and returns
+// the function to release them. The entry might be owned by another cluster which has the same node by
+// mistake, so the owner of the entry is checked and written under the lock, otherwise the concurrent write
+// of the other cluster could re-point the entry in between. It must be called after taking the cluster locks.
+func (s *ClusterStore) lockNodeIndex(clusters ...*Cluster) func() {
+	addrs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, cluster := range clusters {
+		for addr := range clusterNodeLocations("", cluster) {
+			if !seen[addr] {
+				seen[addr] = true
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	sort.Strings(addrs)
+	unlocks := make([]func(), 0, len(addrs))
+	for _, addr := range addrs {
+		unlocks = append(unlocks, s.nodeLocks.Lock(nodeIndexPrefix, addr))
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
+
+func (s *ClusterStore) getNodeLocation(ctx context.Context, addr string) (*NodeLocation, error) {
+	value, err := s.e.Get(ctx, buildNodeIndexKey(addr))
+	if err != nil {
+		return nil, err
+	}
+	var location NodeLocation
+	if err := json.Unmarshal(value, &location); err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+func (s *ClusterStore) setNodeLocation(ctx context.Context, location NodeLocation) error {
+	value, err := json.Marshal(location)
+	if err != nil {
+		return err
+	}
+	return s.e.Set(ctx, buildNodeIndexKey(location.Addr), value)
+}
+
+// maxTxnOps is the max writes of the transaction which is accepted by all engines,
+// it's bounded by the consul which accepts 64 operations in a transaction.
+const maxTxnOps = 64
+
+// nodeIndexOps returns the writes of the index entries from the old cluster to the new cluster,
+// the old cluster is nil when creating and the new cluster is nil when removing. The set ops
+// add or change the entries, and the delete ops remove the entries owned by the old cluster.
+// The entries must be locked by lockNodeIndex until the ops were written.
+func (s *ClusterStore) nodeIndexOps(ctx context.Context, ns string, oldCluster, newCluster *Cluster) ([]engine.Op, []engine.Op, error) {
+	oldLocations := clusterNodeLocations(ns, oldCluster)
+	newLocations := clusterNodeLocations(ns, newCluster)
+	setOps := make([]engine.Op, 0)
+	deleteOps := make([]engine.Op, 0)
+	for addr, oldLocation := range oldLocations {
+		if _, ok := newLocations[addr]; ok {
+			continue
+		}
+		// the entry might be owned by another cluster which has the same node by mistake
+		location, err := s.getNodeLocation(ctx, addr)
+		if errors.Is(err, consts.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if location.Namespace != oldLocation.Namespace || location.Cluster != oldLocation.Cluster {
+			continue
+		}
+		deleteOps = append(deleteOps, engine.OpDelete(buildNodeIndexKey(addr)))
+	}
+	for addr, newLocation := range newLocations {
+		if oldLocation, ok := oldLocations[addr]; ok && oldLocation == newLocation {
+			continue
+		}
+		value, err := json.Marshal(newLocation)
+		if err != nil {
+			return nil, nil, err
+		}
+		setOps = append(setOps, engine.OpSet(buildNodeIndexKey(addr), value))
+	}
+	return setOps, deleteOps, nil
+}
+
+func applyOp(ctx context.Context, e engine.Engine, op engine.Op) error {
+	if op.Delete {
+		if err := e.Delete(ctx, op.Key); err != nil && !errors.Is(err, consts.ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	return e.Set(ctx, op.Key, op.Value)
+}
+
+// clusterWriteOps returns the writes of the cluster with its node index entries and highest version,
+// the cluster is removed with its highest version, revisions and jobs if the new cluster is nil. The set ops of the index entries should be applied
+// before the cluster ops and the delete ops after them.
+func (s *ClusterStore) clusterWriteOps(ctx context.Context, ns string, oldCluster, newCluster *Cluster, value []byte) ([]engine.Op, []engine.Op, []engine.Op, error) {
+	var clusterOps []engine.Op
+	if newCluster != nil {
+		versionOps, err := s.highestVersionOps(ctx, ns, newCluster.Name, newCluster.Version.Load())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("cluster version: %w", err)
+		}
+		clusterOps = append([]engine.Op{engine.OpSet(buildClusterKey(ns, newCluster.Name), value)}, versionOps...)
+	} else {
+		// the recreated cluster starts from the initial version again without the histories
+		clusterOps = append([]engine.Op{
+			engine.OpDelete(buildClusterKey(ns, oldCluster.Name)),
+			engine.OpDelete(buildClusterVersionKey(ns, oldCluster.Name)),
+			engine.OpDelete(buildClusterHistoryKey(ns, oldCluster.Name)),
+		}, removeJobsOps(ns, oldCluster.Name)...)
+	}
+	setOps, deleteOps, err := s.nodeIndexOps(ctx, ns, oldCluster, newCluster)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("node index: %w", err)
+	}
+	return setOps, clusterOps, deleteOps, nil
+}
+
+// writeCluster writes the cluster with its node index entries and highest version, the cluster is
+// removed if the new cluster is nil. They're written in a transaction if the engine supports it. Otherwise,
+// the entries are added before writing the cluster and removed after it, so the index never misses the nodes
+// of the written cluster, and the stale entries left by the failed write are verified by CheckNewNodes.
+// If the writes exceed the limit of a transaction, the entries are written in batches in the same order,
+// and the cluster with its highest version is the single transaction which commits the write.
+func (s *ClusterStore) writeCluster(ctx context.Context, ns string, oldCluster, newCluster *Cluster, value []byte) error {
+	unlock := s.lockNodeIndex(oldCluster, newCluster)
+	defer unlock()
+
+	setOps, clusterOps, deleteOps, err := s.clusterWriteOps(ctx, ns, oldCluster, newCluster, value)
+	if err != nil {
+		return err
+	}
+	ops := append(append(append([]engine.Op{}, setOps...), clusterOps...), deleteOps...)
+	if txn, ok := s.e.(engine.Transactional); ok {
+		if len(ops) <= maxTxnOps {
+			return txn.Txn(ctx, ops)
+		}
+		return s.writeClusterInBatches(ctx, txn, ns, setOps, clusterOps, deleteOps)
+	}
+
+	for _, op := range setOps {
+		if err := applyOp(ctx, s.e, op); err != nil {
+			return fmt.Errorf("node index: %w", err)
+		}
+	}
+	if newCluster == nil {
+		// the data of the removed cluster are deleted before it, so the failed removal can be retried
+		for _, op := range clusterOps[1:] {
+			if err := applyOp(ctx, s.e, op); err != nil {
+				return err
+			}
+		}
+		clusterOps = clusterOps[:1]
+	}
+	if err := applyOp(ctx, s.e, clusterOps[0]); err != nil {
+		return err
+	}
+	for _, op := range clusterOps[1:] {
+		// the cluster was written, the version will be raised by the next write of the cluster
+		if err := applyOp(ctx, s.e, op); err != nil {
+			logger.Get().With(
+				zap.String("namespace", ns),
+				zap.String("key", op.Key),
+				zap.Error(err),
+			).Warn("Failed to raise the highest version of the cluster")
+		}
+	}
+	for _, op := range deleteOps {
+		// the cluster was written, the stale entry will be removed by RebuildNodeIndex
+		if err := applyOp(ctx, s.e, op); err != nil {
+			logger.Get().With(
+				zap.String("namespace", ns),
+				zap.String("key", op.Key),
+				zap.Error(err),
+			).Warn("Failed to remove the stale entry of the node index")
+		}
+	}
+	return nil
+}
+
+// writeClusterInBatches writes the index entries in batches around the transaction of the cluster ops
+func (s *ClusterStore) writeClusterInBatches(ctx context.Context, txn engine.Transactional, ns string,
+	setOps, clusterOps, deleteOps []engine.Op,
+) error {
+	logger.Get().With(
+		zap.String("namespace", ns),
+		zap.String("key", clusterOps[0].Key),
+		zap.Int("index_writes", len(setOps)+len(deleteOps)),
+	).Info("Write the node index entries in batches since they exceed the limit of a transaction")
+	for start := 0; start < len(setOps); start += maxTxnOps {
+		if err := txn.Txn(ctx, setOps[start:min(start+maxTxnOps, len(setOps))]); err != nil {
+			return fmt.Errorf("node index: %w", err)
+		}
+	}
+	if err := txn.Txn(ctx, clusterOps); err != nil {
+		return err
+	}
+	for start := 0; start < len(deleteOps); start += maxTxnOps {
+		// the cluster was written, the stale entries will be removed by RebuildNodeIndex
+		if err := txn.Txn(ctx, deleteOps[start:min(start+maxTxnOps, len(deleteOps))]); err != nil {
+			logger.Get().With(
+				zap.String("namespace", ns),
+				zap.String("key", clusterOps[0].Key),
+				zap.Error(err),
+			).Warn("Failed to remove the stale entries of the node index")
+			break
+		}
+	}
+	return nil
+}
+
+// clusterHasNode returns whether the node is in the cluster of the location
+func (s *ClusterStore) clusterHasNode(ctx context.Context, location *NodeLocation, addr string) (bool, error) {
+	cluster, err := s.GetCluster(ctx, location.Namespace, location.Cluster)
+	if errors.Is(err, consts.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	_, ok := clusterNodeLocations(location.Namespace, cluster)[addr]
+	return ok, nil
+}
+
+// scanNode returns whether the node is in any cluster by scanning all clusters
+func (s *ClusterStore) scanNode(ctx context.Context, addr string) (bool, error) {
+	found := false
+	err := s.forEachCluster(ctx, func(ns string, cluster *Cluster) error {
+		if _, ok := clusterNodeLocations(ns, cluster)[addr]; ok {
+			found = true
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, consts.ErrNotFound) {
+		return false, err
+	}
+	return found, nil
+}
+
+// ListNodes returns the locations of all indexed nodes
+func (s *ClusterStore) ListNodes(ctx context.Context) ([]NodeLocation, error) {
+	entries, err := s.e.List(ctx, nodeIndexPrefix)
+	if err != nil {
+		return nil, err
+	}
+	locations := make([]NodeLocation, 0, len(entries))
+	for _, entry := range entries {
+		var location NodeLocation
+		if err := json.Unmarshal(entry.Value, &location); err != nil {
+			return nil, fmt.Errorf("node %s: %w", entry.Key, err)
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
+
+func (s *ClusterStore) isNodeIndexRebuilt(ctx context.Context) (bool, error) {
+	if s.nodeIndexRebuilt.Load() {
+		return true, nil
+	}
+	_, err := s.e.Get(ctx, nodeIndexRebuiltKey)
+	if errors.Is(err, consts.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	s.nodeIndexRebuilt.Store(true)
+	return true, nil
+}
+
+// RebuildNodeIndex repairs the node index from all clusters, the missing entries are added and
+// the stale entries which don't belong to their clusters are removed. Each cluster is checked under
+// its lock, so the entries written by the concurrent cluster writes won't be removed. It should only
+// be run by the leader. It returns the number of the indexed nodes.
+func (s *ClusterStore) RebuildNodeIndex(ctx context.Context) (int, error) {
+	indexedLocations, err := s.ListNodes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	staleCount := 0
+	for _, location := range indexedLocations {
+		removed, err := s.removeStaleNodeLocation(ctx, location)
+		if err != nil {
+			return 0, err
+		}
+		if removed {
+			staleCount++
+		}
+	}
+	if staleCount > 0 {
+		logger.Get().With(zap.Int("count", staleCount)).Warn("Removed the stale nodes from the node index")
+	}
+
+	// the missing entries are added after removing the stale ones, since the node
+	// might be indexed to the wrong cluster by the interrupted write
+	namespaces, err := s.ListNamespace(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, ns := range namespaces {
+		clusterNames, err := s.ListCluster(ctx, ns)
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range clusterNames {
+			nodes, err := s.repairClusterNodeIndex(ctx, ns, name)
+			if err != nil {
+				return 0, err
+			}
+			count += nodes
+		}
+	}
+	if !s.nodeIndexRebuilt.Load() {
+		if err := s.e.Set(ctx, nodeIndexRebuiltKey, []byte(strconv.FormatInt(time.Now().Unix(), 10))); err != nil {
+			return 0, fmt.Errorf("mark the node index rebuilt: %w", err)
+		}
+		s.nodeIndexRebuilt.Store(true)
+	}
+	return count, nil
+}
+
+// repairClusterNodeIndex adds the missing entries of the cluster nodes, and returns the number of the nodes
+func (s *ClusterStore) repairClusterNodeIndex(ctx context.Context, ns, name string) (int, error) {
+	unlock := s.locks.Lock(ns, name)
+	defer unlock()
+
+	cluster, err := s.getClusterWithoutLock(ctx, ns, name)
+	if errors.Is(err, consts.ErrNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	unlockNodes := s.lockNodeIndex(cluster)
+	defer unlockNodes()
+	locations := clusterNodeLocations(ns, cluster)
+	for addr, location := range locations {
+		// the entry owned by another cluster which has the same node by mistake is kept
+		if _, err := s.getNodeLocation(ctx, addr); err == nil {
+			continue
+		} else if !errors.Is(err, consts.ErrNotFound) {
+			return 0, err
+		}
+		if err := s.setNodeLocation(ctx, location); err != nil {
+			return 0, err
+		}
+	}
+	return len(locations), nil
+}
+
+// removeStaleNodeLocation removes the entry if its cluster doesn't have the node anymore
+func (s *ClusterStore) removeStaleNodeLocation(ctx context.Context, location NodeLocation) (bool, error) {
+	unlock := s.locks.Lock(location.Namespace, location.Cluster)
+	defer unlock()
+	unlockNode := s.nodeLocks.Lock(nodeIndexPrefix, location.Addr)
+	defer unlockNode()
+
+	// the entry might be changed by the cluster write before taking the lock
+	current, err := s.getNodeLocation(ctx, location.Addr)
+	if errors.Is(err, consts.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if *current != location {
+		return false, nil
+	}
+	cluster, err := s.getClusterWithoutLock(ctx, location.Namespace, location.Cluster)
+	if err != nil && !errors.Is(err, consts.ErrNotFound) {
+		return false, err
+	}
+	if err == nil {
+		if _, ok := clusterNodeLocations(location.Namespace, cluster)[location.Addr]; ok {
+			return false, nil
+		}
+	}
+	if err := s.e.Delete(ctx, buildNodeIndexKey(location.Addr)); err != nil && !errors.Is(err, consts.ErrNotFound) {
+		return false, err
+	}
+	return true, nil
+}
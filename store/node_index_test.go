@@ -0,0 +1,209 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_NodeIndex(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222", "127.0.0.1:3333"}, 3)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	nodes, err := s.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Len(t, nodes, 3)
+	require.ErrorIs(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:4444", "127.0.0.1:1111"}), consts.ErrAlreadyExists)
+	require.NoError(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:4444"}))
+
+	// the removed node should be removed from the index and the role change should be updated
+	cluster, err = s.GetCluster(ctx, "ns", "cluster")
+	require.NoError(t, err)
+	removedNode := cluster.Shards[0].Nodes[2]
+	require.NoError(t, cluster.RemoveNode(0, removedNode.ID()))
+	cluster.Shards[0].Nodes[0].SetRole(RoleSlave)
+	cluster.Shards[0].Nodes[1].SetRole(RoleMaster)
+	require.NoError(t, s.UpdateCluster(ctx, "ns", cluster))
+	require.NoError(t, s.CheckNewNodes(ctx, []string{removedNode.Addr()}))
+	location, err := s.getNodeLocation(ctx, cluster.Shards[0].Nodes[1].Addr())
+	require.NoError(t, err)
+	require.Equal(t, RoleMaster, location.Role)
+	require.Equal(t, "cluster", location.Cluster)
+
+	// the stale entry should be removed and the missing entry should be added back
+	require.NoError(t, s.setNodeLocation(ctx, NodeLocation{Namespace: "ns", Cluster: "gone", Addr: "127.0.0.1:5555"}))
+	require.NoError(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:5555"}))
+	require.NoError(t, s.e.Delete(ctx, buildNodeIndexKey(cluster.Shards[0].Nodes[0].Addr())))
+	count, err := s.RebuildNodeIndex(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.NoError(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:5555"}))
+	require.Error(t, s.CheckNewNodes(ctx, []string{cluster.Shards[0].Nodes[0].Addr()}))
+
+	require.NoError(t, s.RemoveCluster(ctx, "ns", "cluster"))
+	nodes, err = s.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+}
+
+func TestClusterStore_NodeIndexTxn(t *testing.T) {
+	ctx := context.Background()
+	errInjected := errors.New("injected")
+	mock := engine.NewMock().WithKeyFailure(buildNodeIndexKey("127.0.0.1:2222"), errInjected)
+	s := NewClusterStore(mock)
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+
+	// the cluster isn't written if the index entries failed to be written
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+	require.NoError(t, err)
+	require.ErrorIs(t, s.CreateCluster(ctx, "ns", cluster), errInjected)
+	_, err = s.GetCluster(ctx, "ns", "cluster")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+	mock.ResetFaults()
+	nodes, err := s.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+}
+
+func TestClusterStore_NodeIndexRebuilt(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+
+	// the clusters are scanned on the miss until the index was rebuilt, since the
+	// clusters written before the index existed have no entries
+	require.NoError(t, s.e.Delete(ctx, buildNodeIndexKey("127.0.0.1:1111")))
+	require.ErrorIs(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:1111"}), consts.ErrAlreadyExists)
+
+	_, err = s.RebuildNodeIndex(ctx)
+	require.NoError(t, err)
+	_, err = s.e.Get(ctx, nodeIndexRebuiltKey)
+	require.NoError(t, err)
+	require.ErrorIs(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:1111"}), consts.ErrAlreadyExists)
+
+	// the marker is persisted for the other controllers
+	s = NewClusterStore(s.e)
+	rebuilt, err := s.isNodeIndexRebuilt(ctx)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+	require.NoError(t, s.e.Delete(ctx, buildNodeIndexKey("127.0.0.1:1111")))
+	require.NoError(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:1111"}))
+}
+
+func TestClusterStore_NodeIndexOwnerRace(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	removedNode := cluster.Shards[0].Nodes[1]
+	require.NoError(t, cluster.RemoveNode(0, removedNode.ID()))
+
+	// the entry is re-pointed by another cluster while the removal is waiting for the entry lock
+	unlock := s.nodeLocks.Lock(nodeIndexPrefix, removedNode.Addr())
+	done := make(chan error)
+	go func() {
+		done <- s.UpdateCluster(ctx, "ns", cluster)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("the cluster was written without the entry lock: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	other := NodeLocation{Namespace: "ns", Cluster: "other", Addr: removedNode.Addr(), Role: RoleMaster}
+	require.NoError(t, s.setNodeLocation(ctx, other))
+	unlock()
+	require.NoError(t, <-done)
+
+	// the removal doesn't delete the entry owned by the other cluster
+	location, err := s.getNodeLocation(ctx, removedNode.Addr())
+	require.NoError(t, err)
+	require.Equal(t, other, *location)
+}
+
+func TestClusterStore_NodeIndexBatches(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	s := NewClusterStore(mock)
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+	addrs := make([]string, 0, maxTxnOps+10)
+	for i := 0; i < cap(addrs); i++ {
+		addrs = append(addrs, fmt.Sprintf("127.0.0.1:%d", 10000+i))
+	}
+
+	// the cluster isn't written if the entries failed to be written
+	mock.WithKeyFailure(buildNodeIndexKey(addrs[len(addrs)-1]), errors.New("injected"))
+	cluster, err := NewCluster("cluster", addrs, 1)
+	require.NoError(t, err)
+	require.Error(t, s.CreateCluster(ctx, "ns", cluster))
+	_, err = s.GetCluster(ctx, "ns", "cluster")
+	require.ErrorIs(t, err, consts.ErrNotFound)
+
+	mock.ResetFaults()
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	nodes, err := s.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Len(t, nodes, len(addrs))
+
+	require.NoError(t, s.RemoveCluster(ctx, "ns", "cluster"))
+	nodes, err = s.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+}
+
+// nonTransactionalEngine hides the transaction of the engine
+type nonTransactionalEngine struct {
+	engine.Engine
+}
+
+func TestClusterStore_NodeIndexWithoutTxn(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(nonTransactionalEngine{engine.NewMock()})
+	require.NoError(t, s.CreateNamespace(ctx, "ns"))
+
+	cluster, err := NewCluster("cluster", []string{"127.0.0.1:1111", "127.0.0.1:2222"}, 2)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateCluster(ctx, "ns", cluster))
+	nodes, err := s.ListNodes(ctx)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	// the clusters are scanned if the entry is missing since the index might be behind
+	require.NoError(t, s.e.Delete(ctx, buildNodeIndexKey("127.0.0.1:1111")))
+	require.ErrorIs(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:1111"}), consts.ErrAlreadyExists)
+	require.NoError(t, s.CheckNewNodes(ctx, []string{"127.0.0.1:3333"}))
+}
@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const (
+	controllerSessionPrefix = "/kvrocks/controller_sessions"
+
+	// SessionHeartbeatInterval is the interval of the controller to refresh its session
+	SessionHeartbeatInterval = 10 * time.Second
+	// SessionTTL is the time after the last heartbeat before the session is considered dead
+	SessionTTL = 3 * SessionHeartbeatInterval
+)
+
+// ControllerSession is registered by each running controller to identify itself
+type ControllerSession struct {
+	ID        string `json:"id"`
+	Addr      string `json:"addr"`
+	Hostname  string `json:"hostname"`
+	Pid       int    `json:"pid"`
+	Zone      string `json:"zone,omitempty"`
+	Standby   bool   `json:"standby"`
+	Leader    bool   `json:"leader"`
+	StartedAt int64  `json:"started_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+func buildSessionKey(id string) string {
+	// the session ID may contain the slash which is the path separator of zookeeper
+	return controllerSessionPrefix + "/" + url.PathEscape(id)
+}
+
+// SaveSession refreshes the session of this controller, the ID is filled by the store
+func (s *ClusterStore) SaveSession(ctx context.Context, session *ControllerSession) error {
+	session.ID = s.ID()
+	session.UpdatedAt = time.Now().Unix()
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.e.Set(ctx, buildSessionKey(session.ID), value)
+}
+
+// RemoveSession removes the session of this controller
+func (s *ClusterStore) RemoveSession(ctx context.Context) error {
+	return s.e.Delete(ctx, buildSessionKey(s.ID()))
+}
+
+func (s *ClusterStore) listSessions(ctx context.Context) ([]ControllerSession, error) {
+	entries, err := s.e.List(ctx, controllerSessionPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]ControllerSession, 0, len(entries))
+	for _, entry := range entries {
+		var session ControllerSession
+		if err := json.Unmarshal(entry.Value, &session); err != nil {
+			return nil, fmt.Errorf("session %s: %w", entry.Key, err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// ListSessions returns the live sessions which were refreshed within the SessionTTL
+func (s *ClusterStore) ListSessions(ctx context.Context) ([]ControllerSession, error) {
+	sessions, err := s.listSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	leader := s.Leader()
+	expiredAt := time.Now().Add(-SessionTTL).Unix()
+	liveSessions := make([]ControllerSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.UpdatedAt < expiredAt {
+			continue
+		}
+		session.Leader = session.ID == leader
+		liveSessions = append(liveSessions, session)
+	}
+	sort.Slice(liveSessions, func(i, j int) bool {
+		return liveSessions[i].StartedAt < liveSessions[j].StartedAt
+	})
+	return liveSessions, nil
+}
+
+// PurgeSessions removes the dead sessions and returns the number of the removed sessions,
+// since not all engines support expiring the keys.
+func (s *ClusterStore) PurgeSessions(ctx context.Context) (int, error) {
+	sessions, err := s.listSessions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	expiredAt := time.Now().Add(-SessionTTL).Unix()
+	purged := 0
+	for _, session := range sessions {
+		if session.UpdatedAt >= expiredAt {
+			continue
+		}
+		if err := s.e.Delete(ctx, buildSessionKey(session.ID)); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/kvrocks-controller/store/engine"
+)
+
+func TestClusterStore_Sessions(t *testing.T) {
+	ctx := context.Background()
+	s := NewClusterStore(engine.NewMock())
+
+	sessions, err := s.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	session := &ControllerSession{Addr: "127.0.0.1:9379", Zone: "zone-a", StartedAt: time.Now().Unix()}
+	require.NoError(t, s.SaveSession(ctx, session))
+	require.Equal(t, s.ID(), session.ID)
+
+	// the session of the dead controller
+	deadSession := ControllerSession{
+		ID:        "host-1-1700000000/127.0.0.1:9380",
+		Addr:      "127.0.0.1:9380",
+		UpdatedAt: time.Now().Add(-2 * SessionTTL).Unix(),
+	}
+	value, err := json.Marshal(deadSession)
+	require.NoError(t, err)
+	require.NoError(t, s.e.Set(ctx, buildSessionKey(deadSession.ID), value))
+
+	sessions, err = s.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, s.ID(), sessions[0].ID)
+	require.Equal(t, "zone-a", sessions[0].Zone)
+	require.True(t, sessions[0].Leader)
+
+	purged, err := s.PurgeSessions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+	exists, err := s.e.Exists(ctx, buildSessionKey(deadSession.ID))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, s.RemoveSession(ctx))
+	sessions, err = s.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
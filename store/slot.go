@@ -172,6 +172,67 @@ func (SlotRanges *SlotRanges) HasOverlap(slotRange SlotRange) bool {
 	return false
 }
 
+// Normalize returns the sorted slot ranges with the overlapped and adjacent ranges merged,
+// the slot ranges itself won't be changed. Implemented following leetcode solution:
+// https://leetcode.com/problems/merge-intervals/solutions/1805268/go-clean-code-with-explanation-and-visual-10ms-100
+func (slotRanges SlotRanges) Normalize() SlotRanges {
+	if len(slotRanges) == 0 {
+		return SlotRanges{}
+	}
+	sorted := make(SlotRanges, len(slotRanges))
+	copy(sorted, slotRanges)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	normalized := make(SlotRanges, 0, len(sorted))
+	normalized = append(normalized, sorted[0])
+	for _, slotRange := range sorted[1:] {
+		last := &normalized[len(normalized)-1]
+		if CanMerge(*last, slotRange) {
+			*last = MergeSlotRanges(*last, slotRange)
+		} else {
+			normalized = append(normalized, slotRange)
+		}
+	}
+	return normalized
+}
+
+// Count returns the number of the distinct slots in the slot ranges
+func (slotRanges SlotRanges) Count() int {
+	count := 0
+	for _, slotRange := range slotRanges.Normalize() {
+		count += slotRange.Stop - slotRange.Start + 1
+	}
+	return count
+}
+
+// Intersect returns the normalized slot ranges which are in both slot ranges
+func (slotRanges SlotRanges) Intersect(that SlotRanges) SlotRanges {
+	a, b := slotRanges.Normalize(), that.Normalize()
+	result := make(SlotRanges, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, stop := max(a[i].Start, b[j].Start), min(a[i].Stop, b[j].Stop)
+		if start <= stop {
+			result = append(result, SlotRange{Start: start, Stop: stop})
+		}
+		if a[i].Stop < b[j].Stop {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// Difference returns the normalized slot ranges which are in the slot ranges but not in that
+func (slotRanges SlotRanges) Difference(that SlotRanges) SlotRanges {
+	result := slotRanges.Normalize()
+	for _, slotRange := range that.Normalize() {
+		result = RemoveSlotFromSlotRanges(result, slotRange)
+	}
+	return result
+}
+
 func (s *SlotRange) Reset() {
 	s.Start = 0
 	s.Stop = 0
@@ -187,12 +248,14 @@ func FromSlotRange(slotRange SlotRange) *MigratingSlot {
 	}
 }
 
+// UnmarshalJSON accepts the slot range string only, the integer slot written by the older
+// controllers is upgraded by migrateClusterSchemaV0 before reaching here.
 func (s *MigratingSlot) UnmarshalJSON(data []byte) error {
 	var slotsString any
 	if err := json.Unmarshal(data, &slotsString); err != nil {
 		return err
 	}
-	switch t := slotsString.(type) {
+	switch slotsString.(type) {
 	case string:
 		slotRange := SlotRange{}
 		err := json.Unmarshal(data, &slotRange)
@@ -202,22 +265,6 @@ func (s *MigratingSlot) UnmarshalJSON(data []byte) error {
 		}
 		s.SlotRange = slotRange
 		s.IsMigrating = true
-	case float64:
-		// We use integer to represent the slot because we don't support the slot range
-		// in the past. So we need to support the integer type for backward compatibility.
-		// But the number in JSON is float64, so we need to convert it to int here.
-		if t == NotMigratingInt {
-			s.Reset()
-			return nil
-		}
-		if t < MinSlotID || t > MaxSlotID {
-			s.Reset()
-			return ErrSlotOutOfRange
-		}
-		slotID := int(t)
-		s.Start = slotID
-		s.Stop = slotID
-		s.IsMigrating = true
 	default:
 		s.Reset()
 		return fmt.Errorf("invalid slot range type: %T", slotsString)
@@ -254,40 +301,23 @@ func MergeSlotRanges(a SlotRange, b SlotRange) SlotRange {
 	}
 }
 
-// Implemented following leetcode solution:
-// https://leetcode.com/problems/merge-intervals/solutions/1805268/go-clean-code-with-explanation-and-visual-10ms-100
+// AddSlotToSlotRanges returns the normalized slot ranges with the slot added,
+// the source won't be changed since it may be shared by the cloned shards.
 func AddSlotToSlotRanges(source SlotRanges, slot SlotRange) SlotRanges {
-	if len(source) == 0 {
-		return append(source, slot)
-	}
-	source = append(source, slot)
-	sort.Slice(source, func(i, j int) bool {
-		return source[i].Start < source[j].Start
-	})
-
-	mergedSlotRanges := make([]SlotRange, 0, len(source))
-	mergedSlotRanges = append(mergedSlotRanges, source[0])
-
-	for _, interval := range source[1:] {
-		lastIntervalPos := len(mergedSlotRanges) - 1
-		lastInterval := mergedSlotRanges[lastIntervalPos]
-		if CanMerge(lastInterval, interval) {
-			mergedSlotRanges[lastIntervalPos] = MergeSlotRanges(interval, lastInterval)
-		} else {
-			mergedSlotRanges = append(mergedSlotRanges, interval)
-		}
-	}
-
-	return mergedSlotRanges
+	return append(source[:len(source):len(source)], slot).Normalize()
 }
 
+// RemoveSlotFromSlotRanges returns the sorted slot ranges without the slot, the source won't be changed.
 func RemoveSlotFromSlotRanges(source SlotRanges, slot SlotRange) SlotRanges {
-	sort.Slice(source, func(i, j int) bool {
-		return source[i].Start < source[j].Start
+	sorted := make(SlotRanges, len(source))
+	copy(sorted, source)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
 	})
-	if !source.HasOverlap(slot) {
-		return source
+	if !sorted.HasOverlap(slot) {
+		return sorted
 	}
+	source = sorted
 
 	result := make([]SlotRange, 0, len(source))
 	for _, slotRange := range source {
@@ -325,3 +355,33 @@ func CalculateSlotRanges(n int) SlotRanges {
 	}
 	return slots
 }
+
+// CalculateWeightedSlotRanges distributes the slots to the shards in proportion to the weights
+func CalculateWeightedSlotRanges(weights []int) (SlotRanges, error) {
+	if len(weights) == 0 {
+		return nil, errors.New("weights should NOT be empty")
+	}
+	total := 0
+	for _, weight := range weights {
+		if weight <= 0 {
+			return nil, errors.New("weight should be greater than 0")
+		}
+		total += weight
+	}
+
+	slots := make([]SlotRange, 0, len(weights))
+	start, accumulated := 0, 0
+	for i, weight := range weights {
+		accumulated += weight
+		stop := (MaxSlotID+1)*accumulated/total - 1
+		if i == len(weights)-1 {
+			stop = MaxSlotID
+		}
+		if stop < start {
+			return nil, fmt.Errorf("the weight of shard %d is too small to own any slot", i)
+		}
+		slots = append(slots, SlotRange{Start: start, Stop: stop})
+		start = stop + 1
+	}
+	return slots, nil
+}
@@ -50,14 +50,7 @@ func TestMigratingSlot_UnmarshalJSON(t *testing.T) {
 	slotBytes, err := json.Marshal(NotMigratingInt)
 	require.NoError(t, err)
 	err = json.Unmarshal(slotBytes, &migratingSlot)
-	require.NoError(t, err, "expects no error since -1 was a valid 'not migrating' value")
-	assert.Equal(t, MigratingSlot{SlotRange{Start: 0, Stop: 0}, false}, migratingSlot)
-
-	migratingSlot = MigratingSlot{SlotRange: SlotRange{Start: 5, Stop: 5}, IsMigrating: true} // to set values to migratingSlot
-	slotBytes, err = json.Marshal(-5)
-	require.NoError(t, err)
-	err = json.Unmarshal(slotBytes, &migratingSlot)
-	require.ErrorIs(t, err, ErrSlotOutOfRange, "-5 is not a valid 'not migrating' value")
+	require.Error(t, err, "the integer slot is upgraded by the cluster schema migration")
 	assert.Equal(t, MigratingSlot{SlotRange{Start: 0, Stop: 0}, false}, migratingSlot)
 
 	slotBytes, err = json.Marshal("456")
@@ -290,6 +283,17 @@ func TestCalculateSlotRanges(t *testing.T) {
 	assert.Equal(t, 16383, slots[4].Stop)
 }
 
+func TestCalculateWeightedSlotRanges(t *testing.T) {
+	slots, err := CalculateWeightedSlotRanges([]int{2, 1, 1})
+	require.NoError(t, err)
+	require.Equal(t, SlotRanges{{Start: 0, Stop: 8191}, {Start: 8192, Stop: 12287}, {Start: 12288, Stop: 16383}}, slots)
+
+	_, err = CalculateWeightedSlotRanges([]int{1, 0})
+	require.Error(t, err)
+	_, err = CalculateWeightedSlotRanges([]int{1, 100000, 1})
+	require.Error(t, err)
+}
+
 func TestSlotRange_HasOverlap(t *testing.T) {
 	type args struct {
 		that SlotRange
@@ -440,3 +444,54 @@ func TestCanMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestSlotRanges_Normalize(t *testing.T) {
+	require.Equal(t, SlotRanges{}, SlotRanges(nil).Normalize())
+
+	slotRanges := SlotRanges{{Start: 200, Stop: 300}, {Start: 0, Stop: 10}, {Start: 11, Stop: 20}, {Start: 250, Stop: 400}, {Start: 5, Stop: 8}}
+	require.Equal(t, SlotRanges{{Start: 0, Stop: 20}, {Start: 200, Stop: 400}}, slotRanges.Normalize())
+	// the slot ranges itself should NOT be changed
+	require.Equal(t, SlotRange{Start: 200, Stop: 300}, slotRanges[0])
+}
+
+func TestSlotRanges_Count(t *testing.T) {
+	require.Equal(t, 0, SlotRanges{}.Count())
+	require.Equal(t, MaxSlotID+1, SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}}.Count())
+	// the overlapped slots are counted once
+	require.Equal(t, 15, SlotRanges{{Start: 0, Stop: 9}, {Start: 5, Stop: 14}}.Count())
+	require.Equal(t, 2, SlotRanges{{Start: 1, Stop: 1}, {Start: 3, Stop: 3}}.Count())
+}
+
+func TestSlotRanges_Intersect(t *testing.T) {
+	a := SlotRanges{{Start: 0, Stop: 100}, {Start: 200, Stop: 300}}
+	require.Empty(t, a.Intersect(SlotRanges{}))
+	require.Empty(t, a.Intersect(SlotRanges{{Start: 101, Stop: 199}}))
+	require.Equal(t, SlotRanges{{Start: 50, Stop: 100}, {Start: 200, Stop: 250}},
+		a.Intersect(SlotRanges{{Start: 50, Stop: 250}}))
+	require.Equal(t, SlotRanges{{Start: 100, Stop: 100}, {Start: 210, Stop: 220}, {Start: 300, Stop: 300}},
+		a.Intersect(SlotRanges{{Start: 300, Stop: 400}, {Start: 100, Stop: 100}, {Start: 210, Stop: 220}}))
+	require.Equal(t, a, a.Intersect(SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}}))
+}
+
+func TestSlotRanges_Difference(t *testing.T) {
+	a := SlotRanges{{Start: 0, Stop: 100}, {Start: 200, Stop: 300}}
+	require.Equal(t, a, a.Difference(SlotRanges{}))
+	require.Empty(t, a.Difference(SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}}))
+	require.Equal(t, SlotRanges{{Start: 0, Stop: 49}, {Start: 251, Stop: 300}},
+		a.Difference(SlotRanges{{Start: 50, Stop: 250}}))
+	require.Equal(t, SlotRanges{{Start: 0, Stop: 9}, {Start: 21, Stop: 100}, {Start: 200, Stop: 300}},
+		a.Difference(SlotRanges{{Start: 10, Stop: 20}, {Start: 101, Stop: 199}}))
+	require.Equal(t, SlotRanges{{Start: 101, Stop: MaxSlotID}},
+		SlotRanges{{Start: MinSlotID, Stop: MaxSlotID}}.Difference(SlotRanges{{Start: 0, Stop: 50}, {Start: 51, Stop: 100}}))
+}
+
+func TestSlotRanges_AddRemoveNotChangeSource(t *testing.T) {
+	source := make(SlotRanges, 0, 4)
+	source = append(source, SlotRange{Start: 200, Stop: 300}, SlotRange{Start: 0, Stop: 100})
+	added := AddSlotToSlotRanges(source, SlotRange{Start: 101, Stop: 101})
+	require.Equal(t, SlotRanges{{Start: 0, Stop: 101}, {Start: 200, Stop: 300}}, added)
+	removed := RemoveSlotFromSlotRanges(source, SlotRange{Start: 50, Stop: 50})
+	require.Equal(t, SlotRanges{{Start: 0, Stop: 49}, {Start: 51, Stop: 100}, {Start: 200, Stop: 300}}, removed)
+	require.Equal(t, SlotRanges{{Start: 200, Stop: 300}, {Start: 0, Stop: 100}}, source)
+	require.Equal(t, SlotRange{}, source[:3][2])
+}
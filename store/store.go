@@ -22,13 +22,17 @@ package store
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/apache/kvrocks-controller/logger"
 	"go.uber.org/zap"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/store/engine"
 )
 
@@ -39,15 +43,29 @@ type Store interface {
 	CreateNamespace(ctx context.Context, ns string) error
 	ExistsNamespace(ctx context.Context, ns string) (bool, error)
 	RemoveNamespace(ctx context.Context, ns string) error
+	GetNamespace(ctx context.Context, ns string) (*Namespace, error)
+	UpdateNamespace(ctx context.Context, namespace *Namespace) error
+	RenameNamespace(ctx context.Context, ns, newNs string) error
+	MoveShard(ctx context.Context, ns, source string, shardIdx int, target string) (int, error)
 
 	ListCluster(ctx context.Context, ns string) ([]string, error)
 	GetCluster(ctx context.Context, ns, cluster string) (*Cluster, error)
+	GetClusters(ctx context.Context, ns string) ([]*Cluster, error)
 	RemoveCluster(ctx context.Context, ns, cluster string) error
 	CreateCluster(ctx context.Context, ns string, cluster *Cluster) error
 	UpdateCluster(ctx context.Context, ns string, cluster *Cluster) error
 	SetCluster(ctx context.Context, ns string, clusterInfo *Cluster) error
 
 	CheckNewNodes(ctx context.Context, nodes []string) error
+	SearchNode(ctx context.Context, addr string) ([]NodeLocation, error)
+	ListNodes(ctx context.Context) ([]NodeLocation, error)
+
+	GetJob(ctx context.Context, ns, cluster, jobType, id string) (*Job, error)
+	ListJobs(ctx context.Context, ns, cluster, jobType string) ([]*Job, error)
+	SaveJob(ctx context.Context, ns, cluster string, job *Job) error
+	UpdateJob(ctx context.Context, ns, cluster, jobType, id string, update func(job *Job) bool) (*Job, error)
+	GetClusterRevision(ctx context.Context, ns, cluster string, version int64) (*ClusterRevision, error)
+	RunFailoverHook(ctx context.Context, ns string, cluster *Cluster, shardIdx int, oldMasterID, newMasterID, trigger string)
 }
 
 var _ Store = (*ClusterStore)(nil)
@@ -55,17 +73,36 @@ var _ Store = (*ClusterStore)(nil)
 type ClusterStore struct {
 	e engine.Engine
 
-	locks         sync.Map
+	locks    ClusterLocks
+	jobLocks ClusterLocks
+	// nodeLocks guard the node index entries keyed by the address, see lockNodeIndex
+	nodeLocks     ClusterLocks
+	labels        labelCache
+	events        *EventLog
 	eventNotifyCh chan EventPayload
+	// eventLogCh queues the events to be appended by appendEventLoop
+	eventLogCh chan EventPayload
+	// droppedEvents is the number of the events which failed to be appended, a gap marker
+	// is appended before the next event to let the consumers resync.
+	droppedEvents atomic.Int64
 	quitCh        chan struct{}
+	quitOnce      sync.Once
+	// failoverHookRunners are the runners of the failover hooks keyed by the shard
+	failoverHookRunners sync.Map
+	// nodeIndexRebuilt caches the persisted marker of the node index rebuild once it was seen
+	nodeIndexRebuilt atomic.Bool
 }
 
 func NewClusterStore(e engine.Engine) *ClusterStore {
-	return &ClusterStore{
+	s := &ClusterStore{
 		e:             e,
+		events:        NewEventLog(e),
 		eventNotifyCh: make(chan EventPayload, 100),
+		eventLogCh:    make(chan EventPayload, eventLogQueueSize),
 		quitCh:        make(chan struct{}),
 	}
+	go s.appendEventLoop()
+	return s
 }
 
 func (s *ClusterStore) IsReady(ctx context.Context) bool {
@@ -95,7 +132,7 @@ func (s *ClusterStore) CreateNamespace(ctx context.Context, ns string) error {
 	if has, _ := s.ExistsNamespace(ctx, ns); has {
 		return consts.ErrAlreadyExists
 	}
-	if err := s.e.Set(ctx, appendPrefix(ns), []byte(ns)); err != nil {
+	if err := s.setNamespace(ctx, &Namespace{Name: ns}); err != nil {
 		return err
 	}
 	s.EmitEvent(EventPayload{
@@ -108,8 +145,16 @@ func (s *ClusterStore) CreateNamespace(ctx context.Context, ns string) error {
 
 // RemoveNamespace delete the specified namespace from store
 func (s *ClusterStore) RemoveNamespace(ctx context.Context, ns string) error {
-	if has, _ := s.ExistsNamespace(ctx, ns); !has {
-		return consts.ErrNotFound
+	// the clusters can't be created in the namespace after checking it has no cluster
+	unlock := s.locks.Lock(ns, "")
+	defer unlock()
+
+	has, err := s.ExistsNamespace(ctx, ns)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return &consts.NotFoundError{Resource: "namespace", Key: ns}
 	}
 	clusters, err := s.ListCluster(ctx, ns)
 	if err != nil {
@@ -126,13 +171,13 @@ func (s *ClusterStore) RemoveNamespace(ctx context.Context, ns string) error {
 		Type:      EventNamespace,
 		Command:   CommandRemove,
 	})
+	s.uncacheLabels(ns, "")
 	return nil
 }
 
-func (s *ClusterStore) getLock(ns, cluster string) *sync.RWMutex {
-	value, _ := s.locks.LoadOrStore(fmt.Sprintf("%s/%s", ns, cluster), &sync.RWMutex{})
-	lock, _ := value.(*sync.RWMutex)
-	return lock
+// CountLocks returns the number of the cluster locks in use, it's only used to export the map size
+func (s *ClusterStore) CountLocks() int {
+	return s.locks.Len()
 }
 
 // ListCluster return the list of name of cluster under the specified namespace
@@ -153,48 +198,97 @@ func (s *ClusterStore) existsCluster(ctx context.Context, ns, cluster string) (b
 }
 
 func (s *ClusterStore) GetCluster(ctx context.Context, ns, cluster string) (*Cluster, error) {
-	lock := s.getLock(ns, cluster)
-	lock.RLock()
-	defer lock.RUnlock()
+	unlock := s.locks.RLock(ns, cluster)
+	defer unlock()
 
 	return s.getClusterWithoutLock(ctx, ns, cluster)
 }
 
+// GetClusters returns all clusters under the namespace sorted by the name, the clusters
+// are fetched by listing the prefix in one round trip instead of getting them one by one.
+func (s *ClusterStore) GetClusters(ctx context.Context, ns string) ([]*Cluster, error) {
+	entries, err := s.e.List(ctx, buildClusterPrefix(ns))
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]*Cluster, 0, len(entries))
+	for _, entry := range entries {
+		var clusterInfo Cluster
+		if err := decodeCluster(entry.Value, &clusterInfo); err != nil {
+			return nil, fmt.Errorf("cluster %s/%s: %w", ns, entry.Key, err)
+		}
+		clusters = append(clusters, &clusterInfo)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
+
 func (s *ClusterStore) getClusterWithoutLock(ctx context.Context, ns, cluster string) (*Cluster, error) {
 	value, err := s.e.Get(ctx, buildClusterKey(ns, cluster))
-	if err != nil {
+	if errors.Is(err, consts.ErrNotFound) {
+		return nil, &consts.NotFoundError{Resource: "cluster", Key: ns + "/" + cluster}
+	} else if err != nil {
 		return nil, fmt.Errorf("cluster: %w", err)
 	}
 	var clusterInfo Cluster
-	if err = json.Unmarshal(value, &clusterInfo); err != nil {
+	if err = decodeCluster(value, &clusterInfo); err != nil {
 		return nil, fmt.Errorf("cluster: %w", err)
 	}
+	s.cacheClusterLabels(ctx, ns, cluster, clusterInfo.Labels)
 	return &clusterInfo, nil
 }
 
 // UpdateCluster update the Name to store under the specified namespace
 func (s *ClusterStore) UpdateCluster(ctx context.Context, ns string, clusterInfo *Cluster) error {
-	lock := s.getLock(ns, clusterInfo.Name)
-	lock.Lock()
-	defer lock.Unlock()
+	unlock := s.locks.Lock(ns, clusterInfo.Name)
+	defer unlock()
+
+	return s.updateClusterWithoutLock(ctx, ns, clusterInfo)
+}
 
+func (s *ClusterStore) updateClusterWithoutLock(ctx context.Context, ns string, clusterInfo *Cluster) error {
 	oldCluster, err := s.getClusterWithoutLock(ctx, ns, clusterInfo.Name)
 	if err != nil {
 		return err
 	}
-	if oldCluster.Version.Load() > clusterInfo.Version.Load() {
-		return fmt.Errorf("the cluster has been updated by others")
+	clusterBytes, err := prepareClusterUpdate(oldCluster, clusterInfo)
+	if err != nil {
+		return err
+	}
+	if err := s.writeCluster(ctx, ns, oldCluster, clusterInfo, clusterBytes); err != nil {
+		return err
 	}
+	s.finishClusterUpdate(ctx, ns, oldCluster, clusterInfo, clusterBytes)
+	return nil
+}
 
+// prepareClusterUpdate bumps the version of the cluster and encodes it, it's rejected
+// if the cluster is older than the stored one.
+func prepareClusterUpdate(oldCluster, clusterInfo *Cluster) ([]byte, error) {
+	if oldCluster.Version.Load() > clusterInfo.Version.Load() {
+		return nil, &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: oldCluster.Version.Load()}
+	}
 	clusterInfo.Version.Add(1)
-	clusterBytes, err := json.Marshal(clusterInfo)
+	clusterBytes, err := encodeCluster(clusterInfo)
 	if err != nil {
-		return fmt.Errorf("cluster: %w", err)
+		return nil, fmt.Errorf("cluster: %w", err)
 	}
-	if err := s.e.Set(ctx, buildClusterKey(ns, clusterInfo.Name), clusterBytes); err != nil {
-		return err
+	return clusterBytes, nil
+}
+
+// finishClusterUpdate caches the labels, records the revision and emits the event of the written cluster
+func (s *ClusterStore) finishClusterUpdate(ctx context.Context, ns string, oldCluster, clusterInfo *Cluster, clusterBytes []byte) {
+	s.cacheClusterLabels(ctx, ns, clusterInfo.Name, clusterInfo.Labels)
+	clusterInfoField := zap.Int("cluster_info_bytes", len(clusterBytes))
+	if isJSONClusterDocument(clusterBytes) {
+		clusterInfoField = zap.ByteString("cluster_info", clusterBytes)
 	}
-	logger.Get().With(zap.String("cluster_info", string(clusterBytes))).Info("Updated the cluster version")
+	logger.Get().With(clusterInfoField).Info("Updated the cluster version")
+	s.tryRecordClusterRevision(ctx, ns, oldCluster, clusterInfo)
+	s.cancelRemovedReplicaSyncs(ctx, ns, oldCluster, clusterInfo)
+	forgetSyncedTopologies(oldCluster, clusterInfo)
 
 	s.EmitEvent(EventPayload{
 		Namespace: ns,
@@ -202,45 +296,54 @@ func (s *ClusterStore) UpdateCluster(ctx context.Context, ns string, clusterInfo
 		Type:      EventCluster,
 		Command:   CommandUpdate,
 	})
-	return nil
 }
 
 // SetCluster set the cluster to store under the specified namespace but won't increase the version.
 func (s *ClusterStore) SetCluster(ctx context.Context, ns string, clusterInfo *Cluster) error {
-	lock := s.getLock(ns, clusterInfo.Name)
-	lock.Lock()
-	defer lock.Unlock()
+	unlock := s.locks.Lock(ns, clusterInfo.Name)
+	defer unlock()
 
 	oldCluster, err := s.getClusterWithoutLock(ctx, ns, clusterInfo.Name)
 	if err != nil {
 		return err
 	}
 	if oldCluster.Version.Load() > clusterInfo.Version.Load() {
-		return fmt.Errorf("the cluster has been updated by others")
+		return &consts.VersionMismatchError{Resource: "cluster", CurrentVersion: oldCluster.Version.Load()}
+	}
+	if err := s.checkVersionRegression(ctx, ns, clusterInfo); err != nil {
+		return err
 	}
 
-	value, err := json.Marshal(clusterInfo)
+	value, err := encodeCluster(clusterInfo)
 	if err != nil {
 		return fmt.Errorf("cluster: %w", err)
 	}
-	return s.e.Set(ctx, buildClusterKey(ns, clusterInfo.Name), value)
+	if err := s.writeCluster(ctx, ns, oldCluster, clusterInfo, value); err != nil {
+		return err
+	}
+	forgetSyncedTopologies(oldCluster, clusterInfo)
+	s.cacheClusterLabels(ctx, ns, clusterInfo.Name, clusterInfo.Labels)
+	return nil
 }
 
 func (s *ClusterStore) CreateCluster(ctx context.Context, ns string, clusterInfo *Cluster) error {
-	lock := s.getLock(ns, clusterInfo.Name)
-	lock.Lock()
-	defer lock.Unlock()
+	// the namespace can't be renamed while creating the cluster in it
+	unlockNamespace := s.locks.RLock(ns, "")
+	defer unlockNamespace()
+	unlock := s.locks.Lock(ns, clusterInfo.Name)
+	defer unlock()
 
 	if exists, _ := s.existsCluster(ctx, ns, clusterInfo.Name); exists {
 		return fmt.Errorf("cluster: %w", consts.ErrAlreadyExists)
 	}
-	clusterBytes, err := json.Marshal(clusterInfo)
+	clusterBytes, err := encodeCluster(clusterInfo)
 	if err != nil {
 		return fmt.Errorf("cluster: %w", err)
 	}
-	if err := s.e.Set(ctx, buildClusterKey(ns, clusterInfo.Name), clusterBytes); err != nil {
+	if err := s.writeCluster(ctx, ns, nil, clusterInfo, clusterBytes); err != nil {
 		return err
 	}
+	s.cacheClusterLabels(ctx, ns, clusterInfo.Name, clusterInfo.Labels)
 	s.EmitEvent(EventPayload{
 		Namespace: ns,
 		Cluster:   clusterInfo.Name,
@@ -251,16 +354,21 @@ func (s *ClusterStore) CreateCluster(ctx context.Context, ns string, clusterInfo
 }
 
 func (s *ClusterStore) RemoveCluster(ctx context.Context, ns, cluster string) error {
-	lock := s.getLock(ns, cluster)
-	lock.Lock()
-	defer lock.Unlock()
+	unlock := s.locks.Lock(ns, cluster)
+	defer unlock()
 
-	if exists, _ := s.existsCluster(ctx, ns, cluster); !exists {
-		return consts.ErrNotFound
+	oldCluster, err := s.getClusterWithoutLock(ctx, ns, cluster)
+	if err != nil {
+		return err
 	}
-	if err := s.e.Delete(ctx, buildClusterKey(ns, cluster)); err != nil {
+	// the jobs of the cluster are removed with it
+	unlockJobs := s.jobLocks.Lock(ns, cluster)
+	err = s.writeCluster(ctx, ns, oldCluster, nil, nil)
+	unlockJobs()
+	if err != nil {
 		return err
 	}
+	forgetSyncedTopologies(oldCluster, nil)
 
 	s.EmitEvent(EventPayload{
 		Namespace: ns,
@@ -268,35 +376,77 @@ func (s *ClusterStore) RemoveCluster(ctx context.Context, ns, cluster string) er
 		Type:      EventCluster,
 		Command:   CommandRemove,
 	})
+	s.uncacheLabels(ns, cluster)
 	return nil
 }
 
-func (s *ClusterStore) CheckNewNodes(ctx context.Context, nodes []string) error {
-	newNodes := make(map[string]bool, 0)
-	for _, node := range nodes {
-		newNodes[node] = true
-	}
+// NodeLocation is where the node is in the clusters
+type NodeLocation struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	Shard     int    `json:"shard"`
+	NodeID    string `json:"node_id"`
+	Addr      string `json:"addr"`
+	Role      string `json:"role"`
+}
 
+// forEachCluster iterates all clusters in all namespaces until the fn returns an error
+func (s *ClusterStore) forEachCluster(ctx context.Context, fn func(ns string, cluster *Cluster) error) error {
 	namespaces, err := s.ListNamespace(ctx)
 	if err != nil {
 		return err
 	}
-	existingNodes := make([]string, 0)
 	for _, ns := range namespaces {
-		clusters, err := s.ListCluster(ctx, ns)
+		clusters, err := s.GetClusters(ctx, ns)
 		if err != nil {
 			return err
 		}
-		for _, cluster := range clusters {
-			c, err := s.GetCluster(ctx, ns, cluster)
+		for _, c := range clusters {
+			if err := fn(ns, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CheckNewNodes returns the already exists error if any node was in the clusters. The node is looked
+// up in the node index, the hit is confirmed by its cluster since the entry might be stale, and the
+// clusters are scanned if the entry was stale or the index isn't written transactionally. The miss is
+// trusted only after the index was rebuilt, since the clusters written before the index existed have no entries.
+func (s *ClusterStore) CheckNewNodes(ctx context.Context, nodes []string) error {
+	_, transactional := s.e.(engine.Transactional)
+	if transactional {
+		rebuilt, err := s.isNodeIndexRebuilt(ctx)
+		if err != nil {
+			return err
+		}
+		transactional = rebuilt
+	}
+	existingNodes := make([]string, 0)
+	for _, node := range nodes {
+		location, err := s.getNodeLocation(ctx, node)
+		if err != nil && !errors.Is(err, consts.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			exists, err := s.clusterHasNode(ctx, location, node)
 			if err != nil {
 				return err
 			}
-			for _, existingNode := range c.GetNodes() {
-				if _, ok := newNodes[existingNode.Addr()]; ok {
-					existingNodes = append(existingNodes, existingNode.Addr())
-				}
+			if exists {
+				existingNodes = append(existingNodes, node)
+				continue
 			}
+		} else if transactional {
+			continue
+		}
+		exists, err := s.scanNode(ctx, node)
+		if err != nil {
+			return err
+		}
+		if exists {
+			existingNodes = append(existingNodes, node)
 		}
 	}
 	if len(existingNodes) > 0 {
@@ -305,14 +455,118 @@ func (s *ClusterStore) CheckNewNodes(ctx context.Context, nodes []string) error
 	return nil
 }
 
+func matchHostname(node Node, addr string) bool {
+	clusterNode, ok := node.(*ClusterNode)
+	return ok && clusterNode.Hostname() != "" && clusterNode.Hostname() == addr
+}
+
+// SearchNode returns the locations of the node address or hostname in all clusters,
+// the address may appear in multiple clusters by mistake.
+func (s *ClusterStore) SearchNode(ctx context.Context, addr string) ([]NodeLocation, error) {
+	locations := make([]NodeLocation, 0)
+	err := s.forEachCluster(ctx, func(ns string, c *Cluster) error {
+		for i, shard := range c.Shards {
+			for _, node := range shard.Nodes {
+				if node.Addr() != addr && !matchHostname(node, addr) {
+					continue
+				}
+				role := RoleSlave
+				if node.IsMaster() {
+					role = RoleMaster
+				}
+				locations = append(locations, NodeLocation{
+					Namespace: ns,
+					Cluster:   c.Name,
+					Shard:     i,
+					NodeID:    node.ID(),
+					Addr:      node.Addr(),
+					Role:      role,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
 func (s *ClusterStore) Notify() <-chan EventPayload {
 	return s.eventNotifyCh
 }
 
 func (s *ClusterStore) EmitEvent(event EventPayload) {
+	if event.Labels == nil {
+		event.Labels = s.StaticLabels(event.Namespace, event.Cluster)
+	}
+	// the event is appended off the caller's path since the caller might hold the cluster lock,
+	// and the consumers can replay it with the last event ID after it was persisted.
+	timer := time.NewTimer(eventLogEnqueueTimeout)
+	select {
+	case s.eventLogCh <- event:
+	case <-timer.C:
+		s.droppedEvents.Add(1)
+		logger.Get().With(zap.Any("event", event)).Error("Failed to append the event log since the queue is full")
+	}
+	timer.Stop()
+	metrics.Get().StoreEvents.WithLabelValues(event.Type.String(), event.Command.String()).Inc()
 	s.eventNotifyCh <- event
 }
 
+// appendEventLoop appends the emitted events to the event log in order. The failed append
+// isn't retried since it might have been applied, the gap marker is appended instead.
+func (s *ClusterStore) appendEventLoop() {
+	ticker := time.NewTicker(eventLogRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-s.eventLogCh:
+			if !s.appendEventGap() {
+				s.droppedEvents.Add(1)
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), eventLogTimeout)
+			if _, err := s.events.Append(ctx, event); err != nil {
+				s.droppedEvents.Add(1)
+				logger.Get().With(zap.Any("event", event), zap.Error(err)).Error("Failed to append the event log")
+			}
+			cancel()
+		case <-ticker.C:
+			// the gap marker should be appended even if no more events are emitted
+			s.appendEventGap()
+		case <-s.quitCh:
+			return
+		}
+	}
+}
+
+// appendEventGap appends the gap marker if some events were dropped, and returns false
+// if the marker failed to be appended.
+func (s *ClusterStore) appendEventGap() bool {
+	dropped := s.droppedEvents.Load()
+	if dropped == 0 {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventLogTimeout)
+	defer cancel()
+	if _, err := s.events.Append(ctx, EventPayload{
+		Type:    EventEngine,
+		Command: CommandGap,
+		Message: fmt.Sprintf("%d events were dropped from the event log", dropped),
+	}); err != nil {
+		logger.Get().With(zap.Int64("dropped", dropped), zap.Error(err)).Error("Failed to append the event gap")
+		return false
+	}
+	s.droppedEvents.Add(-dropped)
+	return true
+}
+
+// Events returns the persisted event log
+func (s *ClusterStore) Events() *EventLog {
+	return s.events
+}
+
 func (s *ClusterStore) GetEngine() engine.Engine {
 	return s.e
 }
@@ -334,6 +588,7 @@ func (s *ClusterStore) ID() string {
 }
 
 func (s *ClusterStore) Close() error {
+	s.quitOnce.Do(func() { close(s.quitCh) })
 	return s.e.Close()
 }
 
@@ -22,10 +22,13 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/apache/kvrocks-controller/consts"
+	"github.com/apache/kvrocks-controller/metrics"
 	"github.com/apache/kvrocks-controller/store/engine"
 )
 
@@ -81,15 +84,34 @@ func TestClusterStore(t *testing.T) {
 		require.NoError(t, err)
 		require.ElementsMatch(t, []string{"cluster0", "cluster1"}, gotClusters)
 
+		clusters, err := store.GetClusters(ctx, ns)
+		require.NoError(t, err)
+		require.Len(t, clusters, 2)
+		require.Equal(t, "cluster0", clusters[0].Name)
+		require.EqualValues(t, 2, clusters[0].Version.Load())
+		require.Equal(t, "cluster1", clusters[1].Name)
+		require.EqualValues(t, 3, clusters[1].Version.Load())
+
 		require.NoError(t, store.UpdateCluster(ctx, ns, cluster0))
 		gotCluster, err = store.GetCluster(ctx, ns, "cluster0")
 		require.NoError(t, err)
 		require.Equal(t, cluster0.Name, gotCluster.Name)
 		require.EqualValues(t, 3, gotCluster.Version.Load())
 
+		staleCluster := &Cluster{Name: "cluster0", Shards: Shards{NewShard()}}
+		staleCluster.Version.Store(2)
+		var mismatchErr *consts.VersionMismatchError
+		require.ErrorAs(t, store.UpdateCluster(ctx, ns, staleCluster), &mismatchErr)
+		require.EqualValues(t, 3, mismatchErr.CurrentVersion)
+		require.ErrorIs(t, mismatchErr, consts.ErrVersionMismatch)
+
 		for _, name := range []string{"cluster0", "cluster1"} {
 			require.NoError(t, store.RemoveCluster(ctx, ns, name))
 			_, err = store.GetCluster(ctx, ns, name)
+			var notFoundErr *consts.NotFoundError
+			require.ErrorAs(t, err, &notFoundErr)
+			require.Equal(t, "cluster", notFoundErr.Resource)
+			require.Equal(t, ns+"/"+name, notFoundErr.Key)
 			require.ErrorIs(t, err, consts.ErrNotFound)
 		}
 	})
@@ -104,4 +126,65 @@ func TestClusterStore(t *testing.T) {
 		require.NotNil(t, store.CheckNewNodes(ctx, []string{"127.0.0.1:3333", "127.0.0.1:4444"}))
 		require.NotNil(t, store.CheckNewNodes(ctx, []string{"127.0.0.1:2222", "127.0.0.1:3333"}))
 	})
+
+	t.Run("search node", func(t *testing.T) {
+		locations, err := store.SearchNode(ctx, "127.0.0.1:2222")
+		require.NoError(t, err)
+		require.Len(t, locations, 1)
+		require.Equal(t, []NodeLocation{{
+			Namespace: "test-ns",
+			Cluster:   "test-cluster-another",
+			Shard:     1,
+			NodeID:    locations[0].NodeID,
+			Addr:      "127.0.0.1:2222",
+			Role:      RoleMaster,
+		}}, locations)
+
+		locations, err = store.SearchNode(ctx, "127.0.0.1:4444")
+		require.NoError(t, err)
+		require.Empty(t, locations)
+	})
+}
+
+func TestClusterStoreEventMetrics(t *testing.T) {
+	ctx := context.Background()
+	store := NewClusterStore(engine.NewMock())
+	counter := func(eventType EventType, command Command) float64 {
+		return testutil.ToFloat64(metrics.Get().StoreEvents.WithLabelValues(eventType.String(), command.String()))
+	}
+	createdNamespaces := counter(EventNamespace, CommandCreate)
+	createdClusters := counter(EventCluster, CommandCreate)
+	removedClusters := counter(EventCluster, CommandRemove)
+
+	require.NoError(t, store.CreateNamespace(ctx, "ns0"))
+	require.NoError(t, store.CreateCluster(ctx, "ns0", &Cluster{Name: "cluster0", Shards: Shards{NewShard()}}))
+	require.NoError(t, store.CreateCluster(ctx, "ns0", &Cluster{Name: "cluster1", Shards: Shards{NewShard()}}))
+	require.NoError(t, store.RemoveCluster(ctx, "ns0", "cluster1"))
+
+	require.EqualValues(t, createdNamespaces+1, counter(EventNamespace, CommandCreate))
+	require.EqualValues(t, createdClusters+2, counter(EventCluster, CommandCreate))
+	require.EqualValues(t, removedClusters+1, counter(EventCluster, CommandRemove))
+}
+
+func TestClusterStoreEventGap(t *testing.T) {
+	ctx := context.Background()
+	mock := engine.NewMock()
+	store := NewClusterStore(mock)
+
+	mock.WithFailureRate(engine.MockOpSet, 1, engine.ErrInjected)
+	store.EmitEvent(EventPayload{Namespace: "ns0", Type: EventNamespace, Command: CommandCreate})
+	require.Eventually(t, func() bool {
+		return store.droppedEvents.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// the gap marker is appended once the engine recovered, even if no more events are emitted
+	mock.ResetFaults()
+	require.Eventually(t, func() bool {
+		events, err := store.Events().Since(ctx, 0)
+		return err == nil && len(events) == 1
+	}, 3*eventLogRetryInterval, 10*time.Millisecond)
+	events, err := store.Events().Since(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, Command(CommandGap).String(), events[0].Command)
+	require.Zero(t, store.droppedEvents.Load())
 }
@@ -0,0 +1,203 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+// Package kvrockstest provides the kvrocks nodes for tests, the nodes are started
+// in docker containers on the free ports, so the tests can run anywhere without
+// depending on the hardcoded addresses.
+package kvrockstest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// EnvAddrs is the comma separated addresses of the pre-started kvrocks nodes,
+	// the docker containers won't be started if it's set.
+	EnvAddrs = "KVROCKS_TEST_ADDRS"
+	// EnvImage is the kvrocks docker image used to start the nodes.
+	EnvImage = "KVROCKS_TEST_IMAGE"
+
+	defaultImage   = "apache/kvrocks:nightly"
+	startupTimeout = 30 * time.Second
+)
+
+var (
+	dockerOnce      sync.Once
+	dockerAvailable bool
+)
+
+func isDockerAvailable() bool {
+	dockerOnce.Do(func() {
+		if _, err := exec.LookPath("docker"); err != nil {
+			return
+		}
+		dockerAvailable = exec.Command("docker", "info").Run() == nil
+	})
+	return dockerAvailable
+}
+
+// Start returns the addresses of n kvrocks nodes with the cluster mode enabled.
+// The nodes in KVROCKS_TEST_ADDRS are used if it's set, they're shared by the tests
+// so they're reset before being returned. Otherwise the nodes will be started in docker
+// containers and removed after the test. The test will be skipped if neither of them is available.
+func Start(t testing.TB, n int) []string {
+	t.Helper()
+
+	if addrs := os.Getenv(EnvAddrs); addrs != "" {
+		nodeAddrs := strings.Split(addrs, ",")
+		if len(nodeAddrs) < n {
+			t.Skipf("%s has %d nodes, but %d nodes are required", EnvAddrs, len(nodeAddrs), n)
+		}
+		if err := reset(context.Background(), nodeAddrs[:n]...); err != nil {
+			t.Fatalf("Failed to reset the kvrocks nodes: %v", err)
+		}
+		return nodeAddrs[:n]
+	}
+	if !isDockerAvailable() {
+		t.Skipf("docker is not available and %s is not set", EnvAddrs)
+	}
+
+	image := os.Getenv(EnvImage)
+	if image == "" {
+		image = defaultImage
+	}
+	addrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		addrs = append(addrs, startContainer(t, image))
+	}
+	return addrs
+}
+
+func startContainer(t testing.TB, image string) string {
+	t.Helper()
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("Failed to allocate the port: %v", err)
+	}
+	portStr := strconv.Itoa(port)
+	output, err := exec.Command("docker", "run", "-d", "--rm",
+		"-p", fmt.Sprintf("127.0.0.1:%d:%d", port, port),
+		"--entrypoint", "kvrocks",
+		image,
+		"--port", portStr,
+		"--bind", "0.0.0.0",
+		"--dir", "/tmp/kvrocks"+portStr,
+		"--cluster-enabled", "yes",
+	).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to start the kvrocks container: %v, output: %s", err, output)
+	}
+	containerID := strings.TrimSpace(string(output))
+	t.Cleanup(func() {
+		_ = exec.Command("docker", "rm", "-f", containerID).Run()
+	})
+
+	addr := net.JoinHostPort("127.0.0.1", portStr)
+	if err := waitForReady(addr, startupTimeout); err != nil {
+		t.Fatalf("Kvrocks node %s is not ready: %v", addr, err)
+	}
+	return addr
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	addr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0, fmt.Errorf("unexpected listener address: %s", listener.Addr())
+	}
+	return addr.Port, nil
+}
+
+func waitForReady(addr string, timeout time.Duration) error {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		err := client.Ping(ctx).Err()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		}
+	}
+}
+
+// reset flushes the data and resets the cluster topology of the nodes,
+// so the tests sharing the pre-started nodes don't see the state of the previous ones.
+// The replicas can't be flushed, they're reset after the flush of their masters was replicated.
+func reset(ctx context.Context, addrs ...string) error {
+	clients := make([]*redis.Client, 0, len(addrs))
+	defer func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}()
+	for _, addr := range addrs {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		clients = append(clients, client)
+		info, err := client.Info(ctx, "replication").Result()
+		if err == nil && !strings.Contains(info, "role:slave") {
+			err = client.FlushAll(ctx).Err()
+		}
+		if err != nil {
+			return fmt.Errorf("flush node %s: %w", addr, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, startupTimeout)
+	defer cancel()
+	for i, client := range clients {
+		for {
+			err := client.Do(ctx, "CLUSTER", "RESET").Err()
+			if err == nil {
+				break
+			}
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				return fmt.Errorf("reset node %s: %w", addrs[i], err)
+			}
+		}
+	}
+	return nil
+}
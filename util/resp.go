@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncodeRESP encodes the value into the Redis serialization protocol, only the string,
+// integer and array types are supported since they're enough for the cluster replies.
+func EncodeRESP(v interface{}) ([]byte, error) {
+	var builder strings.Builder
+	if err := encodeRESP(&builder, v); err != nil {
+		return nil, err
+	}
+	return []byte(builder.String()), nil
+}
+
+func encodeRESP(builder *strings.Builder, v interface{}) error {
+	switch value := v.(type) {
+	case string:
+		builder.WriteString("$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n")
+	case int:
+		builder.WriteString(":" + strconv.Itoa(value) + "\r\n")
+	case int64:
+		builder.WriteString(":" + strconv.FormatInt(value, 10) + "\r\n")
+	case []interface{}:
+		builder.WriteString("*" + strconv.Itoa(len(value)) + "\r\n")
+		for _, elem := range value {
+			if err := encodeRESP(builder, elem); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported RESP type: %T", v)
+	}
+	return nil
+}
@@ -19,6 +19,34 @@
 
 package util
 
+import "strings"
+
+// KeySlot returns the cluster slot of the key, only the hash tag is hashed if the key has one
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if stop := strings.IndexByte(key[start+1:], '}'); stop > 0 {
+			key = key[start+1 : start+1+stop]
+		}
+	}
+	return int(crc16(key) & 16383)
+}
+
+// crc16 is the CRC16/XMODEM checksum used by the redis cluster
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
 // SlotTable is a table of the shortest possible alphanumeric string that is mapped by
 // redis's crc16 to any given redis cluster slot.
 //
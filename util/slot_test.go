@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySlot(t *testing.T) {
+	// the well-known slot of the key in redis cluster
+	require.Equal(t, 12182, KeySlot("foo"))
+	require.Equal(t, 0, KeySlot(""))
+	for _, slot := range []int{0, 1, 100, 8000, 16383} {
+		require.Equal(t, slot, KeySlot(SlotTable[slot]))
+	}
+	// only the hash tag is hashed if it's not empty
+	require.Equal(t, KeySlot("foo"), KeySlot("{foo}.bar"))
+	require.NotEqual(t, KeySlot("foo"), KeySlot("{}foo"))
+}